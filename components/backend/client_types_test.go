@@ -0,0 +1,99 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"ambient-code-backend/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientTypesFile holds the client module's copies of the API types. The client is a separate
+// module, so its structs are compared by parsing the source rather than importing it.
+const clientTypesFile = "pkg/client/types.go"
+
+// jsonFieldNames returns the JSON names of a struct's fields, ignoring "-" fields
+func jsonFieldNames(tags []string) []string {
+	var names []string
+	for _, tag := range tags {
+		name := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func clientStructTags(t *testing.T) map[string][]string {
+	t.Helper()
+	file, err := parser.ParseFile(token.NewFileSet(), clientTypesFile, nil, 0)
+	require.NoError(t, err)
+
+	structs := map[string][]string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok {
+			return true
+		}
+		st, ok := spec.Type.(*ast.StructType)
+		if !ok {
+			return false
+		}
+		var tags []string
+		for _, field := range st.Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			require.NoError(t, err)
+			tags = append(tags, tag)
+		}
+		structs[spec.Name.Name] = jsonFieldNames(tags)
+		return false
+	})
+	return structs
+}
+
+// TestClientTypesMatchBackend fails when a field is added to (or removed from) a backend type
+// without updating its copy in the Go client module
+func TestClientTypesMatchBackend(t *testing.T) {
+	backendTypes := map[string]reflect.Type{
+		"AgenticSession":              reflect.TypeOf(types.AgenticSession{}),
+		"AgenticSessionSpec":          reflect.TypeOf(types.AgenticSessionSpec{}),
+		"AgenticSessionStatus":        reflect.TypeOf(types.AgenticSessionStatus{}),
+		"CreateAgenticSessionRequest": reflect.TypeOf(types.CreateAgenticSessionRequest{}),
+		"SimpleRepo":                  reflect.TypeOf(types.SimpleRepo{}),
+		"LLMSettings":                 reflect.TypeOf(types.LLMSettings{}),
+		"UserContext":                 reflect.TypeOf(types.UserContext{}),
+		"BotAccountRef":               reflect.TypeOf(types.BotAccountRef{}),
+		"ResourceOverrides":           reflect.TypeOf(types.ResourceOverrides{}),
+		"WorkflowSelection":           reflect.TypeOf(types.WorkflowSelection{}),
+		"ReconciledRepo":              reflect.TypeOf(types.ReconciledRepo{}),
+		"SecretFinding":               reflect.TypeOf(types.SecretFinding{}),
+		"ReconciledWorkflow":          reflect.TypeOf(types.ReconciledWorkflow{}),
+		"Condition":                   reflect.TypeOf(types.Condition{}),
+		"RunnerAuth":                  reflect.TypeOf(types.RunnerAuth{}),
+		"SessionArtifact":             reflect.TypeOf(types.SessionArtifact{}),
+		"PromptRef":                   reflect.TypeOf(types.PromptRef{}),
+	}
+	clientTypes := clientStructTags(t)
+
+	for name, typ := range backendTypes {
+		var tags []string
+		for i := 0; i < typ.NumField(); i++ {
+			tags = append(tags, string(typ.Field(i).Tag))
+		}
+		require.Contains(t, clientTypes, name, "client is missing type %s", name)
+		assert.Equal(t, jsonFieldNames(tags), clientTypes[name], "JSON fields of %s differ between types and %s", name, clientTypesFile)
+	}
+	assert.Equal(t, types.MaxPaginationLimit, 100, "update MaxPaginationLimit in %s", clientTypesFile)
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/httpclient"
+	"ambient-code-backend/types"
+)
+
+// NewRepo describes an empty repository to create on a git provider
+type NewRepo struct {
+	Owner       string // User or organization (GitLab: namespace path) that owns the repository
+	Name        string
+	Description string
+	Visibility  string // "private", "internal" or "public"
+}
+
+// CreateGitHubRepo creates an empty GitHub repository and returns its clone URL. A repository
+// owned by the token's user is created under the user, any other owner is treated as an
+// organization. A repository of the same name yields a *types.RepoExistsError.
+func CreateGitHubRepo(ctx context.Context, githubAPIBase, token string, repo NewRepo) (string, error) {
+	apiBase := strings.TrimRight(githubAPIBase, "/")
+	var user struct {
+		Login string `json:"login"`
+	}
+	if status, err := githubJSONRequest(ctx, http.MethodGet, apiBase+"/user", token, nil, &user); err != nil {
+		return "", fmt.Errorf("failed to read GitHub user: %w", err)
+	} else if status != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned status %d reading the token's user", status)
+	}
+
+	endpoint := fmt.Sprintf("%s/orgs/%s/repos", apiBase, repo.Owner)
+	if strings.EqualFold(user.Login, repo.Owner) {
+		endpoint = apiBase + "/user/repos"
+	}
+	payload := map[string]interface{}{
+		"name":        repo.Name,
+		"description": repo.Description,
+		"private":     repo.Visibility != "public",
+	}
+	if repo.Visibility == "internal" {
+		payload["visibility"] = "internal"
+	}
+	var created struct {
+		CloneURL string `json:"clone_url"`
+		Message  string `json:"message"`
+		Errors   []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	status, err := githubJSONRequest(ctx, http.MethodPost, endpoint, token, payload, &created)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s/%s: %w", repo.Owner, repo.Name, err)
+	}
+	if status == http.StatusUnprocessableEntity {
+		for _, e := range created.Errors {
+			if strings.Contains(e.Message, "already exists") {
+				return "", &types.RepoExistsError{URL: fmt.Sprintf("https://%s/%s/%s.git", GitHubWebHost(apiBase), repo.Owner, repo.Name)}
+			}
+		}
+	}
+	if status != http.StatusCreated || strings.TrimSpace(created.CloneURL) == "" {
+		return "", fmt.Errorf("GitHub API returned status %d creating %s/%s: %s", status, repo.Owner, repo.Name, created.Message)
+	}
+	return created.CloneURL, nil
+}
+
+// githubJSONRequest sends payload (when set) as JSON to the GitHub API and decodes the response
+// into out. The status code is returned with a nil error for any response that is not a 2xx.
+func githubJSONRequest(ctx context.Context, method, url, token string, payload, out interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		b, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpclient.Do(req, httpclient.API)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	// Error bodies are decoded too so callers can read GitHub's message and validation errors
+	_ = json.Unmarshal(b, out)
+	return resp.StatusCode, nil
+}
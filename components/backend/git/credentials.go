@@ -0,0 +1,88 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// HostCredential is the token git presents to one host
+type HostCredential struct {
+	Token string `json:"token"`
+	// Scheme is the username sent with the token: empty means x-access-token, which GitHub
+	// expects; GitLab expects oauth2
+	Scheme string `json:"scheme,omitempty"`
+}
+
+// Credentials maps a git host such as "github.com" or "gitlab.example.com" to its credential, so
+// one workspace can hold repos from several providers
+type Credentials map[string]HostCredential
+
+// credentialHost limits the keys of Credentials to host[:port]; the key ends up in a config name
+var credentialHost = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?$`)
+
+// credentialHelper answers git's "get" for one host from the environment of the git process, so
+// tokens appear neither in arguments nor in any git config on disk. %d is the host's index.
+const credentialHelper = `!f() { test "$1" = get && printf 'username=%%s\npassword=%%s\n' "$AMBIENT_GIT_USERNAME_%[1]d" "$AMBIENT_GIT_PASSWORD_%[1]d"; }; f`
+
+// HostOf returns the host[:port] of an https or scp-style git URL, or "" when it has none
+func HostOf(repoURL string) string {
+	repoURL = strings.TrimSpace(repoURL)
+	if strings.HasPrefix(repoURL, "git@") {
+		host, _, _ := strings.Cut(strings.TrimPrefix(repoURL, "git@"), ":")
+		return strings.ToLower(host)
+	}
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// ForURL returns the credential for the host of repoURL
+func (c Credentials) ForURL(repoURL string) (HostCredential, bool) {
+	cred, ok := c[HostOf(repoURL)]
+	return cred, ok && cred.Token != ""
+}
+
+// hosts returns the usable hosts of c in a stable order
+func (c Credentials) hosts() []string {
+	hosts := make([]string, 0, len(c))
+	for host, cred := range c {
+		if cred.Token != "" && credentialHost.MatchString(host) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// Command returns a git command in dir that authenticates to each host of creds through a
+// credential helper scoped to that host. Helpers from the system and user config are reset for
+// those hosts, and git never prompts, so a missing credential fails instead of hanging.
+func Command(ctx context.Context, dir string, creds Credentials, args ...string) *exec.Cmd {
+	hosts := creds.hosts()
+	gitArgs := make([]string, 0, 4*len(hosts)+len(args))
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	for i, host := range hosts {
+		cred := creds[host]
+		username := cred.Scheme
+		if username == "" {
+			username = "x-access-token"
+		}
+		key := "credential.https://" + strings.ToLower(host) + ".helper"
+		gitArgs = append(gitArgs, "-c", key+"=", "-c", key+"="+fmt.Sprintf(credentialHelper, i))
+		env = append(env,
+			fmt.Sprintf("AMBIENT_GIT_USERNAME_%d=%s", i, username),
+			fmt.Sprintf("AMBIENT_GIT_PASSWORD_%d=%s", i, cred.Token))
+	}
+	cmd := exec.CommandContext(ctx, "git", append(gitArgs, args...)...)
+	cmd.Dir = dir
+	cmd.Env = env
+	return cmd
+}
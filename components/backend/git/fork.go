@@ -0,0 +1,109 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"ambient-code-backend/httpclient"
+)
+
+// GitHubForkReadyTimeout bounds how long ForkGitHubRepo waits for GitHub to finish creating a fork
+var GitHubForkReadyTimeout = 2 * time.Minute
+
+// githubForkPollInterval is the delay between checks of whether a requested fork exists yet
+var githubForkPollInterval = 2 * time.Second
+
+// githubRepoInfo is the part of a GitHub repository object the fork helpers read
+type githubRepoInfo struct {
+	FullName    string `json:"full_name"`
+	CloneURL    string `json:"clone_url"`
+	Permissions struct {
+		Push bool `json:"push"`
+	} `json:"permissions"`
+}
+
+// githubRepoRequest calls a /repos endpoint of the GitHub API and decodes the repository it returns.
+// The status code is returned with a nil error for any response that is not a 2xx.
+func githubRepoRequest(ctx context.Context, method, url, token string) (*githubRepoInfo, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := httpclient.Do(req, httpclient.API)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, resp.StatusCode, nil
+	}
+	var info githubRepoInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to parse repository info: %w", err)
+	}
+	return &info, resp.StatusCode, nil
+}
+
+// CanPushGitHubRepo reports whether token may push to the GitHub repository at repoURL
+func CanPushGitHubRepo(ctx context.Context, repoURL, token, githubAPIBase string) (bool, error) {
+	owner, repo, err := ParseGitHubURL(repoURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid GitHub repository URL: %w", err)
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s", ResolveGitHubAPIBase(repoURL, githubAPIBase), owner, repo)
+	info, status, err := githubRepoRequest(ctx, http.MethodGet, apiURL, token)
+	if err != nil {
+		return false, fmt.Errorf("failed to check repository access: %w", err)
+	}
+	if info == nil {
+		return false, fmt.Errorf("GitHub API returned status %d for %s/%s", status, owner, repo)
+	}
+	return info.Permissions.Push, nil
+}
+
+// ForkGitHubRepo forks the GitHub repository at repoURL into the account of token's user and
+// returns the fork's clone URL once GitHub has created it. GitHub returns the existing fork when
+// the user already has one.
+func ForkGitHubRepo(ctx context.Context, repoURL, token, githubAPIBase string) (string, error) {
+	owner, repo, err := ParseGitHubURL(repoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub repository URL: %w", err)
+	}
+	apiBase := ResolveGitHubAPIBase(repoURL, githubAPIBase)
+	fork, status, err := githubRepoRequest(ctx, http.MethodPost, fmt.Sprintf("%s/repos/%s/%s/forks", apiBase, owner, repo), token)
+	if err != nil {
+		return "", fmt.Errorf("failed to fork %s/%s: %w", owner, repo, err)
+	}
+	if fork == nil {
+		return "", fmt.Errorf("GitHub API returned status %d forking %s/%s", status, owner, repo)
+	}
+	if strings.TrimSpace(fork.FullName) == "" || strings.TrimSpace(fork.CloneURL) == "" {
+		return "", fmt.Errorf("GitHub API returned no fork for %s/%s", owner, repo)
+	}
+
+	// Forks are created asynchronously; the repository answers once it can be pushed to
+	ctx, cancel := context.WithTimeout(ctx, GitHubForkReadyTimeout)
+	defer cancel()
+	for {
+		info, _, err := githubRepoRequest(ctx, http.MethodGet, fmt.Sprintf("%s/repos/%s", apiBase, fork.FullName), token)
+		if err == nil && info != nil {
+			return fork.CloneURL, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("fork %s was not ready in time: %w", fork.FullName, ctx.Err())
+		case <-time.After(githubForkPollInterval):
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Reasons GetGitHubToken found no usable GitHub credential. It returns them wrapped in a
+// *GitHubTokenError, so callers test with errors.Is.
+var (
+	// ErrNoInstallation: the GitHub App is available but the user has not installed it, and the project has no PAT
+	ErrNoInstallation = errors.New("GitHub App is not connected")
+	// ErrNoPAT: the GitHub App is not available and the project's integration secret has no GITHUB_TOKEN
+	ErrNoPAT = errors.New("no GITHUB_TOKEN in the project's integration secret")
+	// ErrAppMisconfigured: the user's installation exists but its token could not be minted with the App's credentials
+	ErrAppMisconfigured = errors.New("GitHub App is misconfigured")
+	// ErrUpstreamGitHub: GitHub could not be reached or failed while minting an installation token
+	ErrUpstreamGitHub = errors.New("GitHub could not issue a token")
+)
+
+// Remediation codes a client can turn into a setup prompt
+const (
+	RemediationConnectGitHubApp   = "connect-github-app"
+	RemediationAddGitHubToken     = "add-github-token"
+	RemediationReconnectGitHubApp = "reconnect-github-app"
+	RemediationRetry              = "retry"
+)
+
+// GitHubTokenError is a classified GetGitHubToken failure
+type GitHubTokenError struct {
+	// Reason is one of ErrNoInstallation, ErrNoPAT, ErrAppMisconfigured or ErrUpstreamGitHub
+	Reason error
+	// Remediation is one of the Remediation* codes
+	Remediation string
+	// Hint tells the user how to fix it
+	Hint string
+	// Cause is the underlying failure, for logs only
+	Cause error
+}
+
+func (e *GitHubTokenError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%v: %v", e.Reason, e.Cause)
+	}
+	return e.Reason.Error()
+}
+
+func (e *GitHubTokenError) Unwrap() error {
+	return e.Reason
+}
+
+// noGitHubCredentials is the error when neither the App nor a PAT yields a token. appAvailable
+// reports whether the backend has a GitHub App the user could install.
+func noGitHubCredentials(appAvailable bool) *GitHubTokenError {
+	if appAvailable {
+		return &GitHubTokenError{
+			Reason:      ErrNoInstallation,
+			Remediation: RemediationConnectGitHubApp,
+			Hint:        "Connect the GitHub App in Integrations, or add GITHUB_TOKEN to the project's integration secret",
+		}
+	}
+	return &GitHubTokenError{
+		Reason:      ErrNoPAT,
+		Remediation: RemediationAddGitHubToken,
+		Hint:        "Add GITHUB_TOKEN to the project's integration secret",
+	}
+}
+
+// httpStatusError is implemented by mint errors that carry GitHub's response status
+type httpStatusError interface {
+	HTTPStatus() int
+}
+
+// classifyMintError sorts a failed installation token mint: GitHub rejecting the App's
+// credentials or the installation (401, 403, 404) or a backend without App credentials is a
+// misconfiguration, anything else an upstream failure
+func classifyMintError(err error) *GitHubTokenError {
+	misconfigured := &GitHubTokenError{
+		Reason:      ErrAppMisconfigured,
+		Remediation: RemediationReconnectGitHubApp,
+		Hint:        "Reconnect the GitHub App in Integrations; if that does not help, ask an administrator to check the GitHub App configuration",
+		Cause:       err,
+	}
+	var status httpStatusError
+	if errors.As(err, &status) {
+		switch status.HTTPStatus() {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+			return misconfigured
+		}
+	} else if errors.Is(err, ErrAppMisconfigured) {
+		return misconfigured
+	}
+	return &GitHubTokenError{
+		Reason:      ErrUpstreamGitHub,
+		Remediation: RemediationRetry,
+		Hint:        "GitHub did not issue a token; retry shortly",
+		Cause:       err,
+	}
+}
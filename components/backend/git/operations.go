@@ -18,19 +18,22 @@ import (
 	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
 	"ambient-code-backend/gitlab"
+	"ambient-code-backend/httpclient"
 	"ambient-code-backend/types"
 )
 
 // Package-level dependencies (set from main package)
 var (
 	GetProjectSettingsResource func() schema.GroupVersionResource
-	GetGitHubInstallation      func(context.Context, string) (interface{}, error)
+	GetGitHubInstallation      func(ctx context.Context, userID, owner string) (interface{}, error)
 	GitHubTokenManager         interface{} // *GitHubTokenManager from main package
 	GetBackendNamespace        func() string
 )
@@ -48,11 +51,18 @@ type DiffSummary struct {
 	FilesRemoved int `json:"files_removed"`
 }
 
-// GetGitHubToken tries to get a GitHub token from GitHub App first, then falls back to project runner secret
-func GetGitHubToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynClient dynamic.Interface, project, userID string) (string, error) {
+// GetGitHubToken tries to get a GitHub token from GitHub App first, then falls back to project runner secret.
+// repo (a repo URL, owner/repo or an owner, "" when unknown) picks which of the user's GitHub App
+// installations to use, see GitHubRepoOwner.
+// When neither yields a token the error is a *GitHubTokenError saying why and how to fix it.
+func GetGitHubToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynClient dynamic.Interface, project, userID, repo string) (string, error) {
+	appAvailable := GetGitHubInstallation != nil && GitHubTokenManager != nil
+	// A failed mint for an existing installation explains a missing token better than the missing PAT
+	var appErr *GitHubTokenError
+
 	// Try GitHub App first if available
-	if GetGitHubInstallation != nil && GitHubTokenManager != nil {
-		installation, err := GetGitHubInstallation(ctx, userID)
+	if appAvailable {
+		installation, err := GetGitHubInstallation(ctx, userID, GitHubRepoOwner(repo))
 		if err == nil && installation != nil {
 			// Use reflection-like approach to call MintInstallationTokenForHost
 			// This requires the caller to set up the proper interface/struct
@@ -71,16 +81,26 @@ func GetGitHubToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynCli
 						log.Printf("Using GitHub App token for user %s", userID)
 						return token, nil
 					}
+					if err == nil {
+						err = fmt.Errorf("GitHub returned an empty installation token")
+					}
 					log.Printf("Failed to mint GitHub App token for user %s: %v", userID, err)
+					appErr = classifyMintError(err)
 				}
 			}
 		}
 	}
+	noCredentials := func() error {
+		if appErr != nil {
+			return appErr
+		}
+		return noGitHubCredentials(appAvailable)
+	}
 
 	// Fall back to project integration secret GITHUB_TOKEN (hardcoded secret name)
 	if k8sClient == nil {
 		log.Printf("Cannot read integration secret: k8s client is nil")
-		return "", fmt.Errorf("no GitHub credentials available. Either connect GitHub App or configure GITHUB_TOKEN in integration secrets")
+		return "", fmt.Errorf("no GitHub credentials available: cannot read the project's integration secret")
 	}
 
 	const secretName = "ambient-non-vertex-integrations"
@@ -88,25 +108,29 @@ func GetGitHubToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynCli
 	log.Printf("Attempting to read GITHUB_TOKEN from secret %s/%s", project, secretName)
 
 	secret, err := k8sClient.CoreV1().Secrets(project).Get(ctx, secretName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		log.Printf("Integration secret %s/%s not found", project, secretName)
+		return "", noCredentials()
+	}
 	if err != nil {
 		log.Printf("Failed to get integration secret %s/%s: %v", project, secretName, err)
-		return "", fmt.Errorf("no GitHub credentials available. Either connect GitHub App or configure GITHUB_TOKEN in integration secrets")
+		return "", fmt.Errorf("no GitHub credentials available: failed to read the project's integration secret: %w", err)
 	}
 
 	if secret.Data == nil {
 		log.Printf("Secret %s/%s exists but Data is nil", project, secretName)
-		return "", fmt.Errorf("no GitHub credentials available. Either connect GitHub App or configure GITHUB_TOKEN in integration secrets")
+		return "", noCredentials()
 	}
 
 	token, ok := secret.Data["GITHUB_TOKEN"]
 	if !ok {
 		log.Printf("Secret %s/%s exists but has no GITHUB_TOKEN key (available keys: %v)", project, secretName, getSecretKeys(secret.Data))
-		return "", fmt.Errorf("no GitHub credentials available. Either connect GitHub App or configure GITHUB_TOKEN in integration secrets")
+		return "", noCredentials()
 	}
 
 	if len(token) == 0 {
 		log.Printf("Secret %s/%s has GITHUB_TOKEN key but value is empty", project, secretName)
-		return "", fmt.Errorf("no GitHub credentials available. Either connect GitHub App or configure GITHUB_TOKEN in integration secrets")
+		return "", noCredentials()
 	}
 
 	log.Printf("Using GITHUB_TOKEN from integration secret %s/%s", project, secretName)
@@ -154,7 +178,7 @@ func GetGitToken(ctx context.Context, k8sClient *kubernetes.Clientset, dynClient
 
 	switch provider {
 	case types.ProviderGitHub:
-		return GetGitHubToken(ctx, k8sClient, dynClient, project, userID)
+		return GetGitHubToken(ctx, k8sClient, dynClient, project, userID, repoURL)
 	case types.ProviderGitLab:
 		return GetGitLabToken(ctx, k8sClient, project, userID)
 	default:
@@ -172,14 +196,15 @@ func getSecretKeys(data map[string][]byte) []string {
 }
 
 // CheckRepoSeeding checks if a repo has been seeded by verifying .claude/commands/ and .specify/ exist
-// Supports both GitHub and GitLab repositories
-func CheckRepoSeeding(ctx context.Context, repoURL string, branch *string, token string) (bool, map[string]interface{}, error) {
+// Supports both GitHub and GitLab repositories. githubAPIBase is the project's GitHub API base
+// override ("" derives it from the repo host); when set, unrecognised hosts are treated as GitHub.
+func CheckRepoSeeding(ctx context.Context, repoURL string, branch *string, token, githubAPIBase string) (bool, map[string]interface{}, error) {
 	branchName := "main"
 	if branch != nil && strings.TrimSpace(*branch) != "" {
 		branchName = strings.TrimSpace(*branch)
 	}
 
-	provider := types.DetectProvider(repoURL)
+	provider := detectProviderWithGitHubBase(repoURL, githubAPIBase)
 
 	var claudeExists, claudeCommandsExists, claudeAgentsExists, specifyExists bool
 	var err error
@@ -191,23 +216,24 @@ func CheckRepoSeeding(ctx context.Context, repoURL string, branch *string, token
 		if err != nil {
 			return false, nil, err
 		}
+		api := ResolveGitHubAPIBase(repoURL, githubAPIBase)
 
-		claudeExists, err = checkGitHubPathExists(ctx, owner, repo, branchName, ".claude", token)
+		claudeExists, err = checkGitHubPathExists(ctx, api, owner, repo, branchName, ".claude", token)
 		if err != nil {
 			return false, nil, fmt.Errorf("failed to check .claude: %w", err)
 		}
 
-		claudeCommandsExists, err = checkGitHubPathExists(ctx, owner, repo, branchName, ".claude/commands", token)
+		claudeCommandsExists, err = checkGitHubPathExists(ctx, api, owner, repo, branchName, ".claude/commands", token)
 		if err != nil {
 			return false, nil, fmt.Errorf("failed to check .claude/commands: %w", err)
 		}
 
-		claudeAgentsExists, err = checkGitHubPathExists(ctx, owner, repo, branchName, ".claude/agents", token)
+		claudeAgentsExists, err = checkGitHubPathExists(ctx, api, owner, repo, branchName, ".claude/agents", token)
 		if err != nil {
 			return false, nil, fmt.Errorf("failed to check .claude/agents: %w", err)
 		}
 
-		specifyExists, err = checkGitHubPathExists(ctx, owner, repo, branchName, ".specify", token)
+		specifyExists, err = checkGitHubPathExists(ctx, api, owner, repo, branchName, ".specify", token)
 		if err != nil {
 			return false, nil, fmt.Errorf("failed to check .specify: %w", err)
 		}
@@ -219,7 +245,11 @@ func CheckRepoSeeding(ctx context.Context, repoURL string, branch *string, token
 			return false, nil, fmt.Errorf("invalid GitLab URL: %w", err)
 		}
 
-		client := gitlab.NewClient(parsed.APIURL, token)
+		var client *gitlab.Client
+		client, err = gitlab.NewClient(gitlab.Config{APIURL: parsed.APIURL, Token: token})
+		if err != nil {
+			return false, nil, err
+		}
 
 		claudeExists, err = checkGitLabPathExists(ctx, client, parsed.ProjectID, branchName, ".claude")
 		if err != nil {
@@ -277,22 +307,95 @@ func checkGitLabPathExists(ctx context.Context, client *gitlab.Client, projectID
 
 // ParseGitHubURL extracts owner and repo from a GitHub URL
 func ParseGitHubURL(gitURL string) (owner, repo string, err error) {
-	gitURL = strings.TrimSuffix(gitURL, ".git")
+	_, owner, repo, err = ParseGitHubRepoURL(gitURL)
+	return owner, repo, err
+}
 
-	if strings.Contains(gitURL, "github.com") {
-		parts := strings.Split(gitURL, "github.com")
-		if len(parts) != 2 {
-			return "", "", fmt.Errorf("invalid GitHub URL")
-		}
-		path := strings.Trim(parts[1], "/:")
-		pathParts := strings.Split(path, "/")
-		if len(pathParts) < 2 {
-			return "", "", fmt.Errorf("invalid GitHub URL path")
-		}
-		return pathParts[0], pathParts[1], nil
+// GitHubRepoOwner returns the owner of repo, which may be a GitHub URL, "owner/repo" or a bare
+// owner. It is "" when repo is empty or cannot be parsed.
+func GitHubRepoOwner(repo string) string {
+	repo = strings.TrimSpace(repo)
+	if owner, _, err := ParseGitHubURL(repo); err == nil {
+		return owner
+	}
+	if strings.Contains(repo, "://") || strings.HasPrefix(repo, "git@") {
+		return ""
+	}
+	owner, _, _ := strings.Cut(repo, "/")
+	return owner
+}
+
+// ParseGitHubRepoURL splits an HTTPS or SSH GitHub URL into host, owner and repo. Any host is
+// accepted except GitLab ones, so GitHub Enterprise Server hosts that are not named github.*
+// parse too; callers pick the API base with ResolveGitHubAPIBase.
+func ParseGitHubRepoURL(gitURL string) (host, owner, repo string, err error) {
+	s := strings.TrimSuffix(strings.TrimSpace(gitURL), ".git")
+	if strings.HasPrefix(s, "git@") {
+		// git@host:owner/repo -> https://host/owner/repo
+		s = "https://" + strings.Replace(strings.TrimPrefix(s, "git@"), ":", "/", 1)
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return "", "", "", fmt.Errorf("not a GitHub URL")
+	}
+	if types.DetectProvider(s) == types.ProviderGitLab {
+		return "", "", "", fmt.Errorf("not a GitHub URL")
 	}
+	pathParts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(pathParts) < 2 || pathParts[0] == "" || pathParts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid GitHub URL path")
+	}
+	return u.Hostname(), pathParts[0], pathParts[1], nil
+}
+
+// GitHubAPIBaseEnv overrides the GitHub REST API base for the whole deployment,
+// e.g. https://ghe.example.com/api/v3
+const GitHubAPIBaseEnv = "GITHUB_API_BASE"
+
+// GitHubAPIBaseURL returns the REST API base for a GitHub host. GITHUB_API_BASE wins when set;
+// otherwise github.com (or no host) maps to https://api.github.com and any other host is
+// treated as GitHub Enterprise Server at https://<host>/api/v3.
+func GitHubAPIBaseURL(host string) string {
+	if base := strings.TrimRight(strings.TrimSpace(os.Getenv(GitHubAPIBaseEnv)), "/"); base != "" {
+		return base
+	}
+	if host == "" || host == "github.com" {
+		return "https://api.github.com"
+	}
+	return fmt.Sprintf("https://%s/api/v3", host)
+}
+
+// ResolveGitHubAPIBase picks the REST API base for a repo. projectBase (ProjectSettings
+// spec.githubApiBase) wins; otherwise see GitHubAPIBaseURL. repoURL may also be owner/repo,
+// which resolves like github.com.
+func ResolveGitHubAPIBase(repoURL, projectBase string) string {
+	if base := strings.TrimRight(strings.TrimSpace(projectBase), "/"); base != "" {
+		return base
+	}
+	host, _, _, err := ParseGitHubRepoURL(repoURL)
+	if err != nil {
+		host = ""
+	}
+	return GitHubAPIBaseURL(host)
+}
+
+// GetProjectGitHubAPIBase returns spec.githubApiBase from the project's ProjectSettings, or ""
+// when it is unset or cannot be read
+func GetProjectGitHubAPIBase(ctx context.Context, dynClient dynamic.Interface, project string) string {
+	if dynClient == nil || project == "" || GetProjectSettingsResource == nil {
+		return ""
+	}
+	obj, err := dynClient.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	return ProjectSettingsGitHubAPIBase(obj)
+}
 
-	return "", "", fmt.Errorf("not a GitHub URL")
+// ProjectSettingsGitHubAPIBase returns spec.githubApiBase from a ProjectSettings object
+func ProjectSettingsGitHubAPIBase(obj *unstructured.Unstructured) string {
+	base, _, _ := unstructured.NestedString(obj.Object, "spec", "githubApiBase")
+	return strings.TrimSpace(base)
 }
 
 // IsProtectedBranch checks if a branch name is a protected branch
@@ -308,23 +411,58 @@ func IsProtectedBranch(branchName string) bool {
 	return false
 }
 
-// ValidateBranchName validates a user-provided branch name
-// Returns an error if the branch name is protected or invalid
+// ValidateBranchName checks a user-provided branch name against the git check-ref-format rules
+// for branches. Beyond what git refuses, names starting with '-' are rejected so a branch can never
+// be read as an option by the git commands it is passed to. Protected names are not refused here;
+// see IsProtectedBranch.
 func ValidateBranchName(branchName string) error {
-	normalized := strings.TrimSpace(branchName)
-	if normalized == "" {
+	reason := ""
+	switch {
+	case branchName == "":
 		return fmt.Errorf("branch name cannot be empty")
+	case strings.HasPrefix(branchName, "-"):
+		reason = "must not start with '-'"
+	case branchName == "@":
+		reason = "must not be '@'"
+	case strings.Contains(branchName, ".."):
+		reason = "must not contain '..'"
+	case strings.Contains(branchName, "@{"):
+		reason = "must not contain '@{'"
+	case strings.HasSuffix(branchName, "."):
+		reason = "must not end with '.'"
+	}
+	if reason == "" {
+		for _, r := range branchName {
+			if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+				reason = fmt.Sprintf("must not contain %q", r)
+				break
+			}
+		}
+	}
+	if reason == "" {
+		for _, component := range strings.Split(branchName, "/") {
+			if component == "" {
+				reason = "must not start or end with '/' or contain '//'"
+			} else if strings.HasPrefix(component, ".") {
+				reason = "path components must not start with '.'"
+			} else if strings.HasSuffix(component, ".lock") {
+				reason = "path components must not end with '.lock'"
+			}
+			if reason != "" {
+				break
+			}
+		}
 	}
-	if IsProtectedBranch(normalized) {
-		return fmt.Errorf("'%s' is a protected branch name. Please use a different branch name", normalized)
+	if reason != "" {
+		return fmt.Errorf("%q is not a valid branch name: %s", branchName, reason)
 	}
 	return nil
 }
 
 // checkGitHubPathExists checks if a path exists in a GitHub repo
-func checkGitHubPathExists(ctx context.Context, owner, repo, branch, path, token string) (bool, error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s",
-		owner, repo, path, branch)
+func checkGitHubPathExists(ctx context.Context, apiBase, owner, repo, branch, path, token string) (bool, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
+		apiBase, owner, repo, path, branch)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
@@ -334,7 +472,7 @@ func checkGitHubPathExists(ctx context.Context, owner, repo, branch, path, token
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return false, err
 	}
@@ -364,9 +502,10 @@ type Workflow interface {
 }
 
 // PerformRepoSeeding performs the actual seeding operations
-// wf parameter should implement the Workflow interface
+// wf parameter should implement the Workflow interface; githubAPIBase is the project's GitHub
+// API base override ("" derives it from each repo's host)
 // Returns: branchExisted (bool), error
-func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, token, agentURL, agentBranch, agentPath, specKitRepo, specKitVersion, specKitTemplate string) (bool, error) {
+func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, token, githubAPIBase, agentURL, agentBranch, agentPath, specKitRepo, specKitVersion, specKitTemplate string) (bool, error) {
 	umbrellaRepo := wf.GetUmbrellaRepo()
 	if umbrellaRepo == nil {
 		return false, fmt.Errorf("workflow has no spec repo")
@@ -378,7 +517,7 @@ func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, token, age
 
 	// Validate push access to spec repo before starting
 	log.Printf("Validating push access to spec repo: %s", umbrellaRepo.GetURL())
-	if err := validatePushAccess(ctx, umbrellaRepo.GetURL(), token); err != nil {
+	if err := validatePushAccess(ctx, umbrellaRepo.GetURL(), token, githubAPIBase); err != nil {
 		return false, fmt.Errorf("spec repo access validation failed: %w", err)
 	}
 
@@ -387,7 +526,7 @@ func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, token, age
 	if len(supportingRepos) > 0 {
 		log.Printf("Validating push access to %d supporting repos", len(supportingRepos))
 		for i, repo := range supportingRepos {
-			if err := validatePushAccess(ctx, repo.GetURL(), token); err != nil {
+			if err := validatePushAccess(ctx, repo.GetURL(), token, githubAPIBase); err != nil {
 				return false, fmt.Errorf("supporting repo #%d (%s) access validation failed: %w", i+1, repo.GetURL(), err)
 			}
 		}
@@ -532,7 +671,11 @@ func PerformRepoSeeding(ctx context.Context, wf Workflow, branchName, token, age
 		log.Printf("Downloading spec-kit branch archive: %s", specKitURL)
 	}
 
-	resp, err := http.Get(specKitURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, specKitURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create spec-kit request: %w", err)
+	}
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return false, fmt.Errorf("failed to download spec-kit: %w", err)
 	}
@@ -983,16 +1126,93 @@ func DeriveRepoFolderFromURL(u string) string {
 	return strings.TrimSpace(last)
 }
 
-// PushRepo performs git add/commit/push operations on a repository directory
-func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch, githubToken string) (string, error) {
+// Identity is the git author name and email used for commits
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// IsZero reports whether the identity is incomplete and cannot be used for a commit
+func (i Identity) IsZero() bool {
+	return strings.TrimSpace(i.Name) == "" || strings.TrimSpace(i.Email) == ""
+}
+
+// BotIdentity returns the identity used when the user's GitHub identity is unknown, configurable
+// through GIT_BOT_NAME and GIT_BOT_EMAIL
+func BotIdentity() Identity {
+	id := Identity{Name: strings.TrimSpace(os.Getenv("GIT_BOT_NAME")), Email: strings.TrimSpace(os.Getenv("GIT_BOT_EMAIL"))}
+	if id.Name == "" {
+		id.Name = "Ambient Code Bot"
+	}
+	if id.Email == "" {
+		id.Email = "bot@ambient-code.local"
+	}
+	return id
+}
+
+// FetchGitHubIdentity resolves the author identity of a token's user via GET /user. Users who keep
+// their email private get their noreply address, which GitHub still attributes to their profile.
+func FetchGitHubIdentity(ctx context.Context, apiBase, githubToken string) (Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(apiBase, "/")+"/user", nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Authorization", "token "+githubToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := httpclient.Do(req, httpclient.API)
+	if err != nil {
+		return Identity{}, fmt.Errorf("failed to fetch GitHub user: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusForbidden {
+			return Identity{}, fmt.Errorf("GitHub API /user returned 403 (token lacks 'read:user' scope)")
+		}
+		return Identity{}, fmt.Errorf("GitHub API /user returned status %d", resp.StatusCode)
+	}
+	var ghUser struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ghUser); err != nil {
+		return Identity{}, fmt.Errorf("failed to parse GitHub user info: %w", err)
+	}
+	id := Identity{Name: strings.TrimSpace(ghUser.Name), Email: strings.TrimSpace(ghUser.Email)}
+	if id.Name == "" {
+		id.Name = ghUser.Login
+	}
+	if id.Email == "" && ghUser.Login != "" && ghUser.ID > 0 {
+		id.Email = fmt.Sprintf("%d+%s@users.noreply.%s", ghUser.ID, ghUser.Login, GitHubWebHost(apiBase))
+	}
+	if id.IsZero() {
+		return Identity{}, fmt.Errorf("GitHub user has no login")
+	}
+	return id, nil
+}
+
+// GitHubWebHost maps an API base (https://api.github.com or https://host/api/v3) to the web host
+func GitHubWebHost(apiBase string) string {
+	u, err := url.Parse(apiBase)
+	if err != nil || u.Host == "" || u.Host == "api.github.com" {
+		return "github.com"
+	}
+	return u.Host
+}
+
+// PushRepo performs git add/commit/push operations on a repository directory, authenticating to
+// the output repo's host with creds. The commit is authored by author; when it is incomplete the
+// GitHub user of the output repo's token and then BotIdentity are used. With signing set the commit
+// is signed, and a commit that cannot be signed fails the push with a *SigningError.
+func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch string, creds Credentials, author Identity, signing *CommitSigning) (string, error) {
 	if fi, err := os.Stat(repoDir); err != nil || !fi.IsDir() {
 		return "", fmt.Errorf("repo directory not found: %s", repoDir)
 	}
 
 	run := func(args ...string) (string, string, error) {
 		start := time.Now()
-		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
-		cmd.Dir = repoDir
+		cmd := Command(ctx, repoDir, creds, args[1:]...)
 		var stdout, stderr bytes.Buffer
 		cmd.Stdout = &stdout
 		cmd.Stderr = &stderr
@@ -1007,59 +1227,19 @@ func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch
 		return "", nil
 	}
 
-	// Configure git user identity from GitHub API
-	gitUserName := ""
-	gitUserEmail := ""
-
-	if githubToken != "" {
-		req, _ := http.NewRequest("GET", "https://api.github.com/user", nil)
-		req.Header.Set("Authorization", "token "+githubToken)
-		req.Header.Set("Accept", "application/vnd.github+json")
-		resp, err := http.DefaultClient.Do(req)
-		if err == nil {
-			defer resp.Body.Close()
-			switch resp.StatusCode {
-			case 200:
-				body, err := io.ReadAll(resp.Body)
-				if err == nil {
-					var ghUser struct {
-						Login string `json:"login"`
-						Name  string `json:"name"`
-						Email string `json:"email"`
-					}
-					if err := json.Unmarshal(body, &ghUser); err == nil {
-						if gitUserName == "" && ghUser.Name != "" {
-							gitUserName = ghUser.Name
-						} else if gitUserName == "" && ghUser.Login != "" {
-							gitUserName = ghUser.Login
-						}
-						if gitUserEmail == "" && ghUser.Email != "" {
-							gitUserEmail = ghUser.Email
-						}
-						log.Printf("gitPushRepo: fetched GitHub user name=%q email=%q", gitUserName, gitUserEmail)
-					} else {
-						log.Printf("Failed to parse GitHub user info: %v", err)
-					}
-				}
-			case 403:
-				log.Printf("gitPushRepo: GitHub API /user returned 403 (token lacks 'read:user' scope, using fallback identity)")
-			default:
-				log.Printf("gitPushRepo: GitHub API /user returned status %d", resp.StatusCode)
-			}
+	// Older backends do not forward the author, so resolve it from the token here
+	if cred, ok := creds.ForURL(outputRepoURL); author.IsZero() && ok && types.DetectProvider(outputRepoURL) == types.ProviderGitHub {
+		id, err := FetchGitHubIdentity(ctx, ResolveGitHubAPIBase(outputRepoURL, ""), cred.Token)
+		if err != nil {
+			log.Printf("gitPushRepo: %v (using fallback identity)", err)
 		} else {
-			log.Printf("gitPushRepo: failed to fetch GitHub user: %v", err)
+			author = id
 		}
 	}
-
-	if gitUserName == "" {
-		gitUserName = "Ambient Code Bot"
-	}
-	if gitUserEmail == "" {
-		gitUserEmail = "bot@ambient-code.local"
+	if author.IsZero() {
+		author = BotIdentity()
 	}
-	run("git", "config", "user.name", gitUserName)
-	run("git", "config", "user.email", gitUserEmail)
-	log.Printf("gitPushRepo: configured git identity name=%q email=%q", gitUserName, gitUserEmail)
+	log.Printf("gitPushRepo: committing as name=%q email=%q", author.Name, author.Email)
 
 	// Stage and commit
 	log.Printf("gitPushRepo: staging changes ...")
@@ -1071,9 +1251,25 @@ func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch
 	}
 
 	log.Printf("gitPushRepo: committing changes ...")
-	commitOut, commitErr, commitErrCode := run("git", "commit", "-m", cm)
-	if commitErrCode != nil {
-		log.Printf("gitPushRepo: commit failed (continuing): err=%v stderr=%q stdout=%q", commitErrCode, commitErr, commitOut)
+	if signing != nil {
+		// Pushing unsigned commits is not an option once signing is required, so failures stop here
+		cmd, cleanup, err := signedCommitCommand(ctx, repoDir, signing, "-c", "user.name="+author.Name, "-c", "user.email="+author.Email, "commit", "-m", cm)
+		if err != nil {
+			log.Printf("gitPushRepo: signing setup failed: %v", err)
+			return "", err
+		}
+		out, err := cmd.CombinedOutput()
+		cleanup()
+		if err != nil {
+			log.Printf("gitPushRepo: signed commit failed: err=%v output=%q", err, string(out))
+			return "", &SigningError{Err: err}
+		}
+		log.Printf("gitPushRepo: commit signed with %s key", signing.Format)
+	} else {
+		commitOut, commitErr, commitErrCode := run("git", "-c", "user.name="+author.Name, "-c", "user.email="+author.Email, "commit", "-m", cm)
+		if commitErrCode != nil {
+			log.Printf("gitPushRepo: commit failed (continuing): err=%v stderr=%q stdout=%q", commitErrCode, commitErr, commitOut)
+		}
 	}
 
 	// Determine target refspec
@@ -1092,18 +1288,13 @@ func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch
 		ref = "HEAD:" + branch
 	}
 
-	// Push with token authentication
-	var pushArgs []string
-	if githubToken != "" {
-		cfg := fmt.Sprintf("url.https://x-access-token:%s@github.com/.insteadOf=https://github.com/", githubToken)
-		pushArgs = []string{"git", "-c", cfg, "push", "-u", outputRepoURL, ref}
+	if _, ok := creds.ForURL(outputRepoURL); ok {
 		log.Printf("gitPushRepo: running git push with token auth to %s %s", outputRepoURL, ref)
 	} else {
-		pushArgs = []string{"git", "push", "-u", outputRepoURL, ref}
 		log.Printf("gitPushRepo: running git push %s %s in %s", outputRepoURL, ref, repoDir)
 	}
 
-	out, errOut, err := run(pushArgs...)
+	out, errOut, err := run("git", "push", "-u", outputRepoURL, ref)
 	if err != nil {
 		serr := errOut
 		if len(serr) > 2000 {
@@ -1125,6 +1316,29 @@ func PushRepo(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch
 	return out, nil
 }
 
+// HeadRef returns the commit SHA and branch name of HEAD in a repository directory.
+// The branch is empty when HEAD is detached.
+func HeadRef(ctx context.Context, repoDir string) (string, string, error) {
+	shaCmd := exec.CommandContext(ctx, "git", "rev-parse", "HEAD")
+	shaCmd.Dir = repoDir
+	shaOut, err := shaCmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	branchCmd := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	branchCmd.Dir = repoDir
+	branchOut, err := branchCmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve HEAD branch: %w", err)
+	}
+	branch := strings.TrimSpace(string(branchOut))
+	if branch == "HEAD" {
+		branch = ""
+	}
+	return strings.TrimSpace(string(shaOut)), branch, nil
+}
+
 // AbandonRepo discards all uncommitted changes in a repository directory
 func AbandonRepo(ctx context.Context, repoDir string) error {
 	if fi, err := os.Stat(repoDir); err != nil || !fi.IsDir() {
@@ -1228,10 +1442,14 @@ func DiffRepo(ctx context.Context, repoDir string) (*DiffSummary, error) {
 	return summary, nil
 }
 
-// ReadGitHubFile reads the content of a file from a GitHub repository
-func ReadGitHubFile(ctx context.Context, owner, repo, branch, path, token string) ([]byte, error) {
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s?ref=%s",
-		owner, repo, path, branch)
+// ReadGitHubFile reads the content of a file from a GitHub repository. apiBase is the REST API
+// base (see ResolveGitHubAPIBase); "" uses GitHubAPIBaseURL's default.
+func ReadGitHubFile(ctx context.Context, apiBase, owner, repo, branch, path, token string) ([]byte, error) {
+	if apiBase == "" {
+		apiBase = GitHubAPIBaseURL("")
+	}
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s",
+		apiBase, owner, repo, path, branch)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
@@ -1241,7 +1459,7 @@ func ReadGitHubFile(ctx context.Context, owner, repo, branch, path, token string
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/vnd.github.v3.raw")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return nil, err
 	}
@@ -1255,15 +1473,16 @@ func ReadGitHubFile(ctx context.Context, owner, repo, branch, path, token string
 	return io.ReadAll(resp.Body)
 }
 
-// CheckBranchExists checks if a branch exists in a GitHub repository
-func CheckBranchExists(ctx context.Context, repoURL, branchName, githubToken string) (bool, error) {
+// CheckBranchExists checks if a branch exists in a GitHub repository. githubAPIBase is the
+// project's GitHub API base override ("" derives it from the repo host).
+func CheckBranchExists(ctx context.Context, repoURL, branchName, githubToken, githubAPIBase string) (bool, error) {
 	owner, repo, err := ParseGitHubURL(repoURL)
 	if err != nil {
 		return false, err
 	}
 
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/refs/heads/%s",
-		owner, repo, branchName)
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/git/refs/heads/%s",
+		ResolveGitHubAPIBase(repoURL, githubAPIBase), owner, repo, branchName)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
@@ -1273,7 +1492,7 @@ func CheckBranchExists(ctx context.Context, repoURL, branchName, githubToken str
 	req.Header.Set("Authorization", "Bearer "+githubToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return false, err
 	}
@@ -1290,13 +1509,71 @@ func CheckBranchExists(ctx context.Context, repoURL, branchName, githubToken str
 	return false, fmt.Errorf("GitHub API error: %s (body: %s)", resp.Status, string(body))
 }
 
-// validatePushAccess checks if the user has push access to a repository (supports both GitHub and GitLab)
-func validatePushAccess(ctx context.Context, repoURL, token string) error {
+// CheckRefExists checks whether ref (a commit SHA or tag) names a commit in a GitHub or GitLab
+// repository, through the provider's commits API. githubAPIBase is the project's GitHub API base
+// override ("" derives it from the repo host).
+func CheckRefExists(ctx context.Context, repoURL, ref, token, githubAPIBase string) (bool, error) {
+	var apiURL string
+	switch detectProviderWithGitHubBase(repoURL, githubAPIBase) {
+	case types.ProviderGitHub:
+		owner, repo, err := ParseGitHubURL(repoURL)
+		if err != nil {
+			return false, err
+		}
+		apiURL = fmt.Sprintf("%s/repos/%s/%s/commits/%s",
+			ResolveGitHubAPIBase(repoURL, githubAPIBase), owner, repo, url.PathEscape(ref))
+	case types.ProviderGitLab:
+		parsed, err := gitlab.ParseGitLabURL(repoURL)
+		if err != nil {
+			return false, fmt.Errorf("invalid GitLab repository URL: %w", err)
+		}
+		// parsed.ProjectID is already URL-encoded
+		apiURL = fmt.Sprintf("%s/projects/%s/repository/commits/%s", parsed.APIURL, parsed.ProjectID, url.PathEscape(ref))
+	default:
+		return false, fmt.Errorf("unsupported repository provider for URL: %s", repoURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpclient.Do(req, httpclient.API)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound, http.StatusUnprocessableEntity:
+		// GitHub answers 422 for a ref that does not resolve to a commit
+		return false, nil
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return false, fmt.Errorf("commits API error: %s (body: %s)", resp.Status, string(body))
+}
+
+// detectProviderWithGitHubBase detects the provider of a repo URL. Hosts that are not recognised
+// (GitHub Enterprise Server under a custom name) are GitHub when the project sets a GitHub API base.
+func detectProviderWithGitHubBase(repoURL, githubAPIBase string) types.ProviderType {
 	provider := types.DetectProvider(repoURL)
+	if provider == "" && strings.TrimSpace(githubAPIBase) != "" {
+		return types.ProviderGitHub
+	}
+	return provider
+}
+
+// validatePushAccess checks if the user has push access to a repository (supports both GitHub and GitLab)
+func validatePushAccess(ctx context.Context, repoURL, token, githubAPIBase string) error {
+	provider := detectProviderWithGitHubBase(repoURL, githubAPIBase)
 
 	switch provider {
 	case types.ProviderGitHub:
-		return validateGitHubPushAccess(ctx, repoURL, token)
+		return validateGitHubPushAccess(ctx, repoURL, token, githubAPIBase)
 	case types.ProviderGitLab:
 		return validateGitLabPushAccess(ctx, repoURL, token)
 	default:
@@ -1305,7 +1582,7 @@ func validatePushAccess(ctx context.Context, repoURL, token string) error {
 }
 
 // validateGitHubPushAccess checks if the user has push access to a GitHub repository
-func validateGitHubPushAccess(ctx context.Context, repoURL, githubToken string) error {
+func validateGitHubPushAccess(ctx context.Context, repoURL, githubToken, githubAPIBase string) error {
 	owner, repo, err := ParseGitHubURL(repoURL)
 	if err != nil {
 		return fmt.Errorf("invalid GitHub repository URL: %w", err)
@@ -1313,7 +1590,7 @@ func validateGitHubPushAccess(ctx context.Context, repoURL, githubToken string)
 
 	// Use GitHub API to check repository permissions
 	log.Printf("Validating push access to GitHub repo %s with token (len=%d)", repoURL, len(githubToken))
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
+	apiURL := fmt.Sprintf("%s/repos/%s/%s", ResolveGitHubAPIBase(repoURL, githubAPIBase), owner, repo)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
@@ -1323,7 +1600,7 @@ func validateGitHubPushAccess(ctx context.Context, repoURL, githubToken string)
 	req.Header.Set("Authorization", "Bearer "+githubToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return fmt.Errorf("failed to check repository access: %w", err)
 	}
@@ -1392,7 +1669,7 @@ func validateGitLabPushAccess(ctx context.Context, repoURL, gitlabToken string)
 	req.Header.Set("Authorization", "Bearer "+gitlabToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return fmt.Errorf("failed to check repository access: %w", err)
 	}
@@ -1455,7 +1732,7 @@ func validateGitLabPushAccess(ctx context.Context, repoURL, gitlabToken string)
 		userReq.Header.Set("Authorization", "Bearer "+gitlabToken)
 		userReq.Header.Set("Accept", "application/json")
 
-		userResp, err := http.DefaultClient.Do(userReq)
+		userResp, err := httpclient.Do(userReq, httpclient.API)
 		if err != nil {
 			return fmt.Errorf("failed to get user info: %w", err)
 		}
@@ -1625,6 +1902,18 @@ func ConfigureRemote(ctx context.Context, repoDir, remoteName, remoteURL string)
 	return nil
 }
 
+// ConfigureIdentity sets user.name and user.email in a repository's local git config
+func ConfigureIdentity(ctx context.Context, repoDir string, id Identity) error {
+	for key, value := range map[string]string{"user.name": id.Name, "user.email": id.Email} {
+		cmd := exec.CommandContext(ctx, "git", "config", key, value)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to set %s: %w (output: %s)", key, err, string(out))
+		}
+	}
+	return nil
+}
+
 // MergeStatus contains information about merge conflict status
 type MergeStatus struct {
 	CanMergeClean      bool     `json:"canMergeClean"`
@@ -1712,14 +2001,13 @@ func CheckMergeStatus(ctx context.Context, repoDir, branch string) (*MergeStatus
 	return status, nil
 }
 
-// PullRepo pulls changes from remote branch
-func PullRepo(ctx context.Context, repoDir, branch string) error {
+// PullRepo pulls changes from remote branch, authenticating with creds
+func PullRepo(ctx context.Context, repoDir, branch string, creds Credentials) error {
 	if branch == "" {
 		branch = "main"
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "pull", "--allow-unrelated-histories", "origin", branch)
-	cmd.Dir = repoDir
+	cmd := Command(ctx, repoDir, creds, "pull", "--allow-unrelated-histories", "origin", branch)
 
 	if out, err := cmd.CombinedOutput(); err != nil {
 		outStr := string(out)
@@ -1733,8 +2021,8 @@ func PullRepo(ctx context.Context, repoDir, branch string) error {
 	return nil
 }
 
-// PushToRepo pushes local commits to specified branch
-func PushToRepo(ctx context.Context, repoDir, branch, commitMessage string) error {
+// PushToRepo pushes local commits to specified branch, authenticating with creds
+func PushToRepo(ctx context.Context, repoDir, branch, commitMessage string, creds Credentials) error {
 	if branch == "" {
 		branch = "main"
 	}
@@ -1768,7 +2056,8 @@ func PushToRepo(ctx context.Context, repoDir, branch, commitMessage string) erro
 	}
 
 	// Push to branch
-	if out, err := run("git", "push", "-u", "origin", branch); err != nil {
+	push := Command(ctx, repoDir, creds, "push", "-u", "origin", branch)
+	if out, err := push.CombinedOutput(); err != nil {
 		return fmt.Errorf("failed to push: %w (output: %s)", err, out)
 	}
 
@@ -1831,8 +2120,9 @@ func ListRemoteBranches(ctx context.Context, repoDir string) ([]string, error) {
 	return branches, nil
 }
 
-// SyncRepo commits, pulls, and pushes changes
-func SyncRepo(ctx context.Context, repoDir, commitMessage, branch string) error {
+// SyncRepo commits, pulls, and pushes changes, authenticating with creds. With signing set the
+// commit is signed, and a commit that cannot be signed fails with a *SigningError.
+func SyncRepo(ctx context.Context, repoDir, commitMessage, branch string, creds Credentials, signing *CommitSigning) error {
 	if branch == "" {
 		branch = "main"
 	}
@@ -1845,12 +2135,19 @@ func SyncRepo(ctx context.Context, repoDir, commitMessage, branch string) error
 	}
 
 	// Commit changes (only if there are changes)
-	cmd = exec.CommandContext(ctx, "git", "commit", "-m", commitMessage)
-	cmd.Dir = repoDir
-	if out, err := cmd.CombinedOutput(); err != nil {
+	cmd, cleanup, err := signedCommitCommand(ctx, repoDir, signing, "commit", "-m", commitMessage)
+	if err != nil {
+		return err
+	}
+	out, err := cmd.CombinedOutput()
+	cleanup()
+	if err != nil {
 		// Check if error is "nothing to commit"
 		outStr := string(out)
 		if !strings.Contains(outStr, "nothing to commit") && !strings.Contains(outStr, "no changes added") {
+			if signing != nil {
+				return &SigningError{Err: fmt.Errorf("%w (output: %s)", err, outStr)}
+			}
 			return fmt.Errorf("failed to commit: %w (output: %s)", err, outStr)
 		}
 		// Nothing to commit is not an error
@@ -1858,8 +2155,7 @@ func SyncRepo(ctx context.Context, repoDir, commitMessage, branch string) error
 	}
 
 	// Pull with rebase to sync with remote
-	cmd = exec.CommandContext(ctx, "git", "pull", "--rebase", "origin", branch)
-	cmd.Dir = repoDir
+	cmd = Command(ctx, repoDir, creds, "pull", "--rebase", "origin", branch)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		outStr := string(out)
 		// Check if it's just "no tracking information" (first push)
@@ -1870,8 +2166,7 @@ func SyncRepo(ctx context.Context, repoDir, commitMessage, branch string) error
 	}
 
 	// Push to remote
-	cmd = exec.CommandContext(ctx, "git", "push", "-u", "origin", branch)
-	cmd.Dir = repoDir
+	cmd = Command(ctx, repoDir, creds, "push", "-u", "origin", branch)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		outStr := string(out)
 		if strings.Contains(outStr, "Permission denied") || strings.Contains(outStr, "403") {
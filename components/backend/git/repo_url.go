@@ -0,0 +1,86 @@
+package git
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/types"
+)
+
+// scpLikeRepoURL matches scp-style SSH remotes: [user@]host:path
+var scpLikeRepoURL = regexp.MustCompile(`^(?:[^@/:]+@)?([^@/:]+):(.+)$`)
+
+// NormalizeRepoURL returns the canonical form of a repository URL, for deciding whether two URLs
+// name the same repository: https scheme, no credentials, query or fragment, a lowercased host
+// without its default port, and no trailing "/" or ".git". HTTPS, HTTP, ssh:// and scp-style
+// (git@host:owner/repo) remotes of one repository normalize alike. GitHub and GitLab paths are
+// case-insensitive, so they are lowercased; GitHub paths are cut to owner/repo and GitLab paths
+// before "/-/", so web URLs of the repo match as well. Other hosts keep the path's case.
+//
+// The result is only for comparing; sessions keep and clone the URL as it was given. The operator
+// has a copy (normalizeRepoURL in components/operator/internal/handlers/repo_url.go); keep them in sync.
+func NormalizeRepoURL(repoURL string) string {
+	s := strings.TrimSpace(repoURL)
+	if s == "" {
+		return ""
+	}
+	if !strings.Contains(s, "://") {
+		if m := scpLikeRepoURL.FindStringSubmatch(s); m != nil {
+			s = "ssh://" + m[1] + "/" + strings.TrimPrefix(m[2], "/")
+		} else {
+			s = "https://" + s
+		}
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Hostname() == "" {
+		s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(repoURL)), "/")
+		return strings.TrimSuffix(s, ".git")
+	}
+
+	host := strings.ToLower(u.Hostname())
+	// An ssh:// or git:// port says nothing about the HTTPS one, so only web ports are kept
+	port := u.Port()
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		if port == "443" {
+			port = ""
+		}
+	case "http":
+		if port == "80" {
+			port = ""
+		}
+	default:
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	switch types.DetectProvider("https://" + host) {
+	case types.ProviderGitHub:
+		segs := strings.Split(path, "/")
+		if len(segs) > 2 {
+			segs = segs[:2]
+		}
+		segs[len(segs)-1] = strings.TrimSuffix(segs[len(segs)-1], ".git")
+		path = strings.ToLower(strings.Join(segs, "/"))
+	case types.ProviderGitLab:
+		if i := strings.Index(path, "/-/"); i >= 0 {
+			path = path[:i]
+		}
+		path = strings.ToLower(strings.TrimSuffix(strings.TrimRight(path, "/"), ".git"))
+	}
+	if path == "" {
+		return "https://" + host
+	}
+	return "https://" + host + "/" + path
+}
+
+// SameRepoURL reports whether two repository URLs name the same repository (see NormalizeRepoURL)
+func SameRepoURL(a, b string) bool {
+	na := NormalizeRepoURL(a)
+	return na != "" && na == NormalizeRepoURL(b)
+}
@@ -0,0 +1,110 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SecretFinding is a line added by a pending push that looks like a credential. The matched value
+// itself is never reported.
+type SecretFinding struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// secretRule is one pattern of the pre-push secret scan
+type secretRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// secretRules are deliberately few and specific: a blocked push needs an explicit override, so
+// broad entropy heuristics would cost more than they catch
+var secretRules = []secretRule{
+	{Name: "aws-access-key-id", Pattern: regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-access-key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}secret.{0,20}[:=]\s*['"]?[0-9a-zA-Z/+]{40}\b`)},
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN ((RSA|DSA|EC|OPENSSH|PGP|ENCRYPTED) )?PRIVATE KEY( BLOCK)?-----`)},
+	{Name: "generic-api-key", Pattern: regexp.MustCompile(`(?i)\b(api[_-]?key|secret[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret)\b['"]?\s*[:=]\s*['"]?[0-9a-zA-Z_\-./+=]{16,}`)},
+}
+
+// hunkHeader matches the new-file start line of a unified diff hunk
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ScanDiffForSecrets returns the added lines of a unified diff that match a secret rule, with their
+// line number in the new file. Each line is reported once, under the first rule it matches.
+func ScanDiffForSecrets(diff string) []SecretFinding {
+	var findings []SecretFinding
+	file := ""
+	line := 0
+	// "+++ " is a file header only between "diff --git" and the first hunk; in a hunk it is an
+	// added line starting with "++"
+	inHeader := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		text := scanner.Text()
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			inHeader, file = true, ""
+		case inHeader && strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+			if file == "/dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@"):
+			inHeader = false
+			if m := hunkHeader.FindStringSubmatch(text); m != nil {
+				line, _ = strconv.Atoi(m[1])
+			}
+		case inHeader:
+		case strings.HasPrefix(text, "+"):
+			if file != "" {
+				for _, rule := range secretRules {
+					if rule.Pattern.MatchString(text[1:]) {
+						findings = append(findings, SecretFinding{File: file, Line: line, Rule: rule.Name})
+						break
+					}
+				}
+			}
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+	return findings
+}
+
+// ScanStagedSecrets stages all changes in repoDir, as PushRepo does before committing, and scans
+// the staged diff with ScanDiffForSecrets
+func ScanStagedSecrets(ctx context.Context, repoDir string) ([]SecretFinding, error) {
+	if fi, err := os.Stat(repoDir); err != nil || !fi.IsDir() {
+		return nil, fmt.Errorf("repo directory not found: %s", repoDir)
+	}
+	run := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = repoDir
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return stdout.String(), nil
+	}
+	if _, err := run("add", "-A"); err != nil {
+		return nil, err
+	}
+	diff, err := run("diff", "--cached", "--no-color", "--no-ext-diff", "--unified=0")
+	if err != nil {
+		return nil, err
+	}
+	return ScanDiffForSecrets(diff), nil
+}
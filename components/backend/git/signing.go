@@ -0,0 +1,144 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Key formats of CommitSigning
+const (
+	SigningFormatSSH     = "ssh"
+	SigningFormatOpenPGP = "openpgp"
+)
+
+// CommitSigning signs the commits of one git operation. Key is the private key: an OpenSSH private
+// key for ssh, an ASCII-armored secret key for openpgp. SignerEmail becomes the committer email; a
+// host marks the commit verified only when the key belongs to a user who verified that email.
+type CommitSigning struct {
+	Format      string `json:"format"`
+	Key         string `json:"key"`
+	SignerEmail string `json:"signerEmail,omitempty"`
+}
+
+// SigningError reports a commit that could not be signed, so callers can tell it from other
+// commit and push failures
+type SigningError struct {
+	Err error
+}
+
+func (e *SigningError) Error() string {
+	return "sign commit: " + e.Err.Error()
+}
+
+func (e *SigningError) Unwrap() error {
+	return e.Err
+}
+
+// signingSetup is the git configuration and environment of a signed commit
+type signingSetup struct {
+	args    []string
+	env     []string
+	cleanup func()
+}
+
+// prepare writes the key to a private temporary directory outside the repository and returns the
+// arguments that make a commit sign with it. The caller must run cleanup once the commit is done, so
+// the key does not outlive the operation.
+func (s *CommitSigning) prepare(ctx context.Context) (*signingSetup, error) {
+	if strings.TrimSpace(s.Key) == "" {
+		return nil, &SigningError{Err: fmt.Errorf("no signing key")}
+	}
+	dir, err := os.MkdirTemp("", "ambient-signing-")
+	if err != nil {
+		return nil, &SigningError{Err: err}
+	}
+	setup := &signingSetup{cleanup: func() { _ = os.RemoveAll(dir) }}
+	if email := strings.TrimSpace(s.SignerEmail); email != "" {
+		setup.env = append(setup.env, "GIT_COMMITTER_EMAIL="+email)
+	}
+
+	switch s.Format {
+	case SigningFormatSSH:
+		keyFile := filepath.Join(dir, "signing-key")
+		key := s.Key
+		if !strings.HasSuffix(key, "\n") {
+			key += "\n" // ssh-keygen rejects keys without the final newline
+		}
+		if err := os.WriteFile(keyFile, []byte(key), 0600); err != nil {
+			setup.cleanup()
+			return nil, &SigningError{Err: err}
+		}
+		setup.args = []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + keyFile, "-c", "commit.gpgsign=true"}
+	case SigningFormatOpenPGP:
+		// A keyring of its own holds only this key, selected by its fingerprint
+		home := filepath.Join(dir, "gnupg")
+		if err := os.Mkdir(home, 0700); err != nil {
+			setup.cleanup()
+			return nil, &SigningError{Err: err}
+		}
+		removeDir := setup.cleanup
+		setup.cleanup = func() {
+			// Stop the agent gpg started for the keyring before removing it
+			kill := exec.Command("gpgconf", "--kill", "gpg-agent")
+			kill.Env = append(os.Environ(), "GNUPGHOME="+home)
+			_ = kill.Run()
+			removeDir()
+		}
+		cmd := exec.CommandContext(ctx, "gpg", "--batch", "--import")
+		cmd.Env = append(os.Environ(), "GNUPGHOME="+home)
+		cmd.Stdin = strings.NewReader(s.Key)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			setup.cleanup()
+			return nil, &SigningError{Err: fmt.Errorf("import key: %w (%s)", err, strings.TrimSpace(stderr.String()))}
+		}
+		list := exec.CommandContext(ctx, "gpg", "--batch", "--with-colons", "--list-secret-keys")
+		list.Env = cmd.Env
+		out, err := list.Output()
+		if err != nil {
+			setup.cleanup()
+			return nil, &SigningError{Err: fmt.Errorf("list keys: %w", err)}
+		}
+		fingerprint := ""
+		for _, line := range strings.Split(string(out), "\n") {
+			if fields := strings.Split(line, ":"); fields[0] == "fpr" && len(fields) > 9 {
+				fingerprint = fields[9]
+				break
+			}
+		}
+		if fingerprint == "" {
+			setup.cleanup()
+			return nil, &SigningError{Err: fmt.Errorf("no secret key in the signing key")}
+		}
+		setup.args = []string{"-c", "gpg.format=openpgp", "-c", "user.signingkey=" + fingerprint, "-c", "commit.gpgsign=true"}
+		setup.env = append(setup.env, "GNUPGHOME="+home)
+	default:
+		setup.cleanup()
+		return nil, &SigningError{Err: fmt.Errorf("unsupported key format %q", s.Format)}
+	}
+	return setup, nil
+}
+
+// signedCommitCommand returns a git commit in dir that signs with signing when it is set. A nil
+// signing commits unsigned and cleanup does nothing.
+func signedCommitCommand(ctx context.Context, dir string, signing *CommitSigning, args ...string) (*exec.Cmd, func(), error) {
+	if signing == nil {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		return cmd, func() {}, nil
+	}
+	setup, err := signing.prepare(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.CommandContext(ctx, "git", append(setup.args, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), setup.env...)
+	return cmd, setup.cleanup, nil
+}
@@ -24,9 +24,10 @@ func InitializeTokenManager() {
 	}
 }
 
-// GetInstallation retrieves GitHub App installation for a user (wrapper to handlers package)
-func GetInstallation(ctx context.Context, userID string) (*handlers.GitHubAppInstallation, error) {
-	return handlers.GetGitHubInstallation(ctx, userID)
+// GetInstallation retrieves the user's GitHub App installation on owner's account, or the one linked
+// last when owner is "" or has none (wrapper to handlers package)
+func GetInstallation(ctx context.Context, userID, owner string) (*handlers.GitHubAppInstallation, error) {
+	return handlers.GetGitHubInstallationForOwner(ctx, userID, owner)
 }
 
 // MintSessionToken creates a GitHub access token for a session
@@ -37,7 +38,7 @@ func MintSessionToken(ctx context.Context, userID string) (string, time.Time, er
 	}
 
 	// Get user's GitHub installation
-	installation, err := GetInstallation(ctx, userID)
+	installation, err := GetInstallation(ctx, userID, "")
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to get GitHub installation: %w", err)
 	}
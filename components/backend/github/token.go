@@ -11,11 +11,15 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"ambient-code-backend/git"
+	"ambient-code-backend/httpclient"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -112,10 +116,25 @@ func (m *TokenManager) MintInstallationToken(ctx context.Context, installationID
 	return m.MintInstallationTokenForHost(ctx, installationID, "github.com")
 }
 
+// MintError is a non-2xx response to an installation token request
+type MintError struct {
+	Status int
+	Body   string
+}
+
+func (e *MintError) Error() string {
+	return fmt.Sprintf("GitHub token mint failed (%d): %s", e.Status, e.Body)
+}
+
+// HTTPStatus reports GitHub's response status (see git.GetGitHubToken)
+func (e *MintError) HTTPStatus() int {
+	return e.Status
+}
+
 // MintInstallationTokenForHost mints an installation token against the specified GitHub API host
 func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, installationID int64, host string) (string, time.Time, error) {
 	if m == nil {
-		return "", time.Time{}, fmt.Errorf("GitHub App not configured")
+		return "", time.Time{}, fmt.Errorf("%w: GitHub App not configured", git.ErrAppMisconfigured)
 	}
 	// Serve from cache if still valid (>3 minutes left)
 	m.cacheMu.Lock()
@@ -131,7 +150,7 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 
 	jwtToken, err := m.GenerateJWT()
 	if err != nil {
-		return "", time.Time{}, fmt.Errorf("failed to generate JWT: %w", err)
+		return "", time.Time{}, fmt.Errorf("%w: failed to generate JWT: %v", git.ErrAppMisconfigured, err)
 	}
 
 	apiBase := APIBaseURL(host)
@@ -146,15 +165,14 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 	req.Header.Set("User-Agent", "vTeam-Backend")
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to call GitHub: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", time.Time{}, fmt.Errorf("GitHub token mint failed: %s", string(body))
+		return "", time.Time{}, &MintError{Status: resp.StatusCode, Body: string(body)}
 	}
 	var parsed struct {
 		Token     string    `json:"token"`
@@ -169,20 +187,20 @@ func (m *TokenManager) MintInstallationTokenForHost(ctx context.Context, install
 	return parsed.Token, parsed.ExpiresAt, nil
 }
 
-// ValidateInstallationAccess checks if the installation has access to a repository
+// ValidateInstallationAccess checks if the installation has access to a repository. The API
+// host is taken from repo when it is a full URL (GitHub Enterprise Server); owner/repo means github.com.
 func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installationID int64, repo string) error {
 	if m == nil {
 		return fmt.Errorf("GitHub App not configured")
 	}
-	// Mint installation token (default host github.com)
-	token, _, err := m.MintInstallationTokenForHost(ctx, installationID, "github.com")
-	if err != nil {
-		return fmt.Errorf("failed to mint installation token: %w", err)
-	}
 
 	// repo should be in form "owner/repo"; tolerate full URL and trim
+	host := "github.com"
 	ownerRepo := repo
 	if strings.HasPrefix(ownerRepo, "http://") || strings.HasPrefix(ownerRepo, "https://") {
+		if u, err := url.Parse(ownerRepo); err == nil && u.Hostname() != "" {
+			host = u.Hostname()
+		}
 		// Trim protocol and host
 		// Examples: https://github.com/owner/repo(.git)?
 		// Split by "/" and take last two segments
@@ -191,6 +209,11 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 			ownerRepo = parts[len(parts)-2] + "/" + parts[len(parts)-1]
 		}
 	}
+
+	token, _, err := m.MintInstallationTokenForHost(ctx, installationID, host)
+	if err != nil {
+		return fmt.Errorf("failed to mint installation token: %w", err)
+	}
 	parts := strings.Split(ownerRepo, "/")
 	if len(parts) != 2 {
 		return fmt.Errorf("invalid repo format: expected owner/repo")
@@ -198,9 +221,9 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 	owner := parts[0]
 	name := parts[1]
 
-	apiBase := APIBaseURL("github.com")
-	url := fmt.Sprintf("%s/repos/%s/%s", apiBase, owner, name)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	apiBase := APIBaseURL(host)
+	reqURL := fmt.Sprintf("%s/repos/%s/%s", apiBase, owner, name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -209,8 +232,7 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 	req.Header.Set("User-Agent", "vTeam-Backend")
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return fmt.Errorf("GitHub request failed: %w", err)
 	}
@@ -225,8 +247,12 @@ func (m *TokenManager) ValidateInstallationAccess(ctx context.Context, installat
 	return nil
 }
 
-// APIBaseURL returns the GitHub API base URL for the given host
+// APIBaseURL returns the GitHub API base URL for the given host. GITHUB_API_BASE overrides it
+// for deployments whose GitHub App lives on GitHub Enterprise Server.
 func APIBaseURL(host string) string {
+	if base := strings.TrimRight(strings.TrimSpace(os.Getenv("GITHUB_API_BASE")), "/"); base != "" {
+		return base
+	}
 	if host == "" || host == "github.com" {
 		return "https://api.github.com"
 	}
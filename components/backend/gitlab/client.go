@@ -1,7 +1,10 @@
 package gitlab
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,8 +13,10 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"ambient-code-backend/httpclient"
 	"ambient-code-backend/types"
 	"github.com/google/uuid"
 )
@@ -28,15 +33,51 @@ type Client struct {
 	token      string
 }
 
-// NewClient creates a new GitLab API client with 15-second timeout
-func NewClient(baseURL, token string) *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: 15 * time.Second,
-		},
-		baseURL: baseURL,
-		token:   token,
+// Config describes how to reach a GitLab instance
+type Config struct {
+	APIURL                string // API base URL (e.g., "https://gitlab.example.com/api/v4")
+	Token                 string // Personal or project access token
+	InsecureSkipTLSVerify bool   // Skip server certificate verification (test instances only)
+	CABundle              []byte // PEM certificates trusted in addition to the system roots
+}
+
+// NewClient creates a new GitLab API client; requests get the httpclient.API deadline. Self-hosted
+// instances with a private CA or InsecureSkipTLSVerify get a copy of the shared transport with their
+// own TLS settings, trusting the CA on top of the shared roots; otherwise the shared client is used.
+func NewClient(cfg Config) (*Client, error) {
+	httpClient := httpclient.Client()
+	if len(cfg.CABundle) > 0 || cfg.InsecureSkipTLSVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if len(cfg.CABundle) > 0 {
+			pool := httpclient.RootCAs()
+			if pool == nil {
+				var err error
+				if pool, err = x509.SystemCertPool(); err != nil || pool == nil {
+					pool = x509.NewCertPool()
+				}
+			}
+			if !pool.AppendCertsFromPEM(cfg.CABundle) {
+				return nil, fmt.Errorf("CA bundle contains no valid PEM certificates")
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.InsecureSkipTLSVerify {
+			tlsConfig.InsecureSkipVerify = true
+		}
+		transport := httpclient.Transport().Clone()
+		transport.TLSClientConfig = tlsConfig
+		httpClient = &http.Client{Transport: transport}
 	}
+	return &Client{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(cfg.APIURL, "/"),
+		token:      cfg.Token,
+	}, nil
+}
+
+// WebURL returns the instance's web base URL (the API base without /api/v4)
+func (c *Client) WebURL() string {
+	return strings.TrimSuffix(c.baseURL, "/api/v4")
 }
 
 // doRequest performs an HTTP request with GitLab authentication
@@ -62,7 +103,7 @@ func (c *Client) doRequest(ctx context.Context, method, path string, body io.Rea
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("X-Request-ID", requestID) // Include request ID in headers for GitLab correlation
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpclient.DoWith(c.httpClient, req, httpclient.API)
 	duration := time.Since(startTime)
 
 	if err != nil {
@@ -246,6 +287,124 @@ func (c *Client) GetBranches(ctx context.Context, projectID string, page, perPag
 	return branches, pagination, nil
 }
 
+// ProjectListOptions filters the GitLab /projects API
+type ProjectListOptions struct {
+	Search     string // Matches project path and name
+	Membership bool   // Only projects the token's user is a member of
+	Page       int
+	PerPage    int
+}
+
+// ListProjects retrieves one page of the projects visible to the token, most recently active first
+func (c *Client) ListProjects(ctx context.Context, opts ProjectListOptions) ([]types.GitLabProject, *PaginationInfo, error) {
+	if opts.Page == 0 {
+		opts.Page = 1
+	}
+	if opts.PerPage == 0 {
+		opts.PerPage = 20
+	}
+	query := url.Values{}
+	query.Set("order_by", "last_activity_at")
+	query.Set("page", strconv.Itoa(opts.Page))
+	query.Set("per_page", strconv.Itoa(opts.PerPage))
+	if opts.Search != "" {
+		query.Set("search", opts.Search)
+		query.Set("search_namespaces", "true")
+	}
+	if opts.Membership {
+		query.Set("membership", "true")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", "/projects?"+query.Encode(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp); err != nil {
+		return nil, nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read projects response: %w", err)
+	}
+
+	var projects []types.GitLabProject
+	if err := json.Unmarshal(body, &projects); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse projects response: %w", err)
+	}
+
+	return projects, extractPaginationInfo(resp), nil
+}
+
+// CreateProjectOptions describes an empty project to create in an existing GitLab namespace
+type CreateProjectOptions struct {
+	Namespace   string // Full path of the user or group namespace, e.g. "group/subgroup"
+	Name        string
+	Description string
+	Visibility  string // "private", "internal" or "public"
+}
+
+// CreateProject creates an empty project. A project already at namespace/name yields a
+// *types.RepoExistsError with its clone URL.
+func (c *Client) CreateProject(ctx context.Context, opts CreateProjectOptions) (*types.GitLabProject, error) {
+	resp, err := c.doRequest(ctx, "GET", "/projects/"+EncodeProjectPath(opts.Namespace+"/"+opts.Name), nil)
+	if err != nil {
+		return nil, err
+	}
+	var existing types.GitLabProject
+	if resp.StatusCode == http.StatusOK {
+		err = json.NewDecoder(resp.Body).Decode(&existing)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse project response: %w", err)
+		}
+		return nil, &types.RepoExistsError{URL: existing.HTTPURLToRepo}
+	}
+	resp.Body.Close()
+
+	resp, err = c.doRequest(ctx, "GET", "/namespaces/"+EncodeProjectPath(opts.Namespace), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	var namespace struct {
+		ID int `json:"id"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&namespace)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse namespace response: %w", err)
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"name":         opts.Name,
+		"path":         opts.Name,
+		"namespace_id": namespace.ID,
+		"description":  opts.Description,
+		"visibility":   opts.Visibility,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode project: %w", err)
+	}
+	resp, err = c.doRequest(ctx, "POST", "/projects", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	var project types.GitLabProject
+	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
+		return nil, fmt.Errorf("failed to parse project response: %w", err)
+	}
+	return &project, nil
+}
+
 // getMaxPaginationPages returns the configured maximum pagination pages
 // Can be overridden via GITLAB_MAX_PAGINATION_PAGES environment variable
 func getMaxPaginationPages() int {
@@ -432,3 +591,35 @@ func (c *Client) GetRawFileContents(ctx context.Context, projectID, filePath, re
 
 	return body, nil
 }
+
+// Version is the response of the GitLab /version API
+type Version struct {
+	Version  string `json:"version"`
+	Revision string `json:"revision"`
+}
+
+// GetVersion retrieves the instance version. It needs a valid token, so it doubles as a
+// connectivity, TLS and credentials check.
+func (c *Client) GetVersion(ctx context.Context) (*Version, error) {
+	resp, err := c.doRequest(ctx, "GET", "/version", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version response: %w", err)
+	}
+
+	var version Version
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, fmt.Errorf("failed to parse version response: %w", err)
+	}
+
+	return &version, nil
+}
@@ -40,7 +40,10 @@ func ValidateGitLabToken(ctx context.Context, token, instanceURL string) (*Token
 
 	// Construct API URL
 	apiURL := ConstructAPIURL(ExtractHost(instanceURL))
-	client := NewClient(apiURL, token)
+	client, err := NewClient(Config{APIURL: apiURL, Token: token})
+	if err != nil {
+		return nil, err
+	}
 
 	// Call /user API to validate token
 	user, err := GetCurrentUser(ctx, client)
@@ -138,7 +141,10 @@ func ValidateTokenAndRepository(ctx context.Context, token, repoURL string) (*To
 	}
 
 	// Create client for repository access check
-	client := NewClient(parsed.APIURL, token)
+	client, err := NewClient(Config{APIURL: parsed.APIURL, Token: token})
+	if err != nil {
+		return nil, err
+	}
 
 	// Validate repository access
 	if err := ValidateRepositoryAccess(ctx, client, parsed.Owner, parsed.Repo); err != nil {
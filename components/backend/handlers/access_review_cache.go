@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// accessReviewCacheTTL is how long a SelfSubjectAccessReview result is reused for the same token and
+// request; it bounds how long a revoked permission can still be served from the read cache
+const accessReviewCacheTTL = 10 * time.Second
+
+// accessReviewCacheMaxEntries triggers a sweep of expired entries when the cache grows past it
+const accessReviewCacheMaxEntries = 5000
+
+type accessReviewEntry struct {
+	allowed bool
+	expires time.Time
+}
+
+var (
+	accessReviewCache   = make(map[string]accessReviewEntry) // sha256(token)|attributes -> allowed
+	accessReviewCacheMu sync.Mutex
+)
+
+// cachedAccessReview runs ssar with reqK8s and caches the allow or deny per caller token and
+// attributes for accessReviewCacheTTL, so repeated reads by one user cost no API call. Review
+// failures are not cached. Requests without a token are reviewed every time.
+func cachedAccessReview(c *gin.Context, reqK8s kubernetes.Interface, ssar *authv1.SelfSubjectAccessReview) (bool, error) {
+	token, _, _, _ := extractRequestToken(c)
+	key := ""
+	now := time.Now()
+	if token != "" {
+		sum := sha256.Sum256([]byte(token))
+		key = hex.EncodeToString(sum[:]) + "|" + accessReviewKey(ssar.Spec)
+		accessReviewCacheMu.Lock()
+		entry, found := accessReviewCache[key]
+		accessReviewCacheMu.Unlock()
+		if found && now.Before(entry.expires) {
+			return entry.allowed, nil
+		}
+	}
+
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	if key != "" {
+		accessReviewCacheMu.Lock()
+		if len(accessReviewCache) >= accessReviewCacheMaxEntries {
+			for k, e := range accessReviewCache {
+				if !now.Before(e.expires) {
+					delete(accessReviewCache, k)
+				}
+			}
+		}
+		accessReviewCache[key] = accessReviewEntry{allowed: res.Status.Allowed, expires: now.Add(accessReviewCacheTTL)}
+		accessReviewCacheMu.Unlock()
+	}
+	return res.Status.Allowed, nil
+}
+
+// accessReviewKey identifies the request an access review asks about
+func accessReviewKey(spec authv1.SelfSubjectAccessReviewSpec) string {
+	if ra := spec.ResourceAttributes; ra != nil {
+		return fmt.Sprintf("%s|%s|%s/%s|%s|%s", ra.Namespace, ra.Verb, ra.Group, ra.Resource, ra.Subresource, ra.Name)
+	}
+	if nra := spec.NonResourceAttributes; nra != nil {
+		return fmt.Sprintf("url|%s|%s", nra.Verb, nra.Path)
+	}
+	return ""
+}
+
+// RequireMetricsAccess guards GET /metrics: the caller's token must be allowed to get the
+// non-resource URL /metrics, as for the API server's own metrics, so Prometheus scrapes with a
+// ServiceAccount bound to a ClusterRole granting it.
+func RequireMetricsAccess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reqK8s, _ := GetK8sClientsForRequest(c)
+		if reqK8s == nil {
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+			c.Abort()
+			return
+		}
+		ssar := &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				NonResourceAttributes: &authv1.NonResourceAttributes{Path: "/metrics", Verb: "get"},
+			},
+		}
+		allowed, err := cachedAccessReview(c, reqK8s, ssar)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to check permissions", nil)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Not allowed to read metrics", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"ambient-code-backend/pathutil"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// maxSessionArtifacts caps status.artifacts so a chatty runner cannot bloat the CR
+const maxSessionArtifacts = 200
+
+// artifactWorkspaceRoot is the virtual root artifact paths are resolved against
+const artifactWorkspaceRoot = "/workspace"
+
+// normalizeArtifactPath cleans a workspace-relative artifact path and rejects anything that
+// escapes the workspace (absolute paths are accepted only under /workspace).
+func normalizeArtifactPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return "", fmt.Errorf("path is required")
+	}
+	if strings.HasPrefix(p, "/") {
+		if p != artifactWorkspaceRoot && !strings.HasPrefix(p, artifactWorkspaceRoot+"/") {
+			return "", fmt.Errorf("path %q must be relative to the workspace", p)
+		}
+		p = strings.TrimPrefix(p, artifactWorkspaceRoot)
+	}
+	abs := path.Join(artifactWorkspaceRoot, p)
+	if !pathutil.IsPathWithinBase(abs, artifactWorkspaceRoot) || abs == artifactWorkspaceRoot {
+		return "", fmt.Errorf("path %q is outside the workspace", p)
+	}
+	return strings.TrimPrefix(abs, artifactWorkspaceRoot+"/"), nil
+}
+
+// validateArtifacts normalizes runner-reported artifacts: paths must stay within the workspace,
+// sizes must not be negative, createdAt defaults to now and name to the file name
+func validateArtifacts(reported []types.SessionArtifact) ([]types.SessionArtifact, error) {
+	if len(reported) == 0 {
+		return nil, fmt.Errorf("artifacts must not be empty")
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	artifacts := make([]types.SessionArtifact, 0, len(reported))
+	for _, a := range reported {
+		p, err := normalizeArtifactPath(a.Path)
+		if err != nil {
+			return nil, err
+		}
+		if a.SizeBytes < 0 {
+			return nil, fmt.Errorf("sizeBytes for %q must not be negative", p)
+		}
+		createdAt := strings.TrimSpace(a.CreatedAt)
+		if createdAt == "" {
+			createdAt = now
+		} else if _, err := time.Parse(time.RFC3339, createdAt); err != nil {
+			return nil, fmt.Errorf("createdAt for %q must be an RFC3339 timestamp", p)
+		}
+		name := strings.TrimSpace(a.Name)
+		if name == "" {
+			name = path.Base(p)
+		}
+		artifacts = append(artifacts, types.SessionArtifact{
+			Name:        name,
+			Path:        p,
+			ContentType: strings.TrimSpace(a.ContentType),
+			SizeBytes:   a.SizeBytes,
+			CreatedAt:   createdAt,
+		})
+	}
+	return artifacts, nil
+}
+
+// parseRunnerArtifacts validates the artifacts field of a runner status report
+func parseRunnerArtifacts(v interface{}) ([]types.SessionArtifact, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts must be an array")
+	}
+	var reported []types.SessionArtifact
+	if err := json.Unmarshal(b, &reported); err != nil {
+		return nil, fmt.Errorf("artifacts must be an array of {name, path, contentType, sizeBytes, createdAt}")
+	}
+	return validateArtifacts(reported)
+}
+
+// parseArtifact converts a status.artifacts entry into its typed form
+func parseArtifact(m map[string]interface{}) types.SessionArtifact {
+	artifact := types.SessionArtifact{}
+	artifact.Name, _ = m["name"].(string)
+	artifact.Path, _ = m["path"].(string)
+	artifact.ContentType, _ = m["contentType"].(string)
+	artifact.CreatedAt, _ = m["createdAt"].(string)
+	switch v := m["sizeBytes"].(type) {
+	case int64:
+		artifact.SizeBytes = v
+	case int32:
+		artifact.SizeBytes = int64(v)
+	case float64:
+		artifact.SizeBytes = int64(v)
+	case json.Number:
+		if parsed, err := v.Int64(); err == nil {
+			artifact.SizeBytes = parsed
+		}
+	}
+	return artifact
+}
+
+// artifactDownloadURL maps an artifact onto the workspace file proxy route
+func artifactDownloadURL(project, sessionName, artifactPath string) string {
+	segments := strings.Split(artifactPath, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/workspace/%s",
+		url.PathEscape(project), url.PathEscape(sessionName), strings.Join(segments, "/"))
+}
+
+// mergeArtifacts merges artifacts into obj's status.artifacts keyed by path, keeping the existing order
+func mergeArtifacts(obj *unstructured.Unstructured, artifacts []types.SessionArtifact) error {
+	existing, _, _ := unstructured.NestedSlice(obj.Object, "status", "artifacts")
+	index := make(map[string]int, len(existing))
+	for i, e := range existing {
+		if m, ok := e.(map[string]interface{}); ok {
+			if p, _ := m["path"].(string); p != "" {
+				index[p] = i
+			}
+		}
+	}
+	for _, a := range artifacts {
+		entry := map[string]interface{}{
+			"name":      a.Name,
+			"path":      a.Path,
+			"createdAt": a.CreatedAt,
+		}
+		if a.ContentType != "" {
+			entry["contentType"] = a.ContentType
+		}
+		if a.SizeBytes > 0 {
+			entry["sizeBytes"] = a.SizeBytes
+		}
+		if i, ok := index[a.Path]; ok {
+			existing[i] = entry
+			continue
+		}
+		index[a.Path] = len(existing)
+		existing = append(existing, entry)
+	}
+	if len(existing) > maxSessionArtifacts {
+		return errTooManyArtifacts
+	}
+	return unstructured.SetNestedSlice(obj.Object, existing, "status", "artifacts")
+}
+
+// upsertArtifacts merges artifacts into the session's status.artifacts
+func upsertArtifacts(ctx context.Context, dyn dynamic.Interface, project, sessionName string, artifacts []types.SessionArtifact) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := mergeArtifacts(obj, artifacts); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// errTooManyArtifacts is returned by upsertArtifacts when the merge would exceed maxSessionArtifacts
+var errTooManyArtifacts = fmt.Errorf("at most %d artifacts may be registered per session", maxSessionArtifacts)
+
+// PublishSessionArtifacts registers runner-produced result files in status.artifacts.
+// PUT /api/projects/:projectName/agentic-sessions/:sessionName/artifacts
+// Body: { artifacts: [{ name, path, contentType?, sizeBytes?, createdAt? }] }
+// Called by the runner with its BOT_TOKEN (see authorizeSessionStatusWrite); entries with an already
+// registered path are replaced. The same entries are accepted as artifacts on PUT .../status.
+func PublishSessionArtifacts(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	k8sDyn := authorizeSessionStatusWrite(c, project, sessionName)
+	if k8sDyn == nil {
+		return
+	}
+
+	var req struct {
+		Artifacts []types.SessionArtifact `json:"artifacts" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	artifacts, err := validateArtifacts(req.Artifacts)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+
+	if err := upsertArtifacts(c.Request.Context(), k8sDyn, project, sessionName, artifacts); err != nil {
+		switch {
+		case errors.IsNotFound(err):
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "Session not found", nil)
+		case errors.IsForbidden(err):
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Not allowed to update session status", nil)
+		case err == errTooManyArtifacts:
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		default:
+			log.Printf("Failed to publish artifacts for session %s/%s: %v", project, sessionName, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to publish artifacts", nil)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Artifacts published"})
+}
+
+// ListSessionArtifacts returns the artifact registry with a workspace download URL per entry.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/artifacts
+func ListSessionArtifacts(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	gvr := GetAgenticSessionV1Alpha1Resource()
+
+	item, cached := cachedGet(c, reqK8s, project, gvr, sessionName)
+	if !cached {
+		var err error
+		item, err = k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+		if err != nil {
+			respondK8sError(c, err, "Session not found", "Failed to get agentic session")
+			return
+		}
+	}
+
+	artifacts := []types.SessionArtifact{}
+	if status, ok := item.Object["status"].(map[string]interface{}); ok {
+		if parsed := parseStatus(status); parsed != nil {
+			for _, a := range parsed.Artifacts {
+				a.DownloadURL = artifactDownloadURL(project, sessionName, a.Path)
+				artifacts = append(artifacts, a)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": artifacts})
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMaxRequestBodyBytes caps JSON request bodies. It leaves room for a session carrying an
+// initial prompt up to maxStoredPromptBytes. Override with MAX_REQUEST_BODY_BYTES.
+const defaultMaxRequestBodyBytes = 2 << 20
+
+// defaultMaxWorkspaceWriteBytes caps files written through PUT .../workspace/*path.
+// Override with MAX_WORKSPACE_WRITE_BYTES.
+const defaultMaxWorkspaceWriteBytes = 50 << 20
+
+// bodyDrainLimit is how much of an unread request body is drained after a handler returns so the
+// connection can be reused; a larger remainder is left for the server to close the connection on
+const bodyDrainLimit = 1 << 20
+
+// bodyLimitExceededKey marks a request whose body hit its limit; respondError turns the handler's
+// 400 for the unreadable body into a 413
+const bodyLimitExceededKey = "bodyLimitExceeded"
+
+// envBytes reads a positive byte count from the environment
+func envBytes(name string, def int64) int64 {
+	if raw := strings.TrimSpace(os.Getenv(name)); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// maxRequestBodyBytes returns the configured JSON request body limit
+func maxRequestBodyBytes() int64 {
+	return envBytes("MAX_REQUEST_BODY_BYTES", defaultMaxRequestBodyBytes)
+}
+
+// maxWorkspaceWriteBytes returns the configured workspace file write limit
+func maxWorkspaceWriteBytes() int64 {
+	return envBytes("MAX_WORKSPACE_WRITE_BYTES", defaultMaxWorkspaceWriteBytes)
+}
+
+// requestBodyLimit picks the body limit for a route: workspace writes get the workspace limit, the
+// content service's /content/write gets room for that file base64-encoded in JSON, and every other
+// route the JSON limit
+func requestBodyLimit(c *gin.Context) int64 {
+	path := c.FullPath()
+	switch {
+	case c.Request.Method == http.MethodPut && strings.HasSuffix(path, "/workspace/*path"):
+		return maxWorkspaceWriteBytes()
+	case path == "/content/write":
+		return maxWorkspaceWriteBytes()/3*4 + maxRequestBodyBytes()
+	default:
+		return maxRequestBodyBytes()
+	}
+}
+
+// limitedBody caps a request body and records on the context when the cap is hit
+type limitedBody struct {
+	io.ReadCloser
+	c     *gin.Context
+	limit int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.c.Set(bodyLimitExceededKey, b.limit)
+	}
+	return n, err
+}
+
+// respondBodyTooLarge writes a 413 naming the limit and closes the connection rather than reading
+// the rest of the body
+func respondBodyTooLarge(c *gin.Context, limit int64) {
+	c.Header("Connection", "close")
+	respondError(c, http.StatusRequestEntityTooLarge, ErrorKindLimitExceeded,
+		fmt.Sprintf("Request body exceeds the %d byte limit", limit), gin.H{"limitBytes": limit})
+}
+
+// RequestBodyLimit caps request bodies per route class (see requestBodyLimit). A declared
+// Content-Length over the limit is rejected with 413 before the handler runs; a body that turns
+// out larger is cut off at the limit, and the handler's error for it becomes a 413. Bodies a
+// handler left unread are drained afterwards so the connection can be reused.
+func RequestBodyLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		limit := requestBodyLimit(c)
+		if c.Request.ContentLength > limit {
+			respondBodyTooLarge(c, limit)
+			c.Abort()
+			return
+		}
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(c.Writer, c.Request.Body, limit), c: c, limit: limit}
+		c.Request.Body = body
+		c.Next()
+		if _, exceeded := c.Get(bodyLimitExceededKey); !exceeded {
+			_, _ = io.CopyN(io.Discard, body, bodyDrainLimit)
+		}
+		_ = body.Close()
+	}
+}
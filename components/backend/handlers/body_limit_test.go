@@ -0,0 +1,109 @@
+//go:build test
+
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	test_constants "ambient-code-backend/tests/constants"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request body limits", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelMiddleware), func() {
+	var (
+		router   *gin.Engine
+		received int
+	)
+
+	BeforeEach(func() {
+		GinkgoT().Setenv("MAX_REQUEST_BODY_BYTES", "64")
+		GinkgoT().Setenv("MAX_WORKSPACE_WRITE_BYTES", "256")
+		received = 0
+
+		router = gin.New()
+		api := router.Group("/api", RequestBodyLimit())
+		api.POST("/json", func(c *gin.Context) {
+			var req map[string]interface{}
+			if err := c.ShouldBindJSON(&req); err != nil {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+				return
+			}
+			c.JSON(http.StatusOK, req)
+		})
+		api.PUT("/sessions/:sessionName/workspace/*path", func(c *gin.Context) {
+			payload, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, "Failed to read file data", nil)
+				return
+			}
+			received = len(payload)
+			c.Status(http.StatusNoContent)
+		})
+	})
+
+	// send issues a request; chunked hides the length so the limit is only hit while reading
+	send := func(method, path, body string, chunked bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if chunked {
+			req.ContentLength = -1
+		}
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	errorOf := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]map[string]interface{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &body)).To(Succeed())
+		return body["error"]
+	}
+
+	It("Should pass bodies within the limit through", func() {
+		w := send(http.MethodPost, "/api/json", `{"name":"ok"}`, false)
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(MatchJSON(`{"name":"ok"}`))
+	})
+
+	It("Should reject a declared oversized body with 413 before the handler runs", func() {
+		w := send(http.MethodPost, "/api/json", `{"name":"`+strings.Repeat("x", 100)+`"}`, false)
+
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(w.Header().Get("Connection")).To(Equal("close"))
+		errObj := errorOf(w)
+		Expect(errObj["kind"]).To(Equal("LimitExceeded"))
+		Expect(errObj["message"]).To(Equal("Request body exceeds the 64 byte limit"))
+	})
+
+	It("Should turn the handler's error for a body cut off at the limit into a 413", func() {
+		w := send(http.MethodPost, "/api/json", `{"name":"`+strings.Repeat("x", 100)+`"}`, true)
+
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(errorOf(w)["message"]).To(Equal("Request body exceeds the 64 byte limit"))
+	})
+
+	It("Should apply the workspace write limit to workspace file writes", func() {
+		w := send(http.MethodPut, "/api/sessions/s1/workspace/notes.txt", strings.Repeat("x", 200), true)
+		Expect(w.Code).To(Equal(http.StatusNoContent))
+		Expect(received).To(Equal(200))
+
+		w = send(http.MethodPut, "/api/sessions/s1/workspace/notes.txt", strings.Repeat("x", 300), true)
+		Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		Expect(errorOf(w)["message"]).To(Equal("Request body exceeds the 256 byte limit"))
+	})
+
+	It("Should leave other 400s alone", func() {
+		w := send(http.MethodPost, "/api/json", `not json`, false)
+
+		Expect(w.Code).To(Equal(http.StatusBadRequest))
+		Expect(errorOf(w)["kind"]).To(Equal("Validation"))
+	})
+})
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"ambient-code-backend/git"
+
+	"github.com/gin-gonic/gin"
+)
+
+// invalidBranchError is a create request with a repo branch git would refuse
+type invalidBranchError struct {
+	field string
+	err   error
+}
+
+func (e *invalidBranchError) Error() string {
+	return fmt.Sprintf("%s %v", e.field, e.err)
+}
+
+// requireValidBranch writes 422 naming field when branch is not a legal git branch name (see
+// git.ValidateBranchName). An empty branch is left to the caller's default. The API handlers check
+// branches before they reach the content service, and the content service checks them again
+// before running git.
+func requireValidBranch(c *gin.Context, field, branch string) bool {
+	if branch == "" {
+		return true
+	}
+	if err := git.ValidateBranchName(branch); err != nil {
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation, fmt.Sprintf("%s %v", field, err), nil)
+		return false
+	}
+	return true
+}
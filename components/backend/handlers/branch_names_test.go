@@ -0,0 +1,112 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Branch Names", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	Context("git.ValidateBranchName", func() {
+		It("Should accept names git accepts", func() {
+			for _, name := range []string{"main", "feature/login", "sessions/s-1", "v1.2_fix", "user@host"} {
+				Expect(git.ValidateBranchName(name)).To(Succeed(), name)
+			}
+		})
+
+		It("Should refuse names git check-ref-format refuses", func() {
+			for _, name := range []string{
+				"", "-evil; rm -rf", "has space", "a..b", "a@{1}", "@", "ends.", "ends/", "/starts",
+				"a//b", ".hidden", "a/.hidden", "x.lock", "a/x.lock/b", "tab\tname", "q?", "star*",
+				"brack[et", "back\\slash", "tilde~1", "caret^", "co:lon",
+			} {
+				Expect(git.ValidateBranchName(name)).NotTo(Succeed(), name)
+			}
+		})
+	})
+
+	Context("CreateSession", func() {
+		It("Should answer 422 for a repo branch starting with '-'", func() {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", map[string]interface{}{
+				"initialPrompt": "x",
+				"repos":         []map[string]interface{}{{"url": "https://github.com/org/app", "branch": "-evil; rm -rf"}},
+			})
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+
+			CreateSession(c)
+
+			httpUtils.AssertErrorResponse(http.StatusUnprocessableEntity, "Validation",
+				`repos[0].branch "-evil; rm -rf" is not a valid branch name: must not start with '-'`)
+		})
+	})
+
+	Context("on a running session", func() {
+		BeforeEach(func() {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "sess", "namespace": testNamespace},
+				"spec":       map[string]interface{}{"interactive": true},
+				"status":     map[string]interface{}{"phase": "Running"},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		post := func(path string, body map[string]interface{}) *gin.Context {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/sess"+path, body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			c.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: "sess"}}
+			return c
+		}
+
+		It("Should refuse adding a repo with an invalid branch", func() {
+			AddRepo(post("/repos", map[string]interface{}{"url": "https://github.com/org/app", "branch": "a..b"}))
+
+			httpUtils.AssertErrorResponse(http.StatusUnprocessableEntity, "Validation", `branch "a..b" is not a valid branch name: must not contain '..'`)
+		})
+
+		It("Should refuse a workflow branch with a space", func() {
+			SelectWorkflow(post("/workflow", map[string]interface{}{"gitUrl": "https://github.com/org/wf", "branch": "my branch"}))
+
+			httpUtils.AssertHTTPStatus(http.StatusUnprocessableEntity)
+		})
+
+		It("Should refuse creating an invalid branch before calling the content service", func() {
+			GitCreateBranchSession(post("/git/create-branch", map[string]interface{}{"path": "artifacts", "branchName": "-evil; rm -rf"}))
+
+			httpUtils.AssertHTTPStatus(http.StatusUnprocessableEntity)
+		})
+	})
+})
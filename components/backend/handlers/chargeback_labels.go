@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// chargebackLabelPrefix prefixes the keys of ProjectSettings spec.chargebackLabels on sessions;
+// the operator copies these labels onto the runner Job and pod
+const chargebackLabelPrefix = "chargeback.ambient-code.io/"
+
+// isChargebackLabel reports whether key is a session label copied from spec.chargebackLabels
+func isChargebackLabel(key string) bool {
+	return strings.HasPrefix(key, chargebackLabelPrefix)
+}
+
+// chargebackLabelErrors returns why key and value cannot be a spec.chargebackLabels entry: the
+// prefixed key must be a qualified label name, so key itself is a name without a prefix
+func chargebackLabelErrors(key, value string) []string {
+	errs := validation.IsQualifiedName(chargebackLabelPrefix + key)
+	return append(errs, validation.IsValidLabelValue(value)...)
+}
+
+// projectChargebackLabels reads ProjectSettings spec.chargebackLabels as prefixed session labels.
+// Invalid entries, which settings applied with kubectl can contain, are skipped.
+func projectChargebackLabels(ctx context.Context, dyn dynamic.Interface, project string) (map[string]string, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := unstructuredField(obj, "spec", "chargebackLabels").(map[string]interface{})
+	lbls := map[string]string{}
+	for k, v := range raw {
+		value, _ := v.(string)
+		if errs := chargebackLabelErrors(k, value); len(errs) > 0 {
+			log.Printf("Skipping chargeback label %q in %s: %s", k, project, strings.Join(errs, "; "))
+			continue
+		}
+		lbls[chargebackLabelPrefix+k] = value
+	}
+	return lbls, nil
+}
+
+// resolveChargebackLabels is projectChargebackLabels for a create handler: on failure it writes the
+// error response and returns false
+func resolveChargebackLabels(c *gin.Context, dyn dynamic.Interface, project string) (map[string]string, bool) {
+	chargeback, err := projectChargebackLabels(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read chargeback labels in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return nil, false
+	}
+	return chargeback, true
+}
+
+// setChargebackLabels replaces any chargeback labels in a new session's metadata with chargeback
+func setChargebackLabels(metadata map[string]interface{}, chargeback map[string]string) {
+	lbls, _ := metadata["labels"].(map[string]interface{})
+	if lbls == nil {
+		lbls = map[string]interface{}{}
+	}
+	for k := range lbls {
+		if isChargebackLabel(k) {
+			delete(lbls, k)
+		}
+	}
+	for k, v := range chargeback {
+		lbls[k] = v
+	}
+	if len(lbls) > 0 {
+		metadata["labels"] = lbls
+	}
+}
@@ -2,18 +2,22 @@ package handlers
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"ambient-code-backend/git"
-	"ambient-code-backend/pathutil"
 
 	"github.com/gin-gonic/gin"
 )
@@ -25,16 +29,64 @@ var StateBaseDir string
 // Git operation functions - set by main package during initialization
 // These are set to the actual implementations from git package
 var (
-	GitPushRepo           func(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch, githubToken string) (string, error)
+	GitPushRepo           func(ctx context.Context, repoDir, commitMessage, outputRepoURL, branch string, creds git.Credentials, author git.Identity, signing *git.CommitSigning) (string, error)
+	GitHeadRef            func(ctx context.Context, repoDir string) (string, string, error)
 	GitAbandonRepo        func(ctx context.Context, repoDir string) error
 	GitDiffRepo           func(ctx context.Context, repoDir string) (*git.DiffSummary, error)
 	GitCheckMergeStatus   func(ctx context.Context, repoDir, branch string) (*git.MergeStatus, error)
-	GitPullRepo           func(ctx context.Context, repoDir, branch string) error
-	GitPushToRepo         func(ctx context.Context, repoDir, branch, commitMessage string) error
+	GitPullRepo           func(ctx context.Context, repoDir, branch string, creds git.Credentials) error
+	GitPushToRepo         func(ctx context.Context, repoDir, branch, commitMessage string, creds git.Credentials) error
 	GitCreateBranch       func(ctx context.Context, repoDir, branchName string) error
 	GitListRemoteBranches func(ctx context.Context, repoDir string) ([]string, error)
+	// GitScanSecrets is nil when the pre-push secret scan is disabled (PUSH_SECRET_SCAN=false)
+	GitScanSecrets func(ctx context.Context, repoDir string) ([]git.SecretFinding, error)
 )
 
+// contentWriteMu serializes writes so an If-Match comparison and the write that
+// follows it cannot interleave with another writer
+var contentWriteMu sync.Mutex
+
+// contentETag returns the strong ETag for file content (quoted sha256 hex)
+func contentETag(b []byte) string {
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// contentETagMaxSize is the largest file ContentRead hashes for an ETag
+const contentETagMaxSize = 64 << 20
+
+// contentETagOf returns contentETag of f's content without buffering it, then rewinds f
+func contentETagOf(f io.ReadSeeker) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`, nil
+}
+
+// ifMatchSatisfied reports whether the If-Match header value matches the file at abs.
+// "*" matches any existing file; a missing file never matches.
+func ifMatchSatisfied(ifMatch, abs string) (bool, error) {
+	current, err := os.ReadFile(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	etag := contentETag(current)
+	for _, candidate := range strings.Split(ifMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ContentGitPush handles POST /content/github/push in CONTENT_SERVICE_MODE
 func ContentGitPush(c *gin.Context) {
 	var body struct {
@@ -42,17 +94,21 @@ func ContentGitPush(c *gin.Context) {
 		CommitMessage string `json:"commitMessage"`
 		OutputRepoURL string `json:"outputRepoUrl"`
 		Branch        string `json:"branch"`
+		AllowSecrets  bool   `json:"allowSecrets"`
 	}
 	_ = c.BindJSON(&body)
-	log.Printf("contentGitPush: request received repoPath=%q outputRepoUrl=%q branch=%q commitLen=%d", body.RepoPath, body.OutputRepoURL, body.Branch, len(strings.TrimSpace(body.CommitMessage)))
+	log.Printf("contentGitPush: request received repoPath=%q outputRepoUrl=%q branch=%q commitLen=%d allowSecrets=%t", body.RepoPath, body.OutputRepoURL, body.Branch, len(strings.TrimSpace(body.CommitMessage)), body.AllowSecrets)
 
 	// Require explicit output repo URL and branch from caller
 	if strings.TrimSpace(body.OutputRepoURL) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing outputRepoUrl"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing outputRepoUrl", nil)
 		return
 	}
 	if strings.TrimSpace(body.Branch) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing branch"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing branch", nil)
+		return
+	}
+	if !requireValidBranch(c, "branch", strings.TrimSpace(body.Branch)) {
 		return
 	}
 
@@ -61,32 +117,75 @@ func ContentGitPush(c *gin.Context) {
 		repoDir = StateBaseDir
 	}
 
-	// Basic safety: repoDir must be under StateBaseDir
-	if !pathutil.IsPathWithinBase(repoDir, StateBaseDir) && repoDir != StateBaseDir {
+	// Basic safety: repoDir must be under the content root
+	if !contentPathAllowed(repoDir) {
 		log.Printf("contentGitPush: invalid repoPath resolved=%q stateBaseDir=%q", repoDir, StateBaseDir)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repoPath"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid repoPath", nil)
 		return
 	}
 
 	log.Printf("contentGitPush: using repoDir=%q (stateBaseDir=%q)", repoDir, StateBaseDir)
 
-	// Optional GitHub token provided by backend via internal header
-	gitHubToken := strings.TrimSpace(c.GetHeader("X-GitHub-Token"))
-	log.Printf("contentGitPush: tokenHeaderPresent=%t url.host.redacted=%t branch=%q", gitHubToken != "", strings.HasPrefix(body.OutputRepoURL, "https://"), body.Branch)
+	// Optional per-host credentials provided by backend via internal headers
+	creds := gitCredentialsFromRequest(c)
+	_, hasCred := creds.ForURL(body.OutputRepoURL)
+	log.Printf("contentGitPush: credentialHosts=%d outputHostCovered=%t branch=%q", len(creds), hasCred, body.Branch)
+
+	// Block changes that look like credentials unless the backend passes the caller's override.
+	// A failed scan does not block the push: it fails the same way for repos the push would skip.
+	var secretFindings []git.SecretFinding
+	if GitScanSecrets != nil {
+		findings, err := GitScanSecrets(c.Request.Context(), repoDir)
+		if err != nil {
+			log.Printf("contentGitPush: secret scan failed for %q: %v", repoDir, err)
+		}
+		if len(findings) > 0 && !body.AllowSecrets {
+			log.Printf("contentGitPush: blocked push of %q with %d secret findings", repoDir, len(findings))
+			respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation,
+				"Push blocked: the changes contain possible secrets", gin.H{"secretFindings": findings})
+			return
+		}
+		secretFindings = findings
+	}
+
+	// Commit author resolved by the backend; when absent the push resolves it from the token
+	author := gitAuthorFromHeaders(c)
 
 	// Call refactored git push function
-	out, err := GitPushRepo(c.Request.Context(), repoDir, body.CommitMessage, body.OutputRepoURL, body.Branch, gitHubToken)
+	out, err := GitPushRepo(c.Request.Context(), repoDir, body.CommitMessage, body.OutputRepoURL, body.Branch, creds, author, gitSigningFromRequest(c))
 	if err != nil {
+		var signErr *git.SigningError
+		if errors.As(err, &signErr) {
+			respondSigningFailed(c, err)
+			return
+		}
 		if out == "" {
 			// No changes to commit
 			c.JSON(http.StatusOK, gin.H{"ok": true, "message": "no changes"})
 			return
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"error": "push failed", "stderr": err.Error()})
+		log.Printf("contentGitPush: push failed for %q: %v", repoDir, err)
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to push changes to the remote repository", nil)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"ok": true, "stdout": out})
+	resp := gin.H{"ok": true, "stdout": out}
+	if len(secretFindings) > 0 {
+		resp["secretFindings"] = secretFindings
+	}
+	// Report the pushed commit so the backend can record it in session status
+	if GitHeadRef != nil {
+		if sha, branch, err := GitHeadRef(c.Request.Context(), repoDir); err == nil {
+			resp["sha"] = sha
+			if body.Branch != "auto" {
+				branch = body.Branch
+			}
+			resp["branch"] = branch
+		} else {
+			log.Printf("contentGitPush: failed to resolve pushed HEAD: %v", err)
+		}
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // ContentGitAbandon handles POST /content/github/abandon
@@ -102,16 +201,17 @@ func ContentGitAbandon(c *gin.Context) {
 		repoDir = StateBaseDir
 	}
 
-	if !pathutil.IsPathWithinBase(repoDir, StateBaseDir) && repoDir != StateBaseDir {
+	if !contentPathAllowed(repoDir) {
 		log.Printf("contentGitAbandon: invalid repoPath resolved=%q base=%q", repoDir, StateBaseDir)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repoPath"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid repoPath", nil)
 		return
 	}
 
 	log.Printf("contentGitAbandon: using repoDir=%q", repoDir)
 
 	if err := GitAbandonRepo(c.Request.Context(), repoDir); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Printf("contentGitAbandon: abandon failed for %q: %v", repoDir, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to discard changes", nil)
 		return
 	}
 
@@ -122,13 +222,13 @@ func ContentGitAbandon(c *gin.Context) {
 func ContentGitDiff(c *gin.Context) {
 	repoPath := strings.TrimSpace(c.Query("repoPath"))
 	if repoPath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing repoPath"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing repoPath", nil)
 		return
 	}
 
 	repoDir := filepath.Clean(filepath.Join(StateBaseDir, repoPath))
-	if !pathutil.IsPathWithinBase(repoDir, StateBaseDir) && repoDir != StateBaseDir {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repoPath"})
+	if !contentPathAllowed(repoDir) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid repoPath", nil)
 		return
 	}
 
@@ -161,9 +261,9 @@ func ContentGitDiff(c *gin.Context) {
 func ContentGitStatus(c *gin.Context) {
 	path := filepath.Clean("/" + strings.TrimSpace(c.Query("path")))
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
@@ -220,21 +320,24 @@ func ContentGitConfigureRemote(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
+		return
+	}
+	if !requireValidBranch(c, "branch", body.Branch) {
 		return
 	}
 
 	path := filepath.Clean("/" + body.Path)
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
 	// Check if directory exists
 	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "directory not found"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "directory not found", nil)
 		return
 	}
 
@@ -242,38 +345,47 @@ func ContentGitConfigureRemote(c *gin.Context) {
 	gitDir := filepath.Join(abs, ".git")
 	if _, err := os.Stat(gitDir); err != nil {
 		if err := git.InitRepo(c.Request.Context(), abs); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initialize git"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to initialize git", nil)
 			return
 		}
 		log.Printf("Initialized git repository at %s", abs)
 	}
 
-	// Get GitHub token and inject into URL for authentication
+	// Later git operations authenticate through the credentials each request forwards, so the
+	// remote URL is stored without a token. A backend that only sends X-GitHub-Token for a host it
+	// has no credential map for still gets the token injected into the URL.
 	remoteURL := body.RemoteURL
-	gitHubToken := strings.TrimSpace(c.GetHeader("X-GitHub-Token"))
-	if gitHubToken != "" {
-		if authenticatedURL, err := git.InjectGitHubToken(remoteURL, gitHubToken); err == nil {
-			remoteURL = authenticatedURL
-			log.Printf("Injected GitHub token into remote URL")
+	creds := gitCredentialsFromRequest(c)
+	if c.GetHeader(gitCredentialsHeader) == "" {
+		if cred, ok := creds.ForURL(remoteURL); ok {
+			if authenticatedURL, err := git.InjectGitHubToken(remoteURL, cred.Token); err == nil {
+				remoteURL = authenticatedURL
+				log.Printf("Injected GitHub token into remote URL")
+			}
 		}
 	}
 
-	// Configure remote with authenticated URL
 	if err := git.ConfigureRemote(c.Request.Context(), abs, "origin", remoteURL); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to configure remote"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to configure remote", nil)
 		return
 	}
 
 	log.Printf("Configured remote for %s: %s", abs, body.RemoteURL)
 
+	// Attribute later commits in this repo to the user the backend resolved
+	if author := gitAuthorFromHeaders(c); !author.IsZero() {
+		if err := git.ConfigureIdentity(c.Request.Context(), abs, author); err != nil {
+			log.Printf("ContentGitConfigureRemote: failed to set git identity for %s: %v", abs, err)
+		}
+	}
+
 	// Fetch from remote so merge status can be checked
 	// This is best-effort - don't fail if fetch fails
 	branch := body.Branch
 	if branch == "" {
 		branch = "main"
 	}
-	cmd := exec.CommandContext(c.Request.Context(), "git", "fetch", "origin", branch)
-	cmd.Dir = abs
+	cmd := git.Command(c.Request.Context(), abs, creds, "fetch", "origin", branch)
 	if out, err := cmd.CombinedOutput(); err != nil {
 		log.Printf("Initial fetch after configure remote failed (non-fatal): %v (output: %s)", err, string(out))
 	} else {
@@ -297,30 +409,38 @@ func ContentGitSync(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
+		return
+	}
+	if !requireValidBranch(c, "branch", body.Branch) {
 		return
 	}
 
 	path := filepath.Clean("/" + body.Path)
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
 	// Check if git repo exists
 	gitDir := filepath.Join(abs, ".git")
 	if _, err := os.Stat(gitDir); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "git repository not initialized"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "git repository not initialized", nil)
 		return
 	}
 
 	// Perform git sync operations
-	if err := git.SyncRepo(c.Request.Context(), abs, body.Message, body.Branch); err != nil {
+	if err := git.SyncRepo(c.Request.Context(), abs, body.Message, body.Branch, gitCredentialsFromRequest(c), gitSigningFromRequest(c)); err != nil {
+		var signErr *git.SigningError
+		if errors.As(err, &signErr) {
+			respondSigningFailed(c, err)
+			return
+		}
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
 		log.Printf("Internal server error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Internal server error", nil)
 		return
 	}
 
@@ -340,24 +460,24 @@ func ContentWrite(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("ContentWrite: bind JSON failed: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 	log.Printf("ContentWrite: path=%q contentLen=%d encoding=%q StateBaseDir=%q", req.Path, len(req.Content), req.Encoding, StateBaseDir)
 
 	path := filepath.Clean("/" + strings.TrimSpace(req.Path))
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
 		log.Printf("ContentWrite: path traversal attempt rejected: path=%q abs=%q", path, abs)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 	log.Printf("ContentWrite: absolute path=%q", abs)
 
 	if err := os.MkdirAll(filepath.Dir(abs), 0755); err != nil {
 		log.Printf("ContentWrite: mkdir failed for %q: %v", filepath.Dir(abs), err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create directory"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to create directory", nil)
 		return
 	}
 	var data []byte
@@ -365,19 +485,36 @@ func ContentWrite(c *gin.Context) {
 		b, err := base64.StdEncoding.DecodeString(req.Content)
 		if err != nil {
 			log.Printf("ContentWrite: base64 decode failed: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid base64 content"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid base64 content", nil)
 			return
 		}
 		data = b
 	} else {
 		data = []byte(req.Content)
 	}
+
+	contentWriteMu.Lock()
+	defer contentWriteMu.Unlock()
+	if ifMatch := strings.TrimSpace(c.GetHeader("If-Match")); ifMatch != "" {
+		ok, err := ifMatchSatisfied(ifMatch, abs)
+		if err != nil {
+			log.Printf("ContentWrite: read for If-Match failed for %q: %v", abs, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to read current file", nil)
+			return
+		}
+		if !ok {
+			log.Printf("ContentWrite: If-Match precondition failed for %q", abs)
+			respondError(c, http.StatusPreconditionFailed, ErrorKindConflict, "file was modified since it was read", nil)
+			return
+		}
+	}
 	if err := os.WriteFile(abs, data, 0644); err != nil {
 		log.Printf("ContentWrite: write failed for %q: %v", abs, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to write file"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to write file", nil)
 		return
 	}
 	log.Printf("ContentWrite: successfully wrote %d bytes to %q", len(data), abs)
+	c.Header("ETag", contentETag(data))
 	c.JSON(http.StatusOK, gin.H{"message": "ok"})
 }
 
@@ -388,26 +525,115 @@ func ContentRead(c *gin.Context) {
 	log.Printf("ContentRead: cleaned path=%q", path)
 
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
 		log.Printf("ContentRead: path traversal attempt rejected: path=%q abs=%q", path, abs)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 	log.Printf("ContentRead: absolute path=%q", abs)
 
-	b, err := os.ReadFile(abs)
+	f, err := os.Open(abs)
 	if err != nil {
 		log.Printf("ContentRead: read failed for %q: %v", abs, err)
 		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "not found", nil)
+		} else {
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "read failed", nil)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		log.Printf("ContentRead: %q is not a readable file: %v", abs, err)
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "not a file", nil)
+		return
+	}
+
+	// The ETag backs If-Match on writes; hashing is skipped for files too large to edit
+	if info.Size() <= contentETagMaxSize {
+		etag, err := contentETagOf(f)
+		if err != nil {
+			log.Printf("ContentRead: hashing %q failed: %v", abs, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "read failed", nil)
+			return
+		}
+		c.Header("ETag", etag)
+	}
+	log.Printf("ContentRead: serving %d bytes from %q", info.Size(), abs)
+	// ServeContent streams the file and answers Range requests with 206
+	c.Header("Content-Type", "application/octet-stream")
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+}
+
+// contentNotModified evaluates If-None-Match, or If-Modified-Since when no If-None-Match is sent,
+// against a file's ETag and modification time as http.ServeContent does for GET
+func contentNotModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := strings.TrimSpace(r.Header.Get("If-None-Match")); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || (etag != "" && strings.TrimPrefix(candidate, "W/") == etag) {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !modTime.Truncate(time.Second).After(t)
+		}
+	}
+	return false
+}
+
+// ContentStat handles GET /content/stat?path= -> 204 with the file's ETag (the same one
+// /content/file sends), Last-Modified and X-File-Size and no body. A file matching If-None-Match
+// or If-Modified-Since gets 304.
+func ContentStat(c *gin.Context) {
+	path := filepath.Clean("/" + strings.TrimSpace(c.Query("path")))
+	abs := filepath.Join(StateBaseDir, path)
+	if !contentPathAllowed(abs) {
+		log.Printf("ContentStat: path traversal attempt rejected: path=%q abs=%q", path, abs)
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
+		return
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "not found", nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "read failed"})
+			log.Printf("ContentStat: open failed for %q: %v", abs, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "stat failed", nil)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "not a file", nil)
+		return
+	}
+
+	etag := ""
+	if info.Size() <= contentETagMaxSize {
+		if etag, err = contentETagOf(f); err != nil {
+			log.Printf("ContentStat: hashing %q failed: %v", abs, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "stat failed", nil)
+			return
 		}
+		c.Header("ETag", etag)
+	}
+	c.Header("Last-Modified", info.ModTime().UTC().Format(http.TimeFormat))
+	if contentNotModified(c.Request, etag, info.ModTime()) {
+		c.Status(http.StatusNotModified)
+		c.Writer.WriteHeaderNow()
 		return
 	}
-	log.Printf("ContentRead: successfully read %d bytes from %q", len(b), abs)
-	c.Data(http.StatusOK, "application/octet-stream", b)
+	c.Header("X-File-Size", strconv.FormatInt(info.Size(), 10))
+	c.Status(http.StatusNoContent)
+	c.Writer.WriteHeaderNow()
 }
 
 // ContentList handles GET /content/list?path=
@@ -418,10 +644,10 @@ func ContentList(c *gin.Context) {
 	log.Printf("ContentList: StateBaseDir=%q", StateBaseDir)
 
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
 		log.Printf("ContentList: path traversal attempt rejected: path=%q abs=%q", path, abs)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 	log.Printf("ContentList: absolute path=%q", abs)
@@ -430,9 +656,9 @@ func ContentList(c *gin.Context) {
 	if err != nil {
 		log.Printf("ContentList: stat failed for %q: %v", abs, err)
 		if os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "not found", nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "stat failed"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "stat failed", nil)
 		}
 		return
 	}
@@ -449,7 +675,7 @@ func ContentList(c *gin.Context) {
 	}
 	entries, err := os.ReadDir(abs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "readdir failed"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "readdir failed", nil)
 		return
 	}
 	items := make([]gin.H, 0, len(entries))
@@ -468,11 +694,16 @@ func ContentList(c *gin.Context) {
 }
 
 // ContentWorkflowMetadata handles GET /content/workflow-metadata?session=
-// Parses .claude/commands/*.md and .claude/agents/*.md files from active workflow
+// Parses .claude/commands/*.md and .claude/agents/*.md files from active workflow, and
+// .ambient/commands and .ambient/agents of the session's cloned repos under "repos"
 func ContentWorkflowMetadata(c *gin.Context) {
 	sessionName := c.Query("session")
 	if sessionName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing session parameter"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing session parameter", nil)
+		return
+	}
+	if ContentSessionName != "" && sessionName != ContentSessionName {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "session does not match this content service", nil)
 		return
 	}
 
@@ -485,6 +716,7 @@ func ContentWorkflowMetadata(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"commands": []interface{}{},
 			"agents":   []interface{}{},
+			"repos":    workspaceRepoMetadata(sessionName),
 			"config":   gin.H{"artifactsDir": "artifacts"}, // Default platform folder when no workflow
 		})
 		return
@@ -495,69 +727,13 @@ func ContentWorkflowMetadata(c *gin.Context) {
 	// Parse ambient.json configuration
 	ambientConfig := parseAmbientConfig(workflowDir)
 
-	// Parse commands from .claude/commands/*.md
-	commandsDir := filepath.Join(workflowDir, ".claude", "commands")
-	commands := []map[string]interface{}{}
-
-	if files, err := os.ReadDir(commandsDir); err == nil {
-		for _, file := range files {
-			if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-				filePath := filepath.Join(commandsDir, file.Name())
-				metadata := parseFrontmatter(filePath)
-				commandName := strings.TrimSuffix(file.Name(), ".md")
-
-				displayName := metadata["displayName"]
-				if displayName == "" {
-					displayName = commandName
-				}
-
-				// Extract short command (last segment after final dot)
-				shortCommand := commandName
-				if lastDot := strings.LastIndex(commandName, "."); lastDot != -1 {
-					shortCommand = commandName[lastDot+1:]
-				}
-
-				commands = append(commands, map[string]interface{}{
-					"id":           commandName,
-					"name":         displayName,
-					"description":  metadata["description"],
-					"slashCommand": "/" + shortCommand,
-					"icon":         metadata["icon"],
-				})
-			}
-		}
-		log.Printf("ContentWorkflowMetadata: found %d commands", len(commands))
-	} else {
-		log.Printf("ContentWorkflowMetadata: commands directory not found or unreadable: %v", err)
-	}
-
-	// Parse agents from .claude/agents/*.md
-	agentsDir := filepath.Join(workflowDir, ".claude", "agents")
-	agents := []map[string]interface{}{}
-
-	if files, err := os.ReadDir(agentsDir); err == nil {
-		for _, file := range files {
-			if !file.IsDir() && strings.HasSuffix(file.Name(), ".md") {
-				filePath := filepath.Join(agentsDir, file.Name())
-				metadata := parseFrontmatter(filePath)
-				agentID := strings.TrimSuffix(file.Name(), ".md")
-
-				agents = append(agents, map[string]interface{}{
-					"id":          agentID,
-					"name":        metadata["name"],
-					"description": metadata["description"],
-					"tools":       metadata["tools"],
-				})
-			}
-		}
-		log.Printf("ContentWorkflowMetadata: found %d agents", len(agents))
-	} else {
-		log.Printf("ContentWorkflowMetadata: agents directory not found or unreadable: %v", err)
-	}
+	commands := parseWorkflowCommands(filepath.Join(workflowDir, ".claude", "commands"))
+	agents := parseWorkflowAgents(filepath.Join(workflowDir, ".claude", "agents"))
 
 	c.JSON(http.StatusOK, gin.H{
 		"commands": commands,
 		"agents":   agents,
+		"repos":    workspaceRepoMetadata(sessionName),
 		"config": gin.H{
 			"name":         ambientConfig.Name,
 			"description":  ambientConfig.Description,
@@ -567,6 +743,100 @@ func ContentWorkflowMetadata(c *gin.Context) {
 	})
 }
 
+// parseWorkflowCommands parses the command *.md files in dir
+func parseWorkflowCommands(dir string) []map[string]interface{} {
+	commands := []map[string]interface{}{}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("parseWorkflowCommands: commands directory %q not found or unreadable: %v", dir, err)
+		return commands
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+			continue
+		}
+		metadata := parseFrontmatter(filepath.Join(dir, file.Name()))
+		commandName := strings.TrimSuffix(file.Name(), ".md")
+
+		displayName := metadata["displayName"]
+		if displayName == "" {
+			displayName = commandName
+		}
+
+		// Extract short command (last segment after final dot)
+		shortCommand := commandName
+		if lastDot := strings.LastIndex(commandName, "."); lastDot != -1 {
+			shortCommand = commandName[lastDot+1:]
+		}
+
+		commands = append(commands, map[string]interface{}{
+			"id":           commandName,
+			"name":         displayName,
+			"description":  metadata["description"],
+			"slashCommand": "/" + shortCommand,
+			"icon":         metadata["icon"],
+		})
+	}
+	log.Printf("parseWorkflowCommands: found %d commands in %q", len(commands), dir)
+	return commands
+}
+
+// parseWorkflowAgents parses the agent *.md files in dir
+func parseWorkflowAgents(dir string) []map[string]interface{} {
+	agents := []map[string]interface{}{}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("parseWorkflowAgents: agents directory %q not found or unreadable: %v", dir, err)
+		return agents
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".md") {
+			continue
+		}
+		metadata := parseFrontmatter(filepath.Join(dir, file.Name()))
+		agents = append(agents, map[string]interface{}{
+			"id":          strings.TrimSuffix(file.Name(), ".md"),
+			"name":        metadata["name"],
+			"description": metadata["description"],
+			"tools":       metadata["tools"],
+		})
+	}
+	log.Printf("parseWorkflowAgents: found %d agents in %q", len(agents), dir)
+	return agents
+}
+
+// workspaceRepoMetadata parses .ambient/commands and .ambient/agents of each repo cloned into the
+// session workspace (a top-level directory with a .git entry), in folder name order. Repos with
+// neither are left out.
+func workspaceRepoMetadata(sessionName string) []gin.H {
+	repos := []gin.H{}
+	workspace := filepath.Join(StateBaseDir, "sessions", sessionName, "workspace")
+	entries, err := os.ReadDir(workspace)
+	if err != nil {
+		return repos
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == "workflows" || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		repoDir := filepath.Join(workspace, entry.Name())
+		if _, err := os.Stat(filepath.Join(repoDir, ".git")); err != nil {
+			continue
+		}
+		ambientDir := filepath.Join(repoDir, ".ambient")
+		if stat, err := os.Stat(ambientDir); err != nil || !stat.IsDir() {
+			continue
+		}
+		commands := parseWorkflowCommands(filepath.Join(ambientDir, "commands"))
+		agents := parseWorkflowAgents(filepath.Join(ambientDir, "agents"))
+		if len(commands) == 0 && len(agents) == 0 {
+			continue
+		}
+		repos = append(repos, gin.H{"folder": entry.Name(), "commands": commands, "agents": agents})
+	}
+	return repos
+}
+
 // parseFrontmatter extracts YAML frontmatter from a markdown file
 func parseFrontmatter(filePath string) map[string]string {
 	content, err := os.ReadFile(filePath)
@@ -677,12 +947,15 @@ func ContentGitMergeStatus(c *gin.Context) {
 	branch := strings.TrimSpace(c.Query("branch"))
 
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", branch) {
+		return
+	}
 	if branch == "" {
 		branch = "main"
 	}
@@ -705,7 +978,7 @@ func ContentGitMergeStatus(c *gin.Context) {
 		log.Printf("ContentGitMergeStatus: check failed: %v", err)
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
 		log.Printf("Internal server error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Internal server error", nil)
 		return
 	}
 
@@ -721,24 +994,28 @@ func ContentGitPull(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
 	path := filepath.Clean("/" + body.Path)
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", body.Branch) {
+		return
+	}
 	if body.Branch == "" {
 		body.Branch = "main"
 	}
 
-	if err := GitPullRepo(c.Request.Context(), abs, body.Branch); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := GitPullRepo(c.Request.Context(), abs, body.Branch, gitCredentialsFromRequest(c)); err != nil {
+		log.Printf("ContentGitPull: pull failed for %q: %v", abs, err)
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to pull from the remote repository", nil)
 		return
 	}
 
@@ -756,18 +1033,21 @@ func ContentGitPushToBranch(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
 	path := filepath.Clean("/" + body.Path)
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", body.Branch) {
+		return
+	}
 	if body.Branch == "" {
 		body.Branch = "main"
 	}
@@ -776,8 +1056,9 @@ func ContentGitPushToBranch(c *gin.Context) {
 		body.Message = "Session artifacts update"
 	}
 
-	if err := GitPushToRepo(c.Request.Context(), abs, body.Branch, body.Message); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := GitPushToRepo(c.Request.Context(), abs, body.Branch, body.Message, gitCredentialsFromRequest(c)); err != nil {
+		log.Printf("ContentGitPushToBranch: push failed for %q: %v", abs, err)
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to push changes to the remote repository", nil)
 		return
 	}
 
@@ -794,25 +1075,29 @@ func ContentGitCreateBranch(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
 	path := filepath.Clean("/" + body.Path)
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
 	if body.BranchName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "branchName is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "branchName is required", nil)
+		return
+	}
+	if !requireValidBranch(c, "branchName", body.BranchName) {
 		return
 	}
 
 	if err := GitCreateBranch(c.Request.Context(), abs, body.BranchName); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		log.Printf("ContentGitCreateBranch: create branch failed for %q: %v", abs, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create branch", nil)
 		return
 	}
 
@@ -825,9 +1110,9 @@ func ContentGitListBranches(c *gin.Context) {
 	path := filepath.Clean("/" + strings.TrimSpace(c.Query("path")))
 
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 
@@ -835,7 +1120,7 @@ func ContentGitListBranches(c *gin.Context) {
 	if err != nil {
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
 		log.Printf("Internal server error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Internal server error", nil)
 		return
 	}
 
@@ -849,17 +1134,17 @@ func ContentDelete(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("ContentDelete: bind JSON failed: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 	log.Printf("ContentDelete: path=%q StateBaseDir=%q", req.Path, StateBaseDir)
 
 	path := filepath.Clean("/" + strings.TrimSpace(req.Path))
 	abs := filepath.Join(StateBaseDir, path)
-	// Verify abs is within StateBaseDir to prevent path traversal
-	if !pathutil.IsPathWithinBase(abs, StateBaseDir) {
+	// Verify abs is within the content root to prevent path traversal
+	if !contentPathAllowed(abs) {
 		log.Printf("ContentDelete: path traversal attempt rejected: path=%q abs=%q", path, abs)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
 		return
 	}
 	log.Printf("ContentDelete: absolute path=%q", abs)
@@ -867,14 +1152,14 @@ func ContentDelete(c *gin.Context) {
 	// Check if file exists
 	if _, err := os.Stat(abs); os.IsNotExist(err) {
 		log.Printf("ContentDelete: file not found: %q", abs)
-		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "file not found", nil)
 		return
 	}
 
 	// Delete the file
 	if err := os.Remove(abs); err != nil {
 		log.Printf("ContentDelete: delete failed for %q: %v", abs, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete file"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to delete file", nil)
 		return
 	}
 
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/pathutil"
+
+	"github.com/gin-gonic/gin"
+	authnv1 "k8s.io/api/authentication/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Session served by this content service (CONTENT_SERVICE_MODE). Set by main from
+// AGENTIC_SESSION_NAME and AGENTIC_SESSION_NAMESPACE, which the operator injects into content
+// containers; when empty, as for pods created by older operators, requests are neither
+// authenticated nor scoped.
+var (
+	ContentSessionName      string
+	ContentSessionNamespace string
+)
+
+// ContentReadOnly is set from CONTENT_SERVICE_READONLY on temp content pods spawned for callers who
+// may only view the session; RequireContentWritable then rejects writes, pushes and git changes.
+var ContentReadOnly bool
+
+// ContentCallerClient builds a client authenticated as the caller's token. The content service has
+// no credentials of its own, so callers are identified and authorized with their own token.
+var ContentCallerClient = func(token string) (kubernetes.Interface, error) {
+	if BaseKubeConfig == nil {
+		return nil, fmt.Errorf("no Kubernetes API configuration")
+	}
+	cfg := *BaseKubeConfig
+	cfg.BearerToken = token
+	cfg.BearerTokenFile = ""
+	return kubernetes.NewForConfig(&cfg)
+}
+
+var (
+	errContentCallerUnauthenticated = errors.New("token rejected")
+	errContentCallerForbidden       = errors.New("not authorized for this session")
+)
+
+// contentAuthCache remembers tokens allowed for the served session (sha256(token) -> expiry)
+var (
+	contentAuthCache   = make(map[string]time.Time)
+	contentAuthCacheMu sync.Mutex
+)
+
+// RequireContentAuth authenticates content service requests when the pod serves a single session:
+// the bearer token must belong to that session's runner service account or to a user who may get
+// the session's AgenticSession. Runner service accounts of other sessions are rejected even though
+// their Role can read every session in the namespace.
+func RequireContentAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ContentSessionName == "" {
+			c.Next()
+			return
+		}
+		token, _, _, _ := extractRequestToken(c)
+		if token == "" {
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User token required", nil)
+			c.Abort()
+			return
+		}
+
+		if err := authorizeContentCaller(c.Request.Context(), token); err != nil {
+			switch {
+			case errors.Is(err, errContentCallerUnauthenticated):
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or expired token", nil)
+			case errors.Is(err, errContentCallerForbidden):
+				respondError(c, http.StatusForbidden, ErrorKindForbidden, fmt.Sprintf("Not authorized for session %s", ContentSessionName), nil)
+			default:
+				log.Printf("RequireContentAuth: failed to authorize caller for %s/%s: %v", ContentSessionNamespace, ContentSessionName, err)
+				respondUpstreamUnavailable(c, "Failed to verify token")
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireContentWritable guards content endpoints that modify the workspace or its remotes,
+// answering 403 when the pod serves the workspace read-only
+func RequireContentWritable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ContentReadOnly {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Workspace is read-only", nil)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeContentCaller identifies the token with a SelfSubjectReview (which, unlike a TokenReview,
+// needs no permissions of its own) and then applies the runner or user rule. Allowed tokens are cached
+// for tokenReviewCacheTTL.
+func authorizeContentCaller(ctx context.Context, token string) error {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+	contentAuthCacheMu.Lock()
+	expires, found := contentAuthCache[key]
+	contentAuthCacheMu.Unlock()
+	if found && now.Before(expires) {
+		return nil
+	}
+
+	client, err := ContentCallerClient(token)
+	if err != nil {
+		return err
+	}
+	review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authnv1.SelfSubjectReview{}, v1.CreateOptions{})
+	if err != nil {
+		if k8serrors.IsUnauthorized(err) {
+			return errContentCallerUnauthenticated
+		}
+		return fmt.Errorf("self subject review: %w", err)
+	}
+
+	if ns, sa, ok := serviceAccountFromUsername(review.Status.UserInfo.Username); ok && ns == ContentSessionNamespace && strings.HasPrefix(sa, "ambient-session-") {
+		if sa != fmt.Sprintf("ambient-session-%s", ContentSessionName) {
+			return errContentCallerForbidden
+		}
+	} else {
+		ssar := &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Group:     "vteam.ambient-code",
+					Resource:  "agenticsessions",
+					Verb:      "get",
+					Namespace: ContentSessionNamespace,
+					Name:      ContentSessionName,
+				},
+			},
+		}
+		res, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("access review: %w", err)
+		}
+		if !res.Status.Allowed {
+			return errContentCallerForbidden
+		}
+	}
+
+	contentAuthCacheMu.Lock()
+	if len(contentAuthCache) >= tokenReviewCacheMaxEntries {
+		for k, e := range contentAuthCache {
+			if !now.Before(e) {
+				delete(contentAuthCache, k)
+			}
+		}
+	}
+	contentAuthCache[key] = now.Add(tokenReviewCacheTTL)
+	contentAuthCacheMu.Unlock()
+	return nil
+}
+
+// contentRoot is the directory content requests may touch: the served session's directory when the
+// pod serves a single session, StateBaseDir otherwise
+func contentRoot() string {
+	if ContentSessionName != "" {
+		return filepath.Join(StateBaseDir, "sessions", ContentSessionName)
+	}
+	return StateBaseDir
+}
+
+// contentPathAllowed reports whether abs lies within contentRoot
+func contentPathAllowed(abs string) bool {
+	return pathutil.IsPathWithinBase(abs, contentRoot())
+}
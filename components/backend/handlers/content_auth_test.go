@@ -0,0 +1,191 @@
+//go:build test
+
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Content Service Auth", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelContent), func() {
+	var (
+		httpUtils        *test_utils.HTTPTestUtils
+		tempStateDir     string
+		originalStateDir string
+		originalCaller   func(token string) (kubernetes.Interface, error)
+		// token -> username returned by the SelfSubjectReview; unknown tokens are rejected
+		identities map[string]string
+		// tokens whose SelfSubjectAccessReview is allowed
+		readers map[string]bool
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		var err error
+		tempStateDir, err = os.MkdirTemp("", "content-auth-test-*")
+		Expect(err).NotTo(HaveOccurred())
+		originalStateDir = StateBaseDir
+		originalCaller = ContentCallerClient
+		StateBaseDir = tempStateDir
+		ContentSessionName = "s1"
+		ContentSessionNamespace = "project-a"
+		contentAuthCacheMu.Lock()
+		contentAuthCache = make(map[string]time.Time)
+		contentAuthCacheMu.Unlock()
+
+		identities = map[string]string{
+			"runner-s1":   "system:serviceaccount:project-a:ambient-session-s1",
+			"runner-s2":   "system:serviceaccount:project-a:ambient-session-s2",
+			"user-reader": "alice",
+			"user-other":  "bob",
+		}
+		readers = map[string]bool{"user-reader": true}
+		ContentCallerClient = func(token string) (kubernetes.Interface, error) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				username, ok := identities[token]
+				if !ok {
+					return true, nil, k8serrors.NewUnauthorized("invalid token")
+				}
+				review := &authnv1.SelfSubjectReview{}
+				review.Status.UserInfo.Username = username
+				return true, review, nil
+			})
+			client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				ssar := action.(k8stesting.CreateAction).GetObject().(*authv1.SelfSubjectAccessReview)
+				attrs := ssar.Spec.ResourceAttributes
+				allowed := readers[token] && attrs.Namespace == "project-a" && attrs.Name == "s1" && attrs.Verb == "get"
+				ssar.Status.Allowed = allowed
+				return true, ssar, nil
+			})
+			return client, nil
+		}
+
+		Expect(os.MkdirAll(filepath.Join(tempStateDir, "sessions", "s1", "workspace"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(tempStateDir, "sessions", "s2", "workspace"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(tempStateDir, "sessions", "s1", "workspace", "a.txt"), []byte("s1"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(tempStateDir, "sessions", "s2", "workspace", "a.txt"), []byte("s2"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		StateBaseDir = originalStateDir
+		ContentCallerClient = originalCaller
+		ContentSessionName = ""
+		ContentSessionNamespace = ""
+		os.RemoveAll(tempStateDir)
+	})
+
+	authorize := func(token string) (aborted bool) {
+		c := httpUtils.CreateTestGinContext("GET", "/content/file?path=/sessions/s1/workspace/a.txt", nil)
+		if token != "" {
+			httpUtils.SetAuthHeader(token)
+		}
+		RequireContentAuth()(c)
+		return c.IsAborted()
+	}
+
+	It("Should reject a runner token for a different session with 403", func() {
+		Expect(authorize("runner-s2")).To(BeTrue())
+		httpUtils.AssertHTTPStatus(http.StatusForbidden)
+	})
+
+	It("Should accept the served session's runner token", func() {
+		Expect(authorize("runner-s1")).To(BeFalse())
+	})
+
+	It("Should accept a user who may get the session and reject one who may not", func() {
+		Expect(authorize("user-reader")).To(BeFalse())
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		Expect(authorize("user-other")).To(BeTrue())
+		httpUtils.AssertHTTPStatus(http.StatusForbidden)
+	})
+
+	It("Should return 401 for missing and invalid tokens", func() {
+		Expect(authorize("")).To(BeTrue())
+		httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		Expect(authorize("garbage")).To(BeTrue())
+		httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
+	})
+
+	It("Should fail closed when the API server cannot be reached", func() {
+		ContentCallerClient = func(token string) (kubernetes.Interface, error) {
+			client := fake.NewSimpleClientset()
+			client.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, k8serrors.NewServiceUnavailable("apiserver down")
+			})
+			return client, nil
+		}
+		Expect(authorize("runner-s1")).To(BeTrue())
+		httpUtils.AssertHTTPStatus(http.StatusServiceUnavailable)
+	})
+
+	It("Should cache allowed tokens", func() {
+		calls := 0
+		inner := ContentCallerClient
+		ContentCallerClient = func(token string) (kubernetes.Interface, error) {
+			calls++
+			return inner(token)
+		}
+		Expect(authorize("runner-s1")).To(BeFalse())
+		httpUtils = test_utils.NewHTTPTestUtils()
+		Expect(authorize("runner-s1")).To(BeFalse())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("Should not authenticate pods that serve no particular session", func() {
+		ContentSessionName = ""
+		Expect(authorize("")).To(BeFalse())
+	})
+
+	It("Should only serve paths of the served session", func() {
+		c := httpUtils.CreateTestGinContext("GET", "/content/file?path=/sessions/s1/workspace/a.txt", nil)
+		ContentRead(c)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+
+		for _, path := range []string{"/sessions/s2/workspace/a.txt", "/sessions/s1/../s2/workspace/a.txt", "/"} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c = httpUtils.CreateTestGinContext("GET", "/content/file?path="+path, nil)
+			ContentRead(c)
+			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+		}
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c = httpUtils.CreateTestGinContext("GET", "/content/workflow-metadata?session=s2", nil)
+		ContentWorkflowMetadata(c)
+		httpUtils.AssertHTTPStatus(http.StatusForbidden)
+	})
+
+	It("Should refuse writes on a read-only pod and allow them otherwise", func() {
+		DeferCleanup(func() { ContentReadOnly = false })
+		guard := func() (aborted bool) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/content/write", map[string]string{"path": "/sessions/s1/workspace/a.txt"})
+			RequireContentWritable()(c)
+			return c.IsAborted()
+		}
+
+		Expect(guard()).To(BeFalse())
+
+		ContentReadOnly = true
+		Expect(guard()).To(BeTrue())
+		httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Workspace is read-only")
+	})
+})
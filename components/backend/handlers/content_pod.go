@@ -0,0 +1,434 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultContentPodReadyTimeout bounds how long a request waits for an on-demand temp content pod.
+// Override with CONTENT_POD_READY_TIMEOUT_SECONDS.
+const defaultContentPodReadyTimeout = 60 * time.Second
+
+// tempContentReadOnlyAnnotation asks the operator for a temp content pod that serves the workspace
+// read-only (CONTENT_SERVICE_READONLY); the operator copies it onto the pod
+const tempContentReadOnlyAnnotation = "ambient-code.io/temp-content-readonly"
+
+// contentPodPollInterval is how often the temp content pod is checked for readiness
+var contentPodPollInterval = time.Second
+
+// contentEndpoint is a resolved content service base URL for a session
+type contentEndpoint struct {
+	BaseURL string
+	// PodSpawned is true when the temp content pod had to be started for this request
+	PodSpawned bool
+	// bearerToken replaces the caller's credentials when set (see sign)
+	bearerToken string
+}
+
+// contentPodReadyTimeout returns the configured readiness timeout for spawned temp content pods
+func contentPodReadyTimeout() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("CONTENT_POD_READY_TIMEOUT_SECONDS")); raw != "" {
+		if secs, err := strconv.Atoi(raw); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultContentPodReadyTimeout
+}
+
+// isPodReady reports whether the pod is running, not terminating and passes its readiness probe
+func isPodReady(pod *corev1.Pod) bool {
+	if pod == nil || pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// tempPodReadOnly reports whether a temp content pod was started read-only
+func tempPodReadOnly(pod *corev1.Pod) bool {
+	return pod != nil && pod.Annotations[tempContentReadOnlyAnnotation] == "true"
+}
+
+// canUpdateSessions reports whether the caller may update agenticsessions in the project, which
+// separates editors from viewers
+func canUpdateSessions(ctx context.Context, userClient kubernetes.Interface, project string) (bool, error) {
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+				Verb:      "update",
+				Namespace: project,
+			},
+		},
+	}
+	res, err := userClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return res.Status.Allowed, nil
+}
+
+// requestTempContentPod annotates the session so the operator starts its temp content pod, writable
+// when canEdit (see canUpdateSessions) and read-only otherwise. Viewers cannot update the session, so
+// their request is written with the backend service account after the caller has read the session;
+// it never turns a pod someone else requested read-only. An editor's request replaces a read-only
+// pod. With touch, the last-accessed time is refreshed even when nothing else changes. Returns the
+// session as stored.
+func requestTempContentPod(ctx context.Context, reqDyn dynamic.Interface, project string, item *unstructured.Unstructured, canEdit, touch bool) (*unstructured.Unstructured, error) {
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	requested := annotations["ambient-code.io/temp-content-requested"] == "true"
+	changed := !requested
+	if canEdit && annotations[tempContentReadOnlyAnnotation] == "true" {
+		annotations[tempContentReadOnlyAnnotation] = "false"
+		changed = true
+	} else if !requested {
+		annotations[tempContentReadOnlyAnnotation] = strconv.FormatBool(!canEdit)
+	}
+	if !changed && !touch {
+		return item, nil
+	}
+	annotations["ambient-code.io/temp-content-requested"] = "true"
+	annotations["ambient-code.io/temp-content-last-accessed"] = time.Now().UTC().Format(time.RFC3339)
+	item.SetAnnotations(annotations)
+
+	dyn := reqDyn
+	if !canEdit {
+		if DynamicClient == nil {
+			return nil, fmt.Errorf("backend client not initialized")
+		}
+		dyn = DynamicClient
+	}
+	return dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Update(ctx, item, v1.UpdateOptions{})
+}
+
+// contentPodStatus summarizes a temp content pod for 503 responses
+func contentPodStatus(pod *corev1.Pod) gin.H {
+	if pod == nil {
+		return gin.H{"phase": "NotCreated"}
+	}
+	status := gin.H{"name": pod.Name, "phase": string(pod.Status.Phase)}
+	if pod.DeletionTimestamp != nil {
+		status["phase"] = "Terminating"
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.State.Waiting != nil {
+			status["reason"] = cs.State.Waiting.Reason
+			status["message"] = cs.State.Waiting.Message
+		} else if cs.State.Terminated != nil {
+			status["reason"] = cs.State.Terminated.Reason
+			status["message"] = cs.State.Terminated.Message
+		}
+	}
+	if _, ok := status["reason"]; !ok {
+		for _, cond := range pod.Status.Conditions {
+			if cond.Status != corev1.ConditionTrue && cond.Reason != "" {
+				status["reason"] = cond.Reason
+				status["message"] = cond.Message
+				break
+			}
+		}
+	}
+	return status
+}
+
+// maxContentPodEvents is the number of recent warning events reported for a content pod
+const maxContentPodEvents = 5
+
+// workspaceClaimUser returns a pod other than the session's temp content pod that mounts the
+// session's workspace PVC and has not finished. The PVC is ReadWriteOnce, so a temp content pod
+// created meanwhile would wait in ContainerCreating until that pod is gone.
+func workspaceClaimUser(ctx context.Context, k8s kubernetes.Interface, project, session string) (*corev1.Pod, error) {
+	claim := sessionPVCName(session)
+	pods, err := k8s.CoreV1().Pods(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Name == fmt.Sprintf("temp-content-%s", session) || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == claim {
+				return pod, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// requireWorkspaceClaimFree answers 409 with the pod still mounting the session's workspace instead
+// of requesting a temp content pod that could not start. A failed lookup is logged and not blocking.
+func requireWorkspaceClaimFree(c *gin.Context, k8s kubernetes.Interface, project, session string) bool {
+	pod, err := workspaceClaimUser(c.Request.Context(), k8s, project, session)
+	if err != nil {
+		log.Printf("requireWorkspaceClaimFree: failed to list pods in %s: %v", project, err)
+		return true
+	}
+	if pod == nil {
+		return true
+	}
+	status := contentPodStatus(pod)
+	respondError(c, http.StatusConflict, ErrorKindConflict,
+		fmt.Sprintf("Workspace is still in use by pod %s (%s); try again once it has finished", pod.Name, status["phase"]),
+		gin.H{"blockingPod": status, "claim": sessionPVCName(session)})
+	return false
+}
+
+// contentPodEvents returns the most recent warning events of a pod, such as FailedScheduling,
+// FailedAttachVolume or FailedMount, which explain why it is not ready. Lookup failures yield none.
+func contentPodEvents(ctx context.Context, k8s kubernetes.Interface, pod *corev1.Pod) []gin.H {
+	if pod == nil {
+		return nil
+	}
+	list, err := k8s.CoreV1().Events(pod.Namespace).List(ctx, v1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s,type=%s", pod.Name, corev1.EventTypeWarning),
+	})
+	if err != nil {
+		log.Printf("contentPodEvents: failed to list events of %s/%s: %v", pod.Namespace, pod.Name, err)
+		return nil
+	}
+	var events []corev1.Event
+	for _, ev := range list.Items {
+		if ev.InvolvedObject.Name == pod.Name && ev.Type == corev1.EventTypeWarning {
+			events = append(events, ev)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return eventTime(events[i]).After(eventTime(events[j])) })
+	if len(events) > maxContentPodEvents {
+		events = events[:maxContentPodEvents]
+	}
+	out := make([]gin.H, 0, len(events))
+	for _, ev := range events {
+		out = append(out, gin.H{"reason": ev.Reason, "message": ev.Message, "lastTimestamp": eventTime(ev).UTC().Format(time.RFC3339)})
+	}
+	return out
+}
+
+// eventTime is when an event last occurred
+func eventTime(ev corev1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	}
+	return ev.CreationTimestamp.Time
+}
+
+// resolveContentEndpoint finds a content service for the session. When neither the temp nor the
+// per-job service exists and spawn is set, it requests the temp content pod (as
+// EnableWorkspaceAccess does) and waits for it to become ready. Only the diff/push endpoints pass
+// spawn; status polling must not start pods, so without it a missing workspace is a 503.
+// On failure it writes the error response and returns false.
+func resolveContentEndpoint(c *gin.Context, project, session string, spawn bool) (contentEndpoint, bool) {
+	ctx := c.Request.Context()
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return contentEndpoint{}, false
+	}
+
+	// resolved maps a content Service through ContentResolver, responding 503 on failure
+	resolved := func(svc string, podSpawned bool) (contentEndpoint, bool) {
+		endpoint, err := resolveContentService(ctx, project, session, svc)
+		if err != nil {
+			log.Printf("resolveContentEndpoint: failed to resolve %s for %s/%s: %v", svc, project, session, err)
+			respondUpstreamUnavailable(c, "Content service unavailable")
+			return contentEndpoint{}, false
+		}
+		endpoint.PodSpawned = podSpawned
+		return endpoint, true
+	}
+
+	tempService := fmt.Sprintf("temp-content-%s", session)
+	for _, svc := range []string{tempService, fmt.Sprintf("ambient-content-%s", session)} {
+		if _, err := reqK8s.CoreV1().Services(project).Get(ctx, svc, v1.GetOptions{}); err == nil {
+			return resolved(svc, false)
+		}
+	}
+
+	// endpointForPod prefers a temp service if one exists, falling back to the pod IP
+	endpointForPod := func(pod *corev1.Pod, podSpawned bool) (contentEndpoint, bool) {
+		if _, err := reqK8s.CoreV1().Services(project).Get(ctx, tempService, v1.GetOptions{}); err == nil {
+			return resolved(tempService, podSpawned)
+		}
+		return contentEndpoint{BaseURL: "http://" + net.JoinHostPort(pod.Status.PodIP, contentServicePort), PodSpawned: podSpawned}, true
+	}
+
+	tempPodName := fmt.Sprintf("temp-content-%s", session)
+	pod, err := reqK8s.CoreV1().Pods(project).Get(ctx, tempPodName, v1.GetOptions{})
+	// canEdit is only looked up when it decides something: a read-only pod serves editors only
+	// until they need to spawn, and then is replaced by a writable one
+	var canEdit *bool
+	callerCanEdit := func() (bool, error) {
+		if canEdit == nil {
+			allowed, err := canUpdateSessions(ctx, reqK8s, project)
+			if err != nil {
+				return false, err
+			}
+			canEdit = &allowed
+		}
+		return *canEdit, nil
+	}
+	if err == nil && isPodReady(pod) {
+		if !spawn || !tempPodReadOnly(pod) {
+			return endpointForPod(pod, false)
+		}
+		if allowed, err := callerCanEdit(); err != nil || !allowed {
+			return endpointForPod(pod, false)
+		}
+	}
+	if !spawn {
+		respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, "Workspace not available", nil)
+		return contentEndpoint{}, false
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(ctx, session, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "Session not found", nil)
+		} else {
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to get session", nil)
+		}
+		return contentEndpoint{}, false
+	}
+
+	// Temp content pods are only for finished sessions: the runner owns the workspace while the
+	// session is active, and Pending/Queued sessions have no workspace to read yet
+	phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+	switch phase {
+	case "Stopped", "Completed", "Failed":
+	case "Running", "Creating", "Stopping":
+		respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, "Content service unavailable", gin.H{"phase": phase})
+		return contentEndpoint{}, false
+	default:
+		respondError(c, http.StatusConflict, ErrorKindConflict, "Workspace not available", gin.H{"phase": phase})
+		return contentEndpoint{}, false
+	}
+
+	if !requireWorkspaceClaimFree(c, reqK8s, project, session) {
+		return contentEndpoint{}, false
+	}
+
+	writable, err := callerCanEdit()
+	if err != nil {
+		log.Printf("resolveContentEndpoint: access review failed for %s/%s: %v", project, session, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+		return contentEndpoint{}, false
+	}
+	if _, err := requestTempContentPod(ctx, reqDyn, project, item, writable, false); err != nil && !errors.IsConflict(err) {
+		// A conflict means a concurrent request updated the session (likely also requesting the pod)
+		log.Printf("resolveContentEndpoint: failed to request temp pod for %s/%s: %v", project, session, err)
+		respondUpstreamUnavailable(c, "Failed to request content pod")
+		return contentEndpoint{}, false
+	}
+	log.Printf("resolveContentEndpoint: requested temp content pod for %s/%s (readOnly=%v)", project, session, !writable)
+
+	timeout := contentPodReadyTimeout()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(contentPodPollInterval)
+	defer ticker.Stop()
+
+	var lastPod *corev1.Pod
+	for {
+		if p, err := reqK8s.CoreV1().Pods(project).Get(ctx, tempPodName, v1.GetOptions{}); err == nil {
+			lastPod = p
+			// An editor waits for the writable pod that replaces a read-only one
+			if isPodReady(p) && (!writable || !tempPodReadOnly(p)) {
+				log.Printf("resolveContentEndpoint: temp content pod ready for %s/%s", project, session)
+				return endpointForPod(p, true)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			log.Printf("resolveContentEndpoint: temp content pod for %s/%s not ready after %v", project, session, timeout)
+			podStatus := contentPodStatus(lastPod)
+			if events := contentPodEvents(ctx, reqK8s, lastPod); len(events) > 0 {
+				podStatus["events"] = events
+			}
+			respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable,
+				fmt.Sprintf("Content pod did not become ready within %v", timeout),
+				gin.H{"podStatus": podStatus})
+			return contentEndpoint{}, false
+		case <-ctx.Done():
+			respondUpstreamUnavailable(c, "Request cancelled while waiting for content pod")
+			return contentEndpoint{}, false
+		}
+	}
+}
+
+// withPodSpawned adds podSpawned=true to a JSON object response body when a temp content pod
+// was started for the request. Non-object bodies are returned unchanged.
+func withPodSpawned(body []byte, spawned bool) []byte {
+	if !spawned {
+		return body
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil || obj == nil {
+		return body
+	}
+	obj["podSpawned"] = true
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// respondContentServiceError maps a non-2xx content service response onto the error taxonomy.
+// The content service's message is kept when it sent a structured error; any other body
+// (plain text, legacy shapes, git output) is replaced with a generic message.
+func respondContentServiceError(c *gin.Context, code int, body []byte, podSpawned bool) {
+	msg := "Content service request failed"
+	var upstream struct {
+		Error struct {
+			Kind    ErrorKind `json:"kind"`
+			Message string    `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &upstream); err == nil && strings.TrimSpace(upstream.Error.Message) != "" {
+		msg = upstream.Error.Message
+	}
+	// The status alone cannot tell a signing failure from other rejected requests
+	kind := errorKindForStatus(code)
+	if upstream.Error.Kind == ErrorKindSigningFailed {
+		kind = ErrorKindSigningFailed
+	}
+	var details gin.H
+	if podSpawned {
+		details = gin.H{"podSpawned": true}
+	}
+	respondError(c, code, kind, msg, details)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/tracing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// contentServicePort is the port content services and temp content pods listen on
+const contentServicePort = "8080"
+
+// contentServiceHostPattern matches the in-cluster DNS name of a content Service, <service>.<namespace>.svc
+var contentServiceHostPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?\.[a-z0-9]([-a-z0-9]*[a-z0-9])?\.svc(\.cluster\.local)?$`)
+
+// ContentServiceTarget is where the backend sends requests for one content Service
+type ContentServiceTarget struct {
+	BaseURL string
+	// BearerToken, when set, replaces the caller's credentials on requests to BaseURL
+	BearerToken string
+}
+
+// ContentServiceResolver maps a session's content Service (temp-content-<session> or
+// ambient-content-<session>) to the address the backend reaches it at
+type ContentServiceResolver interface {
+	ResolveContentService(ctx context.Context, project, session, service string) (ContentServiceTarget, error)
+}
+
+// ContentResolver resolves content Services for all content proxy handlers.
+// Set by main; defaults to in-cluster Service DNS.
+var ContentResolver ContentServiceResolver = InClusterContentResolver{}
+
+// InClusterContentResolver addresses content Services through the cluster's Service DNS, for
+// backends running in the same cluster as the sessions
+type InClusterContentResolver struct{}
+
+// ResolveContentService implements ContentServiceResolver
+func (InClusterContentResolver) ResolveContentService(_ context.Context, project, _, service string) (ContentServiceTarget, error) {
+	for _, name := range []string{project, service} {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			return ContentServiceTarget{}, fmt.Errorf("invalid content service name %q: %s", name, strings.Join(errs, "; "))
+		}
+	}
+	return ContentServiceTarget{BaseURL: (&url.URL{Scheme: "http", Host: net.JoinHostPort(service+"."+project+".svc", contentServicePort)}).String()}, nil
+}
+
+// ProjectGatewayContentResolver routes projects whose ProjectSettings set
+// spec.contentServiceGateway through that gateway and signs the requests with the session's runner
+// token so the gateway can authorize them. Other projects use Fallback.
+type ProjectGatewayContentResolver struct {
+	Dynamic  dynamic.Interface    // reads ProjectSettings (backend service account)
+	Secrets  kubernetes.Interface // reads runner token Secrets (backend service account)
+	Fallback ContentServiceResolver
+}
+
+// NewProjectGatewayContentResolver returns a gateway-aware resolver using the backend service account
+func NewProjectGatewayContentResolver(dyn dynamic.Interface, k8s kubernetes.Interface, fallback ContentServiceResolver) *ProjectGatewayContentResolver {
+	return &ProjectGatewayContentResolver{Dynamic: dyn, Secrets: k8s, Fallback: fallback}
+}
+
+// ResolveContentService implements ContentServiceResolver
+func (r *ProjectGatewayContentResolver) ResolveContentService(ctx context.Context, project, session, service string) (ContentServiceTarget, error) {
+	gateway, err := r.projectGateway(ctx, project)
+	if err != nil {
+		return ContentServiceTarget{}, err
+	}
+	if gateway == "" {
+		return r.Fallback.ResolveContentService(ctx, project, session, service)
+	}
+
+	sec, err := r.Secrets.CoreV1().Secrets(project).Get(ctx, runnerTokenSecretName(session), v1.GetOptions{})
+	if err != nil {
+		return ContentServiceTarget{}, fmt.Errorf("read runner token for %s/%s: %w", project, session, err)
+	}
+	token := strings.TrimSpace(string(sec.Data[runnerTokenSecretKey]))
+	if token == "" {
+		return ContentServiceTarget{}, fmt.Errorf("runner token secret for %s/%s has no %s", project, session, runnerTokenSecretKey)
+	}
+	return ContentServiceTarget{BaseURL: expandContentServiceGateway(gateway, project, session, service), BearerToken: token}, nil
+}
+
+// projectGateway reads spec.contentServiceGateway, from SessionReadCache when available
+func (r *ProjectGatewayContentResolver) projectGateway(ctx context.Context, project string) (string, error) {
+	var obj *unstructured.Unstructured
+	ok := false
+	if SessionReadCache != nil {
+		obj, ok = SessionReadCache.Get(project, GetProjectSettingsResource(), "projectsettings")
+	}
+	if !ok {
+		var err error
+		obj, err = r.Dynamic.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("read ProjectSettings in %s: %w", project, err)
+		}
+	}
+	gateway, _, _ := unstructured.NestedString(obj.Object, "spec", "contentServiceGateway")
+	return strings.TrimSpace(gateway), nil
+}
+
+// expandContentServiceGateway fills the {project}, {session} and {service} placeholders of a
+// gateway URL template (e.g. https://gw.example.com/{project}/{service}) and drops a trailing slash
+func expandContentServiceGateway(template, project, session, service string) string {
+	return strings.TrimRight(strings.NewReplacer(
+		"{project}", url.PathEscape(project),
+		"{session}", url.PathEscape(session),
+		"{service}", url.PathEscape(service),
+	).Replace(template), "/")
+}
+
+// resolveContentService resolves a content Service through ContentResolver
+func resolveContentService(ctx context.Context, project, session, service string) (contentEndpoint, error) {
+	target, err := ContentResolver.ResolveContentService(ctx, project, session, service)
+	if err != nil {
+		return contentEndpoint{}, err
+	}
+	return contentEndpoint{BaseURL: target.BaseURL, bearerToken: target.BearerToken}, nil
+}
+
+// sign applies the endpoint's credentials to outgoing content service request headers. Gateway
+// endpoints carry the runner token instead of the caller's token, which must not leave the cluster.
+func (e contentEndpoint) sign(h http.Header) {
+	if e.bearerToken == "" {
+		return
+	}
+	h.Set("Authorization", "Bearer "+e.bearerToken)
+	h.Del("X-Forwarded-Access-Token")
+}
+
+// url builds the URL of a content service endpoint such as "/content/list" with query values.
+// Endpoint segments are escaped and joined to BaseURL, so they cannot change its host or climb out
+// of a gateway's path, and BaseURL must address a content Service (<service>.<namespace>.svc) or a
+// pod IP unless it is a gateway configured in ProjectSettings.
+func (e contentEndpoint) url(endpoint string, query url.Values) (string, error) {
+	base, err := url.Parse(e.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid content service URL: %w", err)
+	}
+	if (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" || base.User != nil || base.RawQuery != "" || base.Fragment != "" {
+		return "", fmt.Errorf("invalid content service URL %q", e.BaseURL)
+	}
+	if host := base.Hostname(); e.bearerToken == "" && net.ParseIP(host) == nil && !contentServiceHostPattern.MatchString(host) {
+		return "", fmt.Errorf("content service host %q is not an in-cluster service", host)
+	}
+	var segments []string
+	for _, seg := range strings.Split(strings.Trim(endpoint, "/"), "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid content service endpoint %q", endpoint)
+		}
+		segments = append(segments, url.PathEscape(seg))
+	}
+	u := base.JoinPath(segments...)
+	u.RawQuery = query.Encode()
+	if u.Host != base.Host {
+		return "", fmt.Errorf("content service endpoint %q changes the host", endpoint)
+	}
+	return u.String(), nil
+}
+
+// newRequest creates a request for a content service endpoint (see url). It is not signed. The
+// trace in ctx, if any, is propagated in the traceparent header.
+func (e contentEndpoint) newRequest(ctx context.Context, method, endpoint string, query url.Values, body io.Reader) (*http.Request, error) {
+	u, err := e.url(endpoint, query)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	tracing.Inject(ctx, req.Header)
+	return req, nil
+}
@@ -0,0 +1,322 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Content Service Resolver", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelContent), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testToken     string
+		resolver      *ProjectGatewayContentResolver
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		resolver = NewProjectGatewayContentResolver(k8sUtils.DynamicClient, k8sUtils.K8sClient, InClusterContentResolver{})
+		previous := ContentResolver
+		ContentResolver = resolver
+		DeferCleanup(func() { ContentResolver = previous })
+	})
+
+	setGateway := func(gateway string) {
+		_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       map[string]interface{}{"contentServiceGateway": gateway},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createRunnerToken := func(session, token string) {
+		_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-runner-token-" + session, Namespace: testNamespace},
+			Data:       map[string][]byte{runnerTokenSecretKey: []byte(token)},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("Should expand the gateway template placeholders", func() {
+		Expect(expandContentServiceGateway("https://gw.example.com/{project}/{service}/", "proj", "s1", "ambient-content-s1")).
+			To(Equal("https://gw.example.com/proj/ambient-content-s1"))
+		Expect(expandContentServiceGateway("https://{session}.gw.example.com", "proj", "s1", "svc")).
+			To(Equal("https://s1.gw.example.com"))
+	})
+
+	It("Should use in-cluster DNS for projects without a gateway", func() {
+		target, err := resolver.ResolveContentService(ctx, testNamespace, "s1", "ambient-content-s1")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(target.BaseURL).To(Equal("http://ambient-content-s1." + testNamespace + ".svc:8080"))
+		Expect(target.BearerToken).To(BeEmpty())
+	})
+
+	It("Should reject names that are not DNS labels for in-cluster DNS", func() {
+		for _, service := range []string{"evil.example.com#", "a/b", "svc:9090", "UPPER"} {
+			_, err := resolver.ResolveContentService(ctx, testNamespace, "s1", service)
+			Expect(err).To(HaveOccurred(), service)
+		}
+		_, err := resolver.ResolveContentService(ctx, "proj@evil.example.com", "s1", "ambient-content-s1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should escape hostile query values so they round-trip", func() {
+		hostile := "/sessions/s1/workspace/my dir/a#b%20c&path=/etc/passwd?.md"
+		content := contentEndpoint{BaseURL: "http://ambient-content-s1." + testNamespace + ".svc:8080"}
+
+		u, err := content.url("/content/file", url.Values{"path": {hostile}})
+
+		Expect(err).NotTo(HaveOccurred())
+		parsed, err := url.Parse(u)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Host).To(Equal("ambient-content-s1." + testNamespace + ".svc:8080"))
+		Expect(parsed.Path).To(Equal("/content/file"))
+		Expect(parsed.Query()["path"]).To(Equal([]string{hostile}))
+		Expect(parsed.Fragment).To(BeEmpty())
+	})
+
+	It("Should keep endpoints on the resolved host and under the gateway path", func() {
+		gateway := contentEndpoint{BaseURL: "https://gw.example.com/proj/ambient-content-s1", bearerToken: "runner-sa-token"}
+
+		u, err := gateway.url("//evil.example.com/content", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u).To(Equal("https://gw.example.com/proj/ambient-content-s1/evil.example.com/content"))
+
+		u, err = gateway.url("/content/a b#c?d", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u).To(Equal("https://gw.example.com/proj/ambient-content-s1/content/a%20b%23c%3Fd"))
+
+		for _, endpoint := range []string{"/content/../../admin", "/./content", ""} {
+			_, err := gateway.url(endpoint, nil)
+			Expect(err).To(HaveOccurred(), endpoint)
+		}
+	})
+
+	It("Should refuse base URLs that do not address a content service", func() {
+		for _, base := range []string{
+			"http://evil.example.com:8080",
+			"http://user@10.0.0.12:8080",
+			"file:///etc/passwd",
+			"http://ambient-content-s1.proj.svc:8080?x=1",
+			"http://ambient-content-s1.proj.svc.evil.example.com:8080",
+		} {
+			_, err := contentEndpoint{BaseURL: base}.url("/content/list", nil)
+			Expect(err).To(HaveOccurred(), base)
+		}
+		for _, base := range []string{"http://10.0.0.12:8080", "http://[fd00::12]:8080", "http://temp-content-s1.proj.svc:8080"} {
+			_, err := contentEndpoint{BaseURL: base}.url("/content/list", nil)
+			Expect(err).NotTo(HaveOccurred(), base)
+		}
+	})
+
+	It("Should fail when a gateway project has no runner token", func() {
+		setGateway("https://gw.example.com/{project}/{service}")
+
+		_, err := resolver.ResolveContentService(ctx, testNamespace, "s1", "ambient-content-s1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should route workspace reads through the gateway with the runner token", func() {
+		var gotPath, gotAuth, gotForwarded string
+		gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotAuth = r.Header.Get("Authorization")
+			gotForwarded = r.Header.Get("X-Forwarded-Access-Token")
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("hello"))
+		}))
+		DeferCleanup(gateway.Close)
+		setGateway(gateway.URL + "/{project}/{service}")
+		createRunnerToken("s1", "runner-sa-token")
+
+		context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/s1/workspace/README.md", nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Request.Header.Set("X-Forwarded-Access-Token", testToken)
+		context.Params = gin.Params{
+			{Key: "projectName", Value: testNamespace},
+			{Key: "sessionName", Value: "s1"},
+			{Key: "path", Value: "/README.md"},
+		}
+		GetSessionWorkspaceFile(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(httpUtils.GetResponseRecorder().Body.String()).To(Equal("hello"))
+		Expect(gotPath).To(Equal("/" + testNamespace + "/ambient-content-s1/content/file"))
+		Expect(gotAuth).To(Equal("Bearer runner-sa-token"))
+		Expect(gotForwarded).To(BeEmpty())
+	})
+
+	It("Should answer HEAD on a workspace file from the content service stat", func() {
+		var gotPath, gotQuery string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath, gotQuery = r.URL.Path, r.URL.Query().Get("path")
+			w.Header().Set("ETag", `"abc"`)
+			w.Header().Set("X-File-Size", "42")
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		DeferCleanup(upstream.Close)
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+
+		context := httpUtils.CreateTestGinContext("HEAD", "/api/projects/"+testNamespace+"/agentic-sessions/s1/workspace/spec.md", nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{
+			{Key: "projectName", Value: testNamespace},
+			{Key: "sessionName", Value: "s1"},
+			{Key: "path", Value: "/spec.md"},
+		}
+		HeadSessionWorkspaceFile(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(gotPath).To(Equal("/content/stat"))
+		Expect(gotQuery).To(Equal("/sessions/s1/workspace/spec.md"))
+		Expect(httpUtils.GetResponseRecorder().Header().Get("Content-Length")).To(Equal("42"))
+		Expect(httpUtils.GetResponseRecorder().Header().Get("ETag")).To(Equal(`"abc"`))
+		Expect(httpUtils.GetResponseRecorder().Body.Len()).To(BeZero())
+	})
+
+	It("Should pass workspace paths with spaces, '#' and '%' to the content service intact", func() {
+		var gotPath, gotQuery string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath, gotQuery = r.URL.Path, r.URL.Query().Get("path")
+			_, _ = w.Write([]byte("ok"))
+		}))
+		DeferCleanup(upstream.Close)
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+
+		context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/s1/workspace/x", nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{
+			{Key: "projectName", Value: testNamespace},
+			{Key: "sessionName", Value: "s1"},
+			{Key: "path", Value: "/my notes/#1 100%.md"},
+		}
+		GetSessionWorkspaceFile(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(gotPath).To(Equal("/content/file"))
+		Expect(gotQuery).To(Equal("/sessions/s1/workspace/my notes/#1 100%.md"))
+	})
+
+	It("Should merge repo agents and commands into the workflow metadata with their source", func() {
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{
+				"commands": [{"id": "wf.review", "slashCommand": "/review"}],
+				"agents": [{"id": "architect"}],
+				"config": {"artifactsDir": "artifacts"},
+				"repos": [
+					{"folder": "a-shared", "commands": [{"id": "team.review", "slashCommand": "/review"}], "agents": [{"id": "reviewer"}]},
+					{"folder": "b-tools", "commands": [], "agents": [{"id": "reviewer"}, {"id": "architect"}]}
+				]
+			}`))
+		}))
+		DeferCleanup(upstream.Close)
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+
+		context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/s1/workflow/metadata", nil)
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: "s1"}}
+		GetWorkflowMetadata(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var response struct {
+			Commands []map[string]interface{} `json:"commands"`
+			Agents   []map[string]interface{} `json:"agents"`
+			Shadowed struct {
+				Commands []map[string]interface{} `json:"commands"`
+				Agents   []map[string]interface{} `json:"agents"`
+			} `json:"shadowed"`
+		}
+		httpUtils.GetResponseJSON(&response)
+		Expect(response.Commands).To(Equal([]map[string]interface{}{{"id": "wf.review", "slashCommand": "/review", "source": "workflow"}}))
+		Expect(response.Agents).To(Equal([]map[string]interface{}{
+			{"id": "architect", "source": "workflow"},
+			{"id": "reviewer", "source": "repo:a-shared"},
+		}))
+		Expect(response.Shadowed.Commands).To(Equal([]map[string]interface{}{
+			{"id": "team.review", "slashCommand": "/review", "source": "repo:a-shared", "shadowedBy": "workflow"},
+		}))
+		Expect(response.Shadowed.Agents).To(Equal([]map[string]interface{}{
+			{"id": "reviewer", "source": "repo:b-tools", "shadowedBy": "repo:a-shared"},
+			{"id": "architect", "source": "repo:b-tools", "shadowedBy": "workflow"},
+		}))
+	})
+
+	It("Should forward a caller token sent only as X-Forwarded-Access-Token as Authorization", func() {
+		var gotAuth, gotForwarded []string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Values("Authorization")
+			gotForwarded = r.Header.Values("X-Forwarded-Access-Token")
+			_, _ = w.Write([]byte(`{"items":[]}`))
+		}))
+		DeferCleanup(upstream.Close)
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+
+		for _, call := range []struct {
+			path    string
+			handler gin.HandlerFunc
+		}{
+			{"/workspace/README.md", GetSessionWorkspaceFile},
+			{"/workspace", ListSessionWorkspace},
+			{"/workflow/metadata", GetWorkflowMetadata},
+		} {
+			gotAuth, gotForwarded = nil, nil
+			httpUtils = test_utils.NewHTTPTestUtils()
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/s1"+call.path, nil)
+			context.Request.Header.Set("X-Forwarded-Access-Token", testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{
+				{Key: "projectName", Value: testNamespace},
+				{Key: "sessionName", Value: "s1"},
+				{Key: "path", Value: "/README.md"},
+			}
+			call.handler(context)
+
+			Expect(gotAuth).To(Equal([]string{"Bearer " + testToken}), call.path)
+			Expect(gotForwarded).To(BeEmpty(), call.path)
+		}
+	})
+})
+
+// fixedContentResolver sends every content Service request to one test server
+type fixedContentResolver struct{ baseURL string }
+
+func (r fixedContentResolver) ResolveContentService(context.Context, string, string, string) (ContentServiceTarget, error) {
+	return ContentServiceTarget{BaseURL: r.baseURL}, nil
+}
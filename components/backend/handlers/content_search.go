@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// minWorkspaceSearchQuery is the shortest query /content/search and the workspace-search proxy accept
+	minWorkspaceSearchQuery = 3
+	// defaultWorkspaceSearchResults and maxWorkspaceSearchResults bound maxResults
+	defaultWorkspaceSearchResults = 100
+	maxWorkspaceSearchResults     = 500
+	// contentSearchMatchesPerFile caps the matches returned from one file, so one generated file
+	// cannot crowd out the rest of the workspace
+	contentSearchMatchesPerFile = 20
+	// contentSearchMaxFileSize skips files too large to be source; they are usually data or build output
+	contentSearchMaxFileSize = 2 << 20
+	// contentSearchSnippetMax truncates snippet lines, e.g. minified code
+	contentSearchSnippetMax = 240
+)
+
+// contentSearchBudget is the wall-clock time a search may take before it returns what it found
+// with partial set. A variable so tests can shorten it.
+var contentSearchBudget = 2 * time.Second
+
+// workspaceSearchMatch is one matching line of a /content/search result
+type workspaceSearchMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	// Snippet is the matching line with up to one line of context either side
+	Snippet string `json:"snippet"`
+	Text    string `json:"text"`
+}
+
+// compileWorkspaceSearch compiles q as a regular expression, or as a literal when regex is false
+func compileWorkspaceSearch(q string, regex bool) (*regexp.Regexp, error) {
+	if !regex {
+		q = regexp.QuoteMeta(q)
+	}
+	return regexp.Compile(q)
+}
+
+// searchFile returns up to limit matches of re in the file at abs, or nil for binary files
+func searchFile(abs, rel string, re *regexp.Regexp, limit int) ([]workspaceSearchMatch, error) {
+	f, err := os.Open(abs)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, 64*1024)
+	// A NUL byte in the first block marks a binary file, as for git and grep
+	head, err := r.Peek(8000)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var matches []workspaceSearchMatch
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), contentSearchMaxFileSize)
+	prev := ""
+	pendingAfter := -1
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if pendingAfter >= 0 {
+			matches[pendingAfter].Snippet += "\n" + truncateSnippet(line)
+			pendingAfter = -1
+		}
+		if len(matches) < limit && re.MatchString(line) {
+			snippet := truncateSnippet(line)
+			if n > 1 {
+				snippet = truncateSnippet(prev) + "\n" + snippet
+			}
+			matches = append(matches, workspaceSearchMatch{Path: rel, Line: n, Snippet: snippet, Text: truncateSnippet(line)})
+			pendingAfter = len(matches) - 1
+		} else if len(matches) >= limit && pendingAfter < 0 {
+			break
+		}
+		prev = line
+	}
+	return matches, scanner.Err()
+}
+
+func truncateSnippet(s string) string {
+	if len(s) <= contentSearchSnippetMax {
+		return s
+	}
+	return strings.ToValidUTF8(s[:contentSearchSnippetMax], "") + "…"
+}
+
+// searchWorkspace walks root for files matching re, skipping .git, symlinks, binary and oversized
+// files. It stops at maxResults or when ctx is done, returning partial=true. Files are ranked by
+// how often they match, then whether their name matches, then path; lines keep file order.
+func searchWorkspace(ctx context.Context, root string, re *regexp.Regexp, maxResults int) ([]workspaceSearchMatch, int, bool) {
+	type fileHits struct {
+		rel       string
+		matches   []workspaceSearchMatch
+		nameMatch bool
+	}
+	var hits []fileHits
+	total, scanned, partial := 0, 0, false
+	errStop := errors.New("stop")
+
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			partial = true
+			return errStop
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && p != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > contentSearchMaxFileSize {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		matches, err := searchFile(p, rel, re, contentSearchMatchesPerFile)
+		scanned++
+		if err != nil {
+			log.Printf("ContentSearch: skipping %q: %v", p, err)
+			return nil
+		}
+		if len(matches) == 0 {
+			return nil
+		}
+		if total+len(matches) > maxResults {
+			matches = matches[:maxResults-total]
+			partial = true
+		}
+		hits = append(hits, fileHits{rel: rel, matches: matches, nameMatch: re.MatchString(d.Name())})
+		total += len(matches)
+		if total >= maxResults {
+			partial = true
+			return errStop
+		}
+		return nil
+	})
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if len(hits[i].matches) != len(hits[j].matches) {
+			return len(hits[i].matches) > len(hits[j].matches)
+		}
+		if hits[i].nameMatch != hits[j].nameMatch {
+			return hits[i].nameMatch
+		}
+		return hits[i].rel < hits[j].rel
+	})
+	out := make([]workspaceSearchMatch, 0, total)
+	for _, h := range hits {
+		out = append(out, h.matches...)
+	}
+	return out, scanned, partial
+}
+
+// ContentSearch handles GET /content/search?path=&q=&maxResults=&regex= -> the lines of files under
+// path matching q (a literal, or an RE2 expression with regex=true), with partial set when the
+// result limit or the wall-clock budget cut the search short.
+func ContentSearch(c *gin.Context) {
+	q := c.Query("q")
+	if len(strings.TrimSpace(q)) < minWorkspaceSearchQuery {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("q must be at least %d characters", minWorkspaceSearchQuery), nil)
+		return
+	}
+	maxResults := defaultWorkspaceSearchResults
+	if v := c.Query("maxResults"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxWorkspaceSearchResults {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("maxResults must be between 1 and %d", maxWorkspaceSearchResults), nil)
+			return
+		}
+		maxResults = n
+	}
+	re, err := compileWorkspaceSearch(q, c.Query("regex") == "true")
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid regular expression: "+err.Error(), nil)
+		return
+	}
+
+	path := filepath.Clean("/" + strings.TrimSpace(c.Query("path")))
+	abs := filepath.Join(StateBaseDir, path)
+	if !contentPathAllowed(abs) {
+		log.Printf("ContentSearch: path traversal attempt rejected: path=%q abs=%q", path, abs)
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid path", nil)
+		return
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			respondError(c, http.StatusNotFound, ErrorKindNotFound, "not found", nil)
+		} else {
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "stat failed", nil)
+		}
+		return
+	}
+	if !info.IsDir() {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "not a directory", nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), contentSearchBudget)
+	defer cancel()
+	start := time.Now()
+	matches, scanned, partial := searchWorkspace(ctx, abs, re, maxResults)
+	log.Printf("ContentSearch: %d matches in %d files under %q in %s (partial=%v)", len(matches), scanned, path, time.Since(start).Round(time.Millisecond), partial)
+	c.JSON(http.StatusOK, gin.H{"matches": matches, "filesScanned": scanned, "partial": partial})
+}
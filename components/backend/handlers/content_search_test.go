@@ -0,0 +1,178 @@
+//go:build test
+
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Workspace Search", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelContent), func() {
+	var (
+		httpUtils *test_utils.HTTPTestUtils
+		workspace string
+	)
+
+	type searchResponse struct {
+		Matches      []workspaceSearchMatch `json:"matches"`
+		FilesScanned int                    `json:"filesScanned"`
+		Partial      bool                   `json:"partial"`
+	}
+
+	write := func(rel, content string) {
+		p := filepath.Join(workspace, rel)
+		Expect(os.MkdirAll(filepath.Dir(p), 0755)).To(Succeed())
+		Expect(os.WriteFile(p, []byte(content), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		stateDir, err := os.MkdirTemp("", "content-search-*")
+		Expect(err).NotTo(HaveOccurred())
+		originalStateDir := StateBaseDir
+		StateBaseDir = stateDir
+		DeferCleanup(func() {
+			StateBaseDir = originalStateDir
+			_ = os.RemoveAll(stateDir)
+		})
+		workspace = filepath.Join(stateDir, "sessions", "s1", "workspace")
+
+		write("main.go", "package main\n\n// TODO: handle errors\nfunc main() {}\n")
+		write("pkg/util.go", "package pkg\n// TODO: one\n// TODO: two\nfunc Util() {}\n")
+		write(".git/config", "TODO inside git metadata\n")
+		write("image.bin", "TODO\x00binary")
+	})
+
+	search := func(query string) searchResponse {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/content/search?path=/sessions/s1/workspace&"+query, nil)
+		ContentSearch(c)
+		var resp searchResponse
+		if httpUtils.GetResponseRecorder().Code == http.StatusOK {
+			httpUtils.GetResponseJSON(&resp)
+		}
+		return resp
+	}
+
+	Context("ContentSearch", func() {
+		It("Should return matches with line and context, ranked by matches per file, skipping .git and binary files", func() {
+			resp := search("q=TODO")
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(resp.Partial).To(BeFalse())
+			Expect(resp.Matches).To(HaveLen(3))
+			Expect(resp.Matches[0].Path).To(Equal("pkg/util.go"))
+			Expect(resp.Matches[0].Line).To(Equal(2))
+			Expect(resp.Matches[1].Line).To(Equal(3))
+			Expect(resp.Matches[2].Path).To(Equal("main.go"))
+			Expect(resp.Matches[2].Text).To(Equal("// TODO: handle errors"))
+			Expect(resp.Matches[2].Snippet).To(Equal("\n// TODO: handle errors\nfunc main() {}"))
+		})
+
+		It("Should treat q as a literal unless regex=true", func() {
+			Expect(search("q=func+.*%28%29").Matches).To(BeEmpty())
+
+			resp := search("q=func+%5BA-Z%5D%5Cw%2B&regex=true")
+			Expect(resp.Matches).To(HaveLen(1))
+			Expect(resp.Matches[0].Path).To(Equal("pkg/util.go"))
+		})
+
+		It("Should stop at maxResults and flag the result partial", func() {
+			resp := search("q=TODO&maxResults=1")
+
+			Expect(resp.Matches).To(HaveLen(1))
+			Expect(resp.Partial).To(BeTrue())
+		})
+
+		It("Should return what it found with partial set when the budget runs out", func() {
+			saved := contentSearchBudget
+			contentSearchBudget = 0
+			DeferCleanup(func() { contentSearchBudget = saved })
+
+			resp := search("q=TODO")
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(resp.Partial).To(BeTrue())
+		})
+
+		It("Should reject short queries and invalid expressions and keep paths in the content root", func() {
+			search("q=ab")
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "q must be at least 3 characters")
+
+			search("q=%28unclosed&regex=true")
+			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/content/search?path=/../../etc&q=root", nil)
+			ContentSearch(c)
+			// Cleaned to <root>/etc, which does not exist
+			httpUtils.AssertHTTPStatus(http.StatusNotFound)
+		})
+
+		It("Should return only the per-file match limit from one file", func() {
+			write("generated.txt", strings.Repeat("TODO\n", contentSearchMatchesPerFile*3))
+
+			resp := search("q=TODO&maxResults=500")
+
+			n := 0
+			for _, m := range resp.Matches {
+				if m.Path == "generated.txt" {
+					n++
+				}
+			}
+			Expect(n).To(Equal(contentSearchMatchesPerFile))
+		})
+	})
+
+	Context("SearchSessionWorkspace", func() {
+		proxy := func(query string) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/projects/p1/agentic-sessions/s1/workspace-search?"+query, nil)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext("p1")
+			c.Set("userID", "alice")
+			SearchSessionWorkspace(c)
+		}
+
+		It("Should enforce the minimum query length and a workspace-relative path", func() {
+			proxy("q=ab")
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "q must be at least 3 characters")
+
+			proxy("q=TODO&path=../../other-session")
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid path: must be within workspace directory")
+
+			proxy("q=TODO&maxResults=5000")
+			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+		})
+
+		It("Should rate limit searches per user", func() {
+			saved := workspaceSearchLimiter
+			workspaceSearchLimiter = &searchRateLimiter{windows: map[string]*searchWindow{}}
+			DeferCleanup(func() { workspaceSearchLimiter = saved })
+			now := time.Now()
+			for i := 0; i < workspaceSearchLimit; i++ {
+				ok, _ := workspaceSearchLimiter.allow("alice", now, workspaceSearchLimit, workspaceSearchWindow)
+				Expect(ok).To(BeTrue())
+			}
+
+			proxy("q=TODO")
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusTooManyRequests, "LimitExceeded", "Too many workspace searches; at most 20 per 1m0s")
+			Expect(errorObj["details"]).To(HaveKey("retryAfterSeconds"))
+			Expect(httpUtils.GetResponseRecorder().Header().Get("Retry-After")).NotTo(BeEmpty())
+			ok, _ := workspaceSearchLimiter.allow("bob", now, workspaceSearchLimit, workspaceSearchWindow)
+			Expect(ok).To(BeTrue(), "other users keep their own budget")
+			ok, _ = workspaceSearchLimiter.allow("alice", now.Add(workspaceSearchWindow), workspaceSearchLimit, workspaceSearchWindow)
+			Expect(ok).To(BeTrue(), "the window resets")
+		})
+	})
+})
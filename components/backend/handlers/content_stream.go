@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"ambient-code-backend/httpclient"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contentStreamIdleTimeout aborts a streamed content service read once the upstream has sent nothing
+// for this long; unlike a request timeout it does not cap the duration of large downloads
+const contentStreamIdleTimeout = 30 * time.Second
+
+// contentStreamErrorBodyLimit bounds how much of an upstream error body is read for its message
+const contentStreamErrorBodyLimit = 64 << 10
+
+// contentStreamHeaders are copied from a successful content service response
+var contentStreamHeaders = []string{"Content-Type", "Content-Length", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified"}
+
+// contentConditionalHeaders are forwarded to the content service so unchanged files cost a 304
+var contentConditionalHeaders = []string{"If-None-Match", "If-Modified-Since"}
+
+// contentStreamHeaderTimeout bounds the wait for a streamed response's headers; the body has no
+// overall deadline and is bounded by contentStreamIdleTimeout instead
+const contentStreamHeaderTimeout = 10 * time.Second
+
+// idleTimeoutReader cancels its request when no Read returns data within timeout
+type idleTimeoutReader struct {
+	r       io.Reader
+	timeout time.Duration
+	timer   *time.Timer
+	once    sync.Once
+}
+
+func newIdleTimeoutReader(r io.Reader, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutReader {
+	return &idleTimeoutReader{r: r, timeout: timeout, timer: time.AfterFunc(timeout, cancel)}
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+func (r *idleTimeoutReader) stop() {
+	r.once.Do(func() { r.timer.Stop() })
+}
+
+// streamContentServiceResponse sends req to the content service and copies the response to the
+// client as it arrives, so memory stays flat regardless of file size. Range, If-Range and the
+// conditional headers are forwarded from the incoming request; 206 responses pass through with
+// their Content-Range and 304 responses without a body. Error responses are mapped with
+// respondContentServiceError.
+func streamContentServiceResponse(c *gin.Context, req *http.Request) {
+	copyRequestHeaders(c, req, "Range", "If-Range")
+	copyRequestHeaders(c, req, contentConditionalHeaders...)
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	headerTimer := time.AfterFunc(contentStreamHeaderTimeout, cancel)
+	resp, err := httpclient.Do(req, httpclient.Stream)
+	headerTimer.Stop()
+	if err != nil {
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		respondNotModified(c, resp)
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, contentStreamErrorBodyLimit))
+		log.Printf("Content service returned error status %d for %s", resp.StatusCode, req.URL.Path)
+		if cr := resp.Header.Get("Content-Range"); cr != "" {
+			c.Header("Content-Range", cr)
+		}
+		respondContentServiceError(c, resp.StatusCode, b, false)
+		return
+	}
+
+	for _, h := range contentStreamHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			c.Header(h, v)
+		}
+	}
+	c.Status(resp.StatusCode)
+
+	body := newIdleTimeoutReader(resp.Body, contentStreamIdleTimeout, cancel)
+	defer body.stop()
+	if n, err := io.Copy(c.Writer, body); err != nil {
+		// Headers are already sent; the client sees a truncated body
+		log.Printf("Content stream for %s aborted after %d bytes: %v", req.URL.Path, n, err)
+	}
+}
+
+// proxyContentStat sends a /content/stat request and answers a HEAD with the file's metadata:
+// Content-Length from X-File-Size, ETag and Last-Modified. A 304 passes through.
+func proxyContentStat(c *gin.Context, req *http.Request) {
+	copyRequestHeaders(c, req, contentConditionalHeaders...)
+	resp, err := httpclient.Do(req, httpclient.Read)
+	if err != nil {
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		respondNotModified(c, resp)
+	case http.StatusNoContent:
+		for _, h := range []string{"ETag", "Last-Modified"} {
+			if v := resp.Header.Get(h); v != "" {
+				c.Header(h, v)
+			}
+		}
+		c.Header("Content-Type", "application/octet-stream")
+		if size := resp.Header.Get("X-File-Size"); size != "" {
+			c.Header("Content-Length", size)
+		}
+		c.Status(http.StatusOK)
+		c.Writer.WriteHeaderNow()
+	default:
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, contentStreamErrorBodyLimit))
+		log.Printf("Content service returned status %d for %s", resp.StatusCode, req.URL.Path)
+		respondContentServiceError(c, resp.StatusCode, b, false)
+	}
+}
+
+// copyRequestHeaders copies the named headers of the incoming request that are set onto req
+func copyRequestHeaders(c *gin.Context, req *http.Request, headers ...string) {
+	for _, h := range headers {
+		if v := c.GetHeader(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+}
+
+// respondNotModified answers 304 with the validators of the upstream 304 and no body
+func respondNotModified(c *gin.Context, resp *http.Response) {
+	for _, h := range []string{"ETag", "Last-Modified"} {
+		if v := resp.Header.Get(h); v != "" {
+			c.Header(h, v)
+		}
+	}
+	c.Status(http.StatusNotModified)
+	c.Writer.WriteHeaderNow()
+}
@@ -0,0 +1,143 @@
+//go:build test
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Content Stream Proxy", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelContent), func() {
+	var (
+		httpUtils *test_utils.HTTPTestUtils
+		upstream  *httptest.Server
+		payload   []byte
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		payload = bytes.Repeat([]byte("0123456789abcdef"), 1<<16) // 1 MiB
+		upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("path") == "/missing" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"error":{"kind":"NotFound","message":"not found"}}`))
+				return
+			}
+			if r.URL.Path == "/content/stat" {
+				w.Header().Set("ETag", `"abc"`)
+				w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+				if r.Header.Get("If-None-Match") == `"abc"` {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("X-File-Size", "1048576")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("ETag", `"abc"`)
+			http.ServeContent(w, r, "big.log", time.Time{}, bytes.NewReader(payload))
+		}))
+		DeferCleanup(upstream.Close)
+	})
+
+	proxy := func(path, rangeHeader string) {
+		context := httpUtils.CreateTestGinContext("GET", "/workspace/big.log", nil)
+		if rangeHeader != "" {
+			context.Request.Header.Set("Range", rangeHeader)
+		}
+		req, err := http.NewRequestWithContext(context.Request.Context(), http.MethodGet, upstream.URL+"/content/file?path="+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		streamContentServiceResponse(context, req)
+	}
+
+	It("Should stream the whole file with upstream length and type", func() {
+		proxy("/big.log", "")
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		recorder := httpUtils.GetResponseRecorder()
+		Expect(recorder.Body.Len()).To(Equal(len(payload)))
+		Expect(recorder.Header().Get("Content-Length")).To(Equal("1048576"))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/octet-stream"))
+		Expect(recorder.Header().Get("ETag")).To(Equal(`"abc"`))
+	})
+
+	It("Should pass Range through and return 206", func() {
+		proxy("/big.log", "bytes=-16")
+
+		httpUtils.AssertHTTPStatus(http.StatusPartialContent)
+		Expect(httpUtils.GetResponseBody()).To(Equal("0123456789abcdef"))
+		Expect(httpUtils.GetResponseRecorder().Header().Get("Content-Range")).To(Equal("bytes 1048560-1048575/1048576"))
+	})
+
+	It("Should pass a 304 for a matching If-None-Match through without a body", func() {
+		context := httpUtils.CreateTestGinContext("GET", "/workspace/big.log", nil)
+		context.Request.Header.Set("If-None-Match", `"abc"`)
+		req, err := http.NewRequestWithContext(context.Request.Context(), http.MethodGet, upstream.URL+"/content/file?path=/big.log", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		streamContentServiceResponse(context, req)
+
+		httpUtils.AssertHTTPStatus(http.StatusNotModified)
+		Expect(httpUtils.GetResponseBody()).To(BeEmpty())
+		Expect(httpUtils.GetResponseRecorder().Header().Get("ETag")).To(Equal(`"abc"`))
+	})
+
+	Describe("proxyContentStat", func() {
+		stat := func(ifNoneMatch string) {
+			context := httpUtils.CreateTestGinContext("HEAD", "/workspace/big.log", nil)
+			if ifNoneMatch != "" {
+				context.Request.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			req, err := http.NewRequestWithContext(context.Request.Context(), http.MethodGet, upstream.URL+"/content/stat?path=/big.log", nil)
+			Expect(err).NotTo(HaveOccurred())
+			proxyContentStat(context, req)
+		}
+
+		It("Should answer with the file metadata headers only", func() {
+			stat("")
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			header := httpUtils.GetResponseRecorder().Header()
+			Expect(header.Get("Content-Length")).To(Equal("1048576"))
+			Expect(header.Get("ETag")).To(Equal(`"abc"`))
+			Expect(header.Get("Last-Modified")).To(Equal("Mon, 02 Jan 2006 15:04:05 GMT"))
+			Expect(httpUtils.GetResponseBody()).To(BeEmpty())
+		})
+
+		It("Should pass a 304 through", func() {
+			stat(`"abc"`)
+
+			httpUtils.AssertHTTPStatus(http.StatusNotModified)
+		})
+	})
+
+	It("Should map upstream errors onto the error taxonomy", func() {
+		proxy("/missing", "")
+
+		httpUtils.AssertErrorResponse(http.StatusNotFound, "NotFound", "not found")
+	})
+
+	It("Should cancel a read that stalls longer than the idle timeout", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		reader := newIdleTimeoutReader(strings.NewReader("x"), 50*time.Millisecond, cancel)
+		defer reader.stop()
+
+		buf := make([]byte, 1)
+		_, err := reader.Read(buf)
+		Expect(err).NotTo(HaveOccurred())
+		Consistently(ctx.Done(), 30*time.Millisecond).ShouldNot(BeClosed())
+		Eventually(ctx.Done(), time.Second).Should(BeClosed())
+	})
+})
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+)
+
+// projectDefaultRepos reads ProjectSettings spec.defaultRepos; nil when unset
+func projectDefaultRepos(ctx context.Context, dyn dynamic.Interface, project string) ([]types.SimpleRepo, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, ok := obj.Object["spec"].(map[string]interface{})
+	if !ok || raw["defaultRepos"] == nil {
+		return nil, nil
+	}
+	var spec struct {
+		DefaultRepos []types.SimpleRepo `json:"defaultRepos"`
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(map[string]interface{}{"defaultRepos": raw["defaultRepos"]}, &spec); err != nil {
+		return nil, fmt.Errorf("spec.defaultRepos: %w", err)
+	}
+	return spec.DefaultRepos, nil
+}
+
+// repoURLKey identifies a repo URL for de-duplication: https and ssh forms of a repo share a key
+func repoURLKey(url string) string {
+	return git.NormalizeRepoURL(url)
+}
+
+// mergeDefaultRepos returns the repos a new session gets: the requested repos, or the defaults when
+// none are requested. With merge the defaults not already requested are appended, so the requested
+// repos keep their positions and the first of them stays the main repo. Duplicates are dropped.
+func mergeDefaultRepos(requested, defaults []types.SimpleRepo, merge bool) []types.SimpleRepo {
+	if len(requested) > 0 && !merge {
+		return requested
+	}
+	seen := map[string]bool{}
+	repos := []types.SimpleRepo{}
+	for _, list := range [][]types.SimpleRepo{requested, defaults} {
+		for _, r := range list {
+			key := repoURLKey(r.URL)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// applyDefaultRepos sets req.Repos from the project's spec.defaultRepos (see mergeDefaultRepos), so
+// the created session carries them exactly as if the client had sent them. On failure it writes the
+// error response and returns false.
+func applyDefaultRepos(c *gin.Context, dyn dynamic.Interface, project string, req *types.CreateAgenticSessionRequest) bool {
+	if len(req.Repos) > 0 && !req.MergeDefaults {
+		return true
+	}
+	defaults, err := projectDefaultRepos(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read default repos in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return false
+	}
+	if len(defaults) > 0 {
+		req.Repos = mergeDefaultRepos(req.Repos, defaults, req.MergeDefaults)
+	}
+	return true
+}
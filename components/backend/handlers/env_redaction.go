@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+)
+
+// redactedEnvValue replaces sensitive environment variable values in API responses
+const redactedEnvValue = "***"
+
+// defaultSensitiveEnvPatterns are matched case-insensitively as substrings of env var keys.
+// Override with SENSITIVE_ENV_PATTERNS (comma-separated).
+var defaultSensitiveEnvPatterns = []string{"TOKEN", "KEY", "SECRET", "PASSWORD"}
+
+// sensitiveEnvPatterns returns the configured list of sensitive key patterns
+func sensitiveEnvPatterns() []string {
+	raw := strings.TrimSpace(os.Getenv("SENSITIVE_ENV_PATTERNS"))
+	if raw == "" {
+		return defaultSensitiveEnvPatterns
+	}
+	var patterns []string
+	for _, p := range strings.Split(raw, ",") {
+		if p = strings.ToUpper(strings.TrimSpace(p)); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// isSensitiveEnvKey reports whether an env var key matches any sensitive pattern
+func isSensitiveEnvKey(key string, patterns []string) bool {
+	upper := strings.ToUpper(key)
+	for _, p := range patterns {
+		if strings.Contains(upper, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactSessionEnv masks sensitive environment variable values on a parsed session spec.
+// The map is replaced rather than mutated so the source object is left untouched.
+func redactSessionEnv(spec *types.AgenticSessionSpec) {
+	if spec == nil || len(spec.EnvironmentVariables) == 0 {
+		return
+	}
+	patterns := sensitiveEnvPatterns()
+	redacted := make(map[string]string, len(spec.EnvironmentVariables))
+	for k, v := range spec.EnvironmentVariables {
+		if isSensitiveEnvKey(k, patterns) {
+			redacted[k] = redactedEnvValue
+		} else {
+			redacted[k] = v
+		}
+	}
+	spec.EnvironmentVariables = redacted
+}
+
+// sessionResponse converts a session object into its API representation. Every handler that
+// returns a session goes through it so sensitive env values are masked unless revealEnv is set
+// (only GET/LIST honour ?revealEnv=true; mutation responses are always masked).
+func sessionResponse(obj *unstructured.Unstructured, revealEnv bool) types.AgenticSession {
+	session := types.AgenticSession{
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+	}
+	if meta, found, _ := unstructured.NestedMap(obj.Object, "metadata"); found {
+		session.Metadata = meta
+	}
+	if spec, found, _ := unstructured.NestedMap(obj.Object, "spec"); found {
+		session.Spec = parseSpec(spec)
+		if !revealEnv {
+			redactSessionEnv(&session.Spec)
+		}
+	}
+	if status, found, _ := unstructured.NestedMap(obj.Object, "status"); found {
+		session.Status = parseStatus(status)
+	}
+	return session
+}
+
+// shouldRevealSessionEnv returns true only when the caller explicitly asked for
+// ?revealEnv=true and is a project admin (same rolebindings SSAR as AccessCheck).
+func shouldRevealSessionEnv(c *gin.Context, reqK8s kubernetes.Interface, project string) bool {
+	if !strings.EqualFold(c.Query("revealEnv"), "true") || reqK8s == nil {
+		return false
+	}
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "rbac.authorization.k8s.io",
+				Resource:  "rolebindings",
+				Verb:      "create",
+				Namespace: project,
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("revealEnv SSAR failed for project %s: %v", project, err)
+		return false
+	}
+	return res.Status.Allowed
+}
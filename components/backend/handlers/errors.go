@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorKind classifies an API error so clients can react without parsing messages
+type ErrorKind string
+
+const (
+	ErrorKindValidation          ErrorKind = "Validation"
+	ErrorKindUnauthorized        ErrorKind = "Unauthorized"
+	ErrorKindForbidden           ErrorKind = "Forbidden"
+	ErrorKindNotFound            ErrorKind = "NotFound"
+	ErrorKindConflict            ErrorKind = "Conflict"
+	ErrorKindUpstreamUnavailable ErrorKind = "UpstreamUnavailable"
+	ErrorKindLimitExceeded       ErrorKind = "LimitExceeded"
+	ErrorKindSigningFailed       ErrorKind = "SigningFailed"
+	ErrorKindPreconditionFailed  ErrorKind = "PreconditionFailed"
+	ErrorKindInternal            ErrorKind = "Internal"
+)
+
+// RequestIDHeader carries the request correlation ID in and out of the backend
+const RequestIDHeader = "X-Request-ID"
+
+// apiError is the body of every error response: {"error": {kind, message, requestId, details?}}
+type apiError struct {
+	Kind      ErrorKind `json:"kind"`
+	Message   string    `json:"message"`
+	RequestID string    `json:"requestId"`
+	Details   gin.H     `json:"details,omitempty"`
+}
+
+// requestID returns the correlation ID for the request, assigning one if the
+// request-ID middleware did not run (e.g. in the content service or tests).
+func requestID(c *gin.Context) string {
+	if id := c.GetString("requestId"); id != "" {
+		return id
+	}
+	id := strings.TrimSpace(c.GetHeader(RequestIDHeader))
+	if id == "" || len(id) > 128 {
+		id = uuid.NewString()
+	}
+	c.Set("requestId", id)
+	c.Header(RequestIDHeader, id)
+	return id
+}
+
+// respondError writes a structured error response. Messages must be safe to show to any
+// caller; log raw Kubernetes or upstream errors instead of passing them here. A 400 for a body
+// that RequestBodyLimit cut off is answered as a 413 naming the limit.
+func respondError(c *gin.Context, code int, kind ErrorKind, msg string, details gin.H) {
+	if limit, exceeded := c.Get(bodyLimitExceededKey); exceeded && code == http.StatusBadRequest {
+		if n, ok := limit.(int64); ok {
+			respondBodyTooLarge(c, n)
+			return
+		}
+	}
+	c.JSON(code, gin.H{"error": apiError{
+		Kind:      kind,
+		Message:   msg,
+		RequestID: requestID(c),
+		Details:   details,
+	}})
+}
+
+// RespondError is respondError for handlers outside this package, such as the websocket routes,
+// so every endpoint answers with the same error shape
+func RespondError(c *gin.Context, code int, kind ErrorKind, msg string, details gin.H) {
+	respondError(c, code, kind, msg, details)
+}
+
+// errorKindForStatus picks the kind for a status code relayed from an upstream service
+func errorKindForStatus(code int) ErrorKind {
+	switch {
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity || code == http.StatusRequestedRangeNotSatisfiable:
+		return ErrorKindValidation
+	case code == http.StatusUnauthorized:
+		return ErrorKindUnauthorized
+	case code == http.StatusForbidden:
+		return ErrorKindForbidden
+	case code == http.StatusNotFound:
+		return ErrorKindNotFound
+	case code == http.StatusConflict || code == http.StatusPreconditionFailed:
+		return ErrorKindConflict
+	case code == http.StatusTooManyRequests:
+		return ErrorKindLimitExceeded
+	case code == http.StatusBadGateway || code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout:
+		return ErrorKindUpstreamUnavailable
+	default:
+		return ErrorKindInternal
+	}
+}
+
+// respondUpstreamUnavailable is the common response when the content service cannot be reached
+func respondUpstreamUnavailable(c *gin.Context, msg string) {
+	respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, msg, nil)
+}
+
+// respondK8sError maps a Kubernetes API error onto the error taxonomy:
+// IsNotFound→404, IsForbidden→403, IsConflict/IsAlreadyExists→409, anything else→500.
+// The raw API message is never returned since it can expose cluster internals.
+func respondK8sError(c *gin.Context, err error, notFoundMsg, failureMsg string) {
+	switch {
+	case errors.IsNotFound(err):
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, notFoundMsg, nil)
+	case errors.IsForbidden(err):
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions", nil)
+	case errors.IsConflict(err):
+		respondError(c, http.StatusConflict, ErrorKindConflict, "Resource was modified concurrently, please retry", nil)
+	case errors.IsAlreadyExists(err):
+		respondError(c, http.StatusConflict, ErrorKindConflict, "Resource already exists", nil)
+	default:
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, failureMsg, nil)
+	}
+}
@@ -0,0 +1,115 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"fmt"
+	"net/http"
+
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("Error Responses", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelErrors), func() {
+	var httpUtils *test_utils.HTTPTestUtils
+
+	sessionsResource := schema.GroupResource{Group: "vteam.ambient-code", Resource: "agenticsessions"}
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+	})
+
+	Context("respondError", func() {
+		It("Should write the structured error body with details", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/p/agentic-sessions/s", nil)
+
+			respondError(context, http.StatusConflict, ErrorKindConflict, "Session is queued", gin.H{"phase": "Queued"})
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Session is queued")
+			Expect(errorObj["details"]).To(Equal(map[string]interface{}{"phase": "Queued"}))
+		})
+
+		It("Should omit details when none are given", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects", nil)
+
+			respondError(context, http.StatusBadRequest, ErrorKindValidation, "Invalid request", nil)
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid request")
+			Expect(errorObj).NotTo(HaveKey("details"))
+		})
+
+		It("Should echo the caller's request ID", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects", nil)
+			context.Request.Header.Set(RequestIDHeader, "req-123")
+
+			respondError(context, http.StatusNotFound, ErrorKindNotFound, "Project not found", nil)
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusNotFound, "NotFound", "Project not found")
+			Expect(errorObj["requestId"]).To(Equal("req-123"))
+			Expect(httpUtils.GetResponseRecorder().Header().Get(RequestIDHeader)).To(Equal("req-123"))
+		})
+	})
+
+	Context("respondK8sError", func() {
+		DescribeTable("Should map Kubernetes errors consistently without leaking the API message",
+			func(err error, status int, kind, message string) {
+				context := httpUtils.CreateTestGinContext("GET", "/api/projects/p/agentic-sessions/s", nil)
+
+				respondK8sError(context, err, "Session not found", "Failed to update session")
+
+				httpUtils.AssertErrorResponse(status, kind, message)
+				Expect(httpUtils.GetResponseBody()).NotTo(ContainSubstring("agenticsessions"))
+			},
+			Entry("NotFound", errors.NewNotFound(sessionsResource, "s"),
+				http.StatusNotFound, "NotFound", "Session not found"),
+			Entry("Forbidden", errors.NewForbidden(sessionsResource, "s", fmt.Errorf("user cannot update")),
+				http.StatusForbidden, "Forbidden", "Insufficient permissions"),
+			Entry("Conflict", errors.NewConflict(sessionsResource, "s", fmt.Errorf("object was modified")),
+				http.StatusConflict, "Conflict", "Resource was modified concurrently, please retry"),
+			Entry("AlreadyExists", errors.NewAlreadyExists(sessionsResource, "s"),
+				http.StatusConflict, "Conflict", "Resource already exists"),
+			Entry("Other", errors.NewInternalError(fmt.Errorf("etcd timeout on agenticsessions")),
+				http.StatusInternalServerError, "Internal", "Failed to update session"),
+		)
+	})
+
+	Context("respondContentServiceError", func() {
+		It("Should keep the message of a structured content service error", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/p/agentic-sessions/s/workspace/missing.txt", nil)
+
+			respondContentServiceError(context, http.StatusNotFound,
+				[]byte(`{"error":{"kind":"NotFound","message":"not found","requestId":"abc"}}`), false)
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusNotFound, "NotFound", "not found")
+			Expect(errorObj).NotTo(HaveKey("details"))
+		})
+
+		It("Should replace unstructured bodies with a generic message", func() {
+			context := httpUtils.CreateTestGinContext("POST", "/api/projects/p/agentic-sessions/s/github/push", nil)
+
+			respondContentServiceError(context, http.StatusBadRequest,
+				[]byte(`{"error":"push failed","stderr":"fatal: could not read from https://token@github.com/o/r"}`), true)
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Content service request failed")
+			Expect(errorObj["details"]).To(Equal(map[string]interface{}{"podSpawned": true}))
+			Expect(httpUtils.GetResponseBody()).NotTo(ContainSubstring("token@"))
+		})
+	})
+
+	Context("errorKindForStatus", func() {
+		It("Should classify relayed upstream status codes", func() {
+			Expect(errorKindForStatus(http.StatusBadRequest)).To(Equal(ErrorKindValidation))
+			Expect(errorKindForStatus(http.StatusNotFound)).To(Equal(ErrorKindNotFound))
+			Expect(errorKindForStatus(http.StatusPreconditionFailed)).To(Equal(ErrorKindConflict))
+			Expect(errorKindForStatus(http.StatusTooManyRequests)).To(Equal(ErrorKindLimitExceeded))
+			Expect(errorKindForStatus(http.StatusBadGateway)).To(Equal(ErrorKindUpstreamUnavailable))
+			Expect(errorKindForStatus(http.StatusTeapot)).To(Equal(ErrorKindInternal))
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// gitCredentialsHeader carries the per-host git credentials (JSON git.Credentials) from the
+// backend to the content service
+const gitCredentialsHeader = "X-Git-Credentials"
+
+// sessionRepoURLs returns the remote URLs of a session's repos: the url, input.url and output.url
+// of spec.repos and the remotes set through ConfigureGitRemote
+func sessionRepoURLs(obj *unstructured.Unstructured) []string {
+	seen := map[string]bool{}
+	var urls []string
+	add := func(u string) {
+		if u = strings.TrimSpace(u); u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+	for _, r := range repos {
+		rm, _ := r.(map[string]interface{})
+		u, _, _ := unstructured.NestedString(rm, "url")
+		add(u)
+		u, _, _ = unstructured.NestedString(rm, "input", "url")
+		add(u)
+		u, _, _ = unstructured.NestedString(rm, "output", "url")
+		add(u)
+	}
+	var remotes []string
+	for k, v := range obj.GetAnnotations() {
+		if strings.HasPrefix(k, "ambient-code.io/remote-") && strings.HasSuffix(k, "-url") {
+			remotes = append(remotes, v)
+		}
+	}
+	sort.Strings(remotes)
+	for _, u := range remotes {
+		add(u)
+	}
+	return urls
+}
+
+// sessionGitCredentials maps the host of each repo URL to the session owner's credential for it:
+// githubToken for GitHub hosts and the owner's GitLab token, sent as oauth2, for GitLab hosts. A
+// github.com entry is always included with githubToken, as the content service used to assume.
+// Hosts without a credential are left out rather than given another provider's token.
+func sessionGitCredentials(c *gin.Context, project string, spec map[string]interface{}, githubToken string, repoURLs []string) git.Credentials {
+	creds := git.Credentials{}
+	if githubToken != "" {
+		creds["github.com"] = git.HostCredential{Token: githubToken}
+	}
+	gitlabToken, gitlabLoaded := "", false
+	for _, u := range repoURLs {
+		host := git.HostOf(u)
+		if host == "" {
+			continue
+		}
+		switch types.DetectProvider(u) {
+		case types.ProviderGitHub:
+			if githubToken != "" {
+				creds[host] = git.HostCredential{Token: githubToken}
+			}
+		case types.ProviderGitLab:
+			if !gitlabLoaded {
+				gitlabLoaded = true
+				gitlabToken = sessionGitLabToken(c, project, spec)
+			}
+			if gitlabToken != "" {
+				creds[host] = git.HostCredential{Token: gitlabToken, Scheme: "oauth2"}
+			}
+		}
+	}
+	return creds
+}
+
+// sessionGitLabToken returns the session owner's GitLab token, or "" when they have none
+func sessionGitLabToken(c *gin.Context, project string, spec map[string]interface{}) string {
+	userID, _, _ := unstructured.NestedString(spec, "userContext", "userId")
+	if strings.TrimSpace(userID) == "" {
+		return ""
+	}
+	k8sClt, _ := GetK8sClientsForRequest(c)
+	if k8sClt == nil {
+		return ""
+	}
+	token, err := git.GetGitLabToken(c.Request.Context(), k8sClt, project, strings.TrimSpace(userID))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// firstGitHubRepo returns the first GitHub repo among urls, or "" when there is none
+func firstGitHubRepo(urls []string) string {
+	for _, u := range urls {
+		if types.DetectProvider(u) == types.ProviderGitHub {
+			return u
+		}
+	}
+	return ""
+}
+
+// setGitCredentialsHeader forwards creds to the content service
+func setGitCredentialsHeader(h http.Header, creds git.Credentials) {
+	if len(creds) == 0 {
+		return
+	}
+	b, err := json.Marshal(creds)
+	if err != nil {
+		log.Printf("setGitCredentialsHeader: failed to encode credentials: %v", err)
+		return
+	}
+	h.Set(gitCredentialsHeader, string(b))
+}
+
+// attachSessionGitCredentials forwards the session owner's credentials for every repo of the
+// session. Failures are logged and the content service falls back to its own credentials.
+func attachSessionGitCredentials(c *gin.Context, h http.Header, project, session string) {
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		return
+	}
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		log.Printf("attachSessionGitCredentials: failed to read session %s/%s: %v", project, session, err)
+		return
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	urls := sessionRepoURLs(obj)
+	token := sessionGitHubToken(c, project, spec, firstGitHubRepo(urls))
+	if token != "" {
+		h.Set("X-GitHub-Token", token)
+	}
+	setGitCredentialsHeader(h, sessionGitCredentials(c, project, spec, token, urls))
+}
+
+// gitCredentialsFromRequest reads the credentials forwarded by the backend. A X-GitHub-Token from
+// an older backend is used for github.com when the map has no entry for it.
+func gitCredentialsFromRequest(c *gin.Context) git.Credentials {
+	creds := git.Credentials{}
+	if raw := strings.TrimSpace(c.GetHeader(gitCredentialsHeader)); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &creds); err != nil {
+			log.Printf("gitCredentialsFromRequest: ignoring malformed %s header: %v", gitCredentialsHeader, err)
+			creds = git.Credentials{}
+		}
+	}
+	if token := strings.TrimSpace(c.GetHeader("X-GitHub-Token")); token != "" {
+		if _, ok := creds["github.com"]; !ok {
+			creds["github.com"] = git.HostCredential{Token: token}
+		}
+	}
+	return creds
+}
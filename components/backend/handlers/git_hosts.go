@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// gitHostPattern returns the canonical host[/path] form of a spec.allowedGitHosts entry or a repo
+// URL (see git.NormalizeRepoURL), so github.com/Acme matches https://github.com/acme/app.git and
+// git@github.com:acme/app alike
+func gitHostPattern(s string) string {
+	return strings.TrimPrefix(git.NormalizeRepoURL(s), "https://")
+}
+
+// gitHostAllowed reports whether repoURL is on a host, or under a host/owner prefix, of patterns.
+// Prefixes match whole path segments: github.com/acme allows github.com/acme/app but not
+// github.com/acme-evil/app. An empty list allows every URL.
+func gitHostAllowed(repoURL string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	target := gitHostPattern(repoURL)
+	if target == "" {
+		return false
+	}
+	for _, p := range patterns {
+		prefix := gitHostPattern(p)
+		if prefix != "" && (target == prefix || strings.HasPrefix(target, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectAllowedGitHosts returns spec.allowedGitHosts from the project's ProjectSettings. A missing
+// ProjectSettings means git hosts are unrestricted.
+func projectAllowedGitHosts(ctx context.Context, dyn dynamic.Interface, project string) ([]string, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	patterns, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "allowedGitHosts")
+	return patterns, nil
+}
+
+// requireAllowedGitURLs checks repository and workflow URLs against the project's
+// spec.allowedGitHosts and writes 403 naming the first blocked host. Empty URLs are skipped. The
+// allowlist is read with dyn; when it cannot be read the request is refused rather than let
+// through. On failure it writes the error response and returns false.
+func requireAllowedGitURLs(c *gin.Context, dyn dynamic.Interface, project string, urls ...string) bool {
+	pending := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			pending = append(pending, u)
+		}
+	}
+	if len(pending) == 0 {
+		return true
+	}
+	patterns, ok := requireAllowedGitHosts(c, dyn, project)
+	if !ok {
+		return false
+	}
+	return requireGitURLsInHosts(c, project, patterns, pending...)
+}
+
+// requireAllowedGitHosts reads the project's spec.allowedGitHosts for a handler that checks several
+// URLs against it; on failure it writes the error response and returns false
+func requireAllowedGitHosts(c *gin.Context, dyn dynamic.Interface, project string) ([]string, bool) {
+	patterns, err := projectAllowedGitHosts(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read allowed git hosts in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return nil, false
+	}
+	return patterns, true
+}
+
+// requireGitURLsInHosts writes 403 naming the first of urls outside patterns and returns false
+func requireGitURLsInHosts(c *gin.Context, project string, patterns []string, urls ...string) bool {
+	for _, u := range urls {
+		if gitHostAllowed(u, patterns) {
+			continue
+		}
+		host := git.HostOf(u)
+		if host == "" {
+			host = u
+		}
+		log.Printf("Blocked git URL %s in project %s: not in allowedGitHosts", u, project)
+		respondError(c, http.StatusForbidden, ErrorKindForbidden,
+			fmt.Sprintf("Git host %s is not allowed in this project", host),
+			gin.H{"url": u, "host": host, "allowedGitHosts": patterns})
+		return false
+	}
+	return true
+}
+
+// createRequestGitURLs returns the repository URLs a create request would clone
+func createRequestGitURLs(repos []types.SimpleRepo) []string {
+	urls := make([]string, 0, len(repos))
+	for _, r := range repos {
+		urls = append(urls, r.URL)
+	}
+	return urls
+}
+
+// sessionSpecGitURLs returns the repository URLs of a session spec: each repo and its output
+// (push target), and the active workflow
+func sessionSpecGitURLs(spec map[string]interface{}) []string {
+	var urls []string
+	repos, _ := spec["repos"].([]interface{})
+	for _, r := range repos {
+		if rm, ok := r.(map[string]interface{}); ok {
+			urls = append(urls, repoEntryURL(rm))
+			if out, ok := rm["output"].(map[string]interface{}); ok {
+				if u, _ := out["url"].(string); u != "" {
+					urls = append(urls, u)
+				}
+			}
+		}
+	}
+	if wf, ok := spec["activeWorkflow"].(map[string]interface{}); ok {
+		if u, _ := wf["gitUrl"].(string); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,201 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Allowed Git Hosts", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec": map[string]interface{}{
+				"groupAccess":     []interface{}{},
+				"allowedGitHosts": []interface{}{"github.com/acme", "gitlab.example.com"},
+			},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("gitHostAllowed", func() {
+		It("Should match hosts and whole owner segments", func() {
+			patterns := []string{"github.com/Acme", "gitlab.example.com"}
+			Expect(gitHostAllowed("https://github.com/acme/app.git", patterns)).To(BeTrue())
+			Expect(gitHostAllowed("git@github.com:acme/app", patterns)).To(BeTrue())
+			Expect(gitHostAllowed("https://gitlab.example.com/group/sub/app", patterns)).To(BeTrue())
+			Expect(gitHostAllowed("https://github.com/acme-evil/app", patterns)).To(BeFalse())
+			Expect(gitHostAllowed("https://gitlab.example.com.evil.io/grp/app", patterns)).To(BeFalse())
+			Expect(gitHostAllowed("https://anywhere.io/x/y", nil)).To(BeTrue())
+		})
+	})
+
+	Context("CreateSession", func() {
+		create := func(body map[string]interface{}) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			CreateSession(c)
+		}
+
+		It("Should create a session whose repos are all allowed", func() {
+			create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/acme/app"},
+				{"url": "https://gitlab.example.com/team/api"},
+			}})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+		})
+
+		It("Should refuse a repo on another host, naming the host", func() {
+			create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/acme/app"},
+				{"url": "https://gitlab.com/acme/app"},
+			}})
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Git host gitlab.com is not allowed in this project")
+			Expect(errorObj["details"]).To(HaveKeyWithValue("url", "https://gitlab.com/acme/app"))
+		})
+
+		It("Should refuse an owner that only shares a prefix with an allowed one", func() {
+			create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/acme-evil/app"},
+			}})
+
+			httpUtils.AssertHTTPStatus(http.StatusForbidden)
+		})
+	})
+
+	Context("on a running session", func() {
+		BeforeEach(func() {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "sess", "namespace": testNamespace},
+				"spec": map[string]interface{}{
+					"interactive": true,
+					"repos":       []interface{}{map[string]interface{}{"url": "https://github.com/acme/app", "branch": "main"}},
+				},
+				"status": map[string]interface{}{"phase": "Running"},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context := func(path string, body map[string]interface{}) *gin.Context {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/sess"+path, body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			c.Params = gin.Params{{Key: "sessionName", Value: "sess"}}
+			return c
+		}
+
+		It("Should refuse adding a repo on another host", func() {
+			AddRepo(context("/repos", map[string]interface{}{"url": "https://bitbucket.org/acme/app"}))
+
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Git host bitbucket.org is not allowed in this project")
+		})
+
+		It("Should refuse a workflow on another host", func() {
+			SelectWorkflow(context("/workflow", map[string]interface{}{"gitUrl": "https://github.com/elsewhere/workflows"}))
+
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Git host github.com is not allowed in this project")
+		})
+	})
+
+	Context("on push", func() {
+		// The spec was changed after create, e.g. by a direct edit, to push outside github.com/acme
+		createSession := func(output map[string]interface{}) {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "sess", "namespace": testNamespace},
+				"spec": map[string]interface{}{
+					"repos": []interface{}{map[string]interface{}{"url": "https://github.com/acme/api", "output": output}},
+				},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		push := func(handler gin.HandlerFunc, path string, body map[string]interface{}) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/sess/github/"+path, body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			c.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: "sess"}}
+			handler(c)
+		}
+
+		It("Should refuse pushing to an output repo on another account", func() {
+			createSession(map[string]interface{}{"url": "https://github.com/elsewhere/api", "branch": "fix"})
+
+			push(PushSessionRepo, "push", map[string]interface{}{"repoIndex": 0})
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Git host github.com is not allowed in this project")
+
+			push(PushAllSessionRepos, "push-all", map[string]interface{}{})
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Git host github.com is not allowed in this project")
+		})
+
+		It("Should refuse an auto-fork into the user's account outside the allowed hosts", func() {
+			createSession(map[string]interface{}{"url": "https://github.com/acme/api", "autoFork": true})
+			github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/api":
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{"full_name": "acme/api", "permissions": map[string]bool{"push": false}})
+				case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/api/forks":
+					w.WriteHeader(http.StatusAccepted)
+					_ = json.NewEncoder(w).Encode(map[string]string{"full_name": "alice/api", "clone_url": "https://github.com/alice/api.git"})
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/alice/api":
+					_ = json.NewEncoder(w).Encode(map[string]string{"full_name": "alice/api"})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			DeferCleanup(github.Close)
+			patterns, err := projectAllowedGitHosts(ctx, k8sUtils.DynamicClient, testNamespace)
+			Expect(err).NotTo(HaveOccurred())
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+			t := resolveRepoPushTarget("sess", 0, repos[0].(map[string]interface{}))
+
+			Expect(forkOutputRepo(ctx, github.URL, testNamespace, "sess", &t, "gh-token", patterns)).To(MatchError(errForkHostNotAllowed))
+
+			Expect(t.OutputURL).To(Equal("https://github.com/acme/api"))
+			obj, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ = unstructured.NestedSlice(obj.Object, "spec", "repos")
+			Expect(repos[0]).To(HaveKeyWithValue("output", HaveKeyWithValue("url", "https://github.com/acme/api")))
+		})
+	})
+})
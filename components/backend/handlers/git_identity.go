@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/git"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Headers carrying the commit author from the backend to the content service
+const (
+	gitAuthorNameHeader  = "X-Git-Author-Name"
+	gitAuthorEmailHeader = "X-Git-Author-Email"
+)
+
+// gitIdentityCacheTTL is how long a resolved GitHub identity is reused for the same user
+const gitIdentityCacheTTL = time.Hour
+
+// gitIdentityFallbackNote is reported in push responses committed as the bot identity
+const gitIdentityFallbackNote = "GitHub identity could not be resolved; committed as the bot identity"
+
+// FetchGitHubIdentity resolves the GitHub user behind a token; set by main to git.FetchGitHubIdentity
+var FetchGitHubIdentity func(ctx context.Context, apiBase, githubToken string) (git.Identity, error)
+
+type gitIdentityEntry struct {
+	identity git.Identity
+	expires  time.Time
+}
+
+var (
+	gitIdentityCache   = make(map[string]gitIdentityEntry) // userID|apiBase -> identity
+	gitIdentityCacheMu sync.Mutex
+)
+
+// resolveGitIdentity returns the commit author for a push made with githubToken on behalf of userID.
+// It reports false when the GitHub user could not be resolved and git.BotIdentity is returned
+// instead. Resolved identities are cached per user and API base; failures are not cached.
+func resolveGitIdentity(ctx context.Context, userID, apiBase, githubToken string) (git.Identity, bool) {
+	if githubToken == "" || FetchGitHubIdentity == nil {
+		return git.BotIdentity(), false
+	}
+	key := userID + "|" + apiBase
+	now := time.Now()
+	if userID != "" {
+		gitIdentityCacheMu.Lock()
+		entry, found := gitIdentityCache[key]
+		gitIdentityCacheMu.Unlock()
+		if found && now.Before(entry.expires) {
+			return entry.identity, true
+		}
+	}
+
+	id, err := FetchGitHubIdentity(ctx, apiBase, githubToken)
+	if err != nil {
+		log.Printf("resolveGitIdentity: falling back to bot identity for user %q: %v", userID, err)
+		return git.BotIdentity(), false
+	}
+	if userID != "" {
+		gitIdentityCacheMu.Lock()
+		for k, e := range gitIdentityCache {
+			if !now.Before(e.expires) {
+				delete(gitIdentityCache, k)
+			}
+		}
+		gitIdentityCache[key] = gitIdentityEntry{identity: id, expires: now.Add(gitIdentityCacheTTL)}
+		gitIdentityCacheMu.Unlock()
+	}
+	return id, true
+}
+
+// sessionGitAuthor resolves the commit author for pushing repoURL with a session's GitHub token.
+// The session owner's userContext.userId keys the identity cache.
+func sessionGitAuthor(c *gin.Context, project string, spec map[string]interface{}, repoURL, githubToken string) (git.Identity, bool) {
+	userID, _, _ := unstructured.NestedString(spec, "userContext", "userId")
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	apiBase := projectGitHubAPIBase(c, k8sClt, k8sDyn, project, repoURL)
+	return resolveGitIdentity(c.Request.Context(), strings.TrimSpace(userID), apiBase, githubToken)
+}
+
+// setGitAuthorHeaders forwards the commit author to the content service
+func setGitAuthorHeaders(h http.Header, id git.Identity) {
+	h.Set(gitAuthorNameHeader, headerSafe(id.Name))
+	h.Set(gitAuthorEmailHeader, headerSafe(id.Email))
+}
+
+// withGitAuthor adds the commit author to a push response, noting when it is the bot fallback
+func withGitAuthor(body []byte, id git.Identity, resolved bool) []byte {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(body, &obj); err != nil || obj == nil {
+		return body
+	}
+	obj["author"] = map[string]string{"name": id.Name, "email": id.Email}
+	if !resolved {
+		obj["authorFallback"] = true
+		obj["authorNote"] = gitIdentityFallbackNote
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// gitAuthorFromHeaders reads the commit author forwarded by the backend; it is zero when absent
+func gitAuthorFromHeaders(c *gin.Context) git.Identity {
+	return git.Identity{
+		Name:  strings.TrimSpace(c.GetHeader(gitAuthorNameHeader)),
+		Email: strings.TrimSpace(c.GetHeader(gitAuthorEmailHeader)),
+	}
+}
+
+// headerSafe drops control characters, which are not allowed in header values
+func headerSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,100 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"ambient-code-backend/git"
+	test_constants "ambient-code-backend/tests/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Git Identity", Label(test_constants.LabelUnit, test_constants.LabelHandlers), func() {
+	var originalFetch func(ctx context.Context, apiBase, githubToken string) (git.Identity, error)
+
+	BeforeEach(func() {
+		originalFetch = FetchGitHubIdentity
+		gitIdentityCacheMu.Lock()
+		gitIdentityCache = make(map[string]gitIdentityEntry)
+		gitIdentityCacheMu.Unlock()
+	})
+
+	AfterEach(func() {
+		FetchGitHubIdentity = originalFetch
+	})
+
+	It("Should resolve the GitHub user once per user and reuse it", func() {
+		calls := 0
+		FetchGitHubIdentity = func(ctx context.Context, apiBase, githubToken string) (git.Identity, error) {
+			calls++
+			return git.Identity{Name: "Octo Cat", Email: "octo@example.com"}, nil
+		}
+
+		for i := 0; i < 3; i++ {
+			id, ok := resolveGitIdentity(context.Background(), "alice", "https://api.github.com", fmt.Sprintf("token-%d", i))
+			Expect(ok).To(BeTrue())
+			Expect(id).To(Equal(git.Identity{Name: "Octo Cat", Email: "octo@example.com"}))
+		}
+		Expect(calls).To(Equal(1))
+	})
+
+	It("Should fall back to the configured bot identity without caching the failure", func() {
+		GinkgoT().Setenv("GIT_BOT_NAME", "Platform Bot")
+		GinkgoT().Setenv("GIT_BOT_EMAIL", "bot@example.com")
+		calls := 0
+		FetchGitHubIdentity = func(ctx context.Context, apiBase, githubToken string) (git.Identity, error) {
+			calls++
+			return git.Identity{}, fmt.Errorf("GitHub API /user returned status 403")
+		}
+
+		for i := 0; i < 2; i++ {
+			id, ok := resolveGitIdentity(context.Background(), "alice", "https://api.github.com", "token")
+			Expect(ok).To(BeFalse())
+			Expect(id).To(Equal(git.Identity{Name: "Platform Bot", Email: "bot@example.com"}))
+		}
+		Expect(calls).To(Equal(2))
+
+		_, ok := resolveGitIdentity(context.Background(), "alice", "https://api.github.com", "")
+		Expect(ok).To(BeFalse(), "no token means no identity lookup")
+	})
+
+	It("Should use the noreply address for users with a private email", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/v3/user"))
+			Expect(r.Header.Get("Authorization")).To(Equal("token gh-token"))
+			_, _ = w.Write([]byte(`{"id":42,"login":"octocat","name":"","email":null}`))
+		}))
+		defer server.Close()
+
+		id, err := git.FetchGitHubIdentity(context.Background(), server.URL+"/api/v3", "gh-token")
+
+		Expect(err).NotTo(HaveOccurred())
+		host := server.Listener.Addr().String()
+		Expect(id).To(Equal(git.Identity{Name: "octocat", Email: "42+octocat@users.noreply." + host}))
+	})
+
+	It("Should note the fallback in push responses", func() {
+		var resp map[string]interface{}
+		Expect(json.Unmarshal(withGitAuthor([]byte(`{"ok":true}`), git.Identity{Name: "Bot", Email: "bot@x"}, false), &resp)).To(Succeed())
+		Expect(resp["author"]).To(Equal(map[string]interface{}{"name": "Bot", "email": "bot@x"}))
+		Expect(resp["authorFallback"]).To(BeTrue())
+		Expect(resp["authorNote"]).To(Equal(gitIdentityFallbackNote))
+
+		resp = nil
+		Expect(json.Unmarshal(withGitAuthor([]byte(`{"ok":true}`), git.Identity{Name: "Octo", Email: "o@x"}, true), &resp)).To(Succeed())
+		Expect(resp).NotTo(HaveKey("authorFallback"))
+	})
+
+	It("Should strip control characters from forwarded headers", func() {
+		h := http.Header{}
+		setGitAuthorHeaders(h, git.Identity{Name: "Evil\r\nX-Injected: 1", Email: "e@x"})
+		Expect(h.Get(gitAuthorNameHeader)).To(Equal("EvilX-Injected: 1"))
+	})
+})
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// gitSigningHeader carries the signing key (JSON git.CommitSigning) from the backend to the content
+// service for a single push or sync. The content service keeps it outside the workspace and
+// removes it once the commit is made.
+const gitSigningHeader = "X-Git-Signing"
+
+// Secret keys read when a commitSigning key ref names none; ssh-privatekey is the key of
+// kubernetes.io/ssh-auth Secrets
+const (
+	commitSigningSSHDefaultKey = "ssh-privatekey"
+	commitSigningGPGDefaultKey = "private.key"
+)
+
+// projectSettingsCommitSigning reads spec.commitSigning from a ProjectSettings object; nil when unset
+// or disabled
+func projectSettingsCommitSigning(obj *unstructured.Unstructured) (*types.CommitSigningConfig, error) {
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec", "commitSigning")
+	if err != nil || !found {
+		return nil, err
+	}
+	return commitSigningConfig(raw)
+}
+
+// commitSigningConfig converts a spec.commitSigning; nil when signing is disabled
+func commitSigningConfig(raw map[string]interface{}) (*types.CommitSigningConfig, error) {
+	var cfg types.CommitSigningConfig
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("spec.commitSigning: %w", err)
+	}
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	return &cfg, nil
+}
+
+// commitSigningKeyRef checks an enabled spec.commitSigning and returns its key ref, with the key
+// defaulted, and the git.SigningFormat* of the key
+func commitSigningKeyRef(cfg *types.CommitSigningConfig) (*types.CommitSigningKeyRef, string, error) {
+	var ref types.CommitSigningKeyRef
+	var format string
+	switch {
+	case cfg.SSHKeySecretRef != nil && cfg.GPGKeySecretRef != nil:
+		return nil, "", fmt.Errorf("set only one of sshKeySecretRef and gpgKeySecretRef")
+	case cfg.SSHKeySecretRef != nil:
+		ref, format = *cfg.SSHKeySecretRef, git.SigningFormatSSH
+		if ref.Key = strings.TrimSpace(ref.Key); ref.Key == "" {
+			ref.Key = commitSigningSSHDefaultKey
+		}
+	case cfg.GPGKeySecretRef != nil:
+		ref, format = *cfg.GPGKeySecretRef, git.SigningFormatOpenPGP
+		if ref.Key = strings.TrimSpace(ref.Key); ref.Key == "" {
+			ref.Key = commitSigningGPGDefaultKey
+		}
+	default:
+		return nil, "", fmt.Errorf("sshKeySecretRef or gpgKeySecretRef is required when signing is enabled")
+	}
+	ref.Name = strings.TrimSpace(ref.Name)
+	if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+		return nil, "", fmt.Errorf("key secret name: %s", strings.Join(errs, "; "))
+	}
+	if errs := validation.IsConfigMapKey(ref.Key); len(errs) > 0 {
+		return nil, "", fmt.Errorf("key secret key: %s", strings.Join(errs, "; "))
+	}
+	if email := strings.TrimSpace(cfg.SignerEmail); email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return nil, "", fmt.Errorf("signerEmail must be a plain email address")
+		}
+	}
+	return &ref, format, nil
+}
+
+// projectCommitSigning loads the signing key of the project's spec.commitSigning; nil when signing is
+// off. The key Secret is read with the backend service account since editors cannot read Secrets.
+func projectCommitSigning(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string) (*git.CommitSigning, error) {
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read ProjectSettings: %w", err)
+	}
+	cfg, err := projectSettingsCommitSigning(obj)
+	if err != nil || cfg == nil {
+		return nil, err
+	}
+	ref, format, err := commitSigningKeyRef(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("spec.commitSigning: %w", err)
+	}
+	secrets := K8sClient
+	if secrets == nil {
+		secrets = reqK8s
+	}
+	sec, err := secrets.CoreV1().Secrets(project).Get(c.Request.Context(), ref.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("read signing key secret %s: %w", ref.Name, err)
+	}
+	if strings.TrimSpace(string(sec.Data[ref.Key])) == "" {
+		return nil, fmt.Errorf("signing key secret %s has no key %s", ref.Name, ref.Key)
+	}
+	return &git.CommitSigning{Format: format, Key: string(sec.Data[ref.Key]), SignerEmail: strings.TrimSpace(cfg.SignerEmail)}, nil
+}
+
+// attachCommitSigning forwards the project's signing key to the content service when signing is
+// enabled. When the key cannot be loaded it responds 422 SigningFailed and returns false: the
+// commit would be rejected by branch protection, so it is not pushed unsigned.
+func attachCommitSigning(c *gin.Context, h http.Header, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string) bool {
+	signing, err := projectCommitSigning(c, reqK8s, reqDyn, project)
+	if err != nil {
+		log.Printf("attachCommitSigning: project %s: %v", project, err)
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindSigningFailed, "Commit signing is enabled but the signing key could not be loaded", nil)
+		return false
+	}
+	if signing == nil {
+		return true
+	}
+	b, err := json.Marshal(signing)
+	if err != nil {
+		log.Printf("attachCommitSigning: failed to encode signing key: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
+		return false
+	}
+	h.Set(gitSigningHeader, string(b))
+	return true
+}
+
+// gitSigningFromRequest reads the signing key forwarded by the backend; nil when commits are unsigned
+func gitSigningFromRequest(c *gin.Context) *git.CommitSigning {
+	raw := strings.TrimSpace(c.GetHeader(gitSigningHeader))
+	if raw == "" {
+		return nil
+	}
+	var signing git.CommitSigning
+	if err := json.Unmarshal([]byte(raw), &signing); err != nil {
+		// Committing unsigned would only be rejected later, so sign with an unusable key instead
+		log.Printf("gitSigningFromRequest: malformed %s header: %v", gitSigningHeader, err)
+		return &git.CommitSigning{}
+	}
+	return &signing
+}
+
+// respondSigningFailed is the content service response to a commit that could not be signed
+func respondSigningFailed(c *gin.Context, err error) {
+	log.Printf("commit signing failed: %v", err)
+	respondError(c, http.StatusUnprocessableEntity, ErrorKindSigningFailed, "Failed to sign the commit with the project's signing key", nil)
+}
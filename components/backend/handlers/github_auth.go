@@ -11,10 +11,14 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"ambient-code-backend/git"
+	"ambient-code-backend/httpclient"
+
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -31,8 +35,8 @@ var (
 
 	// GetGitHubTokenRepo is a dependency-injectable function for getting GitHub tokens in repo operations
 	// Tests can override this to provide mock implementations
-	// Signature: func(context.Context, kubernetes.Interface, dynamic.Interface, string, string) (string, error)
-	GetGitHubTokenRepo func(context.Context, kubernetes.Interface, dynamic.Interface, string, string) (string, error)
+	// Signature: func(ctx, k8s, dyn, project, userID, repo string) (string, error)
+	GetGitHubTokenRepo func(context.Context, kubernetes.Interface, dynamic.Interface, string, string, string) (string, error)
 
 	// DoGitHubRequest is a dependency-injectable function for making GitHub API requests
 	// Tests can override this to provide mock implementations
@@ -43,8 +47,8 @@ var (
 
 // WrapGitHubTokenForRepo wraps git.GetGitHubToken to accept kubernetes.Interface instead of *kubernetes.Clientset
 // This allows dependency injection while maintaining compatibility with git.GetGitHubToken
-func WrapGitHubTokenForRepo(originalFunc func(context.Context, *kubernetes.Clientset, dynamic.Interface, string, string) (string, error)) func(context.Context, kubernetes.Interface, dynamic.Interface, string, string) (string, error) {
-	return func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID string) (string, error) {
+func WrapGitHubTokenForRepo(originalFunc func(context.Context, *kubernetes.Clientset, dynamic.Interface, string, string, string) (string, error)) func(context.Context, kubernetes.Interface, dynamic.Interface, string, string, string) (string, error) {
+	return func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID, repo string) (string, error) {
 		// Type assert to *kubernetes.Clientset for git.GetGitHubToken
 		var k8sClient *kubernetes.Clientset
 		if k8s != nil {
@@ -54,7 +58,7 @@ func WrapGitHubTokenForRepo(originalFunc func(context.Context, *kubernetes.Clien
 				return "", fmt.Errorf("kubernetes client is not a *Clientset (got %T)", k8s)
 			}
 		}
-		return originalFunc(ctx, k8sClient, dyn, project, userID)
+		return originalFunc(ctx, k8sClient, dyn, project, userID, repo)
 	}
 }
 
@@ -65,7 +69,8 @@ type GithubTokenManagerInterface interface {
 
 // GitHubAppInstallation represents a GitHub App installation for a user
 type GitHubAppInstallation struct {
-	UserID         string    `json:"userId"`
+	UserID string `json:"userId"`
+	// GitHubUserID is the login of the account (user or organization) the App is installed on
 	GitHubUserID   string    `json:"githubUserId"`
 	InstallationID int64     `json:"installationId"`
 	Host           string    `json:"host"`
@@ -82,13 +87,9 @@ func (g *GitHubAppInstallation) GetHost() string {
 	return g.Host
 }
 
-// helper: resolve GitHub API base URL from host
+// helper: resolve GitHub API base URL from host (honors GITHUB_API_BASE, see git.GitHubAPIBaseURL)
 func githubAPIBaseURL(host string) string {
-	if host == "" || host == "github.com" {
-		return "https://api.github.com"
-	}
-	// GitHub Enterprise default
-	return fmt.Sprintf("https://%s/api/v3", host)
+	return git.GitHubAPIBaseURL(host)
 }
 
 // doGitHubRequest executes an HTTP request to the GitHub API
@@ -112,8 +113,7 @@ func doGitHubRequest(ctx context.Context, method string, url string, authHeader
 			req.Header.Set("If-None-Match", s)
 		}
 	}
-	client := &http.Client{Timeout: 15 * time.Second}
-	return client.Do(req)
+	return httpclient.Do(req, httpclient.API)
 }
 
 // ===== OAuth during installation (user verification) =====
@@ -131,13 +131,13 @@ func HandleGitHubUserOAuthCallback(c *gin.Context) {
 	clientSecret := os.Getenv("GITHUB_CLIENT_SECRET")
 	stateSecret := os.Getenv("GITHUB_STATE_SECRET")
 	if strings.TrimSpace(clientID) == "" || strings.TrimSpace(clientSecret) == "" || strings.TrimSpace(stateSecret) == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth not configured"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "OAuth not configured", nil)
 		return
 	}
 	code := c.Query("code")
 	state := c.Query("state")
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing code"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing code", nil)
 		return
 	}
 	// Defaults when no state provided
@@ -147,22 +147,22 @@ func HandleGitHubUserOAuthCallback(c *gin.Context) {
 	if state != "" {
 		raw, err := base64.RawURLEncoding.DecodeString(state)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid state", nil)
 			return
 		}
 		parts := strings.SplitN(string(raw), ".", 2)
 		if len(parts) != 2 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid state", nil)
 			return
 		}
 		payload, sig := parts[0], parts[1]
 		if signState(stateSecret, payload) != sig {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad state signature"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "bad state signature", nil)
 			return
 		}
 		fields := strings.Split(payload, ":")
 		if len(fields) != 5 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "bad state payload"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "bad state payload", nil)
 			return
 		}
 		userInState := fields[0]
@@ -171,14 +171,14 @@ func HandleGitHubUserOAuthCallback(c *gin.Context) {
 		instB64 := fields[4]
 		if sec, err := strconv.ParseInt(ts, 10, 64); err == nil {
 			if time.Since(time.Unix(sec, 0)) > 10*time.Minute {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "state expired"})
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, "state expired", nil)
 				return
 			}
 		}
 		// Confirm current session user matches state user
 		userID, _ := c.Get("userID")
 		if userID == nil || userInState != userID.(string) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "user mismatch"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "user mismatch", nil)
 			return
 		}
 		// Decode installation id from state
@@ -189,31 +189,31 @@ func HandleGitHubUserOAuthCallback(c *gin.Context) {
 		// No state (install started outside our UI). Require user session and read installation_id from query.
 		userID, _ := c.Get("userID")
 		if userID == nil || strings.TrimSpace(userID.(string)) == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user identity"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "missing user identity", nil)
 			return
 		}
 		instStr := c.Query("installation_id")
 		var err error
 		instID, err = strconv.ParseInt(instStr, 10, 64)
 		if err != nil || instID <= 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid installation id"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid installation id", nil)
 			return
 		}
 	}
 	// Exchange code → user token
 	token, err := exchangeOAuthCodeForUserToken(clientID, clientSecret, code)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "oauth exchange failed"})
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "oauth exchange failed", nil)
 		return
 	}
 	// Verify ownership: GET /user/installations includes the installation
 	owns, login, err := userOwnsInstallation(token, instID)
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "verification failed"})
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "verification failed", nil)
 		return
 	}
 	if !owns {
-		c.JSON(http.StatusForbidden, gin.H{"error": "installation not owned by user"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "installation not owned by user", nil)
 		return
 	}
 	// Store mapping
@@ -225,7 +225,7 @@ func HandleGitHubUserOAuthCallback(c *gin.Context) {
 		UpdatedAt:      time.Now(),
 	}
 	if err := storeGitHubInstallation(c.Request.Context(), "", &installation); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store installation"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to store installation", nil)
 		return
 	}
 	// Redirect back to return_to if present
@@ -246,7 +246,7 @@ func exchangeOAuthCodeForUserToken(clientID, clientSecret, code string) (string,
 	req, _ := http.NewRequest(http.MethodPost, "https://github.com/login/oauth/access_token", reqBody)
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return "", err
 	}
@@ -264,11 +264,11 @@ func exchangeOAuthCodeForUserToken(clientID, clientSecret, code string) (string,
 }
 
 func userOwnsInstallation(userToken string, installationID int64) (bool, string, error) {
-	req, _ := http.NewRequest(http.MethodGet, "https://api.github.com/user/installations", nil)
+	req, _ := http.NewRequest(http.MethodGet, githubAPIBaseURL("github.com")+"/user/installations", nil)
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("Authorization", "token "+userToken)
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return false, "", err
 	}
@@ -295,13 +295,35 @@ func userOwnsInstallation(userToken string, installationID int64) (bool, string,
 	return false, "", nil
 }
 
-// storeGitHubInstallation persists the GitHub App installation mapping
+// githubInstallationsConfigMap stores each user's GitHub App installations under their user ID
+const githubInstallationsConfigMap = "github-app-installations"
+
+// decodeGitHubInstallations reads a user's entry of the installations ConfigMap: a JSON list, or a
+// single installation as stored before users could link one per account
+func decodeGitHubInstallations(raw string) ([]GitHubAppInstallation, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "{") {
+		var inst GitHubAppInstallation
+		if err := json.Unmarshal([]byte(raw), &inst); err != nil {
+			return nil, err
+		}
+		return []GitHubAppInstallation{inst}, nil
+	}
+	var list []GitHubAppInstallation
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// storeGitHubInstallation persists the GitHub App installation mapping. A user keeps one
+// installation per account; linking another installation on the same account replaces it.
 func storeGitHubInstallation(ctx context.Context, projectName string, installation *GitHubAppInstallation) error {
 	if installation == nil || installation.UserID == "" {
 		return fmt.Errorf("invalid installation payload")
 	}
 	// Cluster-scoped by server namespace; ignore projectName for storage
-	const cmName = "github-app-installations"
+	const cmName = githubInstallationsConfigMap
 	for i := 0; i < 3; i++ { // retry on conflict
 		cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, cmName, v1.GetOptions{})
 		if err != nil {
@@ -323,7 +345,16 @@ func storeGitHubInstallation(ctx context.Context, projectName string, installati
 		if cm.Data == nil {
 			cm.Data = map[string]string{}
 		}
-		b, err := json.Marshal(installation)
+		// An unreadable entry is replaced rather than blocking new links
+		existing, _ := decodeGitHubInstallations(cm.Data[installation.UserID])
+		list := []GitHubAppInstallation{*installation}
+		for _, inst := range existing {
+			sameAccount := installation.GitHubUserID != "" && strings.EqualFold(inst.GitHubUserID, installation.GitHubUserID)
+			if inst.InstallationID != installation.InstallationID && !sameAccount {
+				list = append(list, inst)
+			}
+		}
+		b, err := json.Marshal(list)
 		if err != nil {
 			return fmt.Errorf("failed to marshal installation: %w", err)
 		}
@@ -339,33 +370,57 @@ func storeGitHubInstallation(ctx context.Context, projectName string, installati
 	return fmt.Errorf("failed to update ConfigMap after retries")
 }
 
-// GetGitHubInstallation retrieves GitHub App installation for a user
-func GetGitHubInstallation(ctx context.Context, userID string) (*GitHubAppInstallation, error) {
-	const cmName = "github-app-installations"
-	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, cmName, v1.GetOptions{})
+// GetGitHubInstallations returns all GitHub App installations linked by a user, most recently
+// updated first
+func GetGitHubInstallations(ctx context.Context, userID string) ([]GitHubAppInstallation, error) {
+	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, githubInstallationsConfigMap, v1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return nil, fmt.Errorf("installation not found")
 		}
 		return nil, fmt.Errorf("failed to read ConfigMap: %w", err)
 	}
-	if cm.Data == nil {
-		return nil, fmt.Errorf("installation not found")
-	}
 	raw, ok := cm.Data[userID]
 	if !ok || raw == "" {
 		return nil, fmt.Errorf("installation not found")
 	}
-	var inst GitHubAppInstallation
-	if err := json.Unmarshal([]byte(raw), &inst); err != nil {
+	list, err := decodeGitHubInstallations(raw)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode installation: %w", err)
 	}
-	return &inst, nil
+	if len(list) == 0 {
+		return nil, fmt.Errorf("installation not found")
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].UpdatedAt.After(list[j].UpdatedAt) })
+	return list, nil
+}
+
+// GetGitHubInstallation retrieves the GitHub App installation for a user that was linked last
+func GetGitHubInstallation(ctx context.Context, userID string) (*GitHubAppInstallation, error) {
+	return GetGitHubInstallationForOwner(ctx, userID, "")
+}
+
+// GetGitHubInstallationForOwner retrieves the user's GitHub App installation on the account that
+// owns a repository. Without an owner, or when no installation is on that account, the one
+// linked last is used, as it was when users could link only one.
+func GetGitHubInstallationForOwner(ctx context.Context, userID, owner string) (*GitHubAppInstallation, error) {
+	list, err := GetGitHubInstallations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" {
+		for i := range list {
+			if strings.EqualFold(list[i].GitHubUserID, owner) {
+				return &list[i], nil
+			}
+		}
+	}
+	return &list[0], nil
 }
 
 // deleteGitHubInstallation removes the user mapping from ConfigMap
 func deleteGitHubInstallation(ctx context.Context, userID string) error {
-	const cmName = "github-app-installations"
+	const cmName = githubInstallationsConfigMap
 	cm, err := K8sClient.CoreV1().ConfigMaps(Namespace).Get(ctx, cmName, v1.GetOptions{})
 	if err != nil {
 		return err
@@ -385,14 +440,14 @@ func deleteGitHubInstallation(ctx context.Context, userID string) error {
 func LinkGitHubInstallationGlobal(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	if userID == nil || strings.TrimSpace(userID.(string)) == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user identity"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "missing user identity", nil)
 		return
 	}
 	var req struct {
 		InstallationID int64 `json:"installationId" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 	installation := GitHubAppInstallation{
@@ -423,7 +478,7 @@ func LinkGitHubInstallationGlobal(c *gin.Context) {
 		}
 	}
 	if err := storeGitHubInstallation(c.Request.Context(), "", &installation); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store installation"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to store installation", nil)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "GitHub App installation linked successfully", "installationId": req.InstallationID})
@@ -433,7 +488,7 @@ func LinkGitHubInstallationGlobal(c *gin.Context) {
 func GetGitHubStatusGlobal(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	if userID == nil || strings.TrimSpace(userID.(string)) == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user identity"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "missing user identity", nil)
 		return
 	}
 	inst, err := GetGitHubInstallation(c.Request.Context(), userID.(string))
@@ -455,11 +510,11 @@ func GetGitHubStatusGlobal(c *gin.Context) {
 func DisconnectGitHubGlobal(c *gin.Context) {
 	userID, _ := c.Get("userID")
 	if userID == nil || strings.TrimSpace(userID.(string)) == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing user identity"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "missing user identity", nil)
 		return
 	}
 	if err := deleteGitHubInstallation(c.Request.Context(), userID.(string)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlink installation"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to unlink installation", nil)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "GitHub account disconnected"})
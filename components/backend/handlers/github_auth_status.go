@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ambient-code-backend/git"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GitHub auth paths reported by GetGitHubAuthStatus, in the order GetGitHubToken tries them
+const (
+	gitHubAuthPathApp  = "app"
+	gitHubAuthPathPAT  = "pat"
+	gitHubAuthPathNone = "none"
+)
+
+// gitHubTokenReasons names the git.GitHubTokenError reasons in error details
+var gitHubTokenReasons = map[error]string{
+	git.ErrNoInstallation:   "noInstallation",
+	git.ErrNoPAT:            "noPAT",
+	git.ErrAppMisconfigured: "appMisconfigured",
+	git.ErrUpstreamGitHub:   "upstreamGitHub",
+}
+
+// respondGitHubTokenError answers a GetGitHubToken failure it can classify: missing or broken
+// credentials are a 412 whose details.remediation a client can turn into a setup prompt, a GitHub
+// failure a 502. It reports false for any other error, which the caller answers as before.
+func respondGitHubTokenError(c *gin.Context, err error) bool {
+	var tokenErr *git.GitHubTokenError
+	if !errors.As(err, &tokenErr) {
+		return false
+	}
+	details := gin.H{"reason": gitHubTokenReasons[tokenErr.Reason], "remediation": tokenErr.Remediation}
+	if errors.Is(tokenErr, git.ErrUpstreamGitHub) {
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, tokenErr.Hint, details)
+		return true
+	}
+	respondError(c, http.StatusPreconditionFailed, ErrorKindPreconditionFailed, tokenErr.Hint, details)
+	return true
+}
+
+// projectHasGitHubPAT reports whether the project's integration secret holds a GITHUB_TOKEN. It
+// reads with the backend service account and only reports whether the key is set.
+func projectHasGitHubPAT(c *gin.Context, project string) (bool, error) {
+	if K8sClient == nil {
+		return false, nil
+	}
+	secret, err := K8sClient.CoreV1().Secrets(project).Get(c.Request.Context(), integrationSecretsName, v1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(secret.Data["GITHUB_TOKEN"]) > 0, nil
+}
+
+// GetGitHubAuthStatus handles GET /api/projects/:projectName/github/auth-status
+// Reports which GitHub credential GetGitHubToken would use for the caller: their GitHub App
+// installation, the project's GITHUB_TOKEN, or none, with the remediation for none. It does not
+// mint a token, so a misconfigured App still shows as active.
+func GetGitHubAuthStatus(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	userID := strings.TrimSpace(c.GetString("userID"))
+
+	appAvailable := git.GitHubTokenManager != nil
+	app := gin.H{"available": appAvailable, "installed": false}
+	if userID != "" && K8sClient != nil {
+		if inst, err := GetGitHubInstallation(c.Request.Context(), userID); err == nil {
+			app["installed"] = true
+			app["installationId"] = inst.InstallationID
+			app["host"] = inst.Host
+		}
+	}
+	hasPAT, err := projectHasGitHubPAT(c, project)
+	if err != nil {
+		log.Printf("GetGitHubAuthStatus: failed to read integration secret in %s: %v", project, err)
+		respondK8sError(c, err, "Integration secret not found", "Failed to read GitHub credentials")
+		return
+	}
+
+	resp := gin.H{"app": app, "pat": gin.H{"configured": hasPAT}}
+	switch {
+	case appAvailable && app["installed"] == true:
+		resp["activePath"] = gitHubAuthPathApp
+	case hasPAT:
+		resp["activePath"] = gitHubAuthPathPAT
+	default:
+		resp["activePath"] = gitHubAuthPathNone
+		resp["remediation"] = git.RemediationAddGitHubToken
+		if appAvailable {
+			resp["remediation"] = git.RemediationConnectGitHubApp
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListGitHubInstallations handles GET /api/projects/:projectName/github/installations
+// Lists the caller's GitHub App installations and the account (user or organization) each one
+// covers. GitHub tokens are minted with the installation on the target repo's owner; repos of other
+// owners use the one marked default, the installation linked last.
+func ListGitHubInstallations(c *gin.Context) {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	userID := strings.TrimSpace(c.GetString("userID"))
+	items := []gin.H{}
+	if userID != "" && K8sClient != nil {
+		list, err := GetGitHubInstallations(c.Request.Context(), userID)
+		if err != nil {
+			log.Printf("ListGitHubInstallations: no installations for user %s: %v", userID, err)
+		}
+		for i, inst := range list {
+			items = append(items, gin.H{
+				"installationId": inst.InstallationID,
+				"host":           inst.Host,
+				"account":        inst.GitHubUserID,
+				"updatedAt":      inst.UpdatedAt.UTC().Format(time.RFC3339),
+				"default":        i == 0,
+			})
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"available": git.GitHubTokenManager != nil, "installations": items})
+}
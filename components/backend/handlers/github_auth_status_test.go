@@ -0,0 +1,148 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var _ = Describe("GitHub Auth Status", Label(test_constants.LabelUnit, test_constants.LabelHandlers), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		originalManager, originalNamespace := git.GitHubTokenManager, Namespace
+		Namespace = testNamespace
+		DeferCleanup(func() { git.GitHubTokenManager, Namespace = originalManager, originalNamespace })
+		git.GitHubTokenManager = nil
+	})
+
+	failGitHubToken := func(err error) {
+		GetGitHubToken = func(context.Context, kubernetes.Interface, dynamic.Interface, string, string, string) (string, error) {
+			return "", err
+		}
+	}
+
+	Describe("requireSessionGitHubToken", func() {
+		spec := map[string]interface{}{"userContext": map[string]interface{}{"userId": "alice"}}
+
+		require := func(urls ...string) (string, bool) {
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/s1/github/push-all", nil)
+			httpUtils.SetAuthHeader("test-token")
+			return requireSessionGitHubToken(c, testNamespace, spec, urls)
+		}
+
+		It("Should answer a GitHub push without credentials with 412 and a remediation", func() {
+			failGitHubToken(&git.GitHubTokenError{Reason: git.ErrNoPAT, Remediation: git.RemediationAddGitHubToken, Hint: "Add GITHUB_TOKEN to the project's integration secret"})
+
+			_, ok := require("https://github.com/org/repo")
+
+			Expect(ok).To(BeFalse())
+			errorObj := httpUtils.AssertErrorResponse(http.StatusPreconditionFailed, "PreconditionFailed", "Add GITHUB_TOKEN to the project's integration secret")
+			Expect(errorObj["details"]).To(Equal(map[string]interface{}{"reason": "noPAT", "remediation": "add-github-token"}))
+		})
+
+		It("Should answer a GitHub failure with 502", func() {
+			failGitHubToken(&git.GitHubTokenError{Reason: git.ErrUpstreamGitHub, Remediation: git.RemediationRetry, Hint: "GitHub did not issue a token; retry shortly"})
+
+			_, ok := require("https://github.com/org/repo")
+
+			Expect(ok).To(BeFalse())
+			httpUtils.AssertErrorResponse(http.StatusBadGateway, "UpstreamUnavailable", "GitHub did not issue a token; retry shortly")
+		})
+
+		It("Should push without a token when no output repo is on GitHub or the error is unclassified", func() {
+			failGitHubToken(&git.GitHubTokenError{Reason: git.ErrNoInstallation, Remediation: git.RemediationConnectGitHubApp})
+			token, ok := require("https://gitlab.com/org/repo")
+			Expect(ok).To(BeTrue())
+			Expect(token).To(BeEmpty())
+
+			failGitHubToken(fmt.Errorf("kubernetes client is not a *Clientset"))
+			token, ok = require("https://github.com/org/repo")
+			Expect(ok).To(BeTrue())
+			Expect(token).To(BeEmpty())
+		})
+	})
+
+	Describe("GetGitHubAuthStatus", func() {
+		getStatus := func() map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/github/auth-status", nil)
+			httpUtils.SetAuthHeader("test-token")
+			c.Params = gin.Params{{Key: "projectName", Value: testNamespace}}
+			c.Set("userID", "alice")
+			GetGitHubAuthStatus(c)
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			return resp
+		}
+
+		createSecret := func(name string, data map[string][]byte) {
+			_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Data:       data,
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		It("Should report no auth path with the remediation for the platform", func() {
+			resp := getStatus()
+
+			Expect(resp["activePath"]).To(Equal("none"))
+			Expect(resp["remediation"]).To(Equal("add-github-token"))
+			Expect(resp["pat"]).To(Equal(map[string]interface{}{"configured": false}))
+		})
+
+		It("Should report the project's PAT", func() {
+			createSecret(integrationSecretsName, map[string][]byte{"GITHUB_TOKEN": []byte("ghp_x")})
+
+			resp := getStatus()
+
+			Expect(resp["activePath"]).To(Equal("pat"))
+			Expect(resp).NotTo(HaveKey("remediation"))
+		})
+
+		It("Should prefer the caller's GitHub App installation", func() {
+			git.GitHubTokenManager = struct{}{}
+			createSecret(integrationSecretsName, map[string][]byte{"GITHUB_TOKEN": []byte("ghp_x")})
+			_, err := k8sUtils.K8sClient.CoreV1().ConfigMaps(testNamespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{Name: "github-app-installations", Namespace: testNamespace},
+				Data:       map[string]string{"alice": `{"userId":"alice","installationId":77,"host":"github.com"}`},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			resp := getStatus()
+
+			Expect(resp["activePath"]).To(Equal("app"))
+			Expect(resp["app"]).To(HaveKeyWithValue("installationId", float64(77)))
+		})
+	})
+})
@@ -110,19 +110,13 @@ func (h *GitLabAuthHandler) ConnectGitLab(c *gin.Context) {
 	// Get project from URL parameter
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Project name is required",
-			"statusCode": http.StatusBadRequest,
-		})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	var req ConnectGitLabRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Invalid request body",
-			"statusCode": http.StatusBadRequest,
-		})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
 		return
 	}
 
@@ -133,49 +127,34 @@ func (h *GitLabAuthHandler) ConnectGitLab(c *gin.Context) {
 
 	// Validate input
 	if err := validateGitLabInput(req.InstanceURL, req.PersonalAccessToken); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      fmt.Sprintf("Invalid input: %v", err),
-			"statusCode": http.StatusBadRequest,
-		})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Invalid input: %v", err), nil)
 		return
 	}
 
 	// Get user ID from context (set by authentication middleware)
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "User not authenticated",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User not authenticated", nil)
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Invalid user ID format",
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Invalid user ID format", nil)
 		return
 	}
 
 	// RBAC: Verify user can create/update secrets in this project
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "Invalid or missing token",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	ctx := c.Request.Context()
 	if err := ValidateSecretAccess(ctx, reqK8s, project, "create"); err != nil {
 		gitlab.LogError("RBAC check failed for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "Insufficient permissions to manage GitLab credentials",
-			"statusCode": http.StatusForbidden,
-		})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to manage GitLab credentials", nil)
 		return
 	}
 
@@ -183,10 +162,7 @@ func (h *GitLabAuthHandler) ConnectGitLab(c *gin.Context) {
 	connection, err := h.connectionManager.StoreGitLabConnection(ctx, userIDStr, req.PersonalAccessToken, req.InstanceURL)
 	if err != nil {
 		gitlab.LogError("Failed to store GitLab connection for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      err.Error(),
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to connect GitLab account: verify the token and instance URL", nil)
 		return
 	}
 
@@ -205,49 +181,34 @@ func (h *GitLabAuthHandler) GetGitLabStatus(c *gin.Context) {
 	// Get project from URL parameter
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Project name is required",
-			"statusCode": http.StatusBadRequest,
-		})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "User not authenticated",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User not authenticated", nil)
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Invalid user ID format",
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Invalid user ID format", nil)
 		return
 	}
 
 	// RBAC: Verify user can read secrets in this project
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "Invalid or missing token",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	ctx := c.Request.Context()
 	if err := ValidateSecretAccess(ctx, reqK8s, project, "get"); err != nil {
 		gitlab.LogError("RBAC check failed for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "Insufficient permissions to read GitLab credentials",
-			"statusCode": http.StatusForbidden,
-		})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to read GitLab credentials", nil)
 		return
 	}
 
@@ -255,10 +216,7 @@ func (h *GitLabAuthHandler) GetGitLabStatus(c *gin.Context) {
 	status, err := h.connectionManager.GetConnectionStatus(ctx, userIDStr)
 	if err != nil {
 		gitlab.LogError("Failed to get GitLab status for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Failed to retrieve GitLab connection status",
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to retrieve GitLab connection status", nil)
 		return
 	}
 
@@ -282,59 +240,41 @@ func (h *GitLabAuthHandler) DisconnectGitLab(c *gin.Context) {
 	// Get project from URL parameter
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":      "Project name is required",
-			"statusCode": http.StatusBadRequest,
-		})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	// Get user ID from context
 	userID, exists := c.Get("userID")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "User not authenticated",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User not authenticated", nil)
 		return
 	}
 
 	userIDStr, ok := userID.(string)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Invalid user ID format",
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Invalid user ID format", nil)
 		return
 	}
 
 	// RBAC: Verify user can update secrets in this project
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error":      "Invalid or missing token",
-			"statusCode": http.StatusUnauthorized,
-		})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	ctx := c.Request.Context()
 	if err := ValidateSecretAccess(ctx, reqK8s, project, "update"); err != nil {
 		gitlab.LogError("RBAC check failed for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":      "Insufficient permissions to manage GitLab credentials",
-			"statusCode": http.StatusForbidden,
-		})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to manage GitLab credentials", nil)
 		return
 	}
 
 	// Delete GitLab connection (project-scoped)
 	if err := h.connectionManager.DeleteGitLabConnection(ctx, userIDStr); err != nil {
 		gitlab.LogError("Failed to disconnect GitLab for user %s in project %s: %v", userIDStr, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":      "Failed to disconnect GitLab account",
-			"statusCode": http.StatusInternalServerError,
-		})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to disconnect GitLab account", nil)
 		return
 	}
 
@@ -352,14 +292,14 @@ func ConnectGitLabGlobal(c *gin.Context) {
 	// Get project from URL parameter - this is the namespace where tokens will be stored
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	// Get user-scoped K8s client (RBAC enforcement)
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -374,14 +314,14 @@ func GetGitLabStatusGlobal(c *gin.Context) {
 	// Get project from URL parameter
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	// Get user-scoped K8s client (RBAC enforcement)
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -396,14 +336,14 @@ func DisconnectGitLabGlobal(c *gin.Context) {
 	// Get project from URL parameter
 	project := c.Param("projectName")
 	if project == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	// Get user-scoped K8s client (RBAC enforcement)
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -145,11 +145,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid input: token must be at least 20 characters",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid input: token must be at least 20 characters")
 			})
 
 			It("Should reject tokens that are too long", func() {
@@ -172,11 +168,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid input: token must not exceed 255 characters",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid input: token must not exceed 255 characters")
 			})
 
 			It("Should reject tokens with invalid characters", func() {
@@ -221,11 +213,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 					ConnectGitLabGlobal(context)
 
-					httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-					httpUtils.AssertJSONContains(map[string]interface{}{
-						"error":      "Invalid input: token contains invalid characters",
-						"statusCode": http.StatusBadRequest,
-					})
+					httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid input: token contains invalid characters")
 
 					// Reset for next test
 					httpUtils = test_utils.NewHTTPTestUtils()
@@ -282,11 +270,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid input: instance URL must use HTTPS",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid input: instance URL must use HTTPS")
 			})
 
 			It("Should reject malformed URLs", func() {
@@ -380,11 +364,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Project name is required",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Project name is required")
 			})
 
 			It("Should require valid JSON body", func() {
@@ -397,11 +377,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid request body",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid request body")
 			})
 
 			It("Should require personalAccessToken field", func() {
@@ -419,11 +395,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid request body",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid request body")
 			})
 
 			It("Should require user authentication", func() {
@@ -440,11 +412,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid or missing token",
-					"statusCode": http.StatusUnauthorized,
-				})
+				httpUtils.AssertErrorResponse(http.StatusUnauthorized, "Unauthorized", "Invalid or missing token")
 			})
 
 			It("Should handle invalid user ID type", func() {
@@ -462,11 +430,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				ConnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusInternalServerError)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid user ID format",
-					"statusCode": http.StatusInternalServerError,
-				})
+				httpUtils.AssertErrorResponse(http.StatusInternalServerError, "Internal", "Invalid user ID format")
 			})
 
 			// Note: RBAC permission checks are tested at integration level
@@ -481,11 +445,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				GetGitLabStatusGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Project name is required",
-					"statusCode": http.StatusBadRequest,
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Project name is required")
 			})
 
 			It("Should require user authentication", func() {
@@ -497,11 +457,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				GetGitLabStatusGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid or missing token",
-					"statusCode": http.StatusUnauthorized,
-				})
+				httpUtils.AssertErrorResponse(http.StatusUnauthorized, "Unauthorized", "Invalid or missing token")
 			})
 
 			It("Should handle invalid user ID type", func() {
@@ -514,11 +470,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				GetGitLabStatusGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusInternalServerError)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid user ID format",
-					"statusCode": http.StatusInternalServerError,
-				})
+				httpUtils.AssertErrorResponse(http.StatusInternalServerError, "Internal", "Invalid user ID format")
 			})
 
 			// Note: RBAC permission checks are tested at integration level
@@ -533,10 +485,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				DisconnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Project name is required",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Project name is required")
 			})
 
 			It("Should require user authentication", func() {
@@ -548,11 +497,7 @@ var _ = Describe("GitLab Auth Handler", Label(test_constants.LabelUnit, test_con
 
 				DisconnectGitLabGlobal(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error":      "Invalid or missing token",
-					"statusCode": http.StatusUnauthorized,
-				})
+				httpUtils.AssertErrorResponse(http.StatusUnauthorized, "Unauthorized", "Invalid or missing token")
 			})
 
 			// Note: RBAC permission checks are tested at integration level
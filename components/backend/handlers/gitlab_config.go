@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/gitlab"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// gitLabCABundleDefaultKey is the Secret key read when caBundleSecretRef.key is empty
+const gitLabCABundleDefaultKey = "ca.crt"
+
+// gitLabTokenSecretName holds the project-level token selected by spec.gitlab.tokenSecretKey
+const gitLabTokenSecretName = "ambient-non-vertex-integrations"
+
+// projectSettingsGitLab reads spec.gitlab from a ProjectSettings object; nil when unset
+func projectSettingsGitLab(obj *unstructured.Unstructured) (*types.GitLabInstanceConfig, error) {
+	raw, found, err := unstructured.NestedMap(obj.Object, "spec", "gitlab")
+	if err != nil || !found {
+		return nil, err
+	}
+	var cfg types.GitLabInstanceConfig
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("spec.gitlab: %w", err)
+	}
+	if strings.TrimSpace(cfg.BaseURL) == "" {
+		return nil, nil
+	}
+	return &cfg, nil
+}
+
+// validateGitLabInstanceConfig checks a spec.gitlab before it is written and defaults the CA bundle key
+func validateGitLabInstanceConfig(cfg *types.GitLabInstanceConfig) error {
+	cfg.BaseURL = strings.TrimRight(strings.TrimSpace(cfg.BaseURL), "/")
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("baseUrl must be an https URL")
+	}
+	if u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return fmt.Errorf("baseUrl must not contain credentials, a query or a fragment")
+	}
+	if ref := cfg.CABundleSecretRef; ref != nil {
+		ref.Name = strings.TrimSpace(ref.Name)
+		if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+			return fmt.Errorf("caBundleSecretRef.name: %s", strings.Join(errs, "; "))
+		}
+		if ref.Key = strings.TrimSpace(ref.Key); ref.Key == "" {
+			ref.Key = gitLabCABundleDefaultKey
+		}
+		if errs := validation.IsConfigMapKey(ref.Key); len(errs) > 0 {
+			return fmt.Errorf("caBundleSecretRef.key: %s", strings.Join(errs, "; "))
+		}
+	}
+	if cfg.TokenSecretKey = strings.TrimSpace(cfg.TokenSecretKey); cfg.TokenSecretKey != "" {
+		if errs := validation.IsConfigMapKey(cfg.TokenSecretKey); len(errs) > 0 {
+			return fmt.Errorf("tokenSecretKey: %s", strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// gitLabClientConfig turns spec.gitlab into a client config, loading the CA bundle from its Secret
+func gitLabClientConfig(ctx context.Context, secrets kubernetes.Interface, project string, cfg *types.GitLabInstanceConfig, token string) (gitlab.Config, error) {
+	clientCfg := gitlab.Config{
+		APIURL:                strings.TrimRight(cfg.BaseURL, "/") + "/api/v4",
+		Token:                 token,
+		InsecureSkipTLSVerify: cfg.InsecureSkipTLSVerify,
+	}
+	if ref := cfg.CABundleSecretRef; ref != nil && ref.Name != "" {
+		key := ref.Key
+		if key == "" {
+			key = gitLabCABundleDefaultKey
+		}
+		sec, err := secrets.CoreV1().Secrets(project).Get(ctx, ref.Name, v1.GetOptions{})
+		if err != nil {
+			return gitlab.Config{}, fmt.Errorf("read CA bundle secret %s: %w", ref.Name, err)
+		}
+		if len(sec.Data[key]) == 0 {
+			return gitlab.Config{}, fmt.Errorf("CA bundle secret %s has no key %s", ref.Name, key)
+		}
+		clientCfg.CABundle = sec.Data[key]
+	}
+	return clientCfg, nil
+}
+
+// projectGitLabClient builds the GitLab client for a repo. When the repo lives on the instance
+// configured in the project's spec.gitlab, the configured API base, CA bundle and TLS settings are
+// used; the CA Secret is read with the backend service account since editors cannot read Secrets.
+func projectGitLabClient(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string, parsed *types.ParsedGitLabRepo, token string) (*gitlab.Client, error) {
+	obj, ok := cachedGet(c, reqK8s, project, GetProjectSettingsResource(), "projectsettings")
+	if !ok && reqDyn != nil {
+		obj, _ = reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	}
+	if obj != nil {
+		cfg, err := projectSettingsGitLab(obj)
+		if err != nil {
+			log.Printf("Ignoring invalid spec.gitlab in %s: %v", project, err)
+		} else if cfg != nil && gitlab.ExtractHost(cfg.BaseURL) == parsed.Host {
+			secrets := K8sClient
+			if secrets == nil {
+				secrets = reqK8s
+			}
+			clientCfg, err := gitLabClientConfig(c.Request.Context(), secrets, project, cfg, token)
+			if err != nil {
+				return nil, err
+			}
+			return gitlab.NewClient(clientCfg)
+		}
+	}
+	return gitlab.NewClient(gitlab.Config{APIURL: parsed.APIURL, Token: token})
+}
+
+// projectGitLabToken returns the token used against the project's configured GitLab instance: the
+// tokenSecretKey entry of ambient-non-vertex-integrations, or else the caller's connected GitLab
+// token. When neither is available it returns the reason instead; err is set only when the
+// integration secrets cannot be read.
+func projectGitLabToken(ctx context.Context, secrets kubernetes.Interface, project, userID string, cfg *types.GitLabInstanceConfig) (token, missing string, err error) {
+	if cfg.TokenSecretKey != "" {
+		sec, err := secrets.CoreV1().Secrets(project).Get(ctx, gitLabTokenSecretName, v1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return "", "", err
+		}
+		if sec != nil {
+			token = strings.TrimSpace(string(sec.Data[cfg.TokenSecretKey]))
+		}
+		if token == "" {
+			return "", fmt.Sprintf("%s has no key %s", gitLabTokenSecretName, cfg.TokenSecretKey), nil
+		}
+		return token, "", nil
+	}
+	if token, err = git.GetGitLabToken(ctx, secrets, project, userID); err != nil {
+		return "", "No tokenSecretKey is configured and you have no connected GitLab account", nil
+	}
+	return token, "", nil
+}
+
+// requireProjectAdmin responds 403 unless the caller may update ProjectSettings (project admins only)
+func requireProjectAdmin(c *gin.Context, reqK8s kubernetes.Interface, project string) bool {
+	allowed, err := checkUserCanModifyProject(reqK8s, project)
+	if err != nil {
+		log.Printf("ProjectSettings SSAR failed for project %s: %v", project, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+		return false
+	}
+	if !allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Project admin access required", nil)
+		return false
+	}
+	return true
+}
+
+// GetGitLabConfig returns the project's GitLab instance configuration ({} when unset).
+// GET /api/projects/:projectName/gitlab-config
+func GetGitLabConfig(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if !requireProjectAdmin(c, reqK8s, project) {
+		return
+	}
+
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return
+	}
+	cfg, err := projectSettingsGitLab(obj)
+	if err != nil {
+		log.Printf("Invalid spec.gitlab in %s: %v", project, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Stored GitLab configuration is invalid", nil)
+		return
+	}
+	if cfg == nil {
+		cfg = &types.GitLabInstanceConfig{}
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// UpdateGitLabConfig sets spec.gitlab on the project's ProjectSettings. An empty baseUrl removes it.
+// PUT /api/projects/:projectName/gitlab-config
+// Body: { baseUrl, insecureSkipTLSVerify?, caBundleSecretRef?: {name, key?}, tokenSecretKey? }
+func UpdateGitLabConfig(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if !requireProjectAdmin(c, reqK8s, project) {
+		return
+	}
+
+	var cfg types.GitLabInstanceConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+	remove := strings.TrimSpace(cfg.BaseURL) == ""
+	var value map[string]interface{}
+	if !remove {
+		if err := validateGitLabInstanceConfig(&cfg); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+		var err error
+		if value, err = runtime.DefaultUnstructuredConverter.ToUnstructured(&cfg); err != nil {
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare update", nil)
+			return
+		}
+	}
+
+	gvr := GetProjectSettingsResource()
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if remove {
+			unstructured.RemoveNestedField(obj.Object, "spec", "gitlab")
+		} else if err := unstructured.SetNestedMap(obj.Object, value, "spec", "gitlab"); err != nil {
+			return err
+		}
+		_, err = reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), obj, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("Failed to update GitLab config in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to update GitLab configuration")
+		return
+	}
+	if remove {
+		cfg = types.GitLabInstanceConfig{}
+	}
+	c.JSON(http.StatusOK, cfg)
+}
+
+// TestGitLabConfig checks the configured instance with GET /api/v4/version using the configured CA
+// and credentials: the tokenSecretKey entry of ambient-non-vertex-integrations, or else the caller's
+// connected GitLab token. A failed check is reported as success=false with the reason.
+// POST /api/projects/:projectName/gitlab-config/test
+func TestGitLabConfig(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if !requireProjectAdmin(c, reqK8s, project) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return
+	}
+	cfg, err := projectSettingsGitLab(obj)
+	if err != nil || cfg == nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "No GitLab instance is configured for this project", nil)
+		return
+	}
+
+	userID, _ := c.Get("userID")
+	uid, _ := userID.(string)
+	token, missing, err := projectGitLabToken(ctx, reqK8s, project, uid, cfg)
+	if err != nil {
+		respondK8sError(c, err, "Integration secrets not found", "Failed to read integration secrets")
+		return
+	}
+	if missing != "" {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": missing})
+		return
+	}
+
+	clientCfg, err := gitLabClientConfig(ctx, reqK8s, project, cfg, token)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	client, err := gitlab.NewClient(clientCfg)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	version, err := client.GetVersion(ctx)
+	if err != nil {
+		if gitlabErr, ok := err.(*types.GitLabAPIError); ok {
+			c.JSON(http.StatusOK, gin.H{"success": false, "error": gitlabErr.Error(), "statusCode": gitlabErr.StatusCode})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "version": version.Version, "revision": version.Revision})
+}
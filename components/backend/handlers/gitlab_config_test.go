@@ -0,0 +1,183 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("GitLab Instance Config", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelGitLabAuth), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testToken     string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       map[string]interface{}{},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	call := func(handler gin.HandlerFunc, method, path string, body interface{}) {
+		context := httpUtils.CreateTestGinContext(method, fmt.Sprintf("/api/projects/%s/%s", testNamespace, path), body)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}}
+		handler(context)
+	}
+
+	decode := func() map[string]interface{} {
+		var body map[string]interface{}
+		httpUtils.GetResponseJSON(&body)
+		return body
+	}
+
+	It("Should reject non-https base URLs and invalid secret references", func() {
+		Expect(validateGitLabInstanceConfig(&types.GitLabInstanceConfig{BaseURL: "http://gitlab.corp.example"})).To(MatchError("baseUrl must be an https URL"))
+		Expect(validateGitLabInstanceConfig(&types.GitLabInstanceConfig{BaseURL: "https://user:pw@gitlab.corp.example"})).To(HaveOccurred())
+		Expect(validateGitLabInstanceConfig(&types.GitLabInstanceConfig{
+			BaseURL:           "https://gitlab.corp.example",
+			CABundleSecretRef: &types.GitLabSecretKeyRef{Name: "Not_A_Name"},
+		})).To(HaveOccurred())
+
+		cfg := &types.GitLabInstanceConfig{
+			BaseURL:           "https://gitlab.corp.example/",
+			CABundleSecretRef: &types.GitLabSecretKeyRef{Name: "corp-ca"},
+		}
+		Expect(validateGitLabInstanceConfig(cfg)).To(Succeed())
+		Expect(cfg.BaseURL).To(Equal("https://gitlab.corp.example"))
+		Expect(cfg.CABundleSecretRef.Key).To(Equal(gitLabCABundleDefaultKey))
+	})
+
+	It("Should store the config on ProjectSettings and return it", func() {
+		call(UpdateGitLabConfig, "PUT", "gitlab-config", map[string]interface{}{
+			"baseUrl":           "https://gitlab.corp.example",
+			"caBundleSecretRef": map[string]interface{}{"name": "corp-ca"},
+			"tokenSecretKey":    "GITLAB_TOKEN",
+		})
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+
+		obj, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Get(ctx, "projectsettings", v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		key, _, _ := unstructured.NestedString(obj.Object, "spec", "gitlab", "caBundleSecretRef", "key")
+		Expect(key).To(Equal("ca.crt"))
+
+		call(GetGitLabConfig, "GET", "gitlab-config", nil)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		body := decode()
+		Expect(body["baseUrl"]).To(Equal("https://gitlab.corp.example"))
+		Expect(body["tokenSecretKey"]).To(Equal("GITLAB_TOKEN"))
+
+		call(UpdateGitLabConfig, "PUT", "gitlab-config", map[string]interface{}{"baseUrl": ""})
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		obj, err = k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Get(ctx, "projectsettings", v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, found, _ := unstructured.NestedMap(obj.Object, "spec", "gitlab")
+		Expect(found).To(BeFalse())
+	})
+
+	It("Should require project admin access", func() {
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+		call(UpdateGitLabConfig, "PUT", "gitlab-config", map[string]interface{}{"baseUrl": "https://gitlab.corp.example"})
+
+		httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Project admin access required")
+	})
+
+	It("Should reach /api/v4/version through the configured CA bundle", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/v4/version" || r.Header.Get("Authorization") != "Bearer glpat-project" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"version":"17.2.1-ee","revision":"abc123"}`))
+		}))
+		defer server.Close()
+
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		for name, data := range map[string]map[string][]byte{
+			"corp-ca":             {"ca.crt": caPEM},
+			gitLabTokenSecretName: {"GITLAB_TOKEN": []byte("glpat-project")},
+		} {
+			_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Data:       data,
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		call(UpdateGitLabConfig, "PUT", "gitlab-config", map[string]interface{}{
+			"baseUrl":           server.URL,
+			"caBundleSecretRef": map[string]interface{}{"name": "corp-ca"},
+			"tokenSecretKey":    "GITLAB_TOKEN",
+		})
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+
+		call(TestGitLabConfig, "POST", "gitlab-config/test", nil)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		body := decode()
+		Expect(body["success"]).To(BeTrue(), "response: %v", body)
+		Expect(body["version"]).To(Equal("17.2.1-ee"))
+	})
+
+	It("Should report a TLS failure when no CA bundle is configured", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"version":"17.2.1-ee"}`))
+		}))
+		defer server.Close()
+
+		_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: gitLabTokenSecretName, Namespace: testNamespace},
+			Data:       map[string][]byte{"GITLAB_TOKEN": []byte("glpat-project")},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		call(UpdateGitLabConfig, "PUT", "gitlab-config", map[string]interface{}{"baseUrl": server.URL, "tokenSecretKey": "GITLAB_TOKEN"})
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+
+		call(TestGitLabConfig, "POST", "gitlab-config/test", nil)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(decode()["success"]).To(BeFalse())
+	})
+})
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ambient-code-backend/gitlab"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// gitLabProjectIDPattern matches the numeric GitLab project ids the picker passes back
+var gitLabProjectIDPattern = regexp.MustCompile(`^[0-9]+$`)
+
+// requireProjectGitLabClient builds a client for the project's configured GitLab instance
+// (spec.gitlab) with its configured token. The token and CA Secrets are read with the backend
+// service account since editors cannot read Secrets; it writes the error response itself.
+func requireProjectGitLabClient(c *gin.Context, project string) (*gitlab.Client, bool) {
+	client, _, ok := requireProjectGitLab(c, project)
+	return client, ok
+}
+
+// requireProjectGitLab is requireProjectGitLabClient also returning the token the client uses
+func requireProjectGitLab(c *gin.Context, project string) (*gitlab.Client, string, bool) {
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return nil, "", false
+	}
+	ctx := c.Request.Context()
+	obj, ok := cachedGet(c, reqK8s, project, GetProjectSettingsResource(), "projectsettings")
+	if !ok {
+		var err error
+		if obj, err = reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{}); err != nil {
+			respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+			return nil, "", false
+		}
+	}
+	cfg, err := projectSettingsGitLab(obj)
+	if err != nil || cfg == nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "No GitLab instance is configured for this project", nil)
+		return nil, "", false
+	}
+
+	secrets := K8sClient
+	if secrets == nil {
+		secrets = reqK8s
+	}
+	userID, _ := c.Get("userID")
+	uid, _ := userID.(string)
+	token, missing, err := projectGitLabToken(ctx, secrets, project, uid, cfg)
+	if err != nil {
+		log.Printf("Failed to read GitLab token for project %s: %v", project, err)
+		respondK8sError(c, err, "Integration secrets not found", "Failed to read integration secrets")
+		return nil, "", false
+	}
+	if missing != "" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, missing, nil)
+		return nil, "", false
+	}
+	clientCfg, err := gitLabClientConfig(ctx, secrets, project, cfg, token)
+	if err == nil {
+		var client *gitlab.Client
+		if client, err = gitlab.NewClient(clientCfg); err == nil {
+			return client, token, true
+		}
+	}
+	log.Printf("Failed to configure GitLab client for project %s: %v", project, err)
+	respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to configure GitLab client", nil)
+	return nil, "", false
+}
+
+// respondGitLabError relays a GitLab API failure
+func respondGitLabError(c *gin.Context, err error) {
+	if gitlabErr, ok := err.(*types.GitLabAPIError); ok {
+		respondError(c, gitlabErr.StatusCode, errorKindForStatus(gitlabErr.StatusCode), gitlabErr.Error(), nil)
+		return
+	}
+	log.Printf("GitLab request failed: %v", err)
+	respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitLab request failed", nil)
+}
+
+// setGitLabPaginationHeaders passes GitLab's pagination headers through. GitLab leaves out the
+// totals for very large result sets, so only the values it sent are set.
+func setGitLabPaginationHeaders(c *gin.Context, p *gitlab.PaginationInfo) {
+	for header, value := range map[string]int{
+		"X-Page":        p.CurrentPage,
+		"X-Per-Page":    p.PerPage,
+		"X-Next-Page":   p.NextPage,
+		"X-Prev-Page":   p.PrevPage,
+		"X-Total":       p.Total,
+		"X-Total-Pages": p.TotalPages,
+	} {
+		if value > 0 {
+			c.Header(header, strconv.Itoa(value))
+		}
+	}
+}
+
+// gitLabPageQuery reads the page and perPage query parameters (perPage at most 100)
+func gitLabPageQuery(c *gin.Context) (page, perPage int, ok bool) {
+	for _, q := range []struct {
+		name string
+		dst  *int
+	}{{"page", &page}, {"perPage", &perPage}} {
+		raw := strings.TrimSpace(c.Query(q.name))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || (q.name == "perPage" && n > 100) {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, q.name+" must be a positive integer (perPage at most 100)", nil)
+			return 0, 0, false
+		}
+		*q.dst = n
+	}
+	return page, perPage, true
+}
+
+// ListGitLabProjects lists the projects of the project's configured GitLab instance for repo
+// pickers, passing GitLab's pagination headers through.
+// GET /api/projects/:projectName/gitlab/projects?search=&membership=true&page=&perPage=
+func ListGitLabProjects(c *gin.Context) {
+	project := c.Param("projectName")
+	page, perPage, ok := gitLabPageQuery(c)
+	if !ok {
+		return
+	}
+	client, ok := requireProjectGitLabClient(c, project)
+	if !ok {
+		return
+	}
+
+	projects, pagination, err := client.ListProjects(c.Request.Context(), gitlab.ProjectListOptions{
+		Search:     strings.TrimSpace(c.Query("search")),
+		Membership: c.Query("membership") == "true",
+		Page:       page,
+		PerPage:    perPage,
+	})
+	if err != nil {
+		respondGitLabError(c, err)
+		return
+	}
+	if projects == nil {
+		projects = []types.GitLabProject{}
+	}
+	setGitLabPaginationHeaders(c, pagination)
+	c.JSON(http.StatusOK, gin.H{"projects": projects})
+}
+
+// ListGitLabProjectBranches lists the branches of a project on the configured GitLab instance.
+// GET /api/projects/:projectName/gitlab/projects/:id/branches?page=&perPage=
+func ListGitLabProjectBranches(c *gin.Context) {
+	project := c.Param("projectName")
+	id := c.Param("id")
+	if !gitLabProjectIDPattern.MatchString(id) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "id must be a numeric GitLab project id", nil)
+		return
+	}
+	page, perPage, ok := gitLabPageQuery(c)
+	if !ok {
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	client, ok := requireProjectGitLabClient(c, project)
+	if !ok {
+		return
+	}
+
+	branches, pagination, err := client.GetBranches(c.Request.Context(), id, page, perPage)
+	if err != nil {
+		respondGitLabError(c, err)
+		return
+	}
+	setGitLabPaginationHeaders(c, pagination)
+	c.JSON(http.StatusOK, gin.H{"branches": gitlab.MapGitLabBranchesToCommon(branches)})
+}
@@ -0,0 +1,176 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("GitLab Project Listing", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelGitLabAuth), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testToken     string
+		server        *httptest.Server
+		projectsQuery string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer glpat-project" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/api/v4/projects":
+				projectsQuery = r.URL.RawQuery
+				w.Header().Set("X-Page", "2")
+				w.Header().Set("X-Per-Page", "1")
+				w.Header().Set("X-Next-Page", "3")
+				w.Header().Set("X-Total-Pages", "4")
+				_, _ = w.Write([]byte(`[{"id":42,"name":"api","path_with_namespace":"platform/backend/api","default_branch":"main",` +
+					`"http_url_to_repo":"https://gitlab.corp.example/platform/backend/api.git","visibility":"internal"}]`))
+			case "/api/v4/projects/42/repository/branches":
+				_, _ = w.Write([]byte(`[{"name":"main","default":true,"protected":true,"commit":{"id":"abc123","title":"init","committed_date":"2026-01-02T03:04:05Z"}}]`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message":"404 Project Not Found"}`))
+			}
+		}))
+		DeferCleanup(server.Close)
+
+		caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		for name, data := range map[string]map[string][]byte{
+			"corp-ca":             {"ca.crt": caPEM},
+			gitLabTokenSecretName: {"GITLAB_TOKEN": []byte("glpat-project")},
+		} {
+			_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+				Data:       data,
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	seedGitLabConfig := func(gitlabSpec map[string]interface{}) {
+		spec := map[string]interface{}{}
+		if gitlabSpec != nil {
+			spec["gitlab"] = gitlabSpec
+		}
+		_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       spec,
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	call := func(handler gin.HandlerFunc, path string, params gin.Params) {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		context := httpUtils.CreateTestGinContext("GET", fmt.Sprintf("/api/projects/%s/%s", testNamespace, path), nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = append(gin.Params{{Key: "projectName", Value: testNamespace}}, params...)
+		handler(context)
+	}
+
+	configured := func() map[string]interface{} {
+		return map[string]interface{}{
+			"baseUrl":           server.URL,
+			"caBundleSecretRef": map[string]interface{}{"name": "corp-ca", "key": "ca.crt"},
+			"tokenSecretKey":    "GITLAB_TOKEN",
+		}
+	}
+
+	It("Should list the configured instance's projects with GitLab's pagination headers", func() {
+		seedGitLabConfig(configured())
+
+		call(ListGitLabProjects, "gitlab/projects?search=api&membership=true&page=2&perPage=1", nil)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(projectsQuery).To(ContainSubstring("search=api"))
+		Expect(projectsQuery).To(ContainSubstring("membership=true"))
+		Expect(projectsQuery).To(ContainSubstring("page=2"))
+		header := httpUtils.GetResponseRecorder().Header()
+		Expect(header.Get("X-Next-Page")).To(Equal("3"))
+		Expect(header.Get("X-Total-Pages")).To(Equal("4"))
+		Expect(header.Get("X-Total")).To(BeEmpty())
+
+		var body struct {
+			Projects []map[string]interface{} `json:"projects"`
+		}
+		httpUtils.GetResponseJSON(&body)
+		Expect(body.Projects).To(HaveLen(1))
+		Expect(body.Projects[0]).To(Equal(map[string]interface{}{
+			"id":                  float64(42),
+			"path_with_namespace": "platform/backend/api",
+			"default_branch":      "main",
+			"http_url_to_repo":    "https://gitlab.corp.example/platform/backend/api.git",
+			"visibility":          "internal",
+		}))
+	})
+
+	It("Should list a project's branches", func() {
+		seedGitLabConfig(configured())
+
+		call(ListGitLabProjectBranches, "gitlab/projects/42/branches", gin.Params{{Key: "id", Value: "42"}})
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var body struct {
+			Branches []map[string]interface{} `json:"branches"`
+		}
+		httpUtils.GetResponseJSON(&body)
+		Expect(body.Branches).To(HaveLen(1))
+		Expect(body.Branches[0]["name"]).To(Equal("main"))
+		Expect(body.Branches[0]["default"]).To(BeTrue())
+	})
+
+	It("Should relay GitLab errors and reject non-numeric ids", func() {
+		seedGitLabConfig(configured())
+
+		call(ListGitLabProjectBranches, "gitlab/projects/7/branches", gin.Params{{Key: "id", Value: "7"}})
+		httpUtils.AssertErrorResponse(http.StatusNotFound, "NotFound", "GitLab repository not found. Verify the repository URL and your access permissions")
+
+		call(ListGitLabProjectBranches, "gitlab/projects/a%2Fb/branches", gin.Params{{Key: "id", Value: "a/b"}})
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "id must be a numeric GitLab project id")
+	})
+
+	It("Should require a configured GitLab instance", func() {
+		seedGitLabConfig(nil)
+
+		call(ListGitLabProjects, "gitlab/projects", nil)
+
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "No GitLab instance is configured for this project")
+	})
+})
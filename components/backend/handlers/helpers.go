@@ -8,7 +8,9 @@ import (
 	"time"
 
 	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 )
@@ -74,3 +76,36 @@ func ValidateSecretAccess(ctx context.Context, k8sClient kubernetes.Interface, n
 
 	return nil
 }
+
+// recordSessionEvent records an event on an AgenticSession with the backend service account, since
+// users are not granted events create. Failures are only logged.
+func recordSessionEvent(ctx context.Context, session *unstructured.Unstructured, eventType, reason, message string) {
+	if K8sClient == nil {
+		return
+	}
+	now := v1.Now()
+	ev := &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			// Same naming scheme as the client-go event recorder
+			Name:      fmt.Sprintf("%s.%x", session.GetName(), now.UnixNano()),
+			Namespace: session.GetNamespace(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: session.GetAPIVersion(),
+			Kind:       session.GetKind(),
+			Name:       session.GetName(),
+			Namespace:  session.GetNamespace(),
+			UID:        session.GetUID(),
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1.EventSource{Component: "backend-api"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := K8sClient.CoreV1().Events(session.GetNamespace()).Create(ctx, ev, v1.CreateOptions{}); err != nil {
+		log.Printf("Failed to record %s event for session %s/%s: %v", reason, session.GetNamespace(), session.GetName(), err)
+	}
+}
@@ -9,7 +9,8 @@ import (
 )
 
 // GetK8sClientsForRequest returns K8s typed and dynamic clients using the caller's token when provided.
-// It supports both Authorization: Bearer and X-Forwarded-Access-Token and NEVER falls back to the backend service account.
+// The token is read by extractRequestToken (Authorization, then X-Forwarded-Access-Token, then the token
+// query parameter); it NEVER falls back to the backend service account.
 // Returns nil, nil if no valid user token is provided - all API operations require user authentication.
 // Returns kubernetes.Interface (not *kubernetes.Clientset) to support both real and fake clients in tests.
 //
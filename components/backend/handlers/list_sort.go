@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ambient-code-backend/types"
+)
+
+// Sort keys accepted by ?sortBy= on list endpoints
+const (
+	sortByCreationTimestamp = "creationTimestamp"
+	sortByCompletionTime    = "completionTime"
+	sortByDisplayName       = "displayName"
+)
+
+// listSort is a validated ?sortBy=&order= pair
+type listSort struct {
+	key  string
+	desc bool
+}
+
+// parseListSort validates params.SortBy against the endpoint's keys (the first is the default)
+// and params.Order. Timestamps default to newest first, names to A-Z.
+func parseListSort(params types.PaginationParams, keys ...string) (listSort, error) {
+	s := listSort{key: keys[0]}
+	if params.SortBy != "" {
+		s.key = ""
+		for _, k := range keys {
+			if params.SortBy == k {
+				s.key = k
+			}
+		}
+		if s.key == "" {
+			return listSort{}, fmt.Errorf("sortBy must be one of %s", strings.Join(keys, ", "))
+		}
+	}
+	switch strings.ToLower(params.Order) {
+	case "":
+		s.desc = s.key != sortByDisplayName
+	case "asc":
+	case "desc":
+		s.desc = true
+	default:
+		return listSort{}, fmt.Errorf("order must be asc or desc")
+	}
+	return s, nil
+}
+
+// sortListStable orders items by value, in s's direction. Items without a value come last in
+// either direction, and ties are broken by name ascending so repeated queries page identically.
+func sortListStable[T any](items []T, s listSort, value, name func(T) string) {
+	sort.SliceStable(items, func(i, j int) bool {
+		vi, vj := value(items[i]), value(items[j])
+		switch {
+		case vi == vj:
+			return name(items[i]) < name(items[j])
+		case vi == "":
+			return false
+		case vj == "":
+			return true
+		case s.desc:
+			return vi > vj
+		default:
+			return vi < vj
+		}
+	})
+}
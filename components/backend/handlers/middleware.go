@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -100,16 +99,18 @@ func getK8sClientsDefault(c *gin.Context) (kubernetes.Interface, dynamic.Interfa
 	return nil, nil
 }
 
-// extractRequestToken extracts a caller token from request headers with consistent semantics across
-// production and test builds.
+// extractRequestToken extracts a caller token from the request with consistent semantics across
+// production and test builds. It is the only place the backend reads the caller's token; handlers
+// that call other services forward it with forwardCallerToken.
 //
 // Supported sources (in priority order):
 //  1. Authorization: Bearer <token>  (or raw token)
-//  2. X-Forwarded-Access-Token: <token>
+//  2. X-Forwarded-Access-Token: <token>  (set by an OAuth proxy)
+//  3. ?token=<token>  (websocket and agent callers that cannot set headers)
 //
 // Returns:
 //   - token: trimmed token ("" if none)
-//   - tokenSource: "authorization", "x-forwarded-access-token", or "none"
+//   - tokenSource: "authorization", "x-forwarded-access-token", "query", or "none"
 //   - hasAuthHeader/hasFwdToken: basic presence booleans (safe for logging; never log token content)
 func extractRequestToken(c *gin.Context) (token string, tokenSource string, hasAuthHeader bool, hasFwdToken bool) {
 	rawAuth := c.GetHeader("Authorization")
@@ -135,6 +136,13 @@ func extractRequestToken(c *gin.Context) (token string, tokenSource string, hasA
 		token = strings.TrimSpace(rawFwd)
 	}
 
+	// Fallback to the token query parameter, only when neither header was sent
+	if !hasAuthHeader && !hasFwdToken {
+		if qp := strings.TrimSpace(c.Query("token")); qp != "" {
+			return qp, "query", false, false
+		}
+	}
+
 	if strings.TrimSpace(token) == "" {
 		// Preserve the source if the header existed but was malformed/empty after parsing.
 		if hasAuthHeader {
@@ -148,8 +156,18 @@ func extractRequestToken(c *gin.Context) (token string, tokenSource string, hasA
 	return token, tokenSource, hasAuthHeader, hasFwdToken
 }
 
+// forwardCallerToken sets the caller's token on a request to another backend-facing service as
+// Authorization: Bearer, whichever way the caller sent it. X-Forwarded-Access-Token is never
+// forwarded, so downstream services only ever see one token header.
+func forwardCallerToken(c *gin.Context, h http.Header) {
+	h.Del("X-Forwarded-Access-Token")
+	if token, _, _, _ := extractRequestToken(c); token != "" {
+		h.Set("Authorization", "Bearer "+token)
+	}
+}
+
 // updateAccessKeyLastUsedAnnotation attempts to update the ServiceAccount's last-used annotation
-// when the incoming token is a ServiceAccount JWT. Uses the backend service account client strictly
+// when the incoming token authenticates as a ServiceAccount. Uses the backend service account client strictly
 // for this telemetry update and only for SAs labeled app=ambient-access-key. Best-effort; errors ignored.
 //
 // RBAC:
@@ -160,48 +178,16 @@ func extractRequestToken(c *gin.Context) (token string, tokenSource string, hasA
 // - Only updates the last-used-at annotation (no other metadata changes)
 // - Best-effort operation with all errors ignored (cannot disrupt user requests)
 func updateAccessKeyLastUsedAnnotation(c *gin.Context) {
-	// Parse Authorization header
-	rawAuth := c.GetHeader("Authorization")
-	parts := strings.SplitN(rawAuth, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return
-	}
-	token := strings.TrimSpace(parts[1])
+	token, _, _, _ := extractRequestToken(c)
 	if token == "" {
 		return
 	}
 
-	// Decode JWT payload (second segment)
-	segs := strings.Split(token, ".")
-	if len(segs) < 2 {
-		return
-	}
-	payloadB64 := segs[1]
-	// JWT uses base64url without padding; add padding if necessary
-	if m := len(payloadB64) % 4; m != 0 {
-		payloadB64 += strings.Repeat("=", 4-m)
-	}
-	data, err := base64.URLEncoding.DecodeString(payloadB64)
-	if err != nil {
-		return
-	}
-	var payload map[string]interface{}
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return
-	}
-	// Expect sub like: system:serviceaccount:<namespace>:<sa-name>
-	sub, _ := payload["sub"].(string)
-	const prefix = "system:serviceaccount:"
-	if !strings.HasPrefix(sub, prefix) {
-		return
-	}
-	rest := strings.TrimPrefix(sub, prefix)
-	parts2 := strings.SplitN(rest, ":", 2)
-	if len(parts2) != 2 {
+	// Identify the SA from a TokenReview; an unverified JWT 'sub' claim must not select which SA is written
+	ns, saName, ok := serviceAccountFromUsername(reviewTokenUsername(c.Request.Context(), token))
+	if !ok {
 		return
 	}
-	ns := parts2[0]
-	saName := parts2[1]
 
 	// Backend client must exist
 	if K8sClientMw == nil {
@@ -236,83 +222,38 @@ func updateAccessKeyLastUsedAnnotation(c *gin.Context) {
 	}
 }
 
-// ExtractServiceAccountFromAuth extracts namespace and ServiceAccount name from the Authorization Bearer JWT 'sub' claim
+// ExtractServiceAccountFromAuth extracts namespace and ServiceAccount name from the username a TokenReview
+// reports for the caller's token (see extractRequestToken); the JWT payload itself is never trusted.
 // Also checks X-Remote-User header for service account format (OpenShift OAuth proxy format)
 // Returns (namespace, saName, true) when a SA subject is present, otherwise ("","",false)
 func ExtractServiceAccountFromAuth(c *gin.Context) (string, string, bool) {
 	// Check X-Remote-User header (OpenShift OAuth proxy format)
 	// This is a production feature, not just for tests
-	remoteUser := c.GetHeader("X-Remote-User")
-	if remoteUser != "" {
-		const prefix = "system:serviceaccount:"
-		if strings.HasPrefix(remoteUser, prefix) {
-			rest := strings.TrimPrefix(remoteUser, prefix)
-			parts := strings.SplitN(rest, ":", 2)
-			if len(parts) == 2 {
-				return parts[0], parts[1], true
-			}
-		}
+	if ns, saName, ok := serviceAccountFromUsername(c.GetHeader("X-Remote-User")); ok {
+		return ns, saName, true
 	}
 
-	// Standard Authorization Bearer JWT parsing
-	rawAuth := c.GetHeader("Authorization")
-	parts := strings.SplitN(rawAuth, " ", 2)
-	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		return "", "", false
-	}
-	token := strings.TrimSpace(parts[1])
+	// Caller token, identified through TokenReview
+	token, _, _, _ := extractRequestToken(c)
 	if token == "" {
 		return "", "", false
 	}
-	segs := strings.Split(token, ".")
-	if len(segs) < 2 {
-		return "", "", false
-	}
-	payloadB64 := segs[1]
-	if m := len(payloadB64) % 4; m != 0 {
-		payloadB64 += strings.Repeat("=", 4-m)
-	}
-	data, err := base64.URLEncoding.DecodeString(payloadB64)
-	if err != nil {
-		return "", "", false
-	}
-	var payload map[string]interface{}
-	if err := json.Unmarshal(data, &payload); err != nil {
-		return "", "", false
-	}
-	sub, _ := payload["sub"].(string)
-	const prefix = "system:serviceaccount:"
-	if !strings.HasPrefix(sub, prefix) {
-		return "", "", false
-	}
-	rest := strings.TrimPrefix(sub, prefix)
-	parts2 := strings.SplitN(rest, ":", 2)
-	if len(parts2) != 2 {
-		return "", "", false
-	}
-	return parts2[0], parts2[1], true
+	return serviceAccountFromUsername(reviewTokenUsername(c.Request.Context(), token))
 }
 
 // ValidateProjectContext is middleware for project context validation
 func ValidateProjectContext() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Allow token via query parameter for websocket/agent callers
-		if c.GetHeader("Authorization") == "" && c.GetHeader("X-Forwarded-Access-Token") == "" {
-			if qp := strings.TrimSpace(c.Query("token")); qp != "" {
-				c.Request.Header.Set("Authorization", "Bearer "+qp)
-			}
-		}
-
 		// SECURITY: Authentication is always required - no bypass mechanism
 		// Require user/API key token; do not fall back to service account
-		if c.GetHeader("Authorization") == "" && c.GetHeader("X-Forwarded-Access-Token") == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "User token required"})
+		if token, _, _, _ := extractRequestToken(c); token == "" {
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User token required", nil)
 			c.Abort()
 			return
 		}
 		reqK8s, _ := GetK8sClientsForRequest(c)
 		if reqK8s == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 			c.Abort()
 			return
 		}
@@ -322,14 +263,14 @@ func ValidateProjectContext() gin.HandlerFunc {
 			projectHeader = c.GetHeader("X-OpenShift-Project")
 		}
 		if projectHeader == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Project is required in path /api/projects/:projectName or X-OpenShift-Project header"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project is required in path /api/projects/:projectName or X-OpenShift-Project header", nil)
 			c.Abort()
 			return
 		}
 
 		// Validate namespace name to prevent injection attacks
 		if !isValidKubernetesName(projectHeader) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project name format"})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid project name format", nil)
 			c.Abort()
 			return
 		}
@@ -348,12 +289,12 @@ func ValidateProjectContext() gin.HandlerFunc {
 		res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
 		if err != nil {
 			log.Printf("validateProjectContext: SSAR failed for %s: %v", projectHeader, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to perform access review"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to perform access review", nil)
 			c.Abort()
 			return
 		}
 		if !res.Status.Allowed {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to access project"})
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to access project", nil)
 			c.Abort()
 			return
 		}
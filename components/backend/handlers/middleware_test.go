@@ -4,6 +4,7 @@ package handlers
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"strings"
@@ -264,6 +265,67 @@ var _ = Describe("Middleware Handlers", Label(test_constants.LabelUnit, test_con
 			Expect(hasAuth).To(BeFalse())
 			Expect(hasFwd).To(BeTrue())
 		})
+
+		DescribeTable("Should pick the effective token for each combination of sources",
+			func(auth, forwarded, query, wantToken, wantSource string) {
+				path := "/health"
+				if query != "" {
+					path += "?token=" + query
+				}
+				c := httpUtils.CreateTestGinContext("GET", path, nil)
+				if auth != "" {
+					c.Request.Header.Set("Authorization", auth)
+				}
+				if forwarded != "" {
+					c.Request.Header.Set("X-Forwarded-Access-Token", forwarded)
+				}
+
+				token, src, _, _ := extractRequestToken(c)
+				Expect(token).To(Equal(wantToken))
+				Expect(src).To(Equal(wantSource))
+			},
+			Entry("none", "", "", "", "", "none"),
+			Entry("Authorization only", "Bearer a", "", "", "a", "authorization"),
+			Entry("X-Forwarded-Access-Token only", "", "f", "", "f", "x-forwarded-access-token"),
+			Entry("query only", "", "", "q", "q", "query"),
+			Entry("Authorization and X-Forwarded-Access-Token", "Bearer a", "f", "", "a", "authorization"),
+			Entry("Authorization and query", "Bearer a", "", "q", "a", "authorization"),
+			Entry("X-Forwarded-Access-Token and query", "", "f", "q", "f", "x-forwarded-access-token"),
+			Entry("all three", "Bearer a", "f", "q", "a", "authorization"),
+			Entry("empty Bearer falls back to X-Forwarded-Access-Token", "Bearer ", "f", "", "f", "x-forwarded-access-token"),
+			Entry("empty Bearer does not fall back to query", "Bearer ", "", "q", "", "authorization"),
+		)
+	})
+
+	Describe("forwardCallerToken", func() {
+		DescribeTable("Should forward one normalized Authorization header",
+			func(auth, forwarded, query, wantAuth string) {
+				path := "/api/projects/p/agentic-sessions/s/workspace"
+				if query != "" {
+					path += "?token=" + query
+				}
+				c := httpUtils.CreateTestGinContext("GET", path, nil)
+				if auth != "" {
+					c.Request.Header.Set("Authorization", auth)
+				}
+				if forwarded != "" {
+					c.Request.Header.Set("X-Forwarded-Access-Token", forwarded)
+				}
+				h := http.Header{}
+				h.Set("X-Forwarded-Access-Token", "stale")
+
+				forwardCallerToken(c, h)
+				Expect(h.Get("Authorization")).To(Equal(wantAuth))
+				Expect(h.Values("X-Forwarded-Access-Token")).To(BeEmpty())
+			},
+			Entry("none", "", "", "", ""),
+			Entry("Authorization: Bearer", "Bearer a", "", "", "Bearer a"),
+			Entry("raw Authorization", "a", "", "", "Bearer a"),
+			Entry("X-Forwarded-Access-Token only", "", "f", "", "Bearer f"),
+			Entry("query only", "", "", "q", "Bearer q"),
+			Entry("Authorization and X-Forwarded-Access-Token", "Bearer a", "f", "", "Bearer a"),
+			Entry("X-Forwarded-Access-Token and query", "", "f", "q", "Bearer f"),
+		)
 	})
 
 	Describe("ExtractServiceAccountFromAuth", func() {
@@ -295,6 +357,14 @@ var _ = Describe("Middleware Handlers", Label(test_constants.LabelUnit, test_con
 			Expect(found).To(BeFalse(), "Should not find service account for regular user")
 		})
 
+		It("Should not trust the sub claim of a token the API server rejects", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/test-project/sessions", nil)
+			httpUtils.SetAuthHeader(forgedServiceAccountJWT("test-project", "test-sa"))
+
+			_, _, found := ExtractServiceAccountFromAuth(context)
+			Expect(found).To(BeFalse(), "Unsigned JWT claims must not identify a service account")
+		})
+
 		It("Should handle malformed service account headers", func() {
 			testCases := []string{"", "Bearer", "Bearer invalid.token", "NotBearer token"}
 
@@ -309,4 +379,62 @@ var _ = Describe("Middleware Handlers", Label(test_constants.LabelUnit, test_con
 			}
 		})
 	})
+
+	Describe("updateAccessKeyLastUsedAnnotation", func() {
+		const lastUsedAnnotation = "ambient-code.io/last-used-at"
+
+		ensureAccessKeySA := func(name string) {
+			ctx := context.Background()
+			sa, err := k8sUtils.K8sClient.CoreV1().ServiceAccounts("test-project").Get(ctx, name, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				sa, err = k8sUtils.K8sClient.CoreV1().ServiceAccounts("test-project").Create(ctx, &corev1.ServiceAccount{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-project"},
+				}, metav1.CreateOptions{})
+			}
+			Expect(err).NotTo(HaveOccurred())
+			sa.Labels = map[string]string{"app": "ambient-access-key"}
+			_, err = k8sUtils.K8sClient.CoreV1().ServiceAccounts("test-project").Update(ctx, sa, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		lastUsed := func(name string) string {
+			sa, err := k8sUtils.K8sClient.CoreV1().ServiceAccounts("test-project").Get(context.Background(), name, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			return sa.Annotations[lastUsedAnnotation]
+		}
+
+		It("Should not write the annotation for a forged token", func() {
+			ensureAccessKeySA("victim-key")
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/test-project/sessions", nil)
+			httpUtils.SetAuthHeader(forgedServiceAccountJWT("test-project", "victim-key"))
+
+			updateAccessKeyLastUsedAnnotation(context)
+
+			Expect(lastUsed("victim-key")).To(BeEmpty())
+		})
+
+		It("Should update last-used for a valid access key token", func() {
+			context := httpUtils.CreateTestGinContext("GET", "/api/projects/test-project/sessions", nil)
+			_, saName, err := httpUtils.SetValidTestToken(
+				k8sUtils,
+				"test-project",
+				[]string{"get", "list"},
+				"agenticsessions",
+				"access-key-sa",
+				"test-agenticsessions-read-role",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			ensureAccessKeySA(saName)
+
+			updateAccessKeyLastUsedAnnotation(context)
+
+			Expect(lastUsed(saName)).NotTo(BeEmpty())
+		})
+	})
 })
+
+// forgedServiceAccountJWT builds an unsigned JWT whose sub claims to be the given service account
+func forgedServiceAccountJWT(namespace, saName string) string {
+	payload := fmt.Sprintf(`{"sub":"system:serviceaccount:%s:%s"}`, namespace, saName)
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString([]byte(payload)) + "."
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	modelProviderAnthropic = "anthropic"
+	modelProviderVertex    = "vertex"
+)
+
+// ModelInfo describes a model sessions may use. ID is the name sessions set in
+// spec.llmSettings.model; VertexID is the model the runner calls on Vertex AI.
+type ModelInfo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ContextWindow int    `json:"contextWindow,omitempty"`
+	CostTier      string `json:"costTier,omitempty"`
+	VertexID      string `json:"vertexId,omitempty"`
+}
+
+// ModelsResponse is the response of GET /projects/:projectName/models
+type ModelsResponse struct {
+	Provider string      `json:"provider"`
+	Default  string      `json:"default"`
+	Models   []ModelInfo `json:"models"`
+}
+
+// knownModels is the metadata of the models the runner knows; its Vertex IDs match the runner's
+// mapping of model names to Vertex AI models. The first available model is the default.
+var knownModels = []ModelInfo{
+	{ID: "claude-sonnet-4-5", Name: "Claude Sonnet 4.5", ContextWindow: 200000, CostTier: "standard", VertexID: "claude-sonnet-4-5@20250929"},
+	{ID: "claude-opus-4-5", Name: "Claude Opus 4.5", ContextWindow: 200000, CostTier: "premium", VertexID: "claude-opus-4-5@20251101"},
+	{ID: "claude-opus-4-1", Name: "Claude Opus 4.1", ContextWindow: 200000, CostTier: "premium", VertexID: "claude-opus-4-1@20250805"},
+	{ID: "claude-haiku-4-5", Name: "Claude Haiku 4.5", ContextWindow: 200000, CostTier: "economy", VertexID: "claude-haiku-4-5@20251001"},
+}
+
+// modelProvider returns the provider the runners use: Vertex AI when CLAUDE_CODE_USE_VERTEX=1
+func modelProvider() string {
+	if os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1" {
+		return modelProviderVertex
+	}
+	return modelProviderAnthropic
+}
+
+// providerModels returns the models the provider offers. VERTEX_MODELS or ANTHROPIC_MODELS
+// (comma-separated model IDs) replaces the default list: every known model for the Anthropic API,
+// the known models with a Vertex AI ID for Vertex. Configured IDs that are not known models are
+// listed without metadata.
+func providerModels(provider string) []ModelInfo {
+	env := "ANTHROPIC_MODELS"
+	if provider == modelProviderVertex {
+		env = "VERTEX_MODELS"
+	}
+	if raw := strings.TrimSpace(os.Getenv(env)); raw != "" {
+		var models []ModelInfo
+		for _, id := range strings.Split(raw, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				continue
+			}
+			m := ModelInfo{ID: id, Name: id}
+			for _, known := range knownModels {
+				if known.ID == id {
+					m = known
+					break
+				}
+			}
+			models = append(models, m)
+		}
+		return models
+	}
+	var models []ModelInfo
+	for _, m := range knownModels {
+		if provider == modelProviderVertex && m.VertexID == "" {
+			continue
+		}
+		models = append(models, m)
+	}
+	return models
+}
+
+// projectAllowedModels returns spec.allowedModels from the project's ProjectSettings. A missing
+// ProjectSettings or an empty list allows every model of the provider.
+func projectAllowedModels(ctx context.Context, dyn dynamic.Interface, project string) ([]string, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	allowed, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "allowedModels")
+	return allowed, nil
+}
+
+// projectModels returns the models sessions of the project may use: the provider's models,
+// filtered by the project's allowedModels
+func projectModels(ctx context.Context, dyn dynamic.Interface, project string) ([]ModelInfo, error) {
+	models := providerModels(modelProvider())
+	allowed, err := projectAllowedModels(ctx, dyn, project)
+	if err != nil || len(allowed) == 0 {
+		return models, err
+	}
+	allowedSet := map[string]bool{}
+	for _, id := range allowed {
+		allowedSet[strings.TrimSpace(id)] = true
+	}
+	filtered := []ModelInfo{}
+	for _, m := range models {
+		if allowedSet[m.ID] {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered, nil
+}
+
+func modelIDs(models []ModelInfo) []string {
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// resolveSessionModel validates a requested spec.llmSettings.model against the project's models
+// and returns the model to use: the requested one, or the project's default when none is
+// requested. On failure it writes the error response and returns false.
+func resolveSessionModel(c *gin.Context, dyn dynamic.Interface, project, requested string) (string, bool) {
+	models, err := projectModels(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read allowed models in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return "", false
+	}
+	if len(models) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "no models are available in this project", nil)
+		return "", false
+	}
+	if requested == "" {
+		return models[0].ID, true
+	}
+	for _, m := range models {
+		if m.ID == requested {
+			return requested, true
+		}
+	}
+	respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("model %q is not available in this project", requested),
+		gin.H{"availableModels": modelIDs(models)})
+	return "", false
+}
+
+// ListProjectModels handles GET /projects/:projectName/models
+// Returns the models sessions of the project may use, the same list session creation validates against
+func ListProjectModels(c *gin.Context) {
+	project := c.GetString("project")
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	models, err := projectModels(c.Request.Context(), k8sDyn, project)
+	if err != nil {
+		log.Printf("Failed to read allowed models in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return
+	}
+	resp := ModelsResponse{Provider: modelProvider(), Models: models}
+	if len(models) > 0 {
+		resp.Default = models[0].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// requireAvailableModelChange validates spec.llmSettings.model of an updated spec against the
+// project's models when it differs from the session's current model, so existing sessions keep
+// working after the project's models change. On failure it writes the error response and returns false.
+func requireAvailableModelChange(c *gin.Context, dyn dynamic.Interface, project string, item *unstructured.Unstructured, spec map[string]interface{}) bool {
+	model, _, _ := unstructured.NestedString(spec, "llmSettings", "model")
+	current, _, _ := unstructured.NestedString(item.Object, "spec", "llmSettings", "model")
+	if model == "" || model == current {
+		return true
+	}
+	_, ok := resolveSessionModel(c, dyn, project, model)
+	return ok
+}
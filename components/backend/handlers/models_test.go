@@ -0,0 +1,198 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/k8s"
+	"ambient-code-backend/tests/config"
+	"ambient-code-backend/tests/test_utils"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Project Models", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testToken     string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		GinkgoT().Setenv("CLAUDE_CODE_USE_VERTEX", "")
+		GinkgoT().Setenv("ANTHROPIC_MODELS", "")
+		GinkgoT().Setenv("VERTEX_MODELS", "")
+	})
+
+	seedAllowedModels := func(models ...interface{}) {
+		_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       map[string]interface{}{"allowedModels": models},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	listModels := func() ModelsResponse {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/models", nil)
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		ListProjectModels(context)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var resp ModelsResponse
+		httpUtils.GetResponseJSON(&resp)
+		return resp
+	}
+
+	createWithModel := func(model string) map[string]interface{} {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		body := map[string]interface{}{"initialPrompt": "x"}
+		if model != "" {
+			body["llmSettings"] = map[string]interface{}{"model": model}
+		}
+		context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		CreateSession(context)
+		var response map[string]interface{}
+		httpUtils.GetResponseJSON(&response)
+		return response
+	}
+
+	storedModel := func(name string) string {
+		stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		model, _, _ := unstructured.NestedString(stored.Object, "spec", "llmSettings", "model")
+		return model
+	}
+
+	It("Should list the Anthropic models with their metadata", func() {
+		resp := listModels()
+
+		Expect(resp.Provider).To(Equal("anthropic"))
+		Expect(resp.Default).To(Equal("claude-sonnet-4-5"))
+		Expect(modelIDs(resp.Models)).To(Equal([]string{"claude-sonnet-4-5", "claude-opus-4-5", "claude-opus-4-1", "claude-haiku-4-5"}))
+		Expect(resp.Models[3].CostTier).To(Equal("economy"))
+		Expect(resp.Models[3].ContextWindow).To(Equal(200000))
+	})
+
+	It("Should list the configured Vertex AI models when Vertex is enabled", func() {
+		GinkgoT().Setenv("CLAUDE_CODE_USE_VERTEX", "1")
+		GinkgoT().Setenv("VERTEX_MODELS", "claude-opus-4-5, claude-haiku-4-5")
+
+		resp := listModels()
+
+		Expect(resp.Provider).To(Equal("vertex"))
+		Expect(resp.Default).To(Equal("claude-opus-4-5"))
+		Expect(modelIDs(resp.Models)).To(Equal([]string{"claude-opus-4-5", "claude-haiku-4-5"}))
+		Expect(resp.Models[0].VertexID).To(Equal("claude-opus-4-5@20251101"))
+	})
+
+	It("Should filter the models by the project's allowedModels", func() {
+		seedAllowedModels("claude-haiku-4-5", "not-offered")
+
+		resp := listModels()
+
+		Expect(modelIDs(resp.Models)).To(Equal([]string{"claude-haiku-4-5"}))
+		Expect(resp.Default).To(Equal("claude-haiku-4-5"))
+	})
+
+	It("Should create sessions with an available model and the project default when none is requested", func() {
+		seedAllowedModels("claude-opus-4-1", "claude-haiku-4-5")
+
+		created := createWithModel("claude-opus-4-1")
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		Expect(storedModel(created["name"].(string))).To(Equal("claude-opus-4-1"))
+
+		created = createWithModel("")
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		Expect(storedModel(created["name"].(string))).To(Equal("claude-opus-4-1"))
+	})
+
+	It("Should reject models the project does not offer", func() {
+		seedAllowedModels("claude-haiku-4-5")
+
+		createWithModel("claude-opus-4-5")
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `model "claude-opus-4-5" is not available in this project`)
+
+		createWithModel("sonnet")
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `model "sonnet" is not available in this project`)
+	})
+
+	It("Should reject cloning a session whose model the project no longer offers", func() {
+		seedAllowedModels("claude-haiku-4-5")
+		original := GetOpenShiftProjectResource
+		GetOpenShiftProjectResource = k8s.GetOpenShiftProjectResource
+		DeferCleanup(func() { GetOpenShiftProjectResource = original })
+		_, err := k8sUtils.DynamicClient.Resource(GetOpenShiftProjectResource()).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "project.openshift.io/v1",
+			"kind":       "Project",
+			"metadata":   map[string]interface{}{"name": testNamespace, "labels": map[string]interface{}{"ambient-code.io/managed": "true"}},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		source := createWithModel("claude-haiku-4-5")
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		sourceName := source["name"].(string)
+		stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, sourceName, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unstructured.SetNestedField(stored.Object, "claude-opus-4-5", "spec", "llmSettings", "model")).To(Succeed())
+		_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, stored, v1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/"+sourceName+"/clone",
+			map[string]interface{}{"targetProject": testNamespace, "newSessionName": "cloned"})
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		context.Params = gin.Params{{Key: "sessionName", Value: sourceName}}
+		CloneSession(context)
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `model "claude-opus-4-5" is not available in this project`)
+		Expect(errorObj["details"]).To(HaveKeyWithValue("availableModels", ConsistOf("claude-haiku-4-5")))
+	})
+
+	It("Should reject importing a bundle with a model the project does not offer", func() {
+		seedAllowedModels("claude-haiku-4-5")
+
+		context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/import", map[string]interface{}{
+			"kind":    types.SessionBundleKind,
+			"version": types.SessionBundleVersion,
+			"source":  map[string]interface{}{"project": "elsewhere", "name": "imported"},
+			"spec": map[string]interface{}{
+				"initialPrompt": "x",
+				"llmSettings":   map[string]interface{}{"model": "made-up-model"},
+			},
+		})
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		ImportSession(context)
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `model "made-up-model" is not available in this project`)
+		Expect(errorObj["details"]).To(HaveKeyWithValue("availableModels", ConsistOf("claude-haiku-4-5")))
+	})
+})
@@ -14,6 +14,8 @@ import (
 	"strings"
 	"time"
 
+	"ambient-code-backend/httpclient"
+
 	"github.com/gin-gonic/gin"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -108,7 +110,7 @@ func GetOAuthURL(c *gin.Context) {
 	// Verify user has access to the session using user token
 	reqK8s, reqDyn := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -121,16 +123,16 @@ func GetOAuthURL(c *gin.Context) {
 
 	_, err := reqDyn.Resource(gvr).Namespace(projectName).Get(context.Background(), sessionName, v1.GetOptions{})
 	if errors.IsNotFound(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Session not found", nil)
 		return
 	}
 	if errors.IsForbidden(err) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to session"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Access denied to session", nil)
 		return
 	}
 	if err != nil {
 		log.Printf("Failed to get session %s/%s: %v", projectName, sessionName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify session", nil)
 		return
 	}
 
@@ -138,7 +140,7 @@ func GetOAuthURL(c *gin.Context) {
 	provider, err := getOAuthProvider(providerName)
 	if err != nil {
 		log.Printf("Failed to get OAuth provider: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": fmt.Sprintf("%s OAuth not configured", providerName)})
+		respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, fmt.Sprintf("%s OAuth not configured", providerName), nil)
 		return
 	}
 
@@ -154,7 +156,7 @@ func GetOAuthURL(c *gin.Context) {
 	stateJSON, err := json.Marshal(stateData)
 	if err != nil {
 		log.Printf("Failed to marshal state: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate OAuth state"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to generate OAuth state", nil)
 		return
 	}
 
@@ -162,7 +164,7 @@ func GetOAuthURL(c *gin.Context) {
 	secret := os.Getenv("OAUTH_STATE_SECRET")
 	if secret == "" {
 		log.Printf("OAUTH_STATE_SECRET not configured")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth state validation not configured"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "OAuth state validation not configured", nil)
 		return
 	}
 
@@ -201,7 +203,7 @@ func GetOAuthURL(c *gin.Context) {
 			stateToken,
 		)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported provider: %s", providerName)})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("unsupported provider: %s", providerName), nil)
 		return
 	}
 
@@ -259,7 +261,7 @@ func HandleOAuth2Callback(c *gin.Context) {
 
 	// Validate required parameters
 	if code == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing authorization code"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing authorization code", nil)
 		return
 	}
 
@@ -267,7 +269,7 @@ func HandleOAuth2Callback(c *gin.Context) {
 	providerConfig, err := getOAuthProvider(provider)
 	if err != nil {
 		log.Printf("Failed to get OAuth provider config: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "OAuth provider not configured"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "OAuth provider not configured", nil)
 		return
 	}
 
@@ -288,7 +290,7 @@ func HandleOAuth2Callback(c *gin.Context) {
 		if serr := storeOAuthCallback(c.Request.Context(), state, &callbackData); serr != nil {
 			log.Printf("Failed to store OAuth exchange error: %v", serr)
 		}
-		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to exchange authorization code"})
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "failed to exchange authorization code", nil)
 		return
 	}
 
@@ -337,7 +339,7 @@ func HandleOAuth2Callback(c *gin.Context) {
 		// Fallback: store in oauth-callbacks
 		if err := storeOAuthCallback(c.Request.Context(), state, &callbackData); err != nil {
 			log.Printf("Failed to store OAuth callback: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store OAuth data"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to store OAuth data", nil)
 			return
 		}
 		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(
@@ -373,8 +375,7 @@ func exchangeOAuthCode(ctx context.Context, provider *OAuthProvider, code string
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("Accept", "application/json")
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -492,13 +493,13 @@ func GetOAuthCallback(ctx context.Context, state string) (*OAuthCallbackData, er
 func GetOAuthCallbackEndpoint(c *gin.Context) {
 	state := c.Query("state")
 	if state == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing state parameter"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing state parameter", nil)
 		return
 	}
 
 	data, err := GetOAuthCallback(c.Request.Context(), state)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "callback not found"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "callback not found", nil)
 		return
 	}
 
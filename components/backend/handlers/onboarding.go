@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// runnerSecretAnnotation marks secrets that ListNamespaceSecrets shows and sessions may use
+	runnerSecretAnnotation = "ambient-code.io/runner-secret"
+	// integrationSecretsName holds GITHUB_TOKEN, JIRA_* and custom keys (see secrets.go)
+	integrationSecretsName = "ambient-non-vertex-integrations"
+)
+
+// onboardingCheck describes one checklist entry: which secret and keys satisfy it
+type onboardingCheck struct {
+	name        string
+	description string
+	integration bool     // keys live in the integration secret rather than the runner secret
+	keys        []string // candidate keys
+	requireAll  bool     // all keys must be set (otherwise any one is enough)
+}
+
+var onboardingChecks = []onboardingCheck{
+	{name: "anthropic-api-key", description: "Anthropic API key used by the runner (not needed when Vertex AI is enabled)", keys: []string{anthropicAPIKeyField}},
+	{name: "git-credentials", description: "Token used to clone and push repositories", integration: true, keys: []string{"GITHUB_TOKEN", "GITLAB_TOKEN"}},
+	{name: "jira", description: "Jira connection used by Jira integrations", integration: true, keys: []string{"JIRA_URL", "JIRA_PROJECT", "JIRA_EMAIL", "JIRA_API_TOKEN"}, requireAll: true},
+}
+
+// projectRunnerSecretName returns spec.runnerSecretsName from the project's ProjectSettings,
+// falling back to the default runner secret name
+func projectRunnerSecretName(ctx context.Context, dyn dynamic.Interface, project string) string {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, using default runner secret name: %v", project, err)
+		}
+		return runnerSecretsName
+	}
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "runnerSecretsName"); strings.TrimSpace(name) != "" {
+		return strings.TrimSpace(name)
+	}
+	return runnerSecretsName
+}
+
+// ensureAnnotatedSecret creates the secret if missing, or adds the runner-secret annotation to an
+// existing one. It reports whether it created or annotated the secret.
+func ensureAnnotatedSecret(ctx context.Context, k8s kubernetes.Interface, project, name, app string) (*corev1.Secret, bool, bool, error) {
+	sec, err := k8s.CoreV1().Secrets(project).Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		sec = &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        name,
+				Namespace:   project,
+				Labels:      map[string]string{"app": app},
+				Annotations: map[string]string{runnerSecretAnnotation: "true"},
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+		created, err := k8s.CoreV1().Secrets(project).Create(ctx, sec, v1.CreateOptions{})
+		return created, true, false, err
+	}
+	if err != nil {
+		return nil, false, false, err
+	}
+	if sec.Annotations[runnerSecretAnnotation] == "true" {
+		return sec, false, false, nil
+	}
+	if sec.Annotations == nil {
+		sec.Annotations = map[string]string{}
+	}
+	sec.Annotations[runnerSecretAnnotation] = "true"
+	updated, err := k8s.CoreV1().Secrets(project).Update(ctx, sec, v1.UpdateOptions{})
+	return updated, false, true, err
+}
+
+// copySecretValues fills keys that are missing or empty in dst from src and returns the copied keys
+func copySecretValues(ctx context.Context, k8s kubernetes.Interface, dst *corev1.Secret, srcProject, srcName string) ([]string, error) {
+	src, err := k8s.CoreV1().Secrets(srcProject).Get(ctx, srcName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var copied []string
+	for k, v := range src.Data {
+		if len(v) == 0 || len(dst.Data[k]) > 0 {
+			continue
+		}
+		if dst.Data == nil {
+			dst.Data = map[string][]byte{}
+		}
+		dst.Data[k] = v
+		copied = append(copied, k)
+	}
+	if len(copied) == 0 {
+		return nil, nil
+	}
+	if _, err := k8s.CoreV1().Secrets(dst.Namespace).Update(ctx, dst, v1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	sort.Strings(copied)
+	return copied, nil
+}
+
+// onboardingChecklist evaluates onboardingChecks against the runner and integration secret data
+func onboardingChecklist(runnerName string, runnerData map[string][]byte, integrationData map[string][]byte) ([]types.OnboardingItem, []string) {
+	vertexEnabled := os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1"
+	items := make([]types.OnboardingItem, 0, len(onboardingChecks))
+	missing := []string{}
+	for _, check := range onboardingChecks {
+		data, secretName := runnerData, runnerName
+		if check.integration {
+			data, secretName = integrationData, integrationSecretsName
+		}
+		set := 0
+		for _, k := range check.keys {
+			if len(data[k]) > 0 {
+				set++
+			}
+		}
+		configured := set > 0
+		if check.requireAll {
+			configured = set == len(check.keys)
+		}
+		if check.name == "anthropic-api-key" && vertexEnabled {
+			configured = true
+		}
+		items = append(items, types.OnboardingItem{
+			Name:        check.name,
+			Description: check.description,
+			Secret:      secretName,
+			Keys:        check.keys,
+			Configured:  configured,
+		})
+		if !configured {
+			missing = append(missing, check.name)
+		}
+	}
+	return items, missing
+}
+
+// OnboardProject provisions the runner secret scaffolding for a project and reports what is missing.
+// POST /api/projects/:projectName/onboard
+// Body: { copyFrom?: string }
+// Requires project admin (update projectsettings) on the project and on copyFrom when given.
+func OnboardProject(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var req types.OnboardProjectRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+	}
+	req.CopyFrom = strings.TrimSpace(req.CopyFrom)
+	if req.CopyFrom != "" && (req.CopyFrom == project || !isValidKubernetesName(req.CopyFrom)) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "copyFrom must name a different project", nil)
+		return
+	}
+
+	for _, ns := range []string{project, req.CopyFrom} {
+		if ns == "" {
+			continue
+		}
+		allowed, err := checkUserCanModifyProject(reqK8s, ns)
+		if err != nil {
+			log.Printf("OnboardProject: failed to check admin access on %s: %v", ns, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+			return
+		}
+		if !allowed {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, fmt.Sprintf("Project admin access required on %s", ns), nil)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	resp := types.OnboardProjectResponse{
+		RunnerSecret:      projectRunnerSecretName(ctx, reqDyn, project),
+		IntegrationSecret: integrationSecretsName,
+	}
+
+	secrets := map[string]*corev1.Secret{}
+	for _, s := range []struct{ name, app string }{
+		{resp.RunnerSecret, runnerSecretsName},
+		{integrationSecretsName, "ambient-integration-secrets"},
+	} {
+		sec, created, annotated, err := ensureAnnotatedSecret(ctx, reqK8s, project, s.name, s.app)
+		if err != nil {
+			log.Printf("OnboardProject: failed to provision secret %s/%s: %v", project, s.name, err)
+			respondK8sError(c, err, "Project not found", "Failed to provision runner secrets")
+			return
+		}
+		if created {
+			resp.Created = append(resp.Created, s.name)
+		}
+		if annotated {
+			resp.Annotated = append(resp.Annotated, s.name)
+		}
+		secrets[s.name] = sec
+	}
+
+	if req.CopyFrom != "" {
+		sourceRunner := projectRunnerSecretName(ctx, reqDyn, req.CopyFrom)
+		for _, pair := range [][2]string{{resp.RunnerSecret, sourceRunner}, {integrationSecretsName, integrationSecretsName}} {
+			copied, err := copySecretValues(ctx, reqK8s, secrets[pair[0]], req.CopyFrom, pair[1])
+			if err != nil {
+				log.Printf("OnboardProject: failed to copy %s/%s into %s/%s: %v", req.CopyFrom, pair[1], project, pair[0], err)
+				respondK8sError(c, err, "Source secret not found", "Failed to copy secrets")
+				return
+			}
+			for _, k := range copied {
+				resp.CopiedKeys = append(resp.CopiedKeys, pair[0]+"/"+k)
+			}
+		}
+	}
+
+	resp.Checklist, resp.Missing = onboardingChecklist(resp.RunnerSecret, secrets[resp.RunnerSecret].Data, secrets[integrationSecretsName].Data)
+	c.JSON(http.StatusOK, resp)
+}
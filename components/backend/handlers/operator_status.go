@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// operatorStatusConfigMapName is the ConfigMap the operator publishes its build metadata and
+// reconcile stats to, in its own namespace
+const operatorStatusConfigMapName = "ambient-operator-status"
+
+// defaultOperatorStaleAfter is how old the operator's last reconcile or status refresh may be before
+// it is reported as stale. Override with OPERATOR_STALE_MINUTES.
+const defaultOperatorStaleAfter = 5 * time.Minute
+
+// BuildInfo is a component's build metadata
+type BuildInfo struct {
+	GitVersion string `json:"gitVersion"`
+	GitCommit  string `json:"gitCommit"`
+	GitBranch  string `json:"gitBranch"`
+	BuildDate  string `json:"buildDate"`
+}
+
+// BackendBuildInfo is set by main from the backend's build-time metadata
+var BackendBuildInfo BuildInfo
+
+// OperatorStatus is the build metadata and reconcile stats published by the operator
+type OperatorStatus struct {
+	BuildInfo
+	StartedAt          string `json:"startedAt,omitempty"`
+	UpdatedAt          string `json:"updatedAt,omitempty"`
+	SessionsReconciled int64  `json:"sessionsReconciled"`
+	ReconcileErrors    int64  `json:"reconcileErrors"`
+	LastReconcileTime  string `json:"lastReconcileTime,omitempty"`
+	LastError          string `json:"lastError,omitempty"`
+	LastErrorTime      string `json:"lastErrorTime,omitempty"`
+}
+
+// OperatorStatusResponse is the response of GetOperatorStatus. Operator is nil when the operator
+// has not published its status.
+type OperatorStatusResponse struct {
+	Backend     BuildInfo       `json:"backend"`
+	Operator    *OperatorStatus `json:"operator"`
+	Stale       bool            `json:"stale"`
+	StaleReason string          `json:"staleReason,omitempty"`
+}
+
+// operatorNamespace is where the operator runs (OPERATOR_NAMESPACE, defaulting to the backend's namespace)
+func operatorNamespace() string {
+	if ns := strings.TrimSpace(os.Getenv("OPERATOR_NAMESPACE")); ns != "" {
+		return ns
+	}
+	return Namespace
+}
+
+// operatorStaleAfter returns the configured staleness threshold
+func operatorStaleAfter() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("OPERATOR_STALE_MINUTES")); raw != "" {
+		if mins, err := strconv.Atoi(raw); err == nil && mins > 0 {
+			return time.Duration(mins) * time.Minute
+		}
+	}
+	return defaultOperatorStaleAfter
+}
+
+// parseOperatorStatus reads the operator status ConfigMap data
+func parseOperatorStatus(data map[string]string) *OperatorStatus {
+	s := &OperatorStatus{
+		BuildInfo: BuildInfo{
+			GitVersion: data["gitVersion"],
+			GitCommit:  data["gitCommit"],
+			GitBranch:  data["gitBranch"],
+			BuildDate:  data["buildDate"],
+		},
+		StartedAt:         data["startedAt"],
+		UpdatedAt:         data["updatedAt"],
+		LastReconcileTime: data["lastReconcileTime"],
+		LastError:         data["lastError"],
+		LastErrorTime:     data["lastErrorTime"],
+	}
+	s.SessionsReconciled, _ = strconv.ParseInt(data["sessionsReconciled"], 10, 64)
+	s.ReconcileErrors, _ = strconv.ParseInt(data["reconcileErrors"], 10, 64)
+	return s
+}
+
+// operatorStaleness explains why the operator looks stalled, or returns "" when it looks healthy.
+// An operator that has not reconciled yet is measured from its start.
+func operatorStaleness(s *OperatorStatus, now time.Time, threshold time.Duration) string {
+	if s == nil {
+		return "operator has not published its status"
+	}
+	check := func(field, raw string) string {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return fmt.Sprintf("operator %s is missing or invalid", field)
+		}
+		if age := now.Sub(t); age > threshold {
+			return fmt.Sprintf("operator %s is %s old", field, age.Truncate(time.Second))
+		}
+		return ""
+	}
+	if reason := check("status", s.UpdatedAt); reason != "" {
+		return reason
+	}
+	lastReconcile := s.LastReconcileTime
+	if lastReconcile == "" {
+		lastReconcile = s.StartedAt
+	}
+	return check("last reconcile", lastReconcile)
+}
+
+// canReadOperatorStatus reports whether the caller may read ConfigMaps in the operator namespace,
+// i.e. administers the platform installation rather than a single project
+func canReadOperatorStatus(ctx context.Context, userClient kubernetes.Interface, namespace string) (bool, error) {
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Resource:  "configmaps",
+				Name:      operatorStatusConfigMapName,
+			},
+		},
+	}
+	res, err := userClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return res.Status.Allowed, nil
+}
+
+// GetOperatorStatus handles GET /api/admin/operator-status
+// Returns the backend's build metadata and the operator's published build metadata and reconcile
+// stats. stale is set when the operator's status or last reconcile is older than OPERATOR_STALE_MINUTES.
+func GetOperatorStatus(c *gin.Context) {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	namespace := operatorNamespace()
+	allowed, err := canReadOperatorStatus(c.Request.Context(), reqK8s, namespace)
+	if err != nil {
+		log.Printf("GetOperatorStatus: access review failed: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to check permissions", nil)
+		return
+	}
+	if !allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Platform administrator access required", nil)
+		return
+	}
+
+	resp := OperatorStatusResponse{Backend: BackendBuildInfo}
+	cm, err := K8sClient.CoreV1().ConfigMaps(namespace).Get(c.Request.Context(), operatorStatusConfigMapName, v1.GetOptions{})
+	switch {
+	case err == nil:
+		resp.Operator = parseOperatorStatus(cm.Data)
+	case !errors.IsNotFound(err):
+		log.Printf("GetOperatorStatus: failed to read ConfigMap %s/%s: %v", namespace, operatorStatusConfigMapName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read operator status", nil)
+		return
+	}
+	resp.StaleReason = operatorStaleness(resp.Operator, time.Now(), operatorStaleAfter())
+	resp.Stale = resp.StaleReason != ""
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// startupLatencyHistograms is the operator's session startup histograms, published under the
+// startupLatency key of its status ConfigMap. Counts are cumulative per bucket.
+type startupLatencyHistograms struct {
+	Buckets []float64 `json:"buckets"`
+	Stages  map[string]struct {
+		Counts []int64 `json:"counts"`
+		Sum    float64 `json:"sum"`
+		Count  int64   `json:"count"`
+	} `json:"stages"`
+}
+
+// writeStartupLatencyMetrics renders the operator's session startup stage histograms. Nothing is
+// written when the operator has not published them yet.
+func writeStartupLatencyMetrics(ctx context.Context, b *strings.Builder) {
+	if K8sClient == nil {
+		return
+	}
+	namespace := operatorNamespace()
+	cm, err := K8sClient.CoreV1().ConfigMaps(namespace).Get(ctx, operatorStatusConfigMapName, v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Metrics: failed to read ConfigMap %s/%s: %v", namespace, operatorStatusConfigMapName, err)
+		}
+		return
+	}
+	raw := cm.Data["startupLatency"]
+	if raw == "" {
+		return
+	}
+	var h startupLatencyHistograms
+	if err := json.Unmarshal([]byte(raw), &h); err != nil {
+		log.Printf("Metrics: invalid startupLatency in ConfigMap %s/%s: %v", namespace, operatorStatusConfigMapName, err)
+		return
+	}
+	stages := make([]string, 0, len(h.Stages))
+	for stage := range h.Stages {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+
+	b.WriteString("# HELP ambient_session_startup_stage_seconds Time session starts spent in each startup stage, from status.timeline.\n")
+	b.WriteString("# TYPE ambient_session_startup_stage_seconds histogram\n")
+	for _, stage := range stages {
+		s := h.Stages[stage]
+		for i, le := range h.Buckets {
+			if i < len(s.Counts) {
+				fmt.Fprintf(b, "ambient_session_startup_stage_seconds_bucket{stage=%q,le=\"%g\"} %d\n", stage, le, s.Counts[i])
+			}
+		}
+		fmt.Fprintf(b, "ambient_session_startup_stage_seconds_bucket{stage=%q,le=\"+Inf\"} %d\n", stage, s.Count)
+		fmt.Fprintf(b, "ambient_session_startup_stage_seconds_sum{stage=%q} %g\n", stage, s.Sum)
+		fmt.Fprintf(b, "ambient_session_startup_stage_seconds_count{stage=%q} %d\n", stage, s.Count)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Operator Status", Label(test_constants.LabelUnit, test_constants.LabelHandlers), func() {
+	var (
+		httpUtils         *test_utils.HTTPTestUtils
+		k8sUtils          *test_utils.K8sTestUtils
+		originalNamespace string
+		originalBuild     BuildInfo
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		SetupHandlerDependencies(k8sUtils)
+		originalNamespace, originalBuild = Namespace, BackendBuildInfo
+		Namespace = "ambient-code-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		BackendBuildInfo = BuildInfo{GitVersion: "v2.0.0", GitCommit: "backend-sha"}
+	})
+
+	AfterEach(func() {
+		Namespace, BackendBuildInfo = originalNamespace, originalBuild
+	})
+
+	publish := func(data map[string]string) {
+		_, err := k8sUtils.K8sClient.CoreV1().ConfigMaps(Namespace).Create(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: operatorStatusConfigMapName, Namespace: Namespace},
+			Data:       data,
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	getStatus := func(expectedStatus int) OperatorStatusResponse {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/api/admin/operator-status", nil)
+		httpUtils.SetAuthHeader("test-token")
+		GetOperatorStatus(c)
+		httpUtils.AssertHTTPStatus(expectedStatus)
+		var resp OperatorStatusResponse
+		if expectedStatus == http.StatusOK {
+			httpUtils.GetResponseJSON(&resp)
+		}
+		return resp
+	}
+
+	It("Should return both components' versions for a healthy operator", func() {
+		now := time.Now().UTC().Format(time.RFC3339)
+		publish(map[string]string{
+			"gitVersion": "v1.2.3", "gitCommit": "operator-sha", "startedAt": now, "updatedAt": now,
+			"sessionsReconciled": "42", "reconcileErrors": "2", "lastReconcileTime": now,
+		})
+
+		resp := getStatus(http.StatusOK)
+
+		Expect(resp.Backend.GitVersion).To(Equal("v2.0.0"))
+		Expect(resp.Operator).NotTo(BeNil())
+		Expect(resp.Operator.GitVersion).To(Equal("v1.2.3"))
+		Expect(resp.Operator.SessionsReconciled).To(Equal(int64(42)))
+		Expect(resp.Operator.ReconcileErrors).To(Equal(int64(2)))
+		Expect(resp.Stale).To(BeFalse())
+	})
+
+	It("Should report an operator that stopped reconciling as stale", func() {
+		now := time.Now().UTC()
+		publish(map[string]string{
+			"gitVersion": "v1.2.3", "startedAt": now.Add(-time.Hour).Format(time.RFC3339), "updatedAt": now.Format(time.RFC3339),
+			"lastReconcileTime": now.Add(-20 * time.Minute).Format(time.RFC3339),
+		})
+
+		resp := getStatus(http.StatusOK)
+
+		Expect(resp.Stale).To(BeTrue())
+		Expect(resp.StaleReason).To(Equal("operator last reconcile is 20m0s old"))
+	})
+
+	It("Should report a missing operator status as stale", func() {
+		resp := getStatus(http.StatusOK)
+
+		Expect(resp.Operator).To(BeNil())
+		Expect(resp.Stale).To(BeTrue())
+		Expect(resp.Backend.GitCommit).To(Equal("backend-sha"))
+	})
+
+	It("Should expose the operator's startup stage histograms as metrics", func() {
+		publish(map[string]string{
+			"startupLatency": `{"buckets":[10,60],"stages":{"pod_scheduling":{"counts":[1,3],"sum":95.5,"count":4}}}`,
+		})
+
+		c := httpUtils.CreateTestGinContext("GET", "/metrics", nil)
+		Metrics(c)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		body := httpUtils.GetResponseBody()
+		Expect(body).To(ContainSubstring("# TYPE ambient_session_startup_stage_seconds histogram"))
+		Expect(body).To(ContainSubstring(`ambient_session_startup_stage_seconds_bucket{stage="pod_scheduling",le="10"} 1`))
+		Expect(body).To(ContainSubstring(`ambient_session_startup_stage_seconds_bucket{stage="pod_scheduling",le="60"} 3`))
+		Expect(body).To(ContainSubstring(`ambient_session_startup_stage_seconds_bucket{stage="pod_scheduling",le="+Inf"} 4`))
+		Expect(body).To(ContainSubstring(`ambient_session_startup_stage_seconds_sum{stage="pod_scheduling"} 95.5`))
+	})
+
+	It("Should require access to the operator namespace", func() {
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+		getStatus(http.StatusForbidden)
+	})
+})
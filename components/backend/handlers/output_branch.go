@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"ambient-code-backend/git"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// maxDisplayNameSlugLength caps .DisplayNameSlug so long session titles still give usable branch names
+const maxDisplayNameSlugLength = 50
+
+// OutputBranchData is the data an outputBranchTemplate is rendered with. Every field is already
+// slugged to characters that are safe in a git ref.
+type OutputBranchData struct {
+	SessionName     string
+	User            string
+	Date            string
+	DisplayNameSlug string
+}
+
+var (
+	refSlugUnsafe = regexp.MustCompile(`[^a-z0-9._-]+`)
+	refSlugDots   = regexp.MustCompile(`\.{2,}`)
+)
+
+// slugRefComponent lowercases s and collapses every run of characters outside [a-z0-9._-] into a dash
+func slugRefComponent(s string) string {
+	slug := refSlugUnsafe.ReplaceAllString(strings.ToLower(strings.TrimSpace(s)), "-")
+	slug = refSlugDots.ReplaceAllString(slug, ".")
+	return strings.Trim(slug, "-.")
+}
+
+// outputBranchData builds the template data of a session. Date is the session's creation date, so
+// a branch rendered again after a restart comes out the same; now is used before the session exists.
+func outputBranchData(session *unstructured.Unstructured, now time.Time) OutputBranchData {
+	created := session.GetCreationTimestamp().Time
+	if created.IsZero() {
+		created = now
+	}
+	userID, _, _ := unstructured.NestedString(session.Object, "spec", "userContext", "userId")
+	if at := strings.Index(userID, "@"); at > 0 {
+		userID = userID[:at]
+	}
+	displayName, _, _ := unstructured.NestedString(session.Object, "spec", "displayName")
+	displayNameSlug := slugRefComponent(displayName)
+	if len(displayNameSlug) > maxDisplayNameSlugLength {
+		displayNameSlug = strings.Trim(displayNameSlug[:maxDisplayNameSlugLength], "-.")
+	}
+	return OutputBranchData{
+		SessionName:     slugRefComponent(session.GetName()),
+		User:            slugRefComponent(userID),
+		Date:            created.UTC().Format("2006-01-02"),
+		DisplayNameSlug: displayNameSlug,
+	}
+}
+
+// renderOutputBranch renders a ProjectSettings outputBranchTemplate and checks the result is a
+// legal git branch name
+func renderOutputBranch(tmpl string, data OutputBranchData) (string, error) {
+	t, err := template.New("outputBranchTemplate").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid outputBranchTemplate: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("invalid outputBranchTemplate: %v", err)
+	}
+	branch := strings.TrimSpace(buf.String())
+	if err := git.ValidateBranchName(branch); err != nil {
+		return "", fmt.Errorf("outputBranchTemplate rendered %q, which is not a valid git branch name", branch)
+	}
+	return branch, nil
+}
+
+// projectOutputBranchTemplate returns spec.outputBranchTemplate from the project's ProjectSettings,
+// or "" when none is set
+func projectOutputBranchTemplate(ctx context.Context, dyn dynamic.Interface, project string) string {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, using default output branches: %v", project, err)
+		}
+		return ""
+	}
+	tmpl, _, _ := unstructured.NestedString(obj.Object, "spec", "outputBranchTemplate")
+	return strings.TrimSpace(tmpl)
+}
+
+// sessionOutputBranch renders the project's outputBranchTemplate for a session. It returns "" when
+// the project sets no template, in which case repos keep the sessions/<name> default.
+func sessionOutputBranch(ctx context.Context, dyn dynamic.Interface, project string, session *unstructured.Unstructured) (string, error) {
+	tmpl := projectOutputBranchTemplate(ctx, dyn, project)
+	if tmpl == "" {
+		return "", nil
+	}
+	return renderOutputBranch(tmpl, outputBranchData(session, time.Now()))
+}
+
+// repoOutputBranch returns spec.repos[i].output.branch of a repo entry, or "" when unset
+func repoOutputBranch(rm map[string]interface{}) string {
+	out, _ := rm["output"].(map[string]interface{})
+	branch, _ := out["branch"].(string)
+	return strings.TrimSpace(branch)
+}
+
+// setUnsetOutputBranches sets output.branch on every repo of a session object that has none
+func setUnsetOutputBranches(session map[string]interface{}, branch string) {
+	spec, _ := session["spec"].(map[string]interface{})
+	switch repos := spec["repos"].(type) {
+	case []interface{}:
+		for _, r := range repos {
+			if rm, ok := r.(map[string]interface{}); ok && repoOutputBranch(rm) == "" {
+				setRepoOutputBranch(rm, branch)
+			}
+		}
+	case []map[string]interface{}:
+		for _, rm := range repos {
+			if repoOutputBranch(rm) == "" {
+				setRepoOutputBranch(rm, branch)
+			}
+		}
+	}
+}
+
+func setRepoOutputBranch(rm map[string]interface{}, branch string) {
+	out, ok := rm["output"].(map[string]interface{})
+	if !ok {
+		out = map[string]interface{}{}
+		rm["output"] = out
+	}
+	out["branch"] = branch
+}
+
+// persistRepoOutputBranch records a rendered branch in spec.repos[index].output.branch so later
+// pushes and restarts reuse it even if the template or display name changes. A branch already set
+// there is left alone.
+func persistRepoOutputBranch(ctx context.Context, dyn dynamic.Interface, project, sessionName string, index int, branch string) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+		if index < 0 || index >= len(repos) {
+			return fmt.Errorf("repo index %d out of range", index)
+		}
+		rm, ok := repos[index].(map[string]interface{})
+		if !ok || repoOutputBranch(rm) != "" {
+			return nil
+		}
+		setRepoOutputBranch(rm, branch)
+		if err := unstructured.SetNestedSlice(obj.Object, repos, "spec", "repos"); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).Update(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
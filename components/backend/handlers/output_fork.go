@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// repoAutoFork reports whether spec.repos[i].output.autoFork is set on a repo entry
+func repoAutoFork(rm map[string]interface{}) bool {
+	out, _ := rm["output"].(map[string]interface{})
+	autoFork, _ := out["autoFork"].(bool)
+	return autoFork
+}
+
+// errForkHostNotAllowed is returned by forkOutputRepo when the fork lies outside the project's
+// spec.allowedGitHosts; the push must not go there
+var errForkHostNotAllowed = errors.New("fork is outside the project's allowed git hosts")
+
+// forkOutputRepo points a push target with output.autoFork at a fork in the session owner's
+// account when their token cannot push to the output repo. The fork replaces
+// spec.repos[Index].output.url and status.reconciledRepos records the repo it was forked from, so a
+// pull request can be opened against the original. An access check that fails leaves the target
+// alone and the push reports the problem; an error is returned only when a needed fork fails or,
+// as errForkHostNotAllowed, when the fork is not in allowedHosts (the project's allowedGitHosts).
+func forkOutputRepo(ctx context.Context, apiBase, project, session string, t *repoPushTarget, token string, allowedHosts []string) error {
+	if !t.AutoFork || token == "" || types.DetectProvider(t.OutputURL) != types.ProviderGitHub {
+		return nil
+	}
+	canPush, err := git.CanPushGitHubRepo(ctx, t.OutputURL, token, apiBase)
+	if err != nil {
+		log.Printf("forkOutputRepo: cannot check push access to %s for %s/%s: %v", t.OutputURL, project, session, err)
+		return nil
+	}
+	if canPush {
+		return nil
+	}
+	upstream := t.OutputURL
+	forkURL, err := git.ForkGitHubRepo(ctx, upstream, token, apiBase)
+	if err != nil {
+		return err
+	}
+	if !gitHostAllowed(forkURL, allowedHosts) {
+		log.Printf("forkOutputRepo: refusing fork %s of %s for %s/%s repo %d: not in allowedGitHosts", forkURL, upstream, project, session, t.Index)
+		return errForkHostNotAllowed
+	}
+	log.Printf("forkOutputRepo: %s/%s repo %d pushes to fork %s of %s", project, session, t.Index, forkURL, upstream)
+	t.OutputURL = forkURL
+
+	if DynamicClient == nil {
+		return nil
+	}
+	if err := persistRepoOutputFork(ctx, DynamicClient, project, session, t.Index, upstream, forkURL); err != nil {
+		log.Printf("forkOutputRepo: failed to record fork for %s/%s repo %d: %v", project, session, t.Index, err)
+	}
+	if t.InputURL != "" {
+		if err := setRepoStatus(ctx, DynamicClient, project, session, t.InputURL, map[string]interface{}{"forkOf": upstream, "forkUrl": forkURL}); err != nil {
+			log.Printf("forkOutputRepo: failed to record fork status for %s/%s repo %d: %v", project, session, t.Index, err)
+		}
+	}
+	return nil
+}
+
+// persistRepoOutputFork replaces spec.repos[index].output.url with the fork of upstream so later
+// pushes and restarts use it. An output URL changed since the fork was made is left alone.
+func persistRepoOutputFork(ctx context.Context, dyn dynamic.Interface, project, sessionName string, index int, upstream, forkURL string) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+		if index < 0 || index >= len(repos) {
+			return fmt.Errorf("repo index %d out of range", index)
+		}
+		rm, ok := repos[index].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		out, _ := rm["output"].(map[string]interface{})
+		if current, _ := out["url"].(string); !sameRepoURL(strings.TrimSpace(current), upstream) {
+			return nil
+		}
+		out["url"] = forkURL
+		if err := unstructured.SetNestedSlice(obj.Object, repos, "spec", "repos"); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).Update(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
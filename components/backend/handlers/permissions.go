@@ -61,14 +61,14 @@ type PermissionAssignment struct {
 func ListProjectPermissions(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if strings.TrimSpace(projectName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -76,7 +76,7 @@ func ListProjectPermissions(c *gin.Context) {
 	rbsAll, err := k8sClient.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{})
 	if err != nil {
 		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list permissions"})
+		respondK8sError(c, err, "Project not found", "Failed to list permissions")
 		return
 	}
 
@@ -143,7 +143,7 @@ func AddProjectPermission(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -153,19 +153,19 @@ func AddProjectPermission(c *gin.Context) {
 		Role        string `json:"role" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
 	// Validate subject name is a valid Kubernetes resource name
 	if !isValidKubernetesName(req.SubjectName) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid userName format. Must be a valid Kubernetes resource name."})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid userName format. Must be a valid Kubernetes resource name.", nil)
 		return
 	}
 
 	st := strings.ToLower(strings.TrimSpace(req.SubjectType))
 	if st != "group" && st != "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "subjectType must be one of: group, user", nil)
 		return
 	}
 	subjectKind := "Group"
@@ -182,7 +182,7 @@ func AddProjectPermission(c *gin.Context) {
 	case "view":
 		roleRefName = AmbientRoleView
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of: admin, edit, view"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "role must be one of: admin, edit, view", nil)
 		return
 	}
 
@@ -206,15 +206,15 @@ func AddProjectPermission(c *gin.Context) {
 
 	if _, err := k8sClient.RbacV1().RoleBindings(projectName).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
-			c.JSON(http.StatusConflict, gin.H{"error": "permission already exists for this subject and role"})
+			respondError(c, http.StatusConflict, ErrorKindConflict, "permission already exists for this subject and role", nil)
 			return
 		}
 		if errors.IsForbidden(err) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to grant permission"})
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to grant permission", nil)
 			return
 		}
 		log.Printf("Failed to create RoleBinding in %s for %s %s: %v", projectName, st, req.SubjectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to grant permission"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to grant permission", nil)
 		return
 	}
 
@@ -225,7 +225,7 @@ func AddProjectPermission(c *gin.Context) {
 func RemoveProjectPermission(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if strings.TrimSpace(projectName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project is required in path /api/projects/:projectName or X-OpenShift-Project header"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project is required in path /api/projects/:projectName or X-OpenShift-Project header", nil)
 		return
 	}
 	subjectType := strings.ToLower(c.Param("subjectType"))
@@ -234,23 +234,23 @@ func RemoveProjectPermission(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	if subjectType != "group" && subjectType != "user" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectType must be one of: group, user"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "subjectType must be one of: group, user", nil)
 		return
 	}
 	if strings.TrimSpace(subjectName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "subjectName is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "subjectName is required", nil)
 		return
 	}
 
 	rbs, err := k8sClient.RbacV1().RoleBindings(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-permission"})
 	if err != nil {
 		log.Printf("Failed to list RoleBindings in %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove permission"})
+		respondK8sError(c, err, "Project not found", "Failed to remove permission")
 		return
 	}
 
@@ -278,7 +278,7 @@ func ListProjectKeys(c *gin.Context) {
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -286,7 +286,7 @@ func ListProjectKeys(c *gin.Context) {
 	sas, err := k8sClient.CoreV1().ServiceAccounts(projectName).List(context.TODO(), v1.ListOptions{LabelSelector: "app=ambient-access-key"})
 	if err != nil {
 		log.Printf("Failed to list access keys in %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list access keys"})
+		respondK8sError(c, err, "Project not found", "Failed to list access keys")
 		return
 	}
 
@@ -341,14 +341,14 @@ func ListProjectKeys(c *gin.Context) {
 func CreateProjectKey(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if strings.TrimSpace(projectName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -358,7 +358,7 @@ func CreateProjectKey(c *gin.Context) {
 		Role        string `json:"role"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -376,7 +376,7 @@ func CreateProjectKey(c *gin.Context) {
 	case "view":
 		roleRefName = AmbientRoleView
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be one of: admin, edit, view"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "role must be one of: admin, edit, view", nil)
 		return
 	}
 
@@ -398,7 +398,7 @@ func CreateProjectKey(c *gin.Context) {
 	}
 	if _, err := k8sClient.CoreV1().ServiceAccounts(projectName).Create(context.TODO(), sa, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
 		log.Printf("Failed to create ServiceAccount %s in %s: %v", saName, projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create service account"})
+		respondK8sError(c, err, "Project not found", "Failed to create service account")
 		return
 	}
 
@@ -420,7 +420,7 @@ func CreateProjectKey(c *gin.Context) {
 	}
 	if _, err := k8sClient.RbacV1().RoleBindings(projectName).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
 		log.Printf("Failed to create RoleBinding %s in %s: %v", rbName, projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to bind service account"})
+		respondK8sError(c, err, "Project not found", "Failed to bind service account")
 		return
 	}
 
@@ -429,7 +429,7 @@ func CreateProjectKey(c *gin.Context) {
 	tok, err := k8sClient.CoreV1().ServiceAccounts(projectName).CreateToken(context.TODO(), saName, tr, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("Failed to create token for SA %s/%s: %v", projectName, saName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate access token"})
+		respondK8sError(c, err, "Service account not found", "Failed to generate access token")
 		return
 	}
 
@@ -448,20 +448,20 @@ func CreateProjectKey(c *gin.Context) {
 func DeleteProjectKey(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if strings.TrimSpace(projectName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 
 	keyID := c.Param("keyId")
 	if strings.TrimSpace(keyID) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Key ID is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Key ID is required", nil)
 		return
 	}
 
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	k8sClient := reqK8s
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -477,7 +477,7 @@ func DeleteProjectKey(c *gin.Context) {
 	if err := k8sClient.CoreV1().ServiceAccounts(projectName).Delete(context.TODO(), keyID, v1.DeleteOptions{}); err != nil {
 		if !errors.IsNotFound(err) {
 			log.Printf("Failed to delete service account %s in %s: %v", keyID, projectName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete access key"})
+			respondK8sError(c, err, "Access key not found", "Failed to delete access key")
 			return
 		}
 	}
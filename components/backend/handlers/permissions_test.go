@@ -497,10 +497,8 @@ var _ = Describe("Permissions Handler", Ordered, Label(test_constants.LabelUnit,
 				AddProjectPermission(ginContext)
 
 				// Assert - Our fake client reactors simulate RBAC for rolebindings/namespaces using SSARAllowedFunc
-				httpUtils.AssertHTTPStatus(http.StatusForbidden)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Insufficient permissions to grant permission",
-				})
+				httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden",
+					"Insufficient permissions to grant permission")
 				logger.Log("Correctly rejected permission creation with insufficient RBAC permissions")
 			})
 
@@ -519,10 +517,8 @@ var _ = Describe("Permissions Handler", Ordered, Label(test_constants.LabelUnit,
 
 				AddProjectPermission(ginContext)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "role must be one of: admin, edit, view",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation",
+					"role must be one of: admin, edit, view")
 			})
 
 			It("Should reject empty username", func() {
@@ -768,10 +764,8 @@ var _ = Describe("Permissions Handler", Ordered, Label(test_constants.LabelUnit,
 
 				AddProjectPermission(ginContext)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Invalid userName format. Must be a valid Kubernetes resource name.",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation",
+					"Invalid userName format. Must be a valid Kubernetes resource name.")
 
 				logger.Log("Correctly rejected invalid userName: %s", userName)
 			}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ValidateRunnerScheduling checks the runner scheduling fields of a ProjectSettings spec
+// (runnerNodeSelector, runnerTolerations, runnerAffinity) before it is written. The operator merges
+// these into runner pod specs and skips them entirely when they are malformed, so settings writes
+// must reject bad values instead of letting sessions silently land on the wrong nodes.
+func ValidateRunnerScheduling(spec map[string]interface{}) error {
+	if raw, found := spec["runnerNodeSelector"]; found && raw != nil {
+		selector, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("spec.runnerNodeSelector must be an object of strings")
+		}
+		for k, v := range selector {
+			value, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("spec.runnerNodeSelector[%s] must be a string", k)
+			}
+			if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+				return fmt.Errorf("spec.runnerNodeSelector key %q: %s", k, strings.Join(errs, "; "))
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return fmt.Errorf("spec.runnerNodeSelector[%s]: %s", k, strings.Join(errs, "; "))
+			}
+		}
+	}
+
+	if raw, found := spec["runnerTolerations"]; found && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return fmt.Errorf("spec.runnerTolerations must be a list")
+		}
+		for i, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("spec.runnerTolerations[%d] must be an object", i)
+			}
+			var t corev1.Toleration
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(m, &t, true); err != nil {
+				return fmt.Errorf("spec.runnerTolerations[%d]: %v", i, err)
+			}
+			if err := validateToleration(t); err != nil {
+				return fmt.Errorf("spec.runnerTolerations[%d]: %v", i, err)
+			}
+		}
+	}
+
+	if raw, found := spec["runnerAffinity"]; found && raw != nil {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("spec.runnerAffinity must be an object")
+		}
+		var affinity corev1.Affinity
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(m, &affinity, true); err != nil {
+			return fmt.Errorf("spec.runnerAffinity: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateToleration mirrors the core/v1 toleration rules the API server enforces on pods
+func validateToleration(t corev1.Toleration) error {
+	if t.Key != "" {
+		if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+			return fmt.Errorf("key %q: %s", t.Key, strings.Join(errs, "; "))
+		}
+	}
+	switch t.Operator {
+	case corev1.TolerationOpExists:
+		if t.Value != "" {
+			return fmt.Errorf("value must be empty when operator is Exists")
+		}
+	case corev1.TolerationOpEqual, "":
+		if t.Key == "" {
+			return fmt.Errorf("operator must be Exists when key is empty")
+		}
+	default:
+		return fmt.Errorf("unsupported operator %q", t.Operator)
+	}
+	switch t.Effect {
+	case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return fmt.Errorf("unsupported effect %q", t.Effect)
+	}
+	if t.TolerationSeconds != nil && t.Effect != corev1.TaintEffectNoExecute {
+		return fmt.Errorf("tolerationSeconds requires effect NoExecute")
+	}
+	return nil
+}
+
+// ProjectSettingsFieldError is a problem with one field of a ProjectSettings spec
+type ProjectSettingsFieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ProjectSettingsResponse is the body of GET and PUT .../settings
+type ProjectSettingsResponse struct {
+	ResourceVersion string                 `json:"resourceVersion"`
+	Spec            map[string]interface{} `json:"spec"`
+	Status          map[string]interface{} `json:"status,omitempty"`
+}
+
+// UpdateProjectSettingsRequest replaces the ProjectSettings spec. ResourceVersion must be the one
+// last read, so concurrent edits are rejected instead of overwritten.
+type UpdateProjectSettingsRequest struct {
+	ResourceVersion string                 `json:"resourceVersion"`
+	Spec            map[string]interface{} `json:"spec"`
+}
+
+// validateProjectSettingsSpec checks what the CRD schema cannot: that referenced Secrets exist, and
+// that templates, patterns and durations are usable. Secrets are looked up with the caller's client.
+func validateProjectSettingsSpec(ctx context.Context, reqK8s kubernetes.Interface, project string, spec map[string]interface{}) []ProjectSettingsFieldError {
+	problems := []ProjectSettingsFieldError{}
+	add := func(field, format string, args ...interface{}) {
+		problems = append(problems, ProjectSettingsFieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	secretHasKey := func(field, name, key string) {
+		sec, err := reqK8s.CoreV1().Secrets(project).Get(ctx, name, v1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			add(field, "secret %q not found", name)
+		case err != nil:
+			log.Printf("ProjectSettings validation: failed to read secret %s/%s: %v", project, name, err)
+			add(field, "secret %q could not be read", name)
+		case key != "":
+			if _, ok := sec.Data[key]; !ok {
+				add(field, "secret %q has no key %q", name, key)
+			}
+		}
+	}
+
+	if name, ok := spec["runnerSecretsName"].(string); ok && strings.TrimSpace(name) != "" {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			add("spec.runnerSecretsName", "%s", strings.Join(errs, "; "))
+		} else {
+			secretHasKey("spec.runnerSecretsName", name, "")
+		}
+	}
+
+	if raw, ok := spec["gitlab"].(map[string]interface{}); ok {
+		var cfg types.GitLabInstanceConfig
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &cfg); err != nil {
+			add("spec.gitlab", "%v", err)
+		} else if strings.TrimSpace(cfg.BaseURL) != "" {
+			if err := validateGitLabInstanceConfig(&cfg); err != nil {
+				add("spec.gitlab", "%v", err)
+			} else if ref := cfg.CABundleSecretRef; ref != nil {
+				secretHasKey("spec.gitlab.caBundleSecretRef", ref.Name, ref.Key)
+			}
+		}
+	}
+
+	if raw, ok := spec["commitSigning"].(map[string]interface{}); ok {
+		cfg, err := commitSigningConfig(raw)
+		if err != nil {
+			add("spec.commitSigning", "%v", err)
+		} else if cfg != nil {
+			if ref, _, err := commitSigningKeyRef(cfg); err != nil {
+				add("spec.commitSigning", "%v", err)
+			} else {
+				secretHasKey("spec.commitSigning", ref.Name, ref.Key)
+			}
+		}
+	}
+
+	if tmpl, ok := spec["outputBranchTemplate"].(string); ok && strings.TrimSpace(tmpl) != "" {
+		sample := OutputBranchData{SessionName: "session", User: "user", Date: "2006-01-02", DisplayNameSlug: "display-name"}
+		if _, err := renderOutputBranch(tmpl, sample); err != nil {
+			add("spec.outputBranchTemplate", "%v", err)
+		}
+	}
+
+	if patterns, ok := spec["allowedRunnerImages"].([]interface{}); ok {
+		for i, p := range patterns {
+			s, _ := p.(string)
+			if _, err := path.Match(strings.TrimSpace(s), ""); err != nil || strings.TrimSpace(s) == "" {
+				add(fmt.Sprintf("spec.allowedRunnerImages[%d]", i), "%q is not a valid image pattern", s)
+			}
+		}
+	}
+
+	if patterns, ok := spec["allowedGitHosts"].([]interface{}); ok {
+		for i, p := range patterns {
+			s, _ := p.(string)
+			if strings.TrimSpace(s) == "" || strings.ContainsAny(s, "*? \t") || gitHostPattern(s) == "" {
+				add(fmt.Sprintf("spec.allowedGitHosts[%d]", i), "%q is not a host or host/owner prefix", s)
+			}
+		}
+	}
+
+	if vars, ok := spec["promptVariables"].(map[string]interface{}); ok {
+		names := make([]string, 0, len(vars))
+		for k := range vars {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			field := "spec.promptVariables." + k
+			switch {
+			case !promptVariableNamePattern.MatchString(k):
+				add(field, "%q is not a template field name (letters, digits and '_', not starting with a digit)", k)
+			case slices.Contains(promptTemplateFields, k):
+				add(field, "%q is derived from the session and cannot be set", k)
+			}
+			if _, isString := vars[k].(string); !isString {
+				add(field, "must be a string")
+			}
+		}
+	}
+
+	if maxAge, _, _ := unstructured.NestedString(spec, "sessionRetention", "maxAge"); maxAge != "" {
+		if d, err := time.ParseDuration(maxAge); err != nil || d <= 0 {
+			add("spec.sessionRetention.maxAge", "%q is not a positive duration", maxAge)
+		}
+	}
+
+	// Checked one field at a time so each problem is reported against its own field
+	for _, field := range []string{"runnerNodeSelector", "runnerTolerations", "runnerAffinity"} {
+		if raw, found := spec[field]; found {
+			if err := ValidateRunnerScheduling(map[string]interface{}{field: raw}); err != nil {
+				add("spec."+field, "%v", err)
+			}
+		}
+	}
+
+	if raw, found := spec["chargebackLabels"]; found && raw != nil {
+		if lbls, ok := raw.(map[string]interface{}); !ok {
+			add("spec.chargebackLabels", "must map label names to values")
+		} else {
+			keys := make([]string, 0, len(lbls))
+			for k := range lbls {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				value, ok := lbls[k].(string)
+				if !ok {
+					add("spec.chargebackLabels."+k, "must be a string")
+				} else if errs := chargebackLabelErrors(k, value); len(errs) > 0 {
+					add("spec.chargebackLabels."+k, "%s", strings.Join(errs, "; "))
+				}
+			}
+		}
+	}
+
+	def, _ := numberValue(spec["sessionCostLimitUSD"])
+	max, _ := numberValue(spec["maxSessionCostLimitUSD"])
+	if def > 0 && max > 0 && def > max {
+		add("spec.sessionCostLimitUSD", "must not exceed maxSessionCostLimitUSD (%g)", max)
+	}
+	return problems
+}
+
+func projectSettingsResponse(obj *unstructured.Unstructured) ProjectSettingsResponse {
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	status, _, _ := unstructured.NestedMap(obj.Object, "status")
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+	return ProjectSettingsResponse{ResourceVersion: obj.GetResourceVersion(), Spec: spec, Status: status}
+}
+
+// GetProjectSettings returns the project's ProjectSettings spec and status with its resourceVersion.
+// Problems with settings edited outside the API are reported in status.conditions by the operator.
+// GET /api/projects/:projectName/settings
+func GetProjectSettings(c *gin.Context) {
+	project := c.Param("projectName")
+	_, reqDyn := GetK8sClientsForRequest(c)
+	if reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	obj, err := reqDyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(c.Request.Context(), "projectsettings", v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return
+	}
+	c.JSON(http.StatusOK, projectSettingsResponse(obj))
+}
+
+// UpdateProjectSettings validates and replaces the project's ProjectSettings spec. Invalid fields
+// are all reported at once as details.fields; a stale resourceVersion gets 409.
+// PUT /api/projects/:projectName/settings
+// Body: { resourceVersion, spec }
+func UpdateProjectSettings(c *gin.Context) {
+	project := c.Param("projectName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if !requireProjectAdmin(c, reqK8s, project) {
+		return
+	}
+
+	var req UpdateProjectSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+	if strings.TrimSpace(req.ResourceVersion) == "" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "resourceVersion is required", nil)
+		return
+	}
+	if req.Spec == nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "spec is required", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if problems := validateProjectSettingsSpec(ctx, reqK8s, project, req.Spec); len(problems) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid project settings", gin.H{"fields": problems})
+		return
+	}
+
+	gvr := GetProjectSettingsResource()
+	obj, err := reqDyn.Resource(gvr).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return
+	}
+	obj.Object["spec"] = req.Spec
+	obj.SetResourceVersion(req.ResourceVersion)
+	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(ctx, obj, v1.UpdateOptions{})
+	if err != nil {
+		if errors.IsInvalid(err) {
+			// Rejected by the CRD schema: relay its causes as field errors
+			problems := []ProjectSettingsFieldError{}
+			if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil {
+				for _, cause := range status.Status().Details.Causes {
+					problems = append(problems, ProjectSettingsFieldError{Field: cause.Field, Message: cause.Message})
+				}
+			}
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid project settings", gin.H{"fields": problems})
+			return
+		}
+		log.Printf("Failed to update ProjectSettings in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to update ProjectSettings")
+		return
+	}
+	c.JSON(http.StatusOK, projectSettingsResponse(updated))
+}
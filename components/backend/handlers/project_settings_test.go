@@ -0,0 +1,191 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("ProjectSettings runner scheduling validation", Label(test_constants.LabelUnit, test_constants.LabelHandlers), func() {
+	It("Should accept well-formed node selector, tolerations and affinity", func() {
+		spec := map[string]interface{}{
+			"runnerNodeSelector": map[string]interface{}{"node-role.kubernetes.io/runner": "true"},
+			"runnerTolerations": []interface{}{
+				map[string]interface{}{"key": "dedicated", "operator": "Equal", "value": "runners", "effect": "NoSchedule"},
+				map[string]interface{}{"operator": "Exists"},
+			},
+			"runnerAffinity": map[string]interface{}{
+				"nodeAffinity": map[string]interface{}{
+					"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+						"nodeSelectorTerms": []interface{}{map[string]interface{}{
+							"matchExpressions": []interface{}{map[string]interface{}{
+								"key": "zone", "operator": "In", "values": []interface{}{"us-east-1a"},
+							}},
+						}},
+					},
+				},
+			},
+		}
+		Expect(ValidateRunnerScheduling(spec)).To(Succeed())
+		Expect(ValidateRunnerScheduling(map[string]interface{}{})).To(Succeed())
+	})
+
+	DescribeTable("Should reject malformed constraints",
+		func(spec map[string]interface{}, message string) {
+			err := ValidateRunnerScheduling(spec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(message))
+		},
+		Entry("non-string selector value", map[string]interface{}{"runnerNodeSelector": map[string]interface{}{"zone": int64(1)}}, "must be a string"),
+		Entry("invalid selector key", map[string]interface{}{"runnerNodeSelector": map[string]interface{}{"bad key": "x"}}, "key \"bad key\""),
+		Entry("tolerations not a list", map[string]interface{}{"runnerTolerations": "dedicated"}, "must be a list"),
+		Entry("unknown toleration field", map[string]interface{}{"runnerTolerations": []interface{}{map[string]interface{}{"key": "a", "colour": "red"}}}, "runnerTolerations[0]"),
+		Entry("Exists with value", map[string]interface{}{"runnerTolerations": []interface{}{map[string]interface{}{"key": "a", "operator": "Exists", "value": "b"}}}, "value must be empty"),
+		Entry("unsupported effect", map[string]interface{}{"runnerTolerations": []interface{}{map[string]interface{}{"key": "a", "value": "b", "effect": "Sometimes"}}}, "unsupported effect"),
+		Entry("unknown affinity field", map[string]interface{}{"runnerAffinity": map[string]interface{}{"nodeAfinity": map[string]interface{}{}}}, "spec.runnerAffinity"),
+	)
+})
+
+var _ = Describe("ProjectSettings API", Label(test_constants.LabelUnit, test_constants.LabelHandlers), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testToken     string
+		fakeDyn       *dynamicfake.FakeDynamicClient
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		// The fake tracker ignores resourceVersion; act like the API server and reject stale updates
+		gvr := GetProjectSettingsResource()
+		fakeDyn = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{gvr: "ProjectSettingsList"})
+		Expect(fakeDyn.Tracker().Create(gvr, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace, "resourceVersion": "1"},
+			"spec":       map[string]interface{}{"groupAccess": []interface{}{}},
+		}}, testNamespace)).To(Succeed())
+		fakeDyn.PrependReactor("update", "projectsettings", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			obj := action.(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+			stored, err := fakeDyn.Tracker().Get(gvr, testNamespace, obj.GetName())
+			if err != nil {
+				return true, nil, err
+			}
+			current, _ := strconv.Atoi(stored.(*unstructured.Unstructured).GetResourceVersion())
+			if obj.GetResourceVersion() != strconv.Itoa(current) {
+				return true, nil, errors.NewConflict(gvr.GroupResource(), obj.GetName(), fmt.Errorf("the object has been modified"))
+			}
+			obj.SetResourceVersion(strconv.Itoa(current + 1))
+			return false, nil, nil
+		})
+		DynamicClient = fakeDyn
+	})
+
+	call := func(handler gin.HandlerFunc, method string, body interface{}) ProjectSettingsResponse {
+		context := httpUtils.CreateTestGinContext(method, fmt.Sprintf("/api/projects/%s/settings", testNamespace), body)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}}
+		handler(context)
+		var resp ProjectSettingsResponse
+		httpUtils.GetResponseJSON(&resp)
+		return resp
+	}
+
+	It("Should reject settings that reference a missing secret, with every invalid field", func() {
+		current := call(GetProjectSettings, "GET", nil)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(current.ResourceVersion).NotTo(BeEmpty())
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		call(UpdateProjectSettings, "PUT", map[string]interface{}{
+			"resourceVersion": current.ResourceVersion,
+			"spec": map[string]interface{}{
+				"runnerSecretsName":    "missing-secret",
+				"outputBranchTemplate": "vteam/{{.Nope}}",
+				"sessionRetention":     map[string]interface{}{"maxAge": "0s"},
+				"chargebackLabels":     map[string]interface{}{"costCenter": "cc-42", "team": "not a label value"},
+			},
+		})
+		httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+		var body struct {
+			Error struct {
+				Details struct {
+					Fields []ProjectSettingsFieldError `json:"fields"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		httpUtils.GetResponseJSON(&body)
+		fields := []string{}
+		for _, f := range body.Error.Details.Fields {
+			fields = append(fields, f.Field)
+		}
+		Expect(fields).To(ConsistOf("spec.runnerSecretsName", "spec.outputBranchTemplate", "spec.sessionRetention.maxAge", "spec.chargebackLabels.team"))
+		Expect(body.Error.Details.Fields).To(ContainElement(ProjectSettingsFieldError{Field: "spec.runnerSecretsName", Message: `secret "missing-secret" not found`}))
+
+		obj, err := fakeDyn.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Get(ctx, "projectsettings", v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(obj.Object["spec"]).NotTo(HaveKey("runnerSecretsName"))
+	})
+
+	It("Should save valid settings and reject a stale resourceVersion", func() {
+		_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "team-runner-secrets", Namespace: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		current := call(GetProjectSettings, "GET", nil)
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		saved := call(UpdateProjectSettings, "PUT", map[string]interface{}{
+			"resourceVersion": current.ResourceVersion,
+			"spec":            map[string]interface{}{"runnerSecretsName": "team-runner-secrets"},
+		})
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(saved.Spec).To(HaveKeyWithValue("runnerSecretsName", "team-runner-secrets"))
+		Expect(saved.ResourceVersion).NotTo(Equal(current.ResourceVersion))
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		call(UpdateProjectSettings, "PUT", map[string]interface{}{
+			"resourceVersion": current.ResourceVersion,
+			"spec":            map[string]interface{}{},
+		})
+		httpUtils.AssertHTTPStatus(http.StatusConflict)
+	})
+
+	It("Should require a resourceVersion", func() {
+		call(UpdateProjectSettings, "PUT", map[string]interface{}{"spec": map[string]interface{}{}})
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "resourceVersion is required")
+	})
+})
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/types"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// parallelStatsWorkerCount is the number of namespaces whose sessions are listed concurrently
+const parallelStatsWorkerCount = 10
+
+// projectStatsTimeout bounds the session list of a single namespace so one slow namespace
+// cannot stall the whole project list
+const projectStatsTimeout = 750 * time.Millisecond
+
+// projectStatsCacheTTL is how long computed stats are served without listing sessions again
+const projectStatsCacheTTL = 15 * time.Second
+
+// projectStatsEntry caches the stats of one namespace along with the session list resourceVersion
+// they were computed from
+type projectStatsEntry struct {
+	resourceVersion string
+	sessions        map[string]int
+	lastActivity    string
+	fetchedAt       time.Time
+}
+
+var (
+	projectStatsCache   = make(map[string]projectStatsEntry) // namespace -> stats
+	projectStatsCacheMu sync.Mutex
+)
+
+// summarizeSessions counts sessions by lower-cased phase (a missing phase counts as pending) and
+// returns the most recent status.startTime
+func summarizeSessions(items []unstructured.Unstructured) (map[string]int, string) {
+	counts := map[string]int{}
+	var latest time.Time
+	for _, item := range items {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if phase == "" {
+			phase = sessionPhasePending
+		}
+		counts[strings.ToLower(phase)]++
+
+		startRaw, _, _ := unstructured.NestedString(item.Object, "status", "startTime")
+		if start, err := time.Parse(time.RFC3339, startRaw); err == nil && start.After(latest) {
+			latest = start
+		}
+	}
+	if latest.IsZero() {
+		return counts, ""
+	}
+	return counts, latest.UTC().Format(time.RFC3339)
+}
+
+// loadProjectStats returns the session stats of a namespace, serving them from projectStatsCache
+// while fresh. A list whose resourceVersion matches the cached one reuses the cached stats.
+func loadProjectStats(ctx context.Context, dyn dynamic.Interface, namespace string, now time.Time) (projectStatsEntry, error) {
+	projectStatsCacheMu.Lock()
+	cached, found := projectStatsCache[namespace]
+	projectStatsCacheMu.Unlock()
+	if found && now.Sub(cached.fetchedAt) < projectStatsCacheTTL {
+		return cached, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, projectStatsTimeout)
+	defer cancel()
+	list, err := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return projectStatsEntry{}, err
+	}
+
+	entry := projectStatsEntry{resourceVersion: list.GetResourceVersion(), fetchedAt: now}
+	if found && entry.resourceVersion != "" && entry.resourceVersion == cached.resourceVersion {
+		entry.sessions, entry.lastActivity = cached.sessions, cached.lastActivity
+	} else {
+		entry.sessions, entry.lastActivity = summarizeSessions(list.Items)
+	}
+
+	projectStatsCacheMu.Lock()
+	projectStatsCache[namespace] = entry
+	projectStatsCacheMu.Unlock()
+	return entry, nil
+}
+
+// attachProjectStats fills Sessions and LastActivity of each project using a bounded worker pool.
+// A namespace whose sessions cannot be listed gets StatsError instead; the others are unaffected.
+func attachProjectStats(ctx context.Context, dyn dynamic.Interface, projects []types.AmbientProject) {
+	if len(projects) == 0 {
+		return
+	}
+
+	workerCount := parallelStatsWorkerCount
+	if len(projects) < workerCount {
+		workerCount = len(projects)
+	}
+	now := time.Now()
+	workChan := make(chan int, len(projects))
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range workChan {
+				stats, err := loadProjectStats(ctx, dyn, projects[i].Name, now)
+				if err != nil {
+					log.Printf("Failed to load session stats for project %s: %v", projects[i].Name, err)
+					if errors.Is(err, context.DeadlineExceeded) {
+						projects[i].StatsError = "timed out listing sessions"
+					} else {
+						projects[i].StatsError = "failed to list sessions"
+					}
+					continue
+				}
+				projects[i].Sessions = stats.sessions
+				projects[i].LastActivity = stats.lastActivity
+			}
+		}()
+	}
+	for i := range projects {
+		workChan <- i
+	}
+	close(workChan)
+	wg.Wait()
+}
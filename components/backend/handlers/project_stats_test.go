@@ -0,0 +1,107 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+	"ambient-code-backend/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Project Session Stats", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelProjects), func() {
+	session := func(namespace, name, phase, startTime string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		}}
+		if phase != "" {
+			_ = unstructured.SetNestedField(obj.Object, phase, "status", "phase")
+		}
+		if startTime != "" {
+			_ = unstructured.SetNestedField(obj.Object, startTime, "status", "startTime")
+		}
+		return obj
+	}
+
+	newDynamicClient := func(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+		return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+			map[schema.GroupVersionResource]string{GetAgenticSessionV1Alpha1Resource(): "AgenticSessionList"}, objects...)
+	}
+
+	BeforeEach(func() {
+		SetupHandlerDependencies(test_utils.NewK8sTestUtils(false, *config.TestNamespace))
+		projectStatsCacheMu.Lock()
+		projectStatsCache = make(map[string]projectStatsEntry)
+		projectStatsCacheMu.Unlock()
+	})
+
+	It("Should count sessions by phase and report the latest start time", func() {
+		counts, last := summarizeSessions([]unstructured.Unstructured{
+			*session("p", "a", "Running", "2026-01-01T10:00:00Z"),
+			*session("p", "b", "Running", "2026-01-02T10:00:00Z"),
+			*session("p", "c", "Completed", "2026-01-01T08:00:00Z"),
+			*session("p", "d", "", ""),
+		})
+
+		Expect(counts).To(Equal(map[string]int{"running": 2, "completed": 1, "pending": 1}))
+		Expect(last).To(Equal("2026-01-02T10:00:00Z"))
+	})
+
+	It("Should attach stats per project and keep going when one namespace fails", func() {
+		dyn := newDynamicClient(
+			session("alpha", "s1", "Running", "2026-01-01T10:00:00Z"),
+			session("alpha", "s2", "Completed", "2026-01-01T09:00:00Z"),
+		)
+		dyn.PrependReactor("list", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetNamespace() == "broken" {
+				return true, nil, fmt.Errorf("etcd unavailable")
+			}
+			return false, nil, nil
+		})
+		projects := []types.AmbientProject{{Name: "alpha"}, {Name: "broken"}, {Name: "empty"}}
+
+		attachProjectStats(context.Background(), dyn, projects)
+
+		Expect(projects[0].Sessions).To(Equal(map[string]int{"running": 1, "completed": 1}))
+		Expect(projects[0].LastActivity).To(Equal("2026-01-01T10:00:00Z"))
+		Expect(projects[0].StatsError).To(BeEmpty())
+		Expect(projects[1].Sessions).To(BeNil())
+		Expect(projects[1].StatsError).To(Equal("failed to list sessions"))
+		Expect(projects[2].Sessions).To(BeEmpty())
+		Expect(projects[2].StatsError).To(BeEmpty())
+	})
+
+	It("Should serve fresh stats from the cache without listing again", func() {
+		dyn := newDynamicClient(session("alpha", "s1", "Running", ""))
+		now := time.Now()
+
+		_, err := loadProjectStats(context.Background(), dyn, "alpha", now)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("alpha").Create(context.Background(),
+			session("alpha", "s2", "Running", ""), v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		cached, err := loadProjectStats(context.Background(), dyn, "alpha", now.Add(time.Second))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached.sessions).To(Equal(map[string]int{"running": 1}))
+
+		refreshed, err := loadProjectStats(context.Background(), dyn, "alpha", now.Add(projectStatsCacheTTL+time.Second))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(refreshed.sessions).To(Equal(map[string]int{"running": 2}))
+	})
+})
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -159,24 +158,30 @@ const parallelSSARWorkerCount = 10
 // then uses SubjectAccessReview to verify user access to each namespace.
 // Supports pagination via limit/offset and search filtering.
 // SSAR checks are performed in parallel for improved performance.
+// With ?includeStats=true each returned project also carries its session counts by phase and last activity.
 func ListProjects(c *gin.Context) {
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	// Parse pagination parameters
 	var params types.PaginationParams
 	if err := c.ShouldBindQuery(&params); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pagination parameters"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid pagination parameters", nil)
 		return
 	}
 	types.NormalizePaginationParams(&params)
+	order, err := parseListSort(params, sortByCreationTimestamp, sortByDisplayName)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
 
 	// List namespaces using backend SA (both platforms)
 	if K8sClientProjects == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list projects", nil)
 		return
 	}
 
@@ -190,7 +195,7 @@ func ListProjects(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("Failed to list Namespaces: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list projects", nil)
 		return
 	}
 
@@ -200,13 +205,19 @@ func ListProjects(c *gin.Context) {
 	// Perform parallel SSAR checks using worker pool
 	accessibleProjects := performParallelSSARChecks(ctx, k8sClt, filteredNamespaces, isOpenShift)
 
-	// Sort by creation timestamp (newest first)
-	sortProjectsByCreationTime(accessibleProjects)
+	sortProjects(accessibleProjects, order)
 
 	// Apply pagination
 	totalCount := len(accessibleProjects)
 	paginatedProjects, hasMore, nextOffset := paginateProjects(accessibleProjects, params.Offset, params.Limit)
 
+	// Session stats are only gathered for the returned page
+	if strings.EqualFold(c.Query("includeStats"), "true") {
+		if _, k8sDyn := GetK8sClientsForRequest(c); k8sDyn != nil {
+			attachProjectStats(ctx, k8sDyn, paginatedProjects)
+		}
+	}
+
 	response := types.PaginatedResponse{
 		Items:      paginatedProjects,
 		TotalCount: totalCount,
@@ -336,13 +347,19 @@ func performParallelSSARChecks(ctx context.Context, reqK8s kubernetes.Interface,
 	return projects
 }
 
-// sortProjectsByCreationTime sorts projects by creation timestamp (newest first)
-func sortProjectsByCreationTime(projects []types.AmbientProject) {
-	// Use sort.Slice for O(n log n) performance
-	// RFC3339 timestamps sort lexicographically
-	sort.Slice(projects, func(i, j int) bool {
-		return projects[i].CreationTimestamp > projects[j].CreationTimestamp
-	})
+// sortProjects orders projects by ?sortBy= (default creationTimestamp, newest first); displayName
+// falls back to the namespace name, as it is empty on plain Kubernetes
+func sortProjects(projects []types.AmbientProject, order listSort) {
+	value := func(p types.AmbientProject) string { return p.CreationTimestamp }
+	if order.key == sortByDisplayName {
+		value = func(p types.AmbientProject) string {
+			if p.DisplayName != "" {
+				return strings.ToLower(p.DisplayName)
+			}
+			return strings.ToLower(p.Name)
+		}
+	}
+	sortListStable(projects, order, value, func(p types.AmbientProject) string { return p.Name })
 }
 
 // paginateProjects applies offset/limit pagination to the project list
@@ -406,21 +423,21 @@ func projectFromNamespace(ns *corev1.Namespace, isOpenShift bool) types.AmbientP
 func CreateProject(c *gin.Context) {
 	k8Clt, _ := GetK8sClientsForRequest(c)
 	if k8Clt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
 	var req types.CreateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// Return validation error details for 400 Bad Request (user-facing validation)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
 	// Validate project name
 	if err := validateProjectName(req.Name); err != nil {
 		// Validation errors can be specific for 400 Bad Request
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -428,7 +445,7 @@ func CreateProject(c *gin.Context) {
 	userSubject, err := getUserSubjectFromContext(c)
 	if err != nil {
 		log.Printf("CreateProject: Failed to extract user subject: %v", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid token", nil)
 		return
 	}
 
@@ -467,11 +484,11 @@ func CreateProject(c *gin.Context) {
 	if err != nil {
 		log.Printf("Failed to create namespace %s: %v", req.Name, err)
 		if errors.IsAlreadyExists(err) {
-			c.JSON(http.StatusConflict, gin.H{"error": "Project already exists"})
+			respondError(c, http.StatusConflict, ErrorKindConflict, "Project already exists", nil)
 		} else if errors.IsForbidden(err) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to create project"})
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to create project", nil)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create project", nil)
 		}
 		return
 	}
@@ -539,7 +556,7 @@ func CreateProject(c *gin.Context) {
 			}
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create project permissions", nil)
 		return
 	}
 
@@ -631,12 +648,12 @@ func CreateProject(c *gin.Context) {
 func GetProject(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if projectName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -644,7 +661,7 @@ func GetProject(c *gin.Context) {
 
 	// Get namespace using backend SA
 	if K8sClientProjects == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to get project", nil)
 		return
 	}
 
@@ -653,19 +670,15 @@ func GetProject(c *gin.Context) {
 
 	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-			return
-		}
 		log.Printf("Failed to get Namespace %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		respondK8sError(c, err, "Project not found", "Failed to get project")
 		return
 	}
 
 	// Validate it's an Ambient-managed namespace
 	if ns.Labels["ambient-code.io/managed"] != "true" {
 		log.Printf("SECURITY: User attempted to access non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Project not found or not an Ambient project", nil)
 		return
 	}
 
@@ -673,13 +686,13 @@ func GetProject(c *gin.Context) {
 	canView, err := checkUserCanViewProject(k8sClt, projectName)
 	if err != nil {
 		log.Printf("GetProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 
 	if !canView {
 		log.Printf("User attempted to view project %s without GET projectsettings permission", projectName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to view project"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to view project", nil)
 		return
 	}
 
@@ -694,7 +707,7 @@ func UpdateProject(c *gin.Context) {
 	projectName := c.Param("projectName")
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -704,12 +717,12 @@ func UpdateProject(c *gin.Context) {
 		Description string `json:"description"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
 	if req.Name != "" && req.Name != projectName {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "project name in URL does not match request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "project name in URL does not match request body", nil)
 		return
 	}
 
@@ -717,7 +730,7 @@ func UpdateProject(c *gin.Context) {
 
 	// Get namespace using backend SA
 	if K8sClientProjects == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to update project", nil)
 		return
 	}
 
@@ -726,19 +739,15 @@ func UpdateProject(c *gin.Context) {
 
 	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-			return
-		}
 		log.Printf("Failed to get Namespace %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		respondK8sError(c, err, "Project not found", "Failed to get project")
 		return
 	}
 
 	// Validate it's an Ambient-managed namespace
 	if ns.Labels["ambient-code.io/managed"] != "true" {
 		log.Printf("SECURITY: User attempted to update non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Project not found or not an Ambient project", nil)
 		return
 	}
 
@@ -746,13 +755,13 @@ func UpdateProject(c *gin.Context) {
 	canModify, err := checkUserCanModifyProject(k8sClt, projectName)
 	if err != nil {
 		log.Printf("UpdateProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 
 	if !canModify {
 		log.Printf("User attempted to update project %s without UPDATE projectsettings permission", projectName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update project"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to update project", nil)
 		return
 	}
 
@@ -778,7 +787,7 @@ func UpdateProject(c *gin.Context) {
 		_, err = K8sClientProjects.CoreV1().Namespaces().Update(ctx2, ns, v1.UpdateOptions{})
 		if err != nil {
 			log.Printf("Failed to update Namespace annotations for %s: %v", projectName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update project"})
+			respondK8sError(c, err, "Project not found", "Failed to update project")
 			return
 		}
 
@@ -799,12 +808,12 @@ func UpdateProject(c *gin.Context) {
 func DeleteProject(c *gin.Context) {
 	projectName := c.Param("projectName")
 	if projectName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project name is required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project name is required", nil)
 		return
 	}
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		return
 	}
 
@@ -813,25 +822,21 @@ func DeleteProject(c *gin.Context) {
 
 	// Verify namespace exists and is Ambient-managed (using backend SA)
 	if K8sClientProjects == nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to delete project", nil)
 		return
 	}
 
 	ns, err := K8sClientProjects.CoreV1().Namespaces().Get(ctx, projectName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-			return
-		}
 		log.Printf("Failed to get namespace %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		respondK8sError(c, err, "Project not found", "Failed to get project")
 		return
 	}
 
 	// Validate it's an Ambient-managed namespace
 	if ns.Labels["ambient-code.io/managed"] != "true" {
 		log.Printf("SECURITY: User attempted to delete non-managed namespace: %s", projectName)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found or not an Ambient project"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Project not found or not an Ambient project", nil)
 		return
 	}
 
@@ -839,13 +844,13 @@ func DeleteProject(c *gin.Context) {
 	canModify, err := checkUserCanModifyProject(k8sClt, projectName)
 	if err != nil {
 		log.Printf("DeleteProject: Failed to check access for %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 
 	if !canModify {
 		log.Printf("User attempted to delete project %s without UPDATE projectsettings permission", projectName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions to delete project"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions to delete project", nil)
 		return
 	}
 
@@ -855,12 +860,8 @@ func DeleteProject(c *gin.Context) {
 
 	err = K8sClientProjects.CoreV1().Namespaces().Delete(ctx2, projectName, v1.DeleteOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
-			return
-		}
 		log.Printf("Failed to delete namespace %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		respondK8sError(c, err, "Project not found", "Failed to delete project")
 		return
 	}
 
@@ -11,6 +11,7 @@ import (
 	test_constants "ambient-code-backend/tests/constants"
 	"ambient-code-backend/tests/logger"
 	"ambient-code-backend/tests/test_utils"
+	"ambient-code-backend/types"
 
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo/v2"
@@ -294,10 +295,8 @@ var _ = Describe("Projects Handler", Label(test_constants.LabelUnit, test_consta
 
 				CreateProject(ginContext)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "project name must be lowercase alphanumeric with hyphens (cannot start or end with hyphen)",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation",
+					"project name must be lowercase alphanumeric with hyphens (cannot start or end with hyphen)")
 			})
 
 			It("Should reject empty project name", func() {
@@ -310,10 +309,8 @@ var _ = Describe("Projects Handler", Label(test_constants.LabelUnit, test_consta
 
 				CreateProject(ginContext)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Key: 'CreateProjectRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation",
+					"Key: 'CreateProjectRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag")
 			})
 
 			It("Should handle existing project gracefully", func() {
@@ -431,6 +428,35 @@ var _ = Describe("Projects Handler", Label(test_constants.LabelUnit, test_consta
 				logger.Log("Successfully listed %d projects", len(items))
 			})
 
+			It("Should order projects stably and reject session-only sort keys", func() {
+				list := func(query string) []string {
+					httpUtils = test_utils.NewHTTPTestUtils()
+					ginContext := httpUtils.CreateTestGinContext("GET", "/api/projects?"+query, nil)
+					httpUtils.SetAuthHeader(testToken)
+					ListProjects(ginContext)
+					httpUtils.AssertHTTPStatus(http.StatusOK)
+					var response struct {
+						Items []types.AmbientProject `json:"items"`
+					}
+					httpUtils.GetResponseJSON(&response)
+					names := []string{}
+					for _, p := range response.Items {
+						names = append(names, p.Name)
+					}
+					return names
+				}
+
+				// Equal creation timestamps fall back to name order
+				Expect(list("")).To(Equal([]string{"ambient-managed", "project-1", "project-2"}))
+				Expect(list("sortBy=displayName&order=desc")).To(Equal([]string{"project-2", "project-1", "ambient-managed"}))
+
+				httpUtils = test_utils.NewHTTPTestUtils()
+				ginContext := httpUtils.CreateTestGinContext("GET", "/api/projects?sortBy=completionTime", nil)
+				httpUtils.SetAuthHeader(testToken)
+				ListProjects(ginContext)
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+			})
+
 			It("Should handle no projects gracefully", func() {
 				// Delete all namespaces using same client as handler
 				namespaces, _ := K8sClientProjects.CoreV1().Namespaces().List(
@@ -752,10 +778,8 @@ var _ = Describe("Projects Handler", Label(test_constants.LabelUnit, test_consta
 
 			CreateProject(ginContext)
 
-			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-			httpUtils.AssertJSONContains(map[string]interface{}{
-				"error": "Key: 'CreateProjectRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag",
-			})
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation",
+				"Key: 'CreateProjectRequest.Name' Error:Field validation for 'Name' failed on the 'required' tag")
 		})
 
 		It("Should handle concurrent project creation", func() {
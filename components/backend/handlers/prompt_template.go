@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// promptTemplateAnnotation keeps the initialPrompt a templated session was created from, for audit
+const promptTemplateAnnotation = "ambient-code.io/prompt-template"
+
+// maxPromptTemplateBytes caps templated prompts: the template is kept in an annotation, and a
+// session's annotations together must stay under 256 KiB
+const maxPromptTemplateBytes = 64 * 1024
+
+var (
+	// promptVariableNamePattern is a ProjectSettings spec.promptVariables key, usable as {{.Name}}
+	promptVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// missingPromptKeyPattern picks the key out of a missingkey=error execution error
+	missingPromptKeyPattern = regexp.MustCompile(`map has no entry for key "([^"]+)"`)
+)
+
+// promptTemplateFields are derived from the create request for every templated prompt, next to
+// the project's spec.promptVariables; variables may not reuse these names
+var promptTemplateFields = []string{"Project", "UserDisplayName", "RepoName", "RepoFolders"}
+
+// expandPromptTemplate executes tmpl as a Go template against data. Keys tmpl references that data
+// does not have are returned as missing, all of them and in order of use, instead of an expansion.
+func expandPromptTemplate(tmpl string, data map[string]interface{}) (string, []string, error) {
+	t, err := template.New("initialPrompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", nil, err
+	}
+	values := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		values[k] = v
+	}
+	// Execution stops at the first undefined key; stand each in with "" and run again for the rest
+	var missing []string
+	for {
+		var out strings.Builder
+		err := t.Execute(&out, values)
+		if err == nil {
+			if len(missing) > 0 {
+				return "", missing, nil
+			}
+			return out.String(), nil, nil
+		}
+		m := missingPromptKeyPattern.FindStringSubmatch(err.Error())
+		if m == nil {
+			if len(missing) > 0 {
+				return "", missing, nil
+			}
+			return "", nil, err
+		}
+		if _, seen := values[m[1]]; seen {
+			return "", nil, err
+		}
+		values[m[1]] = ""
+		missing = append(missing, m[1])
+	}
+}
+
+// projectPromptVariables returns spec.promptVariables from the project's ProjectSettings, or nil
+// when it has none
+func projectPromptVariables(ctx context.Context, dyn dynamic.Interface, project string) (map[string]string, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vars, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "promptVariables")
+	return vars, nil
+}
+
+// promptTemplateData returns what a templated initialPrompt may reference: the project's prompt
+// variables, the project, the caller's display name and the folders the request's repos clone into
+// (RepoName is the main repo's)
+func promptTemplateData(c *gin.Context, project string, req *types.CreateAgenticSessionRequest, vars map[string]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(vars)+len(promptTemplateFields))
+	for k, v := range vars {
+		data[k] = v
+	}
+	data["Project"] = project
+	displayName := ""
+	if uc := callerUserContext(c, req.UserContext); uc != nil {
+		displayName, _ = uc["displayName"].(string)
+	}
+	data["UserDisplayName"] = displayName
+
+	// The same assignment buildSessionObject makes, so the names match the session's folders
+	repos := make([]map[string]interface{}, 0, len(req.Repos))
+	for _, r := range req.Repos {
+		repos = append(repos, map[string]interface{}{"url": r.URL, "folder": r.Folder})
+	}
+	assignRepoFolders(repos)
+	folders := make([]string, 0, len(repos))
+	for _, rm := range repos {
+		if folder, _ := rm["folder"].(string); folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+	data["RepoFolders"] = folders
+	data["RepoName"] = ""
+	if len(folders) > 0 {
+		data["RepoName"] = folders[0]
+	}
+	return data
+}
+
+// applyPromptTemplate expands req.InitialPrompt against promptTemplateData when req.TemplatePrompt is
+// set and keeps the template in the ambient-code.io/prompt-template annotation. A template that does
+// not parse or references undefined variables is a 422; on failure it writes the error response
+// and returns false. The annotation is only ever set here, never taken from the request.
+func applyPromptTemplate(c *gin.Context, dyn dynamic.Interface, project string, req *types.CreateAgenticSessionRequest) bool {
+	delete(req.Annotations, promptTemplateAnnotation)
+	if !req.TemplatePrompt || strings.TrimSpace(req.InitialPrompt) == "" {
+		return true
+	}
+	if len(req.InitialPrompt) > maxPromptTemplateBytes {
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation,
+			fmt.Sprintf("a templated initialPrompt must not exceed %d bytes", maxPromptTemplateBytes), nil)
+		return false
+	}
+	vars, err := projectPromptVariables(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read prompt variables in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return false
+	}
+	data := promptTemplateData(c, project, req, vars)
+	prompt, missing, err := expandPromptTemplate(req.InitialPrompt, data)
+	if err != nil {
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation, fmt.Sprintf("initialPrompt is not a valid template: %v", err), nil)
+		return false
+	}
+	if len(missing) > 0 {
+		available := make([]string, 0, len(data))
+		for k := range data {
+			available = append(available, k)
+		}
+		sort.Strings(available)
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation,
+			fmt.Sprintf("initialPrompt references undefined variables: %s", strings.Join(missing, ", ")),
+			gin.H{"missing": missing, "available": available})
+		return false
+	}
+	if len(prompt) > maxStoredPromptBytes {
+		respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation,
+			fmt.Sprintf("the expanded initialPrompt must not exceed %d bytes", maxStoredPromptBytes), nil)
+		return false
+	}
+
+	if req.Annotations == nil {
+		req.Annotations = map[string]string{}
+	}
+	req.Annotations[promptTemplateAnnotation] = req.InitialPrompt
+	req.InitialPrompt = prompt
+	return true
+}
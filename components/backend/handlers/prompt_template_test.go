@@ -0,0 +1,132 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Prompt Templates", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	Context("expandPromptTemplate", func() {
+		It("Should list every undefined key in order of use", func() {
+			_, missing, err := expandPromptTemplate("{{.A}} {{.Known}} {{if .B}}{{.C}}{{end}} {{.A}}", map[string]interface{}{"Known": "k"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(missing).To(Equal([]string{"A", "B"}))
+		})
+
+		It("Should report a template that does not parse", func() {
+			_, _, err := expandPromptTemplate("{{.A", map[string]interface{}{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("CreateSession", func() {
+		BeforeEach(func() {
+			_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+				"spec": map[string]interface{}{
+					"groupAccess":     []interface{}{},
+					"promptVariables": map[string]interface{}{"Standards": "the team style guide"},
+				},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		create := func(body map[string]interface{}) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			CreateSession(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		stored := func(name string) *unstructured.Unstructured {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			return obj
+		}
+
+		It("Should expand project variables and derived fields, keeping the template", func() {
+			tmpl := "Review {{.RepoName}} in {{.Project}} against {{.Standards}}"
+			response := create(map[string]interface{}{
+				"initialPrompt":  tmpl,
+				"templatePrompt": true,
+				"repos":          []map[string]interface{}{{"url": "https://github.com/org/app"}},
+			})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			obj := stored(response["name"].(string))
+			prompt, _, _ := unstructured.NestedString(obj.Object, "spec", "initialPrompt")
+			Expect(prompt).To(Equal("Review app in " + testNamespace + " against the team style guide"))
+			Expect(obj.GetAnnotations()).To(HaveKeyWithValue(promptTemplateAnnotation, tmpl))
+		})
+
+		It("Should answer 422 naming a misspelt variable", func() {
+			create(map[string]interface{}{
+				"initialPrompt":  "Review against {{.Standrds}} and {{.Other}}",
+				"templatePrompt": true,
+			})
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusUnprocessableEntity, "Validation", "initialPrompt references undefined variables: Standrds, Other")
+			details := errorObj["details"].(map[string]interface{})
+			Expect(details["missing"]).To(Equal([]interface{}{"Standrds", "Other"}))
+			Expect(details["available"]).To(ContainElement("Standards"))
+		})
+
+		It("Should store the prompt as sent without templatePrompt and drop a client-set template annotation", func() {
+			response := create(map[string]interface{}{
+				"initialPrompt": "Keep {{.Standards}} literal",
+				"annotations":   map[string]interface{}{promptTemplateAnnotation: "forged"},
+			})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			obj := stored(response["name"].(string))
+			prompt, _, _ := unstructured.NestedString(obj.Object, "spec", "initialPrompt")
+			Expect(prompt).To(Equal("Keep {{.Standards}} literal"))
+			Expect(obj.GetAnnotations()).NotTo(HaveKey(promptTemplateAnnotation))
+		})
+	})
+
+	Context("ProjectSettings validation", func() {
+		It("Should refuse variable names templates cannot use or that shadow derived fields", func() {
+			problems := validateProjectSettingsSpec(ctx, k8sUtils.K8sClient, testNamespace, map[string]interface{}{
+				"promptVariables": map[string]interface{}{"Standards": "ok", "team-name": "x", "Project": "y"},
+			})
+			Expect(problems).To(ConsistOf(
+				ProjectSettingsFieldError{Field: "spec.promptVariables.Project", Message: `"Project" is derived from the session and cannot be set`},
+				ProjectSettingsFieldError{Field: "spec.promptVariables.team-name", Message: `"team-name" is not a template field name (letters, digits and '_', not starting with a digit)`},
+			))
+		})
+	})
+})
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ReadCache serves AgenticSession and ProjectSettings reads from shared informers running as the
+// backend service account. Callers must confirm the user's read permission (cacheReadAllowed)
+// before using it; the cache only saves the API server the GET/LIST itself.
+// Nil disables caching; main sets it up from SESSION_CACHE_RESYNC.
+var SessionReadCache *ReadCache
+
+// Cache header values reported on cache-eligible reads
+const (
+	cacheHeader = "X-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+)
+
+// ReadCache lazily starts one informer factory per project namespace the first time it is read
+type ReadCache struct {
+	client dynamic.Interface
+	resync time.Duration
+
+	mu        sync.Mutex
+	factories map[string]dynamicinformer.DynamicSharedInformerFactory
+	stop      chan struct{}
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// NewReadCache creates a cache backed by client. resync bounds how long a missed watch event can
+// leave an entry stale.
+func NewReadCache(client dynamic.Interface, resync time.Duration) *ReadCache {
+	return &ReadCache{
+		client:    client,
+		resync:    resync,
+		factories: map[string]dynamicinformer.DynamicSharedInformerFactory{},
+		stop:      make(chan struct{}),
+	}
+}
+
+// Stop shuts down all informers
+func (rc *ReadCache) Stop() {
+	close(rc.stop)
+}
+
+// informer returns the namespace's informer for gvr, starting it if needed. It reports false
+// until the informer's initial list has completed.
+func (rc *ReadCache) informer(namespace string, gvr schema.GroupVersionResource) (cache.GenericNamespaceLister, bool) {
+	rc.mu.Lock()
+	factory, ok := rc.factories[namespace]
+	if !ok {
+		factory = dynamicinformer.NewFilteredDynamicSharedInformerFactory(rc.client, rc.resync, namespace, nil)
+		rc.factories[namespace] = factory
+	}
+	informer := factory.ForResource(gvr)
+	// Start only launches informers that are not running yet
+	factory.Start(rc.stop)
+	rc.mu.Unlock()
+
+	if !informer.Informer().HasSynced() {
+		return nil, false
+	}
+	return informer.Lister().ByNamespace(namespace), true
+}
+
+// List returns deep copies of the cached objects of gvr in namespace matching selector, or false
+// when the cache cannot answer yet
+func (rc *ReadCache) List(namespace string, gvr schema.GroupVersionResource, selector labels.Selector) ([]unstructured.Unstructured, bool) {
+	lister, ok := rc.informer(namespace, gvr)
+	if !ok {
+		return nil, false
+	}
+	objs, err := lister.List(selector)
+	if err != nil {
+		log.Printf("ReadCache: list %s in %s failed: %v", gvr.Resource, namespace, err)
+		return nil, false
+	}
+	items := make([]unstructured.Unstructured, 0, len(objs))
+	for _, obj := range objs {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			items = append(items, *u.DeepCopy())
+		}
+	}
+	return items, true
+}
+
+// Get returns a deep copy of the cached object, or false when the cache cannot answer. An object
+// missing from a synced cache also reports false, since it may have been created after the last
+// watch event; callers fall back to the API server.
+func (rc *ReadCache) Get(namespace string, gvr schema.GroupVersionResource, name string) (*unstructured.Unstructured, bool) {
+	lister, ok := rc.informer(namespace, gvr)
+	if !ok {
+		return nil, false
+	}
+	obj, err := lister.Get(name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("ReadCache: get %s %s/%s failed: %v", gvr.Resource, namespace, name, err)
+		}
+		return nil, false
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, false
+	}
+	return u.DeepCopy(), true
+}
+
+// record counts a cache-eligible read and reports it in the X-Cache response header
+func (rc *ReadCache) record(c *gin.Context, hit bool) {
+	if hit {
+		rc.hits.Add(1)
+		c.Header(cacheHeader, cacheHit)
+		return
+	}
+	rc.misses.Add(1)
+	c.Header(cacheHeader, cacheMiss)
+}
+
+// Stats returns the number of cache hits and misses so far
+func (rc *ReadCache) Stats() (hits, misses int64) {
+	return rc.hits.Load(), rc.misses.Load()
+}
+
+// cacheReadAllowed reports whether a read may be served from SessionReadCache: caching must be
+// enabled, the caller must not have asked for ?noCache=true, and a SelfSubjectAccessReview must
+// confirm the caller may perform verb on resource. The review result is reused for
+// accessReviewCacheTTL (cachedAccessReview), so a cache hit usually costs no API call at all. When
+// it returns false the handler reads with the caller's own token, so authorization errors surface
+// exactly as without the cache.
+func cacheReadAllowed(c *gin.Context, reqK8s kubernetes.Interface, project, resource, verb, name string) bool {
+	if SessionReadCache == nil || reqK8s == nil || strings.EqualFold(c.Query("noCache"), "true") {
+		return false
+	}
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "vteam.ambient-code",
+				Resource:  resource,
+				Verb:      verb,
+				Namespace: project,
+				Name:      name,
+			},
+		},
+	}
+	allowed, err := cachedAccessReview(c, reqK8s, ssar)
+	if err != nil {
+		log.Printf("ReadCache: SSAR for %s %s in %s failed: %v", verb, resource, project, err)
+		return false
+	}
+	return allowed
+}
+
+// cachedList lists the gvr objects in project matching selector from the cache when the caller may list
+// them, recording the outcome. It returns false when the handler must read from the API server instead.
+func cachedList(c *gin.Context, reqK8s kubernetes.Interface, project string, gvr schema.GroupVersionResource, selector labels.Selector) ([]unstructured.Unstructured, bool) {
+	if SessionReadCache == nil {
+		return nil, false
+	}
+	if cacheReadAllowed(c, reqK8s, project, gvr.Resource, "list", "") {
+		if items, ok := SessionReadCache.List(project, gvr, selector); ok {
+			SessionReadCache.record(c, true)
+			return items, true
+		}
+	}
+	SessionReadCache.record(c, false)
+	return nil, false
+}
+
+// cachedGet is the single-object counterpart of cachedList
+func cachedGet(c *gin.Context, reqK8s kubernetes.Interface, project string, gvr schema.GroupVersionResource, name string) (*unstructured.Unstructured, bool) {
+	if SessionReadCache == nil {
+		return nil, false
+	}
+	if cacheReadAllowed(c, reqK8s, project, gvr.Resource, "get", name) {
+		if obj, ok := SessionReadCache.Get(project, gvr, name); ok {
+			SessionReadCache.record(c, true)
+			return obj, true
+		}
+	}
+	SessionReadCache.record(c, false)
+	return nil, false
+}
+
+// Metrics serves backend metrics in the Prometheus text exposition format
+func Metrics(c *gin.Context) {
+	var b strings.Builder
+	if SessionReadCache != nil {
+		hits, misses := SessionReadCache.Stats()
+		ratio := 0.0
+		if total := hits + misses; total > 0 {
+			ratio = float64(hits) / float64(total)
+		}
+		b.WriteString("# HELP ambient_backend_read_cache_requests_total Cache-eligible AgenticSession and ProjectSettings reads by result.\n")
+		b.WriteString("# TYPE ambient_backend_read_cache_requests_total counter\n")
+		fmt.Fprintf(&b, "ambient_backend_read_cache_requests_total{result=\"hit\"} %d\n", hits)
+		fmt.Fprintf(&b, "ambient_backend_read_cache_requests_total{result=\"miss\"} %d\n", misses)
+		b.WriteString("# HELP ambient_backend_read_cache_hit_ratio Fraction of cache-eligible reads served from the cache.\n")
+		b.WriteString("# TYPE ambient_backend_read_cache_hit_ratio gauge\n")
+		fmt.Fprintf(&b, "ambient_backend_read_cache_hit_ratio %g\n", ratio)
+	}
+	writeStartupLatencyMetrics(c.Request.Context(), &b)
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,207 @@
+//go:build test
+
+package handlers
+
+import (
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/logger"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authzv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Read Cache", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelReadCache), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		testNamespace string
+		testSession   string
+		testToken     string
+	)
+
+	// The cache's backend-SA client sees a different displayName than the caller's own client,
+	// so responses show which one served them
+	cachedSession := func(name, namespace string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("vteam.ambient-code/v1alpha1")
+		obj.SetKind("AgenticSession")
+		obj.SetName(name)
+		obj.SetNamespace(namespace)
+		_ = unstructured.SetNestedField(obj.Object, "from-cache", "spec", "displayName")
+		return obj
+	}
+
+	getSession := func(query string) (string, string) {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/"+testSession+query, nil)
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+		GetSession(c)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var resp map[string]interface{}
+		httpUtils.GetResponseJSON(&resp)
+		spec, _ := resp["spec"].(map[string]interface{})
+		displayName, _ := spec["displayName"].(string)
+		return httpUtils.GetResponseRecorder().Header().Get("X-Cache"), displayName
+	}
+
+	BeforeEach(func() {
+		logger.Log("Setting up Read Cache test")
+
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		SetupHandlerDependencies(k8sUtils)
+		ctx := context.Background()
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+		testNamespace = "test-project-" + suffix
+		testSession = "test-session-" + suffix
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{ObjectMeta: v1.ObjectMeta{Name: testNamespace}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		direct := createTestSession(testSession, testNamespace, k8sUtils)
+		Expect(unstructured.SetNestedField(direct.Object, "from-api", "spec", "displayName")).To(Succeed())
+		_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, direct, v1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		gvr := GetAgenticSessionV1Alpha1Resource()
+		saClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			gvr:                          "AgenticSessionList",
+			GetProjectSettingsResource(): "ProjectSettingsList",
+		}, cachedSession(testSession, testNamespace))
+		cache := NewReadCache(saClient, time.Minute)
+		SessionReadCache = cache
+		DeferCleanup(func() {
+			cache.Stop()
+			SessionReadCache = nil
+		})
+
+		// Warm the namespace informer
+		Eventually(func() string {
+			header, _ := getSession("")
+			return header
+		}, 5*time.Second, 20*time.Millisecond).Should(Equal("HIT"))
+	})
+
+	It("Should serve GetSession from the cache once synced", func() {
+		header, displayName := getSession("")
+		Expect(header).To(Equal("HIT"))
+		Expect(displayName).To(Equal("from-cache"))
+	})
+
+	It("Should serve ListSessions from the cache", func() {
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions", nil)
+		httpUtils.SetAuthHeader(testToken)
+		httpUtils.SetProjectContext(testNamespace)
+
+		ListSessions(c)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(httpUtils.GetResponseRecorder().Header().Get("X-Cache")).To(Equal("HIT"))
+		var resp map[string]interface{}
+		httpUtils.GetResponseJSON(&resp)
+		Expect(resp["items"]).To(HaveLen(1))
+	})
+
+	It("Should bypass the cache with noCache=true", func() {
+		header, displayName := getSession("?noCache=true")
+		Expect(header).To(Equal("MISS"))
+		Expect(displayName).To(Equal("from-api"))
+	})
+
+	It("Should not serve from the cache when the caller may not read the session", func() {
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool {
+			ssar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SelfSubjectAccessReview)
+			return ssar.Spec.ResourceAttributes.Resource != "agenticsessions"
+		}
+		// A revoked permission applies once the warm-up's cached review expires
+		accessReviewCache = make(map[string]accessReviewEntry)
+
+		header, displayName := getSession("")
+
+		Expect(header).To(Equal("MISS"))
+		Expect(displayName).To(Equal("from-api"))
+	})
+
+	It("Should reuse the caller's access review for repeated reads", func() {
+		reviews := 0
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool {
+			reviews++
+			return true
+		}
+
+		for i := 0; i < 3; i++ {
+			header, _ := getSession("")
+			Expect(header).To(Equal("HIT"))
+		}
+
+		Expect(reviews).To(BeZero(), "the warm-up review is still cached")
+	})
+
+	It("Should expose metrics only to callers allowed to get /metrics", func() {
+		metrics := func(token string) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/metrics", nil)
+			if token != "" {
+				httpUtils.SetAuthHeader(token)
+			}
+			RequireMetricsAccess()(c)
+			if !c.IsAborted() {
+				Metrics(c)
+			}
+		}
+
+		metrics("")
+		httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
+
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool {
+			ssar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SelfSubjectAccessReview)
+			return ssar.Spec.NonResourceAttributes == nil
+		}
+		metrics(testToken)
+		httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Not allowed to read metrics")
+
+		k8sUtils.SSARAllowedFunc = nil
+		accessReviewCache = make(map[string]accessReviewEntry)
+		metrics(testToken)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(httpUtils.GetResponseBody()).To(ContainSubstring("ambient_backend_read_cache_hit_ratio"))
+	})
+
+	It("Should expose hits and misses as metrics", func() {
+		getSession("?noCache=true")
+		hits, misses := SessionReadCache.Stats()
+		Expect(hits).To(BeNumerically(">=", 1))
+		Expect(misses).To(BeNumerically(">=", 1))
+
+		c := httpUtils.CreateTestGinContext("GET", "/metrics", nil)
+		Metrics(c)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		body := httpUtils.GetResponseBody()
+		Expect(body).To(ContainSubstring(`ambient_backend_read_cache_requests_total{result="hit"} ` + strconv.FormatInt(hits, 10)))
+		Expect(body).To(ContainSubstring(`ambient_backend_read_cache_requests_total{result="miss"} ` + strconv.FormatInt(misses, 10)))
+		Expect(body).To(ContainSubstring("ambient_backend_read_cache_hit_ratio"))
+	})
+})
@@ -16,6 +16,8 @@ import (
 	"github.com/gin-gonic/gin"
 	authv1 "k8s.io/api/authorization/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
 )
 
 // Dependencies injected from main package
@@ -52,6 +54,32 @@ func parseOwnerRepo(full string) (string, string, error) {
 
 // Note: githubAPIBaseURL and doGitHubRequest are defined in github_auth.go
 
+// projectGitHubAPIBase resolves the GitHub API base for a repo: the project's ProjectSettings
+// spec.githubApiBase, then GITHUB_API_BASE, then the repo URL's host (owner/repo means github.com)
+func projectGitHubAPIBase(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project, repo string) string {
+	return git.ResolveGitHubAPIBase(repo, projectSettingsGitHubAPIBase(c, reqK8s, reqDyn, project))
+}
+
+// projectSettingsGitHubAPIBase reads spec.githubApiBase from the project's ProjectSettings,
+// from SessionReadCache when the caller may read it
+func projectSettingsGitHubAPIBase(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string) string {
+	if project == "" {
+		return ""
+	}
+	if obj, ok := cachedGet(c, reqK8s, project, GetProjectSettingsResource(), "projectsettings"); ok {
+		return git.ProjectSettingsGitHubAPIBase(obj)
+	}
+	return git.GetProjectGitHubAPIBase(c.Request.Context(), reqDyn, project)
+}
+
+// respondGitHubStatus relays the status of a failed GitHub API call. The response body is
+// logged rather than returned since GitHub error payloads can echo request details.
+func respondGitHubStatus(c *gin.Context, resp *http.Response) {
+	b, _ := io.ReadAll(resp.Body)
+	log.Printf("GitHub request failed with status %d: %s", resp.StatusCode, string(b))
+	respondError(c, resp.StatusCode, errorKindForStatus(resp.StatusCode), fmt.Sprintf("GitHub request failed with status %d", resp.StatusCode), nil)
+}
+
 // ===== Handler Functions =====
 
 // AccessCheck verifies if the caller has write access to ProjectSettings in the project namespace
@@ -60,7 +88,7 @@ func AccessCheck(c *gin.Context) {
 	projectName := c.Param("projectName")
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -82,7 +110,7 @@ func AccessCheck(c *gin.Context) {
 	res, err := k8sClt.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("SSAR failed for project %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to perform access review"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "failed to perform access review", nil)
 		return
 	}
 
@@ -123,7 +151,7 @@ func ListUserForks(c *gin.Context) {
 	upstreamRepo := c.Query("upstreamRepo")
 
 	if upstreamRepo == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "upstreamRepo query parameter required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "upstreamRepo query parameter required", nil)
 		return
 	}
 
@@ -135,26 +163,28 @@ func ListUserForks(c *gin.Context) {
 	var token string
 	var err error
 	if userID != nil {
-		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), upstreamRepo)
 	} else {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		c.Abort()
 		return
 	}
 	if err != nil {
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
 		log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		if !respondGitHubTokenError(c, err) {
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		}
 		return
 	}
 
 	owner, repoName, err := parseOwnerRepo(upstreamRepo)
 	if err != nil {
 		// Validation errors can be specific for 400 Bad Request
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
-	api := githubAPIBaseURL("github.com")
+	api := projectGitHubAPIBase(c, reqK8s, reqDyn, project, upstreamRepo)
 	// Fetch all pages of forks (public + any accessible private). Cap pages for safety.
 	allForksResp := make([]map[string]interface{}, 0, 100)
 	const perPage = 100
@@ -162,20 +192,21 @@ func ListUserForks(c *gin.Context) {
 		url := fmt.Sprintf("%s/repos/%s/%s/forks?per_page=%d&page=%d", api, owner, repoName, perPage, page)
 		resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+			log.Printf("GitHub request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitHub request failed", nil)
 			return
 		}
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
+			respondGitHubStatus(c, resp)
 			_ = resp.Body.Close()
-			c.JSON(resp.StatusCode, gin.H{"error": string(b)})
 			return
 		}
 		var pageForks []map[string]interface{}
 		decErr := json.NewDecoder(resp.Body).Decode(&pageForks)
 		_ = resp.Body.Close()
 		if decErr != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to parse GitHub response: %v", decErr)})
+			log.Printf("Failed to parse GitHub response: %v", decErr)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to parse GitHub response", nil)
 			return
 		}
 		if len(pageForks) == 0 {
@@ -213,7 +244,7 @@ func CreateUserFork(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -222,7 +253,7 @@ func CreateUserFork(c *gin.Context) {
 
 	// Check for missing authentication
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -232,21 +263,23 @@ func CreateUserFork(c *gin.Context) {
 	if userID != nil {
 		userIDStr = userID.(string)
 	}
-	token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userIDStr)
+	token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userIDStr, req.UpstreamRepo)
 	if err != nil {
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
 		log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userIDStr, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		if !respondGitHubTokenError(c, err) {
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		}
 		return
 	}
 
 	owner, repoName, err := parseOwnerRepo(req.UpstreamRepo)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
-	api := githubAPIBaseURL("github.com")
+	api := projectGitHubAPIBase(c, reqK8s, reqDyn, project, req.UpstreamRepo)
 	url := fmt.Sprintf("%s/repos/%s/%s/forks", api, owner, repoName)
 	var resp *http.Response
 	if DoGitHubRequest != nil {
@@ -255,13 +288,13 @@ func CreateUserFork(c *gin.Context) {
 		resp, err = doGitHubRequest(c.Request.Context(), http.MethodPost, url, "Bearer "+token, "", nil)
 	}
 	if err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+		log.Printf("GitHub request failed: %v", err)
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitHub request failed", nil)
 		return
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusAccepted && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
-		b, _ := io.ReadAll(resp.Body)
-		c.JSON(resp.StatusCode, gin.H{"error": string(b)})
+		respondGitHubStatus(c, resp)
 		return
 	}
 	// Respond that fork creation is in progress or created
@@ -277,7 +310,7 @@ func GetRepoTree(c *gin.Context) {
 	path := c.Query("path")
 
 	if repo == "" || ref == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "repo and ref query parameters required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "repo and ref query parameters required", nil)
 		return
 	}
 
@@ -286,7 +319,7 @@ func GetRepoTree(c *gin.Context) {
 
 	// Check for missing user context
 	if userID == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		return
 	}
 
@@ -300,26 +333,32 @@ func GetRepoTree(c *gin.Context) {
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitLab token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 			return
 		}
 
 		// Parse GitLab repository URL
 		parsed, err := gitlab.ParseGitLabURL(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid GitLab URL: %v", err)})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid GitLab URL: %v", err), nil)
 			return
 		}
 
-		// Create GitLab client and fetch tree
-		client := gitlab.NewClient(parsed.APIURL, token)
+		// Create GitLab client (honouring the project's spec.gitlab instance settings) and fetch tree
+		client, err := projectGitLabClient(c, reqK8s, reqDyn, project, parsed, token)
+		if err != nil {
+			log.Printf("Failed to configure GitLab client for project %s: %v", project, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to configure GitLab client", nil)
+			return
+		}
 		gitlabEntries, err := client.GetAllTreeEntries(c.Request.Context(), parsed.ProjectID, ref, path)
 		if err != nil {
 			if gitlabErr, ok := err.(*types.GitLabAPIError); ok {
-				c.JSON(gitlabErr.StatusCode, gin.H{"error": gitlabErr.Error()})
+				respondError(c, gitlabErr.StatusCode, errorKindForStatus(gitlabErr.StatusCode), gitlabErr.Error(), nil)
 				return
 			}
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitLab request failed: %v", err)})
+			log.Printf("GitLab request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitLab request failed", nil)
 			return
 		}
 
@@ -329,20 +368,22 @@ func GetRepoTree(c *gin.Context) {
 
 	case types.ProviderGitHub:
 		// Handle GitHub repository (existing logic)
-		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), repo)
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+			}
 			return
 		}
 
 		owner, repoName, err := parseOwnerRepo(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 			return
 		}
-		api := githubAPIBaseURL("github.com")
+		api := projectGitHubAPIBase(c, reqK8s, reqDyn, project, repo)
 		p := path
 		if p == "" || p == "/" {
 			p = ""
@@ -350,18 +391,19 @@ func GetRepoTree(c *gin.Context) {
 		url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repoName, strings.TrimPrefix(p, "/"), ref)
 		resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+			log.Printf("GitHub request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitHub request failed", nil)
 			return
 		}
 		defer resp.Body.Close()
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
-			c.JSON(resp.StatusCode, gin.H{"error": string(b)})
+			respondGitHubStatus(c, resp)
 			return
 		}
 		var decoded interface{}
 		if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to parse GitHub response: %v", err)})
+			log.Printf("Failed to parse GitHub response: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to parse GitHub response", nil)
 			return
 		}
 		entries := []types.TreeEntry{}
@@ -411,7 +453,7 @@ func GetRepoTree(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"path": path, "entries": entries})
 
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported repository provider (only GitHub and GitLab are supported)"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "unsupported repository provider (only GitHub and GitLab are supported)", nil)
 	}
 }
 
@@ -422,7 +464,7 @@ func ListRepoBranches(c *gin.Context) {
 	repo := c.Query("repo")
 
 	if repo == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "repo query parameter required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "repo query parameter required", nil)
 		return
 	}
 
@@ -431,7 +473,7 @@ func ListRepoBranches(c *gin.Context) {
 
 	// Check for missing user context
 	if userID == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		return
 	}
 
@@ -445,26 +487,32 @@ func ListRepoBranches(c *gin.Context) {
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitLab token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 			return
 		}
 
 		// Parse GitLab repository URL
 		parsed, err := gitlab.ParseGitLabURL(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid GitLab URL: %v", err)})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid GitLab URL: %v", err), nil)
 			return
 		}
 
-		// Create GitLab client and fetch branches
-		client := gitlab.NewClient(parsed.APIURL, token)
+		// Create GitLab client (honouring the project's spec.gitlab instance settings) and fetch branches
+		client, err := projectGitLabClient(c, reqK8s, reqDyn, project, parsed, token)
+		if err != nil {
+			log.Printf("Failed to configure GitLab client for project %s: %v", project, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to configure GitLab client", nil)
+			return
+		}
 		gitlabBranches, err := client.GetAllBranches(c.Request.Context(), parsed.ProjectID)
 		if err != nil {
 			if gitlabErr, ok := err.(*types.GitLabAPIError); ok {
-				c.JSON(gitlabErr.StatusCode, gin.H{"error": gitlabErr.Error()})
+				respondError(c, gitlabErr.StatusCode, errorKindForStatus(gitlabErr.StatusCode), gitlabErr.Error(), nil)
 				return
 			}
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitLab request failed: %v", err)})
+			log.Printf("GitLab request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitLab request failed", nil)
 			return
 		}
 
@@ -474,38 +522,41 @@ func ListRepoBranches(c *gin.Context) {
 
 	case types.ProviderGitHub:
 		// Handle GitHub repository (existing logic)
-		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), repo)
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+			}
 			return
 		}
 
 		owner, repoName, err := parseOwnerRepo(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 			return
 		}
 
-		api := githubAPIBaseURL("github.com")
+		api := projectGitHubAPIBase(c, reqK8s, reqDyn, project, repo)
 		url := fmt.Sprintf("%s/repos/%s/%s/branches", api, owner, repoName)
 		resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+			log.Printf("GitHub request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitHub request failed", nil)
 			return
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
-			c.JSON(resp.StatusCode, gin.H{"error": string(b)})
+			respondGitHubStatus(c, resp)
 			return
 		}
 
 		var branchesResp []map[string]interface{}
 		if err := json.NewDecoder(resp.Body).Decode(&branchesResp); err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to parse GitHub response: %v", err)})
+			log.Printf("Failed to parse GitHub response: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to parse GitHub response", nil)
 			return
 		}
 
@@ -521,7 +572,7 @@ func ListRepoBranches(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"branches": branches})
 
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported repository provider (only GitHub and GitLab are supported)"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "unsupported repository provider (only GitHub and GitLab are supported)", nil)
 	}
 }
 
@@ -534,7 +585,7 @@ func GetRepoBlob(c *gin.Context) {
 	path := c.Query("path")
 
 	if repo == "" || ref == "" || path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "repo, ref, and path query parameters required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "repo, ref, and path query parameters required", nil)
 		return
 	}
 
@@ -543,7 +594,7 @@ func GetRepoBlob(c *gin.Context) {
 
 	// Check for missing user context
 	if userID == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		return
 	}
 
@@ -557,26 +608,32 @@ func GetRepoBlob(c *gin.Context) {
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitLab token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 			return
 		}
 
 		// Parse GitLab repository URL
 		parsed, err := gitlab.ParseGitLabURL(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid GitLab URL: %v", err)})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid GitLab URL: %v", err), nil)
 			return
 		}
 
-		// Create GitLab client and fetch file content
-		client := gitlab.NewClient(parsed.APIURL, token)
+		// Create GitLab client (honouring the project's spec.gitlab instance settings) and fetch file content
+		client, err := projectGitLabClient(c, reqK8s, reqDyn, project, parsed, token)
+		if err != nil {
+			log.Printf("Failed to configure GitLab client for project %s: %v", project, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to configure GitLab client", nil)
+			return
+		}
 		fileContent, err := client.GetFileContents(c.Request.Context(), parsed.ProjectID, path, ref)
 		if err != nil {
 			if gitlabErr, ok := err.(*types.GitLabAPIError); ok {
-				c.JSON(gitlabErr.StatusCode, gin.H{"error": gitlabErr.Error()})
+				respondError(c, gitlabErr.StatusCode, errorKindForStatus(gitlabErr.StatusCode), gitlabErr.Error(), nil)
 				return
 			}
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitLab request failed: %v", err)})
+			log.Printf("GitLab request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitLab request failed", nil)
 			return
 		}
 
@@ -595,36 +652,39 @@ func GetRepoBlob(c *gin.Context) {
 
 	case types.ProviderGitHub:
 		// Handle GitHub repository (existing logic)
-		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err := GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), repo)
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+			}
 			return
 		}
 
 		owner, repoName, err := parseOwnerRepo(repo)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 			return
 		}
-		api := githubAPIBaseURL("github.com")
+		api := projectGitHubAPIBase(c, reqK8s, reqDyn, project, repo)
 		url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repoName, strings.TrimPrefix(path, "/"), ref)
 		resp, err := doGitHubRequest(c.Request.Context(), http.MethodGet, url, "Bearer "+token, "", nil)
 		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("GitHub request failed: %v", err)})
+			log.Printf("GitHub request failed: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "GitHub request failed", nil)
 			return
 		}
 		defer resp.Body.Close()
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
-			c.JSON(resp.StatusCode, gin.H{"error": string(b)})
+			respondGitHubStatus(c, resp)
 			return
 		}
 		// Decode generically first because GitHub returns an array for directories
 		var decoded interface{}
 		if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to parse GitHub response: %v", err)})
+			log.Printf("Failed to parse GitHub response: %v", err)
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to parse GitHub response", nil)
 			return
 		}
 		// If the response is an array, the path is a directory. Return entries for convenience.
@@ -674,9 +734,9 @@ func GetRepoBlob(c *gin.Context) {
 		}
 
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported repository provider (only GitHub and GitLab are supported)"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "unsupported repository provider (only GitHub and GitLab are supported)", nil)
 		return
 	}
 	// Fallback unexpected structure
-	c.JSON(http.StatusBadGateway, gin.H{"error": "unexpected GitHub response structure"})
+	respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "unexpected GitHub response structure", nil)
 }
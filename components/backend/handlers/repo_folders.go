@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// repoFolderPattern is what a spec.repos[].folder may look like: one path segment under the workspace
+var repoFolderPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// validRepoFolder reports whether folder is a usable single workspace directory name
+func validRepoFolder(folder string) bool {
+	return repoFolderPattern.MatchString(folder) && folder != "." && folder != ".."
+}
+
+// repoEntryURL returns the clone URL of a spec.repos entry: input.url when set, otherwise url
+func repoEntryURL(rm map[string]interface{}) string {
+	if in, ok := rm["input"].(map[string]interface{}); ok {
+		if u, _ := in["url"].(string); strings.TrimSpace(u) != "" {
+			return strings.TrimSpace(u)
+		}
+	}
+	u, _ := rm["url"].(string)
+	return strings.TrimSpace(u)
+}
+
+// sessionRepoFolder returns the workspace folder of a spec.repos entry. Sessions created before
+// folders were stored have none, so the folder is derived from the URL as it always was.
+func sessionRepoFolder(rm map[string]interface{}) string {
+	if folder, _ := rm["folder"].(string); strings.TrimSpace(folder) != "" {
+		return strings.TrimSpace(folder)
+	}
+	if DeriveRepoFolderFromURL == nil {
+		return ""
+	}
+	return DeriveRepoFolderFromURL(repoEntryURL(rm))
+}
+
+// specRepoFolderForURL returns the folder of the spec.repos entry cloned from repoURL, or ""
+func specRepoFolderForURL(obj *unstructured.Unstructured, repoURL string) string {
+	repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+	for _, r := range repos {
+		if rm, ok := r.(map[string]interface{}); ok && sameRepoURL(repoEntryURL(rm), repoURL) {
+			return sessionRepoFolder(rm)
+		}
+	}
+	return ""
+}
+
+// repoURLOwner returns the owner (user, organization or GitLab group) of a repo URL, or ""
+func repoURLOwner(repoURL string) string {
+	s := strings.TrimSuffix(strings.TrimSpace(repoURL), "/")
+	if strings.HasPrefix(s, "git@") {
+		s = strings.Replace(strings.TrimPrefix(s, "git@"), ":", "/", 1)
+	} else if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	segs := strings.Split(s, "/")
+	// host, owner..., repo
+	if len(segs) < 3 {
+		return ""
+	}
+	return segs[len(segs)-2]
+}
+
+// uniqueRepoFolder picks the workspace folder for repoURL that no entry in used has taken: the
+// derived folder, then "<folder>-<owner>", then a numeric suffix. The choice is added to used.
+func uniqueRepoFolder(repoURL string, used map[string]bool) string {
+	base := DeriveRepoFolderFromURL(repoURL)
+	if base == "" {
+		return ""
+	}
+	folder := base
+	if used[folder] {
+		if owner := repoURLOwner(repoURL); validRepoFolder(owner) {
+			folder = base + "-" + owner
+		}
+	}
+	for n := 2; used[folder]; n++ {
+		folder = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[folder] = true
+	return folder
+}
+
+// assignRepoFolders stores a folder on every spec.repos entry so that repos with the same name
+// under different owners (org-a/app and org-b/app) clone into different directories. Valid folders
+// already set are kept (the first one wins on a clash); the rest are assigned in order, so earlier
+// entries keep the derived name.
+func assignRepoFolders(repos []map[string]interface{}) {
+	used := make(map[string]bool, len(repos))
+	keep := make([]bool, len(repos))
+	for i, rm := range repos {
+		if folder, _ := rm["folder"].(string); validRepoFolder(folder) && !used[folder] {
+			used[folder] = true
+			keep[i] = true
+		}
+	}
+	for i, rm := range repos {
+		if keep[i] {
+			continue
+		}
+		delete(rm, "folder")
+		if folder := uniqueRepoFolder(repoEntryURL(rm), used); folder != "" {
+			rm["folder"] = folder
+		}
+	}
+}
+
+// repoIndexWorkspacePath maps a repoIndex to its workspace path for endpoints that take no repoPath:
+// the stored spec.repos[index].folder, or the numeric path those endpoints have always used when
+// the session has no folder for it (or cannot be read)
+func repoIndexWorkspacePath(c *gin.Context, project, session string, index int) string {
+	path := fmt.Sprintf("/sessions/%s/workspace/%d", session, index)
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		return path
+	}
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		return path
+	}
+	repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+	if index < 0 || index >= len(repos) {
+		return path
+	}
+	rm, _ := repos[index].(map[string]interface{})
+	if folder, _ := rm["folder"].(string); strings.TrimSpace(folder) != "" {
+		return fmt.Sprintf("/sessions/%s/workspace/%s", session, strings.TrimSpace(folder))
+	}
+	return path
+}
@@ -0,0 +1,208 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Repo Folders", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	storedFolders := func(name string) []string {
+		stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		repos, _, _ := unstructured.NestedSlice(stored.Object, "spec", "repos")
+		folders := []string{}
+		for _, r := range repos {
+			folder, _ := r.(map[string]interface{})["folder"].(string)
+			folders = append(folders, folder)
+		}
+		return folders
+	}
+
+	Context("assignRepoFolders", func() {
+		It("Should append the owner to later repos with the same name", func() {
+			repos := []map[string]interface{}{
+				{"url": "https://github.com/org-a/app"},
+				{"url": "https://github.com/org-b/app"},
+				{"url": "git@github.com:org-c/app"},
+				{"url": "https://github.com/org-b/app"},
+				{"url": "https://github.com/org-a/api"},
+			}
+
+			assignRepoFolders(repos)
+
+			folders := []string{}
+			for _, rm := range repos {
+				folders = append(folders, rm["folder"].(string))
+			}
+			Expect(folders).To(Equal([]string{"app", "app-org-b", "app-org-c", "app-2", "api"}))
+		})
+
+		It("Should keep explicit folders and work around them", func() {
+			repos := []map[string]interface{}{
+				{"url": "https://github.com/org-a/app"},
+				{"url": "https://github.com/org-b/app", "folder": "app"},
+				{"url": "https://github.com/org-c/web", "folder": "../escape"},
+			}
+
+			assignRepoFolders(repos)
+
+			Expect(repos[0]["folder"]).To(Equal("app-org-a"))
+			Expect(repos[1]["folder"]).To(Equal("app"))
+			Expect(repos[2]["folder"]).To(Equal("web"))
+		})
+	})
+
+	Context("CreateSession", func() {
+		create := func(body map[string]interface{}) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			CreateSession(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		It("Should store distinct folders for same-named repos from different owners", func() {
+			created := create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/org-a/app"},
+				{"url": "https://github.com/org-b/app"},
+			}})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			Expect(storedFolders(created["name"].(string))).To(Equal([]string{"app", "app-org-b"}))
+		})
+
+		It("Should reject a folder that is not a single directory name", func() {
+			create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/org-a/app", "folder": "a/b"},
+			}})
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "repos[0].folder must be a single directory name of letters, digits, '.', '_' or '-'")
+		})
+
+		It("Should reject two repos with the same explicit folder", func() {
+			create(map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{
+				{"url": "https://github.com/org-a/app", "folder": "src"},
+				{"url": "https://github.com/org-b/app", "folder": "src"},
+			}})
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `repos[1].folder "src" is used by another repo`)
+		})
+	})
+
+	Context("on a running session", func() {
+		BeforeEach(func() {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "sess", "namespace": testNamespace},
+				"spec": map[string]interface{}{
+					"interactive": true,
+					// Created before folders were stored: the folder is derived from the url
+					"repos": []interface{}{map[string]interface{}{"url": "https://github.com/org-a/app", "branch": "main"}},
+				},
+				"status": map[string]interface{}{"phase": "Running"},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		addRepo := func(body map[string]interface{}) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/sess/repos", body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			c.Params = gin.Params{{Key: "sessionName", Value: "sess"}}
+			AddRepo(c)
+		}
+
+		It("Should give an added repo a folder clear of the existing ones", func() {
+			addRepo(map[string]interface{}{"url": "https://github.com/org-b/app"})
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(storedFolders("sess")).To(Equal([]string{"", "app-org-b"}))
+		})
+
+		It("Should refuse the ssh form of a repo already in the session", func() {
+			addRepo(map[string]interface{}{"url": "git@github.com:Org-A/app.git"})
+
+			errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Repository is already in this session")
+			Expect(errorObj["details"]).To(Equal(map[string]interface{}{"url": "https://github.com/org-a/app", "folder": "app"}))
+			Expect(storedFolders("sess")).To(HaveLen(1))
+		})
+
+		It("Should refuse an explicit folder that is taken", func() {
+			addRepo(map[string]interface{}{"url": "https://github.com/org-b/app", "folder": "app"})
+
+			httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", `Folder "app" is used by another repo in this session`)
+		})
+
+		It("Should remove only the repo with the given folder", func() {
+			addRepo(map[string]interface{}{"url": "https://github.com/org-b/app"})
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("DELETE", "/api/projects/"+testNamespace+"/agentic-sessions/sess/repos/app-org-b", nil)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			c.Params = gin.Params{{Key: "sessionName", Value: "sess"}, {Key: "repoName", Value: "app-org-b"}}
+			RemoveRepo(c)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ := unstructured.NestedSlice(stored.Object, "spec", "repos")
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].(map[string]interface{})["url"]).To(Equal("https://github.com/org-a/app"))
+		})
+
+		It("Should match runner repo reports by the stored folder", func() {
+			addRepo(map[string]interface{}{"url": "https://github.com/org-b/app"})
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+				map[string]interface{}{"url": "https://github.com/org-a/app"},
+				map[string]interface{}{"url": "https://github.com/org-b/app"},
+			}, "status", "reconciledRepos")).To(Succeed())
+
+			Expect(mergeRunnerRepoStatuses(obj, map[string]map[string]interface{}{
+				"app-org-b": {"gitStatus": "dirty"},
+			})).To(Succeed())
+
+			repos, _, _ := unstructured.NestedSlice(obj.Object, "status", "reconciledRepos")
+			Expect(repos).To(HaveLen(2))
+			Expect(repos[0].(map[string]interface{})).NotTo(HaveKey("gitStatus"))
+			Expect(repos[1].(map[string]interface{})["gitStatus"]).To(Equal("dirty"))
+		})
+	})
+})
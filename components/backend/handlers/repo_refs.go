@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// CheckRepoRefExists reports whether ref names a commit in repoURL (set from main to
+// git.CheckRefExists); nil skips the existence check at create time
+var CheckRepoRefExists func(ctx context.Context, repoURL, ref, token, githubAPIBase string) (bool, error)
+
+var (
+	// commitSHAPattern is a full commit SHA
+	commitSHAPattern = regexp.MustCompile(`^[0-9a-fA-F]{40}$`)
+	// repoTagPattern is a tag name we are willing to hand to git checkout
+	repoTagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/+-]*$`)
+)
+
+// repoRefConflictError is a create request that sets both branch and ref on a repo
+type repoRefConflictError struct {
+	index int
+}
+
+func (e *repoRefConflictError) Error() string {
+	return fmt.Sprintf("repos[%d].branch and repos[%d].ref are mutually exclusive", e.index, e.index)
+}
+
+// createValidationStatus is the status for a validateCreateSessionRequest error: 422 for a repo
+// that sets both branch and ref or an invalid branch, 400 otherwise
+func createValidationStatus(err error) int {
+	switch err.(type) {
+	case *repoRefConflictError, *invalidBranchError:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// normalizeRepoRef validates a spec.repos[].ref (a full 40-character commit SHA or a tag) and returns
+// it trimmed, with SHAs lowercased
+func normalizeRepoRef(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if commitSHAPattern.MatchString(ref) {
+		return strings.ToLower(ref), nil
+	}
+	if !repoTagPattern.MatchString(ref) || strings.Contains(ref, "..") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".lock") {
+		return "", fmt.Errorf("must be a full 40-character commit SHA or a tag name")
+	}
+	return ref, nil
+}
+
+// requireRepoRefsExist checks every pinned repo against its provider's commits API when the caller
+// has a token for it, and writes 422 for a ref the provider does not know. Repos without a token,
+// on other providers, or whose check fails are let through; the runner's checkout is the backstop.
+func requireRepoRefsExist(c *gin.Context, reqK8s kubernetes.Interface, k8sDyn dynamic.Interface, project string, repos []types.SimpleRepo) bool {
+	if CheckRepoRefExists == nil {
+		return true
+	}
+	ctx := c.Request.Context()
+	userID := strings.TrimSpace(c.GetString("userID"))
+	apiBase := ""
+	apiBaseLoaded := false
+	for i, repo := range repos {
+		if repo.Ref == "" {
+			continue
+		}
+		token := ""
+		switch types.DetectProvider(repo.URL) {
+		case types.ProviderGitLab:
+			if t, err := git.GetGitLabToken(ctx, reqK8s, project, userID); err == nil {
+				token = t
+			}
+		default:
+			if GetGitHubToken != nil && userID != "" {
+				if t, err := GetGitHubToken(ctx, reqK8s, k8sDyn, project, userID, repo.URL); err == nil {
+					token = t
+				}
+			}
+		}
+		if strings.TrimSpace(token) == "" {
+			continue
+		}
+		if !apiBaseLoaded {
+			apiBase = git.GetProjectGitHubAPIBase(ctx, k8sDyn, project)
+			apiBaseLoaded = true
+		}
+		exists, err := CheckRepoRefExists(ctx, repo.URL, repo.Ref, strings.TrimSpace(token), apiBase)
+		if err != nil {
+			log.Printf("Could not check ref %q of %s in project %s: %v", repo.Ref, repo.URL, project, err)
+			continue
+		}
+		if !exists {
+			respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation,
+				fmt.Sprintf("repos[%d].ref %q was not found in %s", i, repo.Ref, repo.URL), nil)
+			return false
+		}
+	}
+	return true
+}
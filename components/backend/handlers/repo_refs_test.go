@@ -0,0 +1,100 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Repo Refs", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	const pinnedSHA = "3f1c2a9e8b7d6c5f4e3d2c1b0a9f8e7d6c5b4a39"
+
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		checkedRefs   []string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		checkedRefs = nil
+		CheckRepoRefExists = func(_ context.Context, repoURL, ref, token, _ string) (bool, error) {
+			checkedRefs = append(checkedRefs, ref)
+			return ref == pinnedSHA || ref == "v1.2.0", nil
+		}
+		DeferCleanup(func() { CheckRepoRefExists = nil })
+	})
+
+	create := func(repo map[string]interface{}) map[string]interface{} {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions",
+			map[string]interface{}{"initialPrompt": "x", "repos": []map[string]interface{}{repo}})
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		CreateSession(c)
+		var response map[string]interface{}
+		httpUtils.GetResponseJSON(&response)
+		return response
+	}
+
+	storedRepo := func(name string) map[string]interface{} {
+		stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		repos, _, _ := unstructured.NestedSlice(stored.Object, "spec", "repos")
+		Expect(repos).To(HaveLen(1))
+		return repos[0].(map[string]interface{})
+	}
+
+	It("Should store a pinned SHA, lowercased, without a branch", func() {
+		created := create(map[string]interface{}{"url": "https://github.com/org/app", "ref": "3F1C2A9E8B7D6C5F4E3D2C1B0A9F8E7D6C5B4A39"})
+
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		repo := storedRepo(created["name"].(string))
+		Expect(repo["ref"]).To(Equal(pinnedSHA))
+		Expect(repo).NotTo(HaveKey("branch"))
+		Expect(checkedRefs).To(Equal([]string{pinnedSHA}))
+	})
+
+	It("Should accept a tag", func() {
+		created := create(map[string]interface{}{"url": "https://github.com/org/app", "ref": "v1.2.0"})
+
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		Expect(storedRepo(created["name"].(string))["ref"]).To(Equal("v1.2.0"))
+	})
+
+	It("Should reject branch and ref together with 422", func() {
+		create(map[string]interface{}{"url": "https://github.com/org/app", "branch": "main", "ref": pinnedSHA})
+
+		httpUtils.AssertErrorResponse(http.StatusUnprocessableEntity, "Validation", "repos[0].branch and repos[0].ref are mutually exclusive")
+	})
+
+	It("Should reject a ref that is neither a SHA nor a tag name", func() {
+		create(map[string]interface{}{"url": "https://github.com/org/app", "ref": "../HEAD"})
+
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "repos[0].ref must be a full 40-character commit SHA or a tag name")
+	})
+
+	It("Should reject a ref the provider does not know", func() {
+		create(map[string]interface{}{"url": "https://github.com/org/app", "ref": "v9.9.9"})
+
+		httpUtils.AssertErrorResponse(http.StatusUnprocessableEntity, "Validation", `repos[0].ref "v9.9.9" was not found in https://github.com/org/app`)
+	})
+})
@@ -225,7 +225,7 @@ func GetRepoSeedStatus(c *gin.Context) {
 	repoURL := c.Query("repo")
 
 	if repoURL == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "repo query parameter required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "repo query parameter required", nil)
 		return
 	}
 
@@ -234,7 +234,7 @@ func GetRepoSeedStatus(c *gin.Context) {
 
 	// Check for missing user context
 	if userID == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		return
 	}
 
@@ -244,7 +244,8 @@ func GetRepoSeedStatus(c *gin.Context) {
 	// Clone repository temporarily to check structure
 	tmpDir, err := os.MkdirTemp("", "seed-check-*")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create temp directory: %v", err)})
+		log.Printf("Failed to create temp directory: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create temp directory", nil)
 		return
 	}
 	defer func() {
@@ -261,39 +262,45 @@ func GetRepoSeedStatus(c *gin.Context) {
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitLab token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 			return
 		}
 	case types.ProviderGitHub:
-		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), repoURL)
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+			}
 			return
 		}
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported repository provider"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "unsupported repository provider", nil)
 		return
 	}
 
 	// Clone repository
 	authURL, err := git.InjectGitToken(repoURL, token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to prepare repository URL: %v", err)})
+		log.Printf("Failed to prepare repository URL: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare repository URL", nil)
 		return
 	}
 
 	gitClone := exec.CommandContext(c.Request.Context(), "git", "clone", "--depth", "1", authURL, tmpDir)
 	if output, err := gitClone.CombinedOutput(); err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("Failed to clone repository: %v - %s", err, string(output))})
+		log.Printf("Failed to clone repository %s: %v - %s", repoURL, err, string(output))
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to clone repository",
+			gin.H{"remediation": "Verify the repository URL and that your token has read access"})
 		return
 	}
 
 	// Detect missing structure
 	status, err := DetectMissingStructure(c.Request.Context(), tmpDir)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to detect structure: %v", err)})
+		log.Printf("Failed to detect structure: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to detect structure", nil)
 		return
 	}
 
@@ -307,10 +314,13 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 
 	var req SeedRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request: %v", err)})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Invalid request: %v", err), nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", req.Branch) {
+		return
+	}
 	if req.Branch == "" {
 		req.Branch = "main"
 	}
@@ -320,7 +330,7 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 
 	// Check for missing user context
 	if userID == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing user context"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Missing user context", nil)
 		return
 	}
 
@@ -336,32 +346,31 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitLab token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":       "Invalid or missing token",
-				"remediation": "Connect your GitLab account via /auth/gitlab/connect",
-			})
+			respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token",
+				gin.H{"remediation": "Connect your GitLab account via /auth/gitlab/connect"})
 			return
 		}
 	case types.ProviderGitHub:
-		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string))
+		token, err = GetGitHubTokenRepo(c.Request.Context(), reqK8s, reqDyn, project, userID.(string), req.RepositoryURL)
 		if err != nil {
 			// Log actual error for debugging, but return generic message to avoid leaking internal details
 			log.Printf("Failed to get GitHub token for project %s, user %s: %v", project, userID, err)
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":       "Invalid or missing token",
-				"remediation": "Ensure GitHub App is installed or configure GIT_TOKEN in project runner secret",
-			})
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token",
+					gin.H{"remediation": "Ensure GitHub App is installed or configure GIT_TOKEN in project runner secret"})
+			}
 			return
 		}
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported repository provider"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "unsupported repository provider", nil)
 		return
 	}
 
 	// Clone repository
 	tmpDir, err := os.MkdirTemp("", "repo-seed-*")
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to create temp directory: %v", err)})
+		log.Printf("Failed to create temp directory: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create temp directory", nil)
 		return
 	}
 	defer func() {
@@ -372,24 +381,24 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 
 	authURL, err := git.InjectGitToken(req.RepositoryURL, token)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to prepare repository URL: %v", err)})
+		log.Printf("Failed to prepare repository URL: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare repository URL", nil)
 		return
 	}
 
 	gitClone := exec.CommandContext(c.Request.Context(), "git", "clone", "--branch", req.Branch, authURL, tmpDir)
 	if output, err := gitClone.CombinedOutput(); err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":       fmt.Sprintf("Failed to clone repository: %v", err),
-			"details":     string(output),
-			"remediation": "Verify repository URL and branch name, ensure token has read/write access",
-		})
+		log.Printf("Failed to clone repository %s: %v - %s", req.RepositoryURL, err, string(output))
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to clone repository",
+			gin.H{"remediation": "Verify repository URL and branch name, ensure token has read/write access"})
 		return
 	}
 
 	// Check if seeding is needed
 	status, err := DetectMissingStructure(c.Request.Context(), tmpDir)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to detect structure: %v", err)})
+		log.Printf("Failed to detect structure: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to detect structure", nil)
 		return
 	}
 
@@ -409,10 +418,9 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 	// Seed repository
 	response, err := SeedRepository(c.Request.Context(), tmpDir, req.RepositoryURL, req.Branch, userEmail, userName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":       response.Error,
-			"remediation": "Check repository permissions and try again",
-		})
+		log.Printf("Failed to seed repository %s: %v", req.RepositoryURL, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to seed repository",
+			gin.H{"remediation": "Check repository permissions and try again"})
 		return
 	}
 
@@ -421,24 +429,19 @@ func SeedRepositoryEndpoint(c *gin.Context) {
 	if output, err := gitPush.CombinedOutput(); err != nil {
 		// Check for permission errors
 		outputStr := string(output)
+		log.Printf("Failed to push seeded repository %s: %v - %s", req.RepositoryURL, err, outputStr)
 		if strings.Contains(outputStr, "403") || strings.Contains(outputStr, "Permission denied") {
 			remediation := "Ensure your token has write access to the repository"
 			if provider == types.ProviderGitLab {
 				remediation = "Ensure your GitLab PAT has 'write_repository' scope"
 			}
-			c.JSON(http.StatusForbidden, gin.H{
-				"error":       "Failed to push changes: permission denied",
-				"details":     outputStr,
-				"remediation": remediation,
-			})
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Failed to push changes: permission denied",
+				gin.H{"remediation": remediation})
 			return
 		}
 
-		c.JSON(http.StatusBadGateway, gin.H{
-			"error":       fmt.Sprintf("Failed to push changes: %v", err),
-			"details":     outputStr,
-			"remediation": "Check repository permissions and network connectivity",
-		})
+		respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to push changes",
+			gin.H{"remediation": "Check repository permissions and network connectivity"})
 		return
 	}
 
@@ -63,7 +63,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 		K8sClientProjects = k8sUtils.K8sClient
 		Namespace = *config.TestNamespace
 
-		GetGitHubTokenRepo = func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID string) (string, error) {
+		GetGitHubTokenRepo = func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID, repo string) (string, error) {
 			if project == "unauthorized-project" {
 				return "", fmt.Errorf("no GitHub token found for user")
 			}
@@ -311,7 +311,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 				// Note: This handler proceeds to Git operations before checking auth properly,
 				// so it fails with 502 (git error) instead of 401 (auth error)
 				httpUtils.AssertHTTPStatus(http.StatusBadGateway)
-				httpUtils.AssertErrorMessage("Failed to clone repository: exit status 128")
+				httpUtils.AssertErrorMessage("Failed to clone repository")
 			})
 		})
 
@@ -329,10 +329,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 				SeedRepositoryEndpoint(context)
 
 				// Assert
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Invalid request: invalid character 'i' looking for beginning of value",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid request: invalid character 'i' looking for beginning of value")
 			})
 
 			It("Should require repositoryUrl in request body", func() {
@@ -353,10 +350,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 				SeedRepositoryEndpoint(context)
 
 				// Assert
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Invalid request: Key: 'SeedRequest.RepositoryURL' Error:Field validation for 'RepositoryURL' failed on the 'required' tag",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Invalid request: Key: 'SeedRequest.RepositoryURL' Error:Field validation for 'RepositoryURL' failed on the 'required' tag")
 			})
 
 			It("Should default branch to main if not specified", func() {
@@ -423,10 +417,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 				SeedRepositoryEndpoint(context)
 
 				// Assert
-				httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "Invalid or missing token",
-				})
+				httpUtils.AssertErrorResponse(http.StatusUnauthorized, "Unauthorized", "Invalid or missing token")
 			})
 
 			It("Should fail with git error when no authentication provided", func() {
@@ -450,7 +441,7 @@ var _ = Describe("Repository Seeding Handler", Label(test_constants.LabelUnit, t
 				// Note: This handler proceeds to Git operations before checking auth properly,
 				// so it fails with 502 (git error) instead of 401 (auth error)
 				httpUtils.AssertHTTPStatus(http.StatusBadGateway)
-				httpUtils.AssertErrorMessage("Failed to clone repository: exit status 128")
+				httpUtils.AssertErrorMessage("Failed to clone repository")
 			})
 		})
 	})
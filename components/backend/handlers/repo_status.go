@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"ambient-code-backend/git"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// gitSHAPattern matches abbreviated or full SHA-1/SHA-256 commit hashes
+var gitSHAPattern = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+
+// repoGitStatuses are the git states a runner may report in status.reconciledRepos[].gitStatus.
+// status itself stays the operator's clone state (Cloning, Ready, Failed).
+var repoGitStatuses = map[string]bool{
+	"cloned":    true,
+	"dirty":     true,
+	"pushed":    true,
+	"abandoned": true,
+	"error":     true,
+}
+
+// sameRepoURL reports whether two repository URLs name the same repository, whatever their form
+// (https or ssh, with or without .git or credentials); see git.NormalizeRepoURL
+func sameRepoURL(a, b string) bool {
+	return git.SameRepoURL(a, b)
+}
+
+// setRepoStatus merges per-repo fields (clonedSha, pushedSha, ...) into the status.reconciledRepos
+// entry matching repoURL, adding an entry if the operator has not recorded the repo yet.
+func setRepoStatus(ctx context.Context, dyn dynamic.Interface, project, sessionName, repoURL string, fields map[string]interface{}) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		repos, _, _ := unstructured.NestedSlice(obj.Object, "status", "reconciledRepos")
+		var entry map[string]interface{}
+		for _, r := range repos {
+			if m, ok := r.(map[string]interface{}); ok {
+				if u, _ := m["url"].(string); sameRepoURL(u, repoURL) {
+					entry = m
+					break
+				}
+			}
+		}
+		if entry == nil {
+			entry = map[string]interface{}{"url": repoURL}
+			name := specRepoFolderForURL(obj, repoURL)
+			if name == "" && DeriveRepoFolderFromURL != nil {
+				name = DeriveRepoFolderFromURL(repoURL)
+			}
+			if name != "" {
+				entry["name"] = name
+			}
+			repos = append(repos, entry)
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+
+		if err := unstructured.SetNestedSlice(obj.Object, repos, "status", "reconciledRepos"); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// parseRunnerRepoStatuses validates the repos array of a runner status report and returns, per
+// repo name, the status.reconciledRepos fields to merge: gitStatus, clonedSha, clonedBranch,
+// pushedSha and lastUpdated (the report's last_updated, or now).
+func parseRunnerRepoStatuses(v interface{}) (map[string]map[string]interface{}, error) {
+	raw, ok := v.([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("repos must be a non-empty array")
+	}
+	reports := make(map[string]map[string]interface{}, len(raw))
+	for i, item := range raw {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("repos[%d] must be an object", i)
+		}
+		name, _ := entry["name"].(string)
+		if name = strings.TrimSpace(name); name == "" {
+			return nil, fmt.Errorf("repos[%d].name is required", i)
+		}
+		status, _ := entry["status"].(string)
+		if !repoGitStatuses[status] {
+			return nil, fmt.Errorf("repos[%d].status must be one of cloned, dirty, pushed, abandoned, error", i)
+		}
+		fields := map[string]interface{}{"gitStatus": status}
+		for _, field := range []string{"clonedSha", "pushedSha"} {
+			value, present := entry[field]
+			if !present {
+				continue
+			}
+			sha, _ := value.(string)
+			sha = strings.ToLower(strings.TrimSpace(sha))
+			if !gitSHAPattern.MatchString(sha) {
+				return nil, fmt.Errorf("repos[%d].%s must be a hex commit SHA", i, field)
+			}
+			fields[field] = sha
+		}
+		if value, present := entry["clonedBranch"]; present {
+			branch, _ := value.(string)
+			if branch = strings.TrimSpace(branch); branch == "" {
+				return nil, fmt.Errorf("repos[%d].clonedBranch must be a non-empty string", i)
+			}
+			fields["clonedBranch"] = branch
+		}
+		updated := time.Now().UTC()
+		if value, present := entry["last_updated"]; present {
+			ts, _ := value.(string)
+			parsed, err := time.Parse(time.RFC3339, strings.TrimSpace(ts))
+			if err != nil {
+				return nil, fmt.Errorf("repos[%d].last_updated must be an RFC 3339 timestamp", i)
+			}
+			updated = parsed.UTC()
+		}
+		fields["lastUpdated"] = updated.Format(time.RFC3339)
+		reports[name] = fields
+	}
+	return reports, nil
+}
+
+// mergeRunnerRepoStatuses applies parsed runner reports to status.reconciledRepos, matching
+// entries by name (or the folder of their url in spec.repos) and adding entries for repos the
+// operator has not recorded. Other entries and fields are left as they are.
+func mergeRunnerRepoStatuses(obj *unstructured.Unstructured, reports map[string]map[string]interface{}) error {
+	repos, _, _ := unstructured.NestedSlice(obj.Object, "status", "reconciledRepos")
+	merged := make(map[string]bool, len(reports))
+	for _, r := range repos {
+		entry, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := entry["name"].(string)
+		if u, _ := entry["url"].(string); name == "" && u != "" {
+			name = specRepoFolderForURL(obj, u)
+			if name == "" && DeriveRepoFolderFromURL != nil {
+				name = DeriveRepoFolderFromURL(u)
+			}
+		}
+		fields, ok := reports[name]
+		if !ok || merged[name] {
+			continue
+		}
+		for k, v := range fields {
+			entry[k] = v
+		}
+		merged[name] = true
+	}
+	for name, fields := range reports {
+		if merged[name] {
+			continue
+		}
+		entry := map[string]interface{}{"name": name}
+		for k, v := range fields {
+			entry[k] = v
+		}
+		repos = append(repos, entry)
+	}
+	return unstructured.SetNestedSlice(obj.Object, repos, "status", "reconciledRepos")
+}
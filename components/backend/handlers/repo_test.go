@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"strings"
 
+	"ambient-code-backend/git"
 	"ambient-code-backend/tests/logger"
 	"ambient-code-backend/tests/test_utils"
 	"ambient-code-backend/types"
@@ -19,6 +20,8 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	k8stesting "k8s.io/client-go/testing"
@@ -62,7 +65,7 @@ var _ = Describe("Repo Handler >", Label(test_constants.LabelUnit, test_constant
 		K8sClientProjects = k8sUtils.K8sClient
 		Namespace = *config.TestNamespace
 
-		GetGitHubTokenRepo = func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID string) (string, error) {
+		GetGitHubTokenRepo = func(ctx context.Context, k8s kubernetes.Interface, dyn dynamic.Interface, project, userID, repo string) (string, error) {
 			if project == "unauthorized-project" {
 				return "", fmt.Errorf("no GitHub token found for user")
 			}
@@ -249,10 +252,7 @@ var _ = Describe("Repo Handler >", Label(test_constants.LabelUnit, test_constant
 
 				ListUserForks(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "invalid repo format, expected owner/repo",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "invalid repo format, expected owner/repo")
 			})
 
 			It("Should handle unauthorized access", func() {
@@ -321,10 +321,7 @@ var _ = Describe("Repo Handler >", Label(test_constants.LabelUnit, test_constant
 
 				CreateUserFork(context)
 
-				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
-				httpUtils.AssertJSONContains(map[string]interface{}{
-					"error": "invalid repo format, expected owner/repo",
-				})
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "invalid repo format, expected owner/repo")
 			})
 
 			It("Should handle unauthorized access", func() {
@@ -382,6 +379,74 @@ var _ = Describe("Repo Handler >", Label(test_constants.LabelUnit, test_constant
 		})
 	})
 
+	Context("GitHub Enterprise Server", func() {
+		var requestedURL string
+
+		BeforeEach(func() {
+			requestedURL = ""
+			DoGitHubRequest = func(ctx context.Context, method, url, authHeader, accept string, body io.Reader) (*http.Response, error) {
+				requestedURL = url
+				return &http.Response{
+					StatusCode: http.StatusAccepted,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			}
+			originalSettingsResource := git.GetProjectSettingsResource
+			git.GetProjectSettingsResource = GetProjectSettingsResource
+			DeferCleanup(func() { git.GetProjectSettingsResource = originalSettingsResource })
+		})
+
+		forkRepo := func(upstreamRepo string) {
+			context := httpUtils.CreateTestGinContext("POST", "/projects/test-project/users/forks", map[string]interface{}{"upstreamRepo": upstreamRepo})
+			context.Params = gin.Params{{Key: "projectName", Value: "test-project"}}
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetUserContext("test-user", "Test User", "test@example.com")
+			httpUtils.AutoSetProjectContextFromParams()
+
+			CreateUserFork(context)
+		}
+
+		It("Should derive the API base from a GitHub Enterprise repo URL", func() {
+			forkRepo("https://github.example.com/org/repo.git")
+
+			httpUtils.AssertHTTPStatus(http.StatusAccepted)
+			Expect(requestedURL).To(Equal("https://github.example.com/api/v3/repos/org/repo/forks"))
+		})
+
+		It("Should keep api.github.com for github.com repos", func() {
+			forkRepo("owner/repo")
+
+			Expect(requestedURL).To(Equal("https://api.github.com/repos/owner/repo/forks"))
+		})
+
+		It("Should prefer GITHUB_API_BASE over the repo host", func() {
+			GinkgoT().Setenv(git.GitHubAPIBaseEnv, "https://ghe.corp.example/api/v3/")
+			forkRepo("owner/repo")
+
+			Expect(requestedURL).To(Equal("https://ghe.corp.example/api/v3/repos/owner/repo/forks"))
+		})
+
+		It("Should prefer the project's githubApiBase over GITHUB_API_BASE", func() {
+			GinkgoT().Setenv(git.GitHubAPIBaseEnv, "https://ghe.corp.example/api/v3")
+			settings := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "test-project"},
+				"spec":       map[string]interface{}{"githubApiBase": "https://git.team.example/api/v3"},
+			}}
+			_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace("test-project").Create(context.Background(), settings, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_ = k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace("test-project").Delete(context.Background(), "projectsettings", v1.DeleteOptions{})
+			})
+
+			forkRepo("https://git.team.example/org/repo")
+
+			Expect(requestedURL).To(Equal("https://git.team.example/api/v3/repos/org/repo/forks"))
+		})
+	})
+
 	Context("Repository Browsing Operations", func() {
 		Describe("GetRepoTree", func() {
 			It("Should require repo and ref parameters", func() {
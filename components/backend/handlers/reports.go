@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// reportListPageSize is how many sessions are fetched per List call while streaming a report
+const reportListPageSize = 250
+
+// sessionReportColumns is the stable column set of sessions.csv; append new columns at the end
+var sessionReportColumns = []string{
+	"name", "displayName", "user", "model", "phase", "startTime", "completionTime",
+	"durationSeconds", "totalCostUSD", "numTurns", "reposPushed",
+}
+
+// SessionUsage is the model usage of a session as reported by its runner
+type SessionUsage struct {
+	TotalCostUSD float64
+	NumTurns     int
+}
+
+// SessionUsageSummary returns the recorded usage of a session; set by main to the AG-UI event store.
+// Sessions without recorded results report false and get empty usage cells.
+var SessionUsageSummary func(sessionName string) (SessionUsage, bool)
+
+// ExportSessionsCSV handles GET /api/projects/:projectName/reports/sessions.csv?since=&until=
+// It streams one row per session created in [since, until), fetching the sessions page by page so
+// large projects are never held in memory. since and until accept RFC3339 timestamps or dates
+// (YYYY-MM-DD, where until includes that whole day).
+func ExportSessionsCSV(c *gin.Context) {
+	project := c.GetString("project")
+
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	since, sinceLabel, err := parseReportBound(c.Query("since"), false)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid since: %v", err), nil)
+		return
+	}
+	until, untilLabel, err := parseReportBound(c.Query("until"), true)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid until: %v", err), nil)
+		return
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "since must be before until", nil)
+		return
+	}
+	if sinceLabel == "" {
+		sinceLabel = "start"
+	}
+	if untilLabel == "" {
+		untilLabel = time.Now().UTC().Format("2006-01-02")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Minute)
+	defer cancel()
+	gvr := GetAgenticSessionV1Alpha1Resource()
+
+	// The first page is fetched before any output so permission and namespace errors still get a
+	// proper status code
+	list, err := k8sDyn.Resource(gvr).Namespace(project).List(ctx, v1.ListOptions{Limit: reportListPageSize})
+	if err != nil {
+		log.Printf("ExportSessionsCSV: failed to list sessions in project %s: %v", project, err)
+		respondK8sError(c, err, "Project not found", "Failed to list agentic sessions")
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-sessions-%s-to-%s.csv"`, project, sinceLabel, untilLabel))
+	c.Status(http.StatusOK)
+	// The byte order mark makes Excel read the file as UTF-8 instead of the local code page
+	_, _ = c.Writer.Write([]byte("\ufeff"))
+	w := csv.NewWriter(c.Writer)
+	w.UseCRLF = true
+	_ = w.Write(sessionReportColumns)
+
+	rows := 0
+	for {
+		for i := range list.Items {
+			item := &list.Items[i]
+			created := item.GetCreationTimestamp().Time
+			if (!since.IsZero() && created.Before(since)) || (!until.IsZero() && !created.Before(until)) {
+				continue
+			}
+			if err := w.Write(sessionReportRow(item)); err != nil {
+				log.Printf("ExportSessionsCSV: client went away after %d rows: %v", rows, err)
+				return
+			}
+			rows++
+		}
+		w.Flush()
+		c.Writer.Flush()
+
+		next := list.GetContinue()
+		if next == "" {
+			break
+		}
+		list, err = k8sDyn.Resource(gvr).Namespace(project).List(ctx, v1.ListOptions{Limit: reportListPageSize, Continue: next})
+		if err != nil {
+			// Headers are already sent; a truncated file is all that can be signalled
+			log.Printf("ExportSessionsCSV: failed to list sessions in project %s after %d rows: %v", project, rows, err)
+			return
+		}
+	}
+	log.Printf("ExportSessionsCSV: wrote %d sessions for project %s", rows, project)
+}
+
+// parseReportBound parses a since/until query value. Date-only upper bounds are moved to the end of
+// that day. The label is the date used in the suggested filename ("" when unset).
+func parseReportBound(raw string, upper bool) (time.Time, string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, "", nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		if upper {
+			return t.AddDate(0, 0, 1), raw, nil
+		}
+		return t, raw, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("expected YYYY-MM-DD or an RFC3339 timestamp")
+	}
+	return t, t.UTC().Format("2006-01-02"), nil
+}
+
+// sessionReportRow renders one session in sessionReportColumns order
+func sessionReportRow(item *unstructured.Unstructured) []string {
+	spec, _, _ := unstructured.NestedMap(item.Object, "spec")
+	status, _, _ := unstructured.NestedMap(item.Object, "status")
+	parsedSpec := parseSpec(spec)
+	parsedStatus := parseStatus(status)
+	if parsedStatus == nil {
+		parsedStatus = &types.AgenticSessionStatus{}
+	}
+
+	user := ""
+	if parsedSpec.UserContext != nil {
+		user = parsedSpec.UserContext.UserID
+	}
+	startTime, completionTime, duration := "", "", ""
+	if parsedStatus.StartTime != nil {
+		startTime = *parsedStatus.StartTime
+	}
+	if parsedStatus.CompletionTime != nil {
+		completionTime = *parsedStatus.CompletionTime
+	}
+	if start, err := time.Parse(time.RFC3339, startTime); err == nil {
+		if end, err := time.Parse(time.RFC3339, completionTime); err == nil && !end.Before(start) {
+			duration = strconv.FormatInt(int64(end.Sub(start).Seconds()), 10)
+		}
+	}
+	cost, turns := "", ""
+	if SessionUsageSummary != nil {
+		if usage, ok := SessionUsageSummary(item.GetName()); ok {
+			cost = strconv.FormatFloat(usage.TotalCostUSD, 'f', 4, 64)
+			turns = strconv.Itoa(usage.NumTurns)
+		}
+	}
+	var pushed []string
+	for _, repo := range parsedStatus.ReconciledRepos {
+		if repo.PushedSHA == nil || *repo.PushedSHA == "" {
+			continue
+		}
+		branch := repo.Branch
+		if repo.PushedBranch != nil && *repo.PushedBranch != "" {
+			branch = *repo.PushedBranch
+		}
+		pushed = append(pushed, repo.URL+"@"+branch)
+	}
+
+	return []string{
+		csvText(item.GetName()),
+		csvText(parsedSpec.DisplayName),
+		csvText(user),
+		csvText(parsedSpec.LLMSettings.Model),
+		parsedStatus.Phase,
+		startTime,
+		completionTime,
+		duration,
+		cost,
+		turns,
+		csvText(strings.Join(pushed, " ")),
+	}
+}
+
+// csvText neutralizes user-controlled text that spreadsheet applications would evaluate as a
+// formula; quoting of separators and newlines is left to encoding/csv
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// CostReportGroup is the cost of the sessions sharing one groupBy value
+type CostReportGroup struct {
+	Key          string  `json:"key"` // "" for sessions without the user, model or label
+	Sessions     int     `json:"sessions"`
+	TotalCostUSD float64 `json:"totalCostUSD"`
+}
+
+// costReportKey returns the function giving a session's group for a ?groupBy= value: project (the
+// default, one group), user, model, or label:<key> for the chargeback label <key>
+func costReportKey(groupBy, project string) (func(*unstructured.Unstructured) string, error) {
+	switch {
+	case groupBy == "" || groupBy == "project":
+		return func(*unstructured.Unstructured) string { return project }, nil
+	case groupBy == "user":
+		return func(item *unstructured.Unstructured) string {
+			user, _, _ := unstructured.NestedString(item.Object, "spec", "userContext", "userId")
+			return user
+		}, nil
+	case groupBy == "model":
+		return func(item *unstructured.Unstructured) string {
+			model, _, _ := unstructured.NestedString(item.Object, "spec", "llmSettings", "model")
+			return model
+		}, nil
+	case strings.HasPrefix(groupBy, "label:"):
+		key := strings.TrimPrefix(groupBy, "label:")
+		if errs := chargebackLabelErrors(key, ""); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid groupBy label %q: %s", key, strings.Join(errs, "; "))
+		}
+		return func(item *unstructured.Unstructured) string { return item.GetLabels()[chargebackLabelPrefix+key] }, nil
+	}
+	return nil, fmt.Errorf("groupBy must be project, user, model or label:<key>")
+}
+
+// sessionCostUSD is the cost the runner last reported in status.totalCostUSD, or else the cost
+// recorded in the AG-UI event log
+func sessionCostUSD(item *unstructured.Unstructured) (float64, bool) {
+	if cost, ok := numberValue(unstructuredField(item, "status", "totalCostUSD")); ok {
+		return cost, true
+	}
+	if SessionUsageSummary != nil {
+		if usage, ok := SessionUsageSummary(item.GetName()); ok {
+			return usage.TotalCostUSD, true
+		}
+	}
+	return 0, false
+}
+
+// GetCostReport handles GET /api/projects/:projectName/reports/cost?groupBy=&since=&until=
+// It totals the cost of the sessions created in [since, until) per groupBy value, most expensive
+// first. groupBy=label:<key> breaks the cost down by the ProjectSettings chargeback label <key>.
+func GetCostReport(c *gin.Context) {
+	project := c.GetString("project")
+
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	groupBy := strings.TrimSpace(c.Query("groupBy"))
+	keyOf, err := costReportKey(groupBy, project)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	since, _, err := parseReportBound(c.Query("since"), false)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid since: %v", err), nil)
+		return
+	}
+	until, _, err := parseReportBound(c.Query("until"), true)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid until: %v", err), nil)
+		return
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "since must be before until", nil)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+	gvr := GetAgenticSessionV1Alpha1Resource()
+
+	groups := map[string]*CostReportGroup{}
+	total := 0.0
+	opts := v1.ListOptions{Limit: reportListPageSize}
+	for {
+		list, err := k8sDyn.Resource(gvr).Namespace(project).List(ctx, opts)
+		if err != nil {
+			log.Printf("GetCostReport: failed to list sessions in project %s: %v", project, err)
+			respondK8sError(c, err, "Project not found", "Failed to list agentic sessions")
+			return
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			created := item.GetCreationTimestamp().Time
+			if (!since.IsZero() && created.Before(since)) || (!until.IsZero() && !created.Before(until)) {
+				continue
+			}
+			key := keyOf(item)
+			g := groups[key]
+			if g == nil {
+				g = &CostReportGroup{Key: key}
+				groups[key] = g
+			}
+			g.Sessions++
+			if cost, ok := sessionCostUSD(item); ok {
+				g.TotalCostUSD += cost
+				total += cost
+			}
+		}
+		if opts.Continue = list.GetContinue(); opts.Continue == "" {
+			break
+		}
+	}
+
+	result := make([]CostReportGroup, 0, len(groups))
+	for _, g := range groups {
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCostUSD != result[j].TotalCostUSD {
+			return result[i].TotalCostUSD > result[j].TotalCostUSD
+		}
+		return result[i].Key < result[j].Key
+	})
+	if groupBy == "" {
+		groupBy = "project"
+	}
+	c.JSON(http.StatusOK, gin.H{"groupBy": groupBy, "groups": result, "totalCostUSD": total})
+}
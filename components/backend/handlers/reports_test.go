@@ -0,0 +1,201 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Session Reports", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils       *test_utils.HTTPTestUtils
+		k8sUtils        *test_utils.K8sTestUtils
+		testNamespace   string
+		originalSummary func(string) (SessionUsage, bool)
+	)
+
+	reportSession := func(name string, created time.Time, obj map[string]interface{}) {
+		session := &unstructured.Unstructured{Object: obj}
+		session.SetAPIVersion("vteam.ambient-code/v1alpha1")
+		session.SetKind("AgenticSession")
+		session.SetName(name)
+		session.SetNamespace(testNamespace)
+		session.SetCreationTimestamp(v1.NewTime(created))
+		_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(context.Background(), session, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	export := func(query string) [][]string {
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/reports/sessions.csv"+query, nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		ExportSessionsCSV(c)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		body := httpUtils.GetResponseRecorder().Body.String()
+		Expect(body).To(HavePrefix("\ufeff"), "Excel needs the BOM to read UTF-8")
+		records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		return records
+	}
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+		originalSummary = SessionUsageSummary
+		SessionUsageSummary = func(name string) (SessionUsage, bool) {
+			if name == "q1-done" {
+				return SessionUsage{TotalCostUSD: 1.25, NumTurns: 7}, true
+			}
+			return SessionUsage{}, false
+		}
+
+		reportSession("q1-done", time.Date(2026, 2, 10, 9, 0, 0, 0, time.UTC), map[string]interface{}{
+			"spec": map[string]interface{}{
+				"displayName": "Fix login, \"quickly\"\nthen test",
+				"llmSettings": map[string]interface{}{"model": "claude-sonnet-4-5"},
+				"userContext": map[string]interface{}{"userId": "alice"},
+			},
+			"status": map[string]interface{}{
+				"phase":          "Completed",
+				"startTime":      "2026-02-10T09:00:00Z",
+				"completionTime": "2026-02-10T09:30:00Z",
+				"reconciledRepos": []interface{}{
+					map[string]interface{}{"url": "https://github.com/org/app", "branch": "main", "pushedSha": "abc123", "pushedBranch": "fix-login"},
+					map[string]interface{}{"url": "https://github.com/org/docs", "branch": "main"},
+				},
+			},
+		})
+		reportSession("q1-formula", time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC), map[string]interface{}{
+			"spec": map[string]interface{}{"displayName": "=HYPERLINK(\"http://evil\")"},
+		})
+		reportSession("q2-running", time.Date(2026, 4, 2, 9, 0, 0, 0, time.UTC), map[string]interface{}{
+			"spec":   map[string]interface{}{"displayName": "later"},
+			"status": map[string]interface{}{"phase": "Running", "startTime": "2026-04-02T09:00:00Z"},
+		})
+	})
+
+	AfterEach(func() {
+		SessionUsageSummary = originalSummary
+	})
+
+	It("Should stream the stable columns with escaping for sessions in the date range", func() {
+		records := export("?since=2026-01-01&until=2026-03-31")
+
+		Expect(records[0]).To(Equal(sessionReportColumns))
+		Expect(records).To(HaveLen(3), "q2-running is outside the range")
+		rows := map[string][]string{}
+		for _, r := range records[1:] {
+			Expect(r).To(HaveLen(len(sessionReportColumns)))
+			rows[r[0]] = r
+		}
+		Expect(rows["q1-done"]).To(Equal([]string{
+			"q1-done", "Fix login, \"quickly\"\nthen test", "alice", "claude-sonnet-4-5", "Completed",
+			"2026-02-10T09:00:00Z", "2026-02-10T09:30:00Z", "1800", "1.2500", "7", "https://github.com/org/app@fix-login",
+		}))
+		Expect(rows["q1-formula"][1]).To(Equal("'=HYPERLINK(\"http://evil\")"), "formulas must not be evaluated by spreadsheets")
+		Expect(rows["q1-formula"][7:10]).To(Equal([]string{"", "", ""}))
+
+		disposition := httpUtils.GetResponseRecorder().Header().Get("Content-Disposition")
+		Expect(disposition).To(Equal(`attachment; filename="` + testNamespace + `-sessions-2026-01-01-to-2026-03-31.csv"`))
+		Expect(httpUtils.GetResponseRecorder().Header().Get("Content-Type")).To(HavePrefix("text/csv"))
+	})
+
+	It("Should export every session when no range is given", func() {
+		records := export("")
+		Expect(records).To(HaveLen(4))
+		Expect(httpUtils.GetResponseRecorder().Header().Get("Content-Disposition")).To(ContainSubstring("-sessions-start-to-"))
+	})
+
+	Describe("GetCostReport", func() {
+		costReport := func(query string) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/reports/cost"+query, nil)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			GetCostReport(c)
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			return resp
+		}
+
+		BeforeEach(func() {
+			reportSession("q2-cc1", time.Date(2026, 4, 3, 9, 0, 0, 0, time.UTC), map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"chargeback.ambient-code.io/costCenter": "cc-1"}},
+				"spec":     map[string]interface{}{"userContext": map[string]interface{}{"userId": "alice"}},
+				"status":   map[string]interface{}{"totalCostUSD": 2.5},
+			})
+			reportSession("q2-cc2", time.Date(2026, 4, 4, 9, 0, 0, 0, time.UTC), map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{"chargeback.ambient-code.io/costCenter": "cc-2"}},
+				"status":   map[string]interface{}{"totalCostUSD": 0.5},
+			})
+		})
+
+		It("Should break cost down by a chargeback label, most expensive first", func() {
+			resp := costReport("?groupBy=label:costCenter&since=2026-02-01")
+
+			Expect(resp["groupBy"]).To(Equal("label:costCenter"))
+			Expect(resp["totalCostUSD"]).To(BeNumerically("~", 4.25))
+			Expect(resp["groups"]).To(Equal([]interface{}{
+				map[string]interface{}{"key": "cc-1", "sessions": float64(1), "totalCostUSD": 2.5},
+				map[string]interface{}{"key": "", "sessions": float64(3), "totalCostUSD": 1.25},
+				map[string]interface{}{"key": "cc-2", "sessions": float64(1), "totalCostUSD": 0.5},
+			}))
+		})
+
+		It("Should total the project by default and group by user", func() {
+			resp := costReport("")
+			Expect(resp["groups"]).To(Equal([]interface{}{
+				map[string]interface{}{"key": testNamespace, "sessions": float64(5), "totalCostUSD": 4.25},
+			}))
+
+			resp = costReport("?groupBy=user")
+			Expect(resp["groups"]).To(ContainElement(map[string]interface{}{"key": "alice", "sessions": float64(2), "totalCostUSD": 3.75}))
+		})
+
+		It("Should reject unknown groupings", func() {
+			for _, query := range []string{"?groupBy=phase", "?groupBy=label:bad/key"} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/reports/cost"+query, nil)
+				httpUtils.SetAuthHeader("test-token")
+				httpUtils.SetProjectContext(testNamespace)
+				GetCostReport(c)
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+			}
+		})
+	})
+
+	It("Should reject malformed and inverted ranges", func() {
+		for _, query := range []string{"?since=last-quarter", "?until=31/03/2026", "?since=2026-04-01&until=2026-03-31"} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/reports/sessions.csv"+query, nil)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			ExportSessionsCSV(c)
+			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+		}
+	})
+
+	It("Should require a token", func() {
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/reports/sessions.csv", nil)
+		httpUtils.SetProjectContext(testNamespace)
+		ExportSessionsCSV(c)
+		httpUtils.AssertHTTPStatus(http.StatusUnauthorized)
+	})
+})
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// runnerImageAllowed reports whether image matches one of the glob patterns (path.Match syntax, so
+// quay.io/team/runner:* matches any tag of that repository but not other repositories)
+func runnerImageAllowed(image string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(strings.TrimSpace(p), image); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// projectAllowedRunnerImages returns spec.allowedRunnerImages from the project's ProjectSettings.
+// A missing ProjectSettings means no overrides are allowed.
+func projectAllowedRunnerImages(ctx context.Context, dyn dynamic.Interface, project string) ([]string, error) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	patterns, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "allowedRunnerImages")
+	return patterns, nil
+}
+
+// requireAllowedRunnerImage validates a requested spec.runnerImage against the project's allowlist.
+// An empty image always passes; an empty allowlist means only the operator default may be used. On
+// failure it writes the error response and returns false.
+func requireAllowedRunnerImage(c *gin.Context, dyn dynamic.Interface, project, image string) bool {
+	if image == "" {
+		return true
+	}
+	patterns, err := projectAllowedRunnerImages(c.Request.Context(), dyn, project)
+	if err != nil {
+		log.Printf("Failed to read allowed runner images in %s: %v", project, err)
+		respondK8sError(c, err, "ProjectSettings not found", "Failed to read ProjectSettings")
+		return false
+	}
+	if len(patterns) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "runner image overrides are not allowed in this project", nil)
+		return false
+	}
+	if !runnerImageAllowed(image, patterns) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("runner image %q is not allowed in this project", image),
+			gin.H{"allowedRunnerImages": patterns})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkUserCanUpdateSecrets checks if user can UPDATE secrets in the namespace
+// This gates the key-level runner secret editor
+func checkUserCanUpdateSecrets(userClient kubernetes.Interface, namespace string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "update",
+				Resource:  "secrets",
+			},
+		},
+	}
+
+	result, err := userClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return result.Status.Allowed, nil
+}
+
+// runnerSecretForKeyEdit performs the shared checks of the key editor endpoints: a valid token,
+// secrets update access in the project and a runner-annotated Opaque secret. It writes the error
+// response and returns nil when any check fails.
+func runnerSecretForKeyEdit(c *gin.Context) (kubernetes.Interface, *corev1.Secret) {
+	projectName := c.Param("projectName")
+	secretName := c.Param("name")
+	k8sClient, _ := GetK8sClientsForRequest(c)
+	if k8sClient == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return nil, nil
+	}
+	if errs := validation.IsDNS1123Subdomain(secretName); len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Invalid secret name '%s'", secretName), nil)
+		return nil, nil
+	}
+
+	allowed, err := checkUserCanUpdateSecrets(k8sClient, projectName)
+	if err != nil {
+		log.Printf("Failed to check secrets access in %s: %v", projectName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+		return nil, nil
+	}
+	if !allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Updating secrets in this project is not allowed", nil)
+		return nil, nil
+	}
+
+	sec, err := k8sClient.CoreV1().Secrets(projectName).Get(c.Request.Context(), secretName, v1.GetOptions{})
+	if err != nil {
+		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
+		respondK8sError(c, err, "Secret not found", "Failed to read secret")
+		return nil, nil
+	}
+	// Non-runner secrets are reported as missing so the editor cannot probe or touch them
+	if sec.Type != corev1.SecretTypeOpaque || sec.Annotations[runnerSecretAnnotation] != "true" {
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Secret not found", nil)
+		return nil, nil
+	}
+	return k8sClient, sec
+}
+
+// validSecretKey reports whether key is usable as a secret data key
+func validSecretKey(c *gin.Context, key string) bool {
+	if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Invalid key '%s': %s", key, strings.Join(errs, "; ")), nil)
+		return false
+	}
+	return true
+}
+
+// patchSecretKey applies a JSON merge patch touching only data[key]; a nil value removes the key.
+// Patching a single key keeps concurrent edits to other keys intact.
+func patchSecretKey(ctx context.Context, k8sClient kubernetes.Interface, namespace, name, key string, value []byte) error {
+	var entry interface{}
+	if value != nil {
+		entry = value // encoded as base64 by encoding/json, as Secret.data expects
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{key: entry},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k8sClient.CoreV1().Secrets(namespace).Patch(ctx, name, ktypes.MergePatchType, patch, v1.PatchOptions{})
+	return err
+}
+
+// ListSecretKeys handles GET /api/projects/:projectName/secrets/:name/keys -> { name, createdAt, keys: [{name, sizeBytes}] }
+// Only key names and sizes are returned; values never leave the cluster through this endpoint.
+func ListSecretKeys(c *gin.Context) {
+	_, sec := runnerSecretForKeyEdit(c)
+	if sec == nil {
+		return
+	}
+
+	type KeyItem struct {
+		Name      string `json:"name"`
+		SizeBytes int    `json:"sizeBytes"`
+	}
+	keys := make([]KeyItem, 0, len(sec.Data))
+	for k, v := range sec.Data {
+		keys = append(keys, KeyItem{Name: k, SizeBytes: len(v)})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+
+	resp := gin.H{"name": sec.Name, "keys": keys}
+	if !sec.CreationTimestamp.IsZero() {
+		resp["createdAt"] = sec.CreationTimestamp.Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSecretKey handles PUT /api/projects/:projectName/secrets/:name/keys/:key { value }
+// Sets or rotates a single key, leaving the other keys of the secret untouched.
+func UpdateSecretKey(c *gin.Context) {
+	key := c.Param("key")
+	if !validSecretKey(c, key) {
+		return
+	}
+	var req struct {
+		Value *string `json:"value" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	if *req.Value == "" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "value must not be empty; use DELETE to remove a key", nil)
+		return
+	}
+
+	k8sClient, sec := runnerSecretForKeyEdit(c)
+	if sec == nil {
+		return
+	}
+	if err := patchSecretKey(c.Request.Context(), k8sClient, sec.Namespace, sec.Name, key, []byte(*req.Value)); err != nil {
+		log.Printf("Failed to set key %s in Secret %s/%s: %v", key, sec.Namespace, sec.Name, err)
+		respondK8sError(c, err, "Secret not found", "Failed to update secret key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("key %s updated", key)})
+}
+
+// DeleteSecretKey handles DELETE /api/projects/:projectName/secrets/:name/keys/:key
+func DeleteSecretKey(c *gin.Context) {
+	key := c.Param("key")
+	if !validSecretKey(c, key) {
+		return
+	}
+
+	k8sClient, sec := runnerSecretForKeyEdit(c)
+	if sec == nil {
+		return
+	}
+	if _, ok := sec.Data[key]; !ok {
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, fmt.Sprintf("Key %s not found", key), nil)
+		return
+	}
+	if err := patchSecretKey(c.Request.Context(), k8sClient, sec.Namespace, sec.Name, key, nil); err != nil {
+		log.Printf("Failed to delete key %s from Secret %s/%s: %v", key, sec.Namespace, sec.Name, err)
+		respondK8sError(c, err, "Secret not found", "Failed to delete secret key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("key %s deleted", key)})
+}
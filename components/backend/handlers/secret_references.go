@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// runnerTokenSecretAnnotation names the Secret holding a session's runner token
+const runnerTokenSecretAnnotation = "ambient-code.io/runner-token-secret"
+
+// SecretReference is an object in the project that uses a secret by name
+type SecretReference struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Field string `json:"field"`
+	// Phase is the session phase, for AgenticSession references
+	Phase string `json:"phase,omitempty"`
+}
+
+// projectSecretReferences maps each secret name to the ProjectSettings and AgenticSessions of the
+// project that reference it: spec.runnerSecretsName (or the default runner secret while the
+// ProjectSettings leaves it unset) and each session's runner token secret annotation
+func projectSecretReferences(ctx context.Context, dyn dynamic.Interface, project string) (map[string][]SecretReference, error) {
+	refs := map[string][]SecretReference{}
+
+	settings, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, fmt.Errorf("get ProjectSettings: %w", err)
+	}
+	if err == nil {
+		name, _, _ := unstructured.NestedString(settings.Object, "spec", "runnerSecretsName")
+		if name = strings.TrimSpace(name); name == "" {
+			name = runnerSecretsName
+		}
+		refs[name] = append(refs[name], SecretReference{Kind: "ProjectSettings", Name: settings.GetName(), Field: "spec.runnerSecretsName"})
+	}
+
+	sessions, err := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("list AgenticSessions: %w", err)
+	}
+	for i := range sessions.Items {
+		s := &sessions.Items[i]
+		name := strings.TrimSpace(s.GetAnnotations()[runnerTokenSecretAnnotation])
+		if name == "" {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(s.Object, "status", "phase")
+		refs[name] = append(refs[name], SecretReference{
+			Kind:  "AgenticSession",
+			Name:  s.GetName(),
+			Field: "metadata.annotations[" + runnerTokenSecretAnnotation + "]",
+			Phase: phase,
+		})
+	}
+
+	for _, list := range refs {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Kind != list[j].Kind {
+				return list[i].Kind > list[j].Kind // ProjectSettings first
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	return refs, nil
+}
+
+// DeleteNamespaceSecret handles DELETE /api/projects/:projectName/secrets/:name[?force=true]
+// A runner secret that ProjectSettings or a session still references is kept and 409 lists the
+// references in error.details.references; force deletes it anyway.
+func DeleteNamespaceSecret(c *gin.Context) {
+	projectName := c.Param("projectName")
+	secretName := c.Param("name")
+	k8sClient, dynClient := GetK8sClientsForRequest(c)
+	if k8sClient == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if errs := validation.IsDNS1123Subdomain(secretName); len(errs) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Invalid secret name '%s'", secretName), nil)
+		return
+	}
+	ctx := c.Request.Context()
+
+	sec, err := k8sClient.CoreV1().Secrets(projectName).Get(ctx, secretName, v1.GetOptions{})
+	if err != nil {
+		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
+		respondK8sError(c, err, "Secret not found", "Failed to read secret")
+		return
+	}
+	// Only secrets ListNamespaceSecrets shows can be deleted here
+	if sec.Type != corev1.SecretTypeOpaque || sec.Annotations[runnerSecretAnnotation] != "true" {
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Secret not found", nil)
+		return
+	}
+
+	if c.Query("force") != "true" {
+		refs, err := projectSecretReferences(ctx, dynClient, projectName)
+		if err != nil {
+			log.Printf("Failed to check references to Secret %s/%s: %v", projectName, secretName, err)
+			respondK8sError(c, err, "Secret not found", "Failed to check secret references")
+			return
+		}
+		if len(refs[secretName]) > 0 {
+			respondError(c, http.StatusConflict, ErrorKindConflict,
+				fmt.Sprintf("Secret %s is still referenced by %d object(s); use force=true to delete it anyway", secretName, len(refs[secretName])),
+				gin.H{"references": refs[secretName]})
+			return
+		}
+	}
+
+	// The UID precondition keeps a secret recreated since the check from being deleted
+	uid := sec.UID
+	if err := k8sClient.CoreV1().Secrets(projectName).Delete(ctx, secretName, v1.DeleteOptions{Preconditions: &v1.Preconditions{UID: &uid}}); err != nil {
+		log.Printf("Failed to delete Secret %s/%s: %v", projectName, secretName, err)
+		respondK8sError(c, err, "Secret not found", "Failed to delete secret")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("secret %s deleted", secretName)})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+
+	"ambient-code-backend/git"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// contentSecretFindings reads the pre-push secret scan findings from a content service push
+// response: the error details of a blocked push, or the findings of a push made with allowSecrets
+func contentSecretFindings(body []byte) []git.SecretFinding {
+	var resp struct {
+		SecretFindings []git.SecretFinding `json:"secretFindings"`
+		Error          struct {
+			Details struct {
+				SecretFindings []git.SecretFinding `json:"secretFindings"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil
+	}
+	if len(resp.Error.Details.SecretFindings) > 0 {
+		return resp.Error.Details.SecretFindings
+	}
+	return resp.SecretFindings
+}
+
+// secretFindingsStatus converts findings to the status.reconciledRepos[].secretFindings value. An
+// empty list clears the findings of an earlier attempt.
+func secretFindingsStatus(findings []git.SecretFinding) []interface{} {
+	out := make([]interface{}, 0, len(findings))
+	for _, f := range findings {
+		out = append(out, map[string]interface{}{"file": f.File, "line": int64(f.Line), "rule": f.Rule})
+	}
+	return out
+}
+
+// canOverrideSecretScan reports whether the caller has edit rights in the project, which is
+// required to push changes the secret scan flagged
+func canOverrideSecretScan(ctx context.Context, userClient kubernetes.Interface, project string) (bool, error) {
+	return canUpdateSessions(ctx, userClient, project)
+}
@@ -16,12 +16,14 @@ import (
 // 1. ambient-runner-secrets: ANTHROPIC_API_KEY only (ignored when Vertex enabled)
 // 2. ambient-non-vertex-integrations: GITHUB_TOKEN, JIRA_*, custom keys (optional, injected if present)
 
-// ListNamespaceSecrets handles GET /api/projects/:projectName/secrets -> { items: [{name, createdAt}] }
+// ListNamespaceSecrets handles GET /api/projects/:projectName/secrets -> { items: [{name, createdAt, type, referencedBy}] }
+// referencedBy lists the ProjectSettings and sessions using each secret. When the references cannot
+// be read it is left empty and the secrets are still listed.
 func ListNamespaceSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
-	k8sClient, _ := GetK8sClientsForRequest(c)
+	k8sClient, dynClient := GetK8sClientsForRequest(c)
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -29,14 +31,20 @@ func ListNamespaceSecrets(c *gin.Context) {
 	list, err := k8sClient.CoreV1().Secrets(projectName).List(c.Request.Context(), v1.ListOptions{})
 	if err != nil {
 		log.Printf("Failed to list secrets in %s: %v", projectName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list secrets"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list secrets", nil)
 		return
 	}
 
+	refs, err := projectSecretReferences(c.Request.Context(), dynClient, projectName)
+	if err != nil {
+		log.Printf("Failed to read secret references in %s: %v", projectName, err)
+	}
+
 	type Item struct {
-		Name      string `json:"name"`
-		CreatedAt string `json:"createdAt,omitempty"`
-		Type      string `json:"type"`
+		Name         string            `json:"name"`
+		CreatedAt    string            `json:"createdAt,omitempty"`
+		Type         string            `json:"type"`
+		ReferencedBy []SecretReference `json:"referencedBy"`
 	}
 	items := []Item{}
 	for _, s := range list.Items {
@@ -47,7 +55,10 @@ func ListNamespaceSecrets(c *gin.Context) {
 		if s.Annotations == nil || s.Annotations["ambient-code.io/runner-secret"] != "true" {
 			continue
 		}
-		it := Item{Name: s.Name, Type: string(s.Type)}
+		it := Item{Name: s.Name, Type: string(s.Type), ReferencedBy: refs[s.Name]}
+		if it.ReferencedBy == nil {
+			it.ReferencedBy = []SecretReference{}
+		}
 		if !s.CreationTimestamp.IsZero() {
 			it.CreatedAt = s.CreationTimestamp.Format(time.RFC3339)
 		}
@@ -65,7 +76,7 @@ func ListRunnerSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	k8sClient, _ := GetK8sClientsForRequest(c)
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -79,7 +90,7 @@ func ListRunnerSecrets(c *gin.Context) {
 			return
 		}
 		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read runner secrets"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read runner secrets", nil)
 		return
 	}
 
@@ -95,7 +106,7 @@ func UpdateRunnerSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	k8sClient, _ := GetK8sClientsForRequest(c)
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -104,7 +115,7 @@ func UpdateRunnerSecrets(c *gin.Context) {
 		Data map[string]string `json:"data" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -115,9 +126,8 @@ func UpdateRunnerSecrets(c *gin.Context) {
 	}
 	for key := range req.Data {
 		if !allowedKeys[key] {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("Invalid key '%s' for ambient-runner-secrets. Only ANTHROPIC_API_KEY is allowed.", key),
-			})
+			respondError(c, http.StatusBadRequest, ErrorKindValidation,
+				fmt.Sprintf("Invalid key '%s' for ambient-runner-secrets. Only ANTHROPIC_API_KEY is allowed.", key), nil)
 			return
 		}
 	}
@@ -141,12 +151,12 @@ func UpdateRunnerSecrets(c *gin.Context) {
 		}
 		if _, err := k8sClient.CoreV1().Secrets(projectName).Create(c.Request.Context(), newSec, v1.CreateOptions{}); err != nil {
 			log.Printf("Failed to create Secret %s/%s: %v", projectName, secretName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create runner secrets"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create runner secrets", nil)
 			return
 		}
 	} else if err != nil {
 		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read runner secrets"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read runner secrets", nil)
 		return
 	} else {
 		// Update existing - replace Data
@@ -157,7 +167,7 @@ func UpdateRunnerSecrets(c *gin.Context) {
 		}
 		if _, err := k8sClient.CoreV1().Secrets(projectName).Update(c.Request.Context(), sec, v1.UpdateOptions{}); err != nil {
 			log.Printf("Failed to update Secret %s/%s: %v", projectName, secretName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update runner secrets"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to update runner secrets", nil)
 			return
 		}
 	}
@@ -174,7 +184,7 @@ func ListIntegrationSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	k8sClient, _ := GetK8sClientsForRequest(c)
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -188,7 +198,7 @@ func ListIntegrationSecrets(c *gin.Context) {
 			return
 		}
 		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read integration secrets"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read integration secrets", nil)
 		return
 	}
 
@@ -204,7 +214,7 @@ func UpdateIntegrationSecrets(c *gin.Context) {
 	projectName := c.Param("projectName")
 	k8sClient, _ := GetK8sClientsForRequest(c)
 	if k8sClient == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -213,7 +223,7 @@ func UpdateIntegrationSecrets(c *gin.Context) {
 		Data map[string]string `json:"data" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -235,12 +245,12 @@ func UpdateIntegrationSecrets(c *gin.Context) {
 		}
 		if _, err := k8sClient.CoreV1().Secrets(projectName).Create(c.Request.Context(), newSec, v1.CreateOptions{}); err != nil {
 			log.Printf("Failed to create Secret %s/%s: %v", projectName, secretName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create integration secrets"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create integration secrets", nil)
 			return
 		}
 	} else if err != nil {
 		log.Printf("Failed to get Secret %s/%s: %v", projectName, secretName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read integration secrets"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read integration secrets", nil)
 		return
 	} else {
 		sec.Type = corev1.SecretTypeOpaque
@@ -250,7 +260,7 @@ func UpdateIntegrationSecrets(c *gin.Context) {
 		}
 		if _, err := k8sClient.CoreV1().Secrets(projectName).Update(c.Request.Context(), sec, v1.UpdateOptions{}); err != nil {
 			log.Printf("Failed to update Secret %s/%s: %v", projectName, secretName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update integration secrets"})
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to update integration secrets", nil)
 			return
 		}
 	}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	authv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrSessionAccessDenied is returned by CheckSessionAccess when the caller may not get the session
+var ErrSessionAccessDenied = errors.New("not authorized to access session")
+
+// defaultSessionAccessRecheckInterval is how often open session streams re-authorize their caller
+const defaultSessionAccessRecheckInterval = time.Hour
+
+// SessionAccessRecheckInterval returns the configured recheck interval for long-lived session
+// streams (SESSION_STREAM_RECHECK_MINUTES)
+func SessionAccessRecheckInterval() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_STREAM_RECHECK_MINUTES")); raw != "" {
+		if mins, err := strconv.Atoi(raw); err == nil && mins > 0 {
+			return time.Duration(mins) * time.Minute
+		}
+	}
+	return defaultSessionAccessRecheckInterval
+}
+
+// CheckSessionAccess verifies with the caller's own clients that they may get the session and that
+// it exists, so a guessed session name is not enough to attach to its stream. It returns
+// ErrSessionAccessDenied when the access review denies the request and the API error (NotFound for a
+// missing session) otherwise.
+func CheckSessionAccess(ctx context.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project, session string) error {
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+				Verb:      "get",
+				Namespace: project,
+				Name:      session,
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("access review for session %s/%s: %w", project, session, err)
+	}
+	if !res.Status.Allowed {
+		return ErrSessionAccessDenied
+	}
+	if _, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, session, v1.GetOptions{}); err != nil {
+		if k8serrors.IsForbidden(err) {
+			return ErrSessionAccessDenied
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Session Stream Access", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+		createTestSession("s1", testNamespace, k8sUtils)
+	})
+
+	It("Should allow an existing session the caller may read", func() {
+		Expect(CheckSessionAccess(ctx, k8sUtils.K8sClient, k8sUtils.DynamicClient, testNamespace, "s1")).To(Succeed())
+	})
+
+	It("Should report a guessed session name that does not exist as not found", func() {
+		err := CheckSessionAccess(ctx, k8sUtils.K8sClient, k8sUtils.DynamicClient, testNamespace, "guessed")
+		Expect(errors.IsNotFound(err)).To(BeTrue(), "expected NotFound, got %v", err)
+	})
+
+	It("Should deny callers whose access review fails, before looking the session up", func() {
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+		Expect(CheckSessionAccess(ctx, k8sUtils.K8sClient, k8sUtils.DynamicClient, testNamespace, "s1")).To(MatchError(ErrSessionAccessDenied))
+		Expect(CheckSessionAccess(ctx, k8sUtils.K8sClient, k8sUtils.DynamicClient, testNamespace, "guessed")).To(MatchError(ErrSessionAccessDenied))
+	})
+
+	It("Should read the recheck interval from the environment", func() {
+		Expect(SessionAccessRecheckInterval()).To(Equal(time.Hour))
+		GinkgoT().Setenv("SESSION_STREAM_RECHECK_MINUTES", "5")
+		Expect(SessionAccessRecheckInterval()).To(Equal(5 * time.Minute))
+		GinkgoT().Setenv("SESSION_STREAM_RECHECK_MINUTES", "soon")
+		Expect(SessionAccessRecheckInterval()).To(Equal(time.Hour))
+	})
+})
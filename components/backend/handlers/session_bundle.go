@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// supportedSessionBundleVersions are the bundle versions import can read
+var supportedSessionBundleVersions = []string{types.SessionBundleVersion}
+
+// systemAnnotationPrefixes mark annotations managed by the platform; they are neither
+// exported nor accepted on import (e.g. runner token secrets, desired phase, temp pods).
+var systemAnnotationPrefixes = []string{"ambient-code.io/", "vteam.ambient-code/", "kubernetes.io/", "kubectl.kubernetes.io/"}
+
+// importedFromAnnotation records the source project/session of an imported session
+const importedFromAnnotation = "vteam.ambient-code/imported-from"
+
+// isPortableAnnotation reports whether an annotation is user-supplied and safe to carry across clusters
+func isPortableAnnotation(key string) bool {
+	for _, prefix := range systemAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildSessionBundle converts a session object into a portable bundle, masking sensitive
+// environment variable values the same way GetSession does.
+func buildSessionBundle(obj *unstructured.Unstructured) (types.SessionBundle, error) {
+	bundle := types.SessionBundle{
+		Kind:       types.SessionBundleKind,
+		Version:    types.SessionBundleVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Source:     types.SessionBundleSource{Project: obj.GetNamespace(), Name: obj.GetName()},
+	}
+
+	// Round-trip the raw spec so fields the typed spec does not model (autoPushOnComplete) survive
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		raw, err := json.Marshal(spec)
+		if err != nil {
+			return bundle, fmt.Errorf("marshal spec: %w", err)
+		}
+		if err := json.Unmarshal(raw, &bundle.Spec); err != nil {
+			return bundle, fmt.Errorf("decode spec: %w", err)
+		}
+	}
+
+	if len(bundle.Spec.EnvironmentVariables) > 0 {
+		patterns := sensitiveEnvPatterns()
+		keys := make([]string, 0, len(bundle.Spec.EnvironmentVariables))
+		for k := range bundle.Spec.EnvironmentVariables {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if isSensitiveEnvKey(k, patterns) {
+				bundle.Spec.EnvironmentVariables[k] = redactedEnvValue
+				bundle.Redacted = append(bundle.Redacted, "spec.environmentVariables."+k)
+			}
+		}
+	}
+
+	for k, v := range obj.GetAnnotations() {
+		if isPortableAnnotation(k) {
+			if bundle.Annotations == nil {
+				bundle.Annotations = map[string]string{}
+			}
+			bundle.Annotations[k] = v
+		}
+	}
+
+	if status, ok := obj.Object["status"].(map[string]interface{}); ok {
+		if parsed := parseStatus(status); parsed != nil {
+			bundle.RepoStatuses = parsed.ReconciledRepos
+			bundle.Result = &types.SessionBundleResult{
+				Phase:          parsed.Phase,
+				StartTime:      parsed.StartTime,
+				CompletionTime: parsed.CompletionTime,
+				Conditions:     parsed.Conditions,
+			}
+		}
+	}
+	return bundle, nil
+}
+
+// upgradeSessionBundle brings an older bundle up to the current version. New versions add a
+// case that converts from their predecessor and falls through.
+func upgradeSessionBundle(bundle *types.SessionBundle) error {
+	switch bundle.Version {
+	case types.SessionBundleVersion:
+		return nil
+	default:
+		return fmt.Errorf("unsupported bundle version %q", bundle.Version)
+	}
+}
+
+// sessionRequestFromBundle converts an imported bundle into a create request, so the session
+// goes through CreateSession's validation, project checks (prepareSessionCreate) and field whitelist. Redacted environment values and
+// platform-managed annotations are dropped; omitted lists the bundle fields that were not applied.
+func sessionRequestFromBundle(bundle *types.SessionBundle) (req types.CreateAgenticSessionRequest, omittedEnv []string, omitted []string) {
+	spec := bundle.Spec
+	req = types.CreateAgenticSessionRequest{
+		InitialPrompt:      spec.InitialPrompt,
+		DisplayName:        spec.DisplayName,
+		LLMSettings:        spec.LLMSettings,
+		Repos:              spec.Repos,
+		AutoPushOnComplete: spec.AutoPushOnComplete,
+		Priority:           spec.Priority,
+		RunnerImage:        spec.RunnerImage,
+		CostLimitUSD:       spec.CostLimitUSD,
+		WorkspaceSize:      spec.WorkspaceSize,
+	}
+	if spec.Timeout != 0 {
+		timeout := spec.Timeout
+		req.Timeout = &timeout
+	}
+	if spec.Interactive {
+		interactive := true
+		req.Interactive = &interactive
+	}
+
+	// Drop redacted values rather than creating the session with placeholder secrets
+	redacted := make(map[string]bool, len(bundle.Redacted))
+	for _, field := range bundle.Redacted {
+		redacted[field] = true
+	}
+	for k, v := range spec.EnvironmentVariables {
+		if v == redactedEnvValue || redacted["spec.environmentVariables."+k] {
+			omittedEnv = append(omittedEnv, k)
+			continue
+		}
+		if req.EnvironmentVariables == nil {
+			req.EnvironmentVariables = map[string]string{}
+		}
+		req.EnvironmentVariables[k] = v
+	}
+	sort.Strings(omittedEnv)
+
+	for k, v := range bundle.Annotations {
+		if isPortableAnnotation(k) {
+			if req.Annotations == nil {
+				req.Annotations = map[string]string{}
+			}
+			req.Annotations[k] = v
+		}
+	}
+
+	// Not accepted by CreateSession: resource overrides are set by project admins and the
+	// workflow is selected on the new session after it starts.
+	if spec.ResourceOverrides != nil {
+		omitted = append(omitted, "spec.resourceOverrides")
+	}
+	if spec.ActiveWorkflow != nil {
+		omitted = append(omitted, "spec.activeWorkflow")
+	}
+	return req, omittedEnv, omitted
+}
+
+// ExportSession handles GET /api/projects/:projectName/agentic-sessions/:sessionName/bundle
+// Returns a versioned, portable JSON bundle of the session definition (no workspace content).
+func ExportSession(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		log.Printf("Failed to get agentic session %s in project %s for export: %v", sessionName, project, err)
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
+		return
+	}
+
+	// Bundles carry the prompt itself; the prompt ConfigMap is not part of the export
+	if err := inlineSessionPrompt(c, item); err != nil {
+		log.Printf("Failed to resolve prompt of agentic session %s/%s for export: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read session prompt", nil)
+		return
+	}
+
+	bundle, err := buildSessionBundle(item)
+	if err != nil {
+		log.Printf("Failed to export agentic session %s/%s: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to export session", nil)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", sessionName+"-bundle.json"))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportSession handles POST /api/projects/:projectName/agentic-sessions/import[?queue=true]
+// Creates a new Pending session from an exported bundle, renaming on conflict like CloneSession.
+// A project at its concurrent session limit answers 429, or queues the session with queue=true.
+// The request goes through the same prepareSessionCreate checks and defaults as CreateSession;
+// redacted environment variables and fields CreateSession does not accept are omitted and listed
+// in the response.
+func ImportSession(c *gin.Context) {
+	project := c.GetString("project")
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User token required", nil)
+		c.Abort()
+		return
+	}
+
+	var bundle types.SessionBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid session bundle", nil)
+		return
+	}
+	if bundle.Kind != types.SessionBundleKind {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation,
+			fmt.Sprintf("Invalid bundle kind %q: expected %s", bundle.Kind, types.SessionBundleKind), nil)
+		return
+	}
+	if err := upgradeSessionBundle(&bundle); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Unsupported session bundle version",
+			gin.H{"version": bundle.Version, "supportedVersions": supportedSessionBundleVersions})
+		return
+	}
+	req, omittedEnv, omitted := sessionRequestFromBundle(&bundle)
+	chargeback, ok := prepareSessionCreate(c, reqK8s, k8sDyn, project, &req)
+	if !ok {
+		return
+	}
+	// Over ProjectSettings maxConcurrentSessions the import is rejected, or queued with ?queue=true
+	queue := strings.EqualFold(c.Query("queue"), "true")
+	queued := false
+	if !checkConcurrentSessionLimit(c, k8sDyn, project, "", queue) {
+		if !queue {
+			return
+		}
+		queued = true
+	}
+
+	baseName := strings.TrimSpace(bundle.Source.Name)
+	if baseName == "" || len(validation.IsDNS1123Subdomain(baseName)) > 0 {
+		baseName = fmt.Sprintf("agentic-session-%d", time.Now().Unix())
+	}
+	finalName, conflicted := uniqueSessionName(c.Request.Context(), k8sDyn, project, baseName)
+	if conflicted {
+		if strings.TrimSpace(req.DisplayName) != "" {
+			req.DisplayName = fmt.Sprintf("%s (Duplicate)", req.DisplayName)
+		} else {
+			req.DisplayName = fmt.Sprintf("%s (Duplicate)", finalName)
+		}
+	}
+	if bundle.Source.Project != "" && bundle.Source.Name != "" {
+		if req.Annotations == nil {
+			req.Annotations = map[string]string{}
+		}
+		req.Annotations[importedFromAnnotation] = bundle.Source.Project + "/" + bundle.Source.Name
+	}
+
+	session := buildSessionObject(c, project, finalName, &req)
+	setChargebackLabels(session["metadata"].(map[string]interface{}), chargeback)
+	obj := &unstructured.Unstructured{Object: session}
+
+	created, err := createSessionWithPrompt(c.Request.Context(), k8sDyn, obj, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("Failed to import agentic session into project %s: %v", project, err)
+		respondK8sError(c, err, "Project not found", "Failed to import agentic session")
+		return
+	}
+
+	warnings := provisionNewSessionRunnerAuth(c, k8sDyn, project, finalName)
+	if queued {
+		queueNewSession(c.Request.Context(), project, finalName)
+	}
+
+	resp := gin.H{
+		"message": "Agentic session imported successfully",
+		"name":    finalName,
+		"uid":     created.GetUID(),
+		"renamed": conflicted,
+		"queued":  queued,
+		"ready":   len(warnings) == 0,
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	if len(omittedEnv) > 0 {
+		resp["omittedEnvironmentVariables"] = omittedEnv
+	}
+	if len(omitted) > 0 {
+		resp["omittedFields"] = omitted
+	}
+	c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"ambient-code-backend/httpclient"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// changesWorkerCount is the number of concurrent content service requests issued by GetSessionChanges
+const changesWorkerCount = 4
+
+// sessionArtifactsDir is the workspace folder, outside every repo, where sessions write artifacts
+const sessionArtifactsDir = "artifacts"
+
+// Repo states reported by GetSessionChanges
+const (
+	repoChangeStateChanged   = "changed"
+	repoChangeStateClean     = "clean"
+	repoChangeStateNotCloned = "notCloned"
+	repoChangeStateError     = "error"
+)
+
+// RepoChanges is the uncommitted change summary of one session repo
+type RepoChanges struct {
+	RepoIndex    int    `json:"repoIndex"`
+	URL          string `json:"url,omitempty"`
+	Path         string `json:"path"`
+	State        string `json:"state"`
+	FilesAdded   int    `json:"filesAdded"`
+	FilesRemoved int    `json:"filesRemoved"`
+	TotalAdded   int    `json:"totalAdded"`
+	TotalRemoved int    `json:"totalRemoved"`
+	Error        string `json:"error,omitempty"`
+}
+
+// WorkspaceFileChange is a file written to the workspace outside the repos
+type WorkspaceFileChange struct {
+	Path       string `json:"path"`
+	IsDir      bool   `json:"isDir"`
+	Size       int64  `json:"size"`
+	ModifiedAt string `json:"modifiedAt,omitempty"`
+}
+
+// SessionChangeTotals adds up a SessionChanges
+type SessionChangeTotals struct {
+	Repos         int   `json:"repos"`
+	ChangedRepos  int   `json:"changedRepos"`
+	FilesAdded    int   `json:"filesAdded"`
+	FilesRemoved  int   `json:"filesRemoved"`
+	TotalAdded    int   `json:"totalAdded"`
+	TotalRemoved  int   `json:"totalRemoved"`
+	ArtifactFiles int   `json:"artifactFiles"`
+	ArtifactBytes int64 `json:"artifactBytes"`
+}
+
+// SessionChanges is everything a session changed in its workspace, grouped by repo
+type SessionChanges struct {
+	Repos     []RepoChanges         `json:"repos"`
+	Artifacts []WorkspaceFileChange `json:"artifacts"`
+	// ArtifactsError is set when the artifacts folder could not be listed
+	ArtifactsError string              `json:"artifactsError,omitempty"`
+	Totals         SessionChangeTotals `json:"totals"`
+	PodSpawned     bool                `json:"podSpawned,omitempty"`
+}
+
+// fetchContentJSON GETs a content service endpoint and decodes its JSON body into out, returning the
+// response status
+func fetchContentJSON(ctx context.Context, content contentEndpoint, headers http.Header, endpoint string, query url.Values, out interface{}) (int, error) {
+	req, err := content.newRequest(ctx, http.MethodGet, endpoint, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header = headers.Clone()
+	resp, err := httpclient.Do(req, httpclient.Read)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("content service returned %d", resp.StatusCode)
+	}
+	return resp.StatusCode, json.Unmarshal(body, out)
+}
+
+// repoChangesThroughContent reads one repo's diff summary from the content service's /content/git-status.
+// A folder that is missing or not a git repository is reported as notCloned.
+func repoChangesThroughContent(ctx context.Context, content contentEndpoint, headers http.Header, t repoPushTarget) RepoChanges {
+	rc := RepoChanges{RepoIndex: t.Index, URL: t.InputURL, Path: t.RepoPath}
+	var status struct {
+		Initialized  bool `json:"initialized"`
+		HasChanges   bool `json:"hasChanges"`
+		FilesAdded   int  `json:"filesAdded"`
+		FilesRemoved int  `json:"filesRemoved"`
+		TotalAdded   int  `json:"totalAdded"`
+		TotalRemoved int  `json:"totalRemoved"`
+	}
+	if _, err := fetchContentJSON(ctx, content, headers, "/content/git-status", url.Values{"path": {t.RepoPath}}, &status); err != nil {
+		log.Printf("repoChangesThroughContent: repo %d (%s): %v", t.Index, t.RepoPath, err)
+		rc.State, rc.Error = repoChangeStateError, "Failed to read repository changes"
+		return rc
+	}
+	switch {
+	case !status.Initialized:
+		rc.State = repoChangeStateNotCloned
+	case status.HasChanges:
+		rc.State = repoChangeStateChanged
+	default:
+		rc.State = repoChangeStateClean
+	}
+	rc.FilesAdded, rc.FilesRemoved = status.FilesAdded, status.FilesRemoved
+	rc.TotalAdded, rc.TotalRemoved = status.TotalAdded, status.TotalRemoved
+	return rc
+}
+
+// artifactChangesThroughContent lists the workspace artifacts folder. A missing folder has no
+// artifacts; other failures are returned so the caller can report them.
+func artifactChangesThroughContent(ctx context.Context, content contentEndpoint, headers http.Header, session string) ([]WorkspaceFileChange, error) {
+	var listing struct {
+		Items []WorkspaceFileChange `json:"items"`
+	}
+	dir := fmt.Sprintf("/sessions/%s/workspace/%s", session, sessionArtifactsDir)
+	status, err := fetchContentJSON(ctx, content, headers, "/content/list", url.Values{"path": {dir}}, &listing)
+	if status == http.StatusNotFound {
+		return []WorkspaceFileChange{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Paths are reported relative to the workspace, like the workspace file endpoints take them
+	prefix := fmt.Sprintf("/sessions/%s/workspace/", session)
+	for i := range listing.Items {
+		listing.Items[i].Path = strings.TrimPrefix(listing.Items[i].Path, prefix)
+	}
+	return listing.Items, nil
+}
+
+// GetSessionChanges summarizes everything a session changed, for review before pushing.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/changes
+// Every spec.repos entry is checked concurrently through the content service and reported with a
+// state (changed, clean, notCloned or error); one failing repo does not fail the call. Entries of
+// the workspace artifacts folder are listed with their sizes.
+func GetSessionChanges(c *gin.Context) {
+	project := c.Param("projectName")
+	session := c.Param("sessionName")
+
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to read session")
+		return
+	}
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	repos, _ := spec["repos"].([]interface{})
+	targets := make([]repoPushTarget, 0, len(repos))
+	for i, r := range repos {
+		rm, _ := r.(map[string]interface{})
+		targets = append(targets, resolveRepoPushTarget(session, i, rm))
+	}
+
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
+		return
+	}
+	headers := http.Header{}
+	forwardCallerToken(c, headers)
+	content.sign(headers)
+
+	ctx := c.Request.Context()
+	result := SessionChanges{Repos: make([]RepoChanges, len(targets)), PodSpawned: content.PodSpawned}
+	var artifactsErr error
+
+	// Job len(targets) lists the artifacts; the others each read one repo
+	workerCount := changesWorkerCount
+	if len(targets)+1 < workerCount {
+		workerCount = len(targets) + 1
+	}
+	workChan := make(chan int, len(targets)+1)
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range workChan {
+				if i == len(targets) {
+					result.Artifacts, artifactsErr = artifactChangesThroughContent(ctx, content, headers, session)
+					continue
+				}
+				result.Repos[i] = repoChangesThroughContent(ctx, content, headers, targets[i])
+			}
+		}()
+	}
+	for i := 0; i <= len(targets); i++ {
+		workChan <- i
+	}
+	close(workChan)
+	wg.Wait()
+
+	if artifactsErr != nil {
+		log.Printf("GetSessionChanges: failed to list artifacts for %s/%s: %v", project, session, artifactsErr)
+		result.Artifacts, result.ArtifactsError = []WorkspaceFileChange{}, "Failed to list workspace artifacts"
+	}
+
+	result.Totals.Repos = len(result.Repos)
+	for _, r := range result.Repos {
+		if r.State == repoChangeStateChanged {
+			result.Totals.ChangedRepos++
+		}
+		result.Totals.FilesAdded += r.FilesAdded
+		result.Totals.FilesRemoved += r.FilesRemoved
+		result.Totals.TotalAdded += r.TotalAdded
+		result.Totals.TotalRemoved += r.TotalRemoved
+	}
+	for _, f := range result.Artifacts {
+		if !f.IsDir {
+			result.Totals.ArtifactFiles++
+			result.Totals.ArtifactBytes += f.Size
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// costLimitStopReason is the status.stoppedReason of a session stopped for exceeding spec.costLimitUSD
+const costLimitStopReason = "costLimit"
+
+// numberValue converts a number read from an unstructured object, which may have been decoded as
+// an integer or a float depending on how it was written
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// projectSessionCostLimits returns spec.sessionCostLimitUSD (the default) and
+// spec.maxSessionCostLimitUSD (the ceiling) from the project's ProjectSettings; 0 means unset
+func projectSessionCostLimits(ctx context.Context, dyn dynamic.Interface, project string) (float64, float64) {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, not applying session cost limits: %v", project, err)
+		}
+		return 0, 0
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	def, _ := numberValue(spec["sessionCostLimitUSD"])
+	max, _ := numberValue(spec["maxSessionCostLimitUSD"])
+	if def < 0 {
+		def = 0
+	}
+	if max < 0 {
+		max = 0
+	}
+	return def, max
+}
+
+// resolveSessionCostLimit applies the project's cost limits to a requested spec.costLimitUSD. An
+// unset limit takes the project default, or the ceiling when there is no default, so a project
+// with a ceiling has no uncapped sessions. A limit above the ceiling writes a 400 and returns false.
+func resolveSessionCostLimit(c *gin.Context, dyn dynamic.Interface, project string, requested *float64) (*float64, bool) {
+	def, max := projectSessionCostLimits(c.Request.Context(), dyn, project)
+	if requested == nil {
+		switch {
+		case def > 0 && (max == 0 || def <= max):
+			return &def, true
+		case max > 0:
+			return &max, true
+		}
+		return nil, true
+	}
+	if max > 0 && *requested > max {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation,
+			fmt.Sprintf("costLimitUSD must not exceed the project limit of %g", max),
+			gin.H{"maxSessionCostLimitUSD": max})
+		return nil, false
+	}
+	return requested, true
+}
+
+// sessionCostLimitExceeded reports whether obj has a spec.costLimitUSD that its status.totalCostUSD
+// is above. It only looks at obj, so UpdateSessionStatus can check it on the object it just merged.
+func sessionCostLimitExceeded(obj *unstructured.Unstructured) bool {
+	limit, ok := numberValue(unstructuredField(obj, "spec", "costLimitUSD"))
+	if !ok || limit <= 0 {
+		return false
+	}
+	cost, ok := numberValue(unstructuredField(obj, "status", "totalCostUSD"))
+	return ok && cost > limit
+}
+
+func unstructuredField(obj *unstructured.Unstructured, fields ...string) interface{} {
+	v, _, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	return v
+}
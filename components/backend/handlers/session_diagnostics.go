@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SessionDiagnosticsJob describes the runner Job of a session as found in the cluster
+type SessionDiagnosticsJob struct {
+	Name      string `json:"name"`
+	Exists    bool   `json:"exists"`
+	Active    int32  `json:"active"`
+	Succeeded int32  `json:"succeeded"`
+	Failed    int32  `json:"failed"`
+}
+
+// SessionDiagnosticsPod describes one runner pod of a session
+type SessionDiagnosticsPod struct {
+	Name           string   `json:"name"`
+	Phase          string   `json:"phase"`
+	Ready          bool     `json:"ready"`
+	Terminating    bool     `json:"terminating,omitempty"`
+	WaitingReasons []string `json:"waitingReasons,omitempty"`
+}
+
+// SessionInconsistency is a mismatch between the session's recorded phase and its workload
+type SessionInconsistency struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SessionDiagnostics is the response of GetSessionDiagnostics
+type SessionDiagnostics struct {
+	Phase           string                  `json:"phase"`
+	Job             SessionDiagnosticsJob   `json:"job"`
+	Pods            []SessionDiagnosticsPod `json:"pods"`
+	Inconsistencies []SessionInconsistency  `json:"inconsistencies"`
+	Consistent      bool                    `json:"consistent"`
+}
+
+// GetSessionDiagnostics cross-checks a session's phase against its runner Job and pods
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/diagnostics
+func GetSessionDiagnostics(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	ctx := c.Request.Context()
+	session, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
+		return
+	}
+
+	phase, _, _ := unstructured.NestedString(session.Object, "status", "phase")
+	jobName, _, _ := unstructured.NestedString(session.Object, "status", "jobName")
+	if jobName == "" {
+		jobName = fmt.Sprintf("%s-job", sessionName)
+	}
+
+	diag := SessionDiagnostics{Phase: phase, Job: SessionDiagnosticsJob{Name: jobName}, Pods: []SessionDiagnosticsPod{}}
+	job, err := k8sClt.BatchV1().Jobs(project).Get(ctx, jobName, v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("GetSessionDiagnostics: failed to get job %s/%s: %v", project, jobName, err)
+		respondK8sError(c, err, "Job not found", "Failed to get runner job")
+		return
+	}
+	if err != nil {
+		job = nil
+	} else {
+		diag.Job = SessionDiagnosticsJob{Name: jobName, Exists: true, Active: job.Status.Active, Succeeded: job.Status.Succeeded, Failed: job.Status.Failed}
+	}
+
+	// Pods are listed even without a Job: orphans left behind by a deleted Job are worth reporting
+	pods, err := k8sClt.CoreV1().Pods(project).List(ctx, v1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", jobName)})
+	if err != nil {
+		log.Printf("GetSessionDiagnostics: failed to list pods for job %s/%s: %v", project, jobName, err)
+		respondK8sError(c, err, "Pods not found", "Failed to list runner pods")
+		return
+	}
+	for i := range pods.Items {
+		diag.Pods = append(diag.Pods, diagnosticsPod(&pods.Items[i]))
+	}
+
+	diag.Inconsistencies = sessionInconsistencies(phase, job, diag.Pods)
+	diag.Consistent = len(diag.Inconsistencies) == 0
+	c.JSON(http.StatusOK, diag)
+}
+
+func diagnosticsPod(pod *corev1.Pod) SessionDiagnosticsPod {
+	p := SessionDiagnosticsPod{Name: pod.Name, Phase: string(pod.Status.Phase), Terminating: pod.DeletionTimestamp != nil}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			p.Ready = cond.Status == corev1.ConditionTrue
+		}
+	}
+	for _, cs := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			p.WaitingReasons = append(p.WaitingReasons, fmt.Sprintf("%s: %s", cs.Name, cs.State.Waiting.Reason))
+		}
+	}
+	return p
+}
+
+// sessionInconsistencies lists the ways the recorded phase disagrees with the Job (nil when it does
+// not exist) and its pods
+func sessionInconsistencies(phase string, job *batchv1.Job, pods []SessionDiagnosticsPod) []SessionInconsistency {
+	out := []SessionInconsistency{}
+	add := func(code, format string, args ...interface{}) {
+		out = append(out, SessionInconsistency{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	running := 0
+	for _, p := range pods {
+		if p.Phase == string(corev1.PodRunning) && !p.Terminating {
+			running++
+		}
+	}
+	jobExists := job != nil
+
+	switch phase {
+	case "Running", "Creating":
+		if !jobExists {
+			add("JobMissing", "phase is %s but the runner job does not exist", phase)
+			break
+		}
+		if job.Status.Active == 0 && job.Status.Succeeded > 0 {
+			add("JobSucceeded", "phase is %s but the runner job already succeeded", phase)
+		} else if job.Status.Active == 0 && job.Status.Failed > 0 {
+			add("JobFailed", "phase is %s but the runner job failed", phase)
+		}
+		if phase == "Running" && running == 0 {
+			add("NoRunningPods", "phase is Running but no runner pod is running")
+		}
+	case "Pending", "Queued":
+		if jobExists {
+			add("UnexpectedJob", "phase is %s but runner job %s already exists", phase, job.Name)
+		}
+	case "Completed", "Failed", "Stopped", "Error":
+		if jobExists && job.Status.Active > 0 {
+			add("RunnerStillActive", "phase is %s but runner job %s is still active", phase, job.Name)
+		} else if running > 0 {
+			add("RunnerStillActive", "phase is %s but %d runner pod(s) are still running", phase, running)
+		}
+	}
+	if !jobExists && len(pods) > 0 {
+		add("OrphanedPods", "%d runner pod(s) exist without their job", len(pods))
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Session Diagnostics", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	createSession := func(name, phase string) {
+		session := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": name, "namespace": testNamespace},
+			"status":     map[string]interface{}{"phase": phase},
+		}}
+		_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, session, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createJob := func(session string, active int32) {
+		_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{Name: session + "-job", Namespace: testNamespace},
+			Status:     batchv1.JobStatus{Active: active},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createPod := func(session string, phase corev1.PodPhase) {
+		_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: session + "-job-abcde", Namespace: testNamespace, Labels: map[string]string{"job-name": session + "-job"}},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "ambient-code-runner", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}}},
+			},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	diagnose := func(session string) SessionDiagnostics {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/"+session+"/diagnostics", nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: session}}
+		GetSessionDiagnostics(c)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var diag SessionDiagnostics
+		httpUtils.GetResponseJSON(&diag)
+		return diag
+	}
+
+	codes := func(diag SessionDiagnostics) []string {
+		out := []string{}
+		for _, i := range diag.Inconsistencies {
+			out = append(out, i.Code)
+		}
+		return out
+	}
+
+	It("Should report a Running session without a Job", func() {
+		createSession("lost", "Running")
+
+		diag := diagnose("lost")
+
+		Expect(diag.Consistent).To(BeFalse())
+		Expect(diag.Job.Exists).To(BeFalse())
+		Expect(codes(diag)).To(Equal([]string{"JobMissing"}))
+	})
+
+	It("Should report a Running session whose pod is not running", func() {
+		createSession("stuck", "Running")
+		createJob("stuck", 1)
+		createPod("stuck", corev1.PodPending)
+
+		diag := diagnose("stuck")
+
+		Expect(codes(diag)).To(Equal([]string{"NoRunningPods"}))
+		Expect(diag.Pods).To(HaveLen(1))
+		Expect(diag.Pods[0].WaitingReasons).To(Equal([]string{"ambient-code-runner: ImagePullBackOff"}))
+	})
+
+	It("Should report a finished session whose runner is still active", func() {
+		createSession("zombie", "Completed")
+		createJob("zombie", 1)
+
+		Expect(codes(diagnose("zombie"))).To(Equal([]string{"RunnerStillActive"}))
+	})
+
+	It("Should report nothing for a consistent session", func() {
+		createSession("healthy", "Running")
+		createJob("healthy", 1)
+		createPod("healthy", corev1.PodRunning)
+
+		diag := diagnose("healthy")
+
+		Expect(diag.Consistent).To(BeTrue())
+		Expect(diag.Inconsistencies).To(BeEmpty())
+		Expect(diag.Job.Active).To(Equal(int32(1)))
+	})
+
+	It("Should return 404 for unknown sessions", func() {
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/missing/diagnostics", nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: "missing"}}
+		GetSessionDiagnostics(c)
+		httpUtils.AssertHTTPStatus(http.StatusNotFound)
+	})
+})
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// resolveGitWorkspacePath returns the absolute workspace path a git operation runs in. An explicit
+// path wins; otherwise repoIndex selects spec.repos[i] and its folder is derived from the input URL
+// exactly as PushSessionRepo does. With neither supplied the request is rejected instead of falling
+// back to a fixed folder. On failure it writes the error response and returns false.
+func resolveGitWorkspacePath(c *gin.Context, project, session, path string, repoIndex *int) (string, bool) {
+	if path = strings.TrimSpace(path); path != "" {
+		return fmt.Sprintf("/sessions/%s/workspace/%s", session, path), true
+	}
+	if repoIndex == nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "path or repoIndex required", nil)
+		return "", false
+	}
+
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return "", false
+	}
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to read session")
+		return "", false
+	}
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	repos, _ := spec["repos"].([]interface{})
+	if *repoIndex < 0 || *repoIndex >= len(repos) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid repo index", gin.H{"repoCount": len(repos)})
+		return "", false
+	}
+	rm, _ := repos[*repoIndex].(map[string]interface{})
+	return resolveRepoPushTarget(session, *repoIndex, rm).RepoPath, true
+}
+
+// queryRepoIndex parses the optional repoIndex query parameter. It writes a 400 and returns false
+// when the value is not an integer.
+func queryRepoIndex(c *gin.Context) (*int, bool) {
+	raw := strings.TrimSpace(c.Query("repoIndex"))
+	if raw == "" {
+		return nil, true
+	}
+	idx, err := strconv.Atoi(raw)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "repoIndex must be an integer", nil)
+		return nil, false
+	}
+	return &idx, true
+}
+
+// sessionRemoteAnnotationKey derives the annotation key segment for a workspace path; "::" stands
+// for "/" so hyphens in the path stay unambiguous
+func sessionRemoteAnnotationKey(path string) string {
+	return strings.ReplaceAll(path, "/", "::")
+}
+
+// persistSessionRemote records the remote and branch configured for a workspace directory in the
+// session's ambient-code.io/remote-<path>-url and -branch annotations, along with any extra
+// annotations
+func persistSessionRemote(ctx context.Context, dyn dynamic.Interface, project, session, path, remoteURL, branch string, extra map[string]string) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	key := sessionRemoteAnnotationKey(path)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		item, err := dyn.Resource(gvr).Namespace(project).Get(ctx, session, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		anns := item.GetAnnotations()
+		if anns == nil {
+			anns = map[string]string{}
+		}
+		anns[fmt.Sprintf("ambient-code.io/remote-%s-url", key)] = remoteURL
+		anns[fmt.Sprintf("ambient-code.io/remote-%s-branch", key)] = branch
+		for k, v := range extra {
+			anns[k] = v
+		}
+		item.SetAnnotations(anns)
+		_, err = dyn.Resource(gvr).Namespace(project).Update(ctx, item, v1.UpdateOptions{})
+		return err
+	})
+}
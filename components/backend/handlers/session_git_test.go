@@ -0,0 +1,228 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Session Git Paths", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		testSession   string
+		testToken     string
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+		testNamespace = "test-project-" + suffix
+		testSession = "test-session-" + suffix
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+		}, v1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+
+		createTestSession(testSession, testNamespace, k8sUtils)
+		obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/api.git"}},
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/web"}},
+		}, "spec", "repos")).To(Succeed())
+		_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	resolve := func(path string, repoIndex *int) (string, bool) {
+		context := httpUtils.CreateTestGinContext("GET", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/git/status", testNamespace, testSession), nil)
+		httpUtils.SetAuthHeader(testToken)
+		return resolveGitWorkspacePath(context, testNamespace, testSession, path, repoIndex)
+	}
+
+	It("Should derive the folder of the selected repo from its input url", func() {
+		idx := 1
+		absPath, ok := resolve("", &idx)
+
+		Expect(ok).To(BeTrue())
+		Expect(absPath).To(Equal(fmt.Sprintf("/sessions/%s/workspace/web", testSession)))
+	})
+
+	It("Should prefer an explicit path over repoIndex", func() {
+		idx := 1
+		absPath, ok := resolve("artifacts", &idx)
+
+		Expect(ok).To(BeTrue())
+		Expect(absPath).To(Equal(fmt.Sprintf("/sessions/%s/workspace/artifacts", testSession)))
+	})
+
+	It("Should reject an out-of-range repoIndex", func() {
+		idx := 2
+		_, ok := resolve("", &idx)
+
+		Expect(ok).To(BeFalse())
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "invalid repo index")
+	})
+
+	It("Should reject git push when neither path nor repoIndex is given", func() {
+		context := httpUtils.CreateTestGinContext("POST", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/git/push", testNamespace, testSession),
+			map[string]interface{}{"branch": "main"})
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: testSession}}
+
+		GitPushSession(context)
+
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "path or repoIndex required")
+	})
+
+	It("Should reject a non-integer repoIndex on git status", func() {
+		context := httpUtils.CreateTestGinContext("GET", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/git/status?repoIndex=web", testNamespace, testSession), nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: testSession}}
+
+		GetGitStatus(context)
+
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "repoIndex must be an integer")
+	})
+
+	It("Should forward the session owner's credential for each repo host on git pull", func() {
+		obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/api.git"}},
+			map[string]interface{}{
+				"input":  map[string]interface{}{"url": "https://gitlab.example.com/team/web.git"},
+				"output": map[string]interface{}{"url": "https://gitlab.example.com/fork/web.git"},
+			},
+		}, "spec", "repos")).To(Succeed())
+		Expect(unstructured.SetNestedField(obj.Object, "owner-1", "spec", "userContext", "userId")).To(Succeed())
+		_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "gitlab-user-tokens", Namespace: testNamespace},
+			Data:       map[string][]byte{"owner-1": []byte("gl-token")},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = k8sUtils.K8sClient.CoreV1().Services(testNamespace).Create(ctx, &corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-content-" + testSession, Namespace: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		var gotCreds map[string]map[string]string
+		var gotGitHubToken string
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotGitHubToken = r.Header.Get("X-GitHub-Token")
+			_ = json.Unmarshal([]byte(r.Header.Get("X-Git-Credentials")), &gotCreds)
+			_, _ = w.Write([]byte(`{"message":"pulled successfully"}`))
+		}))
+		DeferCleanup(upstream.Close)
+		previous := ContentResolver
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+		DeferCleanup(func() { ContentResolver = previous })
+
+		idx := 1
+		context := httpUtils.CreateTestGinContext("POST", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/git/pull", testNamespace, testSession),
+			map[string]interface{}{"repoIndex": idx})
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: testSession}}
+
+		GitPullSession(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		Expect(gotGitHubToken).To(Equal("fake-github-token"))
+		Expect(gotCreds).To(Equal(map[string]map[string]string{
+			"github.com":         {"token": "fake-github-token"},
+			"gitlab.example.com": {"token": "gl-token", "scheme": "oauth2"},
+		}))
+	})
+
+	It("Should combine the changes of every repo and the artifacts folder in one response", func() {
+		obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/api"}},
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/web"}},
+			map[string]interface{}{"input": map[string]interface{}{"url": "https://github.com/o/docs"}},
+		}, "spec", "repos")).To(Succeed())
+		_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.K8sClient.CoreV1().Services(testNamespace).Create(ctx, &corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-content-" + testSession, Namespace: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		workspace := "/sessions/" + testSession + "/workspace/"
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path + "?" + r.URL.Query().Get("path") {
+			case "/content/git-status?" + workspace + "api":
+				_, _ = w.Write([]byte(`{"initialized":true,"hasChanges":true,"filesAdded":2,"filesRemoved":1,"totalAdded":30,"totalRemoved":4}`))
+			case "/content/git-status?" + workspace + "web":
+				_, _ = w.Write([]byte(`{"initialized":true,"hasChanges":false}`))
+			case "/content/git-status?" + workspace + "docs":
+				_, _ = w.Write([]byte(`{"initialized":false,"hasChanges":false}`))
+			case "/content/list?" + workspace + "artifacts":
+				_, _ = w.Write([]byte(`{"items":[{"name":"report.md","path":"` + workspace + `artifacts/report.md","isDir":false,"size":1200},` +
+					`{"name":"img","path":"` + workspace + `artifacts/img","isDir":true,"size":4096}]}`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		DeferCleanup(upstream.Close)
+		previous := ContentResolver
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+		DeferCleanup(func() { ContentResolver = previous })
+
+		context := httpUtils.CreateTestGinContext("GET", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/changes", testNamespace, testSession), nil)
+		httpUtils.SetAuthHeader(testToken)
+		context.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: testSession}}
+
+		GetSessionChanges(context)
+
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var changes SessionChanges
+		httpUtils.GetResponseJSON(&changes)
+		Expect(changes.Repos).To(HaveLen(3))
+		Expect(changes.Repos[0]).To(Equal(RepoChanges{RepoIndex: 0, URL: "https://github.com/o/api", Path: workspace + "api",
+			State: "changed", FilesAdded: 2, FilesRemoved: 1, TotalAdded: 30, TotalRemoved: 4}))
+		Expect(changes.Repos[1].State).To(Equal("clean"))
+		Expect(changes.Repos[2].State).To(Equal("notCloned"))
+		Expect(changes.Artifacts).To(ConsistOf(
+			WorkspaceFileChange{Path: "artifacts/report.md", Size: 1200},
+			WorkspaceFileChange{Path: "artifacts/img", IsDir: true, Size: 4096},
+		))
+		Expect(changes.Totals).To(Equal(SessionChangeTotals{Repos: 3, ChangedRepos: 1, FilesAdded: 2, FilesRemoved: 1,
+			TotalAdded: 30, TotalRemoved: 4, ArtifactFiles: 1, ArtifactBytes: 1200}))
+	})
+})
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// maxSessionK8sResourcesBatch is the largest number of sessions per batch request
+const maxSessionK8sResourcesBatch = 50
+
+// SessionK8sResourcesBatchRequest is the body of POST .../agentic-sessions-k8s-resources/batch
+type SessionK8sResourcesBatchRequest struct {
+	SessionNames []string `json:"sessionNames"`
+}
+
+// SessionK8sResourcesBatchItem is one session's result; Resources has the GetSessionK8sResources
+// response, Error is set instead when the session could not be found
+type SessionK8sResourcesBatchItem struct {
+	SessionName string                 `json:"sessionName"`
+	Resources   map[string]interface{} `json:"resources,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+}
+
+// sessionJobName returns the runner Job name recorded in the session status, or the default
+func sessionJobName(session *unstructured.Unstructured) string {
+	jobName, _, _ := unstructured.NestedString(session.Object, "status", "jobName")
+	if jobName == "" {
+		jobName = fmt.Sprintf("%s-job", session.GetName())
+	}
+	return jobName
+}
+
+// sessionPVCName returns the name of the session's workspace PVC
+func sessionPVCName(sessionName string) string {
+	return fmt.Sprintf("ambient-workspace-%s", sessionName)
+}
+
+// podResourceInfo describes a pod and its containers for the k8s-resources responses
+func podResourceInfo(pod *corev1.Pod) map[string]interface{} {
+	// Check if pod is terminating (has DeletionTimestamp)
+	podPhase := string(pod.Status.Phase)
+	if pod.DeletionTimestamp != nil {
+		podPhase = "Terminating"
+	}
+
+	containerInfos := []map[string]interface{}{}
+	for _, cs := range pod.Status.ContainerStatuses {
+		state := "Unknown"
+		var exitCode *int32
+		var reason string
+		if cs.State.Running != nil {
+			state = "Running"
+			// If pod is terminating but container still shows running, mark it as terminating
+			if pod.DeletionTimestamp != nil {
+				state = "Terminating"
+			}
+		} else if cs.State.Terminated != nil {
+			state = "Terminated"
+			exitCode = &cs.State.Terminated.ExitCode
+			reason = cs.State.Terminated.Reason
+		} else if cs.State.Waiting != nil {
+			state = "Waiting"
+			reason = cs.State.Waiting.Reason
+		}
+		containerInfos = append(containerInfos, map[string]interface{}{
+			"name":     cs.Name,
+			"state":    state,
+			"exitCode": exitCode,
+			"reason":   reason,
+		})
+	}
+	return map[string]interface{}{
+		"name":       pod.Name,
+		"phase":      podPhase,
+		"containers": containerInfos,
+	}
+}
+
+// sessionK8sResources builds the k8s-resources response of a session from its runner Job (and the
+// error looking it up), the Job's pods, its temp content pod and its workspace PVC. tempPod and pvc
+// are nil when they do not exist.
+func sessionK8sResources(sessionName, jobName string, job *batchv1.Job, jobErr error, jobPods []corev1.Pod, tempPod *corev1.Pod, pvc *corev1.PersistentVolumeClaim) map[string]interface{} {
+	result := map[string]interface{}{}
+
+	if jobErr == nil {
+		result["jobName"] = jobName
+		jobStatus := "Unknown"
+		if job.Status.Active > 0 {
+			jobStatus = "Active"
+		} else if job.Status.Succeeded > 0 {
+			jobStatus = "Succeeded"
+		} else if job.Status.Failed > 0 {
+			jobStatus = "Failed"
+		}
+		result["jobStatus"] = jobStatus
+		result["jobConditions"] = job.Status.Conditions
+	} else if !errors.IsNotFound(jobErr) {
+		// Other error - still show job name but with error status
+		result["jobName"] = jobName
+		result["jobStatus"] = "Error"
+		log.Printf("GetSessionK8sResources: Error getting job %s: %v", jobName, jobErr)
+	}
+	// Job not found - don't include jobName or jobStatus in result
+
+	podInfos := []map[string]interface{}{}
+	if jobErr == nil {
+		for i := range jobPods {
+			podInfos = append(podInfos, podResourceInfo(&jobPods[i]))
+		}
+	}
+	if tempPod != nil {
+		info := podResourceInfo(tempPod)
+		info["isTempPod"] = true
+		podInfos = append(podInfos, info)
+	}
+	result["pods"] = podInfos
+
+	result["pvcName"] = sessionPVCName(sessionName)
+	if pvc != nil {
+		result["pvcExists"] = true
+		if storage, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			result["pvcSize"] = storage.String()
+		}
+		pvcResizeInfo(result, pvc)
+	} else {
+		result["pvcExists"] = false
+	}
+	return result
+}
+
+// BatchGetSessionK8sResources returns the GetSessionK8sResources response for up to 50 sessions,
+// gathered with one list each of sessions, Jobs, pods, PVCs and runner auth objects in the project
+// POST /api/projects/:projectName/agentic-sessions-k8s-resources/batch
+func BatchGetSessionK8sResources(c *gin.Context) {
+	project := c.GetString("project")
+
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var req SessionK8sResourcesBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+	if len(req.SessionNames) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "sessionNames is required", nil)
+		return
+	}
+	if len(req.SessionNames) > maxSessionK8sResourcesBatch {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation,
+			fmt.Sprintf("sessionNames must not contain more than %d sessions", maxSessionK8sResourcesBatch), nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessionList, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("BatchGetSessionK8sResources: failed to list sessions in %s: %v", project, err)
+		respondK8sError(c, err, "Project not found", "Failed to list sessions")
+		return
+	}
+	sessions := make(map[string]*unstructured.Unstructured, len(sessionList.Items))
+	for i := range sessionList.Items {
+		sessions[sessionList.Items[i].GetName()] = &sessionList.Items[i]
+	}
+
+	// A failed list is reported like a failed per-session GET: Jobs as "Error", pods and PVCs as absent
+	jobs := map[string]*batchv1.Job{}
+	jobList, jobsErr := k8sClt.BatchV1().Jobs(project).List(ctx, v1.ListOptions{})
+	if jobsErr == nil {
+		for i := range jobList.Items {
+			jobs[jobList.Items[i].Name] = &jobList.Items[i]
+		}
+	}
+	podsByJob := map[string][]corev1.Pod{}
+	podsByName := map[string]*corev1.Pod{}
+	if podList, err := k8sClt.CoreV1().Pods(project).List(ctx, v1.ListOptions{}); err == nil {
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			podsByName[pod.Name] = pod
+			if jobName := pod.Labels["job-name"]; jobName != "" {
+				podsByJob[jobName] = append(podsByJob[jobName], *pod)
+			}
+		}
+	} else {
+		log.Printf("BatchGetSessionK8sResources: failed to list pods in %s: %v", project, err)
+	}
+	pvcs := map[string]*corev1.PersistentVolumeClaim{}
+	if pvcList, err := k8sClt.CoreV1().PersistentVolumeClaims(project).List(ctx, v1.ListOptions{}); err == nil {
+		for i := range pvcList.Items {
+			pvcs[pvcList.Items[i].Name] = &pvcList.Items[i]
+		}
+	} else {
+		log.Printf("BatchGetSessionK8sResources: failed to list PVCs in %s: %v", project, err)
+	}
+
+	// Runner auth objects: a failed list is reported on every session's auth section
+	authErrs := map[string]error{}
+	sas := map[string]*corev1.ServiceAccount{}
+	if saList, err := k8sClt.CoreV1().ServiceAccounts(project).List(ctx, v1.ListOptions{}); err == nil {
+		for i := range saList.Items {
+			sas[saList.Items[i].Name] = &saList.Items[i]
+		}
+	} else {
+		authErrs["serviceAccount"] = err
+	}
+	roles := map[string]*rbacv1.Role{}
+	if roleList, err := k8sClt.RbacV1().Roles(project).List(ctx, v1.ListOptions{}); err == nil {
+		for i := range roleList.Items {
+			roles[roleList.Items[i].Name] = &roleList.Items[i]
+		}
+	} else {
+		authErrs["role"] = err
+	}
+	rbs := map[string]*rbacv1.RoleBinding{}
+	if rbList, err := k8sClt.RbacV1().RoleBindings(project).List(ctx, v1.ListOptions{}); err == nil {
+		for i := range rbList.Items {
+			rbs[rbList.Items[i].Name] = &rbList.Items[i]
+		}
+	} else {
+		authErrs["roleBinding"] = err
+	}
+	tokenSecrets := map[string]*corev1.Secret{}
+	if secretList, err := k8sClt.CoreV1().Secrets(project).List(ctx, v1.ListOptions{LabelSelector: "app=" + runnerTokenSecretLabel}); err == nil {
+		for i := range secretList.Items {
+			tokenSecrets[secretList.Items[i].Name] = &secretList.Items[i]
+		}
+	} else {
+		authErrs["tokenSecret"] = err
+	}
+	now := time.Now()
+
+	items := make([]SessionK8sResourcesBatchItem, 0, len(req.SessionNames))
+	for _, name := range req.SessionNames {
+		name = strings.TrimSpace(name)
+		session, ok := sessions[name]
+		if !ok {
+			items = append(items, SessionK8sResourcesBatchItem{SessionName: name, Error: "session not found"})
+			continue
+		}
+		jobName := sessionJobName(session)
+		job, jobErr := jobs[jobName], jobsErr
+		if jobErr == nil && job == nil {
+			jobErr = errors.NewNotFound(batchv1.Resource("jobs"), jobName)
+		}
+		resources := sessionK8sResources(name, jobName, job, jobErr, podsByJob[jobName], podsByName[fmt.Sprintf("temp-content-%s", name)], pvcs[sessionPVCName(name)])
+		resources["auth"] = sessionRunnerAuth(name, sas[runnerServiceAccountName(name)], roles[runnerRoleName(name)],
+			rbs[runnerRoleBindingName(name)], tokenSecrets[runnerTokenSecretName(name)], authErrs, now)
+		items = append(items, SessionK8sResourcesBatchItem{SessionName: name, Resources: resources})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
@@ -0,0 +1,208 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Session K8s Resources", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		for _, name := range []string{"running", "idle"} {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": name, "namespace": testNamespace},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+			ObjectMeta: v1.ObjectMeta{Name: "running-job", Namespace: testNamespace},
+			Status:     batchv1.JobStatus{Active: 1},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		for _, pod := range []*corev1.Pod{
+			{
+				ObjectMeta: v1.ObjectMeta{Name: "running-job-abcde", Namespace: testNamespace, Labels: map[string]string{"job-name": "running-job"}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "ambient-code-runner", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				}},
+			},
+			{
+				ObjectMeta: v1.ObjectMeta{Name: "temp-content-idle", Namespace: testNamespace},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			},
+		} {
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, pod, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = k8sUtils.K8sClient.CoreV1().PersistentVolumeClaims(testNamespace).Create(ctx, &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-workspace-running", Namespace: testNamespace},
+			Status:     corev1.PersistentVolumeClaimStatus{Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")}},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	getOne := func(session string) map[string]interface{} {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/"+session+"/k8s-resources", nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: session}}
+		GetSessionK8sResources(c)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		var resp map[string]interface{}
+		Expect(json.Unmarshal(httpUtils.GetResponseRecorder().Body.Bytes(), &resp)).To(Succeed())
+		return resp
+	}
+
+	getBatch := func(names []string) *test_utils.HTTPTestUtils {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions-k8s-resources/batch", map[string]interface{}{"sessionNames": names})
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		BatchGetSessionK8sResources(c)
+		return httpUtils
+	}
+
+	It("Should return what the per-session endpoint returns, in request order", func() {
+		running, idle := getOne("running"), getOne("idle")
+		Expect(running["jobStatus"]).To(Equal("Active"))
+		Expect(running["pvcSize"]).To(Equal("5Gi"))
+		Expect(idle).NotTo(HaveKey("jobName"))
+
+		resp := getBatch([]string{"idle", "missing", "running"})
+		resp.AssertHTTPStatus(http.StatusOK)
+		var body struct {
+			Items []struct {
+				SessionName string                 `json:"sessionName"`
+				Resources   map[string]interface{} `json:"resources"`
+				Error       string                 `json:"error"`
+			} `json:"items"`
+		}
+		Expect(json.Unmarshal(resp.GetResponseRecorder().Body.Bytes(), &body)).To(Succeed())
+
+		Expect(body.Items).To(HaveLen(3))
+		Expect(body.Items[0].SessionName).To(Equal("idle"))
+		Expect(body.Items[0].Resources).To(Equal(idle))
+		Expect(body.Items[1].SessionName).To(Equal("missing"))
+		Expect(body.Items[1].Error).To(Equal("session not found"))
+		Expect(body.Items[1].Resources).To(BeNil())
+		Expect(body.Items[2].Resources).To(Equal(running))
+	})
+
+	It("Should report the runner's auth objects and the permissions its Role lacks", func() {
+		idle := getOne("idle")["auth"].(map[string]interface{})
+		Expect(idle["healthy"]).To(BeFalse())
+		Expect(idle["serviceAccount"]).To(HaveKeyWithValue("exists", false))
+		Expect(idle["tokenSecret"]).To(HaveKeyWithValue("exists", false))
+
+		_, err := k8sUtils.K8sClient.CoreV1().ServiceAccounts(testNamespace).Create(ctx, &corev1.ServiceAccount{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-session-running", Namespace: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		// A Role from before the runner updated annotations: agenticsessions has no update verb
+		rules := runnerRoleRules("running")
+		rules[0].Verbs = []string{"get", "patch"}
+		_, err = k8sUtils.K8sClient.RbacV1().Roles(testNamespace).Create(ctx, &rbacv1.Role{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-session-running-role", Namespace: testNamespace},
+			Rules:      rules,
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.K8sClient.RbacV1().RoleBindings(testNamespace).Create(ctx, &rbacv1.RoleBinding{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-session-running-rb", Namespace: testNamespace},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "ambient-session-running-role"},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		issuedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+		expiresAt := issuedAt.Add(2 * time.Hour)
+		claims, _ := json.Marshal(map[string]int64{"iat": issuedAt.Unix(), "exp": expiresAt.Unix()})
+		token := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(claims) + ".c2ln"
+		_, err = k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-runner-token-running", Namespace: testNamespace, Labels: map[string]string{"app": runnerTokenSecretLabel}},
+			Data:       map[string][]byte{runnerTokenSecretKey: []byte(token)},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		auth := getOne("running")["auth"].(map[string]interface{})
+		Expect(auth["healthy"]).To(BeFalse())
+		Expect(auth["serviceAccount"]).To(HaveKeyWithValue("exists", true))
+		Expect(auth["roleBinding"]).To(HaveKeyWithValue("roleRef", "ambient-session-running-role"))
+		Expect(auth["role"]).To(HaveKeyWithValue("missingPermissions", []interface{}{"update agenticsessions"}))
+		secret := auth["tokenSecret"].(map[string]interface{})
+		Expect(secret["hasToken"]).To(BeTrue())
+		Expect(secret["issuedAt"]).To(Equal(issuedAt.UTC().Format(time.RFC3339)))
+		Expect(secret["expiresAt"]).To(Equal(expiresAt.UTC().Format(time.RFC3339)))
+		Expect(secret["expired"]).To(BeFalse())
+		Expect(secret["ageSeconds"]).To(BeNumerically("~", 3600, 60))
+
+		resp := getBatch([]string{"running"})
+		resp.AssertHTTPStatus(http.StatusOK)
+		var body struct {
+			Items []struct {
+				Resources map[string]interface{} `json:"resources"`
+			} `json:"items"`
+		}
+		Expect(json.Unmarshal(resp.GetResponseRecorder().Body.Bytes(), &body)).To(Succeed())
+		Expect(body.Items[0].Resources["auth"]).To(HaveKeyWithValue("role", auth["role"]))
+	})
+
+	It("Should attach warning events to a temp content pod that is not ready", func() {
+		_, err := k8sUtils.K8sClient.CoreV1().Events(testNamespace).Create(ctx, &corev1.Event{
+			ObjectMeta:     v1.ObjectMeta{Name: "temp-content-idle.1", Namespace: testNamespace},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "temp-content-idle", Namespace: testNamespace},
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedScheduling",
+			Message:        "0/3 nodes are available",
+			LastTimestamp:  v1.Now(),
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		pods := getOne("idle")["pods"].([]interface{})
+		Expect(pods).To(HaveLen(1))
+		events := pods[0].(map[string]interface{})["events"].([]interface{})
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(HaveKeyWithValue("reason", "FailedScheduling"))
+		Expect(events[0]).To(HaveKeyWithValue("message", "0/3 nodes are available"))
+		Expect(getOne("running")["pods"].([]interface{})[0]).NotTo(HaveKey("events"))
+	})
+
+	It("Should reject batches over the limit", func() {
+		names := make([]string, maxSessionK8sResourcesBatch+1)
+		for i := range names {
+			names[i] = "s" + strconv.Itoa(i)
+		}
+		getBatch(names).AssertHTTPStatus(http.StatusBadRequest)
+	})
+})
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// Labels CreateSession mirrors from the spec so sessions can be listed by label selector.
+// Sessions created before these labels existed do not carry them.
+const (
+	sessionUserLabel  = "ambient-code.io/user"  // spec.userContext.userId
+	sessionModelLabel = "ambient-code.io/model" // spec.llmSettings.model
+)
+
+// sessionIdentityLabels are managed by the backend and cannot be set or patched by clients
+var sessionIdentityLabels = []string{sessionUserLabel, sessionModelLabel}
+
+// isSessionIdentityLabel reports whether key is one of sessionIdentityLabels or a chargeback label,
+// which clients cannot set or patch either
+func isSessionIdentityLabel(key string) bool {
+	if isChargebackLabel(key) {
+		return true
+	}
+	for _, l := range sessionIdentityLabels {
+		if key == l {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionLabelValue maps an arbitrary string onto a valid label value: characters outside
+// [A-Za-z0-9-_.] become '-', leading and trailing non-alphanumerics are dropped, and values longer
+// than 63 characters keep a prefix followed by a hash of the original so they stay distinct. The
+// mapping is deterministic, so the same input always selects the same sessions.
+func sessionLabelValue(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	isAlnum := func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+	}
+	v := strings.TrimFunc(b.String(), func(r rune) bool { return !isAlnum(r) })
+	if len(v) <= 63 && v != "" {
+		return v
+	}
+	sum := sha256.Sum256([]byte(s))
+	hash := hex.EncodeToString(sum[:])[:10]
+	if len(v) > 52 {
+		v = strings.TrimRightFunc(v[:52], func(r rune) bool { return !isAlnum(r) })
+	}
+	if v == "" {
+		return hash
+	}
+	return v + "-" + hash
+}
+
+// setSessionIdentityLabels writes the user and model labels into a session's metadata, replacing
+// any client-supplied values for those keys
+func setSessionIdentityLabels(metadata map[string]interface{}, userID, model string) {
+	lbls, _ := metadata["labels"].(map[string]interface{})
+	if lbls == nil {
+		lbls = map[string]interface{}{}
+	}
+	for _, l := range sessionIdentityLabels {
+		delete(lbls, l)
+	}
+	if userID = strings.TrimSpace(userID); userID != "" {
+		lbls[sessionUserLabel] = sessionLabelValue(userID)
+	}
+	if model = strings.TrimSpace(model); model != "" {
+		lbls[sessionModelLabel] = sessionLabelValue(model)
+	}
+	if len(lbls) > 0 {
+		metadata["labels"] = lbls
+	}
+}
+
+// syncSessionModelLabel points the model label at spec.llmSettings.model after the LLM settings of
+// a session changed
+func syncSessionModelLabel(item *unstructured.Unstructured) {
+	model, _, _ := unstructured.NestedString(item.Object, "spec", "llmSettings", "model")
+	lbls := item.GetLabels()
+	if model = strings.TrimSpace(model); model == "" {
+		if _, ok := lbls[sessionModelLabel]; !ok {
+			return
+		}
+		delete(lbls, sessionModelLabel)
+	} else {
+		if lbls == nil {
+			lbls = map[string]string{}
+		}
+		lbls[sessionModelLabel] = sessionLabelValue(model)
+	}
+	item.SetLabels(lbls)
+}
+
+// sessionListSelector translates the user (me or a user ID) and model query parameters of
+// ListSessions into a label selector. The selector is empty, matching every session, when neither
+// is set.
+func sessionListSelector(c *gin.Context) (labels.Selector, error) {
+	selector := labels.NewSelector()
+	if user := strings.TrimSpace(c.Query("user")); user != "" {
+		if user == "me" {
+			uid, _ := c.Get("userID")
+			user, _ = uid.(string)
+			user = strings.TrimSpace(user)
+			if user == "" {
+				return nil, fmt.Errorf("user=me requires an authenticated user")
+			}
+		}
+		req, err := labels.NewRequirement(sessionUserLabel, selection.Equals, []string{sessionLabelValue(user)})
+		if err != nil {
+			return nil, fmt.Errorf("invalid user filter: %w", err)
+		}
+		selector = selector.Add(*req)
+	}
+	if model := strings.TrimSpace(c.Query("model")); model != "" {
+		req, err := labels.NewRequirement(sessionModelLabel, selection.Equals, []string{sessionLabelValue(model)})
+		if err != nil {
+			return nil, fmt.Errorf("invalid model filter: %w", err)
+		}
+		selector = selector.Add(*req)
+	}
+	return selector, nil
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// projectMaxConcurrentSessions returns spec.maxConcurrentSessions from the project's
+// ProjectSettings, or 0 when the project sets no limit
+func projectMaxConcurrentSessions(ctx context.Context, dyn dynamic.Interface, project string) int64 {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, not limiting concurrent sessions: %v", project, err)
+		}
+		return 0
+	}
+	if limit, found, _ := unstructured.NestedInt64(obj.Object, "spec", "maxConcurrentSessions"); found && limit > 0 {
+		return limit
+	}
+	return 0
+}
+
+// holdsSessionSlot reports whether a session in phase counts toward maxConcurrentSessions: it is
+// starting or running. Queued sessions wait for a slot and terminal ones have given theirs back.
+func holdsSessionSlot(phase string) bool {
+	switch phase {
+	case "", sessionPhasePending, sessionPhaseCreating, sessionPhaseRunning, sessionPhaseStopping:
+		return true
+	}
+	return false
+}
+
+// concurrentSessionCount counts the sessions in the project holding a slot, not counting exclude
+func concurrentSessionCount(ctx context.Context, dyn dynamic.Interface, project, exclude string) (int64, error) {
+	list, err := dyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	for i := range list.Items {
+		if list.Items[i].GetName() == exclude {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(list.Items[i].Object, "status", "phase")
+		if holdsSessionSlot(phase) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// checkConcurrentSessionLimit reports whether the project has a free session slot for sessionName
+// ("" for a new session). When it has none and queue is false it responds 429 with the current
+// count and limit and returns false; with queue it returns false without responding, and the
+// caller queues the session instead. A failed count is logged and does not block the start: the
+// operator enforces the limit again before creating the runner Job.
+func checkConcurrentSessionLimit(c *gin.Context, dyn dynamic.Interface, project, sessionName string, queue bool) bool {
+	ctx := c.Request.Context()
+	limit := projectMaxConcurrentSessions(ctx, dyn, project)
+	if limit == 0 {
+		return true
+	}
+	count, err := concurrentSessionCount(ctx, dyn, project, sessionName)
+	if err != nil {
+		log.Printf("Failed to count sessions in %s for the concurrent session limit: %v", project, err)
+		return true
+	}
+	if count < limit {
+		return true
+	}
+	if !queue {
+		respondError(c, http.StatusTooManyRequests, ErrorKindLimitExceeded,
+			fmt.Sprintf("Project is running %d of %d concurrent sessions", count, limit),
+			gin.H{"current": count, "limit": limit})
+	}
+	return false
+}
+
+// queueCreatedSession moves a just-created session to the Queued phase so the operator starts it
+// once a slot frees. The backend SA writes the status, which session creators cannot. A session the
+// operator has already picked up is left alone.
+func queueCreatedSession(ctx context.Context, dyn dynamic.Interface, project, sessionName string) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "" && phase != sessionPhasePending {
+			return nil
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		// Conditions the backend recorded already (a provisioning failure) are kept
+		existing, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		conditions := []interface{}{}
+		for _, cond := range existing {
+			if m, ok := cond.(map[string]interface{}); ok && m["type"] != "JobCreated" && m["type"] != "Ready" {
+				conditions = append(conditions, cond)
+			}
+		}
+		for _, condType := range []string{"JobCreated", "Ready"} {
+			conditions = append(conditions, map[string]interface{}{
+				"type":               condType,
+				"status":             "False",
+				"reason":             "Queued",
+				"message":            "Waiting for project capacity",
+				"lastTransitionTime": now,
+			})
+		}
+		status, _, _ := unstructured.NestedMap(obj.Object, "status")
+		if status == nil {
+			status = map[string]interface{}{}
+		}
+		status["phase"] = sessionPhaseQueued
+		status["queuedAt"] = now
+		status["conditions"] = conditions
+		if err := unstructured.SetNestedMap(obj.Object, status, "status"); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// queueNewSession queues a session created while its project was at capacity. Failures are only
+// logged: the operator queues the session itself when it finds the project at its limit.
+func queueNewSession(ctx context.Context, project, sessionName string) {
+	if DynamicClient == nil {
+		log.Printf("Warning: backend SA client not available, leaving session %s/%s for the operator to queue", project, sessionName)
+		return
+	}
+	if err := queueCreatedSession(ctx, DynamicClient, project, sessionName); err != nil {
+		log.Printf("Warning: failed to queue session %s/%s: %v", project, sessionName, err)
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parseTranscript decodes messages.jsonl, skipping malformed lines and entries without a role
+// (such as pre-AG-UI legacy records), and returns the messages oldest first
+func parseTranscript(data []byte) []types.TranscriptMessage {
+	messages := []types.TranscriptMessage{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var msg types.TranscriptMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.Role == "" {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].Seq < messages[j].Seq })
+	return messages
+}
+
+// paginateTranscript orders the transcript ("asc" oldest first, "desc" newest first) and applies offset/limit
+func paginateTranscript(messages []types.TranscriptMessage, order string, offset, limit int) ([]types.TranscriptMessage, bool, int) {
+	if order == "desc" {
+		reversed := make([]types.TranscriptMessage, len(messages))
+		for i, m := range messages {
+			reversed[len(messages)-1-i] = m
+		}
+		messages = reversed
+	}
+
+	total := len(messages)
+	if offset >= total {
+		return []types.TranscriptMessage{}, false, 0
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return messages[offset:end], end < total, end
+}
+
+// ReadSessionTranscript returns a session's messages.jsonl from the backend state volume, nil when no
+// message has completed yet; set by main to the AG-UI transcript store.
+var ReadSessionTranscript func(sessionName string) ([]byte, error)
+
+// GetSessionMessages returns the persisted chat transcript of a session.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/messages?offset=&limit=&order=asc|desc
+// The backend records the transcript itself (see ReadSessionTranscript), so it is read locally once
+// the caller is shown to be able to get the session; a missing transcript yields an empty page.
+func GetSessionMessages(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	session := c.Param("sessionName")
+
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var params types.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid pagination parameters", nil)
+		return
+	}
+	types.NormalizePaginationParams(&params)
+	order := strings.ToLower(strings.TrimSpace(c.DefaultQuery("order", "asc")))
+	if order != "asc" && order != "desc" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "order must be asc or desc", nil)
+		return
+	}
+	if !isValidKubernetesName(session) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid session name", nil)
+		return
+	}
+	if _, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{}); err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
+		return
+	}
+
+	var data []byte
+	if ReadSessionTranscript != nil {
+		var err error
+		if data, err = ReadSessionTranscript(session); err != nil {
+			log.Printf("GetSessionMessages: failed to read transcript of %s/%s: %v", project, session, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read transcript", nil)
+			return
+		}
+	}
+	messages := parseTranscript(data)
+
+	page, hasMore, nextOffset := paginateTranscript(messages, order, params.Offset, params.Limit)
+	response := types.PaginatedResponse{
+		Items:      page,
+		TotalCount: len(messages),
+		Limit:      params.Limit,
+		Offset:     params.Offset,
+		HasMore:    hasMore,
+	}
+	if hasMore {
+		response.NextOffset = &nextOffset
+	}
+	c.JSON(http.StatusOK, response)
+}
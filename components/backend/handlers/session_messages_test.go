@@ -0,0 +1,59 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/types"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Transcript", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	transcript := []byte(`{"seq":2,"messageId":"a1","role":"assistant","content":"Hi there","timestamp":"2026-01-01T00:00:02Z"}
+{"type":"user_message","payload":{"content":"legacy"}}
+not json
+{"seq":1,"messageId":"u1","role":"user","content":"Hello","timestamp":"2026-01-01T00:00:01Z"}
+
+{"seq":3,"messageId":"a2","role":"assistant","content":"Done","timestamp":"2026-01-01T00:00:03Z"}
+`)
+
+	seqs := func(page []types.TranscriptMessage) []int64 {
+		out := make([]int64, 0, len(page))
+		for _, m := range page {
+			out = append(out, m.Seq)
+		}
+		return out
+	}
+
+	It("Should parse transcript lines in sequence order and skip legacy or malformed lines", func() {
+		messages := parseTranscript(transcript)
+
+		Expect(seqs(messages)).To(Equal([]int64{1, 2, 3}))
+		Expect(messages[0].Role).To(Equal("user"))
+		Expect(messages[0].Content).To(Equal("Hello"))
+	})
+
+	It("Should page oldest-first by default", func() {
+		page, hasMore, next := paginateTranscript(parseTranscript(transcript), "asc", 0, 2)
+
+		Expect(seqs(page)).To(Equal([]int64{1, 2}))
+		Expect(hasMore).To(BeTrue())
+		Expect(next).To(Equal(2))
+	})
+
+	It("Should page newest-first when requested", func() {
+		page, hasMore, _ := paginateTranscript(parseTranscript(transcript), "desc", 1, 5)
+
+		Expect(seqs(page)).To(Equal([]int64{2, 1}))
+		Expect(hasMore).To(BeFalse())
+	})
+
+	It("Should return an empty page past the end", func() {
+		page, hasMore, _ := paginateTranscript(parseTranscript(transcript), "asc", 10, 5)
+
+		Expect(page).To(BeEmpty())
+		Expect(hasMore).To(BeFalse())
+	})
+})
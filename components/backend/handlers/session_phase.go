@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+)
+
+// Session phases (see the status.phase enum in the AgenticSession CRD)
+const (
+	sessionPhasePending   = "Pending"
+	sessionPhaseQueued    = "Queued"
+	sessionPhaseCreating  = "Creating"
+	sessionPhaseRunning   = "Running"
+	sessionPhaseStopping  = "Stopping"
+	sessionPhaseStopped   = "Stopped"
+	sessionPhaseCompleted = "Completed"
+	sessionPhaseFailed    = "Failed"
+	sessionPhaseError     = "Error"
+)
+
+var knownSessionPhases = map[string]bool{
+	sessionPhasePending:   true,
+	sessionPhaseQueued:    true,
+	sessionPhaseCreating:  true,
+	sessionPhaseRunning:   true,
+	sessionPhaseStopping:  true,
+	sessionPhaseStopped:   true,
+	sessionPhaseCompleted: true,
+	sessionPhaseFailed:    true,
+	sessionPhaseError:     true,
+}
+
+// runnerPhaseTransitions lists the phase changes a runner may report. Queued/Stopping/Stopped are
+// owned by the operator and StopSession; leaving a terminal phase requires StartSession's restart.
+var runnerPhaseTransitions = map[string][]string{
+	sessionPhasePending:  {sessionPhaseCreating},
+	sessionPhaseCreating: {sessionPhaseRunning, sessionPhaseFailed, sessionPhaseError},
+	sessionPhaseRunning:  {sessionPhaseCompleted, sessionPhaseFailed, sessionPhaseError},
+}
+
+// runnerStatusWritableFields are the status fields UpdateSessionStatus accepts; the rest belong to the operator
+var runnerStatusWritableFields = map[string]bool{
+	"phase":           true,
+	"sdkSessionId":    true,
+	"sdkRestartCount": true,
+	"totalCostUSD":    true,
+	"usage_delta":     true,
+	"usage_seq":       true,
+	"repos":           true,
+	"artifacts":       true,
+}
+
+// phaseTransitionError rejects a runner-reported phase; Unknown marks phases outside the state machine
+type phaseTransitionError struct {
+	From, To string
+	Unknown  bool
+}
+
+func (e *phaseTransitionError) Error() string {
+	if e.Unknown {
+		return fmt.Sprintf("unknown session phase %q", e.To)
+	}
+	return fmt.Sprintf("illegal session phase transition %s -> %s", e.From, e.To)
+}
+
+// isTerminalSessionPhase reports whether phase ends a run; only StartSession can leave it
+func isTerminalSessionPhase(phase string) bool {
+	switch phase {
+	case sessionPhaseCompleted, sessionPhaseFailed, sessionPhaseStopped, sessionPhaseError:
+		return true
+	}
+	return false
+}
+
+// validateRunnerPhaseTransition checks a runner-reported phase change against the session state
+// machine, returning a *phaseTransitionError when it is not allowed. Reporting the current phase
+// again is a no-op and always allowed.
+func validateRunnerPhaseTransition(from, to string) error {
+	if !knownSessionPhases[to] {
+		return &phaseTransitionError{From: from, To: to, Unknown: true}
+	}
+	if from == "" {
+		from = sessionPhasePending
+	}
+	if from == to {
+		return nil
+	}
+	for _, allowed := range runnerPhaseTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &phaseTransitionError{From: from, To: to}
+}
+
+// UpdateSessionStatus lets the runner report progress on a whitelisted set of status fields.
+// PUT /api/projects/:projectName/agentic-sessions/:sessionName/status
+// Body: { phase?: string, sdkSessionId?: string, sdkRestartCount?: int, totalCostUSD?: number,
+// usage_delta?: object, usage_seq?: int, repos?: [{ name, status, clonedSha?, clonedBranch?, pushedSha?, last_updated? }],
+// artifacts?: [{ name?, path, contentType?, sizeBytes?, createdAt? }] }
+// Phase changes are checked by validateRunnerPhaseTransition: unknown phases get 422, illegal moves 409.
+// A totalCostUSD above spec.costLimitUSD stops the session as StopSession does, with
+// status.stoppedReason "costLimit"; the check uses the object being updated, not another read.
+// usage_delta is added to status.usage when usage_seq is above status.usageSeq, so a retried report
+// is not counted twice; the new totals are published to the session's stream as usage_update.
+// repos entries are merged by name into status.reconciledRepos (see mergeRunnerRepoStatuses), so
+// the commit the runner cloned and pushes it makes itself show up without a backend push call. artifacts entries are validated
+// and merged by path into status.artifacts as PublishSessionArtifacts does.
+func UpdateSessionStatus(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	k8sDyn := authorizeSessionStatusWrite(c, project, sessionName)
+	if k8sDyn == nil {
+		return
+	}
+
+	var req map[string]interface{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+	if len(req) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "No status fields to update", nil)
+		return
+	}
+	for field := range req {
+		if !runnerStatusWritableFields[field] {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("status.%s cannot be updated by the runner", field), nil)
+			return
+		}
+	}
+
+	phase, hasPhase := req["phase"].(string)
+	if _, present := req["phase"]; present && !hasPhase {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "phase must be a string", nil)
+		return
+	}
+	phase = strings.TrimSpace(phase)
+	if v, present := req["sdkSessionId"]; present {
+		if _, ok := v.(string); !ok {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "sdkSessionId must be a string", nil)
+			return
+		}
+	}
+	if v, present := req["sdkRestartCount"]; present {
+		if n, ok := v.(float64); !ok || n < 0 || n != float64(int64(n)) {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "sdkRestartCount must be a non-negative integer", nil)
+			return
+		}
+		req["sdkRestartCount"] = int64(v.(float64))
+	}
+	if v, present := req["totalCostUSD"]; present {
+		if n, ok := v.(float64); !ok || n < 0 {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "totalCostUSD must be a non-negative number", nil)
+			return
+		}
+	}
+	var usageDelta map[string]interface{}
+	var usageSeq int64
+	if v, present := req["usage_delta"]; present {
+		delta, err := parseUsageDelta(v)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+		n, ok := req["usage_seq"].(float64)
+		if !ok || n < 1 || n != float64(int64(n)) {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "usage_seq must be a positive integer", nil)
+			return
+		}
+		usageDelta, usageSeq = delta, int64(n)
+	} else if _, present := req["usage_seq"]; present {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "usage_seq requires usage_delta", nil)
+		return
+	}
+	var repoReports map[string]map[string]interface{}
+	if v, present := req["repos"]; present {
+		reports, err := parseRunnerRepoStatuses(v)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+		repoReports = reports
+	}
+	var artifacts []types.SessionArtifact
+	if v, present := req["artifacts"]; present {
+		parsed, err := parseRunnerArtifacts(v)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+		artifacts = parsed
+	}
+	// The usage fields are accumulated, and repos and artifacts merged, below rather than copied into status
+	delete(req, "usage_delta")
+	delete(req, "usage_seq")
+	delete(req, "repos")
+	delete(req, "artifacts")
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	costLimitStop := false
+	var usageTotals map[string]interface{}
+	var lastUsageSeq int64
+	var updated *unstructured.Unstructured
+	// usageTotals and costLimitStop are only set from the attempt whose UpdateStatus succeeded, so a
+	// conflict retry never reports a delta this request stored as a duplicate
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var totals map[string]interface{}
+		stop := false
+		obj, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		previousPhase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+
+		if hasPhase {
+			if err := validateRunnerPhaseTransition(previousPhase, phase); err != nil {
+				return err
+			}
+			if phase != previousPhase && isTerminalSessionPhase(phase) {
+				_ = unstructured.SetNestedField(obj.Object, time.Now().UTC().Format(time.RFC3339), "status", "completionTime")
+			}
+		}
+		for field, value := range req {
+			if field == "phase" {
+				value = phase
+			}
+			if err := unstructured.SetNestedField(obj.Object, value, "status", field); err != nil {
+				return err
+			}
+		}
+
+		duplicateOnly := false
+		if usageDelta != nil {
+			seq, _ := numberValue(unstructuredField(obj, "status", "usageSeq"))
+			lastUsageSeq = int64(seq)
+			if usageSeq > lastUsageSeq {
+				usage, _, _ := unstructured.NestedMap(obj.Object, "status", "usage")
+				totals = addUsage(usage, usageDelta)
+				_ = unstructured.SetNestedField(obj.Object, totals, "status", "usage")
+				_ = unstructured.SetNestedField(obj.Object, usageSeq, "status", "usageSeq")
+				// The accumulated cost feeds the cost limit unless the runner reported a total itself
+				if cost, ok := totals[usageCostField].(float64); ok {
+					if _, reported := req["totalCostUSD"]; !reported {
+						_ = unstructured.SetNestedField(obj.Object, cost, "status", "totalCostUSD")
+					}
+				}
+			} else {
+				duplicateOnly = len(req) == 0 && repoReports == nil && artifacts == nil
+			}
+		}
+
+		currentPhase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		if sessionCostLimitExceeded(obj) && !isTerminalSessionPhase(currentPhase) && currentPhase != sessionPhaseStopping && !stopRequested(obj) {
+			stop = true
+			_ = unstructured.SetNestedField(obj.Object, costLimitStopReason, "status", "stoppedReason")
+		} else if duplicateOnly {
+			// Only a usage report the session has already counted and nothing left to stop: no write
+			return nil
+		}
+
+		if repoReports != nil {
+			// Merged into the object read on this attempt, so a conflict retry keeps concurrent reports
+			if err := mergeRunnerRepoStatuses(obj, repoReports); err != nil {
+				return err
+			}
+		}
+		if artifacts != nil {
+			if err := mergeArtifacts(obj, artifacts); err != nil {
+				return err
+			}
+		}
+
+		result, err := k8sDyn.Resource(gvr).Namespace(project).UpdateStatus(context.TODO(), obj, v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		updated, usageTotals, costLimitStop = result, totals, stop
+		return nil
+	})
+	if pErr, ok := err.(*phaseTransitionError); ok {
+		log.Printf("UpdateSessionStatus: rejected runner phase report for %s/%s: %v", project, sessionName, pErr)
+		if pErr.Unknown {
+			respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation, fmt.Sprintf("Unknown phase %q", pErr.To), nil)
+			return
+		}
+		respondError(c, http.StatusConflict, ErrorKindConflict, fmt.Sprintf("Cannot move session from %s to %s", pErr.From, pErr.To),
+			gin.H{"currentPhase": pErr.From, "requestedPhase": pErr.To})
+		return
+	}
+	if err == errTooManyArtifacts {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		if errors.IsForbidden(err) {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Not allowed to update session status", nil)
+			return
+		}
+		log.Printf("Failed to update status for session %s/%s: %v", project, sessionName, err)
+		respondK8sError(c, err, "Session not found", "Failed to update session status")
+		return
+	}
+
+	if usageTotals != nil && PublishUsageUpdate != nil {
+		PublishUsageUpdate(sessionName, usageTotals, usageSeq)
+	}
+	if costLimitStop {
+		// The status write has landed; the stop is requested with its own retry so a conflict here
+		// cannot send the report back through the usage accounting
+		log.Printf("UpdateSessionStatus: session %s/%s exceeded its cost limit, stopping", project, sessionName)
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			obj := updated
+			updated = nil
+			if obj == nil {
+				var err error
+				if obj, err = k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{}); err != nil {
+					return err
+				}
+			}
+			currentPhase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+			if isTerminalSessionPhase(currentPhase) || currentPhase == sessionPhaseStopping || stopRequested(obj) {
+				return nil
+			}
+			requestSessionStop(obj)
+			_, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), obj, v1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			log.Printf("Failed to stop session %s/%s over its cost limit: %v", project, sessionName, err)
+			respondK8sError(c, err, "Session not found", "Failed to stop session over its cost limit")
+			return
+		}
+	}
+
+	resp := gin.H{"message": "Session status updated"}
+	if usageDelta != nil {
+		if usageTotals != nil {
+			resp["usage"] = usageTotals
+			resp["usageSeq"] = usageSeq
+		} else {
+			log.Printf("UpdateSessionStatus: ignoring usage_seq %d for %s/%s, already at %d", usageSeq, project, sessionName, lastUsageSeq)
+			resp["duplicateUsage"] = true
+			resp["usageSeq"] = lastUsageSeq
+		}
+	}
+	if costLimitStop {
+		resp["message"] = "cost limit exceeded"
+		resp["stopped"] = true
+		resp["stoppedReason"] = costLimitStopReason
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,519 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Session Phase Transitions", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	allPhases := []string{
+		sessionPhasePending, sessionPhaseQueued, sessionPhaseCreating, sessionPhaseRunning, sessionPhaseStopping,
+		sessionPhaseStopped, sessionPhaseCompleted, sessionPhaseFailed, sessionPhaseError,
+	}
+	legal := map[string]bool{
+		"Pending->Creating":    true,
+		"Creating->Running":    true,
+		"Creating->Failed":     true,
+		"Creating->Error":      true,
+		"Running->Completed":   true,
+		"Running->Failed":      true,
+		"Running->Error":       true,
+		"Pending->Pending":     true,
+		"Queued->Queued":       true,
+		"Creating->Creating":   true,
+		"Running->Running":     true,
+		"Stopping->Stopping":   true,
+		"Stopped->Stopped":     true,
+		"Completed->Completed": true,
+		"Failed->Failed":       true,
+		"Error->Error":         true,
+	}
+
+	It("Should allow exactly the runner transitions in the state machine", func() {
+		for _, from := range allPhases {
+			for _, to := range allPhases {
+				err := validateRunnerPhaseTransition(from, to)
+				if legal[from+"->"+to] {
+					Expect(err).NotTo(HaveOccurred(), "%s -> %s should be allowed", from, to)
+					continue
+				}
+				Expect(err).To(HaveOccurred(), "%s -> %s should be rejected", from, to)
+				pErr, ok := err.(*phaseTransitionError)
+				Expect(ok).To(BeTrue())
+				Expect(pErr.Unknown).To(BeFalse())
+			}
+		}
+	})
+
+	It("Should treat a missing phase as Pending", func() {
+		Expect(validateRunnerPhaseTransition("", sessionPhaseCreating)).To(Succeed())
+		Expect(validateRunnerPhaseTransition("", sessionPhaseRunning)).NotTo(Succeed())
+	})
+
+	It("Should flag unknown phases regardless of the current phase", func() {
+		for _, from := range allPhases {
+			err := validateRunnerPhaseTransition(from, "Paused")
+			pErr, ok := err.(*phaseTransitionError)
+			Expect(ok).To(BeTrue())
+			Expect(pErr.Unknown).To(BeTrue())
+		}
+	})
+
+	Describe("UpdateSessionStatus", func() {
+		var (
+			httpUtils     *test_utils.HTTPTestUtils
+			k8sUtils      *test_utils.K8sTestUtils
+			ctx           context.Context
+			testNamespace string
+			testSession   string
+			testToken     string
+		)
+
+		BeforeEach(func() {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+			ctx = context.Background()
+			suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+			testNamespace = "test-project-" + suffix
+			testSession = "test-session-" + suffix
+			SetupHandlerDependencies(k8sUtils)
+
+			_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+				ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+			}, v1.CreateOptions{})
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+			Expect(err).NotTo(HaveOccurred())
+			testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+			Expect(err).NotTo(HaveOccurred())
+
+			createTestSession(testSession, testNamespace, k8sUtils)
+		})
+
+		setPhase := func(phase string) {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unstructured.SetNestedField(obj.Object, phase, "status", "phase")).To(Succeed())
+			_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		updateStatus := func(body interface{}) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/status", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("PUT", path, body)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+			UpdateSessionStatus(context)
+		}
+
+		storedStatus := func() map[string]interface{} {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			status, _, _ := unstructured.NestedMap(obj.Object, "status")
+			return status
+		}
+
+		It("Should record a legal transition and stamp completionTime on terminal phases", func() {
+			setPhase(sessionPhaseRunning)
+			updateStatus(map[string]interface{}{"phase": "Completed", "sdkRestartCount": 2})
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			status := storedStatus()
+			Expect(status["phase"]).To(Equal("Completed"))
+			Expect(status["completionTime"]).NotTo(BeEmpty())
+			Expect(status["sdkRestartCount"]).To(BeNumerically("==", 2))
+		})
+
+		It("Should reject moving a Stopped session back to Running with 409", func() {
+			setPhase(sessionPhaseStopped)
+			updateStatus(map[string]interface{}{"phase": "Running"})
+
+			httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Cannot move session from Stopped to Running")
+			Expect(storedStatus()["phase"]).To(Equal("Stopped"))
+		})
+
+		It("Should reject unknown phases with 422", func() {
+			setPhase(sessionPhaseRunning)
+			updateStatus(map[string]interface{}{"phase": "Paused"})
+
+			httpUtils.AssertHTTPStatus(http.StatusUnprocessableEntity)
+			Expect(storedStatus()["phase"]).To(Equal("Running"))
+		})
+
+		Context("When the session has a cost limit", func() {
+			BeforeEach(func() {
+				obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unstructured.SetNestedField(obj.Object, 10.0, "spec", "costLimitUSD")).To(Succeed())
+				Expect(unstructured.SetNestedField(obj.Object, sessionPhaseRunning, "status", "phase")).To(Succeed())
+				_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			storedAnnotations := func() map[string]string {
+				obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return obj.GetAnnotations()
+			}
+
+			It("Should record the cost without stopping while under the limit", func() {
+				updateStatus(map[string]interface{}{"totalCostUSD": 9.5})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				status := storedStatus()
+				Expect(status["totalCostUSD"]).To(BeNumerically("==", 9.5))
+				Expect(status).NotTo(HaveKey("stoppedReason"))
+				Expect(storedAnnotations()).NotTo(HaveKey("ambient-code.io/desired-phase"))
+			})
+
+			It("Should stop the session once the reported cost exceeds the limit", func() {
+				updateStatus(map[string]interface{}{"totalCostUSD": 10.01})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				Expect(response["message"]).To(Equal("cost limit exceeded"))
+				Expect(response["stopped"]).To(BeTrue())
+				Expect(storedStatus()["stoppedReason"]).To(Equal("costLimit"))
+				annotations := storedAnnotations()
+				Expect(annotations["ambient-code.io/desired-phase"]).To(Equal("Stopped"))
+				Expect(annotations["ambient-code.io/stop-requested-at"]).NotTo(BeEmpty())
+			})
+
+			It("Should still stop the session and publish usage when the stop update conflicts", func() {
+				gvr := GetAgenticSessionV1Alpha1Resource()
+				current, err := k8sUtils.DynamicClient.Resource(gvr).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"}, current)
+				conflicted := false
+				fakeDyn.PrependReactor("update", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					if action.GetSubresource() != "" || conflicted {
+						return false, nil, nil
+					}
+					conflicted = true
+					return true, nil, errors.NewConflict(gvr.GroupResource(), testSession, fmt.Errorf("the object has been modified"))
+				})
+				DynamicClient = fakeDyn
+				var published []int64
+				original := PublishUsageUpdate
+				PublishUsageUpdate = func(sessionName string, usage map[string]interface{}, seq int64) {
+					published = append(published, seq)
+				}
+				DeferCleanup(func() { PublishUsageUpdate = original })
+
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"total_cost_usd": 10.5}, "usage_seq": 1})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				Expect(conflicted).To(BeTrue())
+				Expect(response["stopped"]).To(BeTrue())
+				Expect(response).NotTo(HaveKey("duplicateUsage"))
+				Expect(response["usageSeq"]).To(BeNumerically("==", 1))
+				Expect(published).To(Equal([]int64{1}))
+				stored, err := fakeDyn.Tracker().Get(gvr, testNamespace, testSession)
+				Expect(err).NotTo(HaveOccurred())
+				obj := stored.(*unstructured.Unstructured)
+				Expect(obj.GetAnnotations()).To(HaveKeyWithValue("ambient-code.io/desired-phase", "Stopped"))
+				reason, _, _ := unstructured.NestedString(obj.Object, "status", "stoppedReason")
+				Expect(reason).To(Equal("costLimit"))
+				usage, _, _ := unstructured.NestedMap(obj.Object, "status", "usage")
+				Expect(usage["total_cost_usd"]).To(BeNumerically("~", 10.5, 1e-9))
+			})
+
+			It("Should not stop a session that already finished", func() {
+				updateStatus(map[string]interface{}{"phase": "Completed", "totalCostUSD": 11})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				Expect(storedStatus()).NotTo(HaveKey("stoppedReason"))
+				Expect(storedAnnotations()).NotTo(HaveKey("ambient-code.io/desired-phase"))
+			})
+
+			It("Should reject a negative cost", func() {
+				updateStatus(map[string]interface{}{"totalCostUSD": -1})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "totalCostUSD must be a non-negative number")
+			})
+		})
+
+		Context("When the runner reports usage deltas", func() {
+			var published []int64
+
+			BeforeEach(func() {
+				published = nil
+				original := PublishUsageUpdate
+				PublishUsageUpdate = func(sessionName string, usage map[string]interface{}, seq int64) {
+					Expect(sessionName).To(Equal(testSession))
+					published = append(published, seq)
+				}
+				DeferCleanup(func() { PublishUsageUpdate = original })
+			})
+
+			It("Should sum the deltas into status.usage and publish the totals", func() {
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"input_tokens": 100, "output_tokens": 20, "total_cost_usd": 0.25}, "usage_seq": 1})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"input_tokens": 50, "total_cost_usd": 0.5}, "usage_seq": 2})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				status := storedStatus()
+				usage := status["usage"].(map[string]interface{})
+				Expect(usage["input_tokens"]).To(BeNumerically("==", 150))
+				Expect(usage["output_tokens"]).To(BeNumerically("==", 20))
+				Expect(usage["total_cost_usd"]).To(BeNumerically("~", 0.75, 1e-9))
+				Expect(status["usageSeq"]).To(BeNumerically("==", 2))
+				Expect(status["totalCostUSD"]).To(BeNumerically("~", 0.75, 1e-9))
+				Expect(published).To(Equal([]int64{1, 2}))
+			})
+
+			It("Should ignore a report whose usage_seq was already counted", func() {
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"output_tokens": 10}, "usage_seq": 5})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"output_tokens": 10}, "usage_seq": 5})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				Expect(response["duplicateUsage"]).To(BeTrue())
+				usage := storedStatus()["usage"].(map[string]interface{})
+				Expect(usage["output_tokens"]).To(BeNumerically("==", 10))
+				Expect(published).To(Equal([]int64{5}))
+			})
+
+			It("Should count a delta once and not report it as a duplicate after a status conflict", func() {
+				gvr := GetAgenticSessionV1Alpha1Resource()
+				current, err := k8sUtils.DynamicClient.Resource(gvr).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"}, current)
+				conflicted := false
+				fakeDyn.PrependReactor("update", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					if action.GetSubresource() != "status" || conflicted {
+						return false, nil, nil
+					}
+					conflicted = true
+					return true, nil, errors.NewConflict(gvr.GroupResource(), testSession, fmt.Errorf("the object has been modified"))
+				})
+				DynamicClient = fakeDyn
+
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"output_tokens": 10}, "usage_seq": 3})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				Expect(conflicted).To(BeTrue())
+				Expect(response).NotTo(HaveKey("duplicateUsage"))
+				Expect(response["usageSeq"]).To(BeNumerically("==", 3))
+				Expect(published).To(Equal([]int64{3}))
+				stored, err := fakeDyn.Tracker().Get(gvr, testNamespace, testSession)
+				Expect(err).NotTo(HaveOccurred())
+				usage, _, _ := unstructured.NestedMap(stored.(*unstructured.Unstructured).Object, "status", "usage")
+				Expect(usage["output_tokens"]).To(BeNumerically("==", 10))
+			})
+
+			It("Should require usage_seq with usage_delta", func() {
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"output_tokens": 10}})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "usage_seq must be a positive integer")
+			})
+
+			It("Should reject negative counters", func() {
+				updateStatus(map[string]interface{}{"usage_delta": map[string]interface{}{"output_tokens": -1}, "usage_seq": 1})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "usage_delta.output_tokens must be a non-negative integer")
+			})
+		})
+
+		Context("When the runner reports per-repo git status", func() {
+			BeforeEach(func() {
+				obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unstructured.SetNestedSlice(obj.Object, []interface{}{
+					map[string]interface{}{"url": "https://github.com/org/alpha.git", "name": "alpha", "status": "Ready", "clonedSha": "aaaaaaa"},
+					map[string]interface{}{"url": "https://github.com/org/beta", "status": "Ready", "clonedSha": "bbbbbbb"},
+				}, "status", "reconciledRepos")).To(Succeed())
+				_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			reconciledRepos := func() map[string]map[string]interface{} {
+				byName := map[string]map[string]interface{}{}
+				for _, r := range storedStatus()["reconciledRepos"].([]interface{}) {
+					entry := r.(map[string]interface{})
+					name, _ := entry["name"].(string)
+					if name == "" {
+						name = DeriveRepoFolderFromURL(entry["url"].(string))
+					}
+					byName[name] = entry
+				}
+				return byName
+			}
+
+			It("Should merge entries by name and keep the rest of each entry", func() {
+				updateStatus(map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"name": "beta", "status": "pushed", "pushedSha": "CCCCCCC", "last_updated": "2026-01-02T03:04:05Z"},
+					map[string]interface{}{"name": "gamma", "status": "dirty"},
+				}})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				repos := reconciledRepos()
+				Expect(repos).To(HaveLen(3))
+				Expect(repos["alpha"]).NotTo(HaveKey("gitStatus"))
+				Expect(repos["beta"]).To(HaveKeyWithValue("gitStatus", "pushed"))
+				Expect(repos["beta"]).To(HaveKeyWithValue("pushedSha", "ccccccc"))
+				Expect(repos["beta"]).To(HaveKeyWithValue("lastUpdated", "2026-01-02T03:04:05Z"))
+				Expect(repos["beta"]).To(HaveKeyWithValue("clonedSha", "bbbbbbb"))
+				Expect(repos["beta"]).To(HaveKeyWithValue("status", "Ready"))
+				Expect(repos["gamma"]).To(HaveKeyWithValue("gitStatus", "dirty"))
+				Expect(repos["gamma"]).To(HaveKey("lastUpdated"))
+			})
+
+			It("Should not drop entries reported concurrently", func() {
+				names := []string{"alpha", "beta", "r1", "r2", "r3", "r4"}
+				var wg sync.WaitGroup
+				for _, name := range names {
+					// NewHTTPTestUtils sets the gin mode, so create it before starting the goroutine
+					utils := test_utils.NewHTTPTestUtils()
+					wg.Add(1)
+					go func(name string) {
+						defer GinkgoRecover()
+						defer wg.Done()
+						path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/status", testNamespace, testSession)
+						c := utils.CreateTestGinContext("PUT", path, map[string]interface{}{"repos": []interface{}{
+							map[string]interface{}{"name": name, "status": "pushed"},
+						}})
+						utils.SetAuthHeader(testToken)
+						utils.SetProjectContext(testNamespace)
+						c.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+						UpdateSessionStatus(c)
+						utils.AssertHTTPStatus(http.StatusOK)
+					}(name)
+				}
+				wg.Wait()
+
+				repos := reconciledRepos()
+				Expect(repos).To(HaveLen(len(names)))
+				for _, name := range names {
+					Expect(repos[name]).To(HaveKeyWithValue("gitStatus", "pushed"), name)
+				}
+			})
+
+			It("Should record the commit and branch the runner cloned", func() {
+				updateStatus(map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"name": "alpha", "status": "cloned", "clonedSha": "0123456789ABCDEF0123456789abcdef01234567", "clonedBranch": "main"},
+				}})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				repos := reconciledRepos()
+				Expect(repos["alpha"]).To(HaveKeyWithValue("clonedSha", "0123456789abcdef0123456789abcdef01234567"))
+				Expect(repos["alpha"]).To(HaveKeyWithValue("clonedBranch", "main"))
+				Expect(repos["alpha"]).To(HaveKeyWithValue("status", "Ready"))
+			})
+
+			It("Should reject statuses outside the known set", func() {
+				updateStatus(map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"name": "alpha", "status": "Ready"},
+				}})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "repos[0].status must be one of cloned, dirty, pushed, abandoned, error")
+			})
+
+			It("Should reject a malformed SHA", func() {
+				updateStatus(map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"name": "alpha", "status": "pushed", "pushedSha": "not-a-sha"},
+				}})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "repos[0].pushedSha must be a hex commit SHA")
+			})
+		})
+
+		It("Should merge reported artifacts into status.artifacts and keep paths in the workspace", func() {
+			updateStatus(map[string]interface{}{"artifacts": []interface{}{
+				map[string]interface{}{"path": "/workspace/artifacts/report.md", "contentType": "text/markdown", "sizeBytes": 120},
+			}})
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			artifacts, _ := storedStatus()["artifacts"].([]interface{})
+			Expect(artifacts).To(HaveLen(1))
+			entry := artifacts[0].(map[string]interface{})
+			Expect(entry["path"]).To(Equal("artifacts/report.md"))
+			Expect(entry["name"]).To(Equal("report.md"))
+			Expect(entry).To(HaveKey("createdAt"))
+
+			updateStatus(map[string]interface{}{"artifacts": []interface{}{map[string]interface{}{"path": "../other-session/secret"}}})
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `path "../other-session/secret" is outside the workspace`)
+		})
+
+		It("Should reject status fields outside the runner whitelist", func() {
+			updateStatus(map[string]interface{}{"reconciledWorkflow": map[string]interface{}{"status": "Active"}})
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "status.reconciledWorkflow cannot be updated by the runner")
+		})
+
+		Context("When the caller is a runner service account", func() {
+			var username string
+
+			BeforeEach(func() {
+				fakeK8s, ok := k8sUtils.K8sClient.(*k8sfake.Clientset)
+				Expect(ok).To(BeTrue())
+				fakeK8s.PrependReactor("create", "selfsubjectreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, &authnv1.SelfSubjectReview{Status: authnv1.SelfSubjectReviewStatus{UserInfo: authnv1.UserInfo{Username: username}}}, nil
+				})
+				k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+				setPhase(sessionPhaseRunning)
+			})
+
+			It("Should accept status from the session's own runner", func() {
+				username = "system:serviceaccount:" + testNamespace + ":" + runnerServiceAccountName(testSession)
+
+				updateStatus(map[string]interface{}{"sdkRestartCount": 1})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+			})
+
+			It("Should refuse status from another session's runner", func() {
+				username = "system:serviceaccount:" + testNamespace + ":" + runnerServiceAccountName("other-session")
+
+				updateStatus(map[string]interface{}{"phase": "Completed"})
+
+				httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Not allowed to update session status")
+				Expect(storedStatus()["phase"]).To(Equal(sessionPhaseRunning))
+			})
+		})
+
+		It("Should refuse callers who cannot update the session status", func() {
+			k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+			updateStatus(map[string]interface{}{"sdkRestartCount": 1})
+
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Not allowed to update session status")
+		})
+	})
+})
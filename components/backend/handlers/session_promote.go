@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/gitlab"
+	"ambient-code-backend/httpclient"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// promotedBranch is the branch a promoted workspace directory is pushed to
+const promotedBranch = "main"
+
+var (
+	// promoteRepoNamePattern matches repository names both providers accept
+	promoteRepoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+	// promoteOwnerPattern matches a user or organization, or for GitLab a nested group path
+	promoteOwnerPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*(/[A-Za-z0-9][A-Za-z0-9._-]*)*$`)
+)
+
+// PromoteRequest is the body of PromoteSessionWorkspace
+type PromoteRequest struct {
+	Path        string             `json:"path"`
+	Provider    types.ProviderType `json:"provider"`
+	Owner       string             `json:"owner"`
+	Name        string             `json:"name"`
+	Visibility  string             `json:"visibility"`
+	Description string             `json:"description"`
+}
+
+// validate normalizes the request and returns why it cannot be promoted, or ""
+func (r *PromoteRequest) validate() string {
+	r.Path = strings.Trim(path.Clean("/"+strings.TrimSpace(r.Path)), "/")
+	r.Owner = strings.TrimSpace(r.Owner)
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Visibility = strings.TrimSpace(r.Visibility); r.Visibility == "" {
+		r.Visibility = "private"
+	}
+	switch {
+	case r.Path == "":
+		return "path must name a directory in the session workspace"
+	case !r.Provider.IsValid():
+		return "provider must be github or gitlab"
+	case !promoteOwnerPattern.MatchString(r.Owner) || (r.Provider == types.ProviderGitHub && strings.Contains(r.Owner, "/")):
+		return "owner must be a user or organization name"
+	case !promoteRepoNamePattern.MatchString(r.Name) || r.Name == "." || r.Name == "..":
+		return "name may only contain letters, digits, '.', '-' and '_'"
+	case r.Visibility != "private" && r.Visibility != "internal" && r.Visibility != "public":
+		return "visibility must be private, internal or public"
+	}
+	return ""
+}
+
+// PromoteSessionWorkspace turns a directory of the session workspace into a new repository: the
+// repository is created on the provider with the caller's token, then the content service
+// initializes git in the directory, points origin at the new repository and pushes it to main.
+// The remote is recorded like ConfigureGitRemote does, and the new repository's URL in the
+// ambient-code.io/promoted-<path>-url annotation. A name already taken on the provider is a 409
+// with the existing repository's URL.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/promote
+func PromoteSessionWorkspace(c *gin.Context) {
+	project := c.Param("projectName")
+	session := c.Param("sessionName")
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var body PromoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
+		return
+	}
+	if msg := body.validate(); msg != "" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, msg, nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, session, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to read session")
+		return
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
+		return
+	}
+
+	headers := http.Header{}
+	forwardCallerToken(c, headers)
+	repo := git.NewRepo{Owner: body.Owner, Name: body.Name, Description: body.Description, Visibility: body.Visibility}
+	var repoURL string
+	switch body.Provider {
+	case types.ProviderGitHub:
+		userID, _ := c.Get("userID")
+		uid, _ := userID.(string)
+		token, err := GetGitHubToken(ctx, k8sClt, k8sDyn, project, uid, body.Owner)
+		if err != nil || strings.TrimSpace(token) == "" {
+			log.Printf("PromoteSessionWorkspace: no GitHub token for %s/%s: %v", project, session, err)
+			if !respondGitHubTokenError(c, err) {
+				respondError(c, http.StatusPreconditionFailed, ErrorKindPreconditionFailed, "A GitHub token is required to create the repository", nil)
+			}
+			return
+		}
+		apiBase := git.ResolveGitHubAPIBase("", projectSettingsGitHubAPIBase(c, k8sClt, k8sDyn, project))
+		// Checked before the repository exists, so a blocked host gets nothing at all
+		if !requireAllowedGitURLs(c, k8sDyn, project, fmt.Sprintf("https://%s/%s/%s", git.GitHubWebHost(apiBase), body.Owner, body.Name)) {
+			return
+		}
+		if repoURL, err = git.CreateGitHubRepo(ctx, apiBase, token, repo); err != nil {
+			if !respondRepoExists(c, body, err) {
+				log.Printf("PromoteSessionWorkspace: failed to create %s/%s for %s/%s: %v", body.Owner, body.Name, project, session, err)
+				respondUpstreamUnavailable(c, "GitHub did not create the repository")
+			}
+			return
+		}
+		headers.Set("X-GitHub-Token", token)
+		setGitCredentialsHeader(headers, git.Credentials{git.HostOf(repoURL): {Token: token}})
+		if author, ok := sessionGitAuthor(c, project, spec, repoURL, token); ok {
+			setGitAuthorHeaders(headers, author)
+		}
+	case types.ProviderGitLab:
+		client, token, ok := requireProjectGitLab(c, project)
+		if !ok {
+			return
+		}
+		if !requireAllowedGitURLs(c, k8sDyn, project, client.WebURL()+"/"+body.Owner+"/"+body.Name) {
+			return
+		}
+		created, err := client.CreateProject(ctx, gitlab.CreateProjectOptions{Namespace: repo.Owner, Name: repo.Name, Description: repo.Description, Visibility: repo.Visibility})
+		if err != nil {
+			if !respondRepoExists(c, body, err) {
+				respondGitLabError(c, err)
+			}
+			return
+		}
+		repoURL = created.HTTPURLToRepo
+		setGitCredentialsHeader(headers, git.Credentials{git.HostOf(repoURL): {Token: token, Scheme: "oauth2"}})
+	}
+	log.Printf("PromoteSessionWorkspace: created %s for %s/%s path %s", repoURL, project, session, body.Path)
+
+	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
+	if !postContentGit(c, content, "/content/git-configure-remote", headers, map[string]interface{}{
+		"path":      absPath,
+		"remoteUrl": repoURL,
+		"branch":    promotedBranch,
+	}) {
+		return
+	}
+	// Recorded before the push so a failed push can be retried through the git/push endpoint
+	if err := persistSessionRemote(ctx, k8sDyn, project, session, body.Path, repoURL, promotedBranch, nil); err != nil {
+		log.Printf("PromoteSessionWorkspace: failed to record remote for %s/%s: %v", project, session, err)
+	}
+	if !postContentGit(c, content, "/content/git-push", headers, map[string]interface{}{
+		"path":    absPath,
+		"branch":  promotedBranch,
+		"message": fmt.Sprintf("Promote %s from session %s", body.Path, session),
+	}) {
+		return
+	}
+	promoted := map[string]string{fmt.Sprintf("ambient-code.io/promoted-%s-url", sessionRemoteAnnotationKey(body.Path)): repoURL}
+	if err := persistSessionRemote(ctx, k8sDyn, project, session, body.Path, repoURL, promotedBranch, promoted); err != nil {
+		log.Printf("PromoteSessionWorkspace: failed to record promoted repo for %s/%s: %v", project, session, err)
+	}
+
+	resp := gin.H{"url": repoURL, "provider": body.Provider, "path": body.Path, "branch": promotedBranch}
+	if content.PodSpawned {
+		resp["podSpawned"] = true
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// respondRepoExists answers a *types.RepoExistsError with a 409 carrying the existing repository's
+// URL and reports whether err was one
+func respondRepoExists(c *gin.Context, body PromoteRequest, err error) bool {
+	var exists *types.RepoExistsError
+	if !errors.As(err, &exists) {
+		return false
+	}
+	respondError(c, http.StatusConflict, ErrorKindConflict,
+		fmt.Sprintf("Repository %s/%s already exists on %s", body.Owner, body.Name, body.Provider), gin.H{"url": exists.URL})
+	return true
+}
+
+// postContentGit posts payload to a content service git endpoint with headers. On failure it
+// writes the error response and returns false.
+func postContentGit(c *gin.Context, content contentEndpoint, endpoint string, headers http.Header, payload map[string]interface{}) bool {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
+		return false
+	}
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, endpoint, nil, strings.NewReader(string(b)))
+	if err != nil {
+		log.Printf("postContentGit: failed to create request for %s: %v", endpoint, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return false
+	}
+	req.Header = headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
+	if err != nil {
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return false
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return false
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("postContentGit: %s returned status=%d", endpoint, resp.StatusCode)
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,225 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("Session Workspace Promotion", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils      *test_utils.HTTPTestUtils
+		k8sUtils       *test_utils.K8sTestUtils
+		ctx            context.Context
+		testNamespace  string
+		testSession    string
+		testToken      string
+		repoExists     bool
+		createdAt      string
+		createdPayload map[string]interface{}
+		contentCalls   []string
+		contentBodies  []map[string]interface{}
+		contentCreds   string
+		pushFails      bool
+	)
+
+	BeforeEach(func() {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+		testNamespace = "test-project-" + suffix
+		testSession = "test-session-" + suffix
+		SetupHandlerDependencies(k8sUtils)
+		repoExists, createdAt, createdPayload, pushFails = false, "", nil, false
+		contentCalls, contentBodies, contentCreds = nil, nil, ""
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+		}, v1.CreateOptions{})
+		if err != nil && !errors.IsAlreadyExists(err) {
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+		Expect(err).NotTo(HaveOccurred())
+		testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+		Expect(err).NotTo(HaveOccurred())
+		createTestSession(testSession, testNamespace, k8sUtils)
+
+		github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/user":
+				_, _ = w.Write([]byte(`{"login":"alice","name":"Alice","email":"alice@example.com"}`))
+			case r.Method == http.MethodPost && (r.URL.Path == "/user/repos" || strings.HasPrefix(r.URL.Path, "/orgs/")):
+				if repoExists {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					_, _ = w.Write([]byte(`{"message":"Repository creation failed.","errors":[{"resource":"Repository","field":"name","message":"name already exists on this account"}]}`))
+					return
+				}
+				createdAt = r.URL.Path
+				_ = json.NewDecoder(r.Body).Decode(&createdPayload)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = fmt.Fprintf(w, `{"clone_url":"https://github.com/alice/%s.git"}`, createdPayload["name"])
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		DeferCleanup(github.Close)
+		GinkgoT().Setenv(git.GitHubAPIBaseEnv, github.URL)
+
+		_, err = k8sUtils.K8sClient.CoreV1().Services(testNamespace).Create(ctx, &corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-content-" + testSession, Namespace: testNamespace},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			contentCalls = append(contentCalls, r.URL.Path)
+			contentBodies = append(contentBodies, body)
+			contentCreds = r.Header.Get(gitCredentialsHeader)
+			if pushFails && r.URL.Path == "/content/git-push" {
+				w.WriteHeader(http.StatusBadGateway)
+				_, _ = w.Write([]byte(`{"error":{"kind":"UpstreamUnavailable","message":"Failed to push changes to the remote repository"}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"message":"ok"}`))
+		}))
+		DeferCleanup(upstream.Close)
+		previous := ContentResolver
+		ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+		DeferCleanup(func() { ContentResolver = previous })
+	})
+
+	promote := func(body map[string]interface{}) {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/promote", testNamespace, testSession), body)
+		httpUtils.SetAuthHeader(testToken)
+		c.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: testSession}}
+		c.Set("userID", "alice")
+		PromoteSessionWorkspace(c)
+	}
+
+	annotations := func() map[string]string {
+		obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return obj.GetAnnotations()
+	}
+
+	It("Should create the repo, push the directory to main and record it on the session", func() {
+		promote(map[string]interface{}{"path": "artifacts/report", "provider": "github", "owner": "alice", "name": "report", "description": "Generated report"})
+
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		var resp map[string]interface{}
+		httpUtils.GetResponseJSON(&resp)
+		Expect(resp).To(HaveKeyWithValue("url", "https://github.com/alice/report.git"))
+		Expect(resp).To(HaveKeyWithValue("branch", "main"))
+		Expect(createdAt).To(Equal("/user/repos"))
+		Expect(createdPayload).To(HaveKeyWithValue("private", true))
+		Expect(createdPayload).To(HaveKeyWithValue("description", "Generated report"))
+
+		workspacePath := "/sessions/" + testSession + "/workspace/artifacts/report"
+		Expect(contentCalls).To(Equal([]string{"/content/git-configure-remote", "/content/git-push"}))
+		Expect(contentBodies[0]).To(Equal(map[string]interface{}{"path": workspacePath, "remoteUrl": "https://github.com/alice/report.git", "branch": "main"}))
+		Expect(contentBodies[1]).To(HaveKeyWithValue("path", workspacePath))
+		Expect(contentBodies[1]).To(HaveKeyWithValue("branch", "main"))
+		Expect(contentCreds).To(MatchJSON(`{"github.com":{"token":"fake-github-token"}}`))
+
+		anns := annotations()
+		Expect(anns).To(HaveKeyWithValue("ambient-code.io/promoted-artifacts::report-url", "https://github.com/alice/report.git"))
+		Expect(anns).To(HaveKeyWithValue("ambient-code.io/remote-artifacts::report-url", "https://github.com/alice/report.git"))
+		Expect(anns).To(HaveKeyWithValue("ambient-code.io/remote-artifacts::report-branch", "main"))
+	})
+
+	It("Should create a repo for another owner in that organization", func() {
+		promote(map[string]interface{}{"path": "docs", "provider": "github", "owner": "platform", "name": "docs", "visibility": "public"})
+
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		Expect(createdAt).To(Equal("/orgs/platform/repos"))
+		Expect(createdPayload).To(HaveKeyWithValue("private", false))
+	})
+
+	It("Should answer a name already taken with 409 and the existing repo's URL", func() {
+		repoExists = true
+
+		promote(map[string]interface{}{"path": "docs", "provider": "github", "owner": "alice", "name": "docs"})
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Repository alice/docs already exists on github")
+		Expect(errorObj["details"]).To(HaveKeyWithValue("url", HaveSuffix("/alice/docs.git")))
+		Expect(contentCalls).To(BeEmpty())
+		Expect(annotations()).NotTo(HaveKey("ambient-code.io/promoted-docs-url"))
+	})
+
+	It("Should keep the remote but not mark the directory promoted when the push fails", func() {
+		pushFails = true
+
+		promote(map[string]interface{}{"path": "docs", "provider": "github", "owner": "alice", "name": "docs"})
+
+		httpUtils.AssertErrorResponse(http.StatusBadGateway, "UpstreamUnavailable", "Failed to push changes to the remote repository")
+		anns := annotations()
+		Expect(anns).To(HaveKeyWithValue("ambient-code.io/remote-docs-url", "https://github.com/alice/docs.git"))
+		Expect(anns).NotTo(HaveKey("ambient-code.io/promoted-docs-url"))
+	})
+
+	DescribeTable("Should reject an invalid request before creating anything",
+		func(body map[string]interface{}, msg string) {
+			promote(body)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", msg)
+			Expect(createdAt).To(BeEmpty())
+		},
+		Entry("missing path", map[string]interface{}{"path": "/", "provider": "github", "owner": "alice", "name": "x"}, "path must name a directory in the session workspace"),
+		Entry("unknown provider", map[string]interface{}{"path": "docs", "provider": "bitbucket", "owner": "alice", "name": "x"}, "provider must be github or gitlab"),
+		Entry("nested GitHub owner", map[string]interface{}{"path": "docs", "provider": "github", "owner": "a/b", "name": "x"}, "owner must be a user or organization name"),
+		Entry("bad name", map[string]interface{}{"path": "docs", "provider": "github", "owner": "alice", "name": "my repo"}, "name may only contain letters, digits, '.', '-' and '_'"),
+		Entry("bad visibility", map[string]interface{}{"path": "docs", "provider": "github", "owner": "alice", "name": "x", "visibility": "secret"}, "visibility must be private, internal or public"),
+	)
+
+	It("Should answer a GitLab project already at the path with 409", func() {
+		gitlabServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.EscapedPath() == "/api/v4/projects/platform%2Ftools%2Fdocs" {
+				_, _ = w.Write([]byte(`{"id":7,"path_with_namespace":"platform/tools/docs","http_url_to_repo":"https://gitlab.example.com/platform/tools/docs.git"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		DeferCleanup(gitlabServer.Close)
+		_, err := k8sUtils.K8sClient.CoreV1().Secrets(testNamespace).Create(ctx, &corev1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: gitLabTokenSecretName, Namespace: testNamespace},
+			Data:       map[string][]byte{"GITLAB_TOKEN": []byte("glpat-project")},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       map[string]interface{}{"gitlab": map[string]interface{}{"baseUrl": gitlabServer.URL, "tokenSecretKey": "GITLAB_TOKEN"}},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		promote(map[string]interface{}{"path": "docs", "provider": "gitlab", "owner": "platform/tools", "name": "docs"})
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Repository platform/tools/docs already exists on gitlab")
+		Expect(errorObj["details"]).To(HaveKeyWithValue("url", "https://gitlab.example.com/platform/tools/docs.git"))
+		Expect(contentCalls).To(BeEmpty())
+	})
+})
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultMaxInlinePromptBytes is the largest initial prompt kept inline in spec.initialPrompt.
+// Override with MAX_INLINE_PROMPT_BYTES.
+const defaultMaxInlinePromptBytes = 32 * 1024
+
+// maxStoredPromptBytes caps prompts stored in a ConfigMap, leaving headroom below the 1 MiB object limit
+const maxStoredPromptBytes = 1000 * 1024
+
+// sessionPromptKey is the ConfigMap key holding an externally stored prompt
+const sessionPromptKey = "prompt"
+
+// maxInlinePromptBytes returns the configured inline prompt limit
+func maxInlinePromptBytes() int {
+	if raw := strings.TrimSpace(os.Getenv("MAX_INLINE_PROMPT_BYTES")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxInlinePromptBytes
+}
+
+// sessionPromptConfigMapName returns the name of the ConfigMap holding a session's prompt
+func sessionPromptConfigMapName(sessionName string) string {
+	return fmt.Sprintf("ambient-prompt-%s", sessionName)
+}
+
+// externalizeInitialPrompt replaces a spec.initialPrompt larger than maxInlinePromptBytes with a
+// spec.promptRef and returns the prompt to store; it returns "" when the prompt stays inline
+func externalizeInitialPrompt(spec map[string]interface{}, sessionName string) string {
+	prompt, _ := spec["initialPrompt"].(string)
+	if len(prompt) <= maxInlinePromptBytes() {
+		return ""
+	}
+	delete(spec, "initialPrompt")
+	spec["promptRef"] = map[string]interface{}{
+		"configMapName": sessionPromptConfigMapName(sessionName),
+		"key":           sessionPromptKey,
+	}
+	return prompt
+}
+
+// storeSessionPrompt creates the ConfigMap referenced by the session's promptRef, owned by the
+// session so it is garbage collected with it. It uses the backend service account because project
+// editors cannot create ConfigMaps.
+func storeSessionPrompt(ctx context.Context, session *unstructured.Unstructured, prompt string) error {
+	if K8sClient == nil {
+		return fmt.Errorf("backend service account client not available")
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      sessionPromptConfigMapName(session.GetName()),
+			Namespace: session.GetNamespace(),
+			Labels: map[string]string{
+				"app":                     "ambient-code",
+				"ambient-code.io/session": session.GetName(),
+			},
+			OwnerReferences: []v1.OwnerReference{{
+				APIVersion: session.GetAPIVersion(),
+				Kind:       session.GetKind(),
+				Name:       session.GetName(),
+				UID:        session.GetUID(),
+			}},
+		},
+		Data: map[string]string{sessionPromptKey: prompt},
+	}
+	_, err := K8sClient.CoreV1().ConfigMaps(session.GetNamespace()).Create(ctx, cm, v1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Left behind by a deleted session of the same name
+		_, err = K8sClient.CoreV1().ConfigMaps(session.GetNamespace()).Update(ctx, cm, v1.UpdateOptions{})
+	}
+	return err
+}
+
+// replaceSessionPrompt stores prompt in the ConfigMap of an existing session ahead of the session
+// update that points promptRef at it, and returns a func that puts the ConfigMap back as it was
+// (or deletes it) for when that update fails.
+func replaceSessionPrompt(ctx context.Context, session *unstructured.Unstructured, prompt string) (func(), error) {
+	if K8sClient == nil {
+		return nil, fmt.Errorf("backend service account client not available")
+	}
+	configMaps := K8sClient.CoreV1().ConfigMaps(session.GetNamespace())
+	name := sessionPromptConfigMapName(session.GetName())
+	previous, err := configMaps.Get(ctx, name, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		previous = nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := storeSessionPrompt(ctx, session, prompt); err != nil {
+		return nil, err
+	}
+	return func() {
+		var err error
+		if previous == nil {
+			err = configMaps.Delete(ctx, name, v1.DeleteOptions{})
+		} else {
+			previous.ResourceVersion = ""
+			_, err = configMaps.Update(ctx, previous, v1.UpdateOptions{})
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("Warning: failed to restore prompt ConfigMap %s/%s: %v", session.GetNamespace(), name, err)
+		}
+	}, nil
+}
+
+// createSessionWithPrompt creates a session, moving an oversized initial prompt into its prompt
+// ConfigMap. The session is deleted again if the prompt cannot be stored. Dry runs render the
+// promptRef but store nothing.
+func createSessionWithPrompt(ctx context.Context, dyn dynamic.Interface, obj *unstructured.Unstructured, opts v1.CreateOptions) (*unstructured.Unstructured, error) {
+	var prompt string
+	if spec, ok := obj.Object["spec"].(map[string]interface{}); ok {
+		prompt = externalizeInitialPrompt(spec, obj.GetName())
+	}
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	created, err := dyn.Resource(gvr).Namespace(obj.GetNamespace()).Create(ctx, obj, opts)
+	if err != nil || prompt == "" || len(opts.DryRun) > 0 {
+		return created, err
+	}
+	if err := storeSessionPrompt(ctx, created, prompt); err != nil {
+		if delErr := dyn.Resource(gvr).Namespace(created.GetNamespace()).Delete(ctx, created.GetName(), v1.DeleteOptions{}); delErr != nil && !errors.IsNotFound(delErr) {
+			log.Printf("Warning: failed to delete session %s/%s after prompt storage error: %v", created.GetNamespace(), created.GetName(), delErr)
+		}
+		return nil, fmt.Errorf("store initial prompt: %w", err)
+	}
+	return created, nil
+}
+
+// resolveSessionPrompt returns the prompt a session's promptRef points at, or "" when the session
+// has none. Only the session's own prompt ConfigMap is read: it is read with the backend service
+// account, so arbitrary references must not be followed.
+func resolveSessionPrompt(ctx context.Context, k8s kubernetes.Interface, session *unstructured.Unstructured) (string, error) {
+	name, _, _ := unstructured.NestedString(session.Object, "spec", "promptRef", "configMapName")
+	key, _, _ := unstructured.NestedString(session.Object, "spec", "promptRef", "key")
+	if name == "" {
+		return "", nil
+	}
+	if name != sessionPromptConfigMapName(session.GetName()) {
+		return "", fmt.Errorf("promptRef %q does not belong to session %s", name, session.GetName())
+	}
+	if k8s == nil {
+		return "", fmt.Errorf("backend service account client not available")
+	}
+	cm, err := k8s.CoreV1().ConfigMaps(session.GetNamespace()).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if !ownedByUID(cm.OwnerReferences, session.GetUID()) {
+		return "", fmt.Errorf("ConfigMap %s is not owned by session %s", name, session.GetName())
+	}
+	prompt, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap %s has no key %q", name, key)
+	}
+	return prompt, nil
+}
+
+// inlineSessionPrompt replaces the session's promptRef with the prompt it points at, for copies
+// of a session (clones, exported bundles) that must not share the source's ConfigMap
+func inlineSessionPrompt(c *gin.Context, session *unstructured.Unstructured) error {
+	prompt, err := resolveSessionPrompt(c.Request.Context(), K8sClient, session)
+	if err != nil || prompt == "" {
+		return err
+	}
+	spec, _ := session.Object["spec"].(map[string]interface{})
+	delete(spec, "promptRef")
+	spec["initialPrompt"] = prompt
+	return nil
+}
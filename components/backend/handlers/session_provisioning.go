@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// provisioningErrorAnnotation holds why the last runner token provisioning of a session failed.
+// Provisioning that succeeds removes it.
+const provisioningErrorAnnotation = "ambient-code.io/provisioning-error"
+
+// conditionRunnerAuthProvisioned is False while a session's runner ServiceAccount, RBAC or token
+// could not be provisioned; it is removed again once provisioning succeeds
+const conditionRunnerAuthProvisioned = "RunnerAuthProvisioned"
+
+// provisioningFailedMessage is what callers and the session see when provisioning fails; the
+// Kubernetes error itself may name cluster internals, so it only goes to the backend log.
+const provisioningFailedMessage = "Runner token provisioning failed; see the backend logs for details"
+
+// provisioningSkippedMessage is recorded when the backend SA clients are missing
+const provisioningSkippedMessage = "Runner token provisioning was skipped: the backend service account is not available"
+
+// provisionNewSessionRunnerAuth provisions the runner token of a session just created or imported
+// and returns the warnings for the create response. A failure does not fail the create: it is
+// recorded on the session (see setProvisioningError) for GET and provision-retry. Without the
+// backend SA clients the skip is recorded with reqDyn, the caller's client, as far as it may write.
+func provisionNewSessionRunnerAuth(c *gin.Context, reqDyn dynamic.Interface, project, name string) []string {
+	if DynamicClient == nil || K8sClient == nil {
+		log.Printf("Warning: backend SA clients not available, skipping runner token provisioning for session %s/%s", project, name)
+		recorder := DynamicClient
+		if recorder == nil {
+			recorder = reqDyn
+		}
+		if recorder != nil {
+			if recErr := setProvisioningError(c.Request.Context(), recorder, project, name, provisioningSkippedMessage); recErr != nil {
+				log.Printf("Warning: failed to record skipped provisioning on session %s/%s: %v", project, name, recErr)
+			}
+		}
+		return []string{provisioningSkippedMessage}
+	}
+	err := provisionRunnerTokenForSession(c, K8sClient, DynamicClient, project, name)
+	if err == nil {
+		return nil
+	}
+	log.Printf("Warning: failed to provision runner token for session %s/%s: %v", project, name, err)
+	if recErr := setProvisioningError(c.Request.Context(), DynamicClient, project, name, provisioningFailedMessage); recErr != nil {
+		log.Printf("Warning: failed to record provisioning error on session %s/%s: %v", project, name, recErr)
+	}
+	return []string{fmt.Sprintf("%s. The session cannot start until it is provisioned; retry with POST .../agentic-sessions/%s/provision-retry", provisioningFailedMessage, name)}
+}
+
+// setProvisioningError records msg as the session's provisioning error: the
+// ambient-code.io/provisioning-error annotation and a False RunnerAuthProvisioned condition. An
+// empty msg removes both. The backend SA writes them, since session creators cannot write status.
+func setProvisioningError(ctx context.Context, dyn dynamic.Interface, project, name, msg string) error {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := dyn.Resource(gvr).Namespace(project).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		anns := obj.GetAnnotations()
+		if current, has := anns[provisioningErrorAnnotation]; (msg == "" && has) || (msg != "" && current != msg) {
+			if anns == nil {
+				anns = map[string]string{}
+			}
+			if msg == "" {
+				delete(anns, provisioningErrorAnnotation)
+			} else {
+				anns[provisioningErrorAnnotation] = msg
+			}
+			obj.SetAnnotations(anns)
+			if obj, err = dyn.Resource(gvr).Namespace(project).Update(ctx, obj, v1.UpdateOptions{}); err != nil {
+				return err
+			}
+		}
+
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		kept := make([]interface{}, 0, len(conditions)+1)
+		for _, cond := range conditions {
+			if m, ok := cond.(map[string]interface{}); ok && m["type"] == conditionRunnerAuthProvisioned {
+				continue
+			}
+			kept = append(kept, cond)
+		}
+		if msg == "" && len(kept) == len(conditions) {
+			return nil
+		}
+		if msg != "" {
+			kept = append(kept, map[string]interface{}{
+				"type":               conditionRunnerAuthProvisioned,
+				"status":             "False",
+				"reason":             "ProvisioningFailed",
+				"message":            msg,
+				"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+			})
+		}
+		if err := unstructured.SetNestedSlice(obj.Object, kept, "status", "conditions"); err != nil {
+			return err
+		}
+		_, err = dyn.Resource(gvr).Namespace(project).UpdateStatus(ctx, obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// RetrySessionProvisioning re-runs runner token provisioning for a session, e.g. after the
+// backend's RBAC was fixed. Provisioning brings existing objects up to date, so it is safe to repeat.
+// On success the session's provisioning error is cleared; on failure it is updated.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/provision-retry
+func RetrySessionProvisioning(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if _, err := reqDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{}); err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
+		return
+	}
+	// The backend SA does the work, so the caller must be allowed to change the session themselves
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:     "vteam.ambient-code",
+				Resource:  "agenticsessions",
+				Verb:      "update",
+				Namespace: project,
+				Name:      sessionName,
+			},
+		},
+	}
+	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("RetrySessionProvisioning: access review failed for %s/%s: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to check permissions", nil)
+		return
+	}
+	if !res.Status.Allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Insufficient permissions", nil)
+		return
+	}
+	if DynamicClient == nil || K8sClient == nil {
+		respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, "Backend service account not available", nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := provisionRunnerTokenForSession(c, K8sClient, DynamicClient, project, sessionName); err != nil {
+		log.Printf("RetrySessionProvisioning: provisioning %s/%s failed again: %v", project, sessionName, err)
+		if recErr := setProvisioningError(ctx, DynamicClient, project, sessionName, provisioningFailedMessage); recErr != nil {
+			log.Printf("RetrySessionProvisioning: failed to record provisioning error on %s/%s: %v", project, sessionName, recErr)
+		}
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Runner token provisioning failed",
+			gin.H{"reason": provisioningFailedMessage, "ready": false})
+		return
+	}
+	if err := setProvisioningError(ctx, DynamicClient, project, sessionName, ""); err != nil {
+		log.Printf("RetrySessionProvisioning: failed to clear provisioning error on %s/%s: %v", project, sessionName, err)
+		respondK8sError(c, err, "Session not found", "Provisioned, but failed to clear the provisioning error")
+		return
+	}
+	log.Printf("RetrySessionProvisioning: provisioned runner token for %s/%s", project, sessionName)
+	c.JSON(http.StatusOK, gin.H{"message": "Runner token provisioned", "ready": true})
+}
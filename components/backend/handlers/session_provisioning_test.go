@@ -0,0 +1,171 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Session Provisioning", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+		denyRBAC      bool
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		fakeK8s, ok := k8sUtils.K8sClient.(*k8sfake.Clientset)
+		Expect(ok).To(BeTrue())
+		denyRBAC = true
+		fakeK8s.PrependReactor("create", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if !denyRBAC {
+				return false, nil, nil
+			}
+			return true, nil, errors.NewForbidden(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"}, "", fmt.Errorf("denied"))
+		})
+		fakeK8s.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if action.GetSubresource() != "token" {
+				return false, nil, nil
+			}
+			return true, &authnv1.TokenRequest{Status: authnv1.TokenRequestStatus{Token: "runner-token"}}, nil
+		})
+	})
+
+	create := func() map[string]interface{} {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", map[string]interface{}{"initialPrompt": "hello"})
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		CreateSession(c)
+		httpUtils.AssertHTTPStatus(http.StatusCreated)
+		var response map[string]interface{}
+		httpUtils.GetResponseJSON(&response)
+		return response
+	}
+
+	retry := func(name string) {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/"+name+"/provision-retry", nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: name}}
+		RetrySessionProvisioning(c)
+	}
+
+	provisioningCondition := func(obj *unstructured.Unstructured) map[string]interface{} {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, cond := range conditions {
+			if m, ok := cond.(map[string]interface{}); ok && m["type"] == conditionRunnerAuthProvisioned {
+				return m
+			}
+		}
+		return nil
+	}
+
+	stored := func(name string) *unstructured.Unstructured {
+		obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return obj
+	}
+
+	It("Should create the session with warnings and record the failure when provisioning fails", func() {
+		response := create()
+
+		Expect(response["ready"]).To(BeFalse())
+		Expect(response["warnings"]).To(ConsistOf(ContainSubstring(provisioningFailedMessage)))
+		Expect(response["warnings"]).To(ConsistOf(Not(ContainSubstring("denied"))))
+		obj := stored(response["name"].(string))
+		Expect(obj.GetAnnotations()).To(HaveKeyWithValue(provisioningErrorAnnotation, provisioningFailedMessage))
+		cond := provisioningCondition(obj)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond["status"]).To(Equal("False"))
+		Expect(cond["reason"]).To(Equal("ProvisioningFailed"))
+	})
+
+	It("Should record a skipped provisioning when the backend SA clients are not available", func() {
+		savedK8s := K8sClient
+		K8sClient = nil
+		DeferCleanup(func() { K8sClient = savedK8s })
+
+		response := create()
+
+		Expect(response["ready"]).To(BeFalse())
+		Expect(response["warnings"]).To(ConsistOf(provisioningSkippedMessage))
+		obj := stored(response["name"].(string))
+		Expect(obj.GetAnnotations()).To(HaveKeyWithValue(provisioningErrorAnnotation, provisioningSkippedMessage))
+		cond := provisioningCondition(obj)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond["message"]).To(Equal(provisioningSkippedMessage))
+	})
+
+	It("Should report ready without warnings when provisioning succeeds", func() {
+		denyRBAC = false
+
+		response := create()
+
+		Expect(response["ready"]).To(BeTrue())
+		Expect(response).NotTo(HaveKey("warnings"))
+		Expect(stored(response["name"].(string)).GetAnnotations()).NotTo(HaveKey(provisioningErrorAnnotation))
+	})
+
+	It("Should clear the recorded failure once a retry succeeds", func() {
+		name := create()["name"].(string)
+
+		retry(name)
+		httpUtils.AssertHTTPStatus(http.StatusInternalServerError)
+		var failed map[string]interface{}
+		httpUtils.GetResponseJSON(&failed)
+		Expect(failed["error"]).To(HaveKeyWithValue("details", HaveKeyWithValue("reason", provisioningFailedMessage)))
+		Expect(stored(name).GetAnnotations()).To(HaveKey(provisioningErrorAnnotation))
+
+		denyRBAC = false
+		retry(name)
+		httpUtils.AssertHTTPStatus(http.StatusOK)
+		obj := stored(name)
+		Expect(obj.GetAnnotations()).NotTo(HaveKey(provisioningErrorAnnotation))
+		Expect(provisioningCondition(obj)).To(BeNil())
+	})
+
+	It("Should refuse a retry by a caller who cannot update the session", func() {
+		name := create()["name"].(string)
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+		retry(name)
+
+		httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Insufficient permissions")
+		Expect(stored(name).GetAnnotations()).To(HaveKey(provisioningErrorAnnotation))
+	})
+
+	It("Should return 404 for a session that does not exist", func() {
+		retry("missing-session")
+
+		httpUtils.AssertHTTPStatus(http.StatusNotFound)
+	})
+})
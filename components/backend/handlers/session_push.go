@@ -0,0 +1,449 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"ambient-code-backend/git"
+	"ambient-code-backend/httpclient"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// parallelPushWorkerCount is the number of concurrent pushes issued by PushAllSessionRepos
+const parallelPushWorkerCount = 4
+
+// repoPushTarget is a session repo resolved for a push through the content service
+type repoPushTarget struct {
+	Index     int
+	InputURL  string
+	RepoPath  string
+	OutputURL string
+	Branch    string
+	// PersistBranch is set when Branch was rendered from the project's outputBranchTemplate and
+	// should be recorded in spec.repos[Index].output.branch once the push succeeds
+	PersistBranch bool
+	// AutoFork is output.autoFork: push to a fork when the owner cannot push to OutputURL
+	AutoFork bool
+}
+
+// resolveRepoPushTarget derives the workspace path, output URL and branch of spec.repos[index].
+// OutputURL is empty when the repo has no output configured.
+func resolveRepoPushTarget(session string, index int, rm map[string]interface{}) repoPushTarget {
+	// default branch when not defined on output
+	t := repoPushTarget{Index: index, Branch: fmt.Sprintf("sessions/%s", session)}
+	if urlv, ok := rm["url"].(string); ok {
+		t.InputURL = strings.TrimSpace(urlv)
+	}
+	// Derive repoPath from input URL folder name
+	if in, ok := rm["input"].(map[string]interface{}); ok {
+		if urlv, ok2 := in["url"].(string); ok2 && strings.TrimSpace(urlv) != "" {
+			t.InputURL = strings.TrimSpace(urlv)
+			if folder := DeriveRepoFolderFromURL(strings.TrimSpace(urlv)); folder != "" {
+				t.RepoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, folder)
+			}
+		}
+	}
+	if out, ok := rm["output"].(map[string]interface{}); ok {
+		if urlv, ok2 := out["url"].(string); ok2 && strings.TrimSpace(urlv) != "" {
+			t.OutputURL = strings.TrimSpace(urlv)
+		}
+		if bs, ok2 := out["branch"].(string); ok2 && strings.TrimSpace(bs) != "" {
+			t.Branch = strings.TrimSpace(bs)
+		}
+	}
+	t.AutoFork = repoAutoFork(rm)
+	// A stored folder is where the runner cloned the repo, whatever the URL derives to
+	if folder, _ := rm["folder"].(string); strings.TrimSpace(folder) != "" {
+		t.RepoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, strings.TrimSpace(folder))
+	}
+	// If input URL missing or unparsable, fall back to numeric index path (last resort)
+	if t.RepoPath == "" {
+		t.RepoPath = fmt.Sprintf("/sessions/%s/workspace/%d", session, index)
+	}
+	return t
+}
+
+// sessionGitHubToken mints a short-lived GitHub token for the session owner (spec.userContext.userId)
+// with their GitHub App installation for repo's owner (see git.GetGitHubToken).
+// Failures are logged and yield an empty token so pushes fall back to the content service's own credentials.
+func sessionGitHubToken(c *gin.Context, project string, spec map[string]interface{}, repo string) string {
+	tokenStr, err := resolveSessionGitHubToken(c, project, spec, repo)
+	if err != nil {
+		log.Printf("sessionGitHubToken: failed to resolve GitHub token: %v", err)
+		return ""
+	}
+	return tokenStr
+}
+
+// resolveSessionGitHubToken is sessionGitHubToken returning GetGitHubToken's error. A session
+// without an owner or a request without clients yields no token and no error.
+func resolveSessionGitHubToken(c *gin.Context, project string, spec map[string]interface{}, repo string) (string, error) {
+	userID := ""
+	if uc, ok := spec["userContext"].(map[string]interface{}); ok {
+		if v, ok := uc["userId"].(string); ok {
+			userID = strings.TrimSpace(v)
+		}
+	}
+	if userID == "" {
+		log.Printf("sessionGitHubToken: session in %s missing userContext.userId; proceeding without token", project)
+		return "", nil
+	}
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil || GetGitHubToken == nil {
+		return "", nil
+	}
+	tokenStr, err := GetGitHubToken(c.Request.Context(), k8sClt, k8sDyn, project, userID, repo)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(tokenStr), nil
+}
+
+// requireSessionGitHubToken resolves the session owner's GitHub token for a push to outputURLs,
+// minted for the owner of the first GitHub repo among them.
+// When one of them is a GitHub repo and GetGitHubToken classified the failure, it answers with
+// respondGitHubTokenError and returns false; other failures yield an empty token as in
+// sessionGitHubToken.
+func requireSessionGitHubToken(c *gin.Context, project string, spec map[string]interface{}, outputURLs []string) (string, bool) {
+	tokenStr, err := resolveSessionGitHubToken(c, project, spec, firstGitHubRepo(outputURLs))
+	if err == nil {
+		return tokenStr, true
+	}
+	log.Printf("sessionGitHubToken: failed to resolve GitHub token: %v", err)
+	for _, u := range outputURLs {
+		if types.DetectProvider(u) == types.ProviderGitHub {
+			return "", !respondGitHubTokenError(c, err)
+		}
+	}
+	return "", true
+}
+
+// PushRepoOutcome is the per-repo result of PushAllSessionRepos
+type PushRepoOutcome struct {
+	RepoIndex int    `json:"repoIndex"`
+	URL       string `json:"url,omitempty"`
+	OutputURL string `json:"outputUrl"`
+	Success   bool   `json:"success"`
+	SHA       string `json:"sha,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// SecretFindings lists what the content service's secret scan flagged when it blocked the push
+	SecretFindings []git.SecretFinding `json:"secretFindings,omitempty"`
+}
+
+// pushRepoThroughContent pushes one repo via the content service's /content/github/push
+func pushRepoThroughContent(ctx context.Context, content contentEndpoint, headers http.Header, t repoPushTarget, commitMessage string) PushRepoOutcome {
+	outcome := PushRepoOutcome{RepoIndex: t.Index, URL: t.InputURL, OutputURL: t.OutputURL, Branch: t.Branch}
+	b, err := json.Marshal(map[string]interface{}{
+		"repoPath":      t.RepoPath,
+		"commitMessage": commitMessage,
+		"branch":        t.Branch,
+		"outputRepoUrl": t.OutputURL,
+	})
+	if err != nil {
+		outcome.Error = "Failed to prepare request"
+		return outcome
+	}
+	req, err := content.newRequest(ctx, http.MethodPost, "/content/github/push", nil, strings.NewReader(string(b)))
+	if err != nil {
+		log.Printf("pushAllSessionRepos: failed to create request for repo %d: %v", t.Index, err)
+		outcome.Error = "Failed to create request"
+		return outcome
+	}
+	req.Header = headers.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpclient.Do(req, httpclient.Push)
+	if err != nil {
+		log.Printf("pushAllSessionRepos: content service request failed for repo %d: %v", t.Index, err)
+		outcome.Error = "Content service unavailable"
+		return outcome
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		outcome.Error = "Failed to read response from content service"
+		return outcome
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("pushAllSessionRepos: content returned status=%d for repo %d", resp.StatusCode, t.Index)
+		// Same sanitization as respondContentServiceError: only structured messages are relayed
+		outcome.Error = "Content service request failed"
+		var upstream struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(bodyBytes, &upstream); err == nil && strings.TrimSpace(upstream.Error.Message) != "" {
+			outcome.Error = upstream.Error.Message
+		}
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			outcome.SecretFindings = contentSecretFindings(bodyBytes)
+		}
+		return outcome
+	}
+
+	var pushResult struct {
+		SHA    string `json:"sha"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal(bodyBytes, &pushResult); err == nil {
+		outcome.SHA = strings.TrimSpace(pushResult.SHA)
+		if branch := strings.TrimSpace(pushResult.Branch); branch != "" {
+			outcome.Branch = branch
+		}
+	}
+	outcome.Success = true
+	return outcome
+}
+
+// PushAllSessionRepos pushes every repo with an output (or the selected ones) in a single call.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/github/push-all
+// Body: { commitMessage: string, repoIndexes?: []int }
+// The GitHub token is minted once and the pushes run concurrently; a failed push does not abort
+// the others, and each successful push is recorded on its repo's status entry. Repos blocked by the
+// secret scan report their findings; allowSecrets is only supported by the single-repo push.
+func PushAllSessionRepos(c *gin.Context) {
+	project := c.Param("projectName")
+	session := c.Param("sessionName")
+
+	var body struct {
+		CommitMessage string `json:"commitMessage"`
+		RepoIndexes   []int  `json:"repoIndexes"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid JSON body", nil)
+		return
+	}
+
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	obj, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to read session")
+		return
+	}
+	spec, _ := obj.Object["spec"].(map[string]interface{})
+	repos, _ := spec["repos"].([]interface{})
+
+	var targets []repoPushTarget
+	if body.RepoIndexes == nil {
+		for i, r := range repos {
+			rm, _ := r.(map[string]interface{})
+			if t := resolveRepoPushTarget(session, i, rm); t.OutputURL != "" {
+				targets = append(targets, t)
+			}
+		}
+	} else {
+		seen := map[int]bool{}
+		for _, i := range body.RepoIndexes {
+			if i < 0 || i >= len(repos) {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("invalid repo index %d", i), nil)
+				return
+			}
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			rm, _ := repos[i].(map[string]interface{})
+			t := resolveRepoPushTarget(session, i, rm)
+			if t.OutputURL == "" {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("repo %d has no output repo url", i), nil)
+				return
+			}
+			targets = append(targets, t)
+		}
+	}
+	if len(targets) == 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "no repos with an output to push", nil)
+		return
+	}
+	// Repos without an output branch use the project's outputBranchTemplate, rendered once per session
+	for i := range targets {
+		rm, _ := repos[targets[i].Index].(map[string]interface{})
+		if repoOutputBranch(rm) != "" {
+			continue
+		}
+		branch, err := sessionOutputBranch(c.Request.Context(), k8sDyn, project, obj)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+		if branch == "" {
+			break
+		}
+		for j := i; j < len(targets); j++ {
+			if rm, _ := repos[targets[j].Index].(map[string]interface{}); repoOutputBranch(rm) == "" {
+				targets[j].Branch, targets[j].PersistBranch = branch, true
+			}
+		}
+		break
+	}
+
+	// Output URLs may have changed since create, so they are checked against the allowlist on every push
+	allowedHosts, ok := requireAllowedGitHosts(c, k8sDyn, project)
+	if !ok {
+		return
+	}
+	for _, t := range targets {
+		if !requireGitURLsInHosts(c, project, allowedHosts, t.OutputURL) {
+			return
+		}
+	}
+
+	// Resolve the content service last so request validation fails fast without spawning a pod
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
+		return
+	}
+
+	headers := http.Header{}
+	forwardCallerToken(c, headers)
+	author, authorResolved := git.BotIdentity(), false
+	outputURLs := make([]string, 0, len(targets))
+	for _, t := range targets {
+		outputURLs = append(outputURLs, t.OutputURL)
+	}
+	token, ok := requireSessionGitHubToken(c, project, spec, outputURLs)
+	if !ok {
+		return
+	}
+	if token != "" {
+		author, authorResolved = sessionGitAuthor(c, project, spec, targets[0].OutputURL, token)
+	}
+	// The token was minted for the first GitHub repo's owner; repos owned by another account get one
+	// from the user's installation on that account, so one push can cover several organizations
+	tokens := make([]string, len(targets))
+	ownerTokens := map[string]string{strings.ToLower(git.GitHubRepoOwner(firstGitHubRepo(outputURLs))): token}
+	for i, t := range targets {
+		tokens[i] = token
+		if token == "" || types.DetectProvider(t.OutputURL) != types.ProviderGitHub {
+			continue
+		}
+		owner := strings.ToLower(git.GitHubRepoOwner(t.OutputURL))
+		ownerToken, ok := ownerTokens[owner]
+		if !ok {
+			if ownerToken = sessionGitHubToken(c, project, spec, t.OutputURL); ownerToken == "" {
+				ownerToken = token
+			}
+			ownerTokens[owner] = ownerToken
+		}
+		tokens[i] = ownerToken
+	}
+	forkAPIBases := map[int]string{}
+	for i, t := range targets {
+		if t.AutoFork && tokens[i] != "" {
+			forkAPIBases[t.Index] = projectGitHubAPIBase(c, k8sClt, k8sDyn, project, t.OutputURL)
+		}
+	}
+	setGitAuthorHeaders(headers, author)
+	tokenHeaders := map[string]http.Header{}
+	for _, tok := range tokens {
+		if _, ok := tokenHeaders[tok]; ok {
+			continue
+		}
+		h := headers.Clone()
+		if tok != "" {
+			h.Set("X-GitHub-Token", tok)
+		}
+		setGitCredentialsHeader(h, sessionGitCredentials(c, project, spec, tok, outputURLs))
+		content.sign(h)
+		tokenHeaders[tok] = h
+	}
+	log.Printf("pushAllSessionRepos: pushing %d repos project=%s session=%s endpoint=%s", len(targets), project, session, content.BaseURL)
+
+	workerCount := parallelPushWorkerCount
+	if len(targets) < workerCount {
+		workerCount = len(targets)
+	}
+	ctx := c.Request.Context()
+	results := make([]PushRepoOutcome, len(targets))
+	workChan := make(chan int, len(targets))
+	var wg sync.WaitGroup
+	for w := 0; w < workerCount; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range workChan {
+				t := targets[i]
+				if err := forkOutputRepo(ctx, forkAPIBases[t.Index], project, session, &t, tokens[i], allowedHosts); err != nil {
+					log.Printf("pushAllSessionRepos: failed to fork output repo of %s/%s repo %d: %v", project, session, t.Index, err)
+					msg := "Failed to fork the output repository"
+					if err == errForkHostNotAllowed {
+						msg = "The fork of the output repository is outside the project's allowed git hosts"
+					}
+					results[i] = PushRepoOutcome{RepoIndex: t.Index, URL: t.InputURL, OutputURL: t.OutputURL, Branch: t.Branch, Error: msg}
+					continue
+				}
+				results[i] = pushRepoThroughContent(ctx, content, tokenHeaders[tokens[i]], t, body.CommitMessage)
+				if DynamicClient == nil {
+					continue
+				}
+				if !results[i].Success {
+					if len(results[i].SecretFindings) > 0 && t.InputURL != "" {
+						if err := setRepoStatus(ctx, DynamicClient, project, session, t.InputURL, map[string]interface{}{"secretFindings": secretFindingsStatus(results[i].SecretFindings)}); err != nil {
+							log.Printf("pushAllSessionRepos: failed to record secret findings for %s/%s repo %d: %v", project, session, t.Index, err)
+						}
+					}
+					continue
+				}
+				if t.PersistBranch {
+					if err := persistRepoOutputBranch(ctx, DynamicClient, project, session, t.Index, t.Branch); err != nil {
+						log.Printf("pushAllSessionRepos: failed to record output branch for %s/%s repo %d: %v", project, session, t.Index, err)
+					}
+				}
+				if results[i].SHA == "" || t.InputURL == "" {
+					continue
+				}
+				fields := map[string]interface{}{"pushedSha": results[i].SHA, "secretFindings": []interface{}{}}
+				if results[i].Branch != "" {
+					fields["pushedBranch"] = results[i].Branch
+				}
+				if err := setRepoStatus(ctx, DynamicClient, project, session, t.InputURL, fields); err != nil {
+					log.Printf("pushAllSessionRepos: failed to record pushed sha for %s/%s repo %d: %v", project, session, t.Index, err)
+				}
+			}
+		}()
+	}
+	for i := range targets {
+		workChan <- i
+	}
+	close(workChan)
+	wg.Wait()
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Success {
+			succeeded++
+		}
+	}
+	resp := gin.H{
+		"results":   results,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	}
+	resp["author"] = gin.H{"name": author.Name, "email": author.Email}
+	if !authorResolved {
+		resp["authorFallback"] = true
+		resp["authorNote"] = gitIdentityFallbackNote
+	}
+	if content.PodSpawned {
+		resp["podSpawned"] = true
+	}
+	c.JSON(http.StatusOK, resp)
+}
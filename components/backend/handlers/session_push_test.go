@@ -0,0 +1,336 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"ambient-code-backend/git"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var _ = Describe("Session Push All", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	BeforeEach(func() {
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	Context("resolveRepoPushTarget", func() {
+		It("Should derive the workspace path from the input url and use the output branch", func() {
+			t := resolveRepoPushTarget("s1", 2, map[string]interface{}{
+				"input":  map[string]interface{}{"url": "https://github.com/o/api"},
+				"output": map[string]interface{}{"url": "https://github.com/me/api", "branch": "feature"},
+			})
+
+			Expect(t.Index).To(Equal(2))
+			Expect(t.InputURL).To(Equal("https://github.com/o/api"))
+			Expect(t.RepoPath).To(Equal("/sessions/s1/workspace/api"))
+			Expect(t.OutputURL).To(Equal("https://github.com/me/api"))
+			Expect(t.Branch).To(Equal("feature"))
+		})
+
+		It("Should use the stored folder over the one derived from the url", func() {
+			t := resolveRepoPushTarget("s1", 1, map[string]interface{}{
+				"input":  map[string]interface{}{"url": "https://github.com/org-b/app"},
+				"folder": "app-org-b",
+				"output": map[string]interface{}{"url": "https://github.com/org-b/app"},
+			})
+
+			Expect(t.RepoPath).To(Equal("/sessions/s1/workspace/app-org-b"))
+			Expect(t.OutputURL).To(Equal("https://github.com/org-b/app"))
+		})
+
+		It("Should default the branch and leave OutputURL empty when no output is configured", func() {
+			t := resolveRepoPushTarget("s1", 0, map[string]interface{}{"url": ""})
+
+			Expect(t.OutputURL).To(BeEmpty())
+			Expect(t.Branch).To(Equal("sessions/s1"))
+			Expect(t.RepoPath).To(Equal("/sessions/s1/workspace/0"))
+		})
+	})
+
+	Context("pushRepoThroughContent", func() {
+		target := repoPushTarget{Index: 1, InputURL: "https://github.com/o/web", RepoPath: "/sessions/s1/workspace/web", OutputURL: "https://github.com/me/web", Branch: "sessions/s1"}
+
+		It("Should report the pushed sha and forward the GitHub token", func() {
+			var got map[string]interface{}
+			var gotToken string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotToken = r.Header.Get("X-GitHub-Token")
+				_ = json.NewDecoder(r.Body).Decode(&got)
+				_, _ = w.Write([]byte(`{"ok":true,"sha":"abc1234","branch":"sessions/s1"}`))
+			}))
+			defer server.Close()
+			headers := http.Header{}
+			headers.Set("X-GitHub-Token", "gh-token")
+
+			outcome := pushRepoThroughContent(context.Background(), contentEndpoint{BaseURL: server.URL}, headers, target, "Update docs")
+
+			Expect(outcome.Success).To(BeTrue())
+			Expect(outcome.SHA).To(Equal("abc1234"))
+			Expect(outcome.RepoIndex).To(Equal(1))
+			Expect(gotToken).To(Equal("gh-token"))
+			Expect(got["repoPath"]).To(Equal("/sessions/s1/workspace/web"))
+			Expect(got["outputRepoUrl"]).To(Equal("https://github.com/me/web"))
+			Expect(got["commitMessage"]).To(Equal("Update docs"))
+		})
+
+		It("Should keep structured content service errors and hide unstructured ones", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"kind":"Validation","message":"nothing to commit"}}`))
+			}))
+			defer server.Close()
+
+			outcome := pushRepoThroughContent(context.Background(), contentEndpoint{BaseURL: server.URL}, http.Header{}, target, "msg")
+			Expect(outcome.Success).To(BeFalse())
+			Expect(outcome.Error).To(Equal("nothing to commit"))
+
+			leaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`fatal: could not read from https://token@github.com/me/web`))
+			}))
+			defer leaky.Close()
+
+			outcome = pushRepoThroughContent(context.Background(), contentEndpoint{BaseURL: leaky.URL}, http.Header{}, target, "msg")
+			Expect(outcome.Success).To(BeFalse())
+			Expect(outcome.Error).To(Equal("Content service request failed"))
+		})
+
+		It("Should report the findings of a push blocked by the secret scan", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"error":{"kind":"Validation","message":"Push blocked: the changes contain possible secrets","details":{"secretFindings":[{"file":"config/id_rsa","line":1,"rule":"private-key"}]}}}`))
+			}))
+			defer server.Close()
+
+			outcome := pushRepoThroughContent(context.Background(), contentEndpoint{BaseURL: server.URL}, http.Header{}, target, "msg")
+			Expect(outcome.Success).To(BeFalse())
+			Expect(outcome.SecretFindings).To(Equal([]git.SecretFinding{{File: "config/id_rsa", Line: 1, Rule: "private-key"}}))
+			Expect(secretFindingsStatus(outcome.SecretFindings)).To(Equal([]interface{}{
+				map[string]interface{}{"file": "config/id_rsa", "line": int64(1), "rule": "private-key"},
+			}))
+		})
+	})
+
+	Context("output branch templates", func() {
+		session := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "agentic-session-1", "creationTimestamp": "2026-03-04T10:00:00Z"},
+			"spec": map[string]interface{}{
+				"displayName": "Fix: Login flow!",
+				"userContext": map[string]interface{}{"userId": "JDoe@example.com"},
+			},
+		}}
+
+		It("Should render slugged session fields into the branch", func() {
+			data := outputBranchData(session, time.Now())
+			Expect(data.Date).To(Equal("2026-03-04"))
+
+			branch, err := renderOutputBranch("vteam/{{.User}}/{{.DisplayNameSlug}}", data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("vteam/jdoe/fix-login-flow"))
+
+			branch, err = renderOutputBranch("{{.Date}}/{{.SessionName}}", data)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(branch).To(Equal("2026-03-04/agentic-session-1"))
+		})
+
+		DescribeTable("Should reject templates that do not give a valid git branch name",
+			func(tmpl string) {
+				_, err := renderOutputBranch(tmpl, outputBranchData(session, time.Now()))
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("unknown field", "vteam/{{.Team}}"),
+			Entry("parse error", "vteam/{{.User"),
+			Entry("lock suffix", "vteam/{{.User}}.lock"),
+			Entry("double dot", "vteam..{{.User}}"),
+			Entry("empty component", "vteam//{{.User}}"),
+			Entry("colon", "vteam:{{.User}}"),
+		)
+
+		It("Should record the rendered branch once", func() {
+			ctx := context.Background()
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("branch-project").Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "s1", "namespace": "branch-project"},
+				"spec": map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"url": "https://github.com/o/api", "output": map[string]interface{}{"url": "https://github.com/me/api"}},
+				}},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(persistRepoOutputBranch(ctx, k8sUtils.DynamicClient, "branch-project", "s1", 0, "vteam/jdoe/fix-login-flow")).To(Succeed())
+			Expect(persistRepoOutputBranch(ctx, k8sUtils.DynamicClient, "branch-project", "s1", 0, "vteam/jdoe/renamed")).To(Succeed())
+
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("branch-project").Get(ctx, "s1", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+			t := resolveRepoPushTarget("s1", 0, repos[0].(map[string]interface{}))
+			Expect(t.Branch).To(Equal("vteam/jdoe/fix-login-flow"))
+			Expect(t.OutputURL).To(Equal("https://github.com/me/api"))
+		})
+	})
+
+	Context("repos owned by different accounts", func() {
+		It("Should push each repo with a token for its owner's installation", func() {
+			ctx := context.Background()
+			project := "owners-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "s1", "namespace": project},
+				"spec": map[string]interface{}{
+					"userContext": map[string]interface{}{"userId": "alice"},
+					"repos": []interface{}{
+						map[string]interface{}{"url": "https://github.com/org-a/api", "output": map[string]interface{}{"url": "https://github.com/org-a/api", "branch": "fix"}},
+						map[string]interface{}{"url": "https://github.com/org-b/web", "output": map[string]interface{}{"url": "https://github.com/org-b/web", "branch": "fix"}},
+					},
+				},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = k8sUtils.K8sClient.CoreV1().Services(project).Create(ctx, &corev1.Service{
+				ObjectMeta: v1.ObjectMeta{Name: "ambient-content-s1", Namespace: project},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			GetGitHubToken = func(_ context.Context, _ kubernetes.Interface, _ dynamic.Interface, _, _, repo string) (string, error) {
+				return "token-" + git.GitHubRepoOwner(repo), nil
+			}
+			github := httptest.NewServer(http.NotFoundHandler())
+			DeferCleanup(github.Close)
+			GinkgoT().Setenv(git.GitHubAPIBaseEnv, github.URL)
+			var mu sync.Mutex
+			pushed := map[string]string{}
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body map[string]interface{}
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				mu.Lock()
+				pushed[body["outputRepoUrl"].(string)] = r.Header.Get("X-GitHub-Token")
+				mu.Unlock()
+				_, _ = w.Write([]byte(`{"ok":true,"sha":"abc123"}`))
+			}))
+			DeferCleanup(upstream.Close)
+			previous := ContentResolver
+			ContentResolver = fixedContentResolver{baseURL: upstream.URL}
+			DeferCleanup(func() { ContentResolver = previous })
+
+			httpUtils := test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+project+"/agentic-sessions/s1/github/push-all", map[string]interface{}{"commitMessage": "Fix"})
+			httpUtils.SetAuthHeader("test-token")
+			c.Params = gin.Params{{Key: "projectName", Value: project}, {Key: "sessionName", Value: "s1"}}
+			PushAllSessionRepos(c)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(pushed).To(Equal(map[string]string{
+				"https://github.com/org-a/api": "token-org-a",
+				"https://github.com/org-b/web": "token-org-b",
+			}))
+		})
+	})
+
+	Context("output.autoFork", func() {
+		var (
+			ctx       context.Context
+			forkPosts int
+			canPush   bool
+			forkFails bool
+			github    *httptest.Server
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			forkPosts, canPush, forkFails = 0, false, false
+			github = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/upstream/api":
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{"full_name": "upstream/api", "permissions": map[string]bool{"push": canPush}})
+				case r.Method == http.MethodPost && r.URL.Path == "/repos/upstream/api/forks":
+					forkPosts++
+					if forkFails {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					w.WriteHeader(http.StatusAccepted)
+					_ = json.NewEncoder(w).Encode(map[string]string{"full_name": "me/api", "clone_url": "https://github.com/me/api.git"})
+				case r.Method == http.MethodGet && r.URL.Path == "/repos/me/api":
+					_ = json.NewEncoder(w).Encode(map[string]string{"full_name": "me/api"})
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			DeferCleanup(github.Close)
+
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("fork-project").Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": "s1", "namespace": "fork-project"},
+				"spec": map[string]interface{}{"repos": []interface{}{
+					map[string]interface{}{"url": "https://github.com/upstream/api", "output": map[string]interface{}{"url": "https://github.com/upstream/api", "autoFork": true}},
+				}},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				_ = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("fork-project").Delete(context.Background(), "s1", v1.DeleteOptions{})
+			})
+		})
+
+		target := func() repoPushTarget {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("fork-project").Get(ctx, "s1", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ := unstructured.NestedSlice(obj.Object, "spec", "repos")
+			return resolveRepoPushTarget("s1", 0, repos[0].(map[string]interface{}))
+		}
+
+		It("Should push to a fork of an output repo the owner cannot push to and record it", func() {
+			t := target()
+			Expect(t.AutoFork).To(BeTrue())
+
+			Expect(forkOutputRepo(ctx, github.URL, "fork-project", "s1", &t, "gh-token", nil)).To(Succeed())
+
+			Expect(t.OutputURL).To(Equal("https://github.com/me/api.git"))
+			Expect(forkPosts).To(Equal(1))
+			Expect(target().OutputURL).To(Equal("https://github.com/me/api.git"), "spec output.url should point at the fork")
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace("fork-project").Get(ctx, "s1", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			repos, _, _ := unstructured.NestedSlice(obj.Object, "status", "reconciledRepos")
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0]).To(HaveKeyWithValue("forkOf", "https://github.com/upstream/api"))
+			Expect(repos[0]).To(HaveKeyWithValue("forkUrl", "https://github.com/me/api.git"))
+		})
+
+		It("Should leave an output repo the owner can push to alone", func() {
+			canPush = true
+			t := target()
+
+			Expect(forkOutputRepo(ctx, github.URL, "fork-project", "s1", &t, "gh-token", nil)).To(Succeed())
+
+			Expect(t.OutputURL).To(Equal("https://github.com/upstream/api"))
+			Expect(forkPosts).To(BeZero())
+		})
+
+		It("Should fail when the fork cannot be created", func() {
+			forkFails = true
+			t := target()
+
+			Expect(forkOutputRepo(ctx, github.URL, "fork-project", "s1", &t, "gh-token", nil)).NotTo(Succeed())
+
+			Expect(target().OutputURL).To(Equal("https://github.com/upstream/api"))
+		})
+	})
+})
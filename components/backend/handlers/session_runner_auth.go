@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authnv1 "k8s.io/api/authentication/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
+)
+
+// runnerTokenSecretLabel labels the runner token Secrets so they can be listed without the
+// project's other Secrets
+const runnerTokenSecretLabel = "ambient-runner-token"
+
+// runnerServiceAccountName is the ServiceAccount a session's runner authenticates as
+func runnerServiceAccountName(session string) string {
+	return fmt.Sprintf("ambient-session-%s", session)
+}
+
+// runnerRoleName is the Role granting runnerRoleRules to a session's runner
+func runnerRoleName(session string) string {
+	return fmt.Sprintf("ambient-session-%s-role", session)
+}
+
+// runnerRoleBindingName binds runnerRoleName to runnerServiceAccountName
+func runnerRoleBindingName(session string) string {
+	return fmt.Sprintf("ambient-session-%s-rb", session)
+}
+
+// runnerTokenSecretName is the Secret holding the runner's ServiceAccount token
+func runnerTokenSecretName(session string) string {
+	return fmt.Sprintf("ambient-runner-token-%s", session)
+}
+
+// runnerRoleRules is the least-privilege Role of a session's runner. It may read and annotate its
+// own session, named in ResourceNames, and check its own access. It has no status rights: status
+// is written by the backend's runner endpoints (see authorizeSessionStatusWrite), which validate
+// phase transitions and writable fields and enforce the cost limit.
+func runnerRoleRules(session string) []rbacv1.PolicyRule {
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{"vteam.ambient-code"},
+			Resources:     []string{"agenticsessions"},
+			ResourceNames: []string{session},
+			Verbs:         []string{"get", "update", "patch"}, // update, patch for annotations
+		},
+		{
+			APIGroups: []string{"authorization.k8s.io"},
+			Resources: []string{"selfsubjectaccessreviews"},
+			Verbs:     []string{"create"},
+		},
+	}
+}
+
+// authorizeSessionStatusWrite authorizes a runner-facing status endpoint (status, artifacts) and
+// returns the backend SA client it writes with. The caller must be the session's own
+// runner ServiceAccount, identified with a SelfSubjectReview, or be allowed to update the session's
+// status; the runner of another session is refused. Runner Roles carry no status rights, so the
+// endpoint's validation is the only way a runner changes status. It writes the error response and
+// returns nil when the write is not allowed.
+func authorizeSessionStatusWrite(c *gin.Context, project, sessionName string) dynamic.Interface {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return nil
+	}
+	ctx := c.Request.Context()
+	allowed := false
+	review, err := reqK8s.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authnv1.SelfSubjectReview{}, v1.CreateOptions{})
+	if err != nil {
+		// Identify by access review alone, e.g. on clusters without the SelfSubjectReview API
+		log.Printf("Self subject review failed for %s/%s status write: %v", project, sessionName, err)
+	} else if ns, sa, ok := serviceAccountFromUsername(review.Status.UserInfo.Username); ok && ns == project && strings.HasPrefix(sa, "ambient-session-") {
+		if sa != runnerServiceAccountName(sessionName) {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Not allowed to update session status", nil)
+			return nil
+		}
+		allowed = true
+	}
+	if !allowed {
+		ssar := &authv1.SelfSubjectAccessReview{
+			Spec: authv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authv1.ResourceAttributes{
+					Group:       "vteam.ambient-code",
+					Resource:    "agenticsessions",
+					Subresource: "status",
+					Verb:        "update",
+					Namespace:   project,
+					Name:        sessionName,
+				},
+			},
+		}
+		res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+		if err != nil {
+			log.Printf("Access review failed for %s/%s status write: %v", project, sessionName, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to check permissions", nil)
+			return nil
+		}
+		if !res.Status.Allowed {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "Not allowed to update session status", nil)
+			return nil
+		}
+	}
+	if DynamicClient == nil {
+		respondError(c, http.StatusServiceUnavailable, ErrorKindUpstreamUnavailable, "Backend service account not available", nil)
+		return nil
+	}
+	return DynamicClient
+}
+
+func containsOrWildcard(values []string, v string) bool {
+	for _, s := range values {
+		if s == v || s == rbacv1.VerbAll {
+			return true
+		}
+	}
+	return false
+}
+
+// ungrantedPermissions lists, as "verb resource", each permission of want that no rule of have grants
+func ungrantedPermissions(want, have []rbacv1.PolicyRule) []string {
+	missing := []string{}
+	for _, w := range want {
+		for _, group := range w.APIGroups {
+			for _, resource := range w.Resources {
+				for _, verb := range w.Verbs {
+					granted := false
+					for _, r := range have {
+						if containsOrWildcard(r.APIGroups, group) && containsOrWildcard(r.Resources, resource) && containsOrWildcard(r.Verbs, verb) {
+							granted = true
+							break
+						}
+					}
+					if !granted {
+						missing = append(missing, verb+" "+resource)
+					}
+				}
+			}
+		}
+	}
+	return missing
+}
+
+// missingRunnerPermissions lists, as "verb resource", each permission of runnerRoleRules that no
+// rule of a Role grants
+func missingRunnerPermissions(session string, rules []rbacv1.PolicyRule) []string {
+	return ungrantedPermissions(runnerRoleRules(session), rules)
+}
+
+// runnerRoleRuleDiff lists the permissions an update from old to new rules grants and revokes
+func runnerRoleRuleDiff(old, new []rbacv1.PolicyRule) (added, removed []string) {
+	return ungrantedPermissions(new, old), ungrantedPermissions(old, new)
+}
+
+// runnerRoleVersionAnnotation records on a runner Role the runnerRoleVersion of its rules, so a Role
+// is only rewritten when the rules change
+const runnerRoleVersionAnnotation = "ambient-code.io/role-version"
+
+// runnerRoleVersion identifies the current runnerRoleRules; it changes whenever the rules do. It
+// is the same for every session, so the rules are hashed for a placeholder session name.
+func runnerRoleVersion() string {
+	b, _ := json.Marshal(runnerRoleRules("{session}"))
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// runnerRoleFreezeAfterDays is the session age after which existing runner Roles keep their rules
+// instead of being upgraded; 0 (the default) upgrades every Role
+func runnerRoleFreezeAfterDays() int {
+	if raw := strings.TrimSpace(os.Getenv("RUNNER_ROLE_FREEZE_AFTER_DAYS")); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// reconcileRunnerRole brings an existing runner Role of session up to runnerRoleRules. A Role
+// already at runnerRoleVersion keeps its rules, as does the Role of a session older than
+// runnerRoleFreezeAfterDays; otherwise the rules are rewritten and the granted and revoked
+// permissions are logged and recorded as an event on the session. An owner left by a deleted
+// session of the same name is replaced in every case.
+func reconcileRunnerRole(ctx context.Context, roleClient rbacv1client.RoleInterface, existing *rbacv1.Role, session *unstructured.Unstructured, ownerRef v1.OwnerReference, now time.Time) error {
+	changed, upgrade := false, ""
+	if !ownedByUID(existing.OwnerReferences, session.GetUID()) {
+		log.Printf("Role %s belongs to a previous session, re-owning it", existing.Name)
+		existing.OwnerReferences = []v1.OwnerReference{ownerRef}
+		changed = true
+	}
+
+	version := runnerRoleVersion()
+	current := existing.Annotations[runnerRoleVersionAnnotation]
+	if current != version {
+		days := runnerRoleFreezeAfterDays()
+		created := session.GetCreationTimestamp().Time
+		if days > 0 && !created.IsZero() && now.Sub(created) > time.Duration(days)*24*time.Hour {
+			log.Printf("Role %s is at version %q, not upgrading it to %q: session is older than %d days", existing.Name, current, version, days)
+		} else {
+			added, removed := runnerRoleRuleDiff(existing.Rules, runnerRoleRules(session.GetName()))
+			existing.Rules = runnerRoleRules(session.GetName())
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+			existing.Annotations[runnerRoleVersionAnnotation] = version
+			upgrade = fmt.Sprintf("Upgraded runner Role %s from version %q to %q (granted: %s; revoked: %s)",
+				existing.Name, current, version, permissionList(added), permissionList(removed))
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	if _, err := roleClient.Update(ctx, existing, v1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("update Role: %w", err)
+	}
+	if upgrade != "" {
+		log.Print(upgrade)
+		recordSessionEvent(ctx, session, corev1.EventTypeNormal, "RunnerRoleUpgraded", upgrade)
+	}
+	return nil
+}
+
+// permissionList formats a runnerRoleRuleDiff list for logs and events
+func permissionList(perms []string) string {
+	if len(perms) == 0 {
+		return "none"
+	}
+	return strings.Join(perms, ", ")
+}
+
+// jwtTimes reads the iat and exp claims of a JWT without verifying it; zero when absent
+func jwtTimes(token string) (issuedAt, expiresAt time.Time, err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, time.Time{}, fmt.Errorf("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("decode JWT payload: %w", err)
+	}
+	var claims struct {
+		IssuedAt  int64 `json:"iat"`
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("decode JWT claims: %w", err)
+	}
+	if claims.IssuedAt > 0 {
+		issuedAt = time.Unix(claims.IssuedAt, 0).UTC()
+	}
+	if claims.ExpiresAt > 0 {
+		expiresAt = time.Unix(claims.ExpiresAt, 0).UTC()
+	}
+	return issuedAt, expiresAt, nil
+}
+
+// runnerTokenInfo describes the runner token Secret. Times come from the token's own claims, falling
+// back to the expiry stored next to it.
+func runnerTokenInfo(secret *corev1.Secret, now time.Time) map[string]interface{} {
+	info := map[string]interface{}{"exists": true, "hasToken": false}
+	token := strings.TrimSpace(string(secret.Data[runnerTokenSecretKey]))
+	if token == "" {
+		return info
+	}
+	info["hasToken"] = true
+	issuedAt, expiresAt, err := jwtTimes(token)
+	if err != nil {
+		info["tokenError"] = err.Error()
+	}
+	if expiresAt.IsZero() {
+		if t, err := time.Parse(time.RFC3339, string(secret.Data[runnerTokenExpirySecretKey])); err == nil {
+			expiresAt = t.UTC()
+		}
+	}
+	if !issuedAt.IsZero() {
+		info["issuedAt"] = issuedAt.Format(time.RFC3339)
+		info["ageSeconds"] = int64(now.Sub(issuedAt).Seconds())
+	}
+	if !expiresAt.IsZero() {
+		info["expiresAt"] = expiresAt.Format(time.RFC3339)
+		info["expired"] = !now.Before(expiresAt)
+	}
+	return info
+}
+
+// sessionRunnerAuth builds the auth section of the k8s-resources response: whether the runner's
+// ServiceAccount, Role, RoleBinding and token Secret exist, which permissions the Role lacks and
+// whether its rules are at runnerRoleVersion, and how old the token is. A nil object is absent; errs holds lookups that failed for another reason,
+// keyed by section.
+func sessionRunnerAuth(session string, sa *corev1.ServiceAccount, role *rbacv1.Role, rb *rbacv1.RoleBinding, secret *corev1.Secret, errs map[string]error, now time.Time) map[string]interface{} {
+	section := func(name string, exists bool, key string) map[string]interface{} {
+		s := map[string]interface{}{"name": name, "exists": exists}
+		if err := errs[key]; err != nil {
+			log.Printf("GetSessionK8sResources: failed to read %s %s: %v", key, name, err)
+			s["error"] = fmt.Sprintf("Failed to read %s", key)
+		}
+		return s
+	}
+
+	saInfo := section(runnerServiceAccountName(session), sa != nil, "serviceAccount")
+
+	roleInfo := section(runnerRoleName(session), role != nil, "role")
+	missing := []string{}
+	if role != nil {
+		missing = missingRunnerPermissions(session, role.Rules)
+		roleInfo["version"] = role.Annotations[runnerRoleVersionAnnotation]
+		roleInfo["currentVersion"] = role.Annotations[runnerRoleVersionAnnotation] == runnerRoleVersion()
+	}
+	roleInfo["missingPermissions"] = missing
+
+	rbInfo := section(runnerRoleBindingName(session), rb != nil, "roleBinding")
+	if rb != nil {
+		rbInfo["roleRef"] = rb.RoleRef.Name
+	}
+
+	tokenInfo := section(runnerTokenSecretName(session), secret != nil, "tokenSecret")
+	if secret != nil {
+		for k, v := range runnerTokenInfo(secret, now) {
+			tokenInfo[k] = v
+		}
+	}
+
+	expired, _ := tokenInfo["expired"].(bool)
+	hasToken, _ := tokenInfo["hasToken"].(bool)
+	return map[string]interface{}{
+		"serviceAccount": saInfo,
+		"role":           roleInfo,
+		"roleBinding":    rbInfo,
+		"tokenSecret":    tokenInfo,
+		"healthy":        sa != nil && role != nil && len(missing) == 0 && rb != nil && hasToken && !expired,
+	}
+}
+
+// getSessionRunnerAuth reads the runner auth objects of one session for sessionRunnerAuth
+func getSessionRunnerAuth(ctx context.Context, clt kubernetes.Interface, project, session string) map[string]interface{} {
+	errs := map[string]error{}
+	keep := func(key string, err error) bool {
+		if err != nil && !errors.IsNotFound(err) {
+			errs[key] = err
+		}
+		return err == nil
+	}
+	sa, err := clt.CoreV1().ServiceAccounts(project).Get(ctx, runnerServiceAccountName(session), v1.GetOptions{})
+	if !keep("serviceAccount", err) {
+		sa = nil
+	}
+	role, err := clt.RbacV1().Roles(project).Get(ctx, runnerRoleName(session), v1.GetOptions{})
+	if !keep("role", err) {
+		role = nil
+	}
+	rb, err := clt.RbacV1().RoleBindings(project).Get(ctx, runnerRoleBindingName(session), v1.GetOptions{})
+	if !keep("roleBinding", err) {
+		rb = nil
+	}
+	secret, err := clt.CoreV1().Secrets(project).Get(ctx, runnerTokenSecretName(session), v1.GetOptions{})
+	if !keep("tokenSecret", err) {
+		secret = nil
+	}
+	return sessionRunnerAuth(session, sa, role, rb, secret, errs, time.Now())
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+)
+
+// defaultMaxSessionTimeoutSeconds caps extend-timeout when ProjectSettings sets no
+// spec.maxSessionTimeoutSeconds; it matches the runner Job's activeDeadlineSeconds safety limit
+const defaultMaxSessionTimeoutSeconds = 14400
+
+// defaultTimeoutWarningLead is how long before the deadline the timeout_warning event is sent
+const defaultTimeoutWarningLead = 2 * time.Minute
+
+// TimeoutWarningLead returns the configured warning lead time (SESSION_TIMEOUT_WARNING_MINUTES)
+func TimeoutWarningLead() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_TIMEOUT_WARNING_MINUTES")); raw != "" {
+		if mins, err := strconv.Atoi(raw); err == nil && mins > 0 {
+			return time.Duration(mins) * time.Minute
+		}
+	}
+	return defaultTimeoutWarningLead
+}
+
+// specTimeoutSeconds reads spec.timeout, which decodes as int64 from the API server and float64 from JSON bodies
+func specTimeoutSeconds(obj *unstructured.Unstructured) int64 {
+	raw, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "timeout")
+	if !found {
+		return 0
+	}
+	switch v := raw.(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// SessionDeadline returns when spec.timeout elapses for a Running session, counted from status.startTime.
+// It reports false for sessions that are not running or have no timeout.
+func SessionDeadline(obj *unstructured.Unstructured) (time.Time, bool) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if phase != sessionPhaseRunning {
+		return time.Time{}, false
+	}
+	timeout := specTimeoutSeconds(obj)
+	startRaw, _, _ := unstructured.NestedString(obj.Object, "status", "startTime")
+	start, err := time.Parse(time.RFC3339, startRaw)
+	if timeout <= 0 || err != nil {
+		return time.Time{}, false
+	}
+	return start.Add(time.Duration(timeout) * time.Second), true
+}
+
+// projectMaxSessionTimeout returns spec.maxSessionTimeoutSeconds from the project's ProjectSettings,
+// falling back to defaultMaxSessionTimeoutSeconds
+func projectMaxSessionTimeout(ctx context.Context, dyn dynamic.Interface, project string) int64 {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, using default max session timeout: %v", project, err)
+		}
+		return defaultMaxSessionTimeoutSeconds
+	}
+	if limit, found, _ := unstructured.NestedInt64(obj.Object, "spec", "maxSessionTimeoutSeconds"); found && limit > 0 {
+		return limit
+	}
+	return defaultMaxSessionTimeoutSeconds
+}
+
+// timeoutExtensionError rejects an extend-timeout request after reading the current session
+type timeoutExtensionError struct {
+	Phase      string
+	Current    int64
+	Max        int64
+	terminated bool
+}
+
+func (e *timeoutExtensionError) Error() string {
+	if e.terminated {
+		return fmt.Sprintf("session is %s", e.Phase)
+	}
+	return fmt.Sprintf("timeout would exceed the project maximum of %d seconds", e.Max)
+}
+
+// ExtendSessionTimeout pushes a session's deadline out.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/extend-timeout
+// Body: { seconds: int }
+// spec.timeout grows by seconds, bounded by ProjectSettings spec.maxSessionTimeoutSeconds, and the
+// runner Job's activeDeadlineSeconds is pushed out by the same amount. Stopping or terminal sessions get 409.
+// The response's jobDeadlineUpdated reports whether the Job was patched; when it could not be, the
+// timeout is still extended and warnings says the Job may stop at its previous deadline.
+func ExtendSessionTimeout(c *gin.Context) {
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	sessionName := c.Param("sessionName")
+
+	_, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var req struct {
+		Seconds int64 `json:"seconds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	if req.Seconds <= 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "seconds must be positive", nil)
+		return
+	}
+
+	maxTimeout := projectMaxSessionTimeout(c.Request.Context(), k8sDyn, project)
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	var updated *unstructured.Unstructured
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		if isTerminalSessionPhase(phase) || phase == sessionPhaseStopping {
+			return &timeoutExtensionError{Phase: phase, terminated: true}
+		}
+		current := specTimeoutSeconds(obj)
+		if current+req.Seconds > maxTimeout {
+			return &timeoutExtensionError{Phase: phase, Current: current, Max: maxTimeout}
+		}
+		if err := unstructured.SetNestedField(obj.Object, current+req.Seconds, "spec", "timeout"); err != nil {
+			return err
+		}
+		updated, err = k8sDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), obj, v1.UpdateOptions{})
+		return err
+	})
+	if tErr, ok := err.(*timeoutExtensionError); ok {
+		if tErr.terminated {
+			respondError(c, http.StatusConflict, ErrorKindConflict, fmt.Sprintf("Cannot extend the timeout of a %s session", tErr.Phase),
+				gin.H{"currentPhase": tErr.Phase})
+			return
+		}
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("Timeout cannot exceed %d seconds", tErr.Max),
+			gin.H{"currentTimeout": tErr.Current, "maxTimeout": tErr.Max})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to extend timeout for session %s/%s: %v", project, sessionName, err)
+		respondK8sError(c, err, "Session not found", "Failed to extend session timeout")
+		return
+	}
+
+	// The Job counts its deadline from its own start, so shift it by the extension rather than resetting it
+	jobName, _, _ := unstructured.NestedString(updated.Object, "status", "jobName")
+	if jobName == "" {
+		jobName = fmt.Sprintf("%s-job", sessionName)
+	}
+	jobUpdated, err := extendJobDeadline(c.Request.Context(), project, jobName, req.Seconds)
+
+	resp := gin.H{"message": "Session timeout extended", "timeout": specTimeoutSeconds(updated), "jobDeadlineUpdated": jobUpdated}
+	if deadline, ok := SessionDeadline(updated); ok {
+		resp["deadline"] = deadline.UTC().Format(time.RFC3339)
+	}
+	if err != nil {
+		log.Printf("Failed to extend activeDeadlineSeconds of Job %s/%s: %v", project, jobName, err)
+		resp["warnings"] = []string{"The runner Job's deadline could not be extended; the session may still be stopped at its previous deadline"}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// extendJobDeadline adds seconds to the runner Job's activeDeadlineSeconds using the backend service
+// account; the caller has already been authorized by updating the session. It reports whether the Job
+// was patched. A Job that does not exist yet has no deadline to move and is not an error; a Job without
+// activeDeadlineSeconds or a failed patch is.
+func extendJobDeadline(ctx context.Context, namespace, jobName string, seconds int64) (bool, error) {
+	if K8sClient == nil {
+		return false, fmt.Errorf("backend service account not available")
+	}
+	job, err := K8sClient.BatchV1().Jobs(namespace).Get(ctx, jobName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if job.Spec.ActiveDeadlineSeconds == nil {
+		return false, fmt.Errorf("job has no activeDeadlineSeconds")
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"activeDeadlineSeconds": *job.Spec.ActiveDeadlineSeconds + seconds},
+	})
+	if err != nil {
+		return false, err
+	}
+	if _, err := K8sClient.BatchV1().Jobs(namespace).Patch(ctx, jobName, ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
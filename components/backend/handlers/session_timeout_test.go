@@ -0,0 +1,221 @@
+//go:build test
+
+package handlers
+
+import (
+	test_constants "ambient-code-backend/tests/constants"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Session Timeout", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	running := func(startTime string, timeout int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		_ = unstructured.SetNestedField(obj.Object, timeout, "spec", "timeout")
+		_ = unstructured.SetNestedField(obj.Object, sessionPhaseRunning, "status", "phase")
+		_ = unstructured.SetNestedField(obj.Object, startTime, "status", "startTime")
+		return obj
+	}
+
+	It("Should compute the deadline from status.startTime and spec.timeout", func() {
+		deadline, ok := SessionDeadline(running("2026-01-01T10:00:00Z", 600))
+
+		Expect(ok).To(BeTrue())
+		Expect(deadline).To(Equal(time.Date(2026, 1, 1, 10, 10, 0, 0, time.UTC)))
+	})
+
+	It("Should report no deadline for sessions that are not running or not started", func() {
+		obj := running("2026-01-01T10:00:00Z", 600)
+		_ = unstructured.SetNestedField(obj.Object, sessionPhaseCompleted, "status", "phase")
+		_, ok := SessionDeadline(obj)
+		Expect(ok).To(BeFalse())
+
+		_, ok = SessionDeadline(running("", 600))
+		Expect(ok).To(BeFalse())
+	})
+
+	Describe("ExtendSessionTimeout", func() {
+		var (
+			httpUtils     *test_utils.HTTPTestUtils
+			k8sUtils      *test_utils.K8sTestUtils
+			ctx           context.Context
+			testNamespace string
+			testSession   string
+			testToken     string
+		)
+
+		BeforeEach(func() {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+			ctx = context.Background()
+			suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+			testNamespace = "test-project-" + suffix
+			testSession = "test-session-" + suffix
+			SetupHandlerDependencies(k8sUtils)
+
+			_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+				ObjectMeta: v1.ObjectMeta{Name: testNamespace},
+			}, v1.CreateOptions{})
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			_, err = k8sUtils.CreateTestRole(ctx, testNamespace, "test-full-access-role", []string{"get", "list", "create", "update", "delete", "patch"}, "*", "")
+			Expect(err).NotTo(HaveOccurred())
+			testToken, _, err = httpUtils.SetValidTestToken(k8sUtils, testNamespace, []string{"get", "list", "create", "update", "delete", "patch"}, "*", "", "test-full-access-role")
+			Expect(err).NotTo(HaveOccurred())
+
+			createTestSession(testSession, testNamespace, k8sUtils)
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unstructured.SetNestedField(obj.Object, int64(300), "spec", "timeout")).To(Succeed())
+			Expect(unstructured.SetNestedField(obj.Object, sessionPhaseRunning, "status", "phase")).To(Succeed())
+			Expect(unstructured.SetNestedField(obj.Object, time.Now().UTC().Format(time.RFC3339), "status", "startTime")).To(Succeed())
+			_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		extend := func(seconds int64) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/extend-timeout", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("POST", path, map[string]interface{}{"seconds": seconds})
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+			ExtendSessionTimeout(context)
+		}
+
+		storedTimeout := func() int64 {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			return specTimeoutSeconds(obj)
+		}
+
+		It("Should extend spec.timeout and the Job's activeDeadlineSeconds", func() {
+			deadline := int64(14400)
+			_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+				ObjectMeta: v1.ObjectMeta{Name: testSession + "-job", Namespace: testNamespace},
+				Spec:       batchv1.JobSpec{ActiveDeadlineSeconds: &deadline},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			extend(600)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			Expect(resp["timeout"]).To(BeNumerically("==", 900))
+			Expect(resp).To(HaveKey("deadline"))
+			Expect(resp["jobDeadlineUpdated"]).To(BeTrue())
+			Expect(resp).NotTo(HaveKey("warnings"))
+			Expect(storedTimeout()).To(Equal(int64(900)))
+
+			job, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Get(ctx, testSession+"-job", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(15000)))
+		})
+
+		It("Should warn when the Job's deadline cannot be extended", func() {
+			deadline := int64(14400)
+			_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+				ObjectMeta: v1.ObjectMeta{Name: testSession + "-job", Namespace: testNamespace},
+				Spec:       batchv1.JobSpec{ActiveDeadlineSeconds: &deadline},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			fakeK8s, ok := k8sUtils.K8sClient.(*k8sfake.Clientset)
+			Expect(ok).To(BeTrue())
+			fakeK8s.PrependReactor("patch", "jobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewForbidden(schema.GroupResource{Group: "batch", Resource: "jobs"}, testSession+"-job", fmt.Errorf("denied"))
+			})
+
+			extend(600)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			Expect(resp["jobDeadlineUpdated"]).To(BeFalse())
+			Expect(resp["warnings"]).To(ConsistOf(ContainSubstring("previous deadline")))
+			Expect(storedTimeout()).To(Equal(int64(900)))
+			job, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Get(ctx, testSession+"-job", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*job.Spec.ActiveDeadlineSeconds).To(Equal(int64(14400)))
+		})
+
+		It("Should warn when the Job has no deadline to extend", func() {
+			_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+				ObjectMeta: v1.ObjectMeta{Name: testSession + "-job", Namespace: testNamespace},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			extend(600)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			Expect(resp["jobDeadlineUpdated"]).To(BeFalse())
+			Expect(resp).To(HaveKey("warnings"))
+		})
+
+		It("Should not warn when the runner Job has not been created yet", func() {
+			extend(600)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp map[string]interface{}
+			httpUtils.GetResponseJSON(&resp)
+			Expect(resp["jobDeadlineUpdated"]).To(BeFalse())
+			Expect(resp).NotTo(HaveKey("warnings"))
+		})
+
+		It("Should reject extensions beyond the project maximum", func() {
+			settings := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+				"spec":       map[string]interface{}{"maxSessionTimeoutSeconds": int64(600)},
+			}}
+			_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, settings, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			extend(400)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Timeout cannot exceed 600 seconds")
+			Expect(storedTimeout()).To(Equal(int64(300)))
+		})
+
+		It("Should reject terminal sessions", func() {
+			obj, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unstructured.SetNestedField(obj.Object, sessionPhaseCompleted, "status", "phase")).To(Succeed())
+			_, err = k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			extend(60)
+
+			httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Cannot extend the timeout of a Completed session")
+			Expect(storedTimeout()).To(Equal(int64(300)))
+		})
+
+		It("Should reject non-positive extensions", func() {
+			extend(-5)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "seconds must be positive")
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"ambient-code-backend/tracing"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// annotateSessionTrace records the trace of the creating request on a new session object, so the
+// operator's reconcile and Job creation spans join it. It does nothing with tracing off.
+func annotateSessionTrace(c *gin.Context, metadata map[string]interface{}) {
+	traceparent := tracing.Traceparent(c.Request.Context())
+	if traceparent == "" {
+		return
+	}
+	if metadata["annotations"] == nil {
+		metadata["annotations"] = make(map[string]interface{})
+	}
+	annotations := metadata["annotations"].(map[string]interface{})
+	annotations[tracing.TraceparentAnnotation] = traceparent
+	annotations[tracing.TraceIDAnnotation] = tracing.TraceID(c.Request.Context())
+}
+
+// startSessionSpan starts a span named name in the trace of the session addressed by the route
+// (its ambient-code.io/traceparent annotation) and points c.Request's context at it, so content
+// service calls made for the request join that trace as well. Browser requests start traces of
+// their own; this is what puts workspace reads next to the session's create and start. The span's
+// request.trace_id attribute names the request's own trace. The returned func ends the span; it
+// does nothing with tracing off or for a session created without tracing.
+func startSessionSpan(c *gin.Context, name string) func() {
+	noop := func() {}
+	if !tracing.Enabled() || DynamicClient == nil {
+		return noop
+	}
+	project := c.GetString("project")
+	if project == "" {
+		project = c.Param("projectName")
+	}
+	session := c.Param("sessionName")
+	if project == "" || session == "" {
+		return noop
+	}
+
+	ctx := c.Request.Context()
+	var obj *unstructured.Unstructured
+	ok := false
+	if SessionReadCache != nil {
+		obj, ok = SessionReadCache.Get(project, GetAgenticSessionV1Alpha1Resource(), session)
+	}
+	if !ok {
+		var err error
+		obj, err = DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, session, v1.GetOptions{})
+		if err != nil {
+			return noop
+		}
+	}
+	traceparent := obj.GetAnnotations()[tracing.TraceparentAnnotation]
+	if traceparent == "" {
+		return noop
+	}
+
+	requestTraceID := tracing.TraceID(ctx)
+	ctx, span := tracing.Start(tracing.WithRemoteParent(ctx, traceparent), name, tracing.KindInternal)
+	span.SetAttribute("ambient.project", project)
+	span.SetAttribute("ambient.session", session)
+	if requestTraceID != "" {
+		span.SetAttribute("request.trace_id", requestTraceID)
+	}
+	c.Request = c.Request.WithContext(ctx)
+	return span.End
+}
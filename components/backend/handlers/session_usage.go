@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// usageTokenFields are the token counters of status.usage a runner usage_delta may add to
+var usageTokenFields = map[string]bool{
+	"input_tokens":                true,
+	"output_tokens":               true,
+	"cache_creation_input_tokens": true,
+	"cache_read_input_tokens":     true,
+}
+
+// usageCostField is the cost in USD kept in status.usage next to the token counters
+const usageCostField = "total_cost_usd"
+
+// PublishUsageUpdate sends a session's new status.usage totals to its connected clients; set by
+// main to the AG-UI event stream. Nil when the backend has no stream to publish to.
+var PublishUsageUpdate func(sessionName string, usage map[string]interface{}, seq int64)
+
+// parseUsageDelta validates a runner usage_delta: an object of non-negative token counts and cost.
+// Token counts are returned as int64 and the cost as float64, ready for the status map.
+func parseUsageDelta(v interface{}) (map[string]interface{}, error) {
+	raw, ok := v.(map[string]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, fmt.Errorf("usage_delta must be a non-empty object")
+	}
+	delta := make(map[string]interface{}, len(raw))
+	for field, value := range raw {
+		n, ok := value.(float64)
+		switch {
+		case usageTokenFields[field]:
+			if !ok || n < 0 || n != float64(int64(n)) {
+				return nil, fmt.Errorf("usage_delta.%s must be a non-negative integer", field)
+			}
+			delta[field] = int64(n)
+		case field == usageCostField:
+			if !ok || n < 0 {
+				return nil, fmt.Errorf("usage_delta.%s must be a non-negative number", field)
+			}
+			delta[field] = n
+		default:
+			return nil, fmt.Errorf("usage_delta.%s is not a usage counter (allowed: %s)", field, strings.Join(usageFieldNames(), ", "))
+		}
+	}
+	return delta, nil
+}
+
+func usageFieldNames() []string {
+	names := []string{usageCostField}
+	for f := range usageTokenFields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addUsage returns the totals of usage plus delta. Counters missing from usage start at zero and
+// fields of usage that delta does not name are kept.
+func addUsage(usage, delta map[string]interface{}) map[string]interface{} {
+	totals := make(map[string]interface{}, len(usage)+len(delta))
+	for field, value := range usage {
+		totals[field] = value
+	}
+	for field, value := range delta {
+		current, _ := numberValue(totals[field])
+		switch d := value.(type) {
+		case int64:
+			totals[field] = int64(current) + d
+		case float64:
+			totals[field] = current + d
+		}
+	}
+	return totals
+}
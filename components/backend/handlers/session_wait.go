@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// Limits of the timeoutSeconds query parameter of WaitForSession
+const (
+	defaultSessionWaitSeconds = 300
+	maxSessionWaitSeconds     = 3600
+)
+
+// sessionWatchRetryDelay is how long a shared session watch waits before reopening a failed watch
+var sessionWatchRetryDelay = time.Second
+
+// sessionWaitConditions maps the "for" query parameter of WaitForSession to the phases that end the wait
+var sessionWaitConditions = map[string]func(phase string) bool{
+	"terminal": isTerminalSessionPhase,
+	"running": func(phase string) bool {
+		return phase == sessionPhaseRunning || isTerminalSessionPhase(phase)
+	},
+}
+
+// sessionWaiter is one WaitForSession caller. Updates is buffered by one and only ever holds the
+// latest version of the session; nil means the session was deleted.
+type sessionWaiter struct {
+	client  dynamic.Interface
+	updates chan *unstructured.Unstructured
+}
+
+// sessionWatch is the one API-server watch shared by every caller waiting on a session
+type sessionWatch struct {
+	gvr     schema.GroupVersionResource
+	project string
+	session string
+	waiters map[*sessionWaiter]struct{}
+	cancel  context.CancelFunc
+}
+
+var (
+	sessionWatchesMu sync.Mutex
+	// sessionWatches is keyed by project/session
+	sessionWatches = map[string]*sessionWatch{}
+)
+
+// addSessionWaiter subscribes a caller to a session's updates, starting the shared watch for the
+// first caller. The watch authenticates as one of its current waiters, each of which has already
+// read the session with its own token.
+func addSessionWaiter(project, session string, client dynamic.Interface) *sessionWaiter {
+	w := &sessionWaiter{client: client, updates: make(chan *unstructured.Unstructured, 1)}
+	key := project + "/" + session
+	sessionWatchesMu.Lock()
+	defer sessionWatchesMu.Unlock()
+	sw, ok := sessionWatches[key]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		sw = &sessionWatch{gvr: GetAgenticSessionV1Alpha1Resource(), project: project, session: session, waiters: map[*sessionWaiter]struct{}{}, cancel: cancel}
+		sessionWatches[key] = sw
+		go sw.run(ctx)
+	}
+	sw.waiters[w] = struct{}{}
+	return w
+}
+
+// removeSessionWaiter unsubscribes a caller, stopping the shared watch after the last one
+func removeSessionWaiter(project, session string, w *sessionWaiter) {
+	key := project + "/" + session
+	sessionWatchesMu.Lock()
+	defer sessionWatchesMu.Unlock()
+	sw, ok := sessionWatches[key]
+	if !ok {
+		return
+	}
+	delete(sw.waiters, w)
+	if len(sw.waiters) == 0 {
+		sw.cancel()
+		delete(sessionWatches, key)
+	}
+}
+
+// client returns the token of any current waiter, or nil when none is left
+func (sw *sessionWatch) client() dynamic.Interface {
+	sessionWatchesMu.Lock()
+	defer sessionWatchesMu.Unlock()
+	for w := range sw.waiters {
+		return w.client
+	}
+	return nil
+}
+
+// broadcast hands obj to every waiter, replacing any update it has not read yet
+func (sw *sessionWatch) broadcast(obj *unstructured.Unstructured) {
+	sessionWatchesMu.Lock()
+	defer sessionWatchesMu.Unlock()
+	for w := range sw.waiters {
+		select {
+		case <-w.updates:
+		default:
+		}
+		w.updates <- obj
+	}
+}
+
+// run keeps a watch on the session open until ctx is cancelled, reopening it when the API server
+// closes it. A watch opened without a resourceVersion starts with the current object, so waiters
+// cannot miss a change made while it was being reopened.
+func (sw *sessionWatch) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		client := sw.client()
+		if client == nil {
+			return
+		}
+		watcher, err := client.Resource(sw.gvr).Namespace(sw.project).Watch(ctx, v1.ListOptions{FieldSelector: "metadata.name=" + sw.session})
+		if err != nil {
+			log.Printf("WaitForSession: failed to watch %s/%s: %v", sw.project, sw.session, err)
+		} else {
+			sw.consume(ctx, watcher)
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(sessionWatchRetryDelay):
+		}
+	}
+}
+
+// consume broadcasts the events of one watch until it ends or ctx is cancelled
+func (sw *sessionWatch) consume(ctx context.Context, watcher watch.Interface) {
+	defer watcher.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if ev.Type == watch.Error {
+				log.Printf("WaitForSession: watch of %s/%s failed: %v", sw.project, sw.session, ev.Object)
+				return
+			}
+			obj, ok := ev.Object.(*unstructured.Unstructured)
+			if !ok || obj.GetName() != sw.session {
+				continue
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				sw.broadcast(obj)
+			case watch.Deleted:
+				sw.broadcast(nil)
+			}
+		}
+	}
+}
+
+// WaitForSession holds the request open until the session reaches the requested phase and returns
+// the session, for CLIs and CI jobs that would otherwise poll.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/wait?timeoutSeconds=300&for=terminal
+// "for" is terminal (Completed, Failed, Stopped or Error; the default) or running (Running or any
+// terminal phase). When timeoutSeconds (default 300, at most 3600) elapses first the response is
+// 408 with the current phase. Concurrent callers waiting on the same session share one watch.
+func WaitForSession(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	timeout := defaultSessionWaitSeconds
+	if raw := c.Query("timeoutSeconds"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSessionWaitSeconds {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "timeoutSeconds must be between 1 and 3600", nil)
+			return
+		}
+		timeout = n
+	}
+	waitFor := c.DefaultQuery("for", "terminal")
+	reached, ok := sessionWaitConditions[waitFor]
+	if !ok {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "for must be terminal or running", nil)
+		return
+	}
+
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	// Subscribe before the first read so no update between the two is lost
+	waiter := addSessionWaiter(project, sessionName, k8sDyn)
+	defer removeSessionWaiter(project, sessionName, waiter)
+
+	ctx := c.Request.Context()
+	item, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(ctx, sessionName, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to read session")
+		return
+	}
+	revealEnv := shouldRevealSessionEnv(c, reqK8s, project)
+
+	timer := time.NewTimer(time.Duration(timeout) * time.Second)
+	defer timer.Stop()
+	for {
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		if reached(phase) {
+			c.JSON(http.StatusOK, sessionResponse(item, revealEnv))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			respondError(c, http.StatusRequestTimeout, ErrorKindLimitExceeded, "Timed out waiting for session", gin.H{"phase": phase})
+			return
+		case obj := <-waiter.updates:
+			if obj == nil {
+				respondError(c, http.StatusNotFound, ErrorKindNotFound, "Session was deleted", nil)
+				return
+			}
+			item = obj
+		}
+	}
+}
@@ -0,0 +1,164 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ = Describe("WaitForSession", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		for name, phase := range map[string]string{"done": "Completed", "busy": "Running"} {
+			_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "AgenticSession",
+				"metadata":   map[string]interface{}{"name": name, "namespace": testNamespace},
+				"spec":       map[string]interface{}{"initialPrompt": "hello"},
+				"status":     map[string]interface{}{"phase": phase},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	// request builds a wait request; the handler runs when the returned function is called
+	request := func(session, query string) (*test_utils.HTTPTestUtils, func()) {
+		httpUtils := test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/"+session+"/wait"+query, nil)
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: session}}
+		return httpUtils, func() { WaitForSession(c) }
+	}
+
+	wait := func(session, query string) *test_utils.HTTPTestUtils {
+		httpUtils, run := request(session, query)
+		run()
+		return httpUtils
+	}
+
+	phaseOf := func(httpUtils *test_utils.HTTPTestUtils) string {
+		var body struct {
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		}
+		Expect(json.Unmarshal(httpUtils.GetResponseRecorder().Body.Bytes(), &body)).To(Succeed())
+		return body.Status.Phase
+	}
+
+	waiterCount := func(session string) int {
+		sessionWatchesMu.Lock()
+		defer sessionWatchesMu.Unlock()
+		if sw, ok := sessionWatches[testNamespace+"/"+session]; ok {
+			return len(sw.waiters)
+		}
+		return 0
+	}
+
+	// finish sets the session's phase, repeating the update until stop is closed so it is seen
+	// however late the shared watch opens
+	finish := func(session, phase string, stop chan struct{}) {
+		defer GinkgoRecover()
+		res := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace)
+		for i := 0; ; i++ {
+			obj, err := res.Get(ctx, session, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unstructured.SetNestedField(obj.Object, phase, "status", "phase")).To(Succeed())
+			obj.SetLabels(map[string]string{"attempt": strconv.Itoa(i)})
+			_, err = res.Update(ctx, obj, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			select {
+			case <-stop:
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+	}
+
+	It("Should return a session that is already terminal immediately", func() {
+		resp := wait("done", "")
+		resp.AssertHTTPStatus(http.StatusOK)
+		Expect(phaseOf(resp)).To(Equal("Completed"))
+		Expect(waiterCount("done")).To(Equal(0))
+	})
+
+	It("Should return once the session reaches a terminal phase, sharing one watch between callers", func() {
+		results := make(chan *test_utils.HTTPTestUtils, 2)
+		for i := 0; i < 2; i++ {
+			httpUtils, run := request("busy", "?timeoutSeconds=10")
+			go func() {
+				defer GinkgoRecover()
+				run()
+				results <- httpUtils
+			}()
+		}
+		Eventually(func() int { return waiterCount("busy") }).Should(Equal(2))
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go finish("busy", "Failed", stop)
+
+		for i := 0; i < 2; i++ {
+			var resp *test_utils.HTTPTestUtils
+			Eventually(results, 5*time.Second).Should(Receive(&resp))
+			resp.AssertHTTPStatus(http.StatusOK)
+			Expect(phaseOf(resp)).To(Equal("Failed"))
+		}
+		Expect(waiterCount("busy")).To(Equal(0))
+	})
+
+	It("Should treat Running as reached when waiting for running", func() {
+		resp := wait("busy", "?for=running")
+		resp.AssertHTTPStatus(http.StatusOK)
+		Expect(phaseOf(resp)).To(Equal("Running"))
+	})
+
+	It("Should return 408 with the current phase when the timeout elapses", func() {
+		resp := wait("busy", "?timeoutSeconds=1")
+		resp.AssertHTTPStatus(http.StatusRequestTimeout)
+		var body struct {
+			Error struct {
+				Details map[string]interface{} `json:"details"`
+			} `json:"error"`
+		}
+		Expect(json.Unmarshal(resp.GetResponseRecorder().Body.Bytes(), &body)).To(Succeed())
+		Expect(body.Error.Details).To(HaveKeyWithValue("phase", "Running"))
+		Expect(waiterCount("busy")).To(Equal(0))
+	})
+
+	It("Should reject invalid parameters", func() {
+		for _, query := range []string{"?timeoutSeconds=0", "?timeoutSeconds=3601", "?timeoutSeconds=soon", "?for=deleted"} {
+			wait("busy", query).AssertHTTPStatus(http.StatusBadRequest)
+		}
+	})
+
+	It("Should return 404 for a missing session", func() {
+		wait("missing", "").AssertHTTPStatus(http.StatusNotFound)
+		Expect(waiterCount("missing")).To(Equal(0))
+	})
+})
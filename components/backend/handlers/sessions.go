@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
@@ -12,13 +13,14 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
 
 	"ambient-code-backend/git"
+	"ambient-code-backend/httpclient"
 	"ambient-code-backend/pathutil"
 	"ambient-code-backend/types"
 
@@ -32,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	ktypes "k8s.io/apimachinery/pkg/types"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
@@ -40,12 +43,17 @@ import (
 var (
 	GetAgenticSessionV1Alpha1Resource func() schema.GroupVersionResource
 	DynamicClient                     dynamic.Interface
-	GetGitHubToken                    func(context.Context, kubernetes.Interface, dynamic.Interface, string, string) (string, error)
+	GetGitHubToken                    func(context.Context, kubernetes.Interface, dynamic.Interface, string, string, string) (string, error)
 	DeriveRepoFolderFromURL           func(string) string
 	// LEGACY: SendMessageToSession removed - AG-UI server uses HTTP/SSE instead of WebSocket
 )
 
-const runnerTokenRefreshedAtAnnotation = "ambient-code.io/token-refreshed-at"
+const (
+	runnerTokenRefreshedAtAnnotation = "ambient-code.io/token-refreshed-at"
+	// Keys within the runner token Secret; the operator projects them into the runner pod as files
+	runnerTokenSecretKey       = "k8s-token"
+	runnerTokenExpirySecretKey = "k8s-token-expiry"
+)
 
 // ootbWorkflowsCache provides in-memory caching for OOTB workflows to avoid GitHub API rate limits.
 // The cache stores workflows by repo URL key and expires after ootbCacheTTL.
@@ -125,6 +133,33 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 		result.Timeout = int(timeout)
 	}
 
+	if priority, ok := spec["priority"].(string); ok {
+		result.Priority = priority
+	}
+
+	if runnerImage, ok := spec["runnerImage"].(string); ok {
+		result.RunnerImage = runnerImage
+	}
+
+	if limit, ok := numberValue(spec["costLimitUSD"]); ok {
+		result.CostLimitUSD = &limit
+	}
+
+	if size, ok := spec["workspaceSize"].(string); ok {
+		result.WorkspaceSize = size
+	}
+
+	if ref, ok := spec["promptRef"].(map[string]interface{}); ok {
+		pr := &types.PromptRef{}
+		if name, ok := ref["configMapName"].(string); ok {
+			pr.ConfigMapName = name
+		}
+		if key, ok := ref["key"].(string); ok {
+			pr.Key = key
+		}
+		result.PromptRef = pr
+	}
+
 	if llmSettings, ok := spec["llmSettings"].(map[string]interface{}); ok {
 		if model, ok := llmSettings["model"].(string); ok {
 			result.LLMSettings.Model = model
@@ -183,6 +218,12 @@ func parseSpec(spec map[string]interface{}) types.AgenticSessionSpec {
 			if branch, ok := m["branch"].(string); ok && strings.TrimSpace(branch) != "" {
 				r.Branch = types.StringPtr(branch)
 			}
+			if ref, ok := m["ref"].(string); ok {
+				r.Ref = ref
+			}
+			if folder, ok := m["folder"].(string); ok {
+				r.Folder = folder
+			}
 			if strings.TrimSpace(r.URL) != "" {
 				repos = append(repos, r)
 			}
@@ -235,6 +276,10 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 		result.Phase = phase
 	}
 
+	if queuedAt, ok := status["queuedAt"].(string); ok && strings.TrimSpace(queuedAt) != "" {
+		result.QueuedAt = types.StringPtr(queuedAt)
+	}
+
 	if startTime, ok := status["startTime"].(string); ok && strings.TrimSpace(startTime) != "" {
 		result.StartTime = types.StringPtr(startTime)
 	}
@@ -265,6 +310,19 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 		}
 	}
 
+	if cost, ok := numberValue(status["totalCostUSD"]); ok {
+		result.TotalCostUSD = &cost
+	}
+	if reason, ok := status["stoppedReason"].(string); ok {
+		result.StoppedReason = reason
+	}
+	if usage, ok := status["usage"].(map[string]interface{}); ok {
+		result.Usage = usage
+	}
+	if seq, ok := numberValue(status["usageSeq"]); ok {
+		result.UsageSeq = int64(seq)
+	}
+
 	if repos, ok := status["reconciledRepos"].([]interface{}); ok && len(repos) > 0 {
 		result.ReconciledRepos = make([]types.ReconciledRepo, 0, len(repos))
 		for _, entry := range repos {
@@ -288,6 +346,30 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 			if clonedAt, ok := m["clonedAt"].(string); ok && strings.TrimSpace(clonedAt) != "" {
 				repo.ClonedAt = types.StringPtr(clonedAt)
 			}
+			if v, ok := m["clonedSha"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.ClonedSHA = types.StringPtr(v)
+			}
+			if v, ok := m["clonedBranch"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.ClonedBranch = types.StringPtr(v)
+			}
+			if v, ok := m["pushedSha"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.PushedSHA = types.StringPtr(v)
+			}
+			if v, ok := m["pushedBranch"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.PushedBranch = types.StringPtr(v)
+			}
+			if v, ok := m["gitStatus"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.GitStatus = types.StringPtr(v)
+			}
+			if v, ok := m["lastUpdated"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.LastUpdated = types.StringPtr(v)
+			}
+			if v, ok := m["forkOf"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.ForkOf = types.StringPtr(v)
+			}
+			if v, ok := m["forkUrl"].(string); ok && strings.TrimSpace(v) != "" {
+				repo.ForkURL = types.StringPtr(v)
+			}
 			result.ReconciledRepos = append(result.ReconciledRepos, repo)
 		}
 	}
@@ -350,6 +432,42 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 		}
 	}
 
+	if ra, ok := status["runnerAuth"].(map[string]interface{}); ok && len(ra) > 0 {
+		runnerAuth := &types.RunnerAuth{}
+		if secretName, ok := ra["secretName"].(string); ok {
+			runnerAuth.SecretName = secretName
+		}
+		if expiresAt, ok := ra["tokenExpiresAt"].(string); ok && strings.TrimSpace(expiresAt) != "" {
+			runnerAuth.TokenExpiresAt = types.StringPtr(expiresAt)
+		}
+		result.RunnerAuth = runnerAuth
+	}
+
+	if tl, ok := status["timeline"].(map[string]interface{}); ok && len(tl) > 0 {
+		milestone := func(key string) *string {
+			if v, ok := tl[key].(string); ok && strings.TrimSpace(v) != "" {
+				return types.StringPtr(v)
+			}
+			return nil
+		}
+		result.Timeline = &types.SessionTimeline{
+			CreatedAt:          milestone("createdAt"),
+			TokenProvisionedAt: milestone("tokenProvisionedAt"),
+			JobCreatedAt:       milestone("jobCreatedAt"),
+			PodScheduledAt:     milestone("podScheduledAt"),
+			RunnerStartedAt:    milestone("runnerStartedAt"),
+		}
+	}
+
+	if artifacts, ok := status["artifacts"].([]interface{}); ok && len(artifacts) > 0 {
+		result.Artifacts = make([]types.SessionArtifact, 0, len(artifacts))
+		for _, entry := range artifacts {
+			if m, ok := entry.(map[string]interface{}); ok {
+				result.Artifacts = append(result.Artifacts, parseArtifact(m))
+			}
+		}
+	}
+
 	return result
 }
 
@@ -358,9 +476,9 @@ func parseStatus(status map[string]interface{}) *types.AgenticSessionStatus {
 func ListSessions(c *gin.Context) {
 	project := c.GetString("project")
 
-	_, k8sDyn := GetK8sClientsForRequest(c)
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -369,10 +487,15 @@ func ListSessions(c *gin.Context) {
 	// Parse pagination parameters
 	var params types.PaginationParams
 	if err := c.ShouldBindQuery(&params); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pagination parameters"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid pagination parameters", nil)
 		return
 	}
 	types.NormalizePaginationParams(&params)
+	order, err := parseListSort(params, sortByCreationTimestamp, sortByCompletionTime, sortByDisplayName)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
 
 	// Build list options with pagination
 	// Note: Kubernetes List with Limit returns a continue token for server-side pagination
@@ -380,34 +503,31 @@ func ListSessions(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	list, err := k8sDyn.Resource(gvr).Namespace(project).List(ctx, v1.ListOptions{})
+	selector, err := sessionListSelector(c)
 	if err != nil {
-		log.Printf("Failed to list agentic sessions in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list agentic sessions"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
-	var sessions []types.AgenticSession
-	for _, item := range list.Items {
-		meta, _, err := unstructured.NestedMap(item.Object, "metadata")
+	items, cached := cachedList(c, reqK8s, project, gvr, selector)
+	if !cached {
+		list, err := k8sDyn.Resource(gvr).Namespace(project).List(ctx, v1.ListOptions{LabelSelector: selector.String()})
 		if err != nil {
-			log.Printf("ListSessions: failed to read metadata for %s/%s: %v", project, item.GetName(), err)
-			meta = map[string]interface{}{}
-		}
-		session := types.AgenticSession{
-			APIVersion: item.GetAPIVersion(),
-			Kind:       item.GetKind(),
-			Metadata:   meta,
+			log.Printf("Failed to list agentic sessions in project %s: %v", project, err)
+			respondK8sError(c, err, "Project not found", "Failed to list agentic sessions")
+			return
 		}
+		items = list.Items
+	}
 
-		if spec, found, err := unstructured.NestedMap(item.Object, "spec"); err == nil && found {
-			session.Spec = parseSpec(spec)
-		}
+	revealEnv := shouldRevealSessionEnv(c, reqK8s, project)
 
-		if status, found, err := unstructured.NestedMap(item.Object, "status"); err == nil && found {
-			session.Status = parseStatus(status)
+	sessions := make([]types.AgenticSession, 0, len(items))
+	for i := range items {
+		session := sessionResponse(&items[i], revealEnv)
+		if session.Metadata == nil {
+			session.Metadata = map[string]interface{}{}
 		}
-
 		sessions = append(sessions, session)
 	}
 
@@ -416,8 +536,7 @@ func ListSessions(c *gin.Context) {
 		sessions = filterSessionsBySearch(sessions, params.Search)
 	}
 
-	// Sort by creation timestamp (newest first)
-	sortSessionsByCreationTime(sessions)
+	sortSessions(sessions, order)
 
 	// Apply pagination
 	totalCount := len(sessions)
@@ -471,15 +590,33 @@ func filterSessionsBySearch(sessions []types.AgenticSession, search string) []ty
 	return filtered
 }
 
-// sortSessionsByCreationTime sorts sessions by creation timestamp (newest first)
-func sortSessionsByCreationTime(sessions []types.AgenticSession) {
-	// Use sort.Slice for O(n log n) performance
-	sort.Slice(sessions, func(i, j int) bool {
-		ts1 := getSessionCreationTimestamp(sessions[i])
-		ts2 := getSessionCreationTimestamp(sessions[j])
-		// Sort descending (newest first) - RFC3339 timestamps sort lexicographically
-		return ts1 > ts2
-	})
+// sortSessions orders sessions by ?sortBy= (default creationTimestamp, newest first). Sessions
+// that have not completed sort last by completionTime; displayName falls back to the name.
+func sortSessions(sessions []types.AgenticSession, order listSort) {
+	value := getSessionCreationTimestamp
+	switch order.key {
+	case sortByCompletionTime:
+		value = func(session types.AgenticSession) string {
+			if session.Status == nil || session.Status.CompletionTime == nil {
+				return ""
+			}
+			return *session.Status.CompletionTime
+		}
+	case sortByDisplayName:
+		value = func(session types.AgenticSession) string {
+			if session.Spec.DisplayName != "" {
+				return strings.ToLower(session.Spec.DisplayName)
+			}
+			return strings.ToLower(getSessionName(session))
+		}
+	}
+	sortListStable(sessions, order, value, getSessionName)
+}
+
+// getSessionName extracts the session name from session metadata
+func getSessionName(session types.AgenticSession) string {
+	name, _ := session.Metadata["name"].(string)
+	return name
 }
 
 // getSessionCreationTimestamp extracts the creation timestamp from session metadata
@@ -512,23 +649,120 @@ func paginateSessions(sessions []types.AgenticSession, offset, limit int) ([]typ
 	return sessions[offset:end], hasMore, nextOffset
 }
 
-func CreateSession(c *gin.Context) {
-	project := c.GetString("project")
+// normalizeSessionPriority validates a requested session priority, defaulting to normal when unset
+func normalizeSessionPriority(priority string) (string, error) {
+	p := strings.ToLower(strings.TrimSpace(priority))
+	switch p {
+	case "":
+		return types.SessionPriorityNormal, nil
+	case types.SessionPriorityLow, types.SessionPriorityNormal, types.SessionPriorityHigh:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid priority %q: must be one of low, normal, high", priority)
+	}
+}
 
-	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
-	if reqK8s == nil || k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User token required"})
-		c.Abort()
-		return
+// callerUserContext builds spec.userContext from the authenticated caller. Client-supplied
+// values are only used as fallbacks for the non-identity fields. Returns nil when the caller
+// has no user ID.
+func callerUserContext(c *gin.Context, fallback *types.UserContext) map[string]interface{} {
+	uidVal, _ := c.Get("userID")
+	uid, _ := uidVal.(string)
+	uid = strings.TrimSpace(uid)
+	if uid == "" {
+		return nil
 	}
-	var req types.CreateAgenticSessionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
+	displayName := ""
+	if v, ok := c.Get("userName"); ok {
+		if s, ok2 := v.(string); ok2 {
+			displayName = s
+		}
+	}
+	groups := []string{}
+	if v, ok := c.Get("userGroups"); ok {
+		if gg, ok2 := v.([]string); ok2 {
+			groups = gg
+		}
+	}
+	if displayName == "" && fallback != nil {
+		displayName = fallback.DisplayName
+	}
+	if len(groups) == 0 && fallback != nil {
+		groups = fallback.Groups
+	}
+	return map[string]interface{}{
+		"userId":      uid,
+		"displayName": displayName,
+		"groups":      groups,
+	}
+}
+
+// validateCreateSessionRequest checks a create request and normalizes its priority. Imported
+// bundles are converted to a create request and go through the same checks.
+func validateCreateSessionRequest(req *types.CreateAgenticSessionRequest) error {
+	priority, err := normalizeSessionPriority(req.Priority)
+	if err != nil {
+		return err
 	}
+	req.Priority = priority
 
-	// Validation for multi-repo can be added here if needed
+	if req.Timeout != nil && *req.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if req.CostLimitUSD != nil && !(*req.CostLimitUSD > 0) {
+		return fmt.Errorf("costLimitUSD must be greater than 0")
+	}
+	if len(req.InitialPrompt) > maxStoredPromptBytes {
+		return fmt.Errorf("initialPrompt must not exceed %d bytes", maxStoredPromptBytes)
+	}
+	req.RunnerImage = strings.TrimSpace(req.RunnerImage)
+	if strings.ContainsAny(req.RunnerImage, " \t\n") {
+		return fmt.Errorf("runnerImage must not contain whitespace")
+	}
+	folders := make(map[string]bool, len(req.Repos))
+	for i, repo := range req.Repos {
+		if strings.TrimSpace(repo.URL) == "" {
+			return fmt.Errorf("repos[%d].url is required", i)
+		}
+		if repo.Branch != nil && strings.TrimSpace(*repo.Branch) != "" {
+			if err := git.ValidateBranchName(strings.TrimSpace(*repo.Branch)); err != nil {
+				return &invalidBranchError{field: fmt.Sprintf("repos[%d].branch", i), err: err}
+			}
+		}
+		if strings.TrimSpace(repo.Ref) != "" {
+			if repo.Branch != nil && strings.TrimSpace(*repo.Branch) != "" {
+				return &repoRefConflictError{index: i}
+			}
+			ref, err := normalizeRepoRef(repo.Ref)
+			if err != nil {
+				return fmt.Errorf("repos[%d].ref %s", i, err)
+			}
+			req.Repos[i].Ref = ref
+		} else {
+			req.Repos[i].Ref = ""
+		}
+		if repo.Folder == "" {
+			continue
+		}
+		if !validRepoFolder(repo.Folder) {
+			return fmt.Errorf("repos[%d].folder must be a single directory name of letters, digits, '.', '_' or '-'", i)
+		}
+		if folders[repo.Folder] {
+			return fmt.Errorf("repos[%d].folder %q is used by another repo", i, repo.Folder)
+		}
+		folders[repo.Folder] = true
+	}
+	for k := range req.EnvironmentVariables {
+		if strings.TrimSpace(k) == "" {
+			return fmt.Errorf("environmentVariables keys must not be empty")
+		}
+	}
+	return nil
+}
 
+// buildSessionObject builds a Pending AgenticSession from a validated create request. Only the
+// fields of CreateAgenticSessionRequest reach the spec; userContext comes from the caller.
+func buildSessionObject(c *gin.Context, project, name string, req *types.CreateAgenticSessionRequest) map[string]interface{} {
 	// Set defaults for LLM settings if not provided
 	llmSettings := types.LLMSettings{
 		Model:       "sonnet",
@@ -552,10 +786,6 @@ func CreateSession(c *gin.Context) {
 		timeout = *req.Timeout
 	}
 
-	// Generate unique name
-	timestamp := time.Now().Unix()
-	name := fmt.Sprintf("agentic-session-%d", timestamp)
-
 	// Create the custom resource
 	// Metadata
 	metadata := map[string]interface{}{
@@ -585,11 +815,21 @@ func CreateSession(c *gin.Context) {
 			"temperature": llmSettings.Temperature,
 			"maxTokens":   llmSettings.MaxTokens,
 		},
-		"timeout": timeout,
+		"timeout":  timeout,
+		"priority": req.Priority,
 	}
 	if strings.TrimSpace(req.InitialPrompt) != "" {
 		spec["initialPrompt"] = req.InitialPrompt
 	}
+	if req.RunnerImage != "" {
+		spec["runnerImage"] = req.RunnerImage
+	}
+	if req.CostLimitUSD != nil {
+		spec["costLimitUSD"] = *req.CostLimitUSD
+	}
+	if req.WorkspaceSize != "" {
+		spec["workspaceSize"] = req.WorkspaceSize
+	}
 
 	session := map[string]interface{}{
 		"apiVersion": "vteam.ambient-code/v1alpha1",
@@ -601,8 +841,9 @@ func CreateSession(c *gin.Context) {
 		},
 	}
 
-	// Optional environment variables passthrough (always, independent of git config presence)
-	envVars := make(map[string]string)
+	// Optional environment variables passthrough (always, independent of git config presence).
+	// Kept as map[string]interface{} so the object stays deep-copyable as unstructured content.
+	envVars := make(map[string]interface{})
 	for k, v := range req.EnvironmentVariables {
 		envVars[k] = v
 	}
@@ -619,6 +860,7 @@ func CreateSession(c *gin.Context) {
 		log.Printf("Creating continuation session from parent %s (operator will handle temp pod cleanup)", req.ParentSessionID)
 		// Note: Operator will delete temp pod when session starts (desired-phase=Running)
 	}
+	annotateSessionTrace(c, metadata)
 
 	if len(envVars) > 0 {
 		spec := session["spec"].(map[string]interface{})
@@ -645,53 +887,177 @@ func CreateSession(c *gin.Context) {
 				if r.Branch != nil {
 					m["branch"] = *r.Branch
 				}
+				if r.Ref != "" {
+					m["ref"] = r.Ref
+				}
+				if r.Folder != "" {
+					m["folder"] = r.Folder
+				}
 				arr = append(arr, m)
 			}
+			assignRepoFolders(arr)
 			spec["repos"] = arr
 		}
 	}
 
 	// Add userContext derived from authenticated caller; ignore client-supplied userId
-	{
-		uidVal, _ := c.Get("userID")
-		uid, _ := uidVal.(string)
-		uid = strings.TrimSpace(uid)
-		if uid != "" {
-			displayName := ""
-			if v, ok := c.Get("userName"); ok {
-				if s, ok2 := v.(string); ok2 {
-					displayName = s
-				}
-			}
-			groups := []string{}
-			if v, ok := c.Get("userGroups"); ok {
-				if gg, ok2 := v.([]string); ok2 {
-					groups = gg
-				}
-			}
-			// Fallbacks for non-identity fields only
-			if displayName == "" && req.UserContext != nil {
-				displayName = req.UserContext.DisplayName
-			}
-			if len(groups) == 0 && req.UserContext != nil {
-				groups = req.UserContext.Groups
+	userID := ""
+	if userContext := callerUserContext(c, req.UserContext); userContext != nil {
+		session["spec"].(map[string]interface{})["userContext"] = userContext
+		userID, _ = userContext["userId"].(string)
+	}
+	setSessionIdentityLabels(metadata, userID, llmSettings.Model)
+
+	return session
+}
+
+// prepareSessionCreate validates a create request and applies the project's checks and defaults
+// to it the same way for every path that creates a new session: runner image, default repos, git
+// hosts, pinned refs, prompt template, model, cost limit and workspace size. It returns the
+// project's chargeback labels; on failure it writes the error response and returns false.
+func prepareSessionCreate(c *gin.Context, reqK8s kubernetes.Interface, k8sDyn dynamic.Interface, project string, req *types.CreateAgenticSessionRequest) (map[string]string, bool) {
+	if err := validateCreateSessionRequest(req); err != nil {
+		respondError(c, createValidationStatus(err), ErrorKindValidation, err.Error(), nil)
+		return nil, false
+	}
+	if !requireAllowedRunnerImage(c, k8sDyn, project, req.RunnerImage) {
+		return nil, false
+	}
+	if !applyDefaultRepos(c, k8sDyn, project, req) {
+		return nil, false
+	}
+	if !requireAllowedGitURLs(c, k8sDyn, project, createRequestGitURLs(req.Repos)...) {
+		return nil, false
+	}
+	if !requireRepoRefsExist(c, reqK8s, k8sDyn, project, req.Repos) {
+		return nil, false
+	}
+	if !applyPromptTemplate(c, k8sDyn, project, req) {
+		return nil, false
+	}
+	requestedModel := ""
+	if req.LLMSettings != nil {
+		requestedModel = req.LLMSettings.Model
+	}
+	model, ok := resolveSessionModel(c, k8sDyn, project, requestedModel)
+	if !ok {
+		return nil, false
+	}
+	if req.LLMSettings == nil {
+		req.LLMSettings = &types.LLMSettings{}
+	}
+	req.LLMSettings.Model = model
+	costLimit, ok := resolveSessionCostLimit(c, k8sDyn, project, req.CostLimitUSD)
+	if !ok {
+		return nil, false
+	}
+	req.CostLimitUSD = costLimit
+	workspaceSize, ok := resolveWorkspaceSize(c, k8sDyn, project, req.WorkspaceSize)
+	if !ok {
+		return nil, false
+	}
+	req.WorkspaceSize = workspaceSize
+	return resolveChargebackLabels(c, k8sDyn, project)
+}
+
+// CreateSession creates an AgenticSession from the request body.
+// POST /api/projects/:projectName/agentic-sessions[?dryRun=true]
+// With dryRun=true the create is sent to the API server as a server-side dry run: validation, defaulting
+// and RBAC run exactly as for a real create but nothing is persisted, and the rendered object is returned
+// with 200 instead. Runner token provisioning is skipped. The runner Job is rendered by the operator
+// once the session exists and is not part of the preview.
+func CreateSession(c *gin.Context) {
+	project := c.GetString("project")
+	dryRun := strings.EqualFold(c.Query("dryRun"), "true")
+
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "User token required", nil)
+		c.Abort()
+		return
+	}
+	var req types.CreateAgenticSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+
+	chargeback, ok := prepareSessionCreate(c, reqK8s, k8sDyn, project, &req)
+	if !ok {
+		return
+	}
+	// A continuation's runner mounts its parent's workspace, which a temp content pod may still hold
+	if req.ParentSessionID != "" && !dryRun && !requireWorkspaceReleased(c, project, req.ParentSessionID) {
+		return
+	}
+	// Over ProjectSettings maxConcurrentSessions the session is rejected, or queued when req.Queue is set
+	queued := false
+	if !checkConcurrentSessionLimit(c, k8sDyn, project, "", req.Queue) {
+		if !req.Queue {
+			return
+		}
+		queued = !dryRun
+	}
+	// A continuation reuses its parent's workspace; anything else provisions a new PVC
+	storageWarning := ""
+	if req.ParentSessionID == "" {
+		storageWarning = sessionStorageWarning(c.Request.Context(), reqK8s, project, req.WorkspaceSize)
+	}
+
+	createOpts := v1.CreateOptions{}
+	if dryRun {
+		createOpts.DryRun = []string{v1.DryRunAll}
+	}
+
+	// Create AgenticSession using user token (enforces user RBAC permissions); the backend SA is
+	// only used below for runner token provisioning and queue status. Prompts over the inline limit
+	// are stored in a ConfigMap referenced by spec.promptRef. Names come from the creation second,
+	// so a concurrent create can take the name first; then a suffixed name is tried instead.
+	var name string
+	var created *unstructured.Unstructured
+	timestamp := time.Now().Unix()
+	for attempt := 0; ; attempt++ {
+		name = newSessionName(timestamp, attempt)
+		session := buildSessionObject(c, project, name, &req)
+		setChargebackLabels(session["metadata"].(map[string]interface{}), chargeback)
+		obj := &unstructured.Unstructured{Object: session}
+
+		// Auto-pushed sessions get their output branch up front, so the runner pushes to it and
+		// restarts keep it
+		if req.AutoPushOnComplete != nil && *req.AutoPushOnComplete {
+			branch, err := sessionOutputBranch(c.Request.Context(), k8sDyn, project, obj)
+			if err != nil {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+				return
 			}
-			session["spec"].(map[string]interface{})["userContext"] = map[string]interface{}{
-				"userId":      uid,
-				"displayName": displayName,
-				"groups":      groups,
+			if branch != "" {
+				setUnsetOutputBranches(session, branch)
 			}
 		}
-	}
 
-	gvr := GetAgenticSessionV1Alpha1Resource()
-	obj := &unstructured.Unstructured{Object: session}
+		var err error
+		created, err = createSessionWithPrompt(context.TODO(), k8sDyn, obj, createOpts)
+		if errors.IsAlreadyExists(err) && attempt+1 < maxSessionNameAttempts {
+			log.Printf("Session name %s/%s is taken, retrying with another name", project, name)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to create agentic session in project %s: %v", project, err)
+			respondK8sError(c, err, "Project not found", "Failed to create agentic session")
+			return
+		}
+		break
+	}
 
-	// Create AgenticSession using user token (enforces user RBAC permissions)
-	created, err := k8sDyn.Resource(gvr).Namespace(project).Create(context.TODO(), obj, v1.CreateOptions{})
-	if err != nil {
-		log.Printf("Failed to create agentic session in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create agentic session"})
+	if dryRun {
+		resp := gin.H{
+			"dryRun":  true,
+			"session": created.Object,
+		}
+		if storageWarning != "" {
+			resp["storageWarning"] = storageWarning
+		}
+		c.JSON(http.StatusOK, resp)
 		return
 	}
 
@@ -719,24 +1085,57 @@ func CreateSession(c *gin.Context) {
 		}
 	}()
 
-	// Provision runner token using backend SA (requires elevated permissions for SA/Role/Secret creation)
-	if DynamicClient == nil || K8sClient == nil {
-		log.Printf("Warning: backend SA clients not available, skipping runner token provisioning for session %s/%s", project, name)
-	} else if err := provisionRunnerTokenForSession(c, K8sClient, DynamicClient, project, name); err != nil {
-		// Nonfatal: log and continue. Operator may retry later if implemented.
-		log.Printf("Warning: failed to provision runner token for session %s/%s: %v", project, name, err)
+	// Provision runner token using backend SA (requires elevated permissions for SA/Role/Secret
+	// creation). A failure is returned as a warning and recorded on the session, not failed.
+	warnings := provisionNewSessionRunnerAuth(c, k8sDyn, project, name)
+
+	if queued {
+		queueNewSession(c.Request.Context(), project, name)
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	resp := gin.H{
 		"message": "Agentic session created successfully",
 		"name":    name,
 		"uid":     created.GetUID(),
-	})
+		"queued":  queued,
+		"ready":   len(warnings) == 0,
+	}
+	if storageWarning != "" {
+		resp["storageWarning"] = storageWarning
+	}
+	if len(warnings) > 0 {
+		resp["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+// maxSessionNameAttempts bounds the names CreateSession tries before giving up with 409
+const maxSessionNameAttempts = 5
+
+// newSessionName returns the name of a session created at timestamp: agentic-session-<unix>, or
+// for retries after a name collision the same with a random suffix
+func newSessionName(timestamp int64, attempt int) string {
+	if attempt == 0 {
+		return fmt.Sprintf("agentic-session-%d", timestamp)
+	}
+	return fmt.Sprintf("agentic-session-%d-%s", timestamp, utilrand.String(5))
+}
+
+// ownedByUID reports whether refs contain an owner with the given UID
+func ownedByUID(refs []v1.OwnerReference, uid ktypes.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
 }
 
 // provisionRunnerTokenForSession creates a per-session ServiceAccount, grants minimal RBAC,
 // mints a short-lived token, stores it in a Secret, and annotates the AgenticSession with the Secret name.
-func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string, sessionName string) error {
+// Objects it creates are deleted again (best-effort) if a later step fails, and objects left behind by
+// a deleted session of the same name are re-owned so they are not garbage collected from under this one.
+func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface, reqDyn dynamic.Interface, project string, sessionName string) (retErr error) {
 	// Load owning AgenticSession to parent all resources
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	obj, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
@@ -751,8 +1150,29 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 		Controller: types.BoolPtr(true),
 	}
 
+	// Roll back whatever this call created if a later step fails
+	type createdObject struct {
+		kind   string
+		name   string
+		delete func(ctx context.Context, name string, opts v1.DeleteOptions) error
+	}
+	var created []createdObject
+	defer func() {
+		if retErr == nil {
+			return
+		}
+		for i := len(created) - 1; i >= 0; i-- {
+			o := created[i]
+			if err := o.delete(context.Background(), o.name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				log.Printf("Warning: failed to roll back %s %s/%s after provisioning error: %v", o.kind, project, o.name, err)
+				continue
+			}
+			log.Printf("Rolled back %s %s/%s after provisioning error", o.kind, project, o.name)
+		}
+	}()
+
 	// Create ServiceAccount
-	saName := fmt.Sprintf("ambient-session-%s", sessionName)
+	saName := runnerServiceAccountName(sessionName)
 	sa := &corev1.ServiceAccount{
 		ObjectMeta: v1.ObjectMeta{
 			Name:            saName,
@@ -761,49 +1181,56 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 			OwnerReferences: []v1.OwnerReference{ownerRef},
 		},
 	}
-	if _, err := reqK8s.CoreV1().ServiceAccounts(project).Create(c.Request.Context(), sa, v1.CreateOptions{}); err != nil {
+	saClient := reqK8s.CoreV1().ServiceAccounts(project)
+	if _, err := saClient.Create(c.Request.Context(), sa, v1.CreateOptions{}); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("create SA: %w", err)
 		}
+		existing, err := saClient.Get(c.Request.Context(), saName, v1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get SA: %w", err)
+		}
+		if !ownedByUID(existing.OwnerReferences, obj.GetUID()) {
+			log.Printf("ServiceAccount %s belongs to a previous session, re-owning it", saName)
+			existing.OwnerReferences = []v1.OwnerReference{ownerRef}
+			if _, err := saClient.Update(c.Request.Context(), existing, v1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("update SA: %w", err)
+			}
+		}
+	} else {
+		created = append(created, createdObject{"ServiceAccount", saName, saClient.Delete})
 	}
 
-	// Create Role with least-privilege for updating AgenticSession status and annotations
-	roleName := fmt.Sprintf("ambient-session-%s-role", sessionName)
+	// Create Role with least-privilege for reading and annotating the AgenticSession
+	roleName := runnerRoleName(sessionName)
 	role := &rbacv1.Role{
 		ObjectMeta: v1.ObjectMeta{
 			Name:            roleName,
 			Namespace:       project,
+			Annotations:     map[string]string{runnerRoleVersionAnnotation: runnerRoleVersion()},
 			OwnerReferences: []v1.OwnerReference{ownerRef},
 		},
-		Rules: []rbacv1.PolicyRule{
-			{
-				APIGroups: []string{"vteam.ambient-code"},
-				Resources: []string{"agenticsessions"},
-				Verbs:     []string{"get", "list", "watch", "update", "patch"}, // Added update, patch for annotations
-			},
-			{
-				APIGroups: []string{"authorization.k8s.io"},
-				Resources: []string{"selfsubjectaccessreviews"},
-				Verbs:     []string{"create"},
-			},
-		},
+		Rules: runnerRoleRules(sessionName),
 	}
-	// Try to create or update the Role to ensure it has latest permissions
-	if _, err := reqK8s.RbacV1().Roles(project).Create(c.Request.Context(), role, v1.CreateOptions{}); err != nil {
-		if errors.IsAlreadyExists(err) {
-			// Role exists - update it to ensure it has the latest permissions (including update/patch)
-			log.Printf("Role %s already exists, updating with latest permissions", roleName)
-			if _, err := reqK8s.RbacV1().Roles(project).Update(c.Request.Context(), role, v1.UpdateOptions{}); err != nil {
-				return fmt.Errorf("update Role: %w", err)
-			}
-			log.Printf("Successfully updated Role %s with annotation update permissions", roleName)
-		} else {
+	// Create the Role, or bring an existing one up to the current rules version (and owner)
+	roleClient := reqK8s.RbacV1().Roles(project)
+	if _, err := roleClient.Create(c.Request.Context(), role, v1.CreateOptions{}); err != nil {
+		if !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("create Role: %w", err)
 		}
+		existing, err := roleClient.Get(c.Request.Context(), roleName, v1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get Role: %w", err)
+		}
+		if err := reconcileRunnerRole(c.Request.Context(), roleClient, existing, obj, ownerRef, time.Now()); err != nil {
+			return err
+		}
+	} else {
+		created = append(created, createdObject{"Role", roleName, roleClient.Delete})
 	}
 
 	// Bind Role to the ServiceAccount
-	rbName := fmt.Sprintf("ambient-session-%s-rb", sessionName)
+	rbName := runnerRoleBindingName(sessionName)
 	rb := &rbacv1.RoleBinding{
 		ObjectMeta: v1.ObjectMeta{
 			Name:            rbName,
@@ -813,10 +1240,25 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 		RoleRef:  rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "Role", Name: roleName},
 		Subjects: []rbacv1.Subject{{Kind: "ServiceAccount", Name: saName, Namespace: project}},
 	}
-	if _, err := reqK8s.RbacV1().RoleBindings(project).Create(context.TODO(), rb, v1.CreateOptions{}); err != nil {
+	rbClient := reqK8s.RbacV1().RoleBindings(project)
+	if _, err := rbClient.Create(c.Request.Context(), rb, v1.CreateOptions{}); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return fmt.Errorf("create RoleBinding: %w", err)
 		}
+		existing, err := rbClient.Get(c.Request.Context(), rbName, v1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get RoleBinding: %w", err)
+		}
+		if !ownedByUID(existing.OwnerReferences, obj.GetUID()) {
+			log.Printf("RoleBinding %s belongs to a previous session, re-owning it", rbName)
+			existing.OwnerReferences = []v1.OwnerReference{ownerRef}
+			existing.Subjects = rb.Subjects
+			if _, err := rbClient.Update(c.Request.Context(), existing, v1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("update RoleBinding: %w", err)
+			}
+		}
+	} else {
+		created = append(created, createdObject{"RoleBinding", rbName, rbClient.Delete})
 	}
 
 	// Mint short-lived K8s ServiceAccount token for CR status updates
@@ -829,20 +1271,25 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 	if strings.TrimSpace(k8sToken) == "" {
 		return fmt.Errorf("received empty token for SA %s", saName)
 	}
+	expiresAt := ""
+	if !tok.Status.ExpirationTimestamp.IsZero() {
+		expiresAt = tok.Status.ExpirationTimestamp.UTC().Format(time.RFC3339)
+	}
 
 	// Only store the K8s token; GitHub tokens are minted on-demand by the runner
 	secretData := map[string]string{
-		"k8s-token": k8sToken,
+		runnerTokenSecretKey:       k8sToken,
+		runnerTokenExpirySecretKey: expiresAt,
 	}
 
 	// Store token in a Secret (update if exists to refresh token)
-	secretName := fmt.Sprintf("ambient-runner-token-%s", sessionName)
+	secretName := runnerTokenSecretName(sessionName)
 	refreshedAt := time.Now().UTC().Format(time.RFC3339)
 	sec := &corev1.Secret{
 		ObjectMeta: v1.ObjectMeta{
 			Name:            secretName,
 			Namespace:       project,
-			Labels:          map[string]string{"app": "ambient-runner-token"},
+			Labels:          map[string]string{"app": runnerTokenSecretLabel},
 			OwnerReferences: []v1.OwnerReference{ownerRef},
 			Annotations: map[string]string{
 				runnerTokenRefreshedAtAnnotation: refreshedAt,
@@ -853,38 +1300,45 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 	}
 
 	// Try to create the secret
-	if _, err := reqK8s.CoreV1().Secrets(project).Create(c.Request.Context(), sec, v1.CreateOptions{}); err != nil {
+	secretClient := reqK8s.CoreV1().Secrets(project)
+	if _, err := secretClient.Create(c.Request.Context(), sec, v1.CreateOptions{}); err != nil {
 		if errors.IsAlreadyExists(err) {
 			// Secret exists - update it with fresh token
 			log.Printf("Updating existing secret %s with fresh token", secretName)
-			existing, getErr := reqK8s.CoreV1().Secrets(project).Get(c.Request.Context(), secretName, v1.GetOptions{})
+			existing, getErr := secretClient.Get(c.Request.Context(), secretName, v1.GetOptions{})
 			if getErr != nil {
 				return fmt.Errorf("get Secret for update: %w", getErr)
 			}
 			secretCopy := existing.DeepCopy()
+			if !ownedByUID(secretCopy.OwnerReferences, obj.GetUID()) {
+				secretCopy.OwnerReferences = []v1.OwnerReference{ownerRef}
+			}
 			if secretCopy.Data == nil {
 				secretCopy.Data = map[string][]byte{}
 			}
-			secretCopy.Data["k8s-token"] = []byte(k8sToken)
+			secretCopy.Data[runnerTokenSecretKey] = []byte(k8sToken)
+			secretCopy.Data[runnerTokenExpirySecretKey] = []byte(expiresAt)
 			if secretCopy.Annotations == nil {
 				secretCopy.Annotations = map[string]string{}
 			}
 			secretCopy.Annotations[runnerTokenRefreshedAtAnnotation] = refreshedAt
-			if _, err := reqK8s.CoreV1().Secrets(project).Update(c.Request.Context(), secretCopy, v1.UpdateOptions{}); err != nil {
+			if _, err := secretClient.Update(c.Request.Context(), secretCopy, v1.UpdateOptions{}); err != nil {
 				return fmt.Errorf("update Secret: %w", err)
 			}
 			log.Printf("Successfully updated secret %s with fresh token", secretName)
 		} else {
 			return fmt.Errorf("create Secret: %w", err)
 		}
+	} else {
+		created = append(created, createdObject{"Secret", secretName, secretClient.Delete})
 	}
 
 	// Annotate the AgenticSession with the Secret and SA names (conflict-safe patch)
 	patch := map[string]interface{}{
 		"metadata": map[string]interface{}{
 			"annotations": map[string]string{
-				"ambient-code.io/runner-token-secret": secretName,
-				"ambient-code.io/runner-sa":           saName,
+				runnerTokenSecretAnnotation: secretName,
+				"ambient-code.io/runner-sa": saName,
 			},
 		},
 	}
@@ -896,6 +1350,27 @@ func provisionRunnerTokenForSession(c *gin.Context, reqK8s kubernetes.Interface,
 		return fmt.Errorf("annotate AgenticSession: %w", err)
 	}
 
+	// Publish the Secret name and token expiry in status so clients need not decode the JWT, and
+	// record the token milestone of the session's startup timeline
+	runnerAuth := map[string]interface{}{"secretName": secretName}
+	if expiresAt != "" {
+		runnerAuth["tokenExpiresAt"] = expiresAt
+	}
+	timeline := map[string]interface{}{"tokenProvisionedAt": time.Now().UTC().Format(time.RFC3339)}
+	if created := obj.GetCreationTimestamp(); !created.IsZero() {
+		timeline["createdAt"] = created.UTC().Format(time.RFC3339)
+	}
+	statusPatch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"runnerAuth": runnerAuth, "timeline": timeline},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal status patch: %w", err)
+	}
+	if _, err := reqDyn.Resource(gvr).Namespace(project).Patch(c.Request.Context(), obj.GetName(), ktypes.MergePatchType, statusPatch, v1.PatchOptions{}, "status"); err != nil {
+		// Non-fatal: the token is usable; only the advertised expiry is missing
+		log.Printf("Warning: failed to set status.runnerAuth and timeline for session %s/%s: %v", project, sessionName, err)
+	}
+
 	return nil
 }
 
@@ -905,42 +1380,43 @@ func GetSession(c *gin.Context) {
 
 	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
 	if reqK8s == nil || k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
-	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+	item, cached := cachedGet(c, reqK8s, project, gvr, sessionName)
+	if !cached {
+		var err error
+		item, err = k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
+		if err != nil {
+			log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
+			respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 			return
 		}
-		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
-		return
-	}
-
-	session := types.AgenticSession{
-		APIVersion: item.GetAPIVersion(),
-		Kind:       item.GetKind(),
-		Metadata:   item.Object["metadata"].(map[string]interface{}),
 	}
 
-	if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(spec)
-	}
+	session := sessionResponse(item, shouldRevealSessionEnv(c, reqK8s, project))
 
-	if status, ok := item.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(status)
+	// Prompts stored in a ConfigMap are only read on request; spec.promptRef is kept in the response
+	if session.Spec.PromptRef != nil && strings.EqualFold(c.Query("resolvePrompt"), "true") {
+		prompt, err := resolveSessionPrompt(c.Request.Context(), K8sClient, item)
+		if err != nil {
+			log.Printf("Failed to resolve prompt of agentic session %s/%s: %v", project, sessionName, err)
+			respondK8sError(c, err, "Session prompt not found", "Failed to read session prompt")
+			return
+		}
+		session.Spec.InitialPrompt = prompt
 	}
 
 	c.JSON(http.StatusOK, session)
 }
 
 // MintSessionGitHubToken validates the token via TokenReview, ensures SA matches CR annotation, and returns a short-lived GitHub token.
-// POST /api/projects/:projectName/agentic-sessions/:sessionName/github/token
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/github/token[?repo=<url|owner/repo|owner>]
+// The token is minted for the user's GitHub App installation on the repo owner's account, by default
+// the session's first GitHub repo.
 // Auth: Authorization: Bearer <BOT_TOKEN> (K8s SA token with audience "ambient-backend")
 func MintSessionGitHubToken(c *gin.Context) {
 	project := c.Param("projectName")
@@ -948,17 +1424,17 @@ func MintSessionGitHubToken(c *gin.Context) {
 
 	rawAuth := strings.TrimSpace(c.GetHeader("Authorization"))
 	if rawAuth == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "missing Authorization header", nil)
 		return
 	}
 	parts := strings.SplitN(rawAuth, " ", 2)
 	if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid Authorization header"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "invalid Authorization header", nil)
 		return
 	}
 	token := strings.TrimSpace(parts[1])
 	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "empty token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "empty token", nil)
 		return
 	}
 
@@ -966,28 +1442,28 @@ func MintSessionGitHubToken(c *gin.Context) {
 	tr := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}
 	rv, err := K8sClient.AuthenticationV1().TokenReviews().Create(c.Request.Context(), tr, v1.CreateOptions{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "token review failed"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "token review failed", nil)
 		return
 	}
 	if rv.Status.Error != "" || !rv.Status.Authenticated {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthenticated"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "unauthenticated", nil)
 		return
 	}
 	subj := strings.TrimSpace(rv.Status.User.Username)
 	const pfx = "system:serviceaccount:"
 	if !strings.HasPrefix(subj, pfx) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "subject is not a service account"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "subject is not a service account", nil)
 		return
 	}
 	rest := strings.TrimPrefix(subj, pfx)
 	segs := strings.SplitN(rest, ":", 2)
 	if len(segs) != 2 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "invalid service account subject"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "invalid service account subject", nil)
 		return
 	}
 	nsFromToken, saFromToken := segs[0], segs[1]
 	if nsFromToken != project {
-		c.JSON(http.StatusForbidden, gin.H{"error": "namespace mismatch"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "namespace mismatch", nil)
 		return
 	}
 
@@ -995,11 +1471,7 @@ func MintSessionGitHubToken(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	obj, err := DynamicClient.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read session"})
+		respondK8sError(c, err, "session not found", "failed to read session")
 		return
 	}
 	meta, _ := obj.Object["metadata"].(map[string]interface{})
@@ -1011,7 +1483,7 @@ func MintSessionGitHubToken(c *gin.Context) {
 		}
 	}
 	if expectedSA == "" || expectedSA != saFromToken {
-		c.JSON(http.StatusForbidden, gin.H{"error": "service account not authorized for session"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "service account not authorized for session", nil)
 		return
 	}
 
@@ -1026,15 +1498,21 @@ func MintSessionGitHubToken(c *gin.Context) {
 		}
 	}
 	if userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "session missing user context"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "session missing user context", nil)
 		return
 	}
 
 	// Get GitHub token (GitHub App or PAT fallback via project runner secret)
-	tokenStr, err := GetGitHubToken(c.Request.Context(), K8sClient, DynamicClient, project, userID)
+	repo := strings.TrimSpace(c.Query("repo"))
+	if repo == "" {
+		repo = firstGitHubRepo(sessionRepoURLs(obj))
+	}
+	tokenStr, err := GetGitHubToken(c.Request.Context(), K8sClient, DynamicClient, project, userID, repo)
 	if err != nil {
 		log.Printf("Failed to get GitHub token for project %s: %v", project, err)
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to retrieve GitHub token"})
+		if !respondGitHubTokenError(c, err) {
+			respondUpstreamUnavailable(c, "Failed to retrieve GitHub token")
+		}
 		return
 	}
 	// Note: PATs don't have expiration, so we omit expiresAt for simplicity
@@ -1042,86 +1520,188 @@ func MintSessionGitHubToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"token": tokenStr})
 }
 
+// PatchSession applies a JSON merge patch to a session's spec, annotations and labels. Only the
+// requested changes are sent to the API server, so concurrent writes to other keys (such as the
+// operator's annotations) are kept; a null value removes the key. Annotations under the reserved
+// platform prefixes can only be changed by project admins. A spec change carries the
+// resourceVersion it was validated against, so it fails with 409 if the session changed meanwhile
+// (for example, left Pending and froze the field).
+// PATCH /api/projects/:projectName/agentic-sessions/:sessionName
 func PatchSession(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
-	_, k8sDyn := GetK8sClientsForRequest(c)
-	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+	reqK8s, k8sDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 
 	var patch map[string]interface{}
 	if err := c.ShouldBindJSON(&patch); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
 		return
 	}
 
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	// Get current resource
+	// The current resource is only read to validate the change, never written back
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
-	// Apply patch to metadata annotations
-	if metaPatch, ok := patch["metadata"].(map[string]interface{}); ok {
-		if annsPatch, ok := metaPatch["annotations"].(map[string]interface{}); ok {
-			metadata, found, err := unstructured.NestedMap(item.Object, "metadata")
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch session"})
-				return
+	mergePatch := map[string]interface{}{}
+	metaPatch := map[string]interface{}{}
+	touchesReserved := false
+	if reqMeta, ok := patch["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"annotations", "labels"} {
+			fieldPatch, ok := reqMeta[field].(map[string]interface{})
+			if !ok || len(fieldPatch) == 0 {
+				continue
 			}
-			if !found || metadata == nil {
-				metadata = map[string]interface{}{}
+			for k, v := range fieldPatch {
+				if field == "labels" && isSessionIdentityLabel(k) {
+					respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("metadata.labels.%s is managed by the backend", k), nil)
+					return
+				}
+				if field == "annotations" && isReservedAnnotation(k) {
+					touchesReserved = true
+				}
+				if _, ok := v.(string); !ok && v != nil {
+					respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("metadata.%s.%s must be a string", field, k), nil)
+					return
+				}
 			}
-			anns, found, err := unstructured.NestedMap(metadata, "annotations")
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch session"})
+			metaPatch[field] = fieldPatch
+		}
+	}
+
+	// Merge spec changes into a copy so frozen fields can be rejected before anything is applied
+	if specPatch, ok := patch["spec"].(map[string]interface{}); ok && len(specPatch) > 0 {
+		if _, ok := specPatch["promptRef"]; ok {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "spec.promptRef is managed by the backend", nil)
+			return
+		}
+		if prompt, ok := specPatch["initialPrompt"].(string); ok {
+			if len(prompt) > maxInlinePromptBytes() {
+				respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("spec.initialPrompt larger than %d bytes must be set with PUT", maxInlinePromptBytes()), nil)
 				return
 			}
-			if !found || anns == nil {
-				anns = map[string]interface{}{}
+			// An inline prompt replaces one stored in a ConfigMap
+			if _, found, _ := unstructured.NestedMap(item.Object, "spec", "promptRef"); found {
+				specPatch["promptRef"] = nil
+			}
+		}
+		spec, _, _ := unstructured.NestedMap(item.Object, "spec")
+		if spec == nil {
+			spec = map[string]interface{}{}
+		}
+		applyMergePatch(spec, specPatch)
+		if err := ValidateSpecMutation(item, spec); err != nil {
+			respondSpecMutationError(c, err)
+			return
+		}
+		if !requireAvailableModelChange(c, k8sDyn, project, item, spec) {
+			return
+		}
+		mergePatch["spec"] = specPatch
+		// Frozen fields were checked against item; the API server rejects the write if it is stale
+		metaPatch["resourceVersion"] = item.GetResourceVersion()
+		if _, ok := specPatch["llmSettings"]; ok {
+			merged := item.DeepCopy()
+			merged.Object["spec"] = spec
+			syncSessionModelLabel(merged)
+			labelsPatch, _ := metaPatch["labels"].(map[string]interface{})
+			if labelsPatch == nil {
+				labelsPatch = map[string]interface{}{}
 			}
-			for k, v := range annsPatch {
-				anns[k] = v
+			if model, ok := merged.GetLabels()[sessionModelLabel]; ok {
+				labelsPatch[sessionModelLabel] = model
+			} else {
+				labelsPatch[sessionModelLabel] = nil
 			}
-			_ = unstructured.SetNestedMap(metadata, anns, "annotations")
-			_ = unstructured.SetNestedMap(item.Object, metadata, "metadata")
+			metaPatch["labels"] = labelsPatch
 		}
 	}
 
-	// Update the resource
-	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	if touchesReserved && !requireProjectAdmin(c, reqK8s, project) {
+		return
+	}
+	if len(metaPatch) > 0 {
+		mergePatch["metadata"] = metaPatch
+	}
+	if len(mergePatch) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Session patched successfully", "annotations": item.GetAnnotations(), "labels": item.GetLabels()})
+		return
+	}
+
+	b, err := json.Marshal(mergePatch)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to patch session", nil)
+		return
+	}
+	updated, err := k8sDyn.Resource(gvr).Namespace(project).Patch(context.TODO(), sessionName, ktypes.MergePatchType, b, v1.PatchOptions{})
 	if err != nil {
 		log.Printf("Failed to patch agentic session %s: %v", sessionName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to patch session"})
+		respondK8sError(c, err, "Session not found", "Failed to patch session")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Session patched successfully", "annotations": updated.GetAnnotations()})
+	c.JSON(http.StatusOK, gin.H{"message": "Session patched successfully", "annotations": updated.GetAnnotations(), "labels": updated.GetLabels()})
+}
+
+// reservedAnnotationPrefixes are the annotation namespaces written by the platform itself
+var reservedAnnotationPrefixes = []string{"ambient-code.io/", "vteam.ambient-code/"}
+
+// isReservedAnnotation reports whether key is under one of reservedAnnotationPrefixes
+func isReservedAnnotation(key string) bool {
+	for _, prefix := range reservedAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMergePatch applies patch to dst with JSON merge patch (RFC 7386) semantics: objects are
+// merged recursively, null removes a key and any other value replaces it
+func applyMergePatch(dst, patch map[string]interface{}) {
+	for k, v := range patch {
+		switch val := v.(type) {
+		case nil:
+			delete(dst, k)
+		case map[string]interface{}:
+			cur, ok := dst[k].(map[string]interface{})
+			if !ok {
+				cur = map[string]interface{}{}
+			}
+			applyMergePatch(cur, val)
+			dst[k] = cur
+		default:
+			dst[k] = v
+		}
+	}
 }
 
+// UpdateSession replaces the editable parts of a session's spec and adds labels.
+// PUT /api/projects/:projectName/agentic-sessions/:sessionName
+// Every rejection (prompt size, frozen spec fields, the project's models, backend-managed labels)
+// happens before the first write, so a refused request leaves the prompt ConfigMap untouched.
 func UpdateSession(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 	var req types.UpdateAgenticSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Printf("Invalid request body for UpdateSession (project=%s session=%s): %v", project, sessionName, err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
 		return
 	}
 
@@ -1140,35 +1720,35 @@ func UpdateSession(c *gin.Context) {
 			continue
 		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 		return
 	}
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Session not found", nil)
 		return
 	}
 
-	// Prevent spec changes while session is running or being created
-	if status, ok := item.Object["status"].(map[string]interface{}); ok {
-		if phase, ok := status["phase"].(string); ok {
-			if strings.EqualFold(phase, "Running") || strings.EqualFold(phase, "Creating") {
-				c.JSON(http.StatusConflict, gin.H{
-					"error": "Cannot modify session specification while the session is running",
-					"phase": phase,
-				})
-				return
-			}
-		}
+	// Apply changes to a copy of the spec so they can be validated before persisting
+	spec, _, _ := unstructured.NestedMap(item.Object, "spec")
+	if spec == nil {
+		spec = map[string]interface{}{}
 	}
-
-	// Update spec
-	spec := item.Object["spec"].(map[string]interface{})
+	var storedPrompt string
 	if req.InitialPrompt != nil {
+		if len(*req.InitialPrompt) > maxStoredPromptBytes {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("initialPrompt must not exceed %d bytes", maxStoredPromptBytes), nil)
+			return
+		}
 		spec["initialPrompt"] = *req.InitialPrompt
+		delete(spec, "promptRef")
+		storedPrompt = externalizeInitialPrompt(spec, sessionName)
 	}
 	if req.DisplayName != nil {
 		spec["displayName"] = *req.DisplayName
 	}
+	if req.Interactive != nil {
+		spec["interactive"] = *req.Interactive
+	}
 
 	if req.LLMSettings != nil {
 		llmSettings := make(map[string]interface{})
@@ -1188,29 +1768,59 @@ func UpdateSession(c *gin.Context) {
 		spec["timeout"] = *req.Timeout
 	}
 
-	// Update the resource
-	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
-	if err != nil {
-		log.Printf("Failed to update agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update agentic session"})
+	// Prompt, repos and LLM settings are frozen once the session has started
+	if err := ValidateSpecMutation(item, spec); err != nil {
+		respondSpecMutationError(c, err)
+		return
+	}
+	if !requireAvailableModelChange(c, k8sDyn, project, item, spec) {
 		return
 	}
+	for k := range req.Labels {
+		if isSessionIdentityLabel(k) {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("labels.%s is managed by the backend", k), nil)
+			return
+		}
+	}
 
-	// Parse and return updated session
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
+	item.Object["spec"] = spec
+	if len(req.Labels) > 0 {
+		labels := item.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range req.Labels {
+			labels[k] = v
+		}
+		item.SetLabels(labels)
+	}
+	if req.LLMSettings != nil {
+		syncSessionModelLabel(item)
 	}
 
-	if spec, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(spec)
+	// The prompt is stored just before the session update and put back if that update fails
+	restorePrompt := func() {}
+	if storedPrompt != "" {
+		restorePrompt, err = replaceSessionPrompt(c.Request.Context(), item, storedPrompt)
+		if err != nil {
+			log.Printf("Failed to store prompt of agentic session %s/%s: %v", project, sessionName, err)
+			respondK8sError(c, err, "Project not found", "Failed to store session prompt")
+			return
+		}
 	}
 
-	if status, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(status)
+	// Update the resource
+	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	if err != nil {
+		restorePrompt()
+		log.Printf("Failed to update agentic session %s in project %s: %v", sessionName, project, err)
+		respondK8sError(c, err, "Session not found", "Failed to update agentic session")
+		return
 	}
 
+	// Parse and return updated session
+	session := sessionResponse(updated, false)
+
 	c.JSON(http.StatusOK, session)
 }
 
@@ -1221,7 +1831,7 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	sessionName := c.Param("sessionName")
 	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sClt == nil || k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -1240,11 +1850,11 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	res, err := k8sClt.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("RBAC check failed for update session display name in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 	if !res.Status.Allowed {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update session in this project"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to update session in this project", nil)
 		return
 	}
 
@@ -1252,13 +1862,13 @@ func UpdateSessionDisplayName(c *gin.Context) {
 		DisplayName string `json:"displayName" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
 	// Validate display name (length, sanitization)
 	if validationErr := ValidateDisplayName(req.DisplayName); validationErr != "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErr})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, validationErr, nil)
 		return
 	}
 
@@ -1267,12 +1877,8 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	// Retrieve current resource
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 		return
 	}
 
@@ -1280,7 +1886,7 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	spec, found, err := unstructured.NestedMap(item.Object, "spec")
 	if err != nil {
 		log.Printf("Failed to get spec from session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse session spec"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to parse session spec", nil)
 		return
 	}
 	if !found {
@@ -1291,7 +1897,7 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	// Set the updated spec back using unstructured helper
 	if err := unstructured.SetNestedMap(item.Object, spec, "spec"); err != nil {
 		log.Printf("Failed to set spec for session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session spec"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to update session spec", nil)
 		return
 	}
 
@@ -1299,24 +1905,12 @@ func UpdateSessionDisplayName(c *gin.Context) {
 	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update display name for agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update display name"})
+		respondK8sError(c, err, "Session not found", "Failed to update display name")
 		return
 	}
 
 	// Respond with updated session summary using safe type access
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-	}
-	if meta, found, _ := unstructured.NestedMap(updated.Object, "metadata"); found {
-		session.Metadata = meta
-	}
-	if s, found, _ := unstructured.NestedMap(updated.Object, "spec"); found {
-		session.Spec = parseSpec(s)
-	}
-	if st, found, _ := unstructured.NestedMap(updated.Object, "status"); found {
-		session.Status = parseStatus(st)
-	}
+	session := sessionResponse(updated, false)
 
 	c.JSON(http.StatusOK, session)
 }
@@ -1328,14 +1922,20 @@ func SelectWorkflow(c *gin.Context) {
 	sessionName := c.Param("sessionName")
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 
 	var req types.WorkflowSelection
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+	if !requireValidBranch(c, "branch", req.Branch) {
+		return
+	}
+	if !requireAllowedGitURLs(c, k8sDyn, project, req.GitURL) {
 		return
 	}
 
@@ -1344,17 +1944,13 @@ func SelectWorkflow(c *gin.Context) {
 	// Retrieve current resource
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 		return
 	}
 
 	if err := ensureRuntimeMutationAllowed(item); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		respondError(c, http.StatusConflict, ErrorKindConflict, err.Error(), nil)
 		return
 	}
 
@@ -1383,24 +1979,14 @@ func SelectWorkflow(c *gin.Context) {
 	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update workflow for agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update workflow"})
+		respondK8sError(c, err, "Session not found", "Failed to update workflow")
 		return
 	}
 
 	log.Printf("Workflow updated for session %s: %s@%s", sessionName, req.GitURL, workflowMap["branch"])
 
 	// Respond with updated session summary
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-	if s, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(s)
-	}
-	if st, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(st)
-	}
+	session := sessionResponse(updated, false)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Workflow updated successfully",
@@ -1415,7 +2001,7 @@ func AddRepo(c *gin.Context) {
 	sessionName := c.Param("sessionName")
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -1423,31 +2009,38 @@ func AddRepo(c *gin.Context) {
 	var req struct {
 		URL    string `json:"url" binding:"required"`
 		Branch string `json:"branch"`
+		Folder string `json:"folder"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", req.Branch) {
+		return
+	}
 	if req.Branch == "" {
 		req.Branch = "main"
 	}
+	if req.Folder != "" && !validRepoFolder(req.Folder) {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "folder must be a single directory name of letters, digits, '.', '_' or '-'", nil)
+		return
+	}
+	if !requireAllowedGitURLs(c, k8sDyn, project, req.URL) {
+		return
+	}
 
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
 	if err := ensureRuntimeMutationAllowed(item); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		respondError(c, http.StatusConflict, ErrorKindConflict, err.Error(), nil)
 		return
 	}
 
@@ -1462,10 +2055,39 @@ func AddRepo(c *gin.Context) {
 		repos = []interface{}{}
 	}
 
+	// The same repository in another form (ssh instead of https, with .git) is still a duplicate
+	for _, r := range repos {
+		if rm, ok := r.(map[string]interface{}); ok && sameRepoURL(repoEntryURL(rm), req.URL) {
+			respondError(c, http.StatusConflict, ErrorKindConflict, "Repository is already in this session",
+				gin.H{"url": repoEntryURL(rm), "folder": sessionRepoFolder(rm)})
+			return
+		}
+	}
+
 	newRepo := map[string]interface{}{
 		"url":    req.URL,
 		"branch": req.Branch,
 	}
+	// Keep clear of the folders existing repos clone into (stored or, for older sessions, derived)
+	used := make(map[string]bool, len(repos))
+	for _, r := range repos {
+		if rm, ok := r.(map[string]interface{}); ok {
+			if folder := sessionRepoFolder(rm); folder != "" {
+				used[folder] = true
+			}
+		}
+	}
+	switch {
+	case req.Folder != "" && used[req.Folder]:
+		respondError(c, http.StatusConflict, ErrorKindConflict, fmt.Sprintf("Folder %q is used by another repo in this session", req.Folder), nil)
+		return
+	case req.Folder != "":
+		newRepo["folder"] = req.Folder
+	default:
+		if folder := uniqueRepoFolder(req.URL, used); folder != "" {
+			newRepo["folder"] = folder
+		}
+	}
 	repos = append(repos, newRepo)
 	spec["repos"] = repos
 
@@ -1473,21 +2095,11 @@ func AddRepo(c *gin.Context) {
 	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		respondK8sError(c, err, "Session not found", "Failed to update session")
 		return
 	}
 
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-	if specMap, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(specMap)
-	}
-	if statusMap, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(statusMap)
-	}
+	session := sessionResponse(updated, false)
 
 	log.Printf("Added repository %s to session %s in project %s", req.URL, sessionName, project)
 	c.JSON(http.StatusOK, gin.H{"message": "Repository added", "session": session})
@@ -1501,31 +2113,27 @@ func RemoveRepo(c *gin.Context) {
 	repoName := c.Param("repoName")
 	_, reqDyn := GetK8sClientsForRequest(c)
 	if reqDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	item, err := reqDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
 	if err := ensureRuntimeMutationAllowed(item); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		respondError(c, http.StatusConflict, ErrorKindConflict, err.Error(), nil)
 		return
 	}
 
 	// Update spec.repos
 	spec, ok := item.Object["spec"].(map[string]interface{})
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Session has no spec"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Session has no spec", nil)
 		return
 	}
 	repos, _ := spec["repos"].([]interface{})
@@ -1534,8 +2142,7 @@ func RemoveRepo(c *gin.Context) {
 	found := false
 	for _, r := range repos {
 		rm, _ := r.(map[string]interface{})
-		url, _ := rm["url"].(string)
-		if DeriveRepoFolderFromURL(url) != repoName {
+		if sessionRepoFolder(rm) != repoName {
 			filteredRepos = append(filteredRepos, r)
 		} else {
 			found = true
@@ -1543,7 +2150,7 @@ func RemoveRepo(c *gin.Context) {
 	}
 
 	if !found {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Repository not found in session"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "Repository not found in session", nil)
 		return
 	}
 
@@ -1553,27 +2160,18 @@ func RemoveRepo(c *gin.Context) {
 	updated, err := reqDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		respondK8sError(c, err, "Session not found", "Failed to update session")
 		return
 	}
 
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-	if specMap, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(specMap)
-	}
-	if statusMap, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(statusMap)
-	}
+	session := sessionResponse(updated, false)
 
 	log.Printf("Removed repository %s from session %s in project %s", repoName, sessionName, project)
 	c.JSON(http.StatusOK, gin.H{"message": "Repository removed", "session": session})
 }
 
-// GetWorkflowMetadata retrieves commands and agents metadata from the active workflow
+// GetWorkflowMetadata retrieves commands and agents metadata from the active workflow and the
+// session's repos (see mergeWorkflowMetadata)
 // GET /api/projects/:projectName/agentic-sessions/:sessionName/workflow/metadata
 func GetWorkflowMetadata(c *gin.Context) {
 	project := c.GetString("project")
@@ -1584,22 +2182,16 @@ func GetWorkflowMetadata(c *gin.Context) {
 
 	if project == "" {
 		log.Printf("GetWorkflowMetadata: project is empty, session=%s", sessionName)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project namespace required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project namespace required", nil)
 		return
 	}
 
-	// Get authorization token
-	token := c.GetHeader("Authorization")
-	if strings.TrimSpace(token) == "" {
-		token = c.GetHeader("X-Forwarded-Access-Token")
-	}
-
 	// Try temp service first (for completed sessions), then regular service
 	serviceName := fmt.Sprintf("temp-content-%s", sessionName)
 	// Use the dependency-injected client selection function
 	reqK8s, _ := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -1611,22 +2203,27 @@ func GetWorkflowMetadata(c *gin.Context) {
 	}
 
 	// Build URL to content service
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	u := fmt.Sprintf("%s/content/workflow-metadata?session=%s", endpoint, sessionName)
-
-	log.Printf("GetWorkflowMetadata: project=%s session=%s endpoint=%s", project, sessionName, endpoint)
+	content, err := resolveContentService(c.Request.Context(), project, sessionName, serviceName)
+	if err != nil {
+		log.Printf("GetWorkflowMetadata: failed to resolve %s for %s/%s: %v", serviceName, project, sessionName, err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
+	log.Printf("GetWorkflowMetadata: project=%s session=%s endpoint=%s", project, sessionName, content.BaseURL)
 
 	// Create and send request to content pod
-	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, u, nil)
-	if strings.TrimSpace(token) != "" {
-		req.Header.Set("Authorization", token)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/workflow-metadata", url.Values{"session": {sessionName}}, nil)
+	if err != nil {
+		log.Printf("GetWorkflowMetadata: failed to create HTTP request: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return
 	}
-	client := &http.Client{Timeout: 4 * time.Second}
-	resp, err := client.Do(req)
+	forwardCallerToken(c, req.Header)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
 		log.Printf("GetWorkflowMetadata: content service request failed: %v", err)
-		// Return empty metadata on error
-		c.JSON(http.StatusOK, gin.H{"commands": []interface{}{}, "agents": []interface{}{}})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -1634,7 +2231,7 @@ func GetWorkflowMetadata(c *gin.Context) {
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GetWorkflowMetadata: failed to read response body: %v", err)
-		c.JSON(http.StatusOK, gin.H{"commands": []interface{}{}, "agents": []interface{}{}})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
 
@@ -1643,13 +2240,23 @@ func GetWorkflowMetadata(c *gin.Context) {
 		log.Printf("GetWorkflowMetadata: content service returned error status %d: %s", resp.StatusCode, string(b))
 	}
 
-	c.Data(resp.StatusCode, "application/json", b)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, b, false)
+		return
+	}
+	merged, err := mergeWorkflowMetadata(b)
+	if err != nil {
+		log.Printf("GetWorkflowMetadata: invalid content service response: %v", err)
+		respondUpstreamUnavailable(c, "Invalid response from content service")
+		return
+	}
+	c.Data(resp.StatusCode, "application/json", merged)
 }
 
 // fetchGitHubFileContent fetches a file from GitHub via API
+// api is the REST API base (see git.ResolveGitHubAPIBase)
 // token is optional - works for public repos without authentication (but has rate limits)
-func fetchGitHubFileContent(ctx context.Context, owner, repo, ref, path, token string) ([]byte, error) {
-	api := "https://api.github.com"
+func fetchGitHubFileContent(ctx context.Context, api, owner, repo, ref, path, token string) ([]byte, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repo, path, ref)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -1664,8 +2271,7 @@ func fetchGitHubFileContent(ctx context.Context, owner, repo, ref, path, token s
 	req.Header.Set("Accept", "application/vnd.github.raw")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return nil, err
 	}
@@ -1684,9 +2290,9 @@ func fetchGitHubFileContent(ctx context.Context, owner, repo, ref, path, token s
 }
 
 // fetchGitHubDirectoryListing lists files/folders in a GitHub directory
+// api is the REST API base (see git.ResolveGitHubAPIBase)
 // token is optional - works for public repos without authentication (but has rate limits)
-func fetchGitHubDirectoryListing(ctx context.Context, owner, repo, ref, path, token string) ([]map[string]interface{}, error) {
-	api := "https://api.github.com"
+func fetchGitHubDirectoryListing(ctx context.Context, api, owner, repo, ref, path, token string) ([]map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", api, owner, repo, path, ref)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -1701,8 +2307,7 @@ func fetchGitHubDirectoryListing(ctx context.Context, owner, repo, ref, path, to
 	req.Header.Set("Accept", "application/vnd.github+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.API)
 	if err != nil {
 		return nil, err
 	}
@@ -1753,8 +2358,18 @@ func ListOOTBWorkflows(c *gin.Context) {
 		ootbWorkflowsPath = "workflows"
 	}
 
+	// GitHub Enterprise Server: the project's spec.githubApiBase, then GITHUB_API_BASE, then the repo host
+	project := c.Query("project") // Optional query parameter
+	projectAPIBase := ""
+	if project != "" {
+		if sessK8s, sessDyn := GetK8sClientsForRequest(c); sessDyn != nil {
+			projectAPIBase = projectSettingsGitHubAPIBase(c, sessK8s, sessDyn, project)
+		}
+	}
+	apiBase := git.ResolveGitHubAPIBase(ootbRepo, projectAPIBase)
+
 	// Build cache key from repo configuration
-	cacheKey := fmt.Sprintf("%s|%s|%s", ootbRepo, ootbBranch, ootbWorkflowsPath)
+	cacheKey := fmt.Sprintf("%s|%s|%s|%s", apiBase, ootbRepo, ootbBranch, ootbWorkflowsPath)
 
 	// Check cache first (read lock)
 	ootbCache.mu.RLock()
@@ -1771,13 +2386,12 @@ func ListOOTBWorkflows(c *gin.Context) {
 	// Try to get user's GitHub token (best effort - not required)
 	// This gives better rate limits (5000/hr vs 60/hr) and supports private repos
 	token := ""
-	project := c.Query("project") // Optional query parameter
 	if project != "" {
 		usrID, _ := c.Get("userID")
 		k8sClt, sessDyn := GetK8sClientsForRequest(c)
 		if k8sClt != nil && sessDyn != nil {
 			if userIDStr, ok := usrID.(string); ok && userIDStr != "" {
-				if githubToken, err := GetGitHubToken(c.Request.Context(), k8sClt, sessDyn, project, userIDStr); err == nil {
+				if githubToken, err := GetGitHubToken(c.Request.Context(), k8sClt, sessDyn, project, userIDStr, ootbRepo); err == nil {
 					token = githubToken
 					log.Printf("ListOOTBWorkflows: using user's GitHub token for project %s (better rate limits)", project)
 				} else {
@@ -1794,12 +2408,12 @@ func ListOOTBWorkflows(c *gin.Context) {
 	owner, repoName, err := git.ParseGitHubURL(ootbRepo)
 	if err != nil {
 		log.Printf("ListOOTBWorkflows: invalid repo URL: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid OOTB repo URL"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Invalid OOTB repo URL", nil)
 		return
 	}
 
 	// List workflow directories
-	entries, err := fetchGitHubDirectoryListing(c.Request.Context(), owner, repoName, ootbBranch, ootbWorkflowsPath, token)
+	entries, err := fetchGitHubDirectoryListing(c.Request.Context(), apiBase, owner, repoName, ootbBranch, ootbWorkflowsPath, token)
 	if err != nil {
 		log.Printf("ListOOTBWorkflows: failed to list workflows directory: %v", err)
 		// On error, try to return stale cache if available
@@ -1812,7 +2426,7 @@ func ListOOTBWorkflows(c *gin.Context) {
 			return
 		}
 		ootbCache.mu.RUnlock()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to discover OOTB workflows"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to discover OOTB workflows", nil)
 		return
 	}
 
@@ -1828,7 +2442,7 @@ func ListOOTBWorkflows(c *gin.Context) {
 
 		// Try to fetch ambient.json from this workflow directory
 		ambientPath := fmt.Sprintf("%s/%s/.ambient/ambient.json", ootbWorkflowsPath, entryName)
-		ambientData, err := fetchGitHubFileContent(c.Request.Context(), owner, repoName, ootbBranch, ambientPath, token)
+		ambientData, err := fetchGitHubFileContent(c.Request.Context(), apiBase, owner, repoName, ootbBranch, ambientPath, token)
 
 		var ambientConfig struct {
 			Name        string `json:"name"`
@@ -1875,7 +2489,7 @@ func DeleteSession(c *gin.Context) {
 	sessionName := c.Param("sessionName")
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -1883,30 +2497,57 @@ func DeleteSession(c *gin.Context) {
 
 	err := k8sDyn.Resource(gvr).Namespace(project).Delete(context.TODO(), sessionName, v1.DeleteOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to delete agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to delete agentic session")
+		return
+	}
+
+	// The operator's finalizer keeps the session until its runner has shut down; report that the
+	// deletion is still in progress so clients can poll GET until the session is gone
+	if obj, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{}); err == nil && obj.GetDeletionTimestamp() != nil {
+		c.JSON(http.StatusAccepted, sessionResponse(obj, false))
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+// uniqueSessionName returns name, or name with a "-duplicate" (and numeric) suffix when a
+// session with that name already exists in namespace. conflicted reports whether it was renamed.
+func uniqueSessionName(ctx context.Context, k8sDyn dynamic.Interface, namespace, name string) (finalName string, conflicted bool) {
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	finalName = name
+	for i := 0; i < 50; i++ {
+		_, getErr := k8sDyn.Resource(gvr).Namespace(namespace).Get(ctx, finalName, v1.GetOptions{})
+		if errors.IsNotFound(getErr) {
+			break
+		}
+		if getErr != nil {
+			// On unexpected error, still attempt to proceed with a duplicate suffix to reduce collision chance
+			log.Printf("uniqueSessionName: name check encountered error for %s/%s: %v", namespace, finalName, getErr)
+		}
+		conflicted = true
+		if i == 0 {
+			finalName = fmt.Sprintf("%s-duplicate", name)
+		} else {
+			finalName = fmt.Sprintf("%s-duplicate-%d", name, i+1)
+		}
+	}
+	return finalName, conflicted
+}
+
 func CloneSession(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 	var req types.CloneSessionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
 		return
 	}
 
@@ -1915,12 +2556,15 @@ func CloneSession(c *gin.Context) {
 	// Get source session
 	sourceItem, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Source session not found"})
-			return
-		}
 		log.Printf("Failed to get source agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get source agentic session"})
+		respondK8sError(c, err, "Source session not found", "Failed to get source agentic session")
+		return
+	}
+
+	// The clone gets its own copy of an externally stored prompt
+	if err := inlineSessionPrompt(c, sourceItem); err != nil {
+		log.Printf("Failed to resolve prompt of agentic session %s/%s for clone: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read source session prompt", nil)
 		return
 	}
 
@@ -1928,11 +2572,7 @@ func CloneSession(c *gin.Context) {
 	projGvr := GetOpenShiftProjectResource()
 	projObj, err := k8sDyn.Resource(projGvr).Get(context.TODO(), req.TargetProject, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Target project not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate target project"})
+		respondK8sError(c, err, "Target project not found", "Failed to validate target project")
 		return
 	}
 
@@ -1945,33 +2585,16 @@ func CloneSession(c *gin.Context) {
 		}
 	}
 	if !isAmbient {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Target project is not managed by Ambient"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Target project is not managed by Ambient", nil)
 		return
 	}
 
-	// Ensure unique target session name in target namespace; if exists, append "-duplicate" (and numeric suffix)
+	// Ensure unique target session name in target namespace
 	newName := strings.TrimSpace(req.NewSessionName)
 	if newName == "" {
 		newName = sessionName
 	}
-	finalName := newName
-	conflicted := false
-	for i := 0; i < 50; i++ {
-		_, getErr := k8sDyn.Resource(gvr).Namespace(req.TargetProject).Get(context.TODO(), finalName, v1.GetOptions{})
-		if errors.IsNotFound(getErr) {
-			break
-		}
-		if getErr != nil && !errors.IsNotFound(getErr) {
-			// On unexpected error, still attempt to proceed with a duplicate suffix to reduce collision chance
-			log.Printf("cloneSession: name check encountered error for %s/%s: %v", req.TargetProject, finalName, getErr)
-		}
-		conflicted = true
-		if i == 0 {
-			finalName = fmt.Sprintf("%s-duplicate", newName)
-		} else {
-			finalName = fmt.Sprintf("%s-duplicate-%d", newName, i+1)
-		}
-	}
+	finalName, conflicted := uniqueSessionName(context.TODO(), k8sDyn, req.TargetProject, newName)
 
 	// Create cloned session
 	clonedSession := map[string]interface{}{
@@ -1997,30 +2620,76 @@ func CloneSession(c *gin.Context) {
 			clonedSpec["displayName"] = fmt.Sprintf("%s (Duplicate)", finalName)
 		}
 	}
+	// A runner image override must also be allowed by the target project
+	if runnerImage, _ := clonedSpec["runnerImage"].(string); !requireAllowedRunnerImage(c, k8sDyn, req.TargetProject, runnerImage) {
+		return
+	}
+	// And its repositories by the target project's git hosts
+	if !requireAllowedGitURLs(c, k8sDyn, req.TargetProject, sessionSpecGitURLs(clonedSpec)...) {
+		return
+	}
+	// So must the cost limit, which falls back to the target project's default when unset
+	var sourceCostLimit *float64
+	if limit, ok := numberValue(clonedSpec["costLimitUSD"]); ok {
+		sourceCostLimit = &limit
+	}
+	costLimit, ok := resolveSessionCostLimit(c, k8sDyn, req.TargetProject, sourceCostLimit)
+	if !ok {
+		return
+	}
+	if costLimit != nil {
+		clonedSpec["costLimitUSD"] = *costLimit
+	}
+	// And the workspace size, which must fit under the target project's maxWorkspaceSize
+	if size, _ := clonedSpec["workspaceSize"].(string); size != "" {
+		workspaceSize, ok := resolveWorkspaceSize(c, k8sDyn, req.TargetProject, size)
+		if !ok {
+			return
+		}
+		clonedSpec["workspaceSize"] = workspaceSize
+	}
+	// The model must be one the target project offers, defaulting like CreateSession when unset
+	sourceModel, _, _ := unstructured.NestedString(clonedSpec, "llmSettings", "model")
+	model, ok := resolveSessionModel(c, k8sDyn, req.TargetProject, sourceModel)
+	if !ok {
+		return
+	}
+	if err := unstructured.SetNestedField(clonedSpec, model, "llmSettings", "model"); err != nil {
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to set session model", nil)
+		return
+	}
+	// Chargeback labels come from the target project
+	chargeback, ok := resolveChargebackLabels(c, k8sDyn, req.TargetProject)
+	if !ok {
+		return
+	}
+	setChargebackLabels(clonedSession["metadata"].(map[string]interface{}), chargeback)
+	// The clone takes a slot in the target project like a new session: 429, or Queued with req.Queue
+	queued := false
+	if !checkConcurrentSessionLimit(c, k8sDyn, req.TargetProject, "", req.Queue) {
+		if !req.Queue {
+			return
+		}
+		queued = true
+	}
 
 	obj := &unstructured.Unstructured{Object: clonedSession}
 
-	created, err := k8sDyn.Resource(gvr).Namespace(req.TargetProject).Create(context.TODO(), obj, v1.CreateOptions{})
+	created, err := createSessionWithPrompt(context.TODO(), k8sDyn, obj, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("Failed to create cloned agentic session in project %s: %v", req.TargetProject, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cloned agentic session"})
+		respondK8sError(c, err, "Target project not found", "Failed to create cloned agentic session")
 		return
 	}
-
-	// Parse and return created session
-	session := types.AgenticSession{
-		APIVersion: created.GetAPIVersion(),
-		Kind:       created.GetKind(),
-		Metadata:   created.Object["metadata"].(map[string]interface{}),
-	}
-
-	if spec, ok := created.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(spec)
+	if queued {
+		queueNewSession(c.Request.Context(), req.TargetProject, finalName)
+		if current, err := k8sDyn.Resource(gvr).Namespace(req.TargetProject).Get(context.TODO(), finalName, v1.GetOptions{}); err == nil {
+			created = current
+		}
 	}
 
-	if status, ok := created.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(status)
-	}
+	// Parse and return created session
+	session := sessionResponse(created, false)
 
 	c.JSON(http.StatusCreated, session)
 }
@@ -2032,7 +2701,7 @@ func StartSession(c *gin.Context) {
 
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -2040,30 +2709,53 @@ func StartSession(c *gin.Context) {
 	// Get current resource
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 		return
 	}
 
+	var req struct {
+		Queue bool `json:"queue"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid request body", nil)
+			return
+		}
+	}
+
 	// Check if this is a continuation (session is in a terminal phase)
 	isActualContinuation := false
 	if currentStatus, ok := item.Object["status"].(map[string]interface{}); ok {
 		if phase, ok := currentStatus["phase"].(string); ok {
-			terminalPhases := []string{"Completed", "Failed", "Stopped", "Error"}
-			for _, terminalPhase := range terminalPhases {
-				if phase == terminalPhase {
-					isActualContinuation = true
-					log.Printf("StartSession: Detected continuation - session is in terminal phase: %s", phase)
-					break
-				}
+			// Queued sessions are already waiting for capacity; the operator starts them in priority order
+			if phase == "Queued" {
+				respondError(c, http.StatusConflict, ErrorKindConflict, "Session is queued waiting for project capacity", gin.H{"phase": phase})
+				return
+			}
+			if isTerminalSessionPhase(phase) {
+				isActualContinuation = true
+				log.Printf("StartSession: Detected continuation - session is in terminal phase: %s", phase)
+			}
+		}
+	}
+
+	// A session that is not already holding a slot needs a free one; with req.Queue the start goes
+	// ahead and the operator queues it until a slot frees
+	if phase, _, _ := unstructured.NestedString(item.Object, "status", "phase"); !holdsSessionSlot(phase) {
+		if !checkConcurrentSessionLimit(c, k8sDyn, project, sessionName, req.Queue) {
+			if !req.Queue {
+				return
 			}
+			log.Printf("StartSession: project %s is at its concurrent session limit, %s will be queued", project, sessionName)
 		}
 	}
 
+	// A continuation's runner mounts the workspace a temp content pod may still hold for browsing
+	if isActualContinuation && !requireWorkspaceReleased(c, project, sessionName) {
+		return
+	}
+
 	// Set annotations to signal desired state to operator
 	annotations := item.GetAnnotations()
 	if annotations == nil {
@@ -2078,6 +2770,9 @@ func StartSession(c *gin.Context) {
 	if isActualContinuation {
 		annotations["vteam.ambient-code/parent-session-id"] = sessionName
 		log.Printf("StartSession: Continuation detected - set parent-session-id=%s for PVC reuse", sessionName)
+		// The temp content pod was released above; keep the operator from recreating it
+		delete(annotations, "ambient-code.io/temp-content-requested")
+		delete(annotations, "ambient-code.io/temp-content-last-accessed")
 	}
 
 	item.SetAnnotations(annotations)
@@ -2094,30 +2789,17 @@ func StartSession(c *gin.Context) {
 	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
 	if err != nil {
 		log.Printf("Failed to update agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		respondK8sError(c, err, "Session not found", "Failed to update session")
 		return
 	}
 
 	log.Printf("StartSession: Set desired-phase=Running annotation (operator will reconcile)")
 
 	// Parse and return updated session
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-
-	if spec, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(spec)
-
-		// NOTE: INITIAL_PROMPT auto-execution handled by runner on startup
-		// Runner POSTs to /agui/run when ready, events flow through backend
-		// This works for both UI and headless/API usage
-	}
-
-	if status, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(status)
-	}
+	// NOTE: INITIAL_PROMPT auto-execution handled by runner on startup
+	// Runner POSTs to /agui/run when ready, events flow through backend
+	// This works for both UI and headless/API usage
+	session := sessionResponse(updated, false)
 
 	c.JSON(http.StatusAccepted, session)
 }
@@ -2159,47 +2841,52 @@ func ensureRuntimeMutationAllowed(item *unstructured.Unstructured) error {
 	return nil
 }
 
-func StopSession(c *gin.Context) {
-	project := c.GetString("project")
-	sessionName := c.Param("sessionName")
-	gvr := GetAgenticSessionV1Alpha1Resource()
+// requestSessionStop sets the annotations that ask the operator to stop item, which deletes its
+// Job and moves it through Stopping to Stopped. Sessions are made interactive so they can be
+// restarted later. The caller writes item back with Update.
+func requestSessionStop(item *unstructured.Unstructured) {
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations["ambient-code.io/desired-phase"] = "Stopped"
+	annotations["ambient-code.io/stop-requested-at"] = time.Now().Format(time.RFC3339)
+	item.SetAnnotations(annotations)
+
+	if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
+		if interactive, ok := spec["interactive"].(bool); !ok || !interactive {
+			spec["interactive"] = true
+			log.Printf("requestSessionStop: Converting headless session %s to interactive for future restart capability", item.GetName())
+		}
+	}
+}
+
+// stopRequested reports whether a stop of item is already waiting for the operator
+func stopRequested(item *unstructured.Unstructured) bool {
+	return item.GetAnnotations()["ambient-code.io/desired-phase"] == "Stopped"
+}
+
+func StopSession(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+	gvr := GetAgenticSessionV1Alpha1Resource()
 
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("Failed to get agentic session %s in project %s: %v", sessionName, project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get agentic session"})
+		respondK8sError(c, err, "Session not found", "Failed to get agentic session")
 		return
 	}
 
-	// Set annotations to signal desired state to operator
-	annotations := item.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
-	}
-
-	// Signal stop request to operator
-	annotations["ambient-code.io/desired-phase"] = "Stopped"
-	annotations["ambient-code.io/stop-requested-at"] = time.Now().Format(time.RFC3339)
-	item.SetAnnotations(annotations)
-
-	// Force interactive mode so session can be restarted later
-	if spec, ok := item.Object["spec"].(map[string]interface{}); ok {
-		if interactive, ok := spec["interactive"].(bool); !ok || !interactive {
-			spec["interactive"] = true
-			log.Printf("StopSession: Converting headless session to interactive for future restart capability")
-		}
-	}
+	// Signal the stop request to the operator
+	requestSessionStop(item)
 
 	// Update spec and annotations (operator will observe and handle job cleanup)
 	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
@@ -2209,48 +2896,35 @@ func StopSession(c *gin.Context) {
 			return
 		}
 		log.Printf("Failed to update agentic session %s: %v", sessionName, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		respondK8sError(c, err, "Session not found", "Failed to update session")
 		return
 	}
 
 	log.Printf("StopSession: Set desired-phase=Stopped annotation (operator will reconcile)")
 
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-	if specMap, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(specMap)
-	}
-	if statusMap, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(statusMap)
-	}
+	session := sessionResponse(updated, false)
 
 	c.JSON(http.StatusAccepted, session)
 }
 
-// EnableWorkspaceAccess requests a temporary content pod for workspace access on stopped sessions
+// EnableWorkspaceAccess requests a temporary content pod for workspace access on stopped sessions.
+// Callers without update rights on agenticsessions get a read-only pod (see requestTempContentPod).
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/workspace/enable
 func EnableWorkspaceAccess(c *gin.Context) {
 	project := c.GetString("project")
 	sessionName := c.Param("sessionName")
 	gvr := GetAgenticSessionV1Alpha1Resource()
 
-	_, k8sDyn := GetK8sClientsForRequest(c)
-	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
@@ -2258,39 +2932,29 @@ func EnableWorkspaceAccess(c *gin.Context) {
 	status, _ := item.Object["status"].(map[string]interface{})
 	phase, _ := status["phase"].(string)
 	if phase != "Stopped" && phase != "Completed" && phase != "Failed" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Workspace access only available for stopped sessions"})
+		respondError(c, http.StatusConflict, ErrorKindConflict, "Workspace access only available for stopped sessions", nil)
 		return
 	}
-
-	// Set annotation to request temp pod
-	annotations := item.GetAnnotations()
-	if annotations == nil {
-		annotations = make(map[string]string)
+	// A runner pod that is still shutting down keeps the workspace PVC attached
+	if !requireWorkspaceClaimFree(c, k8sClt, project, sessionName) {
+		return
 	}
-	now := time.Now().UTC().Format(time.RFC3339)
-	annotations["ambient-code.io/temp-content-requested"] = "true"
-	annotations["ambient-code.io/temp-content-last-accessed"] = now
-	item.SetAnnotations(annotations)
 
-	// Update CR
-	updated, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{})
+	// Viewers get a read-only pod; editors a writable one
+	canEdit, err := canUpdateSessions(c.Request.Context(), k8sClt, project)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable workspace access"})
+		log.Printf("EnableWorkspaceAccess: access review failed for %s/%s: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
-
-	session := types.AgenticSession{
-		APIVersion: updated.GetAPIVersion(),
-		Kind:       updated.GetKind(),
-		Metadata:   updated.Object["metadata"].(map[string]interface{}),
-	}
-	if spec, ok := updated.Object["spec"].(map[string]interface{}); ok {
-		session.Spec = parseSpec(spec)
-	}
-	if status, ok := updated.Object["status"].(map[string]interface{}); ok {
-		session.Status = parseStatus(status)
+	updated, err := requestTempContentPod(c.Request.Context(), k8sDyn, project, item, canEdit, true)
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to enable workspace access")
+		return
 	}
 
+	session := sessionResponse(updated, false)
+
 	log.Printf("EnableWorkspaceAccess: Set temp-content-requested annotation for %s", sessionName)
 	c.JSON(http.StatusAccepted, session)
 }
@@ -2304,18 +2968,14 @@ func TouchWorkspaceAccess(c *gin.Context) {
 
 	_, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 
 	item, err := k8sDyn.Resource(gvr).Namespace(project).Get(context.TODO(), sessionName, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
@@ -2327,7 +2987,7 @@ func TouchWorkspaceAccess(c *gin.Context) {
 	item.SetAnnotations(annotations)
 
 	if _, err := k8sDyn.Resource(gvr).Namespace(project).Update(context.TODO(), item, v1.UpdateOptions{}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update timestamp"})
+		respondK8sError(c, err, "Session not found", "Failed to update timestamp")
 		return
 	}
 
@@ -2347,12 +3007,12 @@ func GetSessionK8sResources(c *gin.Context) {
 
 	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -2361,160 +3021,55 @@ func GetSessionK8sResources(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	session, err := k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+		respondError(c, http.StatusNotFound, ErrorKindNotFound, "session not found", nil)
 		return
 	}
 
-	status, _ := session.Object["status"].(map[string]interface{})
-	jobName, _ := status["jobName"].(string)
-	if jobName == "" {
-		jobName = fmt.Sprintf("%s-job", sessionName)
-	}
-
-	result := map[string]interface{}{}
-
-	// Get Job status
-	job, err := k8sClt.BatchV1().Jobs(project).Get(c.Request.Context(), jobName, v1.GetOptions{})
-	jobExists := err == nil
-
-	if jobExists {
-		result["jobName"] = jobName
-		jobStatus := "Unknown"
-		if job.Status.Active > 0 {
-			jobStatus = "Active"
-		} else if job.Status.Succeeded > 0 {
-			jobStatus = "Succeeded"
-		} else if job.Status.Failed > 0 {
-			jobStatus = "Failed"
-		}
-		result["jobStatus"] = jobStatus
-		result["jobConditions"] = job.Status.Conditions
-	} else if errors.IsNotFound(err) {
-		// Job not found - don't return job info at all
-		log.Printf("GetSessionK8sResources: Job %s not found, omitting from response", jobName)
-		// Don't include jobName or jobStatus in result
-	} else {
-		// Other error - still show job name but with error status
-		result["jobName"] = jobName
-		result["jobStatus"] = "Error"
-		log.Printf("GetSessionK8sResources: Error getting job %s: %v", jobName, err)
-	}
+	jobName := sessionJobName(session)
+	job, jobErr := k8sClt.BatchV1().Jobs(project).Get(c.Request.Context(), jobName, v1.GetOptions{})
 
 	// Get Pods for this job (only if job exists)
-	podInfos := []map[string]interface{}{}
-	if jobExists {
+	var jobPods []corev1.Pod
+	if jobErr == nil {
 		pods, err := k8sClt.CoreV1().Pods(project).List(c.Request.Context(), v1.ListOptions{
 			LabelSelector: fmt.Sprintf("job-name=%s", jobName),
 		})
 		if err == nil {
-			for _, pod := range pods.Items {
-				// Check if pod is terminating (has DeletionTimestamp)
-				podPhase := string(pod.Status.Phase)
-				if pod.DeletionTimestamp != nil {
-					podPhase = "Terminating"
-				}
-
-				containerInfos := []map[string]interface{}{}
-				for _, cs := range pod.Status.ContainerStatuses {
-					state := "Unknown"
-					var exitCode *int32
-					var reason string
-					if cs.State.Running != nil {
-						state = "Running"
-						// If pod is terminating but container still shows running, mark it as terminating
-						if pod.DeletionTimestamp != nil {
-							state = "Terminating"
-						}
-					} else if cs.State.Terminated != nil {
-						state = "Terminated"
-						exitCode = &cs.State.Terminated.ExitCode
-						reason = cs.State.Terminated.Reason
-					} else if cs.State.Waiting != nil {
-						state = "Waiting"
-						reason = cs.State.Waiting.Reason
-					}
-					containerInfos = append(containerInfos, map[string]interface{}{
-						"name":     cs.Name,
-						"state":    state,
-						"exitCode": exitCode,
-						"reason":   reason,
-					})
-				}
-				podInfos = append(podInfos, map[string]interface{}{
-					"name":       pod.Name,
-					"phase":      podPhase,
-					"containers": containerInfos,
-				})
-			}
+			jobPods = pods.Items
 		}
 	}
 
 	// Check for temp-content pod
-	tempPodName := fmt.Sprintf("temp-content-%s", sessionName)
-	tempPod, err := k8sClt.CoreV1().Pods(project).Get(c.Request.Context(), tempPodName, v1.GetOptions{})
-	if err == nil {
-		tempPodPhase := string(tempPod.Status.Phase)
-		if tempPod.DeletionTimestamp != nil {
-			tempPodPhase = "Terminating"
-		}
-
-		containerInfos := []map[string]interface{}{}
-		for _, cs := range tempPod.Status.ContainerStatuses {
-			state := "Unknown"
-			var exitCode *int32
-			var reason string
-			if cs.State.Running != nil {
-				state = "Running"
-				// If pod is terminating but container still shows running, mark as terminating
-				if tempPod.DeletionTimestamp != nil {
-					state = "Terminating"
-				}
-			} else if cs.State.Terminated != nil {
-				state = "Terminated"
-				exitCode = &cs.State.Terminated.ExitCode
-				reason = cs.State.Terminated.Reason
-			} else if cs.State.Waiting != nil {
-				state = "Waiting"
-				reason = cs.State.Waiting.Reason
-			}
-			containerInfos = append(containerInfos, map[string]interface{}{
-				"name":     cs.Name,
-				"state":    state,
-				"exitCode": exitCode,
-				"reason":   reason,
-			})
-		}
-		podInfos = append(podInfos, map[string]interface{}{
-			"name":       tempPod.Name,
-			"phase":      tempPodPhase,
-			"containers": containerInfos,
-			"isTempPod":  true,
-		})
+	tempPod, err := k8sClt.CoreV1().Pods(project).Get(c.Request.Context(), fmt.Sprintf("temp-content-%s", sessionName), v1.GetOptions{})
+	if err != nil {
+		tempPod = nil
 	}
 
-	result["pods"] = podInfos
-
 	// Get PVC info - always use session's own PVC name
 	// Note: If session was created with parent_session_id (via API), the operator handles PVC reuse
-	pvcName := fmt.Sprintf("ambient-workspace-%s", sessionName)
-	pvc, err := k8sClt.CoreV1().PersistentVolumeClaims(project).Get(c.Request.Context(), pvcName, v1.GetOptions{})
-	result["pvcName"] = pvcName
-	if err == nil {
-		result["pvcExists"] = true
-		if storage, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
-			result["pvcSize"] = storage.String()
-		}
-	} else {
-		result["pvcExists"] = false
+	pvc, err := k8sClt.CoreV1().PersistentVolumeClaims(project).Get(c.Request.Context(), sessionPVCName(sessionName), v1.GetOptions{})
+	if err != nil {
+		pvc = nil
 	}
 
+	result := sessionK8sResources(sessionName, jobName, job, jobErr, jobPods, tempPod, pvc)
+	// Warning events tell the UI why a temp content pod is not ready (scheduling, volume attach)
+	if tempPod != nil && !isPodReady(tempPod) {
+		if events := contentPodEvents(c.Request.Context(), k8sClt, tempPod); len(events) > 0 {
+			for _, info := range result["pods"].([]map[string]interface{}) {
+				if info["isTempPod"] == true {
+					info["events"] = events
+				}
+			}
+		}
+	}
+	result["auth"] = getSessionRunnerAuth(c.Request.Context(), k8sClt, project, sessionName)
 	c.JSON(http.StatusOK, result)
 }
 
-// setRepoStatus removed - status.repos no longer in CRD (status simplified to phase, message, is_error)
-
 // ListSessionWorkspace proxies to per-job content service for directory listing.
 func ListSessionWorkspace(c *gin.Context) {
+	defer startSessionSpan(c, "workspace list")()
 	// Get project from context (set by middleware) or param
 	project := c.GetString("project")
 	if project == "" {
@@ -2524,7 +3079,7 @@ func ListSessionWorkspace(c *gin.Context) {
 
 	if project == "" {
 		log.Printf("ListSessionWorkspace: project is empty, session=%s", session)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project namespace required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project namespace required", nil)
 		return
 	}
 
@@ -2535,18 +3090,12 @@ func ListSessionWorkspace(c *gin.Context) {
 		absPath += "/" + rel
 	}
 
-	// Call per-job service or temp service for completed sessions
-	token := c.GetHeader("Authorization")
-	if strings.TrimSpace(token) == "" {
-		token = c.GetHeader("X-Forwarded-Access-Token")
-	}
-
 	// Try temp service first (for completed sessions), then regular service
 	serviceName := fmt.Sprintf("temp-content-%s", session)
 	// AuthN: require user token before probing K8s Services
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -2555,31 +3104,32 @@ func ListSessionWorkspace(c *gin.Context) {
 		serviceName = fmt.Sprintf("ambient-content-%s", session)
 	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	u := fmt.Sprintf("%s/content/list?path=%s", endpoint, url.QueryEscape(absPath))
-	log.Printf("ListSessionWorkspace: project=%s session=%s endpoint=%s", project, session, endpoint)
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, u, nil)
+	content, err := resolveContentService(c.Request.Context(), project, session, serviceName)
 	if err != nil {
-		log.Printf("ListSessionWorkspace: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		log.Printf("ListSessionWorkspace: failed to resolve %s for %s/%s: %v", serviceName, project, session, err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
-	if strings.TrimSpace(token) != "" {
-		req.Header.Set("Authorization", token)
+	log.Printf("ListSessionWorkspace: project=%s session=%s endpoint=%s", project, session, content.BaseURL)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/list", url.Values{"path": {absPath}}, nil)
+	if err != nil {
+		log.Printf("ListSessionWorkspace: failed to create HTTP request: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return
 	}
-	client := &http.Client{Timeout: 4 * time.Second}
-	resp, err := client.Do(req)
+	forwardCallerToken(c, req.Header)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
 		log.Printf("ListSessionWorkspace: content service request failed: %v", err)
-		// Soften error to 200 with empty list so UI doesn't spam
-		c.JSON(http.StatusOK, gin.H{"items": []any{}})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("ListSessionWorkspace: failed to read response body: %v", err)
-		c.JSON(http.StatusOK, gin.H{"items": []any{}})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
 
@@ -2596,11 +3146,45 @@ func ListSessionWorkspace(c *gin.Context) {
 		return
 	}
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, b, false)
+		return
+	}
 	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), b)
 }
 
-// GetSessionWorkspaceFile reads a file via content service.
+// GetSessionWorkspaceFile reads a file via content service. The file is streamed rather than
+// buffered and Range requests are passed through, so clients can fetch the tail of large files.
+// If-None-Match and If-Modified-Since are passed through too, so polling an unchanged file gets 304.
 func GetSessionWorkspaceFile(c *gin.Context) {
+	defer startSessionSpan(c, "workspace read")()
+	req := workspaceFileContentRequest(c, "GetSessionWorkspaceFile", "/content/file")
+	if req == nil {
+		return
+	}
+	streamContentServiceResponse(c, req)
+}
+
+// HeadSessionWorkspaceFile answers HEAD on a workspace file with its size, ETag and Last-Modified
+// from the content service's /content/stat, without reading the file through the proxy
+func HeadSessionWorkspaceFile(c *gin.Context) {
+	req := workspaceFileContentRequest(c, "HeadSessionWorkspaceFile", "/content/stat")
+	if req == nil {
+		return
+	}
+	proxyContentStat(c, req)
+}
+
+// workspaceFileContentRequest builds the content service request for the workspace file in the
+// path param: endpoint is /content/file or /content/stat. It prefers the temp content service of a
+// completed session. It writes the error response and returns nil when the request cannot be built.
+func workspaceFileContentRequest(c *gin.Context, handler, endpoint string) *http.Request {
+	return workspaceContentRequest(c, handler, endpoint, strings.TrimPrefix(c.Param("path"), "/"), nil)
+}
+
+// workspaceContentRequest is workspaceFileContentRequest for the workspace path sub, adding query
+// to the content service request's path parameter
+func workspaceContentRequest(c *gin.Context, handler, endpoint, sub string, query url.Values) *http.Request {
 	// Get project from context (set by middleware) or param
 	project := c.GetString("project")
 	if project == "" {
@@ -2609,61 +3193,44 @@ func GetSessionWorkspaceFile(c *gin.Context) {
 	session := c.Param("sessionName")
 
 	if project == "" {
-		log.Printf("GetSessionWorkspaceFile: project is empty, session=%s", session)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project namespace required"})
-		return
+		log.Printf("%s: project is empty, session=%s", handler, session)
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project namespace required", nil)
+		return nil
 	}
 
-	sub := strings.TrimPrefix(c.Param("path"), "/")
 	absPath := "/sessions/" + session + "/workspace/" + sub
-	token := c.GetHeader("Authorization")
-	if strings.TrimSpace(token) == "" {
-		token = c.GetHeader("X-Forwarded-Access-Token")
-	}
 
 	// Try temp service first (for completed sessions), then regular service
 	serviceName := fmt.Sprintf("temp-content-%s", session)
 	k8sClt, _ := GetK8sClientsForRequest(c)
 	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
-		return
+		return nil
 	}
 	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
 		serviceName = fmt.Sprintf("ambient-content-%s", session)
 	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	u := fmt.Sprintf("%s/content/file?path=%s", endpoint, url.QueryEscape(absPath))
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, u, nil)
+	content, err := resolveContentService(c.Request.Context(), project, session, serviceName)
 	if err != nil {
-		log.Printf("GetSessionWorkspaceFile: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
-		return
-	}
-	if strings.TrimSpace(token) != "" {
-		req.Header.Set("Authorization", token)
+		log.Printf("%s: failed to resolve %s for %s/%s: %v", handler, serviceName, project, session, err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return nil
 	}
-	client := &http.Client{Timeout: 4 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
-		return
+	values := url.Values{"path": {absPath}}
+	for k, v := range query {
+		values[k] = v
 	}
-	defer resp.Body.Close()
-	b, err := io.ReadAll(resp.Body)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, endpoint, values, nil)
 	if err != nil {
-		log.Printf("GetSessionWorkspaceFile: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file from content service"})
-		return
-	}
-
-	// Log if content service returned an error
-	if resp.StatusCode >= 400 {
-		log.Printf("GetSessionWorkspaceFile: content service returned error status %d for path %s", resp.StatusCode, sub)
+		log.Printf("%s: failed to create HTTP request: %v", handler, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return nil
 	}
-
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), b)
+	forwardCallerToken(c, req.Header)
+	content.sign(req.Header)
+	return req
 }
 
 // PutSessionWorkspaceFile writes a file via content service.
@@ -2677,14 +3244,14 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 
 	if project == "" {
 		log.Printf("PutSessionWorkspaceFile: project is empty, session=%s", session)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project namespace required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project namespace required", nil)
 		return
 	}
 
 	// Get user-scoped K8s clients and validate authentication IMMEDIATELY
 	reqK8s, reqDyn := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing authentication token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing authentication token", nil)
 		c.Abort()
 		return
 	}
@@ -2701,7 +3268,7 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	// This is more secure than manual string checks and works across platforms
 	if !pathutil.IsPathWithinBase(absPath, workspaceBase) {
 		log.Printf("PutSessionWorkspaceFile: path traversal attempt detected - path=%q escapes workspace=%q", absPath, workspaceBase)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid path: must be within workspace directory"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid path: must be within workspace directory", nil)
 		return
 	}
 
@@ -2709,11 +3276,6 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	// filepath.Join may use backslashes on Windows, but content service always uses forward slashes
 	absPath = filepath.ToSlash(absPath)
 
-	token := c.GetHeader("Authorization")
-	if strings.TrimSpace(token) == "" {
-		token = c.GetHeader("X-Forwarded-Access-Token")
-	}
-
 	// RBAC check: verify user has update permission on agenticsessions (file operations modify session state)
 	// IMPORTANT: RBAC check MUST happen BEFORE checking session existence to prevent enumeration attacks
 	ssar := &authzv1.SelfSubjectAccessReview{
@@ -2729,11 +3291,11 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("RBAC check failed for file upload in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 	if !res.Status.Allowed {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to modify session workspace"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to modify session workspace", nil)
 		return
 	}
 
@@ -2742,11 +3304,7 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
 	if err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		respondK8sError(c, err, "Session not found", "Failed to get session")
 		return
 	}
 
@@ -2798,7 +3356,7 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 				return
 			}
 			log.Printf("PutSessionWorkspaceFile: Failed to request temp pod: %v", err)
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Content service not available, please try again in a few seconds"})
+			respondUpstreamUnavailable(c, "Content service not available, please try again in a few seconds")
 			return
 		}
 
@@ -2807,19 +3365,32 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 		return
 	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
+	contentSvc, err := resolveContentService(c.Request.Context(), project, session, serviceName)
+	if err != nil {
+		log.Printf("PutSessionWorkspaceFile: failed to resolve %s for %s/%s: %v", serviceName, project, session, err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
 	log.Printf("PutSessionWorkspaceFile: using service %s for session %s", serviceName, session)
 	payload, err := io.ReadAll(c.Request.Body)
 	if err != nil {
 		log.Printf("PutSessionWorkspaceFile: failed to read request body: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file data"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Failed to read file data", nil)
 		return
 	}
 
-	// Detect if content is binary and encode accordingly
+	// ?encoding=base64 means the body is already base64 text; forward it untouched
+	// so the content service decodes it to the exact original bytes
 	encoding := "utf8"
 	var content string
 	contentType := c.GetHeader("Content-Type")
+	requestedEncoding := strings.ToLower(strings.TrimSpace(c.Query("encoding")))
+	switch requestedEncoding {
+	case "", "utf8", "base64":
+	default:
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "encoding must be utf8 or base64", nil)
+		return
+	}
 
 	// If no Content-Type header, detect from payload
 	if contentType == "" {
@@ -2831,7 +3402,14 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	// IMPORTANT: Validate UTF-8 BEFORE converting to string
 	isBinary := isBinaryContentType(contentType) || !utf8.Valid(payload)
 
-	if isBinary {
+	if requestedEncoding == "base64" {
+		encoding = "base64"
+		content = strings.TrimSpace(string(payload))
+		if _, err := base64.StdEncoding.DecodeString(content); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid base64 content", nil)
+			return
+		}
+	} else if isBinary {
 		encoding = "base64"
 		content = base64.StdEncoding.EncodeToString(payload)
 		// Don't log user-controlled strings (contentType header) to prevent log injection
@@ -2849,30 +3427,31 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 	b, err := json.Marshal(wreq)
 	if err != nil {
 		log.Printf("PutSessionWorkspaceFile: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint+"/content/write", strings.NewReader(string(b)))
+	req, err := contentSvc.newRequest(c.Request.Context(), http.MethodPost, "/content/write", nil, bytes.NewReader(b))
 	if err != nil {
 		log.Printf("PutSessionWorkspaceFile: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if strings.TrimSpace(token) != "" {
-		req.Header.Set("Authorization", token)
-	}
+	forwardCallerToken(c, req.Header)
 	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 4 * time.Second}
-	resp, err := client.Do(req)
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	contentSvc.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 	rb, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("PutSessionWorkspaceFile: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
 
@@ -2881,6 +3460,13 @@ func PutSessionWorkspaceFile(c *gin.Context) {
 		log.Printf("PutSessionWorkspaceFile: content service returned error status %d for path %s: %s", resp.StatusCode, sub, string(rb))
 	}
 
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, rb, false)
+		return
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.Header("ETag", etag)
+	}
 	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), rb)
 }
 
@@ -2895,14 +3481,14 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 
 	if project == "" {
 		log.Printf("DeleteSessionWorkspaceFile: project is empty, session=%s", session)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Project namespace required"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Project namespace required", nil)
 		return
 	}
 
 	// Get user-scoped K8s clients and validate authentication IMMEDIATELY
 	reqK8s, reqDyn := GetK8sClientsForRequest(c)
 	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing authentication token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing authentication token", nil)
 		c.Abort()
 		return
 	}
@@ -2919,7 +3505,7 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	// This is more secure than manual string checks and works across platforms
 	if !pathutil.IsPathWithinBase(absPath, workspaceBase) {
 		log.Printf("DeleteSessionWorkspaceFile: path traversal attempt detected - path=%q escapes workspace=%q", absPath, workspaceBase)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid path: must be within workspace directory"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid path: must be within workspace directory", nil)
 		return
 	}
 
@@ -2927,11 +3513,6 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	// filepath.Join may use backslashes on Windows, but content service always uses forward slashes
 	absPath = filepath.ToSlash(absPath)
 
-	token := c.GetHeader("Authorization")
-	if strings.TrimSpace(token) == "" {
-		token = c.GetHeader("X-Forwarded-Access-Token")
-	}
-
 	// RBAC check: verify user has update permission on agenticsessions (file operations modify session state)
 	// IMPORTANT: RBAC check MUST happen BEFORE checking session existence to prevent enumeration attacks
 	ssar := &authzv1.SelfSubjectAccessReview{
@@ -2947,11 +3528,11 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(c.Request.Context(), ssar, v1.CreateOptions{})
 	if err != nil {
 		log.Printf("RBAC check failed for file deletion in project %s: %v", project, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify permissions"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
 		return
 	}
 	if !res.Status.Allowed {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to modify session workspace"})
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to modify session workspace", nil)
 		return
 	}
 
@@ -2959,12 +3540,8 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	// This prevents enumeration attacks - unauthorized users get same "Forbidden" response
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	if _, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{}); err != nil {
-		if errors.IsNotFound(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
-			return
-		}
 		log.Printf("DeleteSessionWorkspaceFile: Failed to verify session existence: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+		respondK8sError(c, err, "Session not found", "Failed to verify session")
 		return
 	}
 
@@ -2977,7 +3554,7 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 		serviceName = fmt.Sprintf("ambient-content-%s", session)
 		if _, err := reqK8s.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
 			log.Printf("DeleteSessionWorkspaceFile: No content service found for session %s", session)
-			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Content service not available"})
+			respondUpstreamUnavailable(c, "Content service unavailable")
 			return
 		} else {
 			serviceFound = true
@@ -2987,11 +3564,16 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	}
 
 	if !serviceFound {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Content service not available"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
+	content, err := resolveContentService(c.Request.Context(), project, session, serviceName)
+	if err != nil {
+		log.Printf("DeleteSessionWorkspaceFile: failed to resolve %s for %s/%s: %v", serviceName, project, session, err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
 	log.Printf("DeleteSessionWorkspaceFile: using service %s for session %s, path=%s", serviceName, session, absPath)
 
 	// Use DELETE request with path in body
@@ -3001,23 +3583,21 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 	b, err := json.Marshal(wreq)
 	if err != nil {
 		log.Printf("DeleteSessionWorkspaceFile: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodDelete, endpoint+"/content/delete", strings.NewReader(string(b)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodDelete, "/content/delete", nil, strings.NewReader(string(b)))
 	if err != nil {
 		log.Printf("DeleteSessionWorkspaceFile: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if strings.TrimSpace(token) != "" {
-		req.Header.Set("Authorization", token)
-	}
+	forwardCallerToken(c, req.Header)
 	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 4 * time.Second}
-	resp, err := client.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3029,7 +3609,7 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 		rb, err := io.ReadAll(resp.Body)
 		if err != nil {
 			log.Printf("DeleteSessionWorkspaceFile: failed to read error response: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+			respondUpstreamUnavailable(c, "Failed to read response from content service")
 			return
 		}
 		// Try to parse error from content service, otherwise use generic message
@@ -3037,14 +3617,16 @@ func DeleteSessionWorkspaceFile(c *gin.Context) {
 		if err := json.Unmarshal(rb, &errResp); err == nil {
 			c.JSON(resp.StatusCode, errResp)
 		} else {
-			c.JSON(resp.StatusCode, gin.H{"error": "Failed to delete file"})
+			respondError(c, resp.StatusCode, errorKindForStatus(resp.StatusCode), "Failed to delete file", nil)
 		}
 	}
 }
 
 // PushSessionRepo proxies a push request for a given session repo to the per-job content service.
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/github/push
-// Body: { repoIndex: number, commitMessage?: string, branch?: string }
+// Body: { repoIndex: number, commitMessage?: string, allowSecrets?: boolean }
+// Changes that the content service's secret scan flags are rejected with 422 listing the matches
+// and recorded on the repo status; allowSecrets pushes them anyway and needs edit on the project.
 func PushSessionRepo(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
@@ -3052,151 +3634,168 @@ func PushSessionRepo(c *gin.Context) {
 	var body struct {
 		RepoIndex     int    `json:"repoIndex"`
 		CommitMessage string `json:"commitMessage"`
+		AllowSecrets  bool   `json:"allowSecrets"`
 	}
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid JSON body", nil)
 		return
 	}
 	log.Printf("pushSessionRepo: request project=%s session=%s repoIndex=%d commitLen=%d", project, session, body.RepoIndex, len(strings.TrimSpace(body.CommitMessage)))
 
-	// Try temp service first (for completed sessions), then regular service
-	serviceName := fmt.Sprintf("temp-content-%s", session)
 	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
 	if k8sClt == nil || k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
+
+	if body.AllowSecrets {
+		allowed, err := canOverrideSecretScan(c.Request.Context(), k8sClt, project)
+		if err != nil {
+			log.Printf("pushSessionRepo: access review failed for project %s: %v", project, err)
+			respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+			return
+		}
+		if !allowed {
+			respondError(c, http.StatusForbidden, ErrorKindForbidden, "allowSecrets requires edit access to the project", nil)
+			return
+		}
 	}
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	log.Printf("pushSessionRepo: using service %s", serviceName)
 
 	// Simplified: 1) get session; 2) compute repoPath from INPUT repo folder; 3) get output url/branch; 4) proxy
-	resolvedRepoPath := ""
-	// default branch when not defined on output
-	resolvedBranch := fmt.Sprintf("sessions/%s", session)
-	resolvedOutputURL := ""
 	gvr := GetAgenticSessionV1Alpha1Resource()
 	obj, err := k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read session"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "failed to read session", nil)
 		return
 	}
 	spec, _ := obj.Object["spec"].(map[string]interface{})
 	repos, _ := spec["repos"].([]interface{})
 	if body.RepoIndex < 0 || body.RepoIndex >= len(repos) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid repo index"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid repo index", nil)
 		return
 	}
 	rm, _ := repos[body.RepoIndex].(map[string]interface{})
-	// Derive repoPath from input URL folder name
-	if in, ok := rm["input"].(map[string]interface{}); ok {
-		if urlv, ok2 := in["url"].(string); ok2 && strings.TrimSpace(urlv) != "" {
-			folder := DeriveRepoFolderFromURL(strings.TrimSpace(urlv))
-			if folder != "" {
-				resolvedRepoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, folder)
-			}
-		}
+	target := resolveRepoPushTarget(session, body.RepoIndex, rm)
+	resolvedRepoPath, resolvedOutputURL, resolvedBranch, inputRepoURL := target.RepoPath, target.OutputURL, target.Branch, target.InputURL
+	if strings.TrimSpace(resolvedOutputURL) == "" {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing output repo url", nil)
+		return
 	}
-	if out, ok := rm["output"].(map[string]interface{}); ok {
-		if urlv, ok2 := out["url"].(string); ok2 && strings.TrimSpace(urlv) != "" {
-			resolvedOutputURL = strings.TrimSpace(urlv)
+	// Without an output branch, the project's outputBranchTemplate names it; it is persisted on success
+	templatedBranch := ""
+	if repoOutputBranch(rm) == "" {
+		templatedBranch, err = sessionOutputBranch(c.Request.Context(), k8sDyn, project, obj)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
 		}
-		if bs, ok2 := out["branch"].(string); ok2 && strings.TrimSpace(bs) != "" {
-			resolvedBranch = strings.TrimSpace(bs)
-		} else if bv, ok2 := out["branch"].(*string); ok2 && bv != nil && strings.TrimSpace(*bv) != "" {
-			resolvedBranch = strings.TrimSpace(*bv)
+		if templatedBranch != "" {
+			resolvedBranch = templatedBranch
 		}
 	}
-	// If input URL missing or unparsable, fall back to numeric index path (last resort)
-	if strings.TrimSpace(resolvedRepoPath) == "" {
-		if body.RepoIndex >= 0 {
-			resolvedRepoPath = fmt.Sprintf("/sessions/%s/workspace/%d", session, body.RepoIndex)
-		} else {
-			resolvedRepoPath = fmt.Sprintf("/sessions/%s/workspace", session)
-		}
+	log.Printf("pushSessionRepo: resolved repoPath=%q outputUrl=%q branch=%q", resolvedRepoPath, resolvedOutputURL, resolvedBranch)
+	// The output URL may have changed since create, so it is checked against the allowlist on every push
+	allowedHosts, ok := requireAllowedGitHosts(c, k8sDyn, project)
+	if !ok || !requireGitURLsInHosts(c, project, allowedHosts, resolvedOutputURL) {
+		return
 	}
-	if strings.TrimSpace(resolvedOutputURL) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing output repo url"})
+
+	// Resolve the content service last so request validation fails fast without spawning a pod
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
 		return
 	}
-	log.Printf("pushSessionRepo: resolved repoPath=%q outputUrl=%q branch=%q", resolvedRepoPath, resolvedOutputURL, resolvedBranch)
+	log.Printf("pushSessionRepo: using content endpoint %s (podSpawned=%t)", content.BaseURL, content.PodSpawned)
+
+	k8sClt, k8sDyn = GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	// Mint a short-lived GitHub token for one-shot authenticated push
+	// Load session to get authoritative userId
+	author, authorResolved := git.BotIdentity(), false
+	tokenStr := ""
+	var creds git.Credentials
+	obj, err = k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
+	if err == nil {
+		spec, _ := obj.Object["spec"].(map[string]interface{})
+		var ok bool
+		if tokenStr, ok = requireSessionGitHubToken(c, project, spec, []string{resolvedOutputURL}); !ok {
+			return
+		}
+		// A fork replaces the output repo, so it is made before the author and credentials are resolved
+		apiBase := projectGitHubAPIBase(c, k8sClt, k8sDyn, project, resolvedOutputURL)
+		if err := forkOutputRepo(c.Request.Context(), apiBase, project, session, &target, tokenStr, allowedHosts); err != nil {
+			log.Printf("pushSessionRepo: failed to fork output repo of %s/%s repo %d: %v", project, session, body.RepoIndex, err)
+			var details gin.H
+			if content.PodSpawned {
+				details = gin.H{"podSpawned": true}
+			}
+			if err == errForkHostNotAllowed {
+				respondError(c, http.StatusForbidden, ErrorKindForbidden, "The fork of the output repository is outside the project's allowed git hosts", details)
+				return
+			}
+			respondError(c, http.StatusBadGateway, ErrorKindUpstreamUnavailable, "Failed to fork the output repository", details)
+			return
+		}
+		resolvedOutputURL = target.OutputURL
+		if tokenStr != "" {
+			author, authorResolved = sessionGitAuthor(c, project, spec, resolvedOutputURL, tokenStr)
+		}
+		creds = sessionGitCredentials(c, project, spec, tokenStr, []string{resolvedOutputURL})
+	} else {
+		log.Printf("pushSessionRepo: failed to read session for token attach: %v", err)
+	}
 
 	payload := map[string]interface{}{
 		"repoPath":      resolvedRepoPath,
 		"commitMessage": body.CommitMessage,
 		"branch":        resolvedBranch,
 		"outputRepoUrl": resolvedOutputURL,
+		"allowSecrets":  body.AllowSecrets,
 	}
 	b, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("pushSessionRepo: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint+"/content/github/push", strings.NewReader(string(b)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/github/push", nil, strings.NewReader(string(b)))
 	if err != nil {
 		log.Printf("pushSessionRepo: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
-	if v := c.GetHeader("X-Forwarded-Access-Token"); v != "" {
-		req.Header.Set("X-Forwarded-Access-Token", v)
-	}
+	forwardCallerToken(c, req.Header)
 	req.Header.Set("Content-Type", "application/json")
-	k8sClt, k8sDyn = GetK8sClientsForRequest(c)
-	if k8sClt == nil || k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
-		return
+	if tokenStr != "" {
+		req.Header.Set("X-GitHub-Token", tokenStr)
+		log.Printf("pushSessionRepo: attached short-lived GitHub token for project=%s session=%s", project, session)
 	}
-
-	// Attach short-lived GitHub token for one-shot authenticated push
-	// Load session to get authoritative userId
-	gvr = GetAgenticSessionV1Alpha1Resource()
-	obj, err = k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), session, v1.GetOptions{})
-	if err == nil {
-		spec, _ := obj.Object["spec"].(map[string]interface{})
-		userID := ""
-		if spec != nil {
-			if uc, ok := spec["userContext"].(map[string]interface{}); ok {
-				if v, ok := uc["userId"].(string); ok {
-					userID = strings.TrimSpace(v)
-				}
-			}
-		}
-		if userID != "" {
-			if tokenStr, err := GetGitHubToken(c.Request.Context(), k8sClt, k8sDyn, project, userID); err == nil && strings.TrimSpace(tokenStr) != "" {
-				req.Header.Set("X-GitHub-Token", tokenStr)
-				log.Printf("pushSessionRepo: attached short-lived GitHub token for project=%s session=%s", project, session)
-			} else if err != nil {
-				log.Printf("pushSessionRepo: failed to resolve GitHub token: %v", err)
-			}
-		} else {
-			log.Printf("pushSessionRepo: session %s/%s missing userContext.userId; proceeding without token", project, session)
-		}
-	} else {
-		log.Printf("pushSessionRepo: failed to read session for token attach: %v", err)
+	setGitCredentialsHeader(req.Header, creds)
+	setGitAuthorHeaders(req.Header, author)
+	if !attachCommitSigning(c, req.Header, k8sClt, k8sDyn, project) {
+		return
 	}
 
-	log.Printf("pushSessionRepo: proxy push project=%s session=%s repoIndex=%d repoPath=%s endpoint=%s", project, session, body.RepoIndex, resolvedRepoPath, endpoint+"/content/github/push")
-	resp, err := http.DefaultClient.Do(req)
+	log.Printf("pushSessionRepo: proxy push project=%s session=%s repoIndex=%d repoPath=%s endpoint=%s", project, session, body.RepoIndex, resolvedRepoPath, content.BaseURL)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
-		log.Printf("Bad gateway error: %v", err)
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Service temporarily unavailable"})
+		log.Printf("Content service request failed: %v", err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("pushSessionRepo: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -3207,12 +3806,48 @@ func PushSessionRepo(c *gin.Context) {
 			}
 			return s
 		}())
-		c.Data(resp.StatusCode, "application/json", bodyBytes)
+		if findings := contentSecretFindings(bodyBytes); resp.StatusCode == http.StatusUnprocessableEntity && len(findings) > 0 {
+			if inputRepoURL != "" && DynamicClient != nil {
+				if err := setRepoStatus(c.Request.Context(), DynamicClient, project, session, inputRepoURL, map[string]interface{}{"secretFindings": secretFindingsStatus(findings)}); err != nil {
+					log.Printf("pushSessionRepo: failed to record secret findings for %s/%s: %v", project, session, err)
+				}
+			}
+			details := gin.H{"secretFindings": findings}
+			if content.PodSpawned {
+				details["podSpawned"] = true
+			}
+			respondError(c, http.StatusUnprocessableEntity, ErrorKindValidation, "Push blocked: the changes contain possible secrets; retry with allowSecrets to push anyway", details)
+			return
+		}
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	// Note: status.repos removed from CRD - no longer tracking per-repo status
 	log.Printf("pushSessionRepo: content push succeeded status=%d body.len=%d", resp.StatusCode, len(bodyBytes))
-	c.Data(http.StatusOK, "application/json", bodyBytes)
+
+	if templatedBranch != "" && DynamicClient != nil {
+		if err := persistRepoOutputBranch(c.Request.Context(), DynamicClient, project, session, body.RepoIndex, templatedBranch); err != nil {
+			log.Printf("pushSessionRepo: failed to record output branch for %s/%s repo %d: %v", project, session, body.RepoIndex, err)
+		}
+	}
+
+	// Record the pushed commit on the repo's status entry
+	var pushResult struct {
+		SHA    string `json:"sha"`
+		Branch string `json:"branch"`
+	}
+	if err := json.Unmarshal(bodyBytes, &pushResult); err == nil && strings.TrimSpace(pushResult.SHA) != "" && inputRepoURL != "" && DynamicClient != nil {
+		fields := map[string]interface{}{
+			"pushedSha":      strings.TrimSpace(pushResult.SHA),
+			"secretFindings": secretFindingsStatus(contentSecretFindings(bodyBytes)),
+		}
+		if branch := strings.TrimSpace(pushResult.Branch); branch != "" {
+			fields["pushedBranch"] = branch
+		}
+		if err := setRepoStatus(c.Request.Context(), DynamicClient, project, session, inputRepoURL, fields); err != nil {
+			log.Printf("pushSessionRepo: failed to record pushed sha for %s/%s: %v", project, session, err)
+		}
+	}
+	c.Data(http.StatusOK, "application/json", withPodSpawned(withGitAuthor(bodyBytes, author, authorResolved), content.PodSpawned))
 }
 
 // AbandonSessionRepo instructs sidecar to discard local changes for a repo.
@@ -3224,27 +3859,19 @@ func AbandonSessionRepo(c *gin.Context) {
 		RepoPath  string `json:"repoPath"`
 	}
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid JSON body", nil)
 		return
 	}
 
-	// Try temp service first (for completed sessions), then regular service
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	log.Printf("AbandonSessionRepo: using service %s", serviceName)
+	log.Printf("AbandonSessionRepo: using content endpoint %s", content.BaseURL)
 	repoPath := strings.TrimSpace(body.RepoPath)
 	if repoPath == "" {
 		if body.RepoIndex >= 0 {
-			repoPath = fmt.Sprintf("/sessions/%s/workspace/%d", session, body.RepoIndex)
+			repoPath = repoIndexWorkspacePath(c, project, session, body.RepoIndex)
 		} else {
 			repoPath = fmt.Sprintf("/sessions/%s/workspace", session)
 		}
@@ -3255,44 +3882,39 @@ func AbandonSessionRepo(c *gin.Context) {
 	b, err := json.Marshal(payload)
 	if err != nil {
 		log.Printf("abandonSessionRepo: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint+"/content/github/abandon", strings.NewReader(string(b)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/github/abandon", nil, strings.NewReader(string(b)))
 	if err != nil {
 		log.Printf("abandonSessionRepo: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
-	if v := c.GetHeader("X-Forwarded-Access-Token"); v != "" {
-		req.Header.Set("X-Forwarded-Access-Token", v)
-	}
+	forwardCallerToken(c, req.Header)
 	req.Header.Set("Content-Type", "application/json")
 	log.Printf("abandonSessionRepo: proxy abandon project=%s session=%s repoIndex=%d repoPath=%s", project, session, body.RepoIndex, repoPath)
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
 		// Log actual error for debugging, but return generic message to avoid leaking internal details
-		log.Printf("Bad gateway error: %v", err)
-		c.JSON(http.StatusBadGateway, gin.H{"error": "Service temporarily unavailable"})
+		log.Printf("Content service request failed: %v", err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("abandonSessionRepo: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		log.Printf("abandonSessionRepo: content returned status=%d body=%s", resp.StatusCode, string(bodyBytes))
-		c.Data(resp.StatusCode, "application/json", bodyBytes)
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	// Note: status.repos removed from CRD - no longer tracking per-repo status
-	c.Data(http.StatusOK, "application/json", bodyBytes)
+	c.Data(http.StatusOK, "application/json", withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // DiffSessionRepo proxies diff counts for a given session repo to the content sidecar.
@@ -3303,105 +3925,83 @@ func DiffSessionRepo(c *gin.Context) {
 	repoIndexStr := strings.TrimSpace(c.Query("repoIndex"))
 	repoPath := strings.TrimSpace(c.Query("repoPath"))
 	if repoPath == "" && repoIndexStr != "" {
-		repoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, repoIndexStr)
+		if idx, err := strconv.Atoi(repoIndexStr); err == nil {
+			repoPath = repoIndexWorkspacePath(c, project, session, idx)
+		} else {
+			repoPath = fmt.Sprintf("/sessions/%s/workspace/%s", session, repoIndexStr)
+		}
 	}
 	if repoPath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "missing repoPath/repoIndex"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "missing repoPath/repoIndex", nil)
 		return
 	}
 
-	// Try temp service first (for completed sessions), then regular service
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080", serviceName, project)
-	log.Printf("DiffSessionRepo: using service %s", serviceName)
-	url := fmt.Sprintf("%s/content/github/diff?repoPath=%s", endpoint, url.QueryEscape(repoPath))
-	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, url, nil)
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
-	if v := c.GetHeader("X-Forwarded-Access-Token"); v != "" {
-		req.Header.Set("X-Forwarded-Access-Token", v)
+	log.Printf("DiffSessionRepo: using content endpoint %s", content.BaseURL)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/github/diff", url.Values{"repoPath": {repoPath}}, nil)
+	if err != nil {
+		log.Printf("DiffSessionRepo: failed to create HTTP request: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return
 	}
-	resp, err := http.DefaultClient.Do(req)
+	forwardCallerToken(c, req.Header)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"files": gin.H{
-				"added":   0,
-				"removed": 0,
-			},
-			"total_added":   0,
-			"total_removed": 0,
-		})
+		log.Printf("DiffSessionRepo: content service request failed: %v", err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("DiffSessionRepo: failed to read response body: %v", err)
-		c.JSON(http.StatusOK, gin.H{
-			"files": gin.H{
-				"added":   0,
-				"removed": 0,
-			},
-			"total_added":   0,
-			"total_removed": 0,
-		})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GetGitStatus returns git status for a directory in the workspace
 // GET /api/projects/:projectName/agentic-sessions/:sessionName/git/status?path=artifacts
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/git/status?repoIndex=1
 func GetGitStatus(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
-	relativePath := strings.TrimSpace(c.Query("path"))
 
-	if relativePath == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "path parameter required"})
+	repoIndex, ok := queryRepoIndex(c)
+	if !ok {
 		return
 	}
-
-	// Build absolute path
-	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, relativePath)
-
-	// Get content service endpoint
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	absPath, ok := resolveGitWorkspacePath(c, project, session, c.Query("path"), repoIndex)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-status?path=%s", serviceName, project, url.QueryEscape(absPath))
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
+		return
+	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, endpoint, nil)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/git-status", url.Values{"path": {absPath}}, nil)
 	if err != nil {
 		log.Printf("GetGitStatus: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3409,10 +4009,14 @@ func GetGitStatus(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GetGitStatus: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // ConfigureGitRemote initializes git and configures remote for a workspace directory
@@ -3421,9 +4025,9 @@ func GetGitStatus(c *gin.Context) {
 func ConfigureGitRemote(c *gin.Context) {
 	project := c.Param("projectName")
 	sessionName := c.Param("sessionName")
-	_, k8sDyn := GetK8sClientsForRequest(c)
-	if k8sDyn == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+	k8sClt, k8sDyn := GetK8sClientsForRequest(c)
+	if k8sClt == nil || k8sDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
 		return
 	}
@@ -3435,30 +4039,27 @@ func ConfigureGitRemote(c *gin.Context) {
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
+	if !requireValidBranch(c, "branch", body.Branch) {
+		return
+	}
 	if body.Branch == "" {
 		body.Branch = "main"
 	}
+	if !requireAllowedGitURLs(c, k8sDyn, project, body.RemoteURL) {
+		return
+	}
 
 	// Build absolute path
 	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", sessionName, body.Path)
 
-	// Get content service endpoint
-	serviceName := fmt.Sprintf("temp-content-%s", sessionName)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	content, ok := resolveContentEndpoint(c, project, sessionName, false)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", sessionName)
-	}
-
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-configure-remote", serviceName, project)
 
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"path":      absPath,
@@ -3467,91 +4068,89 @@ func ConfigureGitRemote(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("ConfigureGitRemote: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(reqBody)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/git-configure-remote", nil, strings.NewReader(string(reqBody)))
 	if err != nil {
 		log.Printf("ConfigureGitRemote: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
 
-	// Get and forward GitHub token for authenticated remote URL
+	// Get and forward the credentials for the remote's host for the initial fetch, and the identity
+	// commits in this repo should be authored as. Without a resolved identity the repo's git config
+	// is left alone.
+	var spec map[string]interface{}
+	if item, err := k8sDyn.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{}); err == nil {
+		spec, _, _ = unstructured.NestedMap(item.Object, "spec")
+	}
+	githubToken := ""
 	if GetGitHubToken != nil {
-		if token, err := GetGitHubToken(c.Request.Context(), k8sClt, k8sDyn, project, ""); err == nil && token != "" {
+		if token, err := GetGitHubToken(c.Request.Context(), k8sClt, k8sDyn, project, "", body.RemoteURL); err == nil && token != "" {
+			githubToken = token
 			req.Header.Set("X-GitHub-Token", token)
 			log.Printf("Forwarding GitHub token for remote configuration")
+			if author, ok := sessionGitAuthor(c, project, spec, body.RemoteURL, token); ok {
+				setGitAuthorHeaders(req.Header, author)
+			}
 		}
 	}
+	setGitCredentialsHeader(req.Header, sessionGitCredentials(c, project, spec, githubToken, []string{body.RemoteURL}))
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
 
 	// If successful, persist remote config to session annotations for persistence
 	if resp.StatusCode == http.StatusOK {
-		// Persist remote config in annotations (supports multiple directories)
-		gvr := GetAgenticSessionV1Alpha1Resource()
-		item, err := k8sDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
-		if err == nil {
-			metadata, _, err := unstructured.NestedMap(item.Object, "metadata")
-			if err != nil || metadata == nil {
-				metadata = map[string]interface{}{}
-			}
-			anns, _, err := unstructured.NestedMap(metadata, "annotations")
-			if err != nil || anns == nil {
-				anns = map[string]interface{}{}
-			}
-
-			// Derive safe annotation key from path (use :: as separator to avoid conflicts with hyphens in path)
-			annotationKey := strings.ReplaceAll(body.Path, "/", "::")
-			anns[fmt.Sprintf("ambient-code.io/remote-%s-url", annotationKey)] = body.RemoteURL
-			anns[fmt.Sprintf("ambient-code.io/remote-%s-branch", annotationKey)] = body.Branch
-			_ = unstructured.SetNestedMap(metadata, anns, "annotations")
-			_ = unstructured.SetNestedMap(item.Object, metadata, "metadata")
-
-			_, err = k8sDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{})
-			if err != nil {
-				log.Printf("Warning: Failed to persist remote config to annotations: %v", err)
-			} else {
-				log.Printf("Persisted remote config for %s to session annotations: %s@%s", body.Path, body.RemoteURL, body.Branch)
-			}
+		if err := persistSessionRemote(c.Request.Context(), k8sDyn, project, sessionName, body.Path, body.RemoteURL, body.Branch, nil); err != nil {
+			log.Printf("Warning: Failed to persist remote config to annotations: %v", err)
+		} else {
+			log.Printf("Persisted remote config for %s to session annotations: %s@%s", body.Path, body.RemoteURL, body.Branch)
 		}
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("ConfigureGitRemote: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // SynchronizeGit commits, pulls, and pushes changes for a workspace directory
-// Body: { path: string, message?: string, branch?: string }
+// Body: { path?: string, repoIndex?: int, message?: string, branch?: string }
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/git/synchronize
 func SynchronizeGit(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
 
 	var body struct {
-		Path    string `json:"path" binding:"required"`
-		Message string `json:"message"`
-		Branch  string `json:"branch"`
+		Path      string `json:"path"`
+		RepoIndex *int   `json:"repoIndex"`
+		Message   string `json:"message"`
+		Branch    string `json:"branch"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
+		return
+	}
+
+	if !requireValidBranch(c, "branch", body.Branch) {
 		return
 	}
 
@@ -3560,22 +4159,15 @@ func SynchronizeGit(c *gin.Context) {
 		body.Message = fmt.Sprintf("Session %s - %s", session, time.Now().Format(time.RFC3339))
 	}
 
-	// Build absolute path
-	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
-
-	// Get content service endpoint
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	absPath, ok := resolveGitWorkspacePath(c, project, session, body.Path, body.RepoIndex)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-sync", serviceName, project)
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
+		return
+	}
 
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"path":    absPath,
@@ -3584,24 +4176,33 @@ func SynchronizeGit(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("SynchronizeGit: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(reqBody)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/git-sync", nil, strings.NewReader(string(reqBody)))
 	if err != nil {
 		log.Printf("SynchronizeGit: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
+	forwardCallerToken(c, req.Header)
+	attachSessionGitCredentials(c, req.Header, project, session)
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+	if !attachCommitSigning(c, req.Header, reqK8s, reqDyn, project) {
+		return
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3609,10 +4210,14 @@ func SynchronizeGit(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("SynchronizeGit: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
+		return
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GetGitMergeStatus checks if local and remote can merge cleanly
@@ -3626,34 +4231,32 @@ func GetGitMergeStatus(c *gin.Context) {
 	if relativePath == "" {
 		relativePath = "artifacts"
 	}
+	if !requireValidBranch(c, "branch", branch) {
+		return
+	}
 	if branch == "" {
 		branch = "main"
 	}
 
 	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, relativePath)
 
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-merge-status?path=%s&branch=%s",
-		serviceName, project, url.QueryEscape(absPath), url.QueryEscape(branch))
-
-	req, _ := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, endpoint, nil)
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/git-merge-status", url.Values{"path": {absPath}, "branch": {branch}}, nil)
+	if err != nil {
+		log.Printf("GetGitMergeStatus: failed to create HTTP request: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
+		return
 	}
+	forwardCallerToken(c, req.Header)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3661,49 +4264,50 @@ func GetGitMergeStatus(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GetGitMergeStatus: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
+		return
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GitPullSession pulls changes from remote
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/git/pull
+// Body: { path?: string, repoIndex?: int, branch?: string }
 func GitPullSession(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
 
 	var body struct {
-		Path   string `json:"path"`
-		Branch string `json:"branch"`
+		Path      string `json:"path"`
+		RepoIndex *int   `json:"repoIndex"`
+		Branch    string `json:"branch"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
-	if body.Path == "" {
-		body.Path = "artifacts"
+	if !requireValidBranch(c, "branch", body.Branch) {
+		return
 	}
 	if body.Branch == "" {
 		body.Branch = "main"
 	}
 
-	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
-
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	absPath, ok := resolveGitWorkspacePath(c, project, session, body.Path, body.RepoIndex)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-pull", serviceName, project)
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
+		return
+	}
 
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"path":   absPath,
@@ -3711,24 +4315,24 @@ func GitPullSession(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("GitPullSession: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(reqBody)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/git-pull", nil, strings.NewReader(string(reqBody)))
 	if err != nil {
 		log.Printf("GitPullSession: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
+	attachSessionGitCredentials(c, req.Header, project, session)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3736,31 +4340,37 @@ func GitPullSession(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GitPullSession: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
+		return
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GitPushSession pushes changes to remote branch
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/git/push
+// Body: { path?: string, repoIndex?: int, branch?: string, message?: string }
 func GitPushSession(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
 
 	var body struct {
-		Path    string `json:"path"`
-		Branch  string `json:"branch"`
-		Message string `json:"message"`
+		Path      string `json:"path"`
+		RepoIndex *int   `json:"repoIndex"`
+		Branch    string `json:"branch"`
+		Message   string `json:"message"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
-	if body.Path == "" {
-		body.Path = "artifacts"
+	if !requireValidBranch(c, "branch", body.Branch) {
+		return
 	}
 	if body.Branch == "" {
 		body.Branch = "main"
@@ -3769,20 +4379,15 @@ func GitPushSession(c *gin.Context) {
 		body.Message = fmt.Sprintf("Session %s artifacts", session)
 	}
 
-	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
-
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	absPath, ok := resolveGitWorkspacePath(c, project, session, body.Path, body.RepoIndex)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-push", serviceName, project)
+	content, ok := resolveContentEndpoint(c, project, session, true)
+	if !ok {
+		return
+	}
 
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"path":    absPath,
@@ -3791,24 +4396,24 @@ func GitPushSession(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("GitPushSession: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(reqBody)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/git-push", nil, strings.NewReader(string(reqBody)))
 	if err != nil {
 		log.Printf("GitPushSession: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
+	attachSessionGitCredentials(c, req.Header, project, session)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3816,46 +4421,47 @@ func GitPushSession(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GitPushSession: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
+		return
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GitCreateBranchSession creates a new git branch
 // POST /api/projects/:projectName/agentic-sessions/:sessionName/git/create-branch
+// Body: { path?: string, repoIndex?: int, branchName: string }
 func GitCreateBranchSession(c *gin.Context) {
 	project := c.Param("projectName")
 	session := c.Param("sessionName")
 
 	var body struct {
 		Path       string `json:"path"`
+		RepoIndex  *int   `json:"repoIndex"`
 		BranchName string `json:"branchName" binding:"required"`
 	}
 
 	if err := c.BindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid request body", nil)
 		return
 	}
 
-	if body.Path == "" {
-		body.Path = "artifacts"
+	if !requireValidBranch(c, "branchName", body.BranchName) {
+		return
 	}
 
-	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, body.Path)
-
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	absPath, ok := resolveGitWorkspacePath(c, project, session, body.Path, body.RepoIndex)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
 
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-create-branch", serviceName, project)
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
+		return
+	}
 
 	reqBody, err := json.Marshal(map[string]interface{}{
 		"path":       absPath,
@@ -3863,24 +4469,23 @@ func GitCreateBranchSession(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("GitCreateBranchSession: failed to marshal request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to prepare request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to prepare request", nil)
 		return
 	}
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, endpoint, strings.NewReader(string(reqBody)))
+	req, err := content.newRequest(c.Request.Context(), http.MethodPost, "/content/git-create-branch", nil, strings.NewReader(string(reqBody)))
 	if err != nil {
 		log.Printf("GitCreateBranchSession: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3888,10 +4493,14 @@ func GitCreateBranchSession(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GitCreateBranchSession: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // GitListBranchesSession lists all remote branches
@@ -3907,33 +4516,23 @@ func GitListBranchesSession(c *gin.Context) {
 
 	absPath := fmt.Sprintf("/sessions/%s/workspace/%s", session, relativePath)
 
-	serviceName := fmt.Sprintf("temp-content-%s", session)
-	k8sClt, _ := GetK8sClientsForRequest(c)
-	if k8sClt == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
+	content, ok := resolveContentEndpoint(c, project, session, false)
+	if !ok {
 		return
 	}
-	if _, err := k8sClt.CoreV1().Services(project).Get(c.Request.Context(), serviceName, v1.GetOptions{}); err != nil {
-		serviceName = fmt.Sprintf("ambient-content-%s", session)
-	}
-
-	endpoint := fmt.Sprintf("http://%s.%s.svc:8080/content/git-list-branches?path=%s",
-		serviceName, project, url.QueryEscape(absPath))
 
-	req, err := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, endpoint, nil)
+	req, err := content.newRequest(c.Request.Context(), http.MethodGet, "/content/git-list-branches", url.Values{"path": {absPath}}, nil)
 	if err != nil {
 		log.Printf("GitListBranchesSession: failed to create HTTP request: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create request"})
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to create request", nil)
 		return
 	}
-	if v := c.GetHeader("Authorization"); v != "" {
-		req.Header.Set("Authorization", v)
-	}
+	forwardCallerToken(c, req.Header)
 
-	resp, err := http.DefaultClient.Do(req)
+	content.sign(req.Header)
+	resp, err := httpclient.Do(req, httpclient.Push)
 	if err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "content service unavailable"})
+		respondUpstreamUnavailable(c, "Content service unavailable")
 		return
 	}
 	defer resp.Body.Close()
@@ -3941,10 +4540,14 @@ func GitListBranchesSession(c *gin.Context) {
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("GitListBranchesSession: failed to read response body: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read response from content service"})
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, bodyBytes, content.PodSpawned)
 		return
 	}
-	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), bodyBytes)
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), withPodSpawned(bodyBytes, content.PodSpawned))
 }
 
 // NOTE: autoTriggerInitialPrompt removed - runner handles INITIAL_PROMPT auto-execution
@@ -3,12 +3,15 @@
 package handlers
 
 import (
+	"ambient-code-backend/k8s"
 	"ambient-code-backend/tests/config"
 	test_constants "ambient-code-backend/tests/constants"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"ambient-code-backend/tests/logger"
@@ -18,11 +21,20 @@ import (
 	"github.com/gin-gonic/gin"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	authnv1 "k8s.io/api/authentication/v1"
+	authzv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
@@ -223,6 +235,153 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 			})
 		})
 
+		Context("When filtering by user and model", func() {
+			// createAs renders a create request as userID and stores it; CreateSession names sessions by
+			// the current second, so several creates in one spec would collide
+			createAs := func(name, userID string, req types.CreateAgenticSessionRequest) {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", nil)
+				httpUtils.SetUserContext(userID, userID, userID)
+				Expect(validateCreateSessionRequest(&req)).To(Succeed())
+				obj := &unstructured.Unstructured{Object: buildSessionObject(context, testNamespace, name, &req)}
+				_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Create(ctx, obj, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			listAs := func(userID, query string) []interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions?"+query, nil)
+				httpUtils.SetUserContext(userID, userID, userID)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				ListSessions(context)
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				items, _ := response["items"].([]interface{})
+				return items
+			}
+
+			BeforeEach(func() {
+				createAs("alice-"+randomName, "alice@example.com", types.CreateAgenticSessionRequest{LLMSettings: &types.LLMSettings{Model: "claude-sonnet-4@20250514"}})
+				createAs("bob-"+randomName, "bob@example.com", types.CreateAgenticSessionRequest{LLMSettings: &types.LLMSettings{Model: "claude-opus-4"}})
+				// Created before the labels existed
+				createTestSession("legacy-"+randomName, testNamespace, k8sUtils)
+			})
+
+			It("Should label created sessions with the sanitized user and model", func() {
+				list, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).List(ctx, v1.ListOptions{LabelSelector: sessionUserLabel})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list.Items).To(HaveLen(2))
+				for _, item := range list.Items {
+					Expect(item.GetLabels()).To(Or(
+						And(HaveKeyWithValue(sessionUserLabel, "alice-example.com"), HaveKeyWithValue(sessionModelLabel, "claude-sonnet-4-20250514")),
+						And(HaveKeyWithValue(sessionUserLabel, "bob-example.com"), HaveKeyWithValue(sessionModelLabel, "claude-opus-4")),
+					))
+				}
+			})
+
+			It("Should return only the caller's sessions for user=me", func() {
+				items := listAs("alice@example.com", "user=me")
+
+				Expect(items).To(HaveLen(1))
+				spec := items[0].(map[string]interface{})["spec"].(map[string]interface{})
+				Expect(spec["userContext"].(map[string]interface{})["userId"]).To(Equal("alice@example.com"))
+			})
+
+			It("Should combine user and model filters", func() {
+				Expect(listAs("alice@example.com", "user=bob@example.com&model=claude-opus-4")).To(HaveLen(1))
+				Expect(listAs("alice@example.com", "user=bob@example.com&model=claude-sonnet-4@20250514")).To(BeEmpty())
+				Expect(listAs("alice@example.com", "")).To(HaveLen(3))
+			})
+
+			It("Should not let clients set the identity labels", func() {
+				createAs("mallory-"+randomName, "mallory", types.CreateAgenticSessionRequest{Labels: map[string]string{sessionUserLabel: "bob-example.com"}})
+
+				Expect(listAs("bob@example.com", "user=me")).To(HaveLen(1))
+				Expect(listAs("mallory", "user=me")).To(HaveLen(1))
+			})
+		})
+
+		Context("When sorting", func() {
+			// namesFor lists the sessions with query and returns their names in response order
+			namesFor := func(query string) []string {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions?"+query, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				ListSessions(context)
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response struct {
+					Items []types.AgenticSession `json:"items"`
+				}
+				httpUtils.GetResponseJSON(&response)
+				names := []string{}
+				for _, item := range response.Items {
+					names = append(names, getSessionName(item))
+				}
+				return names
+			}
+
+			BeforeEach(func() {
+				for _, s := range []struct{ name, created, displayName, completed string }{
+					{"sort-a", "2026-01-01T10:00:00Z", "Zebra", "2026-01-01T12:00:00Z"},
+					{"sort-b", "2026-01-03T10:00:00Z", "apple", ""},
+					{"sort-c", "2026-01-02T10:00:00Z", "", "2026-01-02T11:00:00Z"},
+					{"sort-d", "2026-01-02T10:00:00Z", "Mango", ""},
+				} {
+					created, err := time.Parse(time.RFC3339, s.created)
+					Expect(err).NotTo(HaveOccurred())
+					obj := createTestSession(s.name, testNamespace, k8sUtils)
+					obj.SetCreationTimestamp(v1.NewTime(created))
+					unstructured.SetNestedField(obj.Object, s.displayName, "spec", "displayName")
+					if s.completed != "" {
+						unstructured.SetNestedField(obj.Object, s.completed, "status", "completionTime")
+					}
+					_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("Should default to newest first, breaking ties by name", func() {
+				Expect(namesFor("")).To(Equal([]string{"sort-b", "sort-c", "sort-d", "sort-a"}))
+				Expect(namesFor("sortBy=creationTimestamp&order=asc")).To(Equal([]string{"sort-a", "sort-c", "sort-d", "sort-b"}))
+			})
+
+			It("Should sort by completion time with running sessions last", func() {
+				Expect(namesFor("sortBy=completionTime")).To(Equal([]string{"sort-c", "sort-a", "sort-b", "sort-d"}))
+				Expect(namesFor("sortBy=completionTime&order=asc")).To(Equal([]string{"sort-a", "sort-c", "sort-b", "sort-d"}))
+			})
+
+			It("Should sort by display name A-Z, falling back to the name", func() {
+				Expect(namesFor("sortBy=displayName")).To(Equal([]string{"sort-b", "sort-d", "sort-c", "sort-a"}))
+				Expect(namesFor("sortBy=displayName&order=desc&limit=2")).To(Equal([]string{"sort-a", "sort-c"}))
+			})
+
+			It("Should reject unknown sort keys and orders", func() {
+				for _, query := range []string{"sortBy=name", "order=newest"} {
+					httpUtils = test_utils.NewHTTPTestUtils()
+					context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions?"+query, nil)
+					httpUtils.SetAuthHeader(testToken)
+					httpUtils.SetProjectContext(testNamespace)
+					ListSessions(context)
+					httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+				}
+			})
+		})
+
+		Context("When a user ID is too long for a label value", func() {
+			It("Should hash it into a valid, distinct value", func() {
+				long := strings.Repeat("a", 70)
+				v := sessionLabelValue(long)
+
+				Expect(len(v)).To(BeNumerically("<=", 63))
+				Expect(v).To(MatchRegexp(`^[a-z0-9]([-a-z0-9_.]*[a-z0-9])?$`))
+				Expect(sessionLabelValue(long + "b")).NotTo(Equal(v))
+				Expect(sessionLabelValue(long)).To(Equal(v))
+			})
+		})
+
 		Context("When accessing a different project", func() {
 			It("Should return empty list for unauthorized project (auth disabled in tests)", func() {
 				// Arrange
@@ -341,6 +500,510 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 			})
 		})
 
+		Context("When dryRun is requested", func() {
+			var dryRuns *dryRunRecordingClient
+
+			BeforeEach(func() {
+				dryRuns = &dryRunRecordingClient{Interface: DynamicClient}
+				original := DynamicClient
+				DynamicClient = dryRuns
+				DeferCleanup(func() { DynamicClient = original })
+			})
+
+			createSession := func(path string, body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", path, body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			It("Should render the session without persisting it and match a real create", func() {
+				sessionRequest := map[string]interface{}{
+					"initialPrompt": "Dry run prompt",
+					"repos": []interface{}{
+						map[string]interface{}{"url": "https://github.com/test/repo.git", "branch": "main"},
+					},
+					"timeout": 600,
+				}
+
+				preview := createSession("/api/projects/"+testNamespace+"/agentic-sessions?dryRun=true", sessionRequest)
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				Expect(preview["dryRun"]).To(BeTrue())
+				Expect(dryRuns.creates).To(Equal(1))
+
+				list, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).List(ctx, v1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+				previewName := preview["session"].(map[string]interface{})["metadata"].(map[string]interface{})["name"].(string)
+				_, err = k8sUtils.K8sClient.CoreV1().ServiceAccounts(testNamespace).Get(ctx, "ambient-session-"+previewName, v1.GetOptions{})
+				Expect(errors.IsNotFound(err)).To(BeTrue(), "Dry run must not provision a runner token")
+
+				created := createSession("/api/projects/"+testNamespace+"/agentic-sessions", sessionRequest)
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, created["name"].(string), v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				previewSpec, err := json.Marshal(preview["session"].(map[string]interface{})["spec"])
+				Expect(err).NotTo(HaveOccurred())
+				storedSpec, err := json.Marshal(stored.Object["spec"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(previewSpec)).To(Equal(string(storedSpec)))
+			})
+
+			It("Should reject invalid requests exactly like a real create", func() {
+				sessionRequest := map[string]interface{}{"initialPrompt": "x", "timeout": -1}
+
+				createSession("/api/projects/"+testNamespace+"/agentic-sessions?dryRun=true", sessionRequest)
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "timeout must not be negative")
+
+				createSession("/api/projects/"+testNamespace+"/agentic-sessions", sessionRequest)
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "timeout must not be negative")
+				Expect(dryRuns.creates).To(BeZero())
+			})
+		})
+
+		Context("When a runnerImage is requested", func() {
+			createWithImage := func(image string) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions",
+					map[string]interface{}{"initialPrompt": "x", "runnerImage": image})
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			seedAllowlist := func(patterns ...interface{}) {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec":       map[string]interface{}{"allowedRunnerImages": patterns},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			It("Should store an image that matches the project's allowlist", func() {
+				seedAllowlist("quay.io/myteam/runner:*")
+
+				created := createWithImage("quay.io/myteam/runner:pr-42")
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, created["name"].(string), v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				image, _, _ := unstructured.NestedString(stored.Object, "spec", "runnerImage")
+				Expect(image).To(Equal("quay.io/myteam/runner:pr-42"))
+			})
+
+			It("Should reject images outside the allowlist", func() {
+				seedAllowlist("quay.io/myteam/runner:*")
+
+				createWithImage("quay.io/myteam/other:pr-42")
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", `runner image "quay.io/myteam/other:pr-42" is not allowed in this project`)
+			})
+
+			It("Should reject any override when the project allows none", func() {
+				createWithImage("quay.io/myteam/runner:pr-42")
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "runner image overrides are not allowed in this project")
+			})
+		})
+
+		Context("When the API server rejects the create", func() {
+			createWith := func(hook func(obj *unstructured.Unstructured) error) map[string]interface{} {
+				DynamicClient = &createHookClient{Interface: DynamicClient, hook: hook}
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", map[string]interface{}{"initialPrompt": "x"})
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			It("Should return 403 when the caller may not create agenticsessions", func() {
+				createWith(func(obj *unstructured.Unstructured) error {
+					return errors.NewForbidden(sessionGVR.GroupResource(), "", fmt.Errorf("user cannot create agenticsessions"))
+				})
+
+				httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Insufficient permissions")
+				list, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).List(ctx, v1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list.Items).To(BeEmpty())
+			})
+
+			It("Should pick another name when a concurrent create took the generated one", func() {
+				var tried []string
+				created := createWith(func(obj *unstructured.Unstructured) error {
+					tried = append(tried, obj.GetName())
+					if len(tried) == 1 {
+						return errors.NewAlreadyExists(sessionGVR.GroupResource(), obj.GetName())
+					}
+					return nil
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(tried).To(HaveLen(2))
+				Expect(tried[0]).To(MatchRegexp(`^agentic-session-\d+$`))
+				Expect(created["name"]).To(Equal(tried[1]))
+				Expect(tried[1]).To(MatchRegexp("^" + tried[0] + `-[a-z0-9]{5}$`))
+				_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, tried[1], v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		Context("When the project has a concurrent session limit", func() {
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec":       map[string]interface{}{"maxConcurrentSessions": int64(1)},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				running := createTestSession("running-"+randomName, testNamespace, k8sUtils)
+				unstructured.SetNestedField(running.Object, "Running", "status", "phase")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, running, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			create := func(body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			It("Should reject a session over the limit with the current count and limit", func() {
+				create(map[string]interface{}{"initialPrompt": "x"})
+
+				errorObj := httpUtils.AssertErrorResponse(http.StatusTooManyRequests, "LimitExceeded", "Project is running 1 of 1 concurrent sessions")
+				Expect(errorObj["details"]).To(Equal(map[string]interface{}{"current": float64(1), "limit": float64(1)}))
+				Expect(httpUtils.GetResponseRecorder().Header().Get("Retry-After")).To(BeEmpty())
+			})
+
+			It("Should create the session in the Queued phase when queue is set", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x", "queue": true})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(created["queued"]).To(BeTrue())
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, created["name"].(string), v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				phase, _, _ := unstructured.NestedString(stored.Object, "status", "phase")
+				Expect(phase).To(Equal("Queued"))
+				queuedAt, _, _ := unstructured.NestedString(stored.Object, "status", "queuedAt")
+				Expect(queuedAt).NotTo(BeEmpty())
+			})
+
+			importSession := func(query string) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/import"+query, map[string]interface{}{
+					"kind":    types.SessionBundleKind,
+					"version": types.SessionBundleVersion,
+					"source":  map[string]interface{}{"project": "elsewhere", "name": "imported-" + randomName},
+					"spec":    map[string]interface{}{"initialPrompt": "x"},
+				})
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				ImportSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			cloneSession := func(body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				body["targetProject"] = testNamespace
+				body["newSessionName"] = "clone-" + randomName
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/running-"+randomName+"/clone", body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{{Key: "sessionName", Value: "running-" + randomName}}
+				CloneSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			storedPhase := func(name string) string {
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				phase, _, _ := unstructured.NestedString(stored.Object, "status", "phase")
+				return phase
+			}
+
+			It("Should reject an import over the limit, or queue it with queue=true", func() {
+				importSession("")
+				httpUtils.AssertErrorResponse(http.StatusTooManyRequests, "LimitExceeded", "Project is running 1 of 1 concurrent sessions")
+
+				imported := importSession("?queue=true")
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(imported["queued"]).To(BeTrue())
+				Expect(storedPhase(imported["name"].(string))).To(Equal("Queued"))
+			})
+
+			It("Should reject a clone over the limit, or queue it with queue set", func() {
+				original := GetOpenShiftProjectResource
+				GetOpenShiftProjectResource = k8s.GetOpenShiftProjectResource
+				DeferCleanup(func() { GetOpenShiftProjectResource = original })
+				_, err := k8sUtils.DynamicClient.Resource(GetOpenShiftProjectResource()).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "project.openshift.io/v1",
+					"kind":       "Project",
+					"metadata":   map[string]interface{}{"name": testNamespace, "labels": map[string]interface{}{"ambient-code.io/managed": "true"}},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				cloneSession(map[string]interface{}{})
+				httpUtils.AssertErrorResponse(http.StatusTooManyRequests, "LimitExceeded", "Project is running 1 of 1 concurrent sessions")
+
+				cloneSession(map[string]interface{}{"queue": true})
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedPhase("clone-" + randomName)).To(Equal("Queued"))
+			})
+
+			It("Should not count queued or finished sessions", func() {
+				running, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, "running-"+randomName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				unstructured.SetNestedField(running.Object, "Completed", "status", "phase")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, running, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				queued := createTestSession("queued-"+randomName, testNamespace, k8sUtils)
+				unstructured.SetNestedField(queued.Object, "Queued", "status", "phase")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, queued, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				created := create(map[string]interface{}{"initialPrompt": "x"})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(created).NotTo(HaveKeyWithValue("queued", true))
+			})
+		})
+
+		Context("When the project has session cost limits", func() {
+			create := func(body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			storedCostLimit := func(name string) interface{} {
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				limit, _, _ := unstructured.NestedFieldNoCopy(stored.Object, "spec", "costLimitUSD")
+				return limit
+			}
+
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec":       map[string]interface{}{"sessionCostLimitUSD": 5.0, "maxSessionCostLimitUSD": 20.0},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should apply the project default when the request sets no limit", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x"})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedCostLimit(created["name"].(string))).To(BeNumerically("==", 5))
+			})
+
+			It("Should keep a requested limit under the ceiling", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x", "costLimitUSD": 12.5})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedCostLimit(created["name"].(string))).To(BeNumerically("==", 12.5))
+			})
+
+			It("Should reject a limit above the ceiling", func() {
+				create(map[string]interface{}{"initialPrompt": "x", "costLimitUSD": 50})
+
+				errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "costLimitUSD must not exceed the project limit of 20")
+				Expect(errorObj["details"]).To(Equal(map[string]interface{}{"maxSessionCostLimitUSD": float64(20)}))
+			})
+
+			It("Should reject a limit that is not positive", func() {
+				create(map[string]interface{}{"initialPrompt": "x", "costLimitUSD": 0})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "costLimitUSD must be greater than 0")
+			})
+		})
+
+		Context("When the request sets a workspace size", func() {
+			create := func(body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			storedWorkspaceSize := func(name string) string {
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				size, _, _ := unstructured.NestedString(stored.Object, "spec", "workspaceSize")
+				return size
+			}
+
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec":       map[string]interface{}{"maxWorkspaceSize": "100Gi"},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should store a size under the project limit", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x", "workspaceSize": "50Gi"})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedWorkspaceSize(created["name"].(string))).To(Equal("50Gi"))
+			})
+
+			It("Should leave the size unset when the request has none", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x"})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedWorkspaceSize(created["name"].(string))).To(BeEmpty())
+			})
+
+			It("Should reject a size above the project limit", func() {
+				create(map[string]interface{}{"initialPrompt": "x", "workspaceSize": "200Gi"})
+
+				errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "workspaceSize must not exceed the project limit of 100Gi")
+				Expect(errorObj["details"]).To(Equal(map[string]interface{}{"maxWorkspaceSize": "100Gi"}))
+			})
+
+			It("Should reject a size that is not a storage quantity", func() {
+				create(map[string]interface{}{"initialPrompt": "x", "workspaceSize": "lots"})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "workspaceSize must be a positive storage quantity such as 50Gi")
+			})
+		})
+
+		Context("When the project has default repos", func() {
+			create := func(body map[string]interface{}) map[string]interface{} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			storedRepoURLs := func(name string) []string {
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				repos, _, _ := unstructured.NestedSlice(stored.Object, "spec", "repos")
+				urls := []string{}
+				for _, r := range repos {
+					urls = append(urls, r.(map[string]interface{})["url"].(string))
+				}
+				return urls
+			}
+
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec": map[string]interface{}{"defaultRepos": []interface{}{
+						map[string]interface{}{"url": "https://github.com/org/a.git", "branch": "main"},
+						map[string]interface{}{"url": "https://github.com/org/b"},
+					}},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should attach the default repos when the request has none", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x"})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedRepoURLs(created["name"].(string))).To(Equal([]string{"https://github.com/org/a.git", "https://github.com/org/b"}))
+			})
+
+			It("Should keep requested repos as they are without mergeDefaults", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x", "repos": []interface{}{
+					map[string]interface{}{"url": "https://github.com/org/c"},
+				}})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedRepoURLs(created["name"].(string))).To(Equal([]string{"https://github.com/org/c"}))
+			})
+
+			It("Should append the defaults not already requested with mergeDefaults", func() {
+				created := create(map[string]interface{}{"initialPrompt": "x", "mergeDefaults": true, "repos": []interface{}{
+					map[string]interface{}{"url": "https://github.com/org/c"},
+					map[string]interface{}{"url": "https://github.com/Org/A/"},
+				}})
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedRepoURLs(created["name"].(string))).To(Equal([]string{
+					"https://github.com/org/c", "https://github.com/Org/A/", "https://github.com/org/b",
+				}))
+			})
+		})
+
+		Context("When the project has chargeback labels", func() {
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec": map[string]interface{}{"chargebackLabels": map[string]interface{}{
+						"costCenter": "cc-42",
+						"bad/key":    "skipped",
+					}},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should label the session with them, replacing client values", func() {
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", map[string]interface{}{
+					"initialPrompt": "x",
+					"labels":        map[string]interface{}{"chargeback.ambient-code.io/costCenter": "spoofed", "chargeback.ambient-code.io/team": "spoofed", "team": "payments"},
+				})
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, response["name"].(string), v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetLabels()).To(HaveKeyWithValue("chargeback.ambient-code.io/costCenter", "cc-42"))
+				Expect(stored.GetLabels()).To(HaveKeyWithValue("team", "payments"))
+				Expect(stored.GetLabels()).NotTo(HaveKey("chargeback.ambient-code.io/team"))
+				Expect(stored.GetLabels()).NotTo(HaveKey("chargeback.ambient-code.io/bad/key"))
+			})
+		})
+
 		Context("When creating session with edge case data", func() {
 			It("Should handle empty initial prompt", func() {
 				// Arrange
@@ -405,26 +1068,167 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 				// Assert - handler currently accepts invalid URLs (validation at runtime)
 				httpUtils.AssertHTTPStatus(http.StatusCreated)
 			})
-		})
-	})
-
-	Describe("GetSession", func() {
-		var sessionName string
 
-		BeforeEach(func() {
-			sessionName = testSession
-			createTestSession(sessionName, testNamespace, k8sUtils)
-		})
+			It("Should reject repositories without a URL", func() {
+				sessionRequest := map[string]interface{}{
+					"initialPrompt": "Test prompt",
+					"repos":         []interface{}{map[string]interface{}{"url": ""}},
+				}
 
-		Context("When session exists", func() {
-			It("Should return session details", func() {
-				// Arrange
-				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s", testNamespace, sessionName)
-				context := httpUtils.CreateTestGinContext("GET", path, nil)
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", sessionRequest)
 				httpUtils.SetAuthHeader(testToken)
 				httpUtils.SetProjectContext(testNamespace)
-				context.Params = gin.Params{
-					{Key: "sessionName", Value: sessionName},
+
+				CreateSession(context)
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, string(ErrorKindValidation), "repos[0].url is required")
+			})
+		})
+
+		Context("When creating session with a priority", func() {
+			createWithPriority := func(priority interface{}) {
+				sessionRequest := map[string]interface{}{"initialPrompt": "Test prompt"}
+				if priority != nil {
+					sessionRequest["priority"] = priority
+				}
+				context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", sessionRequest)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				CreateSession(context)
+			}
+
+			storedPriority := func() string {
+				var response map[string]interface{}
+				httpUtils.GetResponseJSON(&response)
+				name, _ := response["name"].(string)
+				obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				priority, _, _ := unstructured.NestedString(obj.Object, "spec", "priority")
+				return priority
+			}
+
+			It("Should default priority to normal", func() {
+				createWithPriority(nil)
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedPriority()).To(Equal("normal"))
+			})
+
+			It("Should accept a valid priority case-insensitively", func() {
+				createWithPriority("High")
+
+				httpUtils.AssertHTTPStatus(http.StatusCreated)
+				Expect(storedPriority()).To(Equal("high"))
+			})
+
+			It("Should reject an unknown priority with 400 Bad Request", func() {
+				createWithPriority("urgent")
+
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+			})
+		})
+	})
+
+	Describe("Large prompts", func() {
+		createSession := func(prompt string) (int, map[string]interface{}) {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			context := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", map[string]interface{}{"initialPrompt": prompt})
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			CreateSession(context)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return httpUtils.GetResponseRecorder().Code, response
+		}
+
+		It("Should store a prompt over the inline limit in a ConfigMap and resolve it on request", func() {
+			prompt := strings.Repeat("p", 500*1024)
+			code, response := createSession(prompt)
+			Expect(code).To(Equal(http.StatusCreated))
+			name := response["name"].(string)
+
+			item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, name, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			spec := item.Object["spec"].(map[string]interface{})
+			Expect(spec).NotTo(HaveKey("initialPrompt"))
+			Expect(spec["promptRef"]).To(Equal(map[string]interface{}{"configMapName": "ambient-prompt-" + name, "key": "prompt"}))
+
+			cm, err := k8sUtils.K8sClient.CoreV1().ConfigMaps(testNamespace).Get(ctx, "ambient-prompt-"+name, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cm.Data["prompt"]).To(Equal(prompt))
+			Expect(cm.OwnerReferences).To(HaveLen(1))
+			Expect(cm.OwnerReferences[0].Name).To(Equal(name))
+
+			for _, query := range []string{"", "?resolvePrompt=true"} {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				context := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/agentic-sessions/"+name+query, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{{Key: "sessionName", Value: name}}
+				GetSession(context)
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				var session types.AgenticSession
+				httpUtils.GetResponseJSON(&session)
+				Expect(session.Spec.PromptRef).NotTo(BeNil())
+				if query == "" {
+					Expect(session.Spec.InitialPrompt).To(BeEmpty())
+				} else {
+					Expect(session.Spec.InitialPrompt).To(Equal(prompt))
+				}
+			}
+		})
+
+		It("Should keep prompts within the limit inline", func() {
+			GinkgoT().Setenv("MAX_INLINE_PROMPT_BYTES", "1024")
+			code, response := createSession(strings.Repeat("p", 1024))
+			Expect(code).To(Equal(http.StatusCreated))
+
+			item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, response["name"].(string), v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(item.Object["spec"]).To(HaveKey("initialPrompt"))
+			Expect(item.Object["spec"]).NotTo(HaveKey("promptRef"))
+		})
+
+		It("Should reject prompts too large for a ConfigMap", func() {
+			code, response := createSession(strings.Repeat("p", maxStoredPromptBytes+1))
+			Expect(code).To(Equal(http.StatusBadRequest))
+			Expect(response["error"].(map[string]interface{})["message"]).To(ContainSubstring("initialPrompt must not exceed"))
+		})
+
+		It("Should not resolve a promptRef pointing at another ConfigMap", func() {
+			_, err := k8sUtils.K8sClient.CoreV1().ConfigMaps(testNamespace).Create(ctx, &corev1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{Name: "other-config", Namespace: testNamespace},
+				Data:       map[string]string{"secret": "value"},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			session := &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "s1", "namespace": testNamespace},
+				"spec":     map[string]interface{}{"promptRef": map[string]interface{}{"configMapName": "other-config", "key": "secret"}},
+			}}
+
+			_, err = resolveSessionPrompt(ctx, k8sUtils.K8sClient, session)
+			Expect(err).To(MatchError(ContainSubstring("does not belong to session s1")))
+		})
+	})
+
+	Describe("GetSession", func() {
+		var sessionName string
+
+		BeforeEach(func() {
+			sessionName = testSession
+			createTestSession(sessionName, testNamespace, k8sUtils)
+		})
+
+		Context("When session exists", func() {
+			It("Should return session details", func() {
+				// Arrange
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s", testNamespace, sessionName)
+				context := httpUtils.CreateTestGinContext("GET", path, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{
+					{Key: "sessionName", Value: sessionName},
 				}
 
 				// Act
@@ -449,6 +1253,58 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 			})
 		})
 
+		Context("When session has environment variables", func() {
+			BeforeEach(func() {
+				item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				unstructured.SetNestedStringMap(item.Object, map[string]string{
+					"API_TOKEN": "super-secret",
+					"LOG_LEVEL": "debug",
+				}, "spec", "environmentVariables")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, item, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				k8sUtils.SSARAllowedFunc = nil
+			})
+
+			getSession := func(query string) types.AgenticSession {
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s%s", testNamespace, sessionName, query)
+				context := httpUtils.CreateTestGinContext("GET", path, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{
+					{Key: "sessionName", Value: sessionName},
+				}
+				GetSession(context)
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response types.AgenticSession
+				httpUtils.GetResponseJSON(&response)
+				return response
+			}
+
+			It("Should mask sensitive values by default", func() {
+				response := getSession("")
+				Expect(response.Spec.EnvironmentVariables["API_TOKEN"]).To(Equal("***"))
+				Expect(response.Spec.EnvironmentVariables["LOG_LEVEL"]).To(Equal("debug"))
+			})
+
+			It("Should reveal values for admins passing revealEnv=true", func() {
+				response := getSession("?revealEnv=true")
+				Expect(response.Spec.EnvironmentVariables["API_TOKEN"]).To(Equal("super-secret"))
+			})
+
+			It("Should keep values masked for non-admins passing revealEnv=true", func() {
+				k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool {
+					ssar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SelfSubjectAccessReview)
+					return ssar.Spec.ResourceAttributes.Resource != "rolebindings"
+				}
+				response := getSession("?revealEnv=true")
+				Expect(response.Spec.EnvironmentVariables["API_TOKEN"]).To(Equal("***"))
+			})
+		})
+
 		Context("When session does not exist", func() {
 			It("Should return 404 Not Found", func() {
 				// Arrange
@@ -470,6 +1326,1158 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 		})
 	})
 
+	Describe("UpdateSession", func() {
+		var sessionName string
+
+		BeforeEach(func() {
+			sessionName = testSession
+			created := createTestSession(sessionName, testNamespace, k8sUtils)
+			unstructured.SetNestedField(created.Object, "Running", "status", "phase")
+			_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, created, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		updateSession := func(body map[string]interface{}) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s", testNamespace, sessionName)
+			context := httpUtils.CreateTestGinContext("PUT", path, body)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{
+				{Key: "sessionName", Value: sessionName},
+			}
+			UpdateSession(context)
+		}
+
+		Context("When session is Running", func() {
+			It("Should reject prompt changes with 409 Conflict", func() {
+				updateSession(map[string]interface{}{"initialPrompt": "A different prompt"})
+
+				errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict",
+					"spec.initialPrompt cannot be changed after the session has started (current phase: Running)")
+				details := errorObj["details"].(map[string]interface{})
+				Expect(details["field"]).To(Equal("initialPrompt"))
+				Expect(details["phase"]).To(Equal("Running"))
+			})
+
+			It("Should reject llmSettings changes with 409 Conflict", func() {
+				updateSession(map[string]interface{}{"llmSettings": map[string]interface{}{"model": "other-model"}})
+
+				httpUtils.AssertHTTPStatus(http.StatusConflict)
+			})
+
+			It("Should allow displayName changes", func() {
+				updateSession(map[string]interface{}{"displayName": "Renamed session"})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response types.AgenticSession
+				httpUtils.GetResponseJSON(&response)
+				Expect(response.Spec.DisplayName).To(Equal("Renamed session"))
+			})
+
+			It("Should allow re-sending the unchanged prompt", func() {
+				updateSession(map[string]interface{}{
+					"initialPrompt": "Test prompt for " + sessionName,
+					"displayName":   "Renamed session",
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+			})
+
+			It("Should mask sensitive env values in the response", func() {
+				item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				unstructured.SetNestedStringMap(item.Object, map[string]string{"API_TOKEN": "super-secret"}, "spec", "environmentVariables")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, item, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				updateSession(map[string]interface{}{"displayName": "Renamed session"})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				var response types.AgenticSession
+				httpUtils.GetResponseJSON(&response)
+				Expect(response.Spec.EnvironmentVariables["API_TOKEN"]).To(Equal("***"))
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				env, _, _ := unstructured.NestedStringMap(stored.Object, "spec", "environmentVariables")
+				Expect(env["API_TOKEN"]).To(Equal("super-secret"))
+			})
+
+			It("Should allow label changes", func() {
+				updateSession(map[string]interface{}{"labels": map[string]interface{}{"team": "platform"}})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetLabels()).To(HaveKeyWithValue("team", "platform"))
+				Expect(stored.GetLabels()).To(HaveKeyWithValue("test-framework", "ambient-code-backend"))
+			})
+		})
+
+		Context("When session is Pending", func() {
+			BeforeEach(func() {
+				GinkgoT().Setenv("MAX_INLINE_PROMPT_BYTES", "16")
+				item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				unstructured.SetNestedField(item.Object, "Pending", "status", "phase")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, item, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			storedPrompt := func() (string, error) {
+				cm, err := k8sUtils.K8sClient.CoreV1().ConfigMaps(testNamespace).Get(ctx, "ambient-prompt-"+sessionName, v1.GetOptions{})
+				if err != nil {
+					return "", err
+				}
+				return cm.Data["prompt"], nil
+			}
+
+			It("Should store a large prompt in the session's ConfigMap", func() {
+				updateSession(map[string]interface{}{"initialPrompt": strings.Repeat("a", 64)})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				Expect(storedPrompt()).To(Equal(strings.Repeat("a", 64)))
+			})
+
+			It("Should leave the stored prompt unchanged when a backend label is rejected", func() {
+				updateSession(map[string]interface{}{"initialPrompt": strings.Repeat("a", 64)})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				httpUtils = test_utils.NewHTTPTestUtils()
+				updateSession(map[string]interface{}{
+					"initialPrompt": strings.Repeat("b", 64),
+					"labels":        map[string]interface{}{sessionUserLabel: "someone-else"},
+				})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", fmt.Sprintf("labels.%s is managed by the backend", sessionUserLabel))
+				Expect(storedPrompt()).To(Equal(strings.Repeat("a", 64)))
+			})
+
+			It("Should not create the prompt ConfigMap when a backend label is rejected", func() {
+				updateSession(map[string]interface{}{
+					"initialPrompt": strings.Repeat("b", 64),
+					"labels":        map[string]interface{}{sessionUserLabel: "someone-else"},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+				_, err := storedPrompt()
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("Should not store a prompt for a session that has already started", func() {
+				item, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				unstructured.SetNestedField(item.Object, "Running", "status", "phase")
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, item, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				updateSession(map[string]interface{}{"initialPrompt": strings.Repeat("b", 64)})
+
+				httpUtils.AssertHTTPStatus(http.StatusConflict)
+				_, err = storedPrompt()
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("Should not store a prompt when the model is not available", func() {
+				updateSession(map[string]interface{}{
+					"initialPrompt": strings.Repeat("b", 64),
+					"llmSettings":   map[string]interface{}{"model": "not-a-model"},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+				_, err := storedPrompt()
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+
+			It("Should remove a newly stored prompt when the session update fails", func() {
+				current, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{sessionGVR: "AgenticSessionList"}, current)
+				fakeDyn.PrependReactor("update", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.NewConflict(sessionGVR.GroupResource(), sessionName, fmt.Errorf("the object has been modified"))
+				})
+				DynamicClient = fakeDyn
+
+				updateSession(map[string]interface{}{"initialPrompt": strings.Repeat("b", 64)})
+
+				httpUtils.AssertHTTPStatus(http.StatusConflict)
+				_, err = storedPrompt()
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("PatchSession", func() {
+		var sessionName string
+
+		BeforeEach(func() {
+			sessionName = testSession
+			created := createTestSession(sessionName, testNamespace, k8sUtils)
+			unstructured.SetNestedField(created.Object, "Running", "status", "phase")
+			_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, created, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		patchSession := func(body map[string]interface{}) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s", testNamespace, sessionName)
+			context := httpUtils.CreateTestGinContext("PATCH", path, body)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{
+				{Key: "sessionName", Value: sessionName},
+			}
+			PatchSession(context)
+		}
+
+		Context("When session is Running", func() {
+			It("Should add, change and remove labels", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{"team": "platform", "test-framework": nil},
+					},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetLabels()).To(Equal(map[string]string{"team": "platform"}))
+			})
+
+			It("Should reject frozen spec fields with 409 Conflict", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": "platform"}},
+					"spec":     map[string]interface{}{"initialPrompt": "A different prompt"},
+				})
+
+				httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict",
+					"spec.initialPrompt cannot be changed after the session has started (current phase: Running)")
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetLabels()).NotTo(HaveKey("team"))
+			})
+
+			It("Should reject changes to backend-managed labels", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{sessionUserLabel: "someone-else"}},
+				})
+
+				httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "metadata.labels.ambient-code.io/user is managed by the backend")
+			})
+
+			It("Should reject non-string label values", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"labels": map[string]interface{}{"team": 3}},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+			})
+		})
+
+		Context("Annotations", func() {
+			storedAnnotations := func() map[string]string {
+				stored, err := DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				return stored.GetAnnotations()
+			}
+
+			It("Should merge new annotations and remove those set to null", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]interface{}{"note": "first", "owner": "alice"}},
+				})
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+
+				httpUtils = test_utils.NewHTTPTestUtils()
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]interface{}{"note": nil}},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				Expect(storedAnnotations()).NotTo(HaveKey("note"))
+				Expect(storedAnnotations()).To(HaveKeyWithValue("owner", "alice"))
+			})
+
+			It("Should keep annotations written concurrently by the operator", func() {
+				current, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{sessionGVR: "AgenticSessionList"}, current)
+				// The handler reads the session, then the operator annotates it before the write
+				fakeDyn.PrependReactor("get", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					stale := current.DeepCopy()
+					fresh := current.DeepCopy()
+					fresh.SetAnnotations(map[string]string{"ambient-code.io/runner-token-secret": "ambient-runner-token-x"})
+					Expect(fakeDyn.Tracker().Update(sessionGVR, fresh, testNamespace)).To(Succeed())
+					return true, stale, nil
+				})
+				DynamicClient = fakeDyn
+
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]interface{}{"note": "hello"}},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				obj, err := fakeDyn.Tracker().Get(sessionGVR, testNamespace, sessionName)
+				Expect(err).NotTo(HaveOccurred())
+				annotations := obj.(*unstructured.Unstructured).GetAnnotations()
+				Expect(annotations).To(HaveKeyWithValue("note", "hello"))
+				Expect(annotations).To(HaveKeyWithValue("ambient-code.io/runner-token-secret", "ambient-runner-token-x"))
+			})
+
+			It("Should reject reserved annotation prefixes from non-admins", func() {
+				k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+				defer func() { k8sUtils.SSARAllowedFunc = nil }()
+
+				for _, key := range []string{"ambient-code.io/desired-phase", "vteam.ambient-code/parent-outcome"} {
+					httpUtils = test_utils.NewHTTPTestUtils()
+					patchSession(map[string]interface{}{
+						"metadata": map[string]interface{}{"annotations": map[string]interface{}{key: "Running"}},
+					})
+					httpUtils.AssertHTTPStatus(http.StatusForbidden)
+					Expect(storedAnnotations()).NotTo(HaveKey(key))
+				}
+			})
+
+			It("Should allow reserved annotation prefixes for project admins", func() {
+				patchSession(map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]interface{}{"ambient-code.io/note": "ok"}},
+				})
+
+				httpUtils.AssertHTTPStatus(http.StatusOK)
+				Expect(storedAnnotations()).To(HaveKeyWithValue("ambient-code.io/note", "ok"))
+			})
+		})
+
+		It("Should not apply a spec patch validated before the session started", func() {
+			pending, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_ = unstructured.SetNestedField(pending.Object, "Pending", "status", "phase")
+			pending.SetResourceVersion("1")
+			fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{sessionGVR: "AgenticSessionList"}, pending)
+			// The handler validates against Pending, then the operator starts the session before the write
+			fakeDyn.PrependReactor("get", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				running := pending.DeepCopy()
+				_ = unstructured.SetNestedField(running.Object, "Running", "status", "phase")
+				running.SetResourceVersion("2")
+				Expect(fakeDyn.Tracker().Update(sessionGVR, running, testNamespace)).To(Succeed())
+				return true, pending.DeepCopy(), nil
+			})
+			// Enforce the resourceVersion precondition of a merge patch like the API server does
+			var sentVersion interface{}
+			fakeDyn.PrependReactor("patch", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				var body map[string]interface{}
+				Expect(json.Unmarshal(action.(k8stesting.PatchAction).GetPatch(), &body)).To(Succeed())
+				meta, _ := body["metadata"].(map[string]interface{})
+				sentVersion = meta["resourceVersion"]
+				if sentVersion != nil && sentVersion != "2" {
+					return true, nil, errors.NewConflict(schema.GroupResource{Group: sessionGVR.Group, Resource: sessionGVR.Resource}, sessionName, fmt.Errorf("the object has been modified"))
+				}
+				return false, nil, nil
+			})
+			DynamicClient = fakeDyn
+
+			patchSession(map[string]interface{}{"spec": map[string]interface{}{"initialPrompt": "A different prompt"}})
+
+			httpUtils.AssertHTTPStatus(http.StatusConflict)
+			Expect(sentVersion).To(Equal("1"))
+			obj, err := fakeDyn.Tracker().Get(sessionGVR, testNamespace, sessionName)
+			Expect(err).NotTo(HaveOccurred())
+			prompt, _, _ := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "initialPrompt")
+			Expect(prompt).NotTo(Equal("A different prompt"))
+		})
+
+		It("Should merge nested spec objects instead of replacing them", func() {
+			unrunning, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_ = unstructured.SetNestedField(unrunning.Object, "Pending", "status", "phase")
+			_ = unstructured.SetNestedStringMap(unrunning.Object, map[string]string{"A": "1", "B": "2"}, "spec", "environmentVariables")
+			_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, unrunning, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			patchSession(map[string]interface{}{
+				"spec": map[string]interface{}{"environmentVariables": map[string]interface{}{"A": nil, "C": "3"}},
+			})
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			env, _, _ := unstructured.NestedStringMap(stored.Object, "spec", "environmentVariables")
+			Expect(env).To(Equal(map[string]string{"B": "2", "C": "3"}))
+		})
+	})
+
+	Describe("Session artifacts", func() {
+		BeforeEach(func() {
+			createTestSession(testSession, testNamespace, k8sUtils)
+		})
+
+		publishArtifacts := func(body map[string]interface{}) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/artifacts", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("PUT", path, body)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{
+				{Key: "sessionName", Value: testSession},
+			}
+			PublishSessionArtifacts(context)
+		}
+
+		It("Should list a published artifact with a workspace download URL", func() {
+			publishArtifacts(map[string]interface{}{
+				"artifacts": []interface{}{
+					map[string]interface{}{"name": "Report", "path": "/workspace/out/report v1.md", "contentType": "text/markdown", "sizeBytes": 42},
+				},
+			})
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/artifacts", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("GET", path, nil)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{
+				{Key: "sessionName", Value: testSession},
+			}
+			ListSessionArtifacts(context)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var resp struct {
+				Items []types.SessionArtifact `json:"items"`
+			}
+			httpUtils.GetResponseJSON(&resp)
+			Expect(resp.Items).To(HaveLen(1))
+			Expect(resp.Items[0].Path).To(Equal("out/report v1.md"))
+			Expect(resp.Items[0].SizeBytes).To(Equal(int64(42)))
+			Expect(resp.Items[0].CreatedAt).NotTo(BeEmpty())
+			Expect(resp.Items[0].DownloadURL).To(Equal(fmt.Sprintf(
+				"/api/projects/%s/agentic-sessions/%s/workspace/out/report%%20v1.md", testNamespace, testSession)))
+		})
+
+		It("Should replace an artifact published again under the same path", func() {
+			publishArtifacts(map[string]interface{}{
+				"artifacts": []interface{}{map[string]interface{}{"name": "old", "path": "patch.diff"}},
+			})
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			publishArtifacts(map[string]interface{}{
+				"artifacts": []interface{}{map[string]interface{}{"name": "new", "path": "./patch.diff"}},
+			})
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+
+			obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			status, _, _ := unstructured.NestedMap(obj.Object, "status")
+			parsed := parseStatus(status)
+			Expect(parsed.Artifacts).To(HaveLen(1))
+			Expect(parsed.Artifacts[0].Name).To(Equal("new"))
+		})
+
+		It("Should reject paths outside the workspace with 400 Bad Request", func() {
+			for _, p := range []string{"../etc/passwd", "/etc/passwd", "a/../../b", "/workspace"} {
+				publishArtifacts(map[string]interface{}{
+					"artifacts": []interface{}{map[string]interface{}{"name": "x", "path": p}},
+				})
+				httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+			}
+		})
+	})
+
+	Describe("resolveContentEndpoint", func() {
+		var originalPollInterval time.Duration
+
+		BeforeEach(func() {
+			originalPollInterval = contentPodPollInterval
+			contentPodPollInterval = 10 * time.Millisecond
+			GinkgoT().Setenv("CONTENT_POD_READY_TIMEOUT_SECONDS", "1")
+		})
+
+		AfterEach(func() {
+			contentPodPollInterval = originalPollInterval
+		})
+
+		setPhase := func(phase string) {
+			created := createTestSession(testSession, testNamespace, k8sUtils)
+			unstructured.SetNestedField(created.Object, phase, "status", "phase")
+			_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, created, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		readyPod := func() *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: "temp-content-" + testSession, Namespace: testNamespace},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodRunning,
+					PodIP: "10.0.0.12",
+					Conditions: []corev1.PodCondition{
+						{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+					},
+				},
+			}
+		}
+
+		resolveWith := func(spawn bool) (contentEndpoint, bool) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/github/diff", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("GET", path, nil)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			return resolveContentEndpoint(context, testNamespace, testSession, spawn)
+		}
+		resolve := func() (contentEndpoint, bool) { return resolveWith(true) }
+
+		expectNoPodRequested := func() {
+			obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.GetAnnotations()).NotTo(HaveKey("ambient-code.io/temp-content-requested"))
+		}
+
+		It("Should use the per-job content service when it exists", func() {
+			setPhase("Running")
+			_, err := k8sUtils.K8sClient.CoreV1().Services(testNamespace).Create(ctx, &corev1.Service{
+				ObjectMeta: v1.ObjectMeta{Name: "ambient-content-" + testSession, Namespace: testNamespace},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			ce, ok := resolve()
+
+			Expect(ok).To(BeTrue())
+			Expect(ce.BaseURL).To(Equal(fmt.Sprintf("http://ambient-content-%s.%s.svc:8080", testSession, testNamespace)))
+			Expect(ce.PodSpawned).To(BeFalse())
+		})
+
+		It("Should reuse an already ready temp content pod", func() {
+			setPhase("Completed")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readyPod(), v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			ce, ok := resolve()
+
+			Expect(ok).To(BeTrue())
+			Expect(ce.BaseURL).To(Equal("http://10.0.0.12:8080"))
+			Expect(ce.PodSpawned).To(BeFalse())
+		})
+
+		It("Should return 503 without spawning a pod while the session is Running", func() {
+			setPhase("Running")
+
+			_, ok := resolve()
+
+			Expect(ok).To(BeFalse())
+			httpUtils.AssertHTTPStatus(http.StatusServiceUnavailable)
+			expectNoPodRequested()
+		})
+
+		for _, phase := range []string{"Pending", "Queued"} {
+			It("Should return 409 without spawning a pod while the session is "+phase, func() {
+				setPhase(phase)
+
+				_, ok := resolve()
+
+				Expect(ok).To(BeFalse())
+				errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Workspace not available")
+				Expect(errorObj["details"]).To(HaveKeyWithValue("phase", phase))
+				expectNoPodRequested()
+			})
+		}
+
+		It("Should not spawn a pod for status requests on a completed session", func() {
+			setPhase("Completed")
+
+			_, ok := resolveWith(false)
+
+			Expect(ok).To(BeFalse())
+			httpUtils.AssertErrorResponse(http.StatusServiceUnavailable, "UpstreamUnavailable", "Workspace not available")
+			expectNoPodRequested()
+		})
+
+		It("Should still use a ready temp content pod for status requests", func() {
+			setPhase("Completed")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readyPod(), v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			ce, ok := resolveWith(false)
+
+			Expect(ok).To(BeTrue())
+			Expect(ce.BaseURL).To(Equal("http://10.0.0.12:8080"))
+		})
+
+		It("Should request the temp content pod and report it as spawned once ready", func() {
+			setPhase("Completed")
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readyPod(), v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			ce, ok := resolve()
+
+			Expect(ok).To(BeTrue())
+			Expect(ce.PodSpawned).To(BeTrue())
+			Expect(ce.BaseURL).To(Equal("http://10.0.0.12:8080"))
+			obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.GetAnnotations()).To(HaveKeyWithValue("ambient-code.io/temp-content-requested", "true"))
+		})
+
+		Context("When the caller may only view sessions", func() {
+			BeforeEach(func() {
+				k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool {
+					ssar := action.(k8stesting.CreateAction).GetObject().(*authzv1.SelfSubjectAccessReview)
+					return ssar.Spec.ResourceAttributes.Verb != "update"
+				}
+			})
+
+			It("Should request a read-only temp content pod", func() {
+				setPhase("Completed")
+				go func() {
+					defer GinkgoRecover()
+					time.Sleep(50 * time.Millisecond)
+					pod := readyPod()
+					pod.Annotations = map[string]string{tempContentReadOnlyAnnotation: "true"}
+					_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, pod, v1.CreateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				}()
+
+				ce, ok := resolve()
+
+				Expect(ok).To(BeTrue())
+				Expect(ce.PodSpawned).To(BeTrue())
+				obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(obj.GetAnnotations()).To(HaveKeyWithValue("ambient-code.io/temp-content-requested", "true"))
+				Expect(obj.GetAnnotations()).To(HaveKeyWithValue(tempContentReadOnlyAnnotation, "true"))
+			})
+
+			It("Should not turn a writable pod read-only", func() {
+				setPhase("Completed")
+				obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				obj.SetAnnotations(map[string]string{"ambient-code.io/temp-content-requested": "true", tempContentReadOnlyAnnotation: "false"})
+				_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readyPod(), v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, ok := resolve()
+
+				Expect(ok).To(BeTrue())
+				obj, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(obj.GetAnnotations()).To(HaveKeyWithValue(tempContentReadOnlyAnnotation, "false"))
+			})
+		})
+
+		It("Should replace a read-only temp content pod when an editor needs the workspace", func() {
+			setPhase("Completed")
+			obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			obj.SetAnnotations(map[string]string{"ambient-code.io/temp-content-requested": "true", tempContentReadOnlyAnnotation: "true"})
+			_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, obj, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			readOnlyPod := readyPod()
+			readOnlyPod.Annotations = map[string]string{tempContentReadOnlyAnnotation: "true"}
+			_, err = k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readOnlyPod, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				defer GinkgoRecover()
+				// What the operator does once it sees the writable request
+				time.Sleep(50 * time.Millisecond)
+				Expect(k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Delete(ctx, readOnlyPod.Name, v1.DeleteOptions{})).To(Succeed())
+				pod := readyPod()
+				pod.Status.PodIP = "10.0.0.13"
+				_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, pod, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			ce, ok := resolve()
+
+			Expect(ok).To(BeTrue())
+			Expect(ce.PodSpawned).To(BeTrue())
+			Expect(ce.BaseURL).To(Equal("http://10.0.0.13:8080"))
+			obj, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(obj.GetAnnotations()).To(HaveKeyWithValue(tempContentReadOnlyAnnotation, "false"))
+		})
+
+		It("Should return 503 with the pod status when the pod never becomes ready", func() {
+			setPhase("Completed")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: "temp-content-" + testSession, Namespace: testNamespace},
+				Status: corev1.PodStatus{
+					Phase: corev1.PodPending,
+					ContainerStatuses: []corev1.ContainerStatus{{
+						Name:  "content",
+						State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "pull failed"}},
+					}},
+				},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := resolve()
+
+			Expect(ok).To(BeFalse())
+			errorObj := httpUtils.AssertErrorResponse(http.StatusServiceUnavailable, "UpstreamUnavailable",
+				"Content pod did not become ready within 1s")
+			details := errorObj["details"].(map[string]interface{})
+			Expect(details).To(HaveKey("podStatus"))
+			podStatus := details["podStatus"].(map[string]interface{})
+			Expect(podStatus["phase"]).To(Equal("Pending"))
+			Expect(podStatus["reason"]).To(Equal("ImagePullBackOff"))
+		})
+
+		It("Should include the pod's warning events when it never becomes ready", func() {
+			setPhase("Completed")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: "temp-content-" + testSession, Namespace: testNamespace},
+				Status:     corev1.PodStatus{Phase: corev1.PodPending},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			for i, ev := range []corev1.Event{
+				{Type: corev1.EventTypeNormal, Reason: "Scheduled", Message: "assigned"},
+				{Type: corev1.EventTypeWarning, Reason: "FailedAttachVolume", Message: "Multi-Attach error for volume"},
+			} {
+				ev.ObjectMeta = v1.ObjectMeta{Name: fmt.Sprintf("temp-content-%s.%d", testSession, i), Namespace: testNamespace}
+				ev.InvolvedObject = corev1.ObjectReference{Kind: "Pod", Name: "temp-content-" + testSession, Namespace: testNamespace}
+				ev.LastTimestamp = v1.Now()
+				_, err := k8sUtils.K8sClient.CoreV1().Events(testNamespace).Create(ctx, &ev, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			_, ok := resolve()
+
+			Expect(ok).To(BeFalse())
+			errorObj := httpUtils.AssertErrorResponse(http.StatusServiceUnavailable, "UpstreamUnavailable",
+				"Content pod did not become ready within 1s")
+			podStatus := errorObj["details"].(map[string]interface{})["podStatus"].(map[string]interface{})
+			Expect(podStatus["events"]).To(HaveLen(1))
+			Expect(podStatus["events"].([]interface{})[0]).To(HaveKeyWithValue("reason", "FailedAttachVolume"))
+		})
+
+		It("Should return 409 naming the pod that still mounts the workspace", func() {
+			setPhase("Stopped")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: testSession + "-runner", Namespace: testNamespace},
+				Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+					Name:         "workspace",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sessionPVCName(testSession)}},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := resolve()
+
+			Expect(ok).To(BeFalse())
+			errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict",
+				"Workspace is still in use by pod "+testSession+"-runner (Running); try again once it has finished")
+			details := errorObj["details"].(map[string]interface{})
+			Expect(details["claim"]).To(Equal(sessionPVCName(testSession)))
+			Expect(details["blockingPod"]).To(HaveKeyWithValue("phase", "Running"))
+			expectNoPodRequested()
+		})
+
+		It("Should ignore finished pods that mounted the workspace", func() {
+			setPhase("Completed")
+			_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+				ObjectMeta: v1.ObjectMeta{Name: testSession + "-runner", Namespace: testNamespace},
+				Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+					Name:         "workspace",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sessionPVCName(testSession)}},
+				}}},
+				Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			go func() {
+				defer GinkgoRecover()
+				time.Sleep(50 * time.Millisecond)
+				_, err := k8sUtils.K8sClient.CoreV1().Pods(testNamespace).Create(ctx, readyPod(), v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}()
+
+			_, ok := resolve()
+
+			Expect(ok).To(BeTrue())
+		})
+
+		It("Should add podSpawned to JSON object responses only when a pod was spawned", func() {
+			Expect(string(withPodSpawned([]byte(`{"ok":true}`), true))).To(MatchJSON(`{"ok":true,"podSpawned":true}`))
+			Expect(string(withPodSpawned([]byte(`{"ok":true}`), false))).To(Equal(`{"ok":true}`))
+			Expect(string(withPodSpawned([]byte(`diff --git a b`), true))).To(Equal(`diff --git a b`))
+		})
+	})
+
+	Describe("StartSession", func() {
+		Context("When session is Queued", func() {
+			It("Should return 409 Conflict", func() {
+				created := createTestSession(testSession, testNamespace, k8sUtils)
+				unstructured.SetNestedField(created.Object, "Queued", "status", "phase")
+				_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, created, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/start", testNamespace, testSession)
+				context := httpUtils.CreateTestGinContext("POST", path, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{
+					{Key: "sessionName", Value: testSession},
+				}
+
+				StartSession(context)
+
+				httpUtils.AssertHTTPStatus(http.StatusConflict)
+			})
+		})
+
+		Context("When the project is at its concurrent session limit", func() {
+			start := func(body map[string]interface{}) {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/start", testNamespace, testSession)
+				context := httpUtils.CreateTestGinContext("POST", path, body)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+				StartSession(context)
+			}
+
+			BeforeEach(func() {
+				_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "vteam.ambient-code/v1alpha1",
+					"kind":       "ProjectSettings",
+					"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+					"spec":       map[string]interface{}{"maxConcurrentSessions": int64(1)},
+				}}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				for name, phase := range map[string]string{"running-" + randomName: "Running", testSession: "Stopped"} {
+					session := createTestSession(name, testNamespace, k8sUtils)
+					unstructured.SetNestedField(session.Object, phase, "status", "phase")
+					_, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, session, v1.UpdateOptions{})
+					Expect(err).NotTo(HaveOccurred())
+				}
+			})
+
+			It("Should reject restarting a stopped session", func() {
+				start(nil)
+
+				httpUtils.AssertErrorResponse(http.StatusTooManyRequests, "LimitExceeded", "Project is running 1 of 1 concurrent sessions")
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetAnnotations()).NotTo(HaveKey("ambient-code.io/desired-phase"))
+			})
+
+			It("Should accept the start when queue is set", func() {
+				start(map[string]interface{}{"queue": true})
+
+				httpUtils.AssertHTTPStatus(http.StatusAccepted)
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetAnnotations()).To(HaveKeyWithValue("ambient-code.io/desired-phase", "Running"))
+			})
+		})
+
+		Context("When a temp content pod holds the workspace", func() {
+			var (
+				fakeK8s              *k8sfake.Clientset
+				originalPollInterval time.Duration
+			)
+
+			start := func() {
+				httpUtils = test_utils.NewHTTPTestUtils()
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/start", testNamespace, testSession)
+				context := httpUtils.CreateTestGinContext("POST", path, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+				StartSession(context)
+			}
+
+			BeforeEach(func() {
+				var ok bool
+				fakeK8s, ok = k8sUtils.K8sClient.(*k8sfake.Clientset)
+				Expect(ok).To(BeTrue())
+				originalPollInterval = workspaceReleasePollInterval
+				workspaceReleasePollInterval = 10 * time.Millisecond
+				GinkgoT().Setenv("WORKSPACE_RELEASE_TIMEOUT_SECONDS", "1")
+
+				session := createTestSession(testSession, testNamespace, k8sUtils)
+				unstructured.SetNestedField(session.Object, "Stopped", "status", "phase")
+				session.SetAnnotations(map[string]string{
+					"ambient-code.io/temp-content-requested":     "true",
+					"ambient-code.io/temp-content-last-accessed": time.Now().UTC().Format(time.RFC3339),
+				})
+				_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, session, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = fakeK8s.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+					ObjectMeta: v1.ObjectMeta{Name: "temp-content-" + testSession, Namespace: testNamespace},
+				}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = fakeK8s.CoreV1().PersistentVolumeClaims(testNamespace).Create(ctx, &corev1.PersistentVolumeClaim{
+					ObjectMeta: v1.ObjectMeta{Name: sessionPVCName(testSession), Namespace: testNamespace},
+					Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: "pv-" + testSession},
+				}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				workspaceReleasePollInterval = originalPollInterval
+			})
+
+			It("Should delete the temp content pod before continuing the session", func() {
+				start()
+
+				httpUtils.AssertHTTPStatus(http.StatusAccepted)
+				_, err := fakeK8s.CoreV1().Pods(testNamespace).Get(ctx, "temp-content-"+testSession, v1.GetOptions{})
+				Expect(errors.IsNotFound(err)).To(BeTrue())
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetAnnotations()).To(HaveKeyWithValue("vteam.ambient-code/parent-session-id", testSession))
+				Expect(stored.GetAnnotations()).NotTo(HaveKey("ambient-code.io/temp-content-requested"))
+			})
+
+			It("Should return 409 with Retry-After while the workspace volume is still attached", func() {
+				pvName := "pv-" + testSession
+				_, err := fakeK8s.StorageV1().VolumeAttachments().Create(ctx, &storagev1.VolumeAttachment{
+					ObjectMeta: v1.ObjectMeta{Name: "va-" + testSession},
+					Spec: storagev1.VolumeAttachmentSpec{
+						Attacher: "csi.example.com",
+						NodeName: "node-a",
+						Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &pvName},
+					},
+				}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+
+				start()
+
+				errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Session workspace is still in use; retry shortly")
+				Expect(httpUtils.GetResponseRecorder().Header().Get("Retry-After")).To(Equal("1"))
+				details := errorObj["details"].(map[string]interface{})
+				Expect(details["heldBy"]).To(Equal("volume attachment of " + pvName + " to node node-a"))
+				stored, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored.GetAnnotations()).NotTo(HaveKey("ambient-code.io/desired-phase"))
+			})
+		})
+	})
+
+	Describe("Session export and import", func() {
+		var targetNamespace string
+
+		BeforeEach(func() {
+			created := createTestSession(testSession, testNamespace, k8sUtils)
+			unstructured.SetNestedField(created.Object, "Exported session", "spec", "displayName")
+			unstructured.SetNestedField(created.Object, true, "spec", "autoPushOnComplete")
+			unstructured.SetNestedField(created.Object, "high", "spec", "priority")
+			unstructured.SetNestedStringMap(created.Object, map[string]string{
+				"API_TOKEN": "super-secret",
+				"LOG_LEVEL": "debug",
+			}, "spec", "environmentVariables")
+			unstructured.SetNestedMap(created.Object, map[string]interface{}{
+				"userId": "original-user", "displayName": "Original", "groups": []interface{}{},
+			}, "spec", "userContext")
+			created.SetAnnotations(map[string]string{
+				"team":                                   "platform",
+				"ambient-code.io/runner-token-secret":    "ambient-runner-token-x",
+				"ambient-code.io/temp-content-requested": "true",
+			})
+			unstructured.SetNestedField(created.Object, "Completed", "status", "phase")
+			unstructured.SetNestedField(created.Object, "2026-01-01T00:00:00Z", "status", "completionTime")
+			unstructured.SetNestedSlice(created.Object, []interface{}{
+				map[string]interface{}{"url": "https://github.com/test/repo.git", "branch": "main", "pushedSha": "abc123"},
+			}, "status", "reconciledRepos")
+			_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, created, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			targetNamespace = "test-import-" + randomName
+			_, err = k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+				ObjectMeta: v1.ObjectMeta{Name: targetNamespace},
+			}, v1.CreateOptions{})
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		AfterEach(func() {
+			_ = k8sUtils.K8sClient.CoreV1().Namespaces().Delete(ctx, targetNamespace, v1.DeleteOptions{})
+		})
+
+		exportBundle := func() types.SessionBundle {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/bundle", testNamespace, testSession)
+			context := httpUtils.CreateTestGinContext("GET", path, nil)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(testNamespace)
+			context.Params = gin.Params{{Key: "sessionName", Value: testSession}}
+
+			ExportSession(context)
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			var bundle types.SessionBundle
+			httpUtils.GetResponseJSON(&bundle)
+			return bundle
+		}
+
+		importBundle := func(namespace string, bundle interface{}) {
+			path := fmt.Sprintf("/api/projects/%s/agentic-sessions/import", namespace)
+			context := httpUtils.CreateTestGinContext("POST", path, bundle)
+			httpUtils.SetAuthHeader(testToken)
+			httpUtils.SetProjectContext(namespace)
+
+			ImportSession(context)
+		}
+
+		It("Should export a versioned bundle with secrets redacted", func() {
+			bundle := exportBundle()
+
+			Expect(bundle.Kind).To(Equal(types.SessionBundleKind))
+			Expect(bundle.Version).To(Equal(types.SessionBundleVersion))
+			Expect(bundle.Source).To(Equal(types.SessionBundleSource{Project: testNamespace, Name: testSession}))
+			Expect(bundle.Spec.DisplayName).To(Equal("Exported session"))
+			Expect(bundle.Spec.AutoPushOnComplete).To(HaveValue(BeTrue()))
+			Expect(bundle.Spec.EnvironmentVariables).To(Equal(map[string]string{"API_TOKEN": "***", "LOG_LEVEL": "debug"}))
+			Expect(bundle.Redacted).To(ConsistOf("spec.environmentVariables.API_TOKEN"))
+			Expect(bundle.Annotations).To(Equal(map[string]string{"team": "platform"}))
+			Expect(bundle.RepoStatuses).To(HaveLen(1))
+			Expect(bundle.RepoStatuses[0].PushedSHA).To(HaveValue(Equal("abc123")))
+			Expect(bundle.Result.Phase).To(Equal("Completed"))
+			Expect(httpUtils.GetResponseRecorder().Header().Get("Content-Disposition")).To(ContainSubstring(testSession + "-bundle.json"))
+		})
+
+		It("Should import into another project as an equivalent Pending session minus secrets", func() {
+			importBundle(targetNamespace, exportBundle())
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			Expect(response["name"]).To(Equal(testSession))
+			Expect(response["renamed"]).To(BeFalse())
+			Expect(response["omittedEnvironmentVariables"]).To(ConsistOf("API_TOKEN"))
+
+			imported, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(targetNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			phase, _, _ := unstructured.NestedString(imported.Object, "status", "phase")
+			Expect(phase).To(Equal("Pending"))
+			spec := parseSpec(imported.Object["spec"].(map[string]interface{}))
+			Expect(spec.Project).To(Equal(targetNamespace))
+			Expect(spec.InitialPrompt).To(Equal("Test prompt for " + testSession))
+			Expect(spec.DisplayName).To(Equal("Exported session"))
+			Expect(spec.Priority).To(Equal("high"))
+			Expect(spec.Repos).To(HaveLen(1))
+			Expect(spec.EnvironmentVariables).To(Equal(map[string]string{"LOG_LEVEL": "debug"}))
+			Expect(spec.UserContext).NotTo(BeNil())
+			Expect(spec.UserContext.UserID).NotTo(Equal("original-user"))
+			autoPush, _, _ := unstructured.NestedBool(imported.Object, "spec", "autoPushOnComplete")
+			Expect(autoPush).To(BeTrue())
+			Expect(imported.GetAnnotations()).To(HaveKeyWithValue("team", "platform"))
+			Expect(imported.GetAnnotations()).To(HaveKeyWithValue("vteam.ambient-code/imported-from", testNamespace+"/"+testSession))
+			Expect(imported.GetAnnotations()).NotTo(HaveKey("ambient-code.io/runner-token-secret"))
+		})
+
+		It("Should rename on conflict like CloneSession", func() {
+			importBundle(testNamespace, exportBundle())
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			Expect(response["name"]).To(Equal(testSession + "-duplicate"))
+			Expect(response["renamed"]).To(BeTrue())
+
+			imported, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession+"-duplicate", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			displayName, _, _ := unstructured.NestedString(imported.Object, "spec", "displayName")
+			Expect(displayName).To(Equal("Exported session (Duplicate)"))
+		})
+
+		It("Should reject system annotations smuggled into a bundle", func() {
+			bundle := exportBundle()
+			bundle.Annotations["ambient-code.io/runner-token-secret"] = "someone-elses-secret"
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			imported, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(targetNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(imported.GetAnnotations()).NotTo(HaveKey("ambient-code.io/runner-token-secret"))
+		})
+
+		It("Should reject unsupported bundle versions", func() {
+			bundle := exportBundle()
+			bundle.Version = "v99"
+			importBundle(targetNamespace, bundle)
+
+			response := httpUtils.AssertErrorResponse(http.StatusBadRequest, string(ErrorKindValidation), "Unsupported session bundle version")
+			details := response["details"].(map[string]interface{})
+			Expect(details["supportedVersions"]).To(ConsistOf(types.SessionBundleVersion))
+		})
+
+		It("Should reject payloads that are not session bundles", func() {
+			importBundle(targetNamespace, map[string]interface{}{"kind": "AgenticSession", "version": "v1"})
+
+			httpUtils.AssertHTTPStatus(http.StatusBadRequest)
+		})
+
+		It("Should reject repos without a URL", func() {
+			bundle := exportBundle()
+			bundle.Spec.Repos = []types.SimpleRepo{{URL: " "}}
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, string(ErrorKindValidation), "repos[0].url is required")
+		})
+
+		It("Should apply CreateSession's validation to imported specs", func() {
+			bundle := exportBundle()
+			bundle.Spec.Timeout = -1
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, string(ErrorKindValidation), "timeout must not be negative")
+		})
+
+		It("Should carry the workspace size, cost limit and runner image into the target project", func() {
+			_, err := k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(targetNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "vteam.ambient-code/v1alpha1",
+				"kind":       "ProjectSettings",
+				"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": targetNamespace},
+				"spec":       map[string]interface{}{"allowedRunnerImages": []interface{}{"quay.io/myteam/runner:*"}},
+			}}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			bundle := exportBundle()
+			costLimit := 12.5
+			bundle.Spec.WorkspaceSize = "20Gi"
+			bundle.Spec.CostLimitUSD = &costLimit
+			bundle.Spec.RunnerImage = "quay.io/myteam/runner:pr-42"
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			imported, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(targetNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			spec := imported.Object["spec"].(map[string]interface{})
+			Expect(spec).To(HaveKeyWithValue("workspaceSize", "20Gi"))
+			Expect(spec).To(HaveKeyWithValue("costLimitUSD", 12.5))
+			Expect(spec).To(HaveKeyWithValue("runnerImage", "quay.io/myteam/runner:pr-42"))
+		})
+
+		It("Should apply the target project's runner image allowlist", func() {
+			bundle := exportBundle()
+			bundle.Spec.RunnerImage = "quay.io/myteam/runner:pr-42"
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "runner image overrides are not allowed in this project")
+		})
+
+		It("Should not apply fields CreateSession does not accept", func() {
+			bundle := exportBundle()
+			bundle.Spec.ResourceOverrides = &types.ResourceOverrides{CPU: "64", PriorityClass: "system-cluster-critical"}
+			bundle.Spec.ActiveWorkflow = &types.WorkflowSelection{GitURL: "https://github.com/attacker/workflows.git"}
+			importBundle(targetNamespace, bundle)
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			Expect(response["omittedFields"]).To(ConsistOf("spec.resourceOverrides", "spec.activeWorkflow"))
+
+			imported, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(targetNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			spec := imported.Object["spec"].(map[string]interface{})
+			Expect(spec).NotTo(HaveKey("resourceOverrides"))
+			Expect(spec).NotTo(HaveKey("activeWorkflow"))
+		})
+	})
+
 	Describe("DeleteSession", func() {
 		var sessionName string
 
@@ -500,6 +2508,37 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 
 				logger.Log("Session deleted successfully: %s", sessionName)
 			})
+
+			It("Should return 202 with the session while the operator drains its runner", func() {
+				current, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, sessionName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				current.SetFinalizers([]string{"ambient-code.io/drain-runner"})
+				fakeDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+					map[schema.GroupVersionResource]string{sessionGVR: "AgenticSessionList"}, current)
+				// Like the API server, a delete of an object with finalizers only marks it for deletion
+				fakeDyn.PrependReactor("delete", "agenticsessions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					marked := current.DeepCopy()
+					now := v1.Now()
+					marked.SetDeletionTimestamp(&now)
+					Expect(fakeDyn.Tracker().Update(sessionGVR, marked, testNamespace)).To(Succeed())
+					return true, nil, nil
+				})
+				DynamicClient = fakeDyn
+
+				path := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s", testNamespace, sessionName)
+				context := httpUtils.CreateTestGinContext("DELETE", path, nil)
+				httpUtils.SetAuthHeader(testToken)
+				httpUtils.SetProjectContext(testNamespace)
+				context.Params = gin.Params{{Key: "sessionName", Value: sessionName}}
+
+				DeleteSession(context)
+
+				httpUtils.AssertHTTPStatus(http.StatusAccepted)
+				var resp types.AgenticSession
+				httpUtils.GetResponseJSON(&resp)
+				Expect(resp.Metadata["deletionTimestamp"]).NotTo(BeNil())
+				Expect(resp.Metadata["finalizers"]).To(ConsistOf("ambient-code.io/drain-runner"))
+			})
 		})
 
 		Context("When deleting non-existent session", func() {
@@ -521,6 +2560,171 @@ var _ = Describe("Sessions Handler", Label(test_constants.LabelUnit, test_consta
 			})
 		})
 	})
+
+	Describe("provisionRunnerTokenForSession", func() {
+		var (
+			fakeK8s *k8sfake.Clientset
+			session *unstructured.Unstructured
+		)
+
+		BeforeEach(func() {
+			var ok bool
+			fakeK8s, ok = k8sUtils.K8sClient.(*k8sfake.Clientset)
+			Expect(ok).To(BeTrue())
+
+			session = createTestSession(testSession, testNamespace, k8sUtils)
+			session.SetUID("uid-current")
+			var err error
+			session, err = k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, session, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeK8s.PrependReactor("create", "serviceaccounts", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "token" {
+					return false, nil, nil
+				}
+				return true, &authnv1.TokenRequest{Status: authnv1.TokenRequestStatus{Token: "runner-token"}}, nil
+			})
+		})
+
+		It("Should record the token milestone in the session's startup timeline", func() {
+			session.SetCreationTimestamp(v1.NewTime(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)))
+			_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, session, v1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", nil)
+
+			Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+
+			obj, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Get(ctx, testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			status, _, _ := unstructured.NestedMap(obj.Object, "status")
+			timeline := parseStatus(status).Timeline
+			Expect(timeline).NotTo(BeNil())
+			Expect(*timeline.CreatedAt).To(Equal("2026-01-01T10:00:00Z"))
+			Expect(timeline.TokenProvisionedAt).NotTo(BeNil())
+			Expect(timeline.JobCreatedAt).To(BeNil())
+		})
+
+		It("Should delete what it created when a later step fails", func() {
+			fakeK8s.PrependReactor("create", "roles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.NewForbidden(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"}, "", fmt.Errorf("denied"))
+			})
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", nil)
+
+			err := provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)
+
+			Expect(err).To(MatchError(ContainSubstring("create Role")))
+			_, err = fakeK8s.CoreV1().ServiceAccounts(testNamespace).Get(ctx, "ambient-session-"+testSession, v1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue(), "ServiceAccount should have been rolled back")
+		})
+
+		It("Should keep pre-existing objects and re-own them from a deleted session of the same name", func() {
+			stale := []v1.OwnerReference{{APIVersion: "vteam.ambient-code/v1alpha1", Kind: "AgenticSession", Name: testSession, UID: "uid-previous"}}
+			_, err := fakeK8s.CoreV1().ServiceAccounts(testNamespace).Create(ctx, &corev1.ServiceAccount{
+				ObjectMeta: v1.ObjectMeta{Name: "ambient-session-" + testSession, Namespace: testNamespace, OwnerReferences: stale},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = fakeK8s.RbacV1().RoleBindings(testNamespace).Create(ctx, &rbacv1.RoleBinding{
+				ObjectMeta: v1.ObjectMeta{Name: "ambient-session-" + testSession + "-rb", Namespace: testNamespace, OwnerReferences: stale},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", nil)
+
+			Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+
+			sa, err := fakeK8s.CoreV1().ServiceAccounts(testNamespace).Get(ctx, "ambient-session-"+testSession, v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sa.OwnerReferences).To(HaveLen(1))
+			Expect(string(sa.OwnerReferences[0].UID)).To(Equal("uid-current"))
+			rb, err := fakeK8s.RbacV1().RoleBindings(testNamespace).Get(ctx, "ambient-session-"+testSession+"-rb", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(rb.OwnerReferences[0].UID)).To(Equal("uid-current"))
+			Expect(rb.Subjects).To(HaveLen(1))
+		})
+
+		Context("When the runner Role already exists", func() {
+			var roleName string
+
+			staleRules := []rbacv1.PolicyRule{{
+				APIGroups: []string{"vteam.ambient-code"},
+				Resources: []string{"agenticsessions"},
+				Verbs:     []string{"get", "list", "watch"},
+			}}
+			roleUpdates := func() int {
+				n := 0
+				for _, a := range fakeK8s.Actions() {
+					if a.GetVerb() == "update" && a.GetResource().Resource == "roles" {
+						n++
+					}
+				}
+				return n
+			}
+			upgradeEvents := func() int {
+				events, err := fakeK8s.CoreV1().Events(testNamespace).List(ctx, v1.ListOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				n := 0
+				for _, ev := range events.Items {
+					if ev.Reason == "RunnerRoleUpgraded" && ev.InvolvedObject.Name == testSession {
+						n++
+					}
+				}
+				return n
+			}
+
+			BeforeEach(func() {
+				roleName = "ambient-session-" + testSession + "-role"
+				owner := []v1.OwnerReference{{APIVersion: "vteam.ambient-code/v1alpha1", Kind: "AgenticSession", Name: testSession, UID: "uid-current"}}
+				_, err := fakeK8s.RbacV1().Roles(testNamespace).Create(ctx, &rbacv1.Role{
+					ObjectMeta: v1.ObjectMeta{Name: roleName, Namespace: testNamespace, OwnerReferences: owner},
+					Rules:      staleRules,
+				}, v1.CreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("Should upgrade an outdated Role exactly once and record the change", func() {
+				c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/"+testSession+"/start", nil)
+
+				Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+				Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+
+				role, err := fakeK8s.RbacV1().Roles(testNamespace).Get(ctx, roleName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(role.Rules).To(Equal(runnerRoleRules(testSession)))
+				Expect(role.Annotations).To(HaveKeyWithValue(runnerRoleVersionAnnotation, runnerRoleVersion()))
+				Expect(roleUpdates()).To(Equal(1))
+				Expect(upgradeEvents()).To(Equal(1))
+			})
+
+			It("Should leave a Role at the current version untouched", func() {
+				role, err := fakeK8s.RbacV1().Roles(testNamespace).Get(ctx, roleName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				role.Annotations = map[string]string{runnerRoleVersionAnnotation: runnerRoleVersion()}
+				_, err = fakeK8s.RbacV1().Roles(testNamespace).Update(ctx, role, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				fakeK8s.ClearActions()
+				c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/"+testSession+"/start", nil)
+
+				Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+
+				Expect(roleUpdates()).To(Equal(0))
+				Expect(upgradeEvents()).To(Equal(0))
+			})
+
+			It("Should keep the rules of sessions older than RUNNER_ROLE_FREEZE_AFTER_DAYS", func() {
+				GinkgoT().Setenv("RUNNER_ROLE_FREEZE_AFTER_DAYS", "7")
+				session.SetCreationTimestamp(v1.NewTime(time.Now().Add(-30 * 24 * time.Hour)))
+				_, err := k8sUtils.DynamicClient.Resource(sessionGVR).Namespace(testNamespace).Update(ctx, session, v1.UpdateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/"+testSession+"/start", nil)
+
+				Expect(provisionRunnerTokenForSession(c, K8sClient, DynamicClient, testNamespace, testSession)).To(Succeed())
+
+				role, err := fakeK8s.RbacV1().Roles(testNamespace).Get(ctx, roleName, v1.GetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(role.Rules).To(Equal(staleRules))
+				Expect(upgradeEvents()).To(Equal(0))
+			})
+		})
+	})
 })
 
 // Helper functions
@@ -571,3 +2775,68 @@ func createTestSession(name, namespace string, k8sUtils *test_utils.K8sTestUtils
 	}
 	return created
 }
+
+// dryRunRecordingClient emulates server-side dry run, which the fake dynamic client ignores: creates
+// with DryRun set are counted and echoed back without being stored
+type dryRunRecordingClient struct {
+	dynamic.Interface
+	creates int
+}
+
+func (d *dryRunRecordingClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &dryRunNamespaceableResource{NamespaceableResourceInterface: d.Interface.Resource(gvr), client: d}
+}
+
+type dryRunNamespaceableResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *dryRunRecordingClient
+}
+
+func (r *dryRunNamespaceableResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &dryRunResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), client: r.client}
+}
+
+type dryRunResource struct {
+	dynamic.ResourceInterface
+	client *dryRunRecordingClient
+}
+
+func (r *dryRunResource) Create(ctx context.Context, obj *unstructured.Unstructured, opts v1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if len(opts.DryRun) == 1 && opts.DryRun[0] == v1.DryRunAll {
+		r.client.creates++
+		return obj, nil
+	}
+	return r.ResourceInterface.Create(ctx, obj, opts, subresources...)
+}
+
+// createHookClient runs hook before every create; a non-nil error fails the create instead, as
+// an API server rejection would
+type createHookClient struct {
+	dynamic.Interface
+	hook func(obj *unstructured.Unstructured) error
+}
+
+func (h *createHookClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &createHookNamespaceableResource{NamespaceableResourceInterface: h.Interface.Resource(gvr), client: h}
+}
+
+type createHookNamespaceableResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *createHookClient
+}
+
+func (r *createHookNamespaceableResource) Namespace(ns string) dynamic.ResourceInterface {
+	return &createHookResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(ns), client: r.client}
+}
+
+type createHookResource struct {
+	dynamic.ResourceInterface
+	client *createHookClient
+}
+
+func (r *createHookResource) Create(ctx context.Context, obj *unstructured.Unstructured, opts v1.CreateOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	if err := r.client.hook(obj); err != nil {
+		return nil, err
+	}
+	return r.ResourceInterface.Create(ctx, obj, opts, subresources...)
+}
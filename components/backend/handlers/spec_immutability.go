@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// immutableSpecFields lists spec fields the runner reads only once at startup.
+// Changing them after the session has left Pending would make the CR diverge
+// from what the runner is actually doing, so they are frozen from then on.
+// displayName, interactive, labels and annotations remain mutable; timeout can
+// only grow afterwards, through ExtendSessionTimeout.
+var immutableSpecFields = []string{
+	"initialPrompt",
+	"promptRef",
+	"repos",
+	"mainRepoIndex",
+	"llmSettings",
+	"timeout",
+}
+
+// SpecImmutableError is returned when an update touches a frozen spec field.
+type SpecImmutableError struct {
+	Field string
+	Phase string
+}
+
+func (e *SpecImmutableError) Error() string {
+	return fmt.Sprintf("spec.%s cannot be changed after the session has started (current phase: %s)", e.Field, e.Phase)
+}
+
+// isSessionSpecLocked reports whether immutable spec fields are frozen for the
+// session, returning the current phase for error reporting.
+func isSessionSpecLocked(item *unstructured.Unstructured) (bool, string) {
+	if item == nil {
+		return false, ""
+	}
+	phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+	phase = strings.TrimSpace(phase)
+	if phase == "" || strings.EqualFold(phase, "Pending") {
+		return false, phase
+	}
+	return true, phase
+}
+
+// ValidateSpecMutation checks a proposed spec against the stored session and
+// returns a *SpecImmutableError naming the first frozen field that changed.
+// Fields that are re-sent with their current value are not treated as changes.
+func ValidateSpecMutation(item *unstructured.Unstructured, newSpec map[string]interface{}) error {
+	locked, phase := isSessionSpecLocked(item)
+	if !locked {
+		return nil
+	}
+	oldSpec, _, _ := unstructured.NestedMap(item.Object, "spec")
+	for _, field := range immutableSpecFields {
+		if !specValuesEqual(oldSpec[field], newSpec[field]) {
+			return &SpecImmutableError{Field: field, Phase: phase}
+		}
+	}
+	return nil
+}
+
+// specValuesEqual compares two unstructured values by their JSON encoding so
+// that numeric type differences (int vs int64 vs float64) do not register as changes.
+func specValuesEqual(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return string(aj) == string(bj)
+}
+
+// respondSpecMutationError writes the 409 for a ValidateSpecMutation failure
+func respondSpecMutationError(c *gin.Context, err error) {
+	var details gin.H
+	if immErr, ok := err.(*SpecImmutableError); ok {
+		details = gin.H{"field": immErr.Field, "phase": immErr.Phase}
+	}
+	respondError(c, http.StatusConflict, ErrorKindConflict, err.Error(), details)
+}
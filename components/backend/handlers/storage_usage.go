@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// workspacePVCPrefix names session workspace PVCs (ambient-workspace-<session>)
+	workspacePVCPrefix = "ambient-workspace-"
+	// defaultWorkspacePVCSize is the operator's workspace size for sessions without spec.workspaceSize
+	defaultWorkspacePVCSize = "5Gi"
+	// defaultStorageWarningPercent is the share of the storage quota above which creates warn
+	defaultStorageWarningPercent = 80
+)
+
+// storageWarningPercent returns the quota share (STORAGE_WARNING_PERCENT, default 80) above which
+// CreateSession adds a storageWarning
+func storageWarningPercent() int {
+	if raw := strings.TrimSpace(os.Getenv("STORAGE_WARNING_PERCENT")); raw != "" {
+		if pct, err := strconv.Atoi(raw); err == nil && pct > 0 {
+			return pct
+		}
+	}
+	return defaultStorageWarningPercent
+}
+
+// SessionStorage is one workspace PVC in a StorageUsage
+type SessionStorage struct {
+	Session   string `json:"session"`
+	PVCName   string `json:"pvcName"`
+	Requested string `json:"requested"`
+	// Capacity is what the volume provides, when the CSI driver reports it in the PVC status
+	Capacity string `json:"capacity,omitempty"`
+	Phase    string `json:"phase"`
+}
+
+// StorageQuota is the namespace ResourceQuota on requests.storage that is closest to its limit
+type StorageQuota struct {
+	Name        string  `json:"name"`
+	Hard        string  `json:"hard"`
+	Used        string  `json:"used"`
+	PercentUsed float64 `json:"percentUsed"`
+
+	hard resource.Quantity
+	used resource.Quantity
+}
+
+// StorageUsage is the storage consumed by a project's session workspaces
+type StorageUsage struct {
+	Project            string           `json:"project"`
+	WorkspaceRequested string           `json:"workspaceRequested"`
+	WorkspaceCapacity  string           `json:"workspaceCapacity"`
+	Quota              *StorageQuota    `json:"quota,omitempty"`
+	PercentUsed        *float64         `json:"percentUsed"`
+	WarningPercent     int              `json:"warningPercent"`
+	Sessions           []SessionStorage `json:"sessions"`
+}
+
+// percentOf returns used as a percentage of hard, to one decimal
+func percentOf(used, hard resource.Quantity) float64 {
+	if hard.Sign() <= 0 {
+		return 100
+	}
+	return math.Round(used.AsApproximateFloat64()/hard.AsApproximateFloat64()*1000) / 10
+}
+
+// isWorkspacePVC reports whether a PVC is a session workspace
+func isWorkspacePVC(pvc *corev1.PersistentVolumeClaim) bool {
+	return pvc.Labels["app"] == "ambient-workspace" || strings.HasPrefix(pvc.Name, workspacePVCPrefix)
+}
+
+// tightestStorageQuota returns the ResourceQuota on requests.storage with the least room left, or nil
+func tightestStorageQuota(quotas []corev1.ResourceQuota) *StorageQuota {
+	var tightest *StorageQuota
+	var tightestLeft int64
+	for _, q := range quotas {
+		hard, ok := q.Spec.Hard[corev1.ResourceRequestsStorage]
+		if !ok {
+			continue
+		}
+		used := q.Status.Used[corev1.ResourceRequestsStorage]
+		left := hard.Value() - used.Value()
+		if tightest != nil && left >= tightestLeft {
+			continue
+		}
+		tightestLeft = left
+		tightest = &StorageQuota{
+			Name:        q.Name,
+			Hard:        hard.String(),
+			Used:        used.String(),
+			PercentUsed: percentOf(used, hard),
+			hard:        hard,
+			used:        used,
+		}
+	}
+	return tightest
+}
+
+// projectStorageUsage sums the requested and provisioned sizes of a project's workspace PVCs and
+// compares them with the namespace storage quota. The quota's own used figure (all PVCs, as kubectl
+// describe quota shows it) gives percentUsed.
+func projectStorageUsage(ctx context.Context, k8s kubernetes.Interface, project string) (*StorageUsage, error) {
+	pvcs, err := k8s.CoreV1().PersistentVolumeClaims(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	quotas, err := k8s.CoreV1().ResourceQuotas(project).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &StorageUsage{Project: project, WarningPercent: storageWarningPercent(), Sessions: []SessionStorage{}}
+	requested := resource.Quantity{Format: resource.BinarySI}
+	capacity := resource.Quantity{Format: resource.BinarySI}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if !isWorkspacePVC(pvc) {
+			continue
+		}
+		entry := SessionStorage{
+			Session: strings.TrimPrefix(pvc.Name, workspacePVCPrefix),
+			PVCName: pvc.Name,
+			Phase:   string(pvc.Status.Phase),
+		}
+		if q, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			requested.Add(q)
+			entry.Requested = q.String()
+		}
+		if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			capacity.Add(q)
+			entry.Capacity = q.String()
+		}
+		usage.Sessions = append(usage.Sessions, entry)
+	}
+	sort.Slice(usage.Sessions, func(i, j int) bool { return usage.Sessions[i].Session < usage.Sessions[j].Session })
+	usage.WorkspaceRequested = requested.String()
+	usage.WorkspaceCapacity = capacity.String()
+
+	if quota := tightestStorageQuota(quotas.Items); quota != nil {
+		usage.Quota = quota
+		pct := quota.PercentUsed
+		usage.PercentUsed = &pct
+	}
+	return usage, nil
+}
+
+// sessionStorageWarning returns a warning when a new workspace of size (empty for the operator
+// default) would take the project's storage quota above STORAGE_WARNING_PERCENT, or "". Lookup
+// failures are logged and produce no warning.
+func sessionStorageWarning(ctx context.Context, k8s kubernetes.Interface, project, size string) string {
+	if strings.TrimSpace(size) == "" {
+		size = defaultWorkspacePVCSize
+	}
+	newPVC, err := resource.ParseQuantity(size)
+	if err != nil {
+		return ""
+	}
+	usage, err := projectStorageUsage(ctx, k8s, project)
+	if err != nil {
+		log.Printf("Could not check storage usage of project %s: %v", project, err)
+		return ""
+	}
+	if usage.Quota == nil {
+		return ""
+	}
+	projected := usage.Quota.used.DeepCopy()
+	projected.Add(newPVC)
+	pct := percentOf(projected, usage.Quota.hard)
+	if pct <= float64(usage.WarningPercent) {
+		return ""
+	}
+	return fmt.Sprintf("The new %s workspace brings storage to %s%% of the project quota %s (%s of %s)",
+		newPVC.String(), strconv.FormatFloat(pct, 'f', -1, 64), usage.Quota.Name, projected.String(), usage.Quota.Hard)
+}
+
+// GetProjectStorageUsage handles GET /api/projects/:projectName/storage-usage
+// Lists the project's session workspace PVCs (ambient-workspace-*) with their requested and, where
+// the CSI driver reports it, provisioned sizes, and the namespace ResourceQuota on requests.storage
+// closest to its limit. percentUsed is that quota's used/hard (null without a quota). Uses the
+// caller's token, so it needs list on PVCs and resourcequotas in the project.
+func GetProjectStorageUsage(c *gin.Context) {
+	project := c.GetString("project")
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	usage, err := projectStorageUsage(c.Request.Context(), reqK8s, project)
+	if err != nil {
+		log.Printf("Failed to compute storage usage of project %s: %v", project, err)
+		respondK8sError(c, err, "Project not found", "Failed to read storage usage")
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
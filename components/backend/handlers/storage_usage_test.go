@@ -0,0 +1,153 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Storage Usage", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	createPVC := func(name, requested, capacity string) {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(requested)},
+			}},
+			Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+		}
+		if capacity != "" {
+			pvc.Status.Capacity = corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(capacity)}
+		}
+		_, err := k8sUtils.K8sClient.CoreV1().PersistentVolumeClaims(testNamespace).Create(ctx, pvc, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createQuota := func(name, hard, used string) {
+		_, err := k8sUtils.K8sClient.CoreV1().ResourceQuotas(testNamespace).Create(ctx, &corev1.ResourceQuota{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: testNamespace},
+			Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourceRequestsStorage: resource.MustParse(hard)}},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourceRequestsStorage: resource.MustParse(hard)},
+				Used: corev1.ResourceList{corev1.ResourceRequestsStorage: resource.MustParse(used)},
+			},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+	})
+
+	Context("GetProjectStorageUsage", func() {
+		get := func() map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/projects/"+testNamespace+"/storage-usage", nil)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			GetProjectStorageUsage(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		It("Should sum workspace PVCs and report the tightest storage quota", func() {
+			createPVC("ambient-workspace-sess-a", "10Gi", "10Gi")
+			createPVC("ambient-workspace-sess-b", "20Gi", "")
+			createPVC("postgres-data", "5Gi", "5Gi")
+			createQuota("loose", "500Gi", "35Gi")
+			createQuota("storage", "50Gi", "35Gi")
+
+			response := get()
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(response["workspaceRequested"]).To(Equal("30Gi"))
+			Expect(response["workspaceCapacity"]).To(Equal("10Gi"))
+			Expect(response["percentUsed"]).To(BeNumerically("==", 70))
+			Expect(response["warningPercent"]).To(BeNumerically("==", 80))
+			quota := response["quota"].(map[string]interface{})
+			Expect(quota["name"]).To(Equal("storage"))
+			Expect(quota["hard"]).To(Equal("50Gi"))
+			Expect(quota["used"]).To(Equal("35Gi"))
+			sessions := response["sessions"].([]interface{})
+			Expect(sessions).To(HaveLen(2))
+			Expect(sessions[0].(map[string]interface{})["session"]).To(Equal("sess-a"))
+			Expect(sessions[1].(map[string]interface{})).NotTo(HaveKey("capacity"))
+		})
+
+		It("Should leave percentUsed null without a storage quota", func() {
+			createPVC("ambient-workspace-sess-a", "10Gi", "")
+
+			response := get()
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(response).To(HaveKeyWithValue("percentUsed", BeNil()))
+			Expect(response).NotTo(HaveKey("quota"))
+		})
+	})
+
+	Context("CreateSession", func() {
+		create := func(body map[string]interface{}) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions", body)
+			httpUtils.SetAuthHeader("test-token")
+			httpUtils.SetProjectContext(testNamespace)
+			CreateSession(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		It("Should warn when the new workspace takes the quota past the threshold", func() {
+			createQuota("storage", "50Gi", "38Gi")
+
+			response := create(map[string]interface{}{"initialPrompt": "x"})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			Expect(response["storageWarning"]).To(Equal("The new 5Gi workspace brings storage to 86% of the project quota storage (43Gi of 50Gi)"))
+		})
+
+		It("Should not warn below the threshold", func() {
+			createQuota("storage", "50Gi", "10Gi")
+
+			response := create(map[string]interface{}{"initialPrompt": "x", "workspaceSize": "10Gi"})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			Expect(response).NotTo(HaveKey("storageWarning"))
+		})
+
+		It("Should honour STORAGE_WARNING_PERCENT", func() {
+			os.Setenv("STORAGE_WARNING_PERCENT", "30")
+			DeferCleanup(os.Unsetenv, "STORAGE_WARNING_PERCENT")
+			createQuota("storage", "50Gi", "10Gi")
+
+			response := create(map[string]interface{}{"initialPrompt": "x", "workspaceSize": "10Gi"})
+
+			httpUtils.AssertHTTPStatus(http.StatusCreated)
+			Expect(response["storageWarning"]).To(ContainSubstring("40% of the project quota storage"))
+		})
+	})
+})
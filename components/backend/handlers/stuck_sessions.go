@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	authv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// defaultStuckOlderThan is how long a session must have gone without status activity to be listed
+	defaultStuckOlderThan = time.Hour
+	// forceFailedByAnnotation records the platform administrator who force-failed a session
+	forceFailedByAnnotation = "ambient-code.io/force-failed-by"
+)
+
+// stuckCandidatePhases are the non-terminal phases a session can get stuck in when the operator
+// cannot make progress on it
+var stuckCandidatePhases = map[string]bool{
+	sessionPhasePending:  true,
+	sessionPhaseCreating: true,
+	sessionPhaseRunning:  true,
+}
+
+// StuckSession is one entry of ListStuckSessions
+type StuckSession struct {
+	Project      string `json:"project"`
+	Name         string `json:"name"`
+	Phase        string `json:"phase"`
+	LastActivity string `json:"lastActivity"`
+	IdleSeconds  int64  `json:"idleSeconds"`
+}
+
+// canAdministerSessions runs a cluster-scope access review for verb on agenticsessions (or one of
+// its subresources), i.e. whether the caller may act on sessions in every project
+func canAdministerSessions(ctx context.Context, userClient kubernetes.Interface, verb, subresource string) (bool, error) {
+	ssar := &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Group:       "vteam.ambient-code",
+				Resource:    "agenticsessions",
+				Subresource: subresource,
+				Verb:        verb,
+			},
+		},
+	}
+	res, err := userClient.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, v1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return res.Status.Allowed, nil
+}
+
+// requireSessionAdmin writes 401/403/500 and returns false unless the caller passes
+// canAdministerSessions for verb and subresource
+func requireSessionAdmin(c *gin.Context, verb, subresource string) bool {
+	reqK8s, _ := GetK8sClientsForRequest(c)
+	if reqK8s == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return false
+	}
+	allowed, err := canAdministerSessions(c.Request.Context(), reqK8s, verb, subresource)
+	if err != nil {
+		log.Printf("Session admin access review failed: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to check permissions", nil)
+		return false
+	}
+	if !allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Platform administrator access required", nil)
+		return false
+	}
+	return true
+}
+
+// sessionLastActivity is the latest status write we can see on a session: its creation, queue and
+// start times, condition transitions and runner repo reports
+func sessionLastActivity(obj *unstructured.Unstructured) time.Time {
+	latest := obj.GetCreationTimestamp().Time
+	consider := func(raw string) {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	for _, field := range []string{"queuedAt", "startTime"} {
+		raw, _, _ := unstructured.NestedString(obj.Object, "status", field)
+		consider(raw)
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, cond := range conditions {
+		if m, ok := cond.(map[string]interface{}); ok {
+			raw, _ := m["lastTransitionTime"].(string)
+			consider(raw)
+		}
+	}
+	repos, _, _ := unstructured.NestedSlice(obj.Object, "status", "reconciledRepos")
+	for _, r := range repos {
+		if m, ok := r.(map[string]interface{}); ok {
+			raw, _ := m["lastUpdated"].(string)
+			consider(raw)
+		}
+	}
+	return latest
+}
+
+// ListStuckSessions handles GET /api/admin/sessions/stuck?olderThan=1h
+// Lists sessions in managed project namespaces that are Pending, Creating or Running, have had no
+// status activity (see sessionLastActivity) for olderThan and have no live runner pod. Requires list
+// on agenticsessions cluster-wide; the backend service account does the listing.
+func ListStuckSessions(c *gin.Context) {
+	if !requireSessionAdmin(c, "list", "") {
+		return
+	}
+
+	olderThan := defaultStuckOlderThan
+	if raw := strings.TrimSpace(c.Query("olderThan")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, "olderThan must be a positive duration such as 1h or 30m", nil)
+			return
+		}
+		olderThan = d
+	}
+
+	ctx := c.Request.Context()
+	nsList, err := K8sClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{LabelSelector: "ambient-code.io/managed=true"})
+	if err != nil {
+		log.Printf("ListStuckSessions: failed to list managed namespaces: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list projects", nil)
+		return
+	}
+	managed := make(map[string]bool, len(nsList.Items))
+	for _, ns := range nsList.Items {
+		managed[ns.Name] = true
+	}
+
+	sessions, err := DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("ListStuckSessions: failed to list sessions: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list sessions", nil)
+		return
+	}
+	pods, err := K8sClient.CoreV1().Pods("").List(ctx, v1.ListOptions{LabelSelector: "job-name"})
+	if err != nil {
+		log.Printf("ListStuckSessions: failed to list runner pods: %v", err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to list pods", nil)
+		return
+	}
+	livePods := map[string]bool{}
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && (pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning) {
+			livePods[pod.Namespace+"/"+pod.Labels["job-name"]] = true
+		}
+	}
+
+	now := time.Now()
+	items := []StuckSession{}
+	for i := range sessions.Items {
+		obj := &sessions.Items[i]
+		if !managed[obj.GetNamespace()] || obj.GetDeletionTimestamp() != nil {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		if phase == "" {
+			phase = sessionPhasePending
+		}
+		if !stuckCandidatePhases[phase] || livePods[obj.GetNamespace()+"/"+obj.GetName()+"-job"] {
+			continue
+		}
+		last := sessionLastActivity(obj)
+		idle := now.Sub(last)
+		if idle < olderThan {
+			continue
+		}
+		items = append(items, StuckSession{
+			Project:      obj.GetNamespace(),
+			Name:         obj.GetName(),
+			Phase:        phase,
+			LastActivity: last.UTC().Format(time.RFC3339),
+			IdleSeconds:  int64(idle.Seconds()),
+		})
+	}
+	// Longest idle first
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].IdleSeconds != items[j].IdleSeconds {
+			return items[i].IdleSeconds > items[j].IdleSeconds
+		}
+		return items[i].Project+"/"+items[i].Name < items[j].Project+"/"+items[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{"items": items, "olderThan": olderThan.String()})
+}
+
+// ForceFailSession handles POST /api/admin/sessions/:projectName/:sessionName/force-fail
+// Body: { message?: string }
+// Deletes the session's runner Job and pods and marks it Failed with a ForceFailed Ready condition,
+// for sessions the operator cannot recover. The acting administrator is recorded in the
+// ambient-code.io/force-failed-by annotation and a ForceFailed event. Requires update on
+// agenticsessions/status cluster-wide; terminal sessions get 409.
+func ForceFailSession(c *gin.Context) {
+	if !requireSessionAdmin(c, "update", "status") {
+		return
+	}
+	project := c.Param("projectName")
+	name := c.Param("sessionName")
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	obj, err := DynamicClient.Resource(gvr).Namespace(project).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to get session")
+		return
+	}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case sessionPhaseCompleted, sessionPhaseFailed, sessionPhaseError, sessionPhaseStopped:
+		respondError(c, http.StatusConflict, ErrorKindConflict, fmt.Sprintf("Session is already %s", phase), nil)
+		return
+	}
+
+	admin := strings.TrimSpace(c.GetString("userID"))
+	if admin == "" {
+		admin = "unknown"
+	}
+	note := fmt.Sprintf("Force-failed by platform administrator %s", admin)
+	if msg := strings.TrimSpace(body.Message); msg != "" {
+		note += ": " + msg
+	}
+
+	// Stop whatever is left of the runner before the status says it is gone
+	jobName := name + "-job"
+	background := v1.DeletePropagationBackground
+	if err := K8sClient.BatchV1().Jobs(project).Delete(ctx, jobName, v1.DeleteOptions{PropagationPolicy: &background}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("ForceFailSession: failed to delete job %s/%s: %v", project, jobName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to delete runner job", nil)
+		return
+	}
+	if err := K8sClient.CoreV1().Pods(project).DeleteCollection(ctx, v1.DeleteOptions{}, v1.ListOptions{LabelSelector: "job-name=" + jobName}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("ForceFailSession: failed to delete pods of %s/%s: %v", project, jobName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to delete runner pods", nil)
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, err := DynamicClient.Resource(gvr).Namespace(project).Get(ctx, name, v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		anns := current.GetAnnotations()
+		if anns == nil {
+			anns = map[string]string{}
+		}
+		anns[forceFailedByAnnotation] = admin
+		current.SetAnnotations(anns)
+		updated, err := DynamicClient.Resource(gvr).Namespace(project).Update(ctx, current, v1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		status, _, _ := unstructured.NestedMap(updated.Object, "status")
+		if status == nil {
+			status = map[string]interface{}{}
+		}
+		conditions, _ := status["conditions"].([]interface{})
+		kept := make([]interface{}, 0, len(conditions)+1)
+		for _, cond := range conditions {
+			if m, ok := cond.(map[string]interface{}); ok && m["type"] == "Ready" {
+				continue
+			}
+			kept = append(kept, cond)
+		}
+		kept = append(kept, map[string]interface{}{
+			"type":               "Ready",
+			"status":             "False",
+			"reason":             "ForceFailed",
+			"message":            note,
+			"lastTransitionTime": now,
+		})
+		status["phase"] = sessionPhaseFailed
+		status["completionTime"] = now
+		status["conditions"] = kept
+		if err := unstructured.SetNestedMap(updated.Object, status, "status"); err != nil {
+			return err
+		}
+		_, err = DynamicClient.Resource(gvr).Namespace(project).UpdateStatus(ctx, updated, v1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("ForceFailSession: failed to mark %s/%s Failed: %v", project, name, err)
+		respondK8sError(c, err, "Session not found", "Failed to update session status")
+		return
+	}
+
+	log.Printf("ForceFailSession: %s force-failed %s/%s (was %s)", admin, project, name, phase)
+	recordSessionEvent(ctx, obj, corev1.EventTypeWarning, "ForceFailed", note)
+	c.JSON(http.StatusOK, gin.H{
+		"message":       note,
+		"project":       project,
+		"name":          name,
+		"phase":         sessionPhaseFailed,
+		"previousPhase": phase,
+		"forceFailedBy": admin,
+	})
+}
@@ -0,0 +1,201 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Stuck Sessions", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	stale := time.Now().Add(-3 * time.Hour).UTC().Format(time.RFC3339)
+
+	createSession := func(namespace, name, phase, startTime string) {
+		_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(namespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata": map[string]interface{}{
+				"name":              name,
+				"namespace":         namespace,
+				"creationTimestamp": stale,
+			},
+			"spec":   map[string]interface{}{"initialPrompt": "x"},
+			"status": map[string]interface{}{"phase": phase, "startTime": startTime},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createRunnerPod := func(namespace, session string, phase corev1.PodPhase) {
+		_, err := k8sUtils.K8sClient.CoreV1().Pods(namespace).Create(ctx, &corev1.Pod{
+			ObjectMeta: v1.ObjectMeta{Name: session + "-job-abc", Namespace: namespace, Labels: map[string]string{"job-name": session + "-job"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.K8sClient.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+			ObjectMeta: v1.ObjectMeta{Name: testNamespace, Labels: map[string]string{"ambient-code.io/managed": "true"}},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("ListStuckSessions", func() {
+		list := func(query string) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("GET", "/api/admin/sessions/stuck"+query, nil)
+			httpUtils.SetAuthHeader("test-token")
+			ListStuckSessions(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		names := func(response map[string]interface{}) []string {
+			out := []string{}
+			for _, item := range response["items"].([]interface{}) {
+				out = append(out, item.(map[string]interface{})["name"].(string))
+			}
+			return out
+		}
+
+		It("Should list idle non-terminal sessions without live runner pods", func() {
+			recent := time.Now().UTC().Format(time.RFC3339)
+			createSession(testNamespace, "stuck-running", "Running", stale)
+			createSession(testNamespace, "stuck-creating", "Creating", "")
+			createSession(testNamespace, "recent", "Running", recent)
+			createSession(testNamespace, "live", "Running", stale)
+			createRunnerPod(testNamespace, "live", corev1.PodRunning)
+			createSession(testNamespace, "dead-pod", "Running", stale)
+			createRunnerPod(testNamespace, "dead-pod", corev1.PodFailed)
+			createSession(testNamespace, "done", "Completed", stale)
+			createSession("unmanaged-"+testNamespace, "elsewhere", "Running", stale)
+
+			response := list("?olderThan=1h")
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(response["olderThan"]).To(Equal("1h0m0s"))
+			Expect(names(response)).To(ConsistOf("stuck-running", "stuck-creating", "dead-pod"))
+		})
+
+		It("Should respect olderThan", func() {
+			createSession(testNamespace, "stuck-running", "Running", stale)
+
+			response := list("?olderThan=4h")
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(names(response)).To(BeEmpty())
+		})
+
+		It("Should reject an invalid olderThan", func() {
+			list("?olderThan=soon")
+
+			httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "olderThan must be a positive duration such as 1h or 30m")
+		})
+
+		It("Should require cluster-wide access to sessions", func() {
+			k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+			list("")
+
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Platform administrator access required")
+		})
+	})
+
+	Context("ForceFailSession", func() {
+		forceFail := func(name string, body interface{}) map[string]interface{} {
+			httpUtils = test_utils.NewHTTPTestUtils()
+			c := httpUtils.CreateTestGinContext("POST", "/api/admin/sessions/"+testNamespace+"/"+name+"/force-fail", body)
+			httpUtils.SetAuthHeader("test-token")
+			c.Params = gin.Params{{Key: "projectName", Value: testNamespace}, {Key: "sessionName", Value: name}}
+			c.Set("userID", "admin-user")
+			ForceFailSession(c)
+			var response map[string]interface{}
+			httpUtils.GetResponseJSON(&response)
+			return response
+		}
+
+		It("Should delete the runner and mark the session Failed", func() {
+			createSession(testNamespace, "sess", "Running", stale)
+			_, err := k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Create(ctx, &batchv1.Job{
+				ObjectMeta: v1.ObjectMeta{Name: "sess-job", Namespace: testNamespace},
+			}, v1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			response := forceFail("sess", map[string]interface{}{"message": "node lost"})
+
+			httpUtils.AssertHTTPStatus(http.StatusOK)
+			Expect(response["previousPhase"]).To(Equal("Running"))
+			Expect(response["forceFailedBy"]).To(Equal("admin-user"))
+
+			_, err = k8sUtils.K8sClient.BatchV1().Jobs(testNamespace).Get(ctx, "sess-job", v1.GetOptions{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored.GetAnnotations()[forceFailedByAnnotation]).To(Equal("admin-user"))
+			phase, _, _ := unstructured.NestedString(stored.Object, "status", "phase")
+			Expect(phase).To(Equal("Failed"))
+			conditions, _, _ := unstructured.NestedSlice(stored.Object, "status", "conditions")
+			Expect(conditions).To(HaveLen(1))
+			ready := conditions[0].(map[string]interface{})
+			Expect(ready["reason"]).To(Equal("ForceFailed"))
+			Expect(ready["message"]).To(Equal("Force-failed by platform administrator admin-user: node lost"))
+		})
+
+		It("Should refuse a session that already finished", func() {
+			createSession(testNamespace, "sess", "Completed", stale)
+
+			forceFail("sess", nil)
+
+			httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "Session is already Completed")
+		})
+
+		It("Should return 404 for a missing session", func() {
+			forceFail("missing", nil)
+
+			httpUtils.AssertHTTPStatus(http.StatusNotFound)
+		})
+
+		It("Should require cluster-wide status update access", func() {
+			createSession(testNamespace, "sess", "Running", stale)
+			k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+			forceFail("sess", nil)
+
+			httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Platform administrator access required")
+			stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			phase, _, _ := unstructured.NestedString(stored.Object, "status", "phase")
+			Expect(phase).To(Equal("Running"))
+		})
+	})
+})
@@ -39,9 +39,11 @@ func SetupHandlerDependencies(k8sUtils *test_utils.K8sTestUtils) {
 	// Auth behavior is enforced by the -tags=test GetK8sClientsForRequest implementation:
 	// it requires a token header and returns K8sClientMw/DynamicClient when present.
 	restoreK8sClientsForRequestHook = nil
+	// Specs reuse tokens with different access review outcomes
+	accessReviewCache = make(map[string]accessReviewEntry)
 
 	// Other handler dependencies with safe defaults for unit tests
-	GetGitHubToken = func(ctx context.Context, k8sClient kubernetes.Interface, dynClient dynamic.Interface, namespace, userID string) (string, error) {
+	GetGitHubToken = func(ctx context.Context, k8sClient kubernetes.Interface, dynClient dynamic.Interface, namespace, userID, repo string) (string, error) {
 		return "fake-github-token", nil
 	}
 	DeriveRepoFolderFromURL = func(url string) string {
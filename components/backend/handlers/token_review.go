@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	authnv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// tokenReviewCacheTTL is how long a TokenReview result is reused for the same token
+const tokenReviewCacheTTL = 30 * time.Second
+
+// tokenReviewCacheMaxEntries triggers a sweep of expired entries when the cache grows past it
+const tokenReviewCacheMaxEntries = 1000
+
+// tokenReviewEntry caches the authenticated username of a token ("" when the token was rejected)
+type tokenReviewEntry struct {
+	username string
+	expires  time.Time
+}
+
+var (
+	tokenReviewCache   = make(map[string]tokenReviewEntry) // sha256(token) -> review result
+	tokenReviewCacheMu sync.Mutex
+)
+
+// reviewTokenUsername returns the username the API server authenticates token as, or "" when the
+// token is rejected or cannot be reviewed. Results are cached per token hash for tokenReviewCacheTTL
+// so the per-request identity lookups do not each cost an API call; review failures are not cached.
+func reviewTokenUsername(ctx context.Context, token string) string {
+	if token == "" || K8sClientMw == nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	tokenReviewCacheMu.Lock()
+	entry, found := tokenReviewCache[key]
+	tokenReviewCacheMu.Unlock()
+	if found && now.Before(entry.expires) {
+		return entry.username
+	}
+
+	tr := &authnv1.TokenReview{Spec: authnv1.TokenReviewSpec{Token: token}}
+	rv, err := K8sClientMw.AuthenticationV1().TokenReviews().Create(ctx, tr, v1.CreateOptions{})
+	if err != nil {
+		log.Printf("TokenReview failed: %v", err)
+		return ""
+	}
+	username := ""
+	if rv.Status.Authenticated && rv.Status.Error == "" {
+		username = strings.TrimSpace(rv.Status.User.Username)
+	}
+
+	tokenReviewCacheMu.Lock()
+	if len(tokenReviewCache) >= tokenReviewCacheMaxEntries {
+		for k, e := range tokenReviewCache {
+			if !now.Before(e.expires) {
+				delete(tokenReviewCache, k)
+			}
+		}
+	}
+	tokenReviewCache[key] = tokenReviewEntry{username: username, expires: now.Add(tokenReviewCacheTTL)}
+	tokenReviewCacheMu.Unlock()
+	return username
+}
+
+// serviceAccountFromUsername splits "system:serviceaccount:<namespace>:<name>" into its parts
+func serviceAccountFromUsername(username string) (string, string, bool) {
+	const prefix = "system:serviceaccount:"
+	if !strings.HasPrefix(username, prefix) {
+		return "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(username, prefix), ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
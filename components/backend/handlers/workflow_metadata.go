@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+)
+
+// Sources of merged workflow metadata entries
+const (
+	workflowMetadataSourceWorkflow   = "workflow"
+	workflowMetadataSourceRepoPrefix = "repo:"
+)
+
+// contentWorkflowMetadata is the content service's /content/workflow-metadata response
+type contentWorkflowMetadata struct {
+	Commands []map[string]interface{} `json:"commands"`
+	Agents   []map[string]interface{} `json:"agents"`
+	Config   map[string]interface{}   `json:"config"`
+	Repos    []struct {
+		Folder   string                   `json:"folder"`
+		Commands []map[string]interface{} `json:"commands"`
+		Agents   []map[string]interface{} `json:"agents"`
+	} `json:"repos"`
+}
+
+// workflowMetadataMerge accumulates entries of one kind, first claim on a name wins
+type workflowMetadataMerge struct {
+	key      string
+	items    []map[string]interface{}
+	shadowed []map[string]interface{}
+	claimed  map[string]string
+}
+
+// add tags entries with source and keeps those whose name is still free; the rest are recorded
+// as shadowed with the source that claimed the name
+func (m *workflowMetadataMerge) add(source string, entries []map[string]interface{}) {
+	if m.claimed == nil {
+		m.claimed = map[string]string{}
+	}
+	for _, entry := range entries {
+		entry["source"] = source
+		name, _ := entry[m.key].(string)
+		if winner, ok := m.claimed[name]; ok && name != "" {
+			entry["shadowedBy"] = winner
+			m.shadowed = append(m.shadowed, entry)
+			continue
+		}
+		m.claimed[name] = source
+		m.items = append(m.items, entry)
+	}
+}
+
+// mergeWorkflowMetadata merges the active workflow's commands and agents with those of the
+// session's repos. Each entry gets a source (workflow or repo:<folder>); on a name clash (slash
+// command for commands, id for agents) the workflow wins, then repos in folder name order, and
+// the losers are listed under shadowed.
+func mergeWorkflowMetadata(body []byte) ([]byte, error) {
+	var meta contentWorkflowMetadata
+	if err := json.Unmarshal(body, &meta); err != nil {
+		return nil, err
+	}
+	commands := &workflowMetadataMerge{key: "slashCommand", items: []map[string]interface{}{}, shadowed: []map[string]interface{}{}}
+	agents := &workflowMetadataMerge{key: "id", items: []map[string]interface{}{}, shadowed: []map[string]interface{}{}}
+	commands.add(workflowMetadataSourceWorkflow, meta.Commands)
+	agents.add(workflowMetadataSourceWorkflow, meta.Agents)
+	for _, repo := range meta.Repos {
+		commands.add(workflowMetadataSourceRepoPrefix+repo.Folder, repo.Commands)
+		agents.add(workflowMetadataSourceRepoPrefix+repo.Folder, repo.Agents)
+	}
+	return json.Marshal(map[string]interface{}{
+		"commands": commands.items,
+		"agents":   agents.items,
+		"config":   meta.Config,
+		"shadowed": map[string]interface{}{"commands": commands.shadowed, "agents": agents.shadowed},
+	})
+}
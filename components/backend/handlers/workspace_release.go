@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultWorkspaceReleaseTimeout bounds how long a start waits for a browsed workspace to be
+// released by its temp content pod. Override with WORKSPACE_RELEASE_TIMEOUT_SECONDS.
+const defaultWorkspaceReleaseTimeout = 30 * time.Second
+
+// workspaceReleasePollInterval is how often a released workspace is checked for its temp content
+// pod and volume attachment
+var workspaceReleasePollInterval = time.Second
+
+// workspaceReleaseTimeout returns the configured wait for a workspace to be released
+func workspaceReleaseTimeout() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("WORKSPACE_RELEASE_TIMEOUT_SECONDS")); raw != "" {
+		if secs, err := strconv.Atoi(raw); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return defaultWorkspaceReleaseTimeout
+}
+
+// releaseSessionWorkspace deletes the temp content pod of workspaceSession and waits until the pod
+// is gone and the workspace's PersistentVolume has no VolumeAttachment left, so a runner scheduled
+// on another node does not hang in ContainerCreating with a Multi-Attach error. It returns "" once
+// the workspace is free (or was never held by a temp pod) and otherwise what is still holding it.
+// Lookups that fail are logged and not waited on.
+func releaseSessionWorkspace(ctx context.Context, project, workspaceSession string) string {
+	if K8sClient == nil {
+		return ""
+	}
+	tempPodName := fmt.Sprintf("temp-content-%s", workspaceSession)
+	err := K8sClient.CoreV1().Pods(project).Delete(ctx, tempPodName, v1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return ""
+	}
+	if err != nil {
+		log.Printf("releaseSessionWorkspace: failed to delete temp content pod %s/%s: %v", project, tempPodName, err)
+		return ""
+	}
+	log.Printf("releaseSessionWorkspace: deleted temp content pod %s/%s, waiting for the workspace to be released", project, tempPodName)
+
+	volumeName := ""
+	if pvc, err := K8sClient.CoreV1().PersistentVolumeClaims(project).Get(ctx, sessionPVCName(workspaceSession), v1.GetOptions{}); err == nil {
+		volumeName = pvc.Spec.VolumeName
+	} else if !errors.IsNotFound(err) {
+		log.Printf("releaseSessionWorkspace: failed to get PVC of %s/%s: %v", project, workspaceSession, err)
+	}
+
+	timeout := workspaceReleaseTimeout()
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(workspaceReleasePollInterval)
+	defer ticker.Stop()
+	for {
+		holder := workspaceHolder(ctx, project, tempPodName, volumeName)
+		if holder == "" {
+			return ""
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			log.Printf("releaseSessionWorkspace: workspace of %s/%s still held by %s after %v", project, workspaceSession, holder, timeout)
+			return holder
+		case <-ctx.Done():
+			return holder
+		}
+	}
+}
+
+// workspaceHolder describes what still holds a workspace: the temp content pod, or an attachment of
+// its volume to a node. It is empty when neither is left.
+func workspaceHolder(ctx context.Context, project, tempPodName, volumeName string) string {
+	_, err := K8sClient.CoreV1().Pods(project).Get(ctx, tempPodName, v1.GetOptions{})
+	if err == nil {
+		return fmt.Sprintf("temp content pod %s", tempPodName)
+	}
+	if !errors.IsNotFound(err) {
+		log.Printf("workspaceHolder: failed to get pod %s/%s: %v", project, tempPodName, err)
+		return ""
+	}
+	if volumeName == "" {
+		return ""
+	}
+	attachments, err := K8sClient.StorageV1().VolumeAttachments().List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("workspaceHolder: failed to list volume attachments: %v", err)
+		return ""
+	}
+	for _, va := range attachments.Items {
+		if pv := va.Spec.Source.PersistentVolumeName; pv != nil && *pv == volumeName {
+			return fmt.Sprintf("volume attachment of %s to node %s", volumeName, va.Spec.NodeName)
+		}
+	}
+	return ""
+}
+
+// requireWorkspaceReleased frees the workspace of workspaceSession for a runner (see
+// releaseSessionWorkspace). When it is still held after the timeout it writes a 409 with a
+// Retry-After hint and returns false.
+func requireWorkspaceReleased(c *gin.Context, project, workspaceSession string) bool {
+	holder := releaseSessionWorkspace(c.Request.Context(), project, workspaceSession)
+	if holder == "" {
+		return true
+	}
+	retryAfter := int(workspaceReleaseTimeout().Seconds())
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	respondError(c, http.StatusConflict, ErrorKindConflict, "Session workspace is still in use; retry shortly",
+		gin.H{"heldBy": holder, "retryAfterSeconds": retryAfter})
+	return false
+}
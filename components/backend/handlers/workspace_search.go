@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-backend/httpclient"
+	"ambient-code-backend/pathutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Each user may run workspaceSearchLimit searches per workspaceSearchWindow across all sessions;
+// a search walks the whole workspace, so the route is rate limited unlike plain file reads
+var (
+	workspaceSearchLimit  = 20
+	workspaceSearchWindow = time.Minute
+)
+
+// searchRateLimiter counts requests per key in fixed windows
+type searchRateLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*searchWindow
+}
+
+type searchWindow struct {
+	start time.Time
+	count int
+}
+
+var workspaceSearchLimiter = &searchRateLimiter{windows: map[string]*searchWindow{}}
+
+// allow records a request for key at now and reports whether it is within limit per window, and
+// otherwise how long until the window resets
+func (l *searchRateLimiter) allow(key string, now time.Time, limit int, window time.Duration) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= window {
+			delete(l.windows, k)
+		}
+	}
+	w, ok := l.windows[key]
+	if !ok {
+		w = &searchWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= limit {
+		return false, w.start.Add(window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// SearchSessionWorkspace searches the text files of a session's workspace through the content
+// service's /content/search, for a literal q or, with regex=true, an RE2 expression.
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/workspace-search?q=&path=&maxResults=&regex=
+func SearchSessionWorkspace(c *gin.Context) {
+	defer startSessionSpan(c, "workspace search")()
+	q := c.Query("q")
+	if len(strings.TrimSpace(q)) < minWorkspaceSearchQuery {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("q must be at least %d characters", minWorkspaceSearchQuery), nil)
+		return
+	}
+	query := url.Values{"q": {q}}
+	if v := c.Query("maxResults"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n < 1 || n > maxWorkspaceSearchResults {
+			respondError(c, http.StatusBadRequest, ErrorKindValidation, fmt.Sprintf("maxResults must be between 1 and %d", maxWorkspaceSearchResults), nil)
+			return
+		}
+		query.Set("maxResults", v)
+	}
+	regex := c.Query("regex") == "true"
+	if _, err := compileWorkspaceSearch(q, regex); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "invalid regular expression: "+err.Error(), nil)
+		return
+	}
+	if regex {
+		query.Set("regex", "true")
+	}
+	dir := path.Join("/workspace", strings.TrimSpace(c.Query("path")))
+	if !pathutil.IsPathWithinBase(dir, "/workspace") {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, "Invalid path: must be within workspace directory", nil)
+		return
+	}
+	sub := strings.TrimPrefix(strings.TrimPrefix(dir, "/workspace"), "/")
+
+	key := c.GetString("userID")
+	if key == "" {
+		key = c.ClientIP()
+	}
+	if ok, wait := workspaceSearchLimiter.allow(key, time.Now(), workspaceSearchLimit, workspaceSearchWindow); !ok {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		respondError(c, http.StatusTooManyRequests, ErrorKindLimitExceeded,
+			fmt.Sprintf("Too many workspace searches; at most %d per %s", workspaceSearchLimit, workspaceSearchWindow),
+			gin.H{"retryAfterSeconds": retryAfter})
+		return
+	}
+
+	req := workspaceContentRequest(c, "SearchSessionWorkspace", "/content/search", sub, query)
+	if req == nil {
+		return
+	}
+	resp, err := httpclient.Do(req, httpclient.Read)
+	if err != nil {
+		log.Printf("SearchSessionWorkspace: content service request failed: %v", err)
+		respondUpstreamUnavailable(c, "Content service unavailable")
+		return
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("SearchSessionWorkspace: failed to read response body: %v", err)
+		respondUpstreamUnavailable(c, "Failed to read response from content service")
+		return
+	}
+	// Like the workspace listing, a workspace the runner has not created yet has no matches
+	if resp.StatusCode == http.StatusNotFound && sub == "" {
+		c.JSON(http.StatusOK, gin.H{"matches": []any{}, "filesScanned": 0, "partial": false})
+		return
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respondContentServiceError(c, resp.StatusCode, b, false)
+		return
+	}
+	c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), b)
+}
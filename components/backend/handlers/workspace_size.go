@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ktypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// defaultStorageClassAnnotation marks the StorageClass used by PVCs that name none
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// parseWorkspaceSize parses a spec.workspaceSize quantity such as "50Gi"; it must be positive
+func parseWorkspaceSize(raw string) (resource.Quantity, error) {
+	q, err := resource.ParseQuantity(strings.TrimSpace(raw))
+	if err != nil || q.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("workspaceSize must be a positive storage quantity such as 50Gi")
+	}
+	return q, nil
+}
+
+// projectMaxWorkspaceSize returns spec.maxWorkspaceSize from the project's ProjectSettings, or nil
+// when the project sets no (valid) ceiling
+func projectMaxWorkspaceSize(ctx context.Context, dyn dynamic.Interface, project string) *resource.Quantity {
+	obj, err := dyn.Resource(GetProjectSettingsResource()).Namespace(project).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("Failed to read ProjectSettings in %s, not capping workspace size: %v", project, err)
+		}
+		return nil
+	}
+	raw, found, _ := unstructured.NestedString(obj.Object, "spec", "maxWorkspaceSize")
+	if !found || raw == "" {
+		return nil
+	}
+	max, err := parseWorkspaceSize(raw)
+	if err != nil {
+		log.Printf("Ignoring invalid ProjectSettings maxWorkspaceSize %q in %s", raw, project)
+		return nil
+	}
+	return &max
+}
+
+// resolveWorkspaceSize validates a requested spec.workspaceSize against the project's
+// maxWorkspaceSize and returns it in canonical form. An empty request stays empty so the operator
+// default applies. On failure it writes a 400 response and returns false.
+func resolveWorkspaceSize(c *gin.Context, dyn dynamic.Interface, project, requested string) (string, bool) {
+	if strings.TrimSpace(requested) == "" {
+		return "", true
+	}
+	size, err := parseWorkspaceSize(requested)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return "", false
+	}
+	if max := projectMaxWorkspaceSize(c.Request.Context(), dyn, project); max != nil && size.Cmp(*max) > 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation,
+			fmt.Sprintf("workspaceSize must not exceed the project limit of %s", max.String()),
+			gin.H{"maxWorkspaceSize": max.String()})
+		return "", false
+	}
+	return size.String(), true
+}
+
+// pvcResizeInfo adds the requested size and any resize conditions of a workspace PVC to a
+// k8s-resources entry. pvcResizing is set while the request is above the provisioned capacity.
+func pvcResizeInfo(result map[string]interface{}, pvc *corev1.PersistentVolumeClaim) {
+	requested, hasRequest := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if hasRequest {
+		result["pvcRequestedSize"] = requested.String()
+	}
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && hasRequest {
+		result["pvcResizing"] = requested.Cmp(capacity) > 0
+	}
+	if len(pvc.Status.Conditions) > 0 {
+		conditions := make([]gin.H, 0, len(pvc.Status.Conditions))
+		for _, cond := range pvc.Status.Conditions {
+			conditions = append(conditions, gin.H{
+				"type":               string(cond.Type),
+				"status":             string(cond.Status),
+				"reason":             cond.Reason,
+				"message":            cond.Message,
+				"lastTransitionTime": cond.LastTransitionTime,
+			})
+		}
+		result["pvcConditions"] = conditions
+	}
+}
+
+// workspaceStorageClassExpandable looks up the StorageClass of a PVC, falling back to the cluster
+// default when the PVC names none, and reports its name and whether it allows volume expansion
+func workspaceStorageClassExpandable(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (string, bool, error) {
+	if pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName != "" {
+		sc, err := K8sClient.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, v1.GetOptions{})
+		if err != nil {
+			return *pvc.Spec.StorageClassName, false, err
+		}
+		return sc.Name, sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+	}
+	list, err := K8sClient.StorageV1().StorageClasses().List(ctx, v1.ListOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	for _, sc := range list.Items {
+		if sc.Annotations[defaultStorageClassAnnotation] == "true" {
+			return sc.Name, sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+		}
+	}
+	return "", false, nil
+}
+
+// ResizeSessionWorkspace grows a session's workspace PVC.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/resize-workspace
+// Body: { size: string }
+// Requires update on agenticsessions. The size may only grow, is capped by ProjectSettings
+// spec.maxWorkspaceSize and needs a StorageClass with allowVolumeExpansion. Users cannot patch PVCs,
+// so the backend service account does after those checks; spec.workspaceSize is updated to match.
+// Progress shows up in GetSessionK8sResources as pvcRequestedSize, pvcResizing and pvcConditions.
+func ResizeSessionWorkspace(c *gin.Context) {
+	project := c.GetString("project")
+	sessionName := c.Param("sessionName")
+
+	reqK8s, reqDyn := GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		respondError(c, http.StatusUnauthorized, ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return
+	}
+
+	var req struct {
+		Size string `json:"size" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation, err.Error(), nil)
+		return
+	}
+
+	allowed, err := canUpdateSessions(c.Request.Context(), reqK8s, project)
+	if err != nil {
+		log.Printf("ResizeSessionWorkspace: access review failed for %s/%s: %v", project, sessionName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to verify permissions", nil)
+		return
+	}
+	if !allowed {
+		respondError(c, http.StatusForbidden, ErrorKindForbidden, "Unauthorized to resize session workspace", nil)
+		return
+	}
+
+	gvr := GetAgenticSessionV1Alpha1Resource()
+	item, err := reqDyn.Resource(gvr).Namespace(project).Get(c.Request.Context(), sessionName, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Session not found", "Failed to get session")
+		return
+	}
+
+	sizeStr, ok := resolveWorkspaceSize(c, reqDyn, project, req.Size)
+	if !ok {
+		return
+	}
+	size := resource.MustParse(sizeStr)
+
+	if K8sClient == nil {
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Backend client not initialized", nil)
+		return
+	}
+	pvcName := sessionPVCName(sessionName)
+	pvc, err := K8sClient.CoreV1().PersistentVolumeClaims(project).Get(c.Request.Context(), pvcName, v1.GetOptions{})
+	if err != nil {
+		respondK8sError(c, err, "Workspace PVC not found", "Failed to get workspace PVC")
+		return
+	}
+	current := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if size.Cmp(current) <= 0 {
+		respondError(c, http.StatusBadRequest, ErrorKindValidation,
+			fmt.Sprintf("Workspace can only grow; it is already %s", current.String()),
+			gin.H{"currentSize": current.String()})
+		return
+	}
+
+	storageClass, expandable, err := workspaceStorageClassExpandable(c.Request.Context(), pvc)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("ResizeSessionWorkspace: failed to read storage class of %s/%s: %v", project, pvcName, err)
+		respondError(c, http.StatusInternalServerError, ErrorKindInternal, "Failed to read the workspace storage class", nil)
+		return
+	}
+	if !expandable {
+		respondError(c, http.StatusConflict, ErrorKindConflict, "The workspace storage class does not support volume expansion",
+			gin.H{"storageClass": storageClass})
+		return
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"resources": map[string]interface{}{
+				"requests": map[string]interface{}{string(corev1.ResourceStorage): sizeStr},
+			},
+		},
+	})
+	if _, err := K8sClient.CoreV1().PersistentVolumeClaims(project).Patch(c.Request.Context(), pvcName, ktypes.MergePatchType, patch, v1.PatchOptions{}); err != nil {
+		log.Printf("ResizeSessionWorkspace: failed to patch %s/%s: %v", project, pvcName, err)
+		respondK8sError(c, err, "Workspace PVC not found", "Failed to resize workspace")
+		return
+	}
+	log.Printf("ResizeSessionWorkspace: %s/%s requested %s (was %s)", project, pvcName, sizeStr, current.String())
+
+	// Keep the spec in line with the PVC; the resize itself has already been requested
+	if err := unstructured.SetNestedField(item.Object, sizeStr, "spec", "workspaceSize"); err == nil {
+		if _, err := reqDyn.Resource(gvr).Namespace(project).Update(c.Request.Context(), item, v1.UpdateOptions{}); err != nil {
+			log.Printf("ResizeSessionWorkspace: failed to record workspaceSize on %s/%s: %v", project, sessionName, err)
+		}
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Workspace resize requested",
+		"pvcName":      pvcName,
+		"size":         sizeStr,
+		"previousSize": current.String(),
+		"storageClass": storageClass,
+	})
+}
@@ -0,0 +1,184 @@
+//go:build test
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ambient-code-backend/tests/config"
+	test_constants "ambient-code-backend/tests/constants"
+	"ambient-code-backend/tests/test_utils"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Workspace Size", Label(test_constants.LabelUnit, test_constants.LabelHandlers, test_constants.LabelSessions), func() {
+	var (
+		httpUtils     *test_utils.HTTPTestUtils
+		k8sUtils      *test_utils.K8sTestUtils
+		ctx           context.Context
+		testNamespace string
+	)
+
+	createStorageClass := func(name string, isDefault, expandable bool) {
+		sc := &storagev1.StorageClass{
+			ObjectMeta:           v1.ObjectMeta{Name: name},
+			Provisioner:          "example.com/csi",
+			AllowVolumeExpansion: &expandable,
+		}
+		if isDefault {
+			sc.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+		}
+		_, err := k8sUtils.K8sClient.StorageV1().StorageClasses().Create(ctx, sc, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	createPVC := func(storageClass *string) {
+		_, err := k8sUtils.K8sClient.CoreV1().PersistentVolumeClaims(testNamespace).Create(ctx, &corev1.PersistentVolumeClaim{
+			ObjectMeta: v1.ObjectMeta{Name: "ambient-workspace-sess", Namespace: testNamespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: storageClass,
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+				},
+			},
+		}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	resize := func(size string) map[string]interface{} {
+		httpUtils = test_utils.NewHTTPTestUtils()
+		c := httpUtils.CreateTestGinContext("POST", "/api/projects/"+testNamespace+"/agentic-sessions/sess/resize-workspace", map[string]interface{}{"size": size})
+		httpUtils.SetAuthHeader("test-token")
+		httpUtils.SetProjectContext(testNamespace)
+		c.Params = gin.Params{{Key: "sessionName", Value: "sess"}}
+		ResizeSessionWorkspace(c)
+		var response map[string]interface{}
+		httpUtils.GetResponseJSON(&response)
+		return response
+	}
+
+	requestedSize := func() string {
+		pvc, err := k8sUtils.K8sClient.CoreV1().PersistentVolumeClaims(testNamespace).Get(ctx, "ambient-workspace-sess", v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		q := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		return q.String()
+	}
+
+	BeforeEach(func() {
+		k8sUtils = test_utils.NewK8sTestUtils(false, *config.TestNamespace)
+		ctx = context.Background()
+		testNamespace = "test-project-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+		SetupHandlerDependencies(k8sUtils)
+
+		_, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": "sess", "namespace": testNamespace},
+			"spec":       map[string]interface{}{"initialPrompt": "x"},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = k8sUtils.DynamicClient.Resource(GetProjectSettingsResource()).Namespace(testNamespace).Create(ctx, &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "ProjectSettings",
+			"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": testNamespace},
+			"spec":       map[string]interface{}{"maxWorkspaceSize": "100Gi"},
+		}}, v1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should grow the PVC and record the size on the session", func() {
+		createStorageClass("expandable", true, true)
+		createPVC(nil)
+
+		response := resize("50Gi")
+
+		httpUtils.AssertHTTPStatus(http.StatusAccepted)
+		Expect(response["previousSize"]).To(Equal("5Gi"))
+		Expect(response["storageClass"]).To(Equal("expandable"))
+		Expect(requestedSize()).To(Equal("50Gi"))
+		stored, err := k8sUtils.DynamicClient.Resource(GetAgenticSessionV1Alpha1Resource()).Namespace(testNamespace).Get(ctx, "sess", v1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		size, _, _ := unstructured.NestedString(stored.Object, "spec", "workspaceSize")
+		Expect(size).To(Equal("50Gi"))
+	})
+
+	It("Should refuse a storage class without volume expansion", func() {
+		createStorageClass("default", true, true)
+		createStorageClass("fixed", false, false)
+		fixed := "fixed"
+		createPVC(&fixed)
+
+		resize("50Gi")
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusConflict, "Conflict", "The workspace storage class does not support volume expansion")
+		Expect(errorObj["details"]).To(Equal(map[string]interface{}{"storageClass": "fixed"}))
+		Expect(requestedSize()).To(Equal("5Gi"))
+	})
+
+	It("Should only grow the workspace", func() {
+		createStorageClass("expandable", true, true)
+		createPVC(nil)
+
+		resize("5Gi")
+
+		errorObj := httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "Workspace can only grow; it is already 5Gi")
+		Expect(errorObj["details"]).To(Equal(map[string]interface{}{"currentSize": "5Gi"}))
+	})
+
+	It("Should cap the size at the project limit", func() {
+		createStorageClass("expandable", true, true)
+		createPVC(nil)
+
+		resize("200Gi")
+
+		httpUtils.AssertErrorResponse(http.StatusBadRequest, "Validation", "workspaceSize must not exceed the project limit of 100Gi")
+		Expect(requestedSize()).To(Equal("5Gi"))
+	})
+
+	It("Should require update on sessions", func() {
+		createStorageClass("expandable", true, true)
+		createPVC(nil)
+		k8sUtils.SSARAllowedFunc = func(action k8stesting.Action) bool { return false }
+
+		resize("50Gi")
+
+		httpUtils.AssertErrorResponse(http.StatusForbidden, "Forbidden", "Unauthorized to resize session workspace")
+		Expect(requestedSize()).To(Equal("5Gi"))
+	})
+
+	It("Should report resize progress in the session's k8s resources", func() {
+		pvc := &corev1.PersistentVolumeClaim{
+			Spec: corev1.PersistentVolumeClaimSpec{Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("50Gi")},
+			}},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+				Conditions: []corev1.PersistentVolumeClaimCondition{
+					{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue, Reason: "Waiting", Message: "restart pod"},
+				},
+			},
+		}
+		result := map[string]interface{}{}
+
+		pvcResizeInfo(result, pvc)
+
+		Expect(result["pvcRequestedSize"]).To(Equal("50Gi"))
+		Expect(result["pvcResizing"]).To(BeTrue())
+		conditions := result["pvcConditions"].([]gin.H)
+		Expect(conditions).To(HaveLen(1))
+		Expect(conditions[0]["type"]).To(Equal("FileSystemResizePending"))
+		Expect(conditions[0]["reason"]).To(Equal("Waiting"))
+	})
+})
@@ -0,0 +1,191 @@
+// Package httpclient provides the HTTP client the backend uses for outbound calls: one pooled
+// transport configured from the environment, with a deadline chosen per call by operation class.
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Class is the kind of outbound call, which decides its deadline
+type Class int
+
+const (
+	// Read is a content service read, listing or small write
+	Read Class = iota
+	// Push is a git push, pull or sync run by the content service
+	Push
+	// API is a call to GitHub, GitLab or an OAuth provider
+	API
+	// Stream has no deadline; the caller bounds it through the request context
+	Stream
+)
+
+// timeouts are the deadlines of each class. Pushes wait for the remote, so they get minutes.
+var timeouts = map[Class]time.Duration{
+	Read: 15 * time.Second,
+	Push: 5 * time.Minute,
+	API:  30 * time.Second,
+}
+
+// Timeout returns the deadline of class, or 0 when it has none
+func Timeout(class Class) time.Duration {
+	return timeouts[class]
+}
+
+// caBundleEnvs name the PEM bundle trusted in addition to the system roots, first set wins.
+// EXTRA_CA_FILE is the older name.
+var caBundleEnvs = []string{"OUTBOUND_CA_BUNDLE_PATH", "EXTRA_CA_FILE"}
+
+var (
+	transportOnce sync.Once
+	transport     *http.Transport
+	client        *http.Client
+)
+
+// Transport returns the shared transport. It honors HTTPS_PROXY, HTTP_PROXY and NO_PROXY, and
+// trusts the PEM certificates in OUTBOUND_CA_BUNDLE_PATH in addition to the system roots.
+func Transport() *http.Transport {
+	transportOnce.Do(func() {
+		transport = newTransport(caBundlePath())
+		client = &http.Client{Transport: transport}
+	})
+	return transport
+}
+
+// Client returns the shared client. It has no overall timeout: use Do, or give the request a
+// context deadline.
+func Client() *http.Client {
+	Transport()
+	return client
+}
+
+// RootCAs returns a copy of the roots the shared transport trusts, or nil when it uses the system
+// roots. Clients with their own TLS settings start from it so the outbound CA bundle still applies.
+func RootCAs() *x509.CertPool {
+	if t := Transport(); t.TLSClientConfig != nil && t.TLSClientConfig.RootCAs != nil {
+		return t.TLSClientConfig.RootCAs.Clone()
+	}
+	return nil
+}
+
+// Summary describes the proxy and CA bundle outbound calls use, for the startup log. Credentials
+// in proxy URLs are redacted.
+func Summary() string {
+	proxies := make([]string, 0, 3)
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		value := os.Getenv(name)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			value = u.Redacted()
+		}
+		proxies = append(proxies, name+"="+value)
+	}
+	proxy := "no proxy"
+	if len(proxies) > 0 {
+		proxy = "proxy " + strings.Join(proxies, " ")
+	}
+	ca := "system CA roots"
+	if path := caBundlePath(); path != "" {
+		if RootCAs() != nil {
+			ca = "system CA roots and " + path
+		} else {
+			ca = "system CA roots (" + path + " could not be loaded)"
+		}
+	}
+	return fmt.Sprintf("Outbound HTTPS: %s; %s", proxy, ca)
+}
+
+// caBundlePath returns the first of caBundleEnvs that is set
+func caBundlePath() string {
+	for _, env := range caBundleEnvs {
+		if path := strings.TrimSpace(os.Getenv(env)); path != "" {
+			return path
+		}
+	}
+	return ""
+}
+
+func newTransport(caFile string) *http.Transport {
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	if caFile == "" {
+		return t
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		log.Printf("httpclient: ignoring CA bundle %s: %v", caFile, err)
+		return t
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		log.Printf("httpclient: ignoring CA bundle %s: no PEM certificates", caFile)
+		return t
+	}
+	t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+	return t
+}
+
+// Do sends req through the shared client with the deadline of class, or the earlier deadline of
+// req's context. The deadline also covers reading the body, and closing the body releases it.
+func Do(req *http.Request, class Class) (*http.Response, error) {
+	return DoWith(Client(), req, class)
+}
+
+// DoWith is Do for a client with its own transport, such as one trusting a per-instance CA
+func DoWith(client *http.Client, req *http.Request, class Class) (*http.Response, error) {
+	timeout := Timeout(class)
+	if timeout == 0 {
+		return client.Do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's deadline once its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"os"
+	"time"
 
 	"ambient-code-backend/git"
 	"ambient-code-backend/github"
 	"ambient-code-backend/handlers"
+	"ambient-code-backend/httpclient"
 	"ambient-code-backend/k8s"
 	"ambient-code-backend/server"
+	"ambient-code-backend/tracing"
 	"ambient-code-backend/websocket"
 
 	"github.com/joho/godotenv"
@@ -49,19 +53,33 @@ func main() {
 	_ = godotenv.Overload(".env.local")
 	_ = godotenv.Overload(".env")
 
+	validateOnly := flag.Bool("validate-config", false, "validate the configuration and exit (also "+server.ValidateConfigEnv+"=true)")
+	flag.Parse()
+
 	// Log build information
 	logBuildInfo()
 
+	// Validate the whole environment up front so every misconfiguration is reported at once
+	cfg, err := server.InitConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	cfg.LogSummary()
+	log.Println(httpclient.Summary())
+	if *validateOnly || os.Getenv(server.ValidateConfigEnv) == "true" {
+		log.Println("Configuration is valid")
+		return
+	}
+
 	// Content service mode - minimal initialization, no K8s access needed
 	if os.Getenv("CONTENT_SERVICE_MODE") == "true" {
 		log.Println("Starting in CONTENT_SERVICE_MODE (no K8s client initialization)")
-
-		// Initialize config to set StateBaseDir from environment
-		server.InitConfig()
+		tracing.Init("ambient-code-content-service")
 
 		// Only initialize what content service needs
 		handlers.StateBaseDir = server.StateBaseDir
 		handlers.GitPushRepo = git.PushRepo
+		handlers.GitHeadRef = git.HeadRef
 		handlers.GitAbandonRepo = git.AbandonRepo
 		handlers.GitDiffRepo = git.DiffRepo
 		handlers.GitCheckMergeStatus = git.CheckMergeStatus
@@ -69,8 +87,27 @@ func main() {
 		handlers.GitPushToRepo = git.PushToRepo
 		handlers.GitCreateBranch = git.CreateBranch
 		handlers.GitListRemoteBranches = git.ListRemoteBranches
+		// Pre-push secret scan; PUSH_SECRET_SCAN=false turns it off
+		if os.Getenv("PUSH_SECRET_SCAN") != "false" {
+			handlers.GitScanSecrets = git.ScanStagedSecrets
+		}
 
 		log.Printf("Content service using StateBaseDir: %s", server.StateBaseDir)
+		if os.Getenv("CONTENT_SERVICE_READONLY") == "true" {
+			handlers.ContentReadOnly = true
+			log.Println("Content service is read-only: writes, pushes and git changes are rejected")
+		}
+
+		// Pods serving a single session authenticate callers against that session
+		handlers.ContentSessionName = os.Getenv("AGENTIC_SESSION_NAME")
+		handlers.ContentSessionNamespace = os.Getenv("AGENTIC_SESSION_NAMESPACE")
+		if handlers.ContentSessionName != "" {
+			if err := server.InitContentKubeConfig(); err != nil {
+				log.Printf("Content service cannot reach the Kubernetes API, rejecting authenticated requests: %v", err)
+			}
+			handlers.BaseKubeConfig = server.BaseKubeConfig
+			log.Printf("Content service scoped to session %s/%s", handlers.ContentSessionNamespace, handlers.ContentSessionName)
+		}
 
 		if err := server.RunContentService(registerContentRoutes); err != nil {
 			log.Fatalf("Content service error: %v", err)
@@ -80,6 +117,7 @@ func main() {
 
 	// Normal server mode - full initialization
 	log.Println("Starting in normal server mode with K8s client initialization")
+	tracing.Init("ambient-code-backend")
 
 	// Initialize components
 	github.InitializeTokenManager()
@@ -88,14 +126,15 @@ func main() {
 		log.Fatalf("Failed to initialize Kubernetes clients: %v", err)
 	}
 
-	server.InitConfig()
-
 	// Initialize git package
 	git.GetProjectSettingsResource = k8s.GetProjectSettingsResource
-	git.GetGitHubInstallation = func(ctx context.Context, userID string) (interface{}, error) {
-		return github.GetInstallation(ctx, userID)
+	git.GetGitHubInstallation = func(ctx context.Context, userID, owner string) (interface{}, error) {
+		return github.GetInstallation(ctx, userID, owner)
+	}
+	// Left nil without a GitHub App, so GetGitHubToken points users at a PAT instead
+	if github.Manager != nil {
+		git.GitHubTokenManager = github.Manager
 	}
-	git.GitHubTokenManager = github.Manager
 	git.GetBackendNamespace = func() string {
 		return server.Namespace
 	}
@@ -103,18 +142,29 @@ func main() {
 	// Initialize content handlers
 	handlers.StateBaseDir = server.StateBaseDir
 	handlers.GitPushRepo = git.PushRepo
+	handlers.GitHeadRef = git.HeadRef
 	handlers.GitAbandonRepo = git.AbandonRepo
 	handlers.GitDiffRepo = git.DiffRepo
 	handlers.GitCheckMergeStatus = git.CheckMergeStatus
 	handlers.GitPullRepo = git.PullRepo
 	handlers.GitPushToRepo = git.PushToRepo
+	handlers.FetchGitHubIdentity = git.FetchGitHubIdentity
 	handlers.GitCreateBranch = git.CreateBranch
 	handlers.GitListRemoteBranches = git.ListRemoteBranches
+	if os.Getenv("PUSH_SECRET_SCAN") != "false" {
+		handlers.GitScanSecrets = git.ScanStagedSecrets
+	}
 
 	// Initialize GitHub auth handlers
 	handlers.K8sClient = server.K8sClient
 	handlers.Namespace = server.Namespace
 	handlers.GithubTokenManager = github.Manager
+	handlers.BackendBuildInfo = handlers.BuildInfo{
+		GitVersion: GitVersion,
+		GitCommit:  GitCommit,
+		GitBranch:  GitBranch,
+		BuildDate:  BuildDate,
+	}
 
 	// Initialize project handlers
 	handlers.GetOpenShiftProjectResource = k8s.GetOpenShiftProjectResource
@@ -126,8 +176,20 @@ func main() {
 	handlers.DynamicClient = server.DynamicClient
 	handlers.GetGitHubToken = handlers.WrapGitHubTokenForRepo(git.GetGitHubToken)
 	handlers.DeriveRepoFolderFromURL = git.DeriveRepoFolderFromURL
+	handlers.CheckRepoRefExists = git.CheckRefExists
 	// LEGACY: SendMessageToSession removed - AG-UI server uses HTTP/SSE instead of WebSocket
 
+	// Shared informer cache for session and ProjectSettings reads (SESSION_CACHE_RESYNC=0 disables)
+	if resync, err := time.ParseDuration(getEnvOrDefault("SESSION_CACHE_RESYNC", "30s")); err != nil {
+		log.Printf("Invalid SESSION_CACHE_RESYNC, read cache disabled: %v", err)
+	} else if resync > 0 {
+		handlers.SessionReadCache = handlers.NewReadCache(server.DynamicClient, resync)
+		log.Printf("Read cache enabled (resync %s)", resync)
+	}
+
+	// Content service endpoints: in-cluster DNS unless ProjectSettings sets spec.contentServiceGateway
+	handlers.ContentResolver = handlers.NewProjectGatewayContentResolver(server.DynamicClient, server.K8sClient, handlers.InClusterContentResolver{})
+
 	// Initialize repo handlers (default implementation already set in client_selection.go)
 	// GetK8sClientsForRequestRepoFunc uses getK8sClientsForRequestRepoDefault by default
 	handlers.GetGitHubTokenRepo = handlers.WrapGitHubTokenForRepo(git.GetGitHubToken)
@@ -139,6 +201,9 @@ func main() {
 
 	// Initialize websocket package
 	websocket.StateBaseDir = server.StateBaseDir
+	handlers.SessionUsageSummary = websocket.SummarizeSessionUsage
+	handlers.PublishUsageUpdate = websocket.PublishUsageUpdate
+	handlers.ReadSessionTranscript = websocket.ReadSessionTranscript
 
 	// Normal server mode
 	if err := server.Run(registerRoutes); err != nil {
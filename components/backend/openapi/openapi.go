@@ -0,0 +1,117 @@
+// Package openapi serves the hand-maintained OpenAPI definition of the backend API.
+package openapi
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec is the OpenAPI 3 definition of the /api routes. Keep it in sync with routes.go;
+// the route coverage test fails when a registered /api route is missing.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// SpecPath is where the definition is served; the docs page loads it from here
+const SpecPath = "/api/openapi.json"
+
+// swaggerUIFiles holds the Swagger UI release fetched by `make swagger-ui`. Only README.md is
+// committed, so a build without the fetched files serves the fallback page.
+//
+//go:embed swagger-ui
+var swaggerUIFiles embed.FS
+
+// swaggerUI is the asset directory the docs handlers read; tests substitute their own files
+var swaggerUI fs.FS = mustSub(swaggerUIFiles, "swagger-ui")
+
+// docsAssets are the Swagger UI files served under /api/docs/assets, by content type
+var docsAssets = map[string]string{
+	"swagger-ui.css":       "text/css; charset=utf-8",
+	"swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+}
+
+// docsPage is a minimal Swagger UI shell using the embedded assets
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Ambient Code API</title>
+  <link rel="stylesheet" href="/api/docs/assets/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="/api/docs/assets/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "` + SpecPath + `", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// docsUnavailablePage is served when the build does not include the Swagger UI assets
+const docsUnavailablePage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Ambient Code API</title>
+</head>
+<body>
+  <p>Swagger UI is not bundled with this build (run <code>make swagger-ui</code> before building).
+  The API definition is available at <a href="` + SpecPath + `">` + SpecPath + `</a>.</p>
+</body>
+</html>
+`
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// docsAssetsBundled reports whether every Swagger UI asset is present
+func docsAssetsBundled() bool {
+	for name := range docsAssets {
+		if _, err := fs.Stat(swaggerUI, name); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeSpec handles GET /api/openapi.json
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", Spec)
+}
+
+// ServeDocs handles GET /api/docs with a Swagger UI page for the spec
+func ServeDocs(c *gin.Context) {
+	page := docsPage
+	if !docsAssetsBundled() {
+		page = docsUnavailablePage
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(page))
+}
+
+// ServeDocsAsset handles GET /api/docs/assets/:file with an embedded Swagger UI file
+func ServeDocsAsset(c *gin.Context) {
+	name := c.Param("file")
+	contentType, ok := docsAssets[name]
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	data, err := fs.ReadFile(swaggerUI, name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var httpMethods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true}
+
+var pathTemplateParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func loadSpec(t *testing.T) map[string]interface{} {
+	t.Helper()
+	var spec map[string]interface{}
+	require.NoError(t, json.Unmarshal(Spec, &spec), "openapi.json must be valid JSON")
+	return spec
+}
+
+// resolveRef follows a local JSON pointer such as #/components/schemas/Project
+func resolveRef(spec map[string]interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+	var node interface{} = spec
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func collectRefs(node interface{}, refs *[]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if s, ok := child.(string); ok && k == "$ref" {
+				*refs = append(*refs, s)
+				continue
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func TestSpecHeader(t *testing.T) {
+	spec := loadSpec(t)
+
+	assert.Regexp(t, `^3\.\d+\.\d+$`, spec["openapi"])
+	info, ok := spec["info"].(map[string]interface{})
+	require.True(t, ok, "info is required")
+	assert.NotEmpty(t, info["title"])
+	assert.NotEmpty(t, info["version"])
+	assert.NotEmpty(t, spec["paths"])
+}
+
+func TestSpecRefsResolve(t *testing.T) {
+	spec := loadSpec(t)
+
+	var refs []string
+	collectRefs(spec, &refs)
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		_, ok := resolveRef(spec, ref)
+		assert.True(t, ok, "unresolved $ref %s", ref)
+	}
+}
+
+func TestSpecOperationsAreWellFormed(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	operationIDs := make(map[string]string)
+	for path, item := range paths {
+		require.True(t, strings.HasPrefix(path, "/"), "path %s must start with /", path)
+		ops := item.(map[string]interface{})
+
+		for method, rawOp := range ops {
+			require.True(t, httpMethods[method], "unexpected key %q under %s", method, path)
+			op := rawOp.(map[string]interface{})
+			where := strings.ToUpper(method) + " " + path
+
+			id, _ := op["operationId"].(string)
+			require.NotEmpty(t, id, "%s needs an operationId", where)
+			if prev, dup := operationIDs[id]; dup {
+				t.Errorf("operationId %s is used by both %s and %s", id, prev, where)
+			}
+			operationIDs[id] = where
+
+			responses, ok := op["responses"].(map[string]interface{})
+			require.True(t, ok && len(responses) > 0, "%s needs at least one response", where)
+			for code := range responses {
+				assert.Regexp(t, `^([1-5]\d\d|default)$`, code, "%s has invalid response code", where)
+			}
+
+			// Every {param} in the template must be declared as a path parameter, and vice versa
+			declared := make(map[string]bool)
+			params, _ := op["parameters"].([]interface{})
+			for _, rawParam := range params {
+				param := rawParam.(map[string]interface{})
+				if ref, ok := param["$ref"].(string); ok {
+					resolved, found := resolveRef(spec, ref)
+					require.True(t, found, "%s: unresolved parameter %s", where, ref)
+					param = resolved.(map[string]interface{})
+				}
+				if param["in"] == "path" {
+					assert.Equal(t, true, param["required"], "%s: path parameter %v must be required", where, param["name"])
+					declared[param["name"].(string)] = true
+				}
+			}
+			inTemplate := pathTemplateParam.FindAllStringSubmatch(path, -1)
+			for _, m := range inTemplate {
+				assert.True(t, declared[m[1]], "%s does not declare path parameter %s", where, m[1])
+			}
+			assert.Len(t, declared, len(inTemplate), "%s declares path parameters not in its template", where)
+		}
+	}
+}
+
+func TestSpecCoversProjectRoutes(t *testing.T) {
+	spec := loadSpec(t)
+	paths := spec["paths"].(map[string]interface{})
+
+	for _, path := range []string{
+		"/api/projects",
+		"/api/projects/{projectName}",
+		"/api/projects/{projectName}/agentic-sessions",
+		"/api/projects/{projectName}/agentic-sessions/{sessionName}",
+		"/api/projects/{projectName}/permissions",
+		"/api/projects/{projectName}/keys",
+	} {
+		assert.Contains(t, paths, path)
+	}
+}
+
+func TestServeSpecAndDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET(SpecPath, ServeSpec)
+	r.GET("/api/docs", ServeDocs)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, SpecPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.True(t, json.Valid(w.Body.Bytes()))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), SpecPath)
+	assert.NotContains(t, w.Body.String(), "https://", "the docs page must not load assets from a CDN")
+}
+
+// withSwaggerUI replaces the embedded Swagger UI files for the duration of a test
+func withSwaggerUI(t *testing.T, files fstest.MapFS) {
+	t.Helper()
+	orig := swaggerUI
+	swaggerUI = files
+	t.Cleanup(func() { swaggerUI = orig })
+}
+
+func docsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/docs", ServeDocs)
+	r.GET("/api/docs/assets/:file", ServeDocsAsset)
+	return r
+}
+
+func TestServeDocsWithBundledAssets(t *testing.T) {
+	withSwaggerUI(t, fstest.MapFS{
+		"swagger-ui.css":       {Data: []byte("body{}")},
+		"swagger-ui-bundle.js": {Data: []byte("var SwaggerUIBundle;")},
+		"README.md":            {Data: []byte("readme")},
+	})
+	r := docsRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/api/docs/assets/swagger-ui-bundle.js")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/assets/swagger-ui-bundle.js", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/javascript")
+	assert.Equal(t, "var SwaggerUIBundle;", w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/assets/swagger-ui.css", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/css")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/assets/README.md", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "only the Swagger UI files are served")
+}
+
+func TestServeDocsWithoutBundledAssets(t *testing.T) {
+	withSwaggerUI(t, fstest.MapFS{"README.md": {Data: []byte("readme")}})
+	r := docsRouter()
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "make swagger-ui")
+	assert.NotContains(t, w.Body.String(), "SwaggerUIBundle")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs/assets/swagger-ui.css", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
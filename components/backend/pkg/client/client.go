@@ -0,0 +1,208 @@
+// Package client is a typed Go client for the backend API.
+//
+// It is a separate module with no dependency on the backend; its request and response structs
+// mirror the backend's types package. Idempotent requests (GET, PUT, DELETE) that fail with 429
+// or a 5xx status are retried with exponential backoff. POST requests are only retried when
+// the backend answers 429 or 503 with Retry-After, since any other failure may have been
+// applied. All other failures are returned as *APIError.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries is how many times a retryable response is retried before giving up
+	DefaultMaxRetries = 3
+	// DefaultRetryBaseDelay is the first backoff delay; later attempts double it
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay caps a single backoff (including Retry-After) so callers are not stalled
+	maxRetryDelay = 30 * time.Second
+)
+
+// Client talks to the backend over HTTP. Create one with NewClient; it is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	token      string
+	httpClient *http.Client
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+var _ Interface = (*Client)(nil)
+
+// Option customizes a Client
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client (e.g. for custom TLS or timeouts)
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		if hc != nil {
+			c.httpClient = hc
+		}
+	}
+}
+
+// WithRetry sets how many times retryable responses are retried and the initial backoff delay.
+// maxRetries of 0 disables retries.
+func WithRetry(maxRetries int, baseDelay time.Duration) Option {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+		if baseDelay > 0 {
+			c.baseDelay = baseDelay
+		}
+	}
+}
+
+// NewClient returns a client for the backend at baseURL (e.g. https://vteam.example.com).
+// The "/api" prefix is added when missing. token is sent as a bearer token on every request.
+func NewClient(baseURL, token string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSpace(baseURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme and host are required", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(u.Path, "/api") {
+		u.Path += "/api"
+	}
+
+	c := &Client{
+		baseURL:    u,
+		token:      strings.TrimSpace(token),
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		maxRetries: DefaultMaxRetries,
+		baseDelay:  DefaultRetryBaseDelay,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// endpoint builds an absolute URL from path segments, escaping each one
+func (c *Client) endpoint(query url.Values, segments ...string) string {
+	u := *c.baseURL
+	escaped := make([]string, 0, len(segments))
+	for _, s := range segments {
+		escaped = append(escaped, url.PathEscape(s))
+	}
+	u.Path = u.Path + "/" + strings.Join(segments, "/")
+	u.RawPath = c.baseURL.EscapedPath() + "/" + strings.Join(escaped, "/")
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// do sends the request, retrying retryable failures with backoff, and returns the final
+// successful response body. Non-2xx responses are returned as *APIError.
+func (c *Client) do(ctx context.Context, method, rawURL, contentType string, body []byte) ([]byte, int, error) {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+		if err != nil {
+			return nil, 0, err
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		if body != nil && contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			// Transport errors are not retried: the request may already have been applied
+			return nil, 0, err
+		}
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			return nil, resp.StatusCode, fmt.Errorf("failed to read response: %w", readErr)
+		}
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return respBody, resp.StatusCode, nil
+		}
+		lastErr = newAPIError(resp, respBody)
+		if !retryable(method, resp.StatusCode, resp.Header.Get("Retry-After")) || attempt >= c.maxRetries {
+			return nil, resp.StatusCode, lastErr
+		}
+
+		select {
+		case <-time.After(c.retryDelay(attempt, resp.Header.Get("Retry-After"))):
+		case <-ctx.Done():
+			return nil, resp.StatusCode, ctx.Err()
+		}
+	}
+}
+
+// doJSON sends in (if non-nil) as JSON and decodes the response into out (if non-nil)
+func (c *Client) doJSON(ctx context.Context, method, rawURL string, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = b
+	}
+	respBody, _, err := c.do(ctx, method, rawURL, "application/json", body)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// retryable reports whether a failed request may be sent again. Idempotent methods retry rate
+// limiting and server-side failures. A POST may already have been applied when the server
+// fails, so it is only retried when the server asked for a retry (429/503 with Retry-After).
+func retryable(method string, status int, retryAfter string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return status == http.StatusTooManyRequests || status >= 500
+	default:
+		return (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) &&
+			strings.TrimSpace(retryAfter) != ""
+	}
+}
+
+// retryDelay honours Retry-After (seconds) when present, otherwise uses exponential backoff with jitter
+func (c *Client) retryDelay(attempt int, retryAfter string) time.Duration {
+	if secs, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && secs >= 0 {
+		return min(time.Duration(secs)*time.Second, maxRetryDelay)
+	}
+	delay := c.baseDelay << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	// Up to 20% jitter so concurrent clients do not retry in lockstep
+	jitter := time.Duration(rand.Int63n(int64(delay)/5 + 1))
+	return delay + jitter
+}
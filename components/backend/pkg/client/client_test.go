@@ -0,0 +1,507 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ambient-code/vTeam/components/backend/pkg/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testToken = "test-token"
+
+// fakeBackend mimics the session, workspace and push handlers with an in-memory store.
+// Response shapes match handlers/sessions.go, including the structured error body.
+type fakeBackend struct {
+	mu       sync.Mutex
+	sessions map[string]*client.AgenticSession
+	order    []string
+	files    map[string][]byte
+	pushes   int
+	// failNext makes the next n requests fail with failStatus before being handled
+	failNext   int
+	failStatus int
+	// retryAfter is sent with injected failures when non-empty
+	retryAfter string
+	// acceptWrites makes the next n workspace writes answer 202 (content service starting)
+	acceptWrites int
+}
+
+func newFakeBackend(t *testing.T) (*fakeBackend, *httptest.Server) {
+	t.Helper()
+	fb := &fakeBackend{sessions: map[string]*client.AgenticSession{}, files: map[string][]byte{}, retryAfter: "0"}
+
+	const sessions = "/api/projects/{projectName}/agentic-sessions"
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+sessions, fb.list)
+	mux.HandleFunc("POST "+sessions, fb.create)
+	mux.HandleFunc("GET "+sessions+"/{sessionName}", fb.get)
+	mux.HandleFunc("DELETE "+sessions+"/{sessionName}", fb.delete)
+	mux.HandleFunc("POST "+sessions+"/{sessionName}/start", fb.setPhase("Creating"))
+	mux.HandleFunc("POST "+sessions+"/{sessionName}/stop", fb.setPhase("Stopped"))
+	mux.HandleFunc("GET "+sessions+"/{sessionName}/workspace/{path...}", fb.readFile)
+	mux.HandleFunc("PUT "+sessions+"/{sessionName}/workspace/{path...}", fb.writeFile)
+	mux.HandleFunc("POST "+sessions+"/{sessionName}/github/push", fb.push)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			apiError(w, http.StatusUnauthorized, client.ErrorKindUnauthorized, "Invalid or missing authentication token")
+			return
+		}
+		if fb.injectFailure(w) {
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return fb, srv
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func apiError(w http.ResponseWriter, code int, kind, msg string) {
+	writeJSON(w, code, map[string]interface{}{
+		"error": map[string]string{"kind": kind, "message": msg, "requestId": "req-123"},
+	})
+}
+
+func (fb *fakeBackend) injectFailure(w http.ResponseWriter) bool {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	if fb.failNext == 0 {
+		return false
+	}
+	fb.failNext--
+	if fb.retryAfter != "" {
+		w.Header().Set("Retry-After", fb.retryAfter)
+	}
+	apiError(w, fb.failStatus, client.ErrorKindUpstreamUnavailable, "try again")
+	return true
+}
+
+func (fb *fakeBackend) key(r *http.Request) string {
+	return r.PathValue("projectName") + "/" + r.PathValue("sessionName")
+}
+
+func (fb *fakeBackend) create(w http.ResponseWriter, r *http.Request) {
+	var req client.CreateAgenticSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apiError(w, http.StatusBadRequest, client.ErrorKindValidation, "Invalid request body")
+		return
+	}
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	project := r.PathValue("projectName")
+	name := fmt.Sprintf("session-%d", len(fb.order)+1)
+	repos := make([]client.SimpleRepo, len(req.Repos))
+	copy(repos, req.Repos)
+	fb.sessions[project+"/"+name] = &client.AgenticSession{
+		APIVersion: "vteam.ambient-code/v1alpha1",
+		Kind:       "AgenticSession",
+		Metadata:   map[string]interface{}{"name": name, "namespace": project},
+		Spec:       client.AgenticSessionSpec{InitialPrompt: req.InitialPrompt, DisplayName: req.DisplayName, Repos: repos},
+		Status:     &client.AgenticSessionStatus{Phase: "Pending"},
+	}
+	fb.order = append(fb.order, name)
+	writeJSON(w, http.StatusCreated, client.CreateSessionResponse{Message: "Agentic session created successfully", Name: name, UID: "uid-" + name})
+}
+
+func (fb *fakeBackend) get(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	s, ok := fb.sessions[fb.key(r)]
+	if !ok {
+		apiError(w, http.StatusNotFound, client.ErrorKindNotFound, "Session not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+func (fb *fakeBackend) list(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	var items []client.AgenticSession
+	for _, name := range fb.order {
+		if s, ok := fb.sessions[r.PathValue("projectName")+"/"+name]; ok {
+			items = append(items, *s)
+		}
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limit = 20
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	end := min(offset+limit, len(items))
+	page := []client.AgenticSession{}
+	if offset < len(items) {
+		page = items[offset:end]
+	}
+	resp := client.SessionList{Items: page, TotalCount: len(items), Limit: limit, Offset: offset, HasMore: end < len(items)}
+	if resp.HasMore {
+		resp.NextOffset = &end
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (fb *fakeBackend) delete(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	if _, ok := fb.sessions[fb.key(r)]; !ok {
+		apiError(w, http.StatusNotFound, client.ErrorKindNotFound, "Session not found")
+		return
+	}
+	delete(fb.sessions, fb.key(r))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fb *fakeBackend) setPhase(phase string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fb.mu.Lock()
+		defer fb.mu.Unlock()
+		s, ok := fb.sessions[fb.key(r)]
+		if !ok {
+			apiError(w, http.StatusNotFound, client.ErrorKindNotFound, "Session not found")
+			return
+		}
+		s.Status.Phase = phase
+		writeJSON(w, http.StatusAccepted, s)
+	}
+}
+
+func (fb *fakeBackend) readFile(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	b, ok := fb.files[fb.key(r)+"/"+r.PathValue("path")]
+	if !ok {
+		apiError(w, http.StatusNotFound, client.ErrorKindNotFound, "File not found")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(b)
+}
+
+func (fb *fakeBackend) writeFile(w http.ResponseWriter, r *http.Request) {
+	payload, _ := io.ReadAll(r.Body)
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	if fb.acceptWrites > 0 {
+		fb.acceptWrites--
+		writeJSON(w, http.StatusAccepted, map[string]string{"message": "Content service starting, please retry upload in a few seconds"})
+		return
+	}
+	fb.files[fb.key(r)+"/"+r.PathValue("path")] = payload
+	writeJSON(w, http.StatusOK, map[string]string{"message": "ok"})
+}
+
+func (fb *fakeBackend) push(w http.ResponseWriter, r *http.Request) {
+	var body client.PushRepoRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		apiError(w, http.StatusBadRequest, client.ErrorKindValidation, "invalid JSON body")
+		return
+	}
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	s, ok := fb.sessions[fb.key(r)]
+	if !ok || body.RepoIndex < 0 || body.RepoIndex >= len(s.Spec.Repos) {
+		apiError(w, http.StatusBadRequest, client.ErrorKindValidation, "invalid repo index")
+		return
+	}
+	fb.pushes++
+	writeJSON(w, http.StatusOK, client.PushRepoResult{OK: true, SHA: "abc123", Branch: "sessions/" + r.PathValue("sessionName"), PodSpawned: true})
+}
+
+func newTestClient(t *testing.T, srv *httptest.Server) *client.Client {
+	t.Helper()
+	c, err := client.NewClient(srv.URL, testToken, client.WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+	return c
+}
+
+func TestSessionLifecycle(t *testing.T) {
+	fb, srv := newFakeBackend(t)
+	c := newTestClient(t, srv)
+	ctx := context.Background()
+
+	created, err := c.CreateSession(ctx, "proj", client.CreateAgenticSessionRequest{
+		InitialPrompt: "Add tests",
+		DisplayName:   "Tests",
+		Repos:         []client.SimpleRepo{{URL: "https://github.com/org/repo"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", created.Name)
+
+	got, err := c.GetSession(ctx, "proj", created.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "Add tests", got.Spec.InitialPrompt)
+	assert.Equal(t, "Pending", got.Status.Phase)
+
+	started, err := c.StartSession(ctx, "proj", created.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "Creating", started.Status.Phase)
+
+	require.NoError(t, c.WriteWorkspaceFile(ctx, "proj", created.Name, "repo/notes/todo.md", []byte("# TODO\n")))
+	content, err := c.ReadWorkspaceFile(ctx, "proj", created.Name, "/repo/notes/todo.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# TODO\n", string(content))
+
+	pushed, err := c.PushRepo(ctx, "proj", created.Name, client.PushRepoRequest{RepoIndex: 0, CommitMessage: "Add tests"})
+	require.NoError(t, err)
+	assert.True(t, pushed.OK)
+	assert.Equal(t, "abc123", pushed.SHA)
+	assert.True(t, pushed.PodSpawned)
+	assert.Equal(t, 1, fb.pushes)
+
+	stopped, err := c.StopSession(ctx, "proj", created.Name)
+	require.NoError(t, err)
+	assert.Equal(t, "Stopped", stopped.Status.Phase)
+
+	require.NoError(t, c.DeleteSession(ctx, "proj", created.Name))
+	_, err = c.GetSession(ctx, "proj", created.Name)
+	assert.True(t, client.IsNotFound(err))
+}
+
+func TestListSessionsPagination(t *testing.T) {
+	_, srv := newFakeBackend(t)
+	c := newTestClient(t, srv)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		_, err := c.CreateSession(ctx, "proj", client.CreateAgenticSessionRequest{InitialPrompt: "p"})
+		require.NoError(t, err)
+	}
+
+	page, err := c.ListSessions(ctx, "proj", client.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, 5, page.TotalCount)
+	assert.True(t, page.HasMore)
+	require.NotNil(t, page.NextOffset)
+	assert.Equal(t, 2, *page.NextOffset)
+
+	last, err := c.ListSessions(ctx, "proj", client.ListOptions{Limit: 2, Offset: 4})
+	require.NoError(t, err)
+	assert.Len(t, last.Items, 1)
+	assert.False(t, last.HasMore)
+
+	all, err := client.ListAllSessions(ctx, c, "proj", "")
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+}
+
+// pagedLister serves fixed pages so ListAllSessions can be checked against a mock Interface
+type pagedLister struct {
+	client.Interface
+	pages []client.SessionList
+	calls []client.ListOptions
+}
+
+func (p *pagedLister) ListSessions(_ context.Context, _ string, opts client.ListOptions) (*client.SessionList, error) {
+	p.calls = append(p.calls, opts)
+	page := p.pages[len(p.calls)-1]
+	return &page, nil
+}
+
+func TestListAllSessionsFollowsContinueToken(t *testing.T) {
+	mock := &pagedLister{pages: []client.SessionList{
+		{Items: make([]client.AgenticSession, 2), HasMore: true, Continue: "tok"},
+		{Items: make([]client.AgenticSession, 1)},
+	}}
+	all, err := client.ListAllSessions(context.Background(), mock, "proj", "")
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+	require.Len(t, mock.calls, 2)
+	assert.Equal(t, "tok", mock.calls[1].Continue)
+}
+
+func TestRetriesTransientFailures(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusBadGateway} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			fb, srv := newFakeBackend(t)
+			c := newTestClient(t, srv)
+			_, err := c.CreateSession(context.Background(), "proj", client.CreateAgenticSessionRequest{InitialPrompt: "p"})
+			require.NoError(t, err)
+			fb.failNext, fb.failStatus, fb.retryAfter = 2, status, ""
+
+			got, err := c.GetSession(context.Background(), "proj", "session-1")
+			require.NoError(t, err)
+			assert.Equal(t, "p", got.Spec.InitialPrompt)
+			assert.Equal(t, 0, fb.failNext)
+		})
+	}
+}
+
+func TestPostRetriedOnlyWhenServerAsksForRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		retried    bool
+	}{
+		{"429 with Retry-After", http.StatusTooManyRequests, "0", true},
+		{"503 with Retry-After", http.StatusServiceUnavailable, "0", true},
+		{"503 without Retry-After", http.StatusServiceUnavailable, "", false},
+		{"502 with Retry-After", http.StatusBadGateway, "0", false},
+		{"500", http.StatusInternalServerError, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fb, srv := newFakeBackend(t)
+			c := newTestClient(t, srv)
+			fb.failNext, fb.failStatus, fb.retryAfter = 1, tt.status, tt.retryAfter
+
+			_, err := c.CreateSession(context.Background(), "proj", client.CreateAgenticSessionRequest{InitialPrompt: "p"})
+			if tt.retried {
+				require.NoError(t, err)
+				assert.Len(t, fb.sessions, 1)
+				return
+			}
+			var apiErr *client.APIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Empty(t, fb.sessions)
+		})
+	}
+}
+
+func TestRetriesExhaustedReturnsAPIError(t *testing.T) {
+	fb, srv := newFakeBackend(t)
+	c := newTestClient(t, srv)
+	fb.failNext, fb.failStatus = 10, http.StatusServiceUnavailable
+
+	_, err := c.GetSession(context.Background(), "proj", "missing")
+	require.Error(t, err)
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, client.ErrorKindUpstreamUnavailable, apiErr.Kind)
+	assert.Equal(t, "req-123", apiErr.RequestID)
+	assert.True(t, client.IsUnavailable(err))
+	// One initial attempt plus three retries
+	assert.Equal(t, 6, fb.failNext)
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"kind":"Validation","message":"Invalid request body","requestId":"r1","details":{"field":"spec"}}}`)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	_, err := c.CreateSession(context.Background(), "proj", client.CreateAgenticSessionRequest{})
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, client.ErrorKindValidation, apiErr.Kind)
+	assert.Equal(t, "spec", apiErr.Details["field"])
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestLegacyErrorShape(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = io.WriteString(w, `{"error":"already exists"}`)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	_, err := c.GetSession(context.Background(), "proj", "s")
+	assert.True(t, client.IsConflict(err))
+	assert.Contains(t, err.Error(), "already exists")
+}
+
+func TestSigningFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = io.WriteString(w, `{"error":{"kind":"SigningFailed","message":"Failed to sign the commit with the project's signing key","requestId":"r1"}}`)
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv)
+
+	_, err := c.PushRepo(context.Background(), "proj", "s", client.PushRepoRequest{RepoIndex: 0})
+	assert.True(t, client.IsSigningFailed(err))
+	assert.False(t, client.IsSigningFailed(errors.New("other")))
+}
+
+func TestUnauthorized(t *testing.T) {
+	_, srv := newFakeBackend(t)
+	c, err := client.NewClient(srv.URL, "wrong")
+	require.NoError(t, err)
+
+	_, err = c.GetSession(context.Background(), "proj", "s")
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, client.ErrorKindUnauthorized, apiErr.Kind)
+}
+
+func TestWriteWorkspaceFileRetriesWhileContentServiceStarts(t *testing.T) {
+	fb, srv := newFakeBackend(t)
+	c := newTestClient(t, srv)
+	fb.acceptWrites = 2
+
+	require.NoError(t, c.WriteWorkspaceFile(context.Background(), "proj", "s", "a.txt", []byte("hi")))
+	assert.Equal(t, []byte("hi"), fb.files["proj/s/a.txt"])
+
+	fb.acceptWrites = 10
+	err := c.WriteWorkspaceFile(context.Background(), "proj", "s", "b.txt", []byte("hi"))
+	var apiErr *client.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusAccepted, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "Content service starting")
+}
+
+func TestContextCancellationStopsRetries(t *testing.T) {
+	// No Retry-After header, so the hour-long computed backoff applies
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c, err := client.NewClient(srv.URL, testToken, client.WithRetry(5, time.Hour))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = c.GetSession(ctx, "proj", "s")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := client.NewClient("not a url", testToken)
+	assert.Error(t, err)
+
+	var gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	for _, base := range []string{srv.URL, srv.URL + "/", srv.URL + "/api", srv.URL + "/api/"} {
+		c, err := client.NewClient(base, testToken)
+		require.NoError(t, err)
+		_, err = c.ReadWorkspaceFile(context.Background(), "proj", "s", "dir/my file.txt")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(gotPath, "/api/projects/proj/"), base)
+		assert.Equal(t, "/api/projects/proj/agentic-sessions/s/workspace/dir/my%20file.txt", gotPath)
+	}
+}
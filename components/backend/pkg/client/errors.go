@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Error kinds returned by the backend (see handlers/errors.go)
+const (
+	ErrorKindValidation          = "Validation"
+	ErrorKindUnauthorized        = "Unauthorized"
+	ErrorKindForbidden           = "Forbidden"
+	ErrorKindNotFound            = "NotFound"
+	ErrorKindConflict            = "Conflict"
+	ErrorKindUpstreamUnavailable = "UpstreamUnavailable"
+	ErrorKindLimitExceeded       = "LimitExceeded"
+	ErrorKindSigningFailed       = "SigningFailed"
+	ErrorKindInternal            = "Internal"
+)
+
+// APIError is a non-2xx response from the backend
+type APIError struct {
+	StatusCode int
+	Kind       string
+	Message    string
+	RequestID  string
+	Details    map[string]interface{}
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("backend returned %d", e.StatusCode)
+	if e.Kind != "" {
+		msg += " " + e.Kind
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+// IsNotFound reports whether err is a 404 from the backend
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether err is a 409 from the backend
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsUnavailable reports whether err is a 503 (e.g. the content service is still starting)
+func IsUnavailable(err error) bool {
+	return hasStatus(err, http.StatusServiceUnavailable)
+}
+
+// IsLimitExceeded reports whether err is a 429 from the backend, e.g. the project is at its
+// concurrent session limit
+func IsLimitExceeded(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsSigningFailed reports whether err is a push or sync whose commit could not be signed with the
+// project's commitSigning key
+func IsSigningFailed(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Kind == ErrorKindSigningFailed
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// newAPIError parses the structured error body {"error": {kind, message, requestId, details}}.
+// The older {"error": "message"} shape and non-JSON bodies are tolerated.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && len(envelope.Error) > 0 {
+		var structured struct {
+			Kind      string                 `json:"kind"`
+			Message   string                 `json:"message"`
+			RequestID string                 `json:"requestId"`
+			Details   map[string]interface{} `json:"details"`
+		}
+		var plain string
+		if err := json.Unmarshal(envelope.Error, &structured); err == nil {
+			apiErr.Kind = structured.Kind
+			apiErr.Message = structured.Message
+			apiErr.Details = structured.Details
+			if structured.RequestID != "" {
+				apiErr.RequestID = structured.RequestID
+			}
+		} else if err := json.Unmarshal(envelope.Error, &plain); err == nil {
+			apiErr.Message = plain
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+		if len(apiErr.Message) > 512 {
+			apiErr.Message = apiErr.Message[:512] + "..."
+		}
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interface is the set of backend operations offered by Client. Depend on it instead of
+// *Client so callers can substitute a fake in tests.
+type Interface interface {
+	CreateSession(ctx context.Context, project string, req CreateAgenticSessionRequest) (*CreateSessionResponse, error)
+	GetSession(ctx context.Context, project, name string) (*AgenticSession, error)
+	ListSessions(ctx context.Context, project string, opts ListOptions) (*SessionList, error)
+	StartSession(ctx context.Context, project, name string) (*AgenticSession, error)
+	StopSession(ctx context.Context, project, name string) (*AgenticSession, error)
+	DeleteSession(ctx context.Context, project, name string) error
+	PushRepo(ctx context.Context, project, session string, req PushRepoRequest) (*PushRepoResult, error)
+	ReadWorkspaceFile(ctx context.Context, project, session, path string) ([]byte, error)
+	WriteWorkspaceFile(ctx context.Context, project, session, path string, content []byte) error
+}
+
+// CreateSessionResponse is the body of POST /agentic-sessions
+type CreateSessionResponse struct {
+	Message string `json:"message"`
+	Name    string `json:"name"`
+	UID     string `json:"uid"`
+	Queued  bool   `json:"queued,omitempty"`
+	// StorageWarning is set when the new workspace takes the project near its storage quota
+	StorageWarning string `json:"storageWarning,omitempty"`
+	// Ready is false when the runner token could not be provisioned; Warnings says why
+	Ready    bool     `json:"ready"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ListOptions selects a page of sessions; zero values use the backend defaults
+type ListOptions struct {
+	Limit    int
+	Offset   int
+	Continue string
+	Search   string
+	// SortBy is creationTimestamp (default), completionTime or displayName
+	SortBy string
+	// Order is asc or desc; the backend defaults to desc for timestamps and asc for displayName
+	Order string
+}
+
+// SessionList is one page of GET /agentic-sessions (the backend's PaginatedResponse with typed items)
+type SessionList struct {
+	Items      []AgenticSession `json:"items"`
+	TotalCount int              `json:"totalCount"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	HasMore    bool             `json:"hasMore"`
+	Continue   string           `json:"continue,omitempty"`
+	NextOffset *int             `json:"nextOffset,omitempty"`
+}
+
+// PushRepoRequest is the body of POST /agentic-sessions/:sessionName/github/push
+type PushRepoRequest struct {
+	RepoIndex     int    `json:"repoIndex"`
+	CommitMessage string `json:"commitMessage,omitempty"`
+}
+
+// PushRepoResult is the content service's push response
+type PushRepoResult struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+	SHA     string `json:"sha,omitempty"`
+	Branch  string `json:"branch,omitempty"`
+	// Author is the identity the commit was made as
+	Author *GitAuthor `json:"author,omitempty"`
+	// AuthorFallback is set when the user's GitHub identity could not be resolved and the bot
+	// identity was used; AuthorNote explains it
+	AuthorFallback bool   `json:"authorFallback,omitempty"`
+	AuthorNote     string `json:"authorNote,omitempty"`
+	// PodSpawned is set when the backend had to start a temp content pod to serve the push
+	PodSpawned bool `json:"podSpawned,omitempty"`
+}
+
+// GitAuthor is a git commit author
+type GitAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (c *Client) sessionURL(query url.Values, project string, rest ...string) string {
+	segments := append([]string{"projects", project, "agentic-sessions"}, rest...)
+	return c.endpoint(query, segments...)
+}
+
+// CreateSession creates a session in project
+func (c *Client) CreateSession(ctx context.Context, project string, req CreateAgenticSessionRequest) (*CreateSessionResponse, error) {
+	var out CreateSessionResponse
+	if err := c.doJSON(ctx, http.MethodPost, c.sessionURL(nil, project), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetSession fetches a single session
+func (c *Client) GetSession(ctx context.Context, project, name string) (*AgenticSession, error) {
+	var out AgenticSession
+	if err := c.doJSON(ctx, http.MethodGet, c.sessionURL(nil, project, name), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListSessions returns one page of sessions. Use ListAllSessions to walk every page.
+func (c *Client) ListSessions(ctx context.Context, project string, opts ListOptions) (*SessionList, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+	if opts.Continue != "" {
+		query.Set("continue", opts.Continue)
+	}
+	if opts.Search != "" {
+		query.Set("search", opts.Search)
+	}
+	if opts.SortBy != "" {
+		query.Set("sortBy", opts.SortBy)
+	}
+	if opts.Order != "" {
+		query.Set("order", opts.Order)
+	}
+	var out SessionList
+	if err := c.doJSON(ctx, http.MethodGet, c.sessionURL(query, project), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAllSessions follows pagination until every session matching search has been returned
+func ListAllSessions(ctx context.Context, c Interface, project, search string) ([]AgenticSession, error) {
+	var all []AgenticSession
+	opts := ListOptions{Limit: MaxPaginationLimit, Search: search}
+	for {
+		page, err := c.ListSessions(ctx, project, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Items...)
+		if !page.HasMore {
+			return all, nil
+		}
+		switch {
+		case page.Continue != "":
+			opts.Continue = page.Continue
+		case page.NextOffset != nil && *page.NextOffset > opts.Offset:
+			opts.Offset = *page.NextOffset
+		default:
+			// The backend reported more items without a way to fetch them; stop rather than loop
+			return all, nil
+		}
+	}
+}
+
+// StartSession starts (or restarts) a session
+func (c *Client) StartSession(ctx context.Context, project, name string) (*AgenticSession, error) {
+	var out AgenticSession
+	if err := c.doJSON(ctx, http.MethodPost, c.sessionURL(nil, project, name, "start"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// StopSession stops a running session
+func (c *Client) StopSession(ctx context.Context, project, name string) (*AgenticSession, error) {
+	var out AgenticSession
+	if err := c.doJSON(ctx, http.MethodPost, c.sessionURL(nil, project, name, "stop"), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteSession deletes a session and its resources
+func (c *Client) DeleteSession(ctx context.Context, project, name string) error {
+	return c.doJSON(ctx, http.MethodDelete, c.sessionURL(nil, project, name), nil, nil)
+}
+
+// PushRepo commits and pushes the session's changes for one of its repos
+func (c *Client) PushRepo(ctx context.Context, project, session string, req PushRepoRequest) (*PushRepoResult, error) {
+	var out PushRepoResult
+	if err := c.doJSON(ctx, http.MethodPost, c.sessionURL(nil, project, session, "github", "push"), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListArtifacts returns the result files the session runner has published
+func (c *Client) ListArtifacts(ctx context.Context, project, session string) ([]SessionArtifact, error) {
+	var out struct {
+		Items []SessionArtifact `json:"items"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, c.sessionURL(nil, project, session, "artifacts"), nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Items, nil
+}
+
+// workspaceURL builds the workspace file URL, escaping each path segment
+func (c *Client) workspaceURL(project, session, path string) string {
+	rest := []string{session, "workspace"}
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part != "" {
+			rest = append(rest, part)
+		}
+	}
+	return c.sessionURL(nil, project, rest...)
+}
+
+// ReadWorkspaceFile returns the raw contents of a file in the session workspace
+func (c *Client) ReadWorkspaceFile(ctx context.Context, project, session, path string) ([]byte, error) {
+	body, _, err := c.do(ctx, http.MethodGet, c.workspaceURL(project, session, path), "", nil)
+	return body, err
+}
+
+// WriteWorkspaceFile writes content to a file in the session workspace. While the content
+// service is starting the backend answers 202 without writing; those are retried like 5xx.
+func (c *Client) WriteWorkspaceFile(ctx context.Context, project, session, path string, content []byte) error {
+	rawURL := c.workspaceURL(project, session, path)
+	for attempt := 0; ; attempt++ {
+		body, status, err := c.do(ctx, http.MethodPut, rawURL, http.DetectContentType(content), content)
+		if err != nil {
+			return err
+		}
+		if status != http.StatusAccepted {
+			return nil
+		}
+		if attempt >= c.maxRetries {
+			apiErr := &APIError{StatusCode: status, Kind: ErrorKindUpstreamUnavailable}
+			var msg struct {
+				Message string `json:"message"`
+			}
+			if json.Unmarshal(body, &msg) == nil {
+				apiErr.Message = msg.Message
+			}
+			return apiErr
+		}
+		select {
+		case <-time.After(c.retryDelay(attempt, "")):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package client
+
+// The types below mirror the JSON shapes of the backend's types package so the client
+// module has no dependency on the backend module. TestClientTypesMatchBackend in the
+// backend's contract tests fails when a field is added to one side but not the other.
+
+// MaxPaginationLimit is the largest page size the backend accepts
+const MaxPaginationLimit = 100
+
+// AgenticSession is a session as returned by the backend
+type AgenticSession struct {
+	APIVersion string                 `json:"apiVersion"`
+	Kind       string                 `json:"kind"`
+	Metadata   map[string]interface{} `json:"metadata"`
+	Spec       AgenticSessionSpec     `json:"spec"`
+	Status     *AgenticSessionStatus  `json:"status,omitempty"`
+}
+
+// AgenticSessionSpec is the desired state of a session
+type AgenticSessionSpec struct {
+	InitialPrompt        string             `json:"initialPrompt,omitempty"`
+	Interactive          bool               `json:"interactive,omitempty"`
+	DisplayName          string             `json:"displayName"`
+	LLMSettings          LLMSettings        `json:"llmSettings"`
+	Timeout              int                `json:"timeout"`
+	UserContext          *UserContext       `json:"userContext,omitempty"`
+	BotAccount           *BotAccountRef     `json:"botAccount,omitempty"`
+	ResourceOverrides    *ResourceOverrides `json:"resourceOverrides,omitempty"`
+	EnvironmentVariables map[string]string  `json:"environmentVariables,omitempty"`
+	Project              string             `json:"project,omitempty"`
+	Repos                []SimpleRepo       `json:"repos,omitempty"`
+	ActiveWorkflow       *WorkflowSelection `json:"activeWorkflow,omitempty"`
+	Priority             string             `json:"priority,omitempty"`
+	RunnerImage          string             `json:"runnerImage,omitempty"`
+	PromptRef            *PromptRef         `json:"promptRef,omitempty"`
+	CostLimitUSD         *float64           `json:"costLimitUSD,omitempty"`
+	WorkspaceSize        string             `json:"workspaceSize,omitempty"`
+}
+
+// PromptRef points at the ConfigMap key holding a session's initial prompt. It is set instead of
+// InitialPrompt for prompts too large to store inline in the session.
+type PromptRef struct {
+	ConfigMapName string `json:"configMapName"`
+	Key           string `json:"key"`
+}
+
+// AgenticSessionStatus is the observed state of a session
+type AgenticSessionStatus struct {
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty"`
+	Phase              string                 `json:"phase,omitempty"`
+	QueuedAt           *string                `json:"queuedAt,omitempty"`
+	StartTime          *string                `json:"startTime,omitempty"`
+	CompletionTime     *string                `json:"completionTime,omitempty"`
+	ReconciledRepos    []ReconciledRepo       `json:"reconciledRepos,omitempty"`
+	ReconciledWorkflow *ReconciledWorkflow    `json:"reconciledWorkflow,omitempty"`
+	SDKSessionID       string                 `json:"sdkSessionId,omitempty"`
+	SDKRestartCount    int                    `json:"sdkRestartCount,omitempty"`
+	Conditions         []Condition            `json:"conditions,omitempty"`
+	RunnerAuth         *RunnerAuth            `json:"runnerAuth,omitempty"`
+	Artifacts          []SessionArtifact      `json:"artifacts,omitempty"`
+	TotalCostUSD       *float64               `json:"totalCostUSD,omitempty"`
+	StoppedReason      string                 `json:"stoppedReason,omitempty"`
+	Usage              map[string]interface{} `json:"usage,omitempty"`
+	UsageSeq           int64                  `json:"usageSeq,omitempty"`
+	Timeline           *SessionTimeline       `json:"timeline,omitempty"`
+}
+
+// SessionTimeline holds the RFC3339 times a session's latest start reached each startup milestone
+type SessionTimeline struct {
+	CreatedAt          *string `json:"createdAt,omitempty"`
+	TokenProvisionedAt *string `json:"tokenProvisionedAt,omitempty"`
+	JobCreatedAt       *string `json:"jobCreatedAt,omitempty"`
+	PodScheduledAt     *string `json:"podScheduledAt,omitempty"`
+	RunnerStartedAt    *string `json:"runnerStartedAt,omitempty"`
+}
+
+// CreateAgenticSessionRequest is the body of POST /agentic-sessions
+type CreateAgenticSessionRequest struct {
+	InitialPrompt        string            `json:"initialPrompt,omitempty"`
+	DisplayName          string            `json:"displayName,omitempty"`
+	LLMSettings          *LLMSettings      `json:"llmSettings,omitempty"`
+	Timeout              *int              `json:"timeout,omitempty"`
+	Interactive          *bool             `json:"interactive,omitempty"`
+	ParentSessionID      string            `json:"parent_session_id,omitempty"`
+	Repos                []SimpleRepo      `json:"repos,omitempty"`
+	MergeDefaults        bool              `json:"mergeDefaults,omitempty"`
+	AutoPushOnComplete   *bool             `json:"autoPushOnComplete,omitempty"`
+	UserContext          *UserContext      `json:"userContext,omitempty"`
+	EnvironmentVariables map[string]string `json:"environmentVariables,omitempty"`
+	Labels               map[string]string `json:"labels,omitempty"`
+	Annotations          map[string]string `json:"annotations,omitempty"`
+	Priority             string            `json:"priority,omitempty"`
+	RunnerImage          string            `json:"runnerImage,omitempty"`
+	Queue                bool              `json:"queue,omitempty"`
+	CostLimitUSD         *float64          `json:"costLimitUSD,omitempty"`
+	WorkspaceSize        string            `json:"workspaceSize,omitempty"`
+	TemplatePrompt       bool              `json:"templatePrompt,omitempty"`
+}
+
+// SimpleRepo is a repository attached to a session
+type SimpleRepo struct {
+	URL    string  `json:"url"`
+	Branch *string `json:"branch,omitempty"`
+	Ref    string  `json:"ref,omitempty"`
+	Folder string  `json:"folder,omitempty"`
+}
+
+// LLMSettings selects the model and sampling parameters
+type LLMSettings struct {
+	Model       string  `json:"model"`
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"maxTokens"`
+}
+
+// UserContext identifies the user a session runs on behalf of
+type UserContext struct {
+	UserID      string   `json:"userId"`
+	DisplayName string   `json:"displayName"`
+	Groups      []string `json:"groups"`
+}
+
+// BotAccountRef names the bot account a session runs as
+type BotAccountRef struct {
+	Name string `json:"name"`
+}
+
+// ResourceOverrides adjusts the runner pod's resources
+type ResourceOverrides struct {
+	CPU           string `json:"cpu,omitempty"`
+	Memory        string `json:"memory,omitempty"`
+	StorageClass  string `json:"storageClass,omitempty"`
+	PriorityClass string `json:"priorityClass,omitempty"`
+}
+
+// WorkflowSelection is the workflow loaded into a session
+type WorkflowSelection struct {
+	GitURL string `json:"gitUrl"`
+	Branch string `json:"branch,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// ReconciledRepo is the reconciliation state of one repository
+type ReconciledRepo struct {
+	URL            string          `json:"url"`
+	Branch         string          `json:"branch"`
+	Name           string          `json:"name,omitempty"`
+	Status         string          `json:"status,omitempty"`
+	ClonedAt       *string         `json:"clonedAt,omitempty"`
+	ClonedSHA      *string         `json:"clonedSha,omitempty"`
+	ClonedBranch   *string         `json:"clonedBranch,omitempty"`
+	PushedSHA      *string         `json:"pushedSha,omitempty"`
+	PushedBranch   *string         `json:"pushedBranch,omitempty"`
+	GitStatus      *string         `json:"gitStatus,omitempty"`
+	LastUpdated    *string         `json:"lastUpdated,omitempty"`
+	ForkOf         *string         `json:"forkOf,omitempty"`
+	ForkURL        *string         `json:"forkUrl,omitempty"`
+	SecretFindings []SecretFinding `json:"secretFindings,omitempty"`
+}
+
+// SecretFinding is a pushed line that looks like a credential
+type SecretFinding struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
+}
+
+// ReconciledWorkflow is the reconciliation state of the active workflow
+type ReconciledWorkflow struct {
+	GitURL    string  `json:"gitUrl"`
+	Branch    string  `json:"branch"`
+	Path      string  `json:"path,omitempty"`
+	Status    string  `json:"status,omitempty"`
+	AppliedAt *string `json:"appliedAt,omitempty"`
+}
+
+// Condition is a status condition of a session
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+}
+
+// RunnerAuth describes the runner token Secret and when its token expires
+type RunnerAuth struct {
+	SecretName     string  `json:"secretName"`
+	TokenExpiresAt *string `json:"tokenExpiresAt,omitempty"`
+}
+
+// SessionArtifact is a runner-published result file, addressed relative to the session workspace
+type SessionArtifact struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	ContentType string `json:"contentType,omitempty"`
+	SizeBytes   int64  `json:"sizeBytes,omitempty"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+	// DownloadURL is set by ListArtifacts and points at the workspace file proxy
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
@@ -2,37 +2,49 @@ package main
 
 import (
 	"ambient-code-backend/handlers"
+	"ambient-code-backend/openapi"
 	"ambient-code-backend/websocket"
 
 	"github.com/gin-gonic/gin"
 )
 
 func registerContentRoutes(r *gin.Engine) {
-	r.POST("/content/write", handlers.ContentWrite)
-	r.GET("/content/file", handlers.ContentRead)
-	r.GET("/content/list", handlers.ContentList)
-	r.DELETE("/content/delete", handlers.ContentDelete)
-	r.POST("/content/github/push", handlers.ContentGitPush)
-	r.POST("/content/github/abandon", handlers.ContentGitAbandon)
-	r.GET("/content/github/diff", handlers.ContentGitDiff)
-	r.GET("/content/git-status", handlers.ContentGitStatus)
-	r.POST("/content/git-configure-remote", handlers.ContentGitConfigureRemote)
-	r.POST("/content/git-sync", handlers.ContentGitSync)
-	r.GET("/content/workflow-metadata", handlers.ContentWorkflowMetadata)
-	r.GET("/content/git-merge-status", handlers.ContentGitMergeStatus)
-	r.POST("/content/git-pull", handlers.ContentGitPull)
-	r.POST("/content/git-push", handlers.ContentGitPushToBranch)
-	r.POST("/content/git-create-branch", handlers.ContentGitCreateBranch)
-	r.GET("/content/git-list-branches", handlers.ContentGitListBranches)
+	content := r.Group("/content", handlers.RequestBodyLimit(), handlers.RequireContentAuth())
+	{
+		// Writes are refused on read-only pods (CONTENT_SERVICE_READONLY); reads stay available
+		content.POST("/write", handlers.RequireContentWritable(), handlers.ContentWrite)
+		content.GET("/file", handlers.ContentRead)
+		content.GET("/stat", handlers.ContentStat)
+		content.GET("/list", handlers.ContentList)
+		content.GET("/search", handlers.ContentSearch)
+		content.DELETE("/delete", handlers.RequireContentWritable(), handlers.ContentDelete)
+		content.POST("/github/push", handlers.RequireContentWritable(), handlers.ContentGitPush)
+		content.POST("/github/abandon", handlers.RequireContentWritable(), handlers.ContentGitAbandon)
+		content.GET("/github/diff", handlers.ContentGitDiff)
+		content.GET("/git-status", handlers.ContentGitStatus)
+		content.POST("/git-configure-remote", handlers.RequireContentWritable(), handlers.ContentGitConfigureRemote)
+		content.POST("/git-sync", handlers.RequireContentWritable(), handlers.ContentGitSync)
+		content.GET("/workflow-metadata", handlers.ContentWorkflowMetadata)
+		content.GET("/git-merge-status", handlers.ContentGitMergeStatus)
+		content.POST("/git-pull", handlers.RequireContentWritable(), handlers.ContentGitPull)
+		content.POST("/git-push", handlers.RequireContentWritable(), handlers.ContentGitPushToBranch)
+		content.POST("/git-create-branch", handlers.RequireContentWritable(), handlers.ContentGitCreateBranch)
+		content.GET("/git-list-branches", handlers.ContentGitListBranches)
+	}
 }
 
 func registerRoutes(r *gin.Engine) {
 	// API routes
-	api := r.Group("/api")
+	api := r.Group("/api", handlers.RequestBodyLimit())
 	{
 		// Public endpoints (no auth required)
 		api.GET("/workflows/ootb", handlers.ListOOTBWorkflows)
 
+		// API definition and docs (public, no auth required)
+		api.GET("/openapi.json", openapi.ServeSpec)
+		api.GET("/docs", openapi.ServeDocs)
+		api.GET("/docs/assets/:file", openapi.ServeDocsAsset)
+
 		api.POST("/projects/:projectName/agentic-sessions/:sessionName/github/token", handlers.MintSessionGitHubToken)
 
 		projectGroup := api.Group("/projects/:projectName", handlers.ValidateProjectContext())
@@ -47,37 +59,58 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.GET("/repo/seed-status", handlers.GetRepoSeedStatus)
 			projectGroup.POST("/repo/seed", handlers.SeedRepositoryEndpoint)
 
+			projectGroup.GET("/reports/sessions.csv", handlers.ExportSessionsCSV)
+			projectGroup.GET("/reports/cost", handlers.GetCostReport)
+			projectGroup.GET("/storage-usage", handlers.GetProjectStorageUsage)
+
 			projectGroup.GET("/agentic-sessions", handlers.ListSessions)
 			projectGroup.POST("/agentic-sessions", handlers.CreateSession)
+			projectGroup.POST("/agentic-sessions/import", handlers.ImportSession)
 			projectGroup.GET("/agentic-sessions/:sessionName", handlers.GetSession)
+			projectGroup.GET("/agentic-sessions/:sessionName/wait", handlers.WaitForSession)
 			projectGroup.PUT("/agentic-sessions/:sessionName", handlers.UpdateSession)
 			projectGroup.PATCH("/agentic-sessions/:sessionName", handlers.PatchSession)
 			projectGroup.DELETE("/agentic-sessions/:sessionName", handlers.DeleteSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/clone", handlers.CloneSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/start", handlers.StartSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/stop", handlers.StopSession)
+			projectGroup.POST("/agentic-sessions/:sessionName/extend-timeout", handlers.ExtendSessionTimeout)
 			projectGroup.POST("/agentic-sessions/:sessionName/workspace/enable", handlers.EnableWorkspaceAccess)
 			projectGroup.POST("/agentic-sessions/:sessionName/workspace/touch", handlers.TouchWorkspaceAccess)
 			projectGroup.GET("/agentic-sessions/:sessionName/workspace", handlers.ListSessionWorkspace)
+			projectGroup.GET("/agentic-sessions/:sessionName/workspace-search", handlers.SearchSessionWorkspace)
 			projectGroup.GET("/agentic-sessions/:sessionName/workspace/*path", handlers.GetSessionWorkspaceFile)
+			projectGroup.HEAD("/agentic-sessions/:sessionName/workspace/*path", handlers.HeadSessionWorkspaceFile)
 			projectGroup.PUT("/agentic-sessions/:sessionName/workspace/*path", handlers.PutSessionWorkspaceFile)
 			projectGroup.DELETE("/agentic-sessions/:sessionName/workspace/*path", handlers.DeleteSessionWorkspaceFile)
 			projectGroup.POST("/agentic-sessions/:sessionName/github/push", handlers.PushSessionRepo)
+			projectGroup.POST("/agentic-sessions/:sessionName/github/push-all", handlers.PushAllSessionRepos)
 			projectGroup.POST("/agentic-sessions/:sessionName/github/abandon", handlers.AbandonSessionRepo)
 			projectGroup.GET("/agentic-sessions/:sessionName/github/diff", handlers.DiffSessionRepo)
+			projectGroup.GET("/agentic-sessions/:sessionName/changes", handlers.GetSessionChanges)
 			projectGroup.GET("/agentic-sessions/:sessionName/git/status", handlers.GetGitStatus)
 			projectGroup.POST("/agentic-sessions/:sessionName/git/configure-remote", handlers.ConfigureGitRemote)
 			projectGroup.POST("/agentic-sessions/:sessionName/git/synchronize", handlers.SynchronizeGit)
 			projectGroup.GET("/agentic-sessions/:sessionName/git/merge-status", handlers.GetGitMergeStatus)
 			projectGroup.POST("/agentic-sessions/:sessionName/git/pull", handlers.GitPullSession)
 			projectGroup.POST("/agentic-sessions/:sessionName/git/push", handlers.GitPushSession)
+			projectGroup.POST("/agentic-sessions/:sessionName/promote", handlers.PromoteSessionWorkspace)
+			projectGroup.POST("/agentic-sessions/:sessionName/resize-workspace", handlers.ResizeSessionWorkspace)
 			projectGroup.POST("/agentic-sessions/:sessionName/git/create-branch", handlers.GitCreateBranchSession)
 			projectGroup.GET("/agentic-sessions/:sessionName/git/list-branches", handlers.GitListBranchesSession)
 			projectGroup.GET("/agentic-sessions/:sessionName/k8s-resources", handlers.GetSessionK8sResources)
+			projectGroup.POST("/agentic-sessions-k8s-resources/batch", handlers.BatchGetSessionK8sResources)
+			projectGroup.POST("/agentic-sessions/:sessionName/provision-retry", handlers.RetrySessionProvisioning)
+			projectGroup.GET("/agentic-sessions/:sessionName/diagnostics", handlers.GetSessionDiagnostics)
 			projectGroup.POST("/agentic-sessions/:sessionName/workflow", handlers.SelectWorkflow)
 			projectGroup.GET("/agentic-sessions/:sessionName/workflow/metadata", handlers.GetWorkflowMetadata)
 			projectGroup.POST("/agentic-sessions/:sessionName/repos", handlers.AddRepo)
 			projectGroup.DELETE("/agentic-sessions/:sessionName/repos/:repoName", handlers.RemoveRepo)
+			projectGroup.PUT("/agentic-sessions/:sessionName/status", handlers.UpdateSessionStatus)
+			projectGroup.GET("/agentic-sessions/:sessionName/artifacts", handlers.ListSessionArtifacts)
+			projectGroup.PUT("/agentic-sessions/:sessionName/artifacts", handlers.PublishSessionArtifacts)
+			projectGroup.GET("/agentic-sessions/:sessionName/messages", handlers.GetSessionMessages)
+			projectGroup.POST("/agentic-sessions/:sessionName/messages", websocket.HandleSendSessionMessage)
 			projectGroup.PUT("/agentic-sessions/:sessionName/displayname", handlers.UpdateSessionDisplayName)
 
 			// OAuth integration - requires user auth like all other session endpoints
@@ -94,6 +127,7 @@ func registerRoutes(r *gin.Engine) {
 
 			// Session export
 			projectGroup.GET("/agentic-sessions/:sessionName/export", websocket.HandleExportSession)
+			projectGroup.GET("/agentic-sessions/:sessionName/bundle", handlers.ExportSession)
 
 			projectGroup.GET("/permissions", handlers.ListProjectPermissions)
 			projectGroup.POST("/permissions", handlers.AddProjectPermission)
@@ -104,10 +138,25 @@ func registerRoutes(r *gin.Engine) {
 			projectGroup.DELETE("/keys/:keyId", handlers.DeleteProjectKey)
 
 			projectGroup.GET("/secrets", handlers.ListNamespaceSecrets)
+			projectGroup.GET("/secrets/:name/keys", handlers.ListSecretKeys)
+			projectGroup.PUT("/secrets/:name/keys/:key", handlers.UpdateSecretKey)
+			projectGroup.DELETE("/secrets/:name/keys/:key", handlers.DeleteSecretKey)
+			projectGroup.DELETE("/secrets/:name", handlers.DeleteNamespaceSecret)
 			projectGroup.GET("/runner-secrets", handlers.ListRunnerSecrets)
 			projectGroup.PUT("/runner-secrets", handlers.UpdateRunnerSecrets)
 			projectGroup.GET("/integration-secrets", handlers.ListIntegrationSecrets)
 			projectGroup.PUT("/integration-secrets", handlers.UpdateIntegrationSecrets)
+			projectGroup.GET("/gitlab-config", handlers.GetGitLabConfig)
+			projectGroup.PUT("/gitlab-config", handlers.UpdateGitLabConfig)
+			projectGroup.POST("/gitlab-config/test", handlers.TestGitLabConfig)
+			projectGroup.GET("/gitlab/projects", handlers.ListGitLabProjects)
+			projectGroup.GET("/gitlab/projects/:id/branches", handlers.ListGitLabProjectBranches)
+			projectGroup.GET("/github/auth-status", handlers.GetGitHubAuthStatus)
+			projectGroup.GET("/github/installations", handlers.ListGitHubInstallations)
+			projectGroup.GET("/settings", handlers.GetProjectSettings)
+			projectGroup.PUT("/settings", handlers.UpdateProjectSettings)
+			projectGroup.GET("/models", handlers.ListProjectModels)
+			projectGroup.POST("/onboard", handlers.OnboardProject)
 
 			// GitLab authentication endpoints (project-scoped)
 			projectGroup.POST("/auth/gitlab/connect", handlers.ConnectGitLabGlobal)
@@ -123,6 +172,12 @@ func registerRoutes(r *gin.Engine) {
 		// Cluster info endpoint (public, no auth required)
 		api.GET("/cluster-info", handlers.GetClusterInfo)
 
+		// Platform administration (restricted by an access review on the operator namespace)
+		api.GET("/admin/operator-status", handlers.GetOperatorStatus)
+		// Sessions the operator cannot recover (restricted by cluster-scope access reviews on agenticsessions)
+		api.GET("/admin/sessions/stuck", handlers.ListStuckSessions)
+		api.POST("/admin/sessions/:projectName/:sessionName/force-fail", handlers.ForceFailSession)
+
 		api.GET("/projects", handlers.ListProjects)
 		api.POST("/projects", handlers.CreateProject)
 		api.GET("/projects/:projectName", handlers.GetProject)
@@ -133,6 +188,9 @@ func registerRoutes(r *gin.Engine) {
 	// Health check endpoint
 	r.GET("/health", handlers.Health)
 
+	// Prometheus metrics (read cache hit ratio), for callers allowed to get /metrics
+	r.GET("/metrics", handlers.RequireMetricsAccess(), handlers.Metrics)
+
 	// Generic OAuth2 callback endpoint (outside /api for MCP compatibility)
 	r.GET("/oauth2callback", handlers.HandleOAuth2Callback)
 
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"ambient-code-backend/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ginParamPattern = regexp.MustCompile(`[:*]([A-Za-z_]+)`)
+
+// openAPIPath converts a gin route path (/agentic-sessions/:sessionName/workspace/*path)
+// to its OpenAPI template (/agentic-sessions/{sessionName}/workspace/{path})
+func openAPIPath(ginPath string) string {
+	return ginParamPattern.ReplaceAllString(ginPath, "{$1}")
+}
+
+func registeredAPIRoutes(t *testing.T) map[string]map[string]bool {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+
+	routes := make(map[string]map[string]bool)
+	for _, route := range r.Routes() {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		if routes[path] == nil {
+			routes[path] = make(map[string]bool)
+		}
+		routes[path][strings.ToLower(route.Method)] = true
+	}
+	return routes
+}
+
+func loadSpecPaths(t *testing.T) map[string]map[string]json.RawMessage {
+	t.Helper()
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(openapi.Spec, &spec))
+	return spec.Paths
+}
+
+// TestOpenAPISpecCoversRegisteredRoutes fails when a route is added to registerRoutes
+// without documenting it in openapi/openapi.json
+func TestOpenAPISpecCoversRegisteredRoutes(t *testing.T) {
+	routes := registeredAPIRoutes(t)
+	specPaths := loadSpecPaths(t)
+
+	require.NotEmpty(t, routes)
+	for path, methods := range routes {
+		for method := range methods {
+			_, ok := specPaths[path][method]
+			assert.True(t, ok, "route %s %s is not documented in openapi/openapi.json", strings.ToUpper(method), path)
+		}
+	}
+}
+
+// TestOpenAPISpecHasNoStaleOperations fails when the spec documents a route that is no longer registered
+func TestOpenAPISpecHasNoStaleOperations(t *testing.T) {
+	routes := registeredAPIRoutes(t)
+	specPaths := loadSpecPaths(t)
+
+	for path, ops := range specPaths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			assert.True(t, routes[path][method], "openapi/openapi.json documents %s %s but no such route is registered", strings.ToUpper(method), path)
+		}
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert.Equal(t, "/api/projects/{projectName}/agentic-sessions/{sessionName}/workspace/{path}",
+		openAPIPath("/api/projects/:projectName/agentic-sessions/:sessionName/workspace/*path"))
+	assert.Equal(t, "/api/projects", openAPIPath("/api/projects"))
+}
+
+// TestSingleCreateSessionRoute guards against a second session create implementation being wired:
+// the one route must be handlers.CreateSession, which creates with the caller's token
+func TestSingleCreateSessionRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+
+	var handlers []string
+	for _, route := range r.Routes() {
+		if route.Method == "POST" && route.Path == "/api/projects/:projectName/agentic-sessions" {
+			handlers = append(handlers, route.Handler)
+		}
+	}
+	require.Len(t, handlers, 1)
+	assert.True(t, strings.HasSuffix(handlers[0], "handlers.CreateSession"), "create is served by %s", handlers[0])
+}
+
+// TestRoutesServedByHandlerPackages guards against handler implementations creeping back into
+// package main next to the handlers package: every route, API and content service alike, must be
+// served by a function outside main. Gin itself refuses a second registration of a method and path.
+func TestRoutesServedByHandlerPackages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+	registerContentRoutes(r)
+
+	for _, route := range r.Routes() {
+		assert.False(t, strings.HasPrefix(route.Handler, "main."),
+			"%s %s is served by %s; implement it in the handlers package", route.Method, route.Path, route.Handler)
+	}
+}
+
+// TestBatchK8sResourcesRouteLeavesSessionRoutes guards the batch read's path: served under
+// /agentic-sessions/ as k8s-resources:batch, gin would take ":batch" for a parameter and route no
+// POST to a session whose name starts with k8s-resources
+func TestBatchK8sResourcesRouteLeavesSessionRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerRoutes(r)
+
+	for _, path := range []string{
+		"/api/projects/p/agentic-sessions-k8s-resources/batch",
+		"/api/projects/p/agentic-sessions/k8s-resources-x/clone",
+		"/api/projects/p/agentic-sessions/k8s-resources-x/stop",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		assert.NotEqual(t, http.StatusNotFound, w.Code, "POST %s is not routed", path)
+	}
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidateConfigEnv set to true makes the backend exit after validating its configuration, like
+// the --validate-config flag, for use in init containers
+const ValidateConfigEnv = "VALIDATE_CONFIG"
+
+// setting is one environment variable the backend reads. Check validates a non-empty value;
+// Secret values are masked in the configuration summary.
+type setting struct {
+	Env      string
+	Default  string
+	Required bool
+	Secret   bool
+	Check    func(string) error
+}
+
+// commonSettings apply to both the API server and the content service
+var commonSettings = []setting{
+	{Env: "STATE_BASE_DIR", Default: "/workspace", Check: checkWritableDir},
+	{Env: "PORT", Default: "8080", Check: checkPort},
+	{Env: "OUTBOUND_CA_BUNDLE_PATH", Check: checkReadableFile},
+	{Env: "EXTRA_CA_FILE", Check: checkReadableFile},
+	{Env: "GITHUB_API_BASE", Check: checkHTTPURL},
+	{Env: "PUSH_SECRET_SCAN", Check: checkBool},
+	{Env: "OTEL_EXPORTER_OTLP_ENDPOINT", Check: checkHTTPURL},
+	{Env: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", Check: checkHTTPURL},
+	{Env: "OTEL_EXPORTER_OTLP_HEADERS", Secret: true},
+	{Env: "OTEL_SERVICE_NAME"},
+}
+
+// serverSettings are read by the API server only
+var serverSettings = []setting{
+	{Env: "NAMESPACE", Default: "default"},
+	{Env: "PVC_BASE_DIR", Default: "/workspace"},
+	{Env: "BACKEND_URL", Check: checkHTTPURL},
+	{Env: "OOTB_WORKFLOWS_REPO", Default: "https://github.com/ambient-code/ootb-ambient-workflows.git", Check: checkHTTPURL},
+	{Env: "OOTB_WORKFLOWS_BRANCH", Default: "main"},
+	{Env: "OOTB_WORKFLOWS_PATH", Default: "workflows"},
+	{Env: "SESSION_CACHE_RESYNC", Default: "30s", Check: checkDuration},
+	{Env: "MAX_INLINE_PROMPT_BYTES", Check: checkPositiveInt},
+	{Env: "CONTENT_POD_READY_TIMEOUT_SECONDS", Check: checkPositiveInt},
+	{Env: "WORKSPACE_RELEASE_TIMEOUT_SECONDS", Check: checkPositiveInt},
+	{Env: "MAX_REQUEST_BODY_BYTES", Check: checkPositiveInt},
+	{Env: "MAX_WORKSPACE_WRITE_BYTES", Check: checkPositiveInt},
+	{Env: "SESSION_TIMEOUT_WARNING_MINUTES", Check: checkPositiveInt},
+	{Env: "SESSION_STREAM_RECHECK_MINUTES", Check: checkPositiveInt},
+	{Env: "OPERATOR_STALE_MINUTES", Check: checkPositiveInt},
+	{Env: "STORAGE_WARNING_PERCENT", Check: checkPositiveInt},
+	{Env: "RUNNER_ROLE_FREEZE_AFTER_DAYS", Check: checkNonNegativeInt},
+	{Env: "GITLAB_MAX_PAGINATION_PAGES", Check: checkPositiveInt},
+	{Env: "ANTHROPIC_MODELS"},
+	{Env: "VERTEX_MODELS"},
+	{Env: "GITHUB_APP_ID", Check: checkPositiveInt},
+	{Env: "GITHUB_PRIVATE_KEY", Secret: true},
+	{Env: "GITHUB_CLIENT_ID"},
+	{Env: "GITHUB_CLIENT_SECRET", Secret: true},
+	{Env: "GITHUB_STATE_SECRET", Secret: true},
+	{Env: "OAUTH_STATE_SECRET", Secret: true},
+	{Env: "GOOGLE_OAUTH_CLIENT_ID"},
+	{Env: "GOOGLE_OAUTH_CLIENT_SECRET", Secret: true},
+}
+
+// contentSessionSettings are required of a content service scoped to one session
+var contentSessionSettings = []setting{
+	{Env: "AGENTIC_SESSION_NAMESPACE", Required: true},
+	{Env: "KUBERNETES_SERVICE_HOST", Required: true},
+	{Env: "KUBERNETES_SERVICE_PORT", Required: true, Check: checkPort},
+	{Env: "KUBE_CA_FILE", Check: checkReadableFile},
+}
+
+// settingPairs must be set together: one without the other is a half-configured integration
+var settingPairs = [][2]string{
+	{"GITHUB_APP_ID", "GITHUB_PRIVATE_KEY"},
+	{"GITHUB_CLIENT_ID", "GITHUB_CLIENT_SECRET"},
+	{"GOOGLE_OAUTH_CLIENT_ID", "GOOGLE_OAUTH_CLIENT_SECRET"},
+}
+
+// Config is the backend's environment configuration with defaults applied
+type Config struct {
+	ContentServiceMode bool
+	values             map[string]string
+	settings           []setting
+}
+
+// Get returns the effective value of an environment variable of the configuration
+func (c *Config) Get(env string) string {
+	return c.values[env]
+}
+
+// ConfigError lists every problem found in the configuration
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("%d configuration problem(s):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// LoadConfig reads and validates the backend configuration from the environment. All problems are
+// collected into one *ConfigError instead of stopping at the first; the Config is returned either way.
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		ContentServiceMode: os.Getenv("CONTENT_SERVICE_MODE") == "true",
+		values:             map[string]string{},
+	}
+	cfg.settings = append(cfg.settings, commonSettings...)
+	if cfg.ContentServiceMode {
+		if strings.TrimSpace(os.Getenv("AGENTIC_SESSION_NAME")) != "" {
+			cfg.settings = append(cfg.settings, contentSessionSettings...)
+		}
+	} else {
+		cfg.settings = append(cfg.settings, serverSettings...)
+	}
+
+	var problems []string
+	for _, s := range cfg.settings {
+		raw := strings.TrimSpace(os.Getenv(s.Env))
+		if raw == "" {
+			if s.Required {
+				problems = append(problems, fmt.Sprintf("%s is required", s.Env))
+			}
+			cfg.values[s.Env] = s.Default
+			if s.Default == "" || s.Check == nil {
+				continue
+			}
+			raw = s.Default
+		}
+		cfg.values[s.Env] = raw
+		if s.Check != nil {
+			if err := s.Check(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s=%q: %v", s.Env, maskValue(s, raw), err))
+			}
+		}
+	}
+	for _, pair := range settingPairs {
+		a, aKnown := cfg.values[pair[0]]
+		b, bKnown := cfg.values[pair[1]]
+		if !aKnown || !bKnown {
+			continue
+		}
+		if (a == "") != (b == "") {
+			problems = append(problems, fmt.Sprintf("%s and %s must be set together", pair[0], pair[1]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return cfg, &ConfigError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// LogSummary logs the effective configuration with secret values masked
+func (c *Config) LogSummary() {
+	settings := append([]setting(nil), c.settings...)
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Env < settings[j].Env })
+	log.Println("Effective configuration:")
+	for _, s := range settings {
+		v := c.values[s.Env]
+		if v == "" {
+			v = "(unset)"
+		} else {
+			v = maskValue(s, v)
+		}
+		log.Printf("  %s=%s", s.Env, v)
+	}
+}
+
+func maskValue(s setting, v string) string {
+	if s.Secret {
+		return "****"
+	}
+	return v
+}
+
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create directory: %v", err)
+	}
+	f, err := os.CreateTemp(dir, ".write-probe-")
+	if err != nil {
+		return fmt.Errorf("directory is not writable: %v", err)
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+	return nil
+}
+
+func checkReadableFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot read file: %v", err)
+	}
+	return f.Close()
+}
+
+func checkHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("not a URL: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+func checkPort(raw string) error {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("must be a port number between 1 and 65535")
+	}
+	return nil
+}
+
+func checkPositiveInt(raw string) error {
+	if n, err := strconv.Atoi(raw); err != nil || n <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func checkNonNegativeInt(raw string) error {
+	if n, err := strconv.Atoi(raw); err != nil || n < 0 {
+		return fmt.Errorf("must be a non-negative integer")
+	}
+	return nil
+}
+
+func checkDuration(raw string) error {
+	if d, err := time.ParseDuration(raw); err != nil || d < 0 {
+		return fmt.Errorf("must be a non-negative duration such as 30s")
+	}
+	return nil
+}
+
+func checkBool(raw string) error {
+	if raw != "true" && raw != "false" {
+		return fmt.Errorf("must be true or false")
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"net"
 	"os"
 
 	"k8s.io/client-go/dynamic"
@@ -60,23 +61,28 @@ func InitK8sClients() error {
 	return nil
 }
 
-// InitConfig initializes configuration from environment variables
-func InitConfig() {
-	// Get namespace from environment or use default
-	Namespace = os.Getenv("NAMESPACE")
-	if Namespace == "" {
-		Namespace = "default"
+// InitContentKubeConfig sets BaseKubeConfig for CONTENT_SERVICE_MODE. Content pods do not mount a
+// ServiceAccount token, so the config only locates the API server (KUBERNETES_SERVICE_HOST/PORT and
+// the CA bundle at KUBE_CA_FILE) and callers' own tokens are used for every request.
+func InitContentKubeConfig() error {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return fmt.Errorf("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
 	}
-
-	// Get state storage base directory
-	StateBaseDir = os.Getenv("STATE_BASE_DIR")
-	if StateBaseDir == "" {
-		StateBaseDir = "/workspace"
+	config := &rest.Config{Host: "https://" + net.JoinHostPort(host, port)}
+	if caFile := os.Getenv("KUBE_CA_FILE"); caFile != "" {
+		config.TLSClientConfig.CAFile = caFile
 	}
+	BaseKubeConfig = config
+	return nil
+}
 
-	// Get PVC base directory for RFE workspaces
-	PvcBaseDir = os.Getenv("PVC_BASE_DIR")
-	if PvcBaseDir == "" {
-		PvcBaseDir = "/workspace"
-	}
+// InitConfig loads and validates the configuration from environment variables (see LoadConfig)
+// and sets Namespace, StateBaseDir and PvcBaseDir from it. A *ConfigError lists every problem.
+func InitConfig() (*Config, error) {
+	cfg, err := LoadConfig()
+	Namespace = cfg.Get("NAMESPACE")
+	StateBaseDir = cfg.Get("STATE_BASE_DIR")
+	PvcBaseDir = cfg.Get("PVC_BASE_DIR")
+	return cfg, err
 }
@@ -10,6 +10,9 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"ambient-code-backend/tracing"
 )
 
 // RouterFunc is a function that can register routes on a Gin router
@@ -34,14 +37,23 @@ func Run(registerRoutes RouterFunc) error {
 		)
 	}))
 
+	// Middleware to assign a correlation ID used in error responses
+	r.Use(requestIDMiddleware())
+
 	// Middleware to populate user context from forwarded headers
 	r.Use(forwardedIdentityMiddleware())
 
+	// A span per request when OTLP export is configured
+	if tracing.Enabled() {
+		r.Use(tracingMiddleware())
+	}
+
 	// Configure CORS
 	config := cors.DefaultConfig()
 	config.AllowAllOrigins = true
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "X-Request-ID", tracing.TraceparentHeader}
+	config.ExposeHeaders = []string{"X-Request-ID"}
 	r.Use(cors.New(config))
 
 	// Register routes
@@ -63,6 +75,55 @@ func Run(registerRoutes RouterFunc) error {
 	return nil
 }
 
+// requestIDMiddleware propagates the caller's X-Request-ID (or generates one) so error
+// responses and logs can be correlated
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := strings.TrimSpace(c.GetHeader("X-Request-ID"))
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		c.Set("requestId", id)
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+// tracingMiddleware records a server span for each request, continuing the caller's traceparent.
+// Handlers reach it through c.Request.Context(). Health checks are not traced.
+func tracingMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "/health" {
+			c.Next()
+			return
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		ctx := tracing.WithRemoteParent(c.Request.Context(), c.GetHeader(tracing.TraceparentHeader))
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route, tracing.KindServer)
+		defer span.End()
+		span.SetAttribute("http.request.method", c.Request.Method)
+		span.SetAttribute("http.route", route)
+		if id := c.GetString("requestId"); id != "" {
+			span.SetAttribute("request.id", id)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if project := c.GetString("project"); project != "" {
+			span.SetAttribute("ambient.project", project)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetError(http.StatusText(status))
+		}
+	}
+}
+
 // forwardedIdentityMiddleware populates Gin context from common OAuth proxy headers
 func forwardedIdentityMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -112,6 +173,11 @@ func RunContentService(registerContentRoutes RouterFunc) error {
 		)
 	}))
 
+	// Continue the backend's trace across proxied content requests
+	if tracing.Enabled() {
+		r.Use(tracingMiddleware())
+	}
+
 	// Register content service routes
 	registerContentRoutes(r)
 
@@ -25,6 +25,8 @@ const (
 	LabelContent     = "content"
 	LabelDisplayName = "display-name"
 	LabelHealth      = "health"
+	LabelErrors      = "errors"
+	LabelReadCache   = "read-cache"
 
 	// Specific component labels for other areas
 	LabelOperations = "operations" // for git operations
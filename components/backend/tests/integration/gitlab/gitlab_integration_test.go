@@ -358,7 +358,8 @@ func TestGitLabClientLogging(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	client := gitlab.NewClient("https://gitlab.com/api/v4", token)
+	client, err := gitlab.NewClient(gitlab.Config{APIURL: "https://gitlab.com/api/v4", Token: token})
+	require.NoError(t, err)
 
 	// Make a simple API request
 	resp, err := gitlab.GetCurrentUser(ctx, client)
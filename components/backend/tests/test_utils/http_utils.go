@@ -224,17 +224,41 @@ func (h *HTTPTestUtils) AssertJSONStructure(expectedKeys []string) {
 	}
 }
 
-// AssertErrorMessage asserts that the response contains an error message
+// AssertErrorMessage asserts that the response contains an error message.
+// Both the structured {"error": {"message": ...}} and legacy {"error": "..."} shapes are accepted.
 func (h *HTTPTestUtils) AssertErrorMessage(expectedMessage string) {
 	var responseData map[string]interface{}
 	h.GetResponseJSON(&responseData)
 
 	Expect(responseData).To(HaveKey("error"), "Response should contain error field")
-	errorMessage := responseData["error"].(string)
+	var errorMessage string
+	switch e := responseData["error"].(type) {
+	case string:
+		errorMessage = e
+	case map[string]interface{}:
+		errorMessage, _ = e["message"].(string)
+	}
 	Expect(errorMessage).To(ContainSubstring(expectedMessage),
 		fmt.Sprintf("Expected error message to contain '%s', got '%s'", expectedMessage, errorMessage))
 }
 
+// AssertErrorResponse asserts a structured error response: status code, error kind and exact message.
+// It returns the error object so callers can inspect details.
+func (h *HTTPTestUtils) AssertErrorResponse(expectedStatus int, expectedKind, expectedMessage string) map[string]interface{} {
+	h.AssertHTTPStatus(expectedStatus)
+
+	var responseData map[string]interface{}
+	h.GetResponseJSON(&responseData)
+
+	Expect(responseData).To(HaveKey("error"), "Response should contain error field")
+	errorObj, ok := responseData["error"].(map[string]interface{})
+	Expect(ok).To(BeTrue(), "Error field should be a structured error object")
+	Expect(errorObj["kind"]).To(Equal(expectedKind))
+	Expect(errorObj["message"]).To(Equal(expectedMessage))
+	Expect(errorObj["requestId"]).NotTo(BeEmpty(), "Error should carry a request ID")
+	return errorObj
+}
+
 // HTTPClient represents a test HTTP client with retry capabilities
 type HTTPClient struct {
 	client         *http.Client
@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// record turns tracing on for one test, keeping ended spans in memory
+func record(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	enable(exporter, "test-service")
+	t.Cleanup(func() { provider = nil })
+	return exporter
+}
+
+func TestDisabledTracingRecordsNothing(t *testing.T) {
+	ctx, span := Start(context.Background(), "request", KindServer)
+	if span != nil {
+		t.Fatalf("Start returned a span with tracing off")
+	}
+	span.SetAttribute("k", "v")
+	span.SetError("boom")
+	span.End()
+
+	ctx = WithRemoteParent(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if tp := Traceparent(ctx); tp != "" {
+		t.Errorf("Traceparent = %q with tracing off, want empty", tp)
+	}
+	header := http.Header{}
+	Inject(ctx, header)
+	if len(header) != 0 {
+		t.Errorf("Inject set %v with tracing off", header)
+	}
+}
+
+func TestSpansContinueRemoteParent(t *testing.T) {
+	record(t)
+
+	ctx := WithRemoteParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	ctx, span := Start(ctx, "POST /api/projects/:projectName/agentic-sessions", KindServer)
+	if span.TraceID() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("TraceID = %q, want the remote parent's", span.TraceID())
+	}
+	if TraceID(ctx) != span.TraceID() {
+		t.Errorf("TraceID(ctx) = %q, want %q", TraceID(ctx), span.TraceID())
+	}
+
+	header := http.Header{}
+	Inject(ctx, header)
+	tp := header.Get(TraceparentHeader)
+	if !strings.HasPrefix(tp, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || strings.Contains(tp, "00f067aa0ba902b7") || !strings.HasSuffix(tp, "-01") {
+		t.Errorf("injected traceparent = %q, want the trace with the new span's ID, sampled", tp)
+	}
+
+	if got := WithRemoteParent(context.Background(), "00-00000000000000000000000000000000-00f067aa0ba902b7-01"); TraceID(got) != "" {
+		t.Errorf("an all-zero trace ID was accepted")
+	}
+	_, root := Start(context.Background(), "root", KindInternal)
+	if root.TraceID() == span.TraceID() {
+		t.Errorf("Start without a parent did not begin a new trace")
+	}
+}
+
+func TestSpansRecordAttributesAndErrors(t *testing.T) {
+	exporter := record(t)
+
+	ctx, parent := Start(context.Background(), "parent", KindServer)
+	_, child := Start(ctx, "child", KindClient)
+	child.SetAttribute("http.response.status_code", 502)
+	child.SetAttribute("retried", true)
+	child.SetError("Bad Gateway")
+	child.End()
+	child.End()
+	parent.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Name != "child" || c.Parent.SpanID() != p.SpanContext.SpanID() || p.Parent.IsValid() {
+		t.Errorf("child %+v is not parented on %+v", c, p)
+	}
+	if c.Status.Code != codes.Error || c.Status.Description != "Bad Gateway" {
+		t.Errorf("child status = %+v", c.Status)
+	}
+	if len(c.Attributes) != 2 || c.Attributes[0].Value.AsInt64() != 502 || !c.Attributes[1].Value.AsBool() {
+		t.Errorf("child attributes = %+v", c.Attributes)
+	}
+}
+
+func TestInitExportsToTheConfiguredCollector(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case got <- r:
+		default:
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer%20collector")
+	Init("test-service")
+	if !Enabled() {
+		t.Fatalf("Init did not enable tracing")
+	}
+	t.Cleanup(func() { provider = nil })
+
+	_, span := Start(context.Background(), "request", KindServer)
+	span.End()
+	if err := provider.ForceFlush(context.Background()); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	r := <-got
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Authorization") != "Bearer collector" {
+		t.Errorf("collector got %s with Authorization %q", r.URL.Path, r.Header.Get("Authorization"))
+	}
+}
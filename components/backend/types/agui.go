@@ -97,6 +97,16 @@ type Message struct {
 	Metadata   interface{} `json:"metadata,omitempty"`
 }
 
+// TranscriptMessage is one line of a session's chat transcript (sessions/<name>/messages.jsonl)
+type TranscriptMessage struct {
+	Seq       int64  `json:"seq"`
+	MessageID string `json:"messageId,omitempty"`
+	RunID     string `json:"runId,omitempty"`
+	Role      string `json:"role"`
+	Content   string `json:"content"`
+	Timestamp string `json:"timestamp"`
+}
+
 // ToolCall represents a tool call made by the assistant
 type ToolCall struct {
 	ID              string `json:"id"`
@@ -0,0 +1,16 @@
+package types
+
+// CommitSigningConfig is ProjectSettings spec.commitSigning: the key that signs commits pushed
+// from the project's sessions. Exactly one of SSHKeySecretRef and GPGKeySecretRef is set.
+type CommitSigningConfig struct {
+	Enabled         bool                 `json:"enabled"`
+	SSHKeySecretRef *CommitSigningKeyRef `json:"sshKeySecretRef,omitempty"` // OpenSSH private key
+	GPGKeySecretRef *CommitSigningKeyRef `json:"gpgKeySecretRef,omitempty"` // ASCII-armored GPG secret key
+	SignerEmail     string               `json:"signerEmail,omitempty"`     // Committer email, verified for the key's owner
+}
+
+// CommitSigningKeyRef selects the key of a Secret in the project namespace holding a signing key
+type CommitSigningKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"` // Defaults to ssh-privatekey for SSH keys and private.key for GPG keys
+}
@@ -101,6 +101,8 @@ type PaginationParams struct {
 	Offset   int    `form:"offset"`   // Offset for offset-based pagination
 	Continue string `form:"continue"` // Continuation token for k8s-style pagination
 	Search   string `form:"search"`   // Search/filter term
+	SortBy   string `form:"sortBy"`   // Sort key; each list endpoint documents the keys it accepts
+	Order    string `form:"order"`    // "asc" or "desc"; the default depends on the sort key
 }
 
 // PaginatedResponse is a generic paginated response structure
@@ -1,5 +1,7 @@
 package types
 
+import "fmt"
+
 // GetProviderSpecificGuidance returns remediation guidance for provider-specific errors
 func GetProviderSpecificGuidance(provider ProviderType, errorType string) string {
 	switch provider {
@@ -29,3 +31,13 @@ func GetProviderSpecificGuidance(provider ProviderType, errorType string) string
 		return "Check your repository configuration and try again"
 	}
 }
+
+// RepoExistsError is returned when a repository to be created already exists on the provider
+type RepoExistsError struct {
+	URL string // Clone URL of the existing repository
+}
+
+// Error implements the error interface
+func (e *RepoExistsError) Error() string {
+	return fmt.Sprintf("repository %s already exists", e.URL)
+}
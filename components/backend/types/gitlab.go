@@ -11,6 +11,21 @@ type GitLabConnection struct {
 	UpdatedAt    time.Time `json:"updatedAt"`    // Last connection update
 }
 
+// GitLabInstanceConfig is ProjectSettings spec.gitlab: the GitLab instance a project uses,
+// typically self-hosted behind a private CA
+type GitLabInstanceConfig struct {
+	BaseURL               string              `json:"baseUrl"`                         // e.g., "https://gitlab.corp.example.com"
+	InsecureSkipTLSVerify bool                `json:"insecureSkipTLSVerify,omitempty"` // Skip certificate verification
+	CABundleSecretRef     *GitLabSecretKeyRef `json:"caBundleSecretRef,omitempty"`     // PEM CA bundle in a project Secret
+	TokenSecretKey        string              `json:"tokenSecretKey,omitempty"`        // Key in ambient-non-vertex-integrations holding a token
+}
+
+// GitLabSecretKeyRef selects a key of a Secret in the project namespace
+type GitLabSecretKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key,omitempty"` // Defaults to "ca.crt"
+}
+
 // ParsedGitLabRepo extends GitRepository for GitLab-specific attributes.
 // Internal parsed representation (not persisted to CRD)
 type ParsedGitLabRepo struct {
@@ -47,6 +62,15 @@ type GitLabBranch struct {
 	Default   bool         `json:"default"`
 }
 
+// GitLabProject is a repository listed by the GitLab /projects API
+type GitLabProject struct {
+	ID                int    `json:"id"`
+	PathWithNamespace string `json:"path_with_namespace"` // e.g. "group/subgroup/repo"
+	DefaultBranch     string `json:"default_branch"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	Visibility        string `json:"visibility"` // "private", "internal" or "public"
+}
+
 // GitLabCommit represents commit information
 type GitLabCommit struct {
 	ID            string    `json:"id"`          // SHA
@@ -10,10 +10,42 @@ type AmbientProject struct {
 	CreationTimestamp string            `json:"creationTimestamp"`
 	Status            string            `json:"status"`
 	IsOpenShift       bool              `json:"isOpenShift"` // true if running on OpenShift cluster
+	// Populated by GET /api/projects?includeStats=true
+	Sessions     map[string]int `json:"sessions,omitzero"`      // session counts by lower-cased phase
+	LastActivity string         `json:"lastActivity,omitempty"` // most recent session start time
+	StatsError   string         `json:"statsError,omitempty"`   // set when the namespace's sessions could not be listed
 }
 
+// CreateProjectRequest is the body of POST /api/projects (see openapi/openapi.json)
 type CreateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	DisplayName string `json:"displayName,omitempty"` // Optional: only used on OpenShift
 	Description string `json:"description,omitempty"` // Optional: only used on OpenShift
 }
+
+// OnboardProjectRequest is the body of POST /api/projects/:projectName/onboard
+type OnboardProjectRequest struct {
+	// CopyFrom names another project whose runner and integration secret values are copied
+	// into keys that are still empty here; the caller must administer both projects
+	CopyFrom string `json:"copyFrom,omitempty"`
+}
+
+// OnboardingItem is one entry of the onboarding checklist
+type OnboardingItem struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Secret      string   `json:"secret"`
+	Keys        []string `json:"keys"`
+	Configured  bool     `json:"configured"`
+}
+
+// OnboardProjectResponse reports the provisioned secrets and what is still missing
+type OnboardProjectResponse struct {
+	RunnerSecret      string           `json:"runnerSecret"`
+	IntegrationSecret string           `json:"integrationSecret"`
+	Created           []string         `json:"created,omitempty"`
+	Annotated         []string         `json:"annotated,omitempty"`
+	CopiedKeys        []string         `json:"copiedKeys,omitempty"`
+	Checklist         []OnboardingItem `json:"checklist"`
+	Missing           []string         `json:"missing"`
+}
@@ -24,17 +24,47 @@ type AgenticSessionSpec struct {
 	Repos []SimpleRepo `json:"repos,omitempty"`
 	// Active workflow for dynamic workflow switching
 	ActiveWorkflow *WorkflowSelection `json:"activeWorkflow,omitempty"`
+	// Scheduling priority when the project is at capacity (low, normal, high)
+	Priority string `json:"priority,omitempty"`
+	// Runner container image override (empty uses the project or operator default)
+	RunnerImage string `json:"runnerImage,omitempty"`
+	// Set by the backend instead of InitialPrompt when the prompt is too large to keep inline
+	PromptRef *PromptRef `json:"promptRef,omitempty"`
+	// Session is stopped once the runner reports a cumulative cost above this (USD)
+	CostLimitUSD *float64 `json:"costLimitUSD,omitempty"`
+	// Size of the workspace PVC (e.g. "50Gi"); empty uses the operator default of 5Gi
+	WorkspaceSize string `json:"workspaceSize,omitempty"`
 }
 
+// PromptRef points at the ConfigMap key holding a session's initial prompt
+type PromptRef struct {
+	ConfigMapName string `json:"configMapName"`
+	Key           string `json:"key"`
+}
+
+// Session scheduling priorities honoured by the operator's queue
+const (
+	SessionPriorityLow    = "low"
+	SessionPriorityNormal = "normal"
+	SessionPriorityHigh   = "high"
+)
+
 // SimpleRepo represents a simplified repository configuration
 type SimpleRepo struct {
 	URL    string  `json:"url"`
 	Branch *string `json:"branch,omitempty"`
+	// Ref pins the repo to a full commit SHA or a tag instead of a branch; the runner checks it out
+	// detached. Mutually exclusive with Branch.
+	Ref string `json:"ref,omitempty"`
+	// Folder is the workspace directory the repo is cloned into. The backend assigns one when
+	// unset, appending -<owner> when two repos would otherwise share a folder.
+	Folder string `json:"folder,omitempty"`
 }
 
 type AgenticSessionStatus struct {
 	ObservedGeneration int64               `json:"observedGeneration,omitempty"`
 	Phase              string              `json:"phase,omitempty"`
+	QueuedAt           *string             `json:"queuedAt,omitempty"`
 	StartTime          *string             `json:"startTime,omitempty"`
 	CompletionTime     *string             `json:"completionTime,omitempty"`
 	ReconciledRepos    []ReconciledRepo    `json:"reconciledRepos,omitempty"`
@@ -42,8 +72,48 @@ type AgenticSessionStatus struct {
 	SDKSessionID       string              `json:"sdkSessionId,omitempty"`
 	SDKRestartCount    int                 `json:"sdkRestartCount,omitempty"`
 	Conditions         []Condition         `json:"conditions,omitempty"`
+	RunnerAuth         *RunnerAuth         `json:"runnerAuth,omitempty"`
+	Artifacts          []SessionArtifact   `json:"artifacts,omitempty"`
+	// TotalCostUSD is the cumulative cost the runner last reported
+	TotalCostUSD *float64 `json:"totalCostUSD,omitempty"`
+	// StoppedReason is "costLimit" when the backend stopped the session for exceeding spec.costLimitUSD
+	StoppedReason string `json:"stoppedReason,omitempty"`
+	// Usage sums the runner's usage_delta reports: token counters and total_cost_usd
+	Usage map[string]interface{} `json:"usage,omitempty"`
+	// UsageSeq is the usage_seq of the last usage_delta counted into Usage
+	UsageSeq int64 `json:"usageSeq,omitempty"`
+	// Timeline records when the latest start reached each startup milestone
+	Timeline *SessionTimeline `json:"timeline,omitempty"`
+}
+
+// SessionTimeline holds RFC3339 times of a start's milestones. CreatedAt is when the session was
+// created or its restart requested; the backend sets TokenProvisionedAt and the operator the rest.
+type SessionTimeline struct {
+	CreatedAt          *string `json:"createdAt,omitempty"`
+	TokenProvisionedAt *string `json:"tokenProvisionedAt,omitempty"`
+	JobCreatedAt       *string `json:"jobCreatedAt,omitempty"`
+	PodScheduledAt     *string `json:"podScheduledAt,omitempty"`
+	RunnerStartedAt    *string `json:"runnerStartedAt,omitempty"`
+}
+
+// SessionArtifact is a runner-published result file, addressed relative to the session workspace
+type SessionArtifact struct {
+	Name        string `json:"name"`
+	Path        string `json:"path"`
+	ContentType string `json:"contentType,omitempty"`
+	SizeBytes   int64  `json:"sizeBytes,omitempty"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+	// DownloadURL is only set in API responses and points at the workspace file proxy
+	DownloadURL string `json:"downloadUrl,omitempty"`
+}
+
+// RunnerAuth describes the runner token Secret and when its token expires
+type RunnerAuth struct {
+	SecretName     string  `json:"secretName"`
+	TokenExpiresAt *string `json:"tokenExpiresAt,omitempty"`
 }
 
+// CreateAgenticSessionRequest is the body of POST /api/projects/:projectName/agentic-sessions (see openapi/openapi.json)
 type CreateAgenticSessionRequest struct {
 	InitialPrompt   string       `json:"initialPrompt,omitempty"`
 	DisplayName     string       `json:"displayName,omitempty"`
@@ -52,17 +122,37 @@ type CreateAgenticSessionRequest struct {
 	Interactive     *bool        `json:"interactive,omitempty"`
 	ParentSessionID string       `json:"parent_session_id,omitempty"`
 	// Multi-repo support
-	Repos                []SimpleRepo      `json:"repos,omitempty"`
+	Repos []SimpleRepo `json:"repos,omitempty"`
+	// MergeDefaults adds ProjectSettings spec.defaultRepos to Repos; without it they are used only
+	// when Repos is empty
+	MergeDefaults        bool              `json:"mergeDefaults,omitempty"`
 	AutoPushOnComplete   *bool             `json:"autoPushOnComplete,omitempty"`
 	UserContext          *UserContext      `json:"userContext,omitempty"`
 	EnvironmentVariables map[string]string `json:"environmentVariables,omitempty"`
 	Labels               map[string]string `json:"labels,omitempty"`
 	Annotations          map[string]string `json:"annotations,omitempty"`
+	Priority             string            `json:"priority,omitempty"`
+	// RunnerImage overrides the runner container image; it must match ProjectSettings spec.allowedRunnerImages
+	RunnerImage string `json:"runnerImage,omitempty"`
+	// Queue creates the session in the Queued phase instead of failing with 429 when the project
+	// is at ProjectSettings spec.maxConcurrentSessions
+	Queue bool `json:"queue,omitempty"`
+	// CostLimitUSD stops the session once its reported cost exceeds it; defaults to and may not
+	// exceed the project's ProjectSettings limits
+	CostLimitUSD *float64 `json:"costLimitUSD,omitempty"`
+	// WorkspaceSize sizes the workspace PVC; it may not exceed ProjectSettings spec.maxWorkspaceSize
+	WorkspaceSize string `json:"workspaceSize,omitempty"`
+	// TemplatePrompt expands InitialPrompt as a Go template against ProjectSettings
+	// spec.promptVariables and fields derived from the request
+	TemplatePrompt bool `json:"templatePrompt,omitempty"`
 }
 
 type CloneSessionRequest struct {
 	TargetProject  string `json:"targetProject" binding:"required"`
 	NewSessionName string `json:"newSessionName" binding:"required"`
+	// Queue creates the clone in the Queued phase instead of failing with 429 when the target
+	// project is at ProjectSettings spec.maxConcurrentSessions
+	Queue bool `json:"queue,omitempty"`
 }
 
 type UpdateAgenticSessionRequest struct {
@@ -70,6 +160,9 @@ type UpdateAgenticSessionRequest struct {
 	DisplayName   *string      `json:"displayName,omitempty"`
 	Timeout       *int         `json:"timeout,omitempty"`
 	LLMSettings   *LLMSettings `json:"llmSettings,omitempty"`
+	Interactive   *bool        `json:"interactive,omitempty"`
+	// Labels are merged into metadata.labels; they stay editable after the session starts
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 type CloneAgenticSessionRequest struct {
@@ -93,6 +186,26 @@ type ReconciledRepo struct {
 	Name     string  `json:"name,omitempty"`
 	Status   string  `json:"status,omitempty"`
 	ClonedAt *string `json:"clonedAt,omitempty"`
+	// Commit the runner cloned and the last commit pushed from the session
+	ClonedSHA    *string `json:"clonedSha,omitempty"`
+	ClonedBranch *string `json:"clonedBranch,omitempty"`
+	PushedSHA    *string `json:"pushedSha,omitempty"`
+	PushedBranch *string `json:"pushedBranch,omitempty"`
+	// Git state the runner last reported (cloned, dirty, pushed, abandoned, error) and when
+	GitStatus   *string `json:"gitStatus,omitempty"`
+	LastUpdated *string `json:"lastUpdated,omitempty"`
+	// Set when output.autoFork pushed to a fork: the output repo it was forked from, and the fork
+	ForkOf  *string `json:"forkOf,omitempty"`
+	ForkURL *string `json:"forkUrl,omitempty"`
+	// Possible secrets found by the pre-push scan of the last push attempt
+	SecretFindings []SecretFinding `json:"secretFindings,omitempty"`
+}
+
+// SecretFinding is a pushed line that looks like a credential
+type SecretFinding struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Rule string `json:"rule"`
 }
 
 // ReconciledWorkflow captures reconciliation state for the active workflow
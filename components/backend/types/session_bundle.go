@@ -0,0 +1,58 @@
+package types
+
+// SessionBundleKind identifies a session export bundle
+const SessionBundleKind = "AgenticSessionBundle"
+
+// SessionBundleVersion is the bundle format written by export. Bump it (and teach import to
+// read the previous versions) whenever the bundle schema changes incompatibly.
+const SessionBundleVersion = "v1"
+
+// SessionBundle is a portable session definition produced by
+// GET /api/projects/:projectName/agentic-sessions/:sessionName/bundle and accepted by
+// POST /api/projects/:projectName/agentic-sessions/import. It carries no workspace content.
+type SessionBundle struct {
+	Kind       string              `json:"kind"`
+	Version    string              `json:"version"`
+	ExportedAt string              `json:"exportedAt,omitempty"`
+	Source     SessionBundleSource `json:"source"`
+	Spec       SessionBundleSpec   `json:"spec"`
+	// Annotations are the user-supplied annotations; system-managed ones are not exported
+	Annotations  map[string]string    `json:"annotations,omitempty"`
+	RepoStatuses []ReconciledRepo     `json:"repoStatuses,omitempty"`
+	Result       *SessionBundleResult `json:"result,omitempty"`
+	// Redacted lists the fields whose values were removed on export (e.g. spec.environmentVariables.API_TOKEN)
+	Redacted []string `json:"redacted,omitempty"`
+}
+
+// SessionBundleSource records where a bundle was exported from
+type SessionBundleSource struct {
+	Project string `json:"project"`
+	Name    string `json:"name"`
+}
+
+// SessionBundleSpec is the portable subset of the session spec. Caller identity (userContext),
+// bot accounts and the owning project are cluster-specific and are set again on import.
+type SessionBundleSpec struct {
+	DisplayName          string             `json:"displayName,omitempty"`
+	InitialPrompt        string             `json:"initialPrompt,omitempty"`
+	Interactive          bool               `json:"interactive,omitempty"`
+	LLMSettings          *LLMSettings       `json:"llmSettings,omitempty"`
+	Timeout              int                `json:"timeout,omitempty"`
+	Priority             string             `json:"priority,omitempty"`
+	AutoPushOnComplete   *bool              `json:"autoPushOnComplete,omitempty"`
+	Repos                []SimpleRepo       `json:"repos,omitempty"`
+	ActiveWorkflow       *WorkflowSelection `json:"activeWorkflow,omitempty"`
+	ResourceOverrides    *ResourceOverrides `json:"resourceOverrides,omitempty"`
+	EnvironmentVariables map[string]string  `json:"environmentVariables,omitempty"`
+	RunnerImage          string             `json:"runnerImage,omitempty"`
+	CostLimitUSD         *float64           `json:"costLimitUSD,omitempty"`
+	WorkspaceSize        string             `json:"workspaceSize,omitempty"`
+}
+
+// SessionBundleResult summarizes how the exported session ended (or where it was)
+type SessionBundleResult struct {
+	Phase          string      `json:"phase,omitempty"`
+	StartTime      *string     `json:"startTime,omitempty"`
+	CompletionTime *string     `json:"completionTime,omitempty"`
+	Conditions     []Condition `json:"conditions,omitempty"`
+}
@@ -110,6 +110,9 @@ func RouteAGUIEvent(sessionID string, event map[string]interface{}) {
 		return
 	}
 
+	// Transcript assembly relies on stream order, so record before the async persistence below
+	recordTranscriptEvent(sessionID, event)
+
 	// Find active run for this session
 	var activeRunState *AGUIRunState
 	aguiRunsMu.RLock()
@@ -298,7 +301,8 @@ func extractBaseEvent(event interface{}) (*types.BaseEvent, bool) {
 
 // streamThreadEvents streams events from ALL runs in a thread (session)
 // This is the correct AG-UI pattern: client connects to thread, not individual runs
-func streamThreadEvents(c *gin.Context, projectName, sessionName string) {
+func streamThreadEvents(c *gin.Context, access *streamAccess) {
+	projectName, sessionName := access.projectName, access.sessionName
 	threadID := sessionName
 	eventCh := make(chan interface{}, 100)
 	ctx := c.Request.Context()
@@ -464,10 +468,29 @@ func streamThreadEvents(c *gin.Context, projectName, sessionName string) {
 	keepaliveTicker := time.NewTicker(15 * time.Second)
 	defer keepaliveTicker.Stop()
 
+	// Warn the client before spec.timeout elapses
+	timeoutTicker := time.NewTicker(timeoutCheckInterval)
+	defer timeoutTicker.Stop()
+	warningLead := handlers.TimeoutWarningLead()
+	var warnedDeadline time.Time
+
+	// Re-authorize periodically so revoked users are disconnected
+	accessTicker := time.NewTicker(handlers.SessionAccessRecheckInterval())
+	defer accessTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-accessTicker.C:
+			if !access.stillAllowed(ctx) {
+				return
+			}
+		case <-timeoutTicker.C:
+			if warning := timeoutWarningEvent(fetchSessionForTimeout(projectName, sessionName), time.Now(), warningLead, &warnedDeadline); warning != nil {
+				writeSSEEvent(c.Writer, warning)
+				c.Writer.(http.Flusher).Flush()
+			}
 		case <-keepaliveTicker.C:
 			// Send SSE comment to prevent gateway timeout
 			_, err := c.Writer.Write([]byte(": keepalive\n\n"))
@@ -494,32 +517,9 @@ func HandleAGUIEvents(c *gin.Context) {
 	sessionName := c.Param("sessionName")
 	runID := c.Query("runId")
 
-	// SECURITY: Authenticate user and get user-scoped K8s client
-	reqK8s, _ := handlers.GetK8sClientsForRequest(c)
-	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
-		c.Abort()
-		return
-	}
-
-	// SECURITY: Verify user has permission to read this session
-	ctx := context.Background()
-	ssar := &authv1.SelfSubjectAccessReview{
-		Spec: authv1.SelfSubjectAccessReviewSpec{
-			ResourceAttributes: &authv1.ResourceAttributes{
-				Group:     "vteam.ambient-code",
-				Resource:  "agenticsessions",
-				Verb:      "get",
-				Namespace: projectName,
-				Name:      sessionName,
-			},
-		},
-	}
-	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, metav1.CreateOptions{})
-	if err != nil || !res.Status.Allowed {
-		log.Printf("AGUI Events: User not authorized to read session %s/%s", projectName, sessionName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
-		c.Abort()
+	// SECURITY: Verify the caller may read this session and that it exists; a guessed name is not enough
+	access, ok := authorizeSessionStream(c, projectName, sessionName)
+	if !ok {
 		return
 	}
 
@@ -532,7 +532,7 @@ func HandleAGUIEvents(c *gin.Context) {
 	// If no runId specified, stream the entire THREAD (all runs for this session)
 	// This is the correct AG-UI pattern: client connects once to thread stream
 	if runID == "" {
-		streamThreadEvents(c, projectName, sessionName)
+		streamThreadEvents(c, access)
 		return
 	}
 
@@ -586,11 +586,18 @@ func HandleAGUIEvents(c *gin.Context) {
 	// Create context for client disconnection
 	streamCtx := c.Request.Context()
 
+	accessTicker := time.NewTicker(handlers.SessionAccessRecheckInterval())
+	defer accessTicker.Stop()
+
 	// Stream events
 	for {
 		select {
 		case <-streamCtx.Done():
 			return
+		case <-accessTicker.C:
+			if !access.stillAllowed(streamCtx) {
+				return
+			}
 		case event, ok := <-fullEventCh:
 			if !ok {
 				return
@@ -3,6 +3,7 @@ package websocket
 
 import (
 	"ambient-code-backend/handlers"
+	"ambient-code-backend/httpclient"
 	"ambient-code-backend/types"
 	"bufio"
 	"bytes"
@@ -20,6 +21,7 @@ import (
 	authv1 "k8s.io/api/authorization/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
 )
 
 // HandleAGUIRunProxy proxies AG-UI run requests to runner's FastAPI server
@@ -28,12 +30,48 @@ func HandleAGUIRunProxy(c *gin.Context) {
 	projectName := c.Param("projectName")
 	sessionName := c.Param("sessionName")
 
+	if _, ok := authorizeSessionUpdate(c, projectName, sessionName); !ok {
+		return
+	}
+
+	log.Printf("AGUI Proxy: Forwarding run request for %s/%s", projectName, sessionName)
+
+	var input types.RunAgentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		log.Printf("AGUI Proxy: Failed to parse input: %v", err)
+		handlers.RespondError(c, http.StatusBadRequest, handlers.ErrorKindValidation, fmt.Sprintf("invalid input: %v", err), nil)
+		return
+	}
+	log.Printf("AGUI Proxy: Input has %d messages", len(input.Messages))
+
+	threadID, runID, err := startAGUIRun(projectName, sessionName, input)
+	if err != nil {
+		log.Printf("AGUI Proxy: Failed to start run: %v", err)
+		handlers.RespondError(c, http.StatusServiceUnavailable, handlers.ErrorKindUpstreamUnavailable, "Runner not available", nil)
+		return
+	}
+
+	// Return run metadata immediately (don't wait for stream)
+	// Events will be broadcast to GET /agui/events subscribers
+	streamURL := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/agui/events", projectName, sessionName)
+
+	c.JSON(http.StatusOK, gin.H{
+		"threadId":  threadID,
+		"runId":     runID,
+		"streamUrl": streamURL,
+		"status":    "started",
+	})
+}
+
+// authorizeSessionUpdate checks that the caller may update the session, writing 401 or 403
+// otherwise, and returns the caller's dynamic client
+func authorizeSessionUpdate(c *gin.Context, projectName, sessionName string) (dynamic.Interface, bool) {
 	// SECURITY: Authenticate user and get user-scoped K8s client
-	reqK8s, _ := handlers.GetK8sClientsForRequest(c)
-	if reqK8s == nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or missing token"})
+	reqK8s, reqDyn := handlers.GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		handlers.RespondError(c, http.StatusUnauthorized, handlers.ErrorKindUnauthorized, "Invalid or missing token", nil)
 		c.Abort()
-		return
+		return nil, false
 	}
 
 	// SECURITY: Verify user has permission to update this session
@@ -52,21 +90,16 @@ func HandleAGUIRunProxy(c *gin.Context) {
 	res, err := reqK8s.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, ssar, metav1.CreateOptions{})
 	if err != nil || !res.Status.Allowed {
 		log.Printf("AGUI Proxy: User not authorized to update session %s/%s", projectName, sessionName)
-		c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized"})
+		handlers.RespondError(c, http.StatusForbidden, handlers.ErrorKindForbidden, "Unauthorized", nil)
 		c.Abort()
-		return
+		return nil, false
 	}
+	return reqDyn, true
+}
 
-	log.Printf("AGUI Proxy: Forwarding run request for %s/%s", projectName, sessionName)
-
-	var input types.RunAgentInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Printf("AGUI Proxy: Failed to parse input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid input: %v", err)})
-		return
-	}
-	log.Printf("AGUI Proxy: Input has %d messages", len(input.Messages))
-
+// startAGUIRun registers a run for input and streams it from the runner in the background,
+// persisting and broadcasting its events. It returns the run's thread and run IDs.
+func startAGUIRun(projectName, sessionName string, input types.RunAgentInput) (string, string, error) {
 	// Generate or use provided IDs
 	threadID := input.ThreadID
 	if threadID == "" {
@@ -120,9 +153,8 @@ func HandleAGUIRunProxy(c *gin.Context) {
 	// Get runner endpoint
 	runnerURL, err := getRunnerEndpoint(projectName, sessionName)
 	if err != nil {
-		log.Printf("AGUI Proxy: Failed to get runner endpoint: %v", err)
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Runner not available"})
-		return
+		updateRunStatus(runID, "error")
+		return "", "", fmt.Errorf("get runner endpoint: %w", err)
 	}
 
 	log.Printf("AGUI Proxy: Runner endpoint: %s", runnerURL)
@@ -130,9 +162,8 @@ func HandleAGUIRunProxy(c *gin.Context) {
 	// Serialize input for proxy request
 	bodyBytes, err := json.Marshal(input)
 	if err != nil {
-		log.Printf("AGUI Proxy: Failed to serialize input: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serialize input"})
-		return
+		updateRunStatus(runID, "error")
+		return "", "", fmt.Errorf("serialize input: %w", err)
 	}
 
 	log.Printf("AGUI Proxy: Run %s starting, will consume runner stream in background", runID)
@@ -148,9 +179,7 @@ func HandleAGUIRunProxy(c *gin.Context) {
 		defer cancel()
 
 		// Execute request with retries (runner may not be ready immediately after startup)
-		client := &http.Client{
-			Timeout: 0, // No timeout, context handles it
-		}
+		client := httpclient.Client() // No client timeout, ctx bounds the run
 
 		var resp *http.Response
 		maxRetries := 15
@@ -252,16 +281,7 @@ func HandleAGUIRunProxy(c *gin.Context) {
 		log.Printf("AGUI Proxy: Background stream completed for run %s (status=%s)", runID, currentStatus)
 	}()
 
-	// Return run metadata immediately (don't wait for stream)
-	// Events will be broadcast to GET /agui/events subscribers
-	streamURL := fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/agui/events", projectName, sessionName)
-
-	c.JSON(http.StatusOK, gin.H{
-		"threadId":  threadID,
-		"runId":     runID,
-		"streamUrl": streamURL,
-		"status":    "started",
-	})
+	return threadID, runID, nil
 }
 
 // handleStreamedEvent parses and persists a streamed AG-UI event
@@ -292,6 +312,7 @@ func handleStreamedEvent(sessionID, runID, threadID, jsonData string, runState *
 
 	// Persist event
 	persistAGUIEventMap(sessionID, runID, event)
+	recordTranscriptEvent(sessionID, event)
 
 	// Broadcast to subscribers (for SSE /events endpoint)
 	if runState != nil {
@@ -386,8 +407,7 @@ func HandleAGUIInterrupt(c *gin.Context) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := httpclient.Do(req, httpclient.Read)
 	if err != nil {
 		log.Printf("AGUI Interrupt: Request failed: %v", err)
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"ambient-code-backend/handlers"
+	"ambient-code-backend/types"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// SendSessionMessageRequest is the body of POST .../agentic-sessions/:sessionName/messages
+type SendSessionMessageRequest struct {
+	Content string `json:"content"`
+	// Role defaults to "user", the only role a client may send
+	Role string `json:"role,omitempty"`
+}
+
+// HandleSendSessionMessage sends a follow-up prompt to a running interactive session without
+// holding a stream open. The message is written to the transcript, which assigns its sequence
+// number, and delivered to the runner as a new AG-UI run; the reply is read by polling
+// GET .../messages?offset=<seq>.
+// POST /api/projects/:projectName/agentic-sessions/:sessionName/messages
+func HandleSendSessionMessage(c *gin.Context) {
+	projectName := c.Param("projectName")
+	sessionName := c.Param("sessionName")
+
+	reqDyn, ok := authorizeSessionUpdate(c, projectName, sessionName)
+	if !ok {
+		return
+	}
+
+	var req SendSessionMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handlers.RespondError(c, http.StatusBadRequest, handlers.ErrorKindValidation, "Invalid request body", nil)
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		handlers.RespondError(c, http.StatusBadRequest, handlers.ErrorKindValidation, "content is required", nil)
+		return
+	}
+	if req.Role != "" && req.Role != types.RoleUser {
+		handlers.RespondError(c, http.StatusBadRequest, handlers.ErrorKindValidation, "role must be user", nil)
+		return
+	}
+
+	session, err := reqDyn.Resource(handlers.GetAgenticSessionV1Alpha1Resource()).Namespace(projectName).Get(c.Request.Context(), sessionName, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			handlers.RespondError(c, http.StatusNotFound, handlers.ErrorKindNotFound, "Session not found", nil)
+			return
+		}
+		log.Printf("Session Messages: failed to read session %s/%s: %v", projectName, sessionName, err)
+		handlers.RespondError(c, http.StatusInternalServerError, handlers.ErrorKindInternal, "Failed to read session", nil)
+		return
+	}
+	phase, _, _ := unstructured.NestedString(session.Object, "status", "phase")
+	interactive, _, _ := unstructured.NestedBool(session.Object, "spec", "interactive")
+	if phase != "Running" || !interactive {
+		handlers.RespondError(c, http.StatusConflict, handlers.ErrorKindConflict, "Session is not accepting input", gin.H{
+			"phase":       phase,
+			"interactive": interactive,
+		})
+		return
+	}
+
+	messageID := uuid.New().String()
+	seq := recordUserMessage(sessionName, messageID, content)
+	input := types.RunAgentInput{Messages: []types.Message{{ID: messageID, Role: types.RoleUser, Content: content}}}
+	threadID, runID, err := startAGUIRun(projectName, sessionName, input)
+	if err != nil {
+		log.Printf("Session Messages: failed to deliver message %d to %s/%s: %v", seq, projectName, sessionName, err)
+		handlers.RespondError(c, http.StatusServiceUnavailable, handlers.ErrorKindUpstreamUnavailable, "Runner not available", nil)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"seq":         seq,
+		"messageId":   messageID,
+		"threadId":    threadID,
+		"runId":       runID,
+		"streamUrl":   fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/agui/events", projectName, sessionName),
+		"messagesUrl": fmt.Sprintf("/api/projects/%s/agentic-sessions/%s/messages?offset=%d", projectName, sessionName, seq),
+	})
+}
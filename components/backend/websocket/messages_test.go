@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorBody decodes the structured {error: {kind, message, requestId}} response
+func errorBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var body struct {
+		Error map[string]interface{} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == nil {
+		t.Fatalf("response %q is not a structured error", w.Body.String())
+	}
+	if body.Error["requestId"] == "" {
+		t.Errorf("error has no requestId: %v", body.Error)
+	}
+	return body.Error
+}
+
+func TestSendSessionMessageRequiresTokenWithStructuredError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/projects/p1/agentic-sessions/s1/messages", strings.NewReader(`{"content":"hi"}`))
+	c.Params = gin.Params{{Key: "projectName", Value: "p1"}, {Key: "sessionName", Value: "s1"}}
+
+	HandleSendSessionMessage(c)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if e := errorBody(t, w); e["kind"] != "Unauthorized" || e["message"] != "Invalid or missing token" {
+		t.Errorf("error = %v", e)
+	}
+}
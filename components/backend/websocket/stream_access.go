@@ -0,0 +1,73 @@
+package websocket
+
+import (
+	"ambient-code-backend/handlers"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// streamAccess holds the caller's clients for a long-lived session stream so access can be
+// re-checked with the same credentials while the stream is open
+type streamAccess struct {
+	k8s         kubernetes.Interface
+	dyn         dynamic.Interface
+	projectName string
+	sessionName string
+}
+
+// authorizeSessionStream checks before a stream opens that the caller may get the session and that
+// it exists, writing the error response otherwise
+func authorizeSessionStream(c *gin.Context, projectName, sessionName string) (*streamAccess, bool) {
+	reqK8s, reqDyn := handlers.GetK8sClientsForRequest(c)
+	if reqK8s == nil || reqDyn == nil {
+		handlers.RespondError(c, http.StatusUnauthorized, handlers.ErrorKindUnauthorized, "Invalid or missing token", nil)
+		c.Abort()
+		return nil, false
+	}
+	err := handlers.CheckSessionAccess(c.Request.Context(), reqK8s, reqDyn, projectName, sessionName)
+	if err != nil {
+		respondSessionAccessError(c, projectName, sessionName, err)
+		return nil, false
+	}
+	return &streamAccess{k8s: reqK8s, dyn: reqDyn, projectName: projectName, sessionName: sessionName}, true
+}
+
+// respondSessionAccessError answers a failed CheckSessionAccess: 403 when the access review denies
+// the caller, 404 only when the session does not exist, 401 for a rejected token and 500 otherwise
+func respondSessionAccessError(c *gin.Context, projectName, sessionName string, err error) {
+	switch {
+	case errors.Is(err, handlers.ErrSessionAccessDenied):
+		log.Printf("AGUI Events: User not authorized to read session %s/%s", projectName, sessionName)
+		handlers.RespondError(c, http.StatusForbidden, handlers.ErrorKindForbidden, "Not allowed to access session", nil)
+	case k8serrors.IsNotFound(err):
+		handlers.RespondError(c, http.StatusNotFound, handlers.ErrorKindNotFound, "Session not found", nil)
+	case k8serrors.IsUnauthorized(err):
+		handlers.RespondError(c, http.StatusUnauthorized, handlers.ErrorKindUnauthorized, "Invalid or missing token", nil)
+	default:
+		log.Printf("AGUI Events: access check for session %s/%s failed: %v", projectName, sessionName, err)
+		handlers.RespondError(c, http.StatusInternalServerError, handlers.ErrorKindInternal, "Failed to check permissions", nil)
+	}
+	c.Abort()
+}
+
+// stillAllowed re-runs the access check for an open stream. It reports false when access was revoked,
+// the token expired or the session was deleted; other API errors keep the stream open.
+func (a *streamAccess) stillAllowed(ctx context.Context) bool {
+	err := handlers.CheckSessionAccess(ctx, a.k8s, a.dyn, a.projectName, a.sessionName)
+	if err == nil {
+		return true
+	}
+	if errors.Is(err, handlers.ErrSessionAccessDenied) || k8serrors.IsNotFound(err) || k8serrors.IsUnauthorized(err) {
+		log.Printf("AGUI Events: closing stream for %s/%s: %v", a.projectName, a.sessionName, err)
+		return false
+	}
+	log.Printf("AGUI Events: access recheck for %s/%s failed, keeping stream open: %v", a.projectName, a.sessionName, err)
+	return true
+}
@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"ambient-code-backend/handlers"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestAuthorizeSessionStreamRequiresTokenWithStructuredError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/projects/p1/agentic-sessions/s1/agui/events", nil)
+
+	if access, ok := authorizeSessionStream(c, "p1", "s1"); ok || access != nil {
+		t.Fatalf("stream authorized without a token")
+	}
+
+	if w.Code != http.StatusUnauthorized || !c.IsAborted() {
+		t.Fatalf("status = %d aborted = %v, want an aborted 401", w.Code, c.IsAborted())
+	}
+	if e := errorBody(t, w); e["kind"] != "Unauthorized" {
+		t.Errorf("error = %v", e)
+	}
+}
+
+func TestRespondSessionAccessErrorSeparatesDeniedFromMissing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	notFound := k8serrors.NewNotFound(schema.GroupResource{Group: "vteam.ambient-code", Resource: "agenticsessions"}, "s1")
+	cases := []struct {
+		err  error
+		code int
+		kind string
+	}{
+		{handlers.ErrSessionAccessDenied, http.StatusForbidden, "Forbidden"},
+		{notFound, http.StatusNotFound, "NotFound"},
+		{fmt.Errorf("access review for session p1/s1: %w", errors.New("connection refused")), http.StatusInternalServerError, "Internal"},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/projects/p1/agentic-sessions/s1/agui/events", nil)
+
+		respondSessionAccessError(c, "p1", "s1", tc.err)
+
+		if w.Code != tc.code || !c.IsAborted() {
+			t.Errorf("%v: status = %d aborted = %v, want an aborted %d", tc.err, w.Code, c.IsAborted(), tc.code)
+		}
+		if e := errorBody(t, w); e["kind"] != tc.kind {
+			t.Errorf("%v: error = %v, want kind %s", tc.err, e, tc.kind)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"ambient-code-backend/handlers"
+	"ambient-code-backend/types"
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// timeoutCheckInterval is how often an open thread stream re-reads the session deadline
+const timeoutCheckInterval = 30 * time.Second
+
+// fetchSessionForTimeout reads the session through the shared read cache when enabled. The stream
+// was authorized when it opened, so the backend service account is used for the periodic reads.
+func fetchSessionForTimeout(projectName, sessionName string) *unstructured.Unstructured {
+	gvr := handlers.GetAgenticSessionV1Alpha1Resource()
+	if handlers.SessionReadCache != nil {
+		if obj, ok := handlers.SessionReadCache.Get(projectName, gvr, sessionName); ok {
+			return obj
+		}
+	}
+	if handlers.DynamicClient == nil {
+		return nil
+	}
+	obj, err := handlers.DynamicClient.Resource(gvr).Namespace(projectName).Get(context.Background(), sessionName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+// timeoutWarningEvent returns a RAW event carrying a timeout_warning once the session is within lead
+// of its deadline. warned holds the deadline already warned about, so each deadline is announced once
+// and extending the timeout re-arms the warning.
+func timeoutWarningEvent(session *unstructured.Unstructured, now time.Time, lead time.Duration, warned *time.Time) *types.RawEvent {
+	if session == nil {
+		return nil
+	}
+	deadline, ok := handlers.SessionDeadline(session)
+	if !ok || deadline.Equal(*warned) {
+		return nil
+	}
+	remaining := deadline.Sub(now)
+	if remaining <= 0 || remaining > lead {
+		return nil
+	}
+	*warned = deadline
+	return &types.RawEvent{
+		BaseEvent: types.NewBaseEvent(types.EventTypeRaw, session.GetName(), ""),
+		Data: map[string]interface{}{
+			"type":             "timeout_warning",
+			"secondsRemaining": int64(remaining.Seconds()),
+			"deadline":         deadline.UTC().Format(time.RFC3339),
+		},
+	}
+}
@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func runningSession(start time.Time, timeout int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetName("s1")
+	_ = unstructured.SetNestedField(obj.Object, timeout, "spec", "timeout")
+	_ = unstructured.SetNestedField(obj.Object, "Running", "status", "phase")
+	_ = unstructured.SetNestedField(obj.Object, start.UTC().Format(time.RFC3339), "status", "startTime")
+	return obj
+}
+
+func TestTimeoutWarningEventWarnsOncePerDeadline(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	session := runningSession(start, 600)
+	lead := 2 * time.Minute
+	var warned time.Time
+
+	if ev := timeoutWarningEvent(session, start.Add(5*time.Minute), lead, &warned); ev != nil {
+		t.Fatalf("warned %v before the lead time", ev.Data)
+	}
+	ev := timeoutWarningEvent(session, start.Add(9*time.Minute), lead, &warned)
+	if ev == nil {
+		t.Fatalf("no warning within the lead time")
+	}
+	data := ev.Data.(map[string]interface{})
+	if data["type"] != "timeout_warning" || data["secondsRemaining"] != int64(60) || data["deadline"] != "2026-01-01T10:10:00Z" {
+		t.Errorf("warning data = %v", data)
+	}
+	if ev := timeoutWarningEvent(session, start.Add(9*time.Minute+30*time.Second), lead, &warned); ev != nil {
+		t.Errorf("warned twice about the same deadline")
+	}
+
+	// Extending the timeout moves the deadline and re-arms the warning
+	extended := runningSession(start, 1200)
+	if ev := timeoutWarningEvent(extended, start.Add(10*time.Minute), lead, &warned); ev != nil {
+		t.Errorf("warned about the extended deadline too early")
+	}
+	if ev := timeoutWarningEvent(extended, start.Add(19*time.Minute), lead, &warned); ev == nil {
+		t.Errorf("no warning for the extended deadline")
+	}
+}
+
+func TestTimeoutWarningEventIgnoresPastAndMissingDeadlines(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	var warned time.Time
+
+	if ev := timeoutWarningEvent(runningSession(start, 600), start.Add(11*time.Minute), time.Minute, &warned); ev != nil {
+		t.Errorf("warned after the deadline passed")
+	}
+	if ev := timeoutWarningEvent(nil, start, time.Minute, &warned); ev != nil {
+		t.Errorf("warned for a missing session")
+	}
+	if ev := timeoutWarningEvent(runningSession(start, 0), start, time.Hour, &warned); ev != nil {
+		t.Errorf("warned for a session without a timeout")
+	}
+}
@@ -0,0 +1,193 @@
+package websocket
+
+import (
+	"ambient-code-backend/types"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// sessionTranscript assembles streamed TEXT_MESSAGE_* events into complete transcript lines
+type sessionTranscript struct {
+	mu      sync.Mutex
+	loaded  bool
+	nextSeq int64
+	open    map[string]*types.TranscriptMessage // messageId -> message being streamed
+	// recorded holds messages sent over REST, already in the transcript, whose runner echo is skipped
+	recorded map[string]bool
+	lastUsed time.Time // guarded by transcriptsMu
+}
+
+// transcriptIdleTTL is how long a session's transcript state is kept without events. Every finished
+// message is already on the state volume, so an evicted session recounts it on its next message.
+const transcriptIdleTTL = 30 * time.Minute
+
+var (
+	transcripts   = make(map[string]*sessionTranscript) // sessionID -> transcript state
+	transcriptsMu sync.Mutex
+)
+
+// Periodically drop the transcript state of sessions that stopped streaming
+func init() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		for range ticker.C {
+			evictIdleTranscripts(time.Now().Add(-transcriptIdleTTL))
+		}
+	}()
+}
+
+// evictIdleTranscripts forgets transcripts not used since cutoff, so deleted and finished sessions
+// do not stay in memory
+func evictIdleTranscripts(cutoff time.Time) {
+	transcriptsMu.Lock()
+	defer transcriptsMu.Unlock()
+	for sessionID, t := range transcripts {
+		if t.lastUsed.Before(cutoff) {
+			delete(transcripts, sessionID)
+		}
+	}
+}
+
+// transcriptPath is the chat transcript file served by GET .../agentic-sessions/:sessionName/messages
+func transcriptPath(sessionID string) string {
+	return fmt.Sprintf("%s/sessions/%s/messages.jsonl", StateBaseDir, sessionID)
+}
+
+func getSessionTranscript(sessionID string) *sessionTranscript {
+	transcriptsMu.Lock()
+	defer transcriptsMu.Unlock()
+	t, ok := transcripts[sessionID]
+	if !ok {
+		t = &sessionTranscript{open: make(map[string]*types.TranscriptMessage), recorded: make(map[string]bool)}
+		transcripts[sessionID] = t
+	}
+	t.lastUsed = time.Now()
+	return t
+}
+
+// recordTranscriptEvent appends a user or assistant message to the session transcript once its
+// TEXT_MESSAGE_END arrives. Events must be passed in stream order.
+func recordTranscriptEvent(sessionID string, event map[string]interface{}) {
+	eventType, _ := event["type"].(string)
+	switch eventType {
+	case types.EventTypeTextMessageStart, types.EventTypeTextMessageContent, types.EventTypeTextMessageEnd:
+	default:
+		return
+	}
+
+	// Handle both camelCase and snake_case
+	messageID, _ := event["messageId"].(string)
+	if messageID == "" {
+		messageID, _ = event["message_id"].(string)
+	}
+	if messageID == "" {
+		return
+	}
+
+	t := getSessionTranscript(sessionID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.recorded[messageID] {
+		if eventType == types.EventTypeTextMessageEnd {
+			delete(t.recorded, messageID)
+		}
+		return
+	}
+
+	msg, ok := t.open[messageID]
+	if !ok {
+		runID, _ := event["runId"].(string)
+		msg = &types.TranscriptMessage{MessageID: messageID, RunID: runID, Role: types.RoleAssistant}
+		t.open[messageID] = msg
+	}
+
+	switch eventType {
+	case types.EventTypeTextMessageStart:
+		if role, _ := event["role"].(string); role != "" {
+			msg.Role = role
+		}
+	case types.EventTypeTextMessageContent:
+		delta, _ := event["delta"].(string)
+		msg.Content += delta
+	case types.EventTypeTextMessageEnd:
+		delete(t.open, messageID)
+		if msg.Content == "" {
+			return
+		}
+		t.append(sessionID, msg)
+	}
+}
+
+// recordUserMessage appends a user message sent over REST to the transcript before it is delivered,
+// so the sender gets its sequence number, and returns that number. The runner's echo of the message
+// is left out of the transcript.
+func recordUserMessage(sessionID, messageID, content string) int64 {
+	t := getSessionTranscript(sessionID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	msg := &types.TranscriptMessage{MessageID: messageID, Role: types.RoleUser, Content: content}
+	t.append(sessionID, msg)
+	t.recorded[messageID] = true
+	return msg.Seq
+}
+
+// append assigns msg the next sequence number and writes it; t.mu must be held
+func (t *sessionTranscript) append(sessionID string, msg *types.TranscriptMessage) {
+	if !t.loaded {
+		t.nextSeq = countTranscriptLines(sessionID)
+		t.loaded = true
+	}
+	t.nextSeq++
+	msg.Seq = t.nextSeq
+	msg.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	appendTranscriptMessage(sessionID, msg)
+}
+
+// countTranscriptLines returns the number of messages already on disk so sequence numbers
+// continue across backend restarts
+func countTranscriptLines(sessionID string) int64 {
+	data, err := os.ReadFile(transcriptPath(sessionID))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Transcript: failed to read %s: %v", transcriptPath(sessionID), err)
+		}
+		return 0
+	}
+	return int64(len(splitLines(data)))
+}
+
+// ReadSessionTranscript returns the session's transcript file, or nil when none has been written yet
+func ReadSessionTranscript(sessionID string) ([]byte, error) {
+	data, err := os.ReadFile(transcriptPath(sessionID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func appendTranscriptMessage(sessionID string, msg *types.TranscriptMessage) {
+	_ = ensureDir(fmt.Sprintf("%s/sessions/%s", StateBaseDir, sessionID))
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("Transcript: failed to marshal message: %v", err)
+		return
+	}
+
+	f, err := openFileAppend(transcriptPath(sessionID))
+	if err != nil {
+		log.Printf("Transcript: failed to open transcript: %v", err)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		log.Printf("Transcript: failed to write message: %v", err)
+	}
+}
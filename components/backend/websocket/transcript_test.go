@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-backend/handlers"
+	"ambient-code-backend/k8s"
+	"ambient-code-backend/types"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/client-go/rest"
+)
+
+// fakeSessionAPI answers GETs of agentic sessions for the caller-scoped clients
+func fakeSessionAPI(t *testing.T, session string) *rest.Config {
+	t.Helper()
+	saved := handlers.GetAgenticSessionV1Alpha1Resource
+	t.Cleanup(func() { handlers.GetAgenticSessionV1Alpha1Resource = saved })
+	handlers.GetAgenticSessionV1Alpha1Resource = k8s.GetAgenticSessionV1Alpha1Resource
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, "/agenticsessions/"+session) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"vteam.ambient-code/v1alpha1","kind":"AgenticSession","metadata":{"name":"` + session + `","namespace":"p1"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return &rest.Config{Host: srv.URL}
+}
+
+func TestRecordedTranscriptIsServedByGetSessionMessages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const session = "transcript-roundtrip"
+	savedBase, savedKube, savedRead := StateBaseDir, handlers.BaseKubeConfig, handlers.ReadSessionTranscript
+	t.Cleanup(func() {
+		StateBaseDir, handlers.BaseKubeConfig, handlers.ReadSessionTranscript = savedBase, savedKube, savedRead
+		transcriptsMu.Lock()
+		delete(transcripts, session)
+		transcriptsMu.Unlock()
+	})
+	StateBaseDir = t.TempDir()
+	handlers.BaseKubeConfig = fakeSessionAPI(t, session)
+	handlers.ReadSessionTranscript = ReadSessionTranscript
+
+	recordUserMessage(session, "m1", "Hello")
+	for _, event := range []map[string]interface{}{
+		{"type": types.EventTypeTextMessageStart, "messageId": "m2", "role": types.RoleAssistant},
+		{"type": types.EventTypeTextMessageContent, "messageId": "m2", "delta": "Hi "},
+		{"type": types.EventTypeTextMessageContent, "messageId": "m2", "delta": "there"},
+		{"type": types.EventTypeTextMessageEnd, "messageId": "m2"},
+	} {
+		recordTranscriptEvent(session, event)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/projects/p1/agentic-sessions/"+session+"/messages", nil)
+	c.Request.Header.Set("Authorization", "Bearer user-token")
+	c.Params = gin.Params{{Key: "projectName", Value: "p1"}, {Key: "sessionName", Value: session}}
+
+	handlers.GetSessionMessages(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	var page struct {
+		Items      []types.TranscriptMessage `json:"items"`
+		TotalCount int                       `json:"totalCount"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if page.TotalCount != 2 || len(page.Items) != 2 {
+		t.Fatalf("got %d of %d messages, want 2: %s", len(page.Items), page.TotalCount, w.Body.String())
+	}
+	if got := page.Items[0]; got.Seq != 1 || got.Role != types.RoleUser || got.Content != "Hello" {
+		t.Errorf("first message = %+v", got)
+	}
+	if got := page.Items[1]; got.Seq != 2 || got.Role != types.RoleAssistant || got.Content != "Hi there" {
+		t.Errorf("second message = %+v", got)
+	}
+}
+
+func TestGetSessionMessagesOfUnknownSessionIsNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	savedKube := handlers.BaseKubeConfig
+	t.Cleanup(func() { handlers.BaseKubeConfig = savedKube })
+	handlers.BaseKubeConfig = fakeSessionAPI(t, "other-session")
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/projects/p1/agentic-sessions/missing/messages", nil)
+	c.Request.Header.Set("Authorization", "Bearer user-token")
+	c.Params = gin.Params{{Key: "projectName", Value: "p1"}, {Key: "sessionName", Value: "missing"}}
+
+	handlers.GetSessionMessages(c)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestIdleTranscriptIsEvictedAndSequenceContinuesFromDisk(t *testing.T) {
+	const session = "transcript-evict"
+	savedBase := StateBaseDir
+	t.Cleanup(func() {
+		StateBaseDir = savedBase
+		transcriptsMu.Lock()
+		delete(transcripts, session)
+		transcriptsMu.Unlock()
+	})
+	StateBaseDir = t.TempDir()
+
+	if seq := recordUserMessage(session, "m1", "Hello"); seq != 1 {
+		t.Fatalf("first seq = %d, want 1", seq)
+	}
+	evictIdleTranscripts(time.Now().Add(-time.Minute))
+	transcriptsMu.Lock()
+	_, kept := transcripts[session]
+	transcriptsMu.Unlock()
+	if !kept {
+		t.Fatalf("transcript evicted before it was idle")
+	}
+
+	evictIdleTranscripts(time.Now().Add(time.Minute))
+	transcriptsMu.Lock()
+	_, kept = transcripts[session]
+	transcriptsMu.Unlock()
+	if kept {
+		t.Fatalf("idle transcript was not evicted")
+	}
+
+	if seq := recordUserMessage(session, "m2", "Again"); seq != 2 {
+		t.Errorf("seq after eviction = %d, want 2", seq)
+	}
+}
@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"ambient-code-backend/handlers"
+	"ambient-code-backend/types"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+)
+
+// maxEventLineBytes bounds a single persisted event when scanning the event log
+const maxEventLineBytes = 16 * 1024 * 1024
+
+// SummarizeSessionUsage adds up the cost and turns the runner reported in its /lastResult state
+// deltas. The values come from the Claude SDK result of each run and are cumulative for one runner
+// process, so a value lower than the previous one marks a restart and starts a new segment.
+// It reports false when the session has no recorded results.
+func SummarizeSessionUsage(sessionName string) (handlers.SessionUsage, bool) {
+	path := fmt.Sprintf("%s/sessions/%s/agui-events.jsonl", StateBaseDir, sessionName)
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("SummarizeSessionUsage: failed to open event log for %s: %v", sessionName, err)
+		}
+		return handlers.SessionUsage{}, false
+	}
+	defer f.Close()
+
+	var (
+		found                bool
+		costBase, lastCost   float64
+		turnsBase, lastTurns int
+		marker               = []byte(`"/lastResult"`)
+	)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLineBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Only state deltas replacing /lastResult are relevant; skip decoding everything else
+		if !bytes.Contains(line, marker) {
+			continue
+		}
+		var event struct {
+			Type  string `json:"type"`
+			Delta []struct {
+				Path  string `json:"path"`
+				Value struct {
+					TotalCostUSD *float64 `json:"total_cost_usd"`
+					NumTurns     *int     `json:"num_turns"`
+				} `json:"value"`
+			} `json:"delta"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil || event.Type != "STATE_DELTA" {
+			continue
+		}
+		for _, op := range event.Delta {
+			if op.Path != "/lastResult" {
+				continue
+			}
+			found = true
+			if cost := op.Value.TotalCostUSD; cost != nil {
+				if *cost < lastCost {
+					costBase += lastCost
+				}
+				lastCost = *cost
+			}
+			if turns := op.Value.NumTurns; turns != nil {
+				if *turns < lastTurns {
+					turnsBase += lastTurns
+				}
+				lastTurns = *turns
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("SummarizeSessionUsage: failed to read event log for %s: %v", sessionName, err)
+	}
+	return handlers.SessionUsage{TotalCostUSD: costBase + lastCost, NumTurns: turnsBase + lastTurns}, found
+}
+
+// PublishUsageUpdate sends a usage_update RAW event with a session's status.usage totals to the
+// clients streaming its thread. The totals are kept in the session status, so the event is not
+// persisted to the event log.
+func PublishUsageUpdate(sessionName string, usage map[string]interface{}, seq int64) {
+	event := &types.RawEvent{
+		BaseEvent: types.NewBaseEvent(types.EventTypeRaw, sessionName, ""),
+		Data: map[string]interface{}{
+			"type":     "usage_update",
+			"usage":    usage,
+			"usageSeq": seq,
+		},
+	}
+	threadSubscribersMu.RLock()
+	defer threadSubscribersMu.RUnlock()
+	for ch := range threadSubscribers[sessionName] {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is behind; it reads the totals from the session on its next fetch
+		}
+	}
+}
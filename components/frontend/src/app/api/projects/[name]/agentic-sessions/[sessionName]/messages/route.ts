@@ -0,0 +1,29 @@
+/**
+ * Session Transcript Endpoint Proxy
+ * Returns the persisted chat transcript (paginated, ?offset=&limit=&order=asc|desc).
+ */
+
+import { BACKEND_URL } from '@/lib/config'
+import { buildForwardHeadersAsync } from '@/lib/auth'
+
+export async function GET(
+  request: Request,
+  { params }: { params: Promise<{ name: string; sessionName: string }> },
+) {
+  const { name, sessionName } = await params
+  const url = new URL(request.url)
+  const headers = await buildForwardHeadersAsync(request)
+
+  const backendUrl = `${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/messages${url.search}`
+
+  const resp = await fetch(backendUrl, {
+    method: 'GET',
+    headers,
+  })
+
+  const data = await resp.text()
+  return new Response(data, {
+    status: resp.status,
+    headers: { 'Content-Type': 'application/json' },
+  })
+}
@@ -1,19 +1,50 @@
 import { buildForwardHeadersAsync } from '@/lib/auth'
 import { BACKEND_URL } from '@/lib/config';
 
-export async function GET(
+// Headers passed back to the browser; the body is streamed rather than buffered so large files
+// and Range requests (206) work
+const PASSTHROUGH_HEADERS = ['content-type', 'content-length', 'content-range', 'accept-ranges', 'etag', 'last-modified']
+
+// Request headers forwarded so unchanged files cost a 304 without a body
+const CONDITIONAL_HEADERS = ['if-none-match', 'if-modified-since']
+
+async function proxyRead(
+  method: 'GET' | 'HEAD',
   request: Request,
   { params }: { params: Promise<{ name: string; sessionName: string; path: string[] }> },
 ) {
   const { name, sessionName, path } = await params
   const headers = await buildForwardHeadersAsync(request)
   const rel = path.join('/')
-  const resp = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/workspace/${encodeURIComponent(rel)}`, { headers })
-  const contentType = resp.headers.get('content-type') || 'application/octet-stream'
-  const buf = await resp.arrayBuffer()
-  return new Response(buf, { status: resp.status, headers: { 'Content-Type': contentType } })
+  const forwardHeaders: Record<string, string> = { ...headers }
+  for (const h of method === 'GET' ? ['range', ...CONDITIONAL_HEADERS] : CONDITIONAL_HEADERS) {
+    const v = request.headers.get(h)
+    if (v) forwardHeaders[h] = v
+  }
+  const resp = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/workspace/${encodeURIComponent(rel)}`, { method, headers: forwardHeaders })
+  const respHeaders: Record<string, string> = { 'content-type': 'application/octet-stream' }
+  for (const h of PASSTHROUGH_HEADERS) {
+    const v = resp.headers.get(h)
+    if (v) respHeaders[h] = v
+  }
+  const body = method === 'HEAD' || resp.status === 304 ? null : resp.body
+  return new Response(body, { status: resp.status, headers: respHeaders })
 }
 
+export async function GET(
+  request: Request,
+  context: { params: Promise<{ name: string; sessionName: string; path: string[] }> },
+) {
+  return proxyRead('GET', request, context)
+}
+
+// HEAD returns the file's size, ETag and Last-Modified without its content
+export async function HEAD(
+  request: Request,
+  context: { params: Promise<{ name: string; sessionName: string; path: string[] }> },
+) {
+  return proxyRead('HEAD', request, context)
+}
 
 export async function PUT(
   request: Request,
@@ -23,14 +54,22 @@ export async function PUT(
   const headers = await buildForwardHeadersAsync(request)
   const rel = path.join('/')
   const contentType = request.headers.get('content-type') || 'text/plain; charset=utf-8'
-  const textBody = await request.text()
-  const resp = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/workspace/${encodeURIComponent(rel)}`, {
+  // Forward raw bytes so binary content is not mangled by text decoding
+  const body = await request.arrayBuffer()
+  const forwardHeaders: Record<string, string> = { ...headers, 'Content-Type': contentType }
+  const ifMatch = request.headers.get('if-match')
+  if (ifMatch) forwardHeaders['If-Match'] = ifMatch
+  const { search } = new URL(request.url)
+  const resp = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/agentic-sessions/${encodeURIComponent(sessionName)}/workspace/${encodeURIComponent(rel)}${search}`, {
     method: 'PUT',
-    headers: { ...headers, 'Content-Type': contentType },
-    body: textBody,
+    headers: forwardHeaders,
+    body,
   })
   const respBody = await resp.text()
-  return new Response(respBody, { status: resp.status, headers: { 'Content-Type': 'application/json' } })
+  const respHeaders: Record<string, string> = { 'Content-Type': 'application/json' }
+  const etag = resp.headers.get('etag')
+  if (etag) respHeaders['ETag'] = etag
+  return new Response(respBody, { status: resp.status, headers: respHeaders })
 }
 
 export async function DELETE(
@@ -0,0 +1,22 @@
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+
+// POST /api/projects/[name]/gitlab-config/test
+export async function POST(
+  request: Request,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  try {
+    const { name } = await params;
+    const headers = await buildForwardHeadersAsync(request);
+    const response = await fetch(`${BACKEND_URL}/projects/${encodeURIComponent(name)}/gitlab-config/test`, {
+      method: 'POST',
+      headers,
+    });
+    const text = await response.text();
+    return new Response(text, { status: response.status, headers: { 'Content-Type': 'application/json' } });
+  } catch (error) {
+    console.error('Error testing GitLab config:', error);
+    return Response.json({ error: 'Failed to test GitLab config' }, { status: 500 });
+  }
+}
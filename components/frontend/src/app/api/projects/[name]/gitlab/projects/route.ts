@@ -0,0 +1,36 @@
+import { NextRequest } from 'next/server';
+import { BACKEND_URL } from '@/lib/config';
+import { buildForwardHeadersAsync } from '@/lib/auth';
+
+const PAGINATION_HEADERS = ['X-Page', 'X-Per-Page', 'X-Next-Page', 'X-Prev-Page', 'X-Total', 'X-Total-Pages'];
+
+// Copy GitLab's pagination headers through from the backend
+function paginationHeaders(response: Response): Headers {
+  const headers = new Headers({ 'Content-Type': 'application/json' });
+  for (const name of PAGINATION_HEADERS) {
+    const value = response.headers.get(name);
+    if (value) headers.set(name, value);
+  }
+  return headers;
+}
+
+// GET /api/projects/[name]/gitlab/projects
+export async function GET(
+  request: NextRequest,
+  { params }: { params: Promise<{ name: string }> }
+) {
+  try {
+    const { name } = await params;
+    const headers = await buildForwardHeadersAsync(request);
+    const query = request.nextUrl.searchParams.toString();
+    const response = await fetch(
+      `${BACKEND_URL}/projects/${encodeURIComponent(name)}/gitlab/projects${query ? `?${query}` : ''}`,
+      { headers }
+    );
+    const text = await response.text();
+    return new Response(text, { status: response.status, headers: paginationHeaders(response) });
+  } catch (error) {
+    console.error('Error listing GitLab projects:', error);
+    return Response.json({ error: 'Failed to list GitLab projects' }, { status: 500 });
+  }
+}
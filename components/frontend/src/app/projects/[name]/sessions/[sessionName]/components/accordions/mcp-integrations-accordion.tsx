@@ -9,6 +9,7 @@ import {
 } from '@/components/ui/accordion'
 import { Button } from '@/components/ui/button'
 import { Badge } from '@/components/ui/badge'
+import { getApiErrorMessage } from '@/types/api'
 
 type McpIntegrationsAccordionProps = {
   projectName: string
@@ -33,7 +34,7 @@ export function McpIntegrationsAccordion({
 
       if (!response.ok) {
         const error = await response.json()
-        throw new Error(error.error || 'Failed to get OAuth URL')
+        throw new Error(getApiErrorMessage(error.error) || 'Failed to get OAuth URL')
       }
 
       const data = await response.json()
@@ -9,6 +9,7 @@ import { Button } from "@/components/ui/button";
 type Repository = {
   url: string;
   branch?: string;
+  folder?: string;
 };
 
 type UploadedFile = {
@@ -95,7 +96,7 @@ export function RepositoriesAccordion({
             <div className="space-y-2">
               {/* Repositories */}
               {repositories.map((repo, idx) => {
-                const repoName = repo.url.split('/').pop()?.replace('.git', '') || `repo-${idx}`;
+                const repoName = repo.folder || repo.url.split('/').pop()?.replace('.git', '') || `repo-${idx}`;
                 const isRemoving = removingRepo === repoName;
 
                 return (
@@ -2,6 +2,7 @@
 
 import { useState, useCallback } from "react";
 import { successToast, errorToast } from "@/hooks/use-toast";
+import { getApiErrorMessage } from "@/types/api";
 import type { WorkflowConfig } from "../lib/types";
 
 type UseWorkflowManagementProps = {
@@ -45,7 +46,7 @@ export function useWorkflowManagement({
       
       if (!response.ok) {
         const errorData = await response.json();
-        throw new Error(errorData.error || "Failed to update workflow");
+        throw new Error(getApiErrorMessage(errorData.error) || "Failed to update workflow");
       }
       
       // Note: Workflow clone and restart handled by operator
@@ -58,12 +58,14 @@ export type WorkflowCommand = {
   slashCommand: string;
   description?: string;
   icon?: string;
+  source?: string;
 };
 
 export type WorkflowAgent = {
   id: string;
   name: string;
   description?: string;
+  source?: string;
 };
 
 export type WorkflowMetadata = {
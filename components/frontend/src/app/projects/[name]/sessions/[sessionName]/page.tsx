@@ -82,6 +82,7 @@ import type { DirectoryOption, DirectoryRemote } from "./lib/types";
 
 import type { MessageObject, ToolUseMessages, HierarchicalToolMessage } from "@/types/agentic-session";
 import type { AGUIToolCall } from "@/types/agui";
+import { getApiErrorMessage } from "@/types/api";
 
 // AG-UI streaming
 import { useAGUIStream } from "@/hooks/use-agui-stream";
@@ -367,7 +368,7 @@ export default function ProjectSessionDetailPage({
 
       if (!response.ok) {
         const error = await response.json();
-        throw new Error(error.error || "Upload failed");
+        throw new Error(getApiErrorMessage(error.error) || "Upload failed");
       }
 
       return response.json();
@@ -397,7 +398,7 @@ export default function ProjectSessionDetailPage({
 
       if (!response.ok) {
         const error = await response.json();
-        throw new Error(error.error || "Failed to remove file");
+        throw new Error(getApiErrorMessage(error.error) || "Failed to remove file");
       }
 
       return response.json();
@@ -562,7 +563,7 @@ export default function ProjectSessionDetailPage({
 
     if (session?.spec?.repos) {
       session.spec.repos.forEach((repo, idx) => {
-        const repoName = repo.url.split('/').pop()?.replace('.git', '') || `repo-${idx}`;
+        const repoName = repo.folder || repo.url.split('/').pop()?.replace('.git', '') || `repo-${idx}`;
         options.push({
           type: "repo",
           name: repoName,
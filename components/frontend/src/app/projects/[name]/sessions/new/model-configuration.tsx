@@ -4,20 +4,15 @@ import { Control } from "react-hook-form";
 import { FormControl, FormDescription, FormField, FormItem, FormLabel, FormMessage } from "@/components/ui/form";
 import { Input } from "@/components/ui/input";
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
-
-const models = [
-  { value: "claude-sonnet-4-5", label: "Claude Sonnet 4.5" },
-  { value: "claude-opus-4-5", label: "Claude Opus 4.5" },
-  { value: "claude-opus-4-1", label: "Claude Opus 4.1" },
-  { value: "claude-haiku-4-5", label: "Claude Haiku 4.5" },
-];
+import type { ModelInfo } from "@/types/api";
 
 type ModelConfigurationProps = {
   // eslint-disable-next-line @typescript-eslint/no-explicit-any
   control: Control<any>;
+  models: ModelInfo[];
 };
 
-export function ModelConfiguration({ control }: ModelConfigurationProps) {
+export function ModelConfiguration({ control, models }: ModelConfigurationProps) {
   return (
     <div className="space-y-4">
       <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
@@ -27,7 +22,7 @@ export function ModelConfiguration({ control }: ModelConfigurationProps) {
           render={({ field }) => (
             <FormItem>
               <FormLabel>Model</FormLabel>
-              <Select onValueChange={field.onChange} defaultValue={field.value}>
+              <Select onValueChange={field.onChange} value={field.value}>
                 <FormControl>
                   <SelectTrigger>
                     <SelectValue placeholder="Select a model" />
@@ -35,8 +30,8 @@ export function ModelConfiguration({ control }: ModelConfigurationProps) {
                 </FormControl>
                 <SelectContent>
                   {models.map((m) => (
-                    <SelectItem key={m.value} value={m.value}>
-                      {m.label}
+                    <SelectItem key={m.id} value={m.id}>
+                      {m.name}
                     </SelectItem>
                   ))}
                 </SelectContent>
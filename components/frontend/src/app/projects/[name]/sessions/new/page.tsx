@@ -20,6 +20,7 @@ import { RepositoryDialog } from "./repository-dialog";
 import { RepositoryList } from "./repository-list";
 import { ModelConfiguration } from "./model-configuration";
 import { useCreateSession } from "@/services/queries/use-sessions";
+import { useProjectModels } from "@/services/queries/use-projects";
 
 const formSchema = z
   .object({
@@ -63,6 +64,7 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
 
   // React Query hooks
   const createSessionMutation = useCreateSession();
+  const { data: modelsData } = useProjectModels(projectName);
 
   useEffect(() => {
     params.then(({ name }) => setProjectName(name));
@@ -72,7 +74,7 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
     resolver: zodResolver(formSchema),
     defaultValues: {
       initialPrompt: "",
-      model: "claude-sonnet-4-5",
+      model: "",
       temperature: 0.7,
       maxTokens: 4000,
       timeout: 300,
@@ -82,6 +84,15 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
     },
   });
 
+  // Select the project's default model until the user picks one the project offers
+  useEffect(() => {
+    if (!modelsData) return;
+    const current = form.getValues("model");
+    if (!modelsData.models.some((m) => m.id === current)) {
+      form.setValue("model", modelsData.default);
+    }
+  }, [modelsData, form]);
+
   // Field arrays for multi-repo configuration
   const { append: appendRepo, remove: removeRepo, update: updateRepo } = useFieldArray({ control: form.control, name: "repos" });
 
@@ -197,7 +208,7 @@ export default function NewProjectSessionPage({ params }: { params: Promise<{ na
               )}
 
 
-              <ModelConfiguration control={form.control} />
+              <ModelConfiguration control={form.control} models={modelsData?.models ?? []} />
 
               {/* Multi-agent selection */}
               <div className="space-y-2">
@@ -4,7 +4,10 @@ import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Select, SelectContent, SelectItem, SelectTrigger, SelectValue } from "@/components/ui/select";
 import { Dialog, DialogContent, DialogDescription, DialogHeader, DialogTitle } from "@/components/ui/dialog";
-import { useRepoBranches } from "@/services/queries";
+import { useState } from "react";
+import { useDebounce } from "@/hooks/use-debounce";
+import { useGitLabProjectBranches, useGitLabProjects, useRepoBranches } from "@/services/queries";
+import type { GitLabProject } from "@/types/api";
 
 type Repo = {
   url: string;
@@ -30,12 +33,30 @@ export function RepositoryDialog({
   isEditing,
   projectName,
 }: RepositoryDialogProps) {
-  // Fetch branches for the repository
-  const { data: branchesData, isLoading: branchesLoading } = useRepoBranches(
+  // Browse the project's configured GitLab instance; the section stays hidden when none is configured
+  const [gitlabSearch, setGitlabSearch] = useState("");
+  const [gitlabPage, setGitlabPage] = useState(1);
+  const [gitlabProject, setGitlabProject] = useState<GitLabProject | null>(null);
+  const debouncedGitlabSearch = useDebounce(gitlabSearch, 300);
+  const { data: gitlabProjects, isError: gitlabUnavailable, isFetching: gitlabLoading } = useGitLabProjects(
+    projectName,
+    { search: debouncedGitlabSearch, membership: true, page: gitlabPage },
+    { enabled: open }
+  );
+  const pickedGitlabProject = gitlabProject && gitlabProject.http_url_to_repo === repo.url ? gitlabProject : null;
+
+  // Fetch branches for the repository, by id for a project picked from GitLab
+  const repoBranches = useRepoBranches(
     projectName,
     repo.url,
-    { enabled: !!repo.url && open }
+    { enabled: !!repo.url && open && !pickedGitlabProject }
+  );
+  const gitlabBranches = useGitLabProjectBranches(
+    projectName,
+    pickedGitlabProject?.id,
+    { enabled: open }
   );
+  const { data: branchesData, isLoading: branchesLoading } = pickedGitlabProject ? gitlabBranches : repoBranches;
 
   return (
     <Dialog open={open} onOpenChange={onOpenChange}>
@@ -45,6 +66,59 @@ export function RepositoryDialog({
           <DialogDescription>Configure repository URL and branch</DialogDescription>
         </DialogHeader>
         <div className="space-y-4 py-4">
+          {!gitlabUnavailable && gitlabProjects && (
+            <div className="space-y-2">
+              <label className="text-sm font-medium">GitLab projects</label>
+              <Input
+                placeholder="Search by group, subgroup or project"
+                value={gitlabSearch}
+                onChange={(e) => {
+                  setGitlabSearch(e.target.value);
+                  setGitlabPage(1);
+                }}
+              />
+              <div className="max-h-48 overflow-y-auto rounded-md border">
+                {gitlabProjects.projects.length === 0 ? (
+                  <p className="p-2 text-xs text-muted-foreground">
+                    {gitlabLoading ? "Loading projects..." : "No projects found"}
+                  </p>
+                ) : (
+                  gitlabProjects.projects.map((project) => (
+                    <button
+                      key={project.id}
+                      type="button"
+                      className={`flex w-full items-center justify-between px-2 py-1.5 text-left text-sm hover:bg-muted ${
+                        pickedGitlabProject?.id === project.id ? "bg-muted" : ""
+                      }`}
+                      onClick={() => {
+                        setGitlabProject(project);
+                        onRepoChange({ url: project.http_url_to_repo, branch: project.default_branch || repo.branch });
+                      }}
+                    >
+                      <span className="truncate">{project.path_with_namespace}</span>
+                      <span className="ml-2 text-xs text-muted-foreground">{project.visibility}</span>
+                    </button>
+                  ))
+                )}
+              </div>
+              {(gitlabPage > 1 || gitlabProjects.nextPage) && (
+                <div className="flex justify-end gap-2">
+                  <Button type="button" variant="outline" size="sm" disabled={gitlabPage <= 1} onClick={() => setGitlabPage(gitlabPage - 1)}>
+                    Previous
+                  </Button>
+                  <Button
+                    type="button"
+                    variant="outline"
+                    size="sm"
+                    disabled={!gitlabProjects.nextPage}
+                    onClick={() => gitlabProjects.nextPage && setGitlabPage(gitlabProjects.nextPage)}
+                  >
+                    Next
+                  </Button>
+                </div>
+              )}
+            </div>
+          )}
           <div className="space-y-2">
             <label className="text-sm font-medium">Repository URL</label>
             <Input
@@ -1,6 +1,6 @@
 "use client";
 
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { useForm } from "react-hook-form";
 import { zodResolver } from "@hookform/resolvers/zod";
 import * as z from "zod";
@@ -35,15 +35,9 @@ import { Input } from "@/components/ui/input";
 import { Accordion, AccordionContent, AccordionItem, AccordionTrigger } from "@/components/ui/accordion";
 import type { CreateAgenticSessionRequest } from "@/types/agentic-session";
 import { useCreateSession } from "@/services/queries/use-sessions";
+import { useProjectModels } from "@/services/queries/use-projects";
 import { successToast, errorToast } from "@/hooks/use-toast";
 
-const models = [
-  { value: "claude-sonnet-4-5", label: "Claude Sonnet 4.5" },
-  { value: "claude-opus-4-5", label: "Claude Opus 4.5" },
-  { value: "claude-opus-4-1", label: "Claude Opus 4.1" },
-  { value: "claude-haiku-4-5", label: "Claude Haiku 4.5" },
-];
-
 const formSchema = z.object({
   model: z.string().min(1, "Please select a model"),
   temperature: z.number().min(0).max(2),
@@ -67,17 +61,28 @@ export function CreateSessionDialog({
   const [open, setOpen] = useState(false);
   const router = useRouter();
   const createSessionMutation = useCreateSession();
+  const { data: modelsData } = useProjectModels(projectName);
+  const models = modelsData?.models ?? [];
 
   const form = useForm<FormValues>({
     resolver: zodResolver(formSchema),
     defaultValues: {
-      model: "claude-sonnet-4-5",
+      model: "",
       temperature: 0.7,
       maxTokens: 4000,
       timeout: 300,
     },
   });
 
+  // Select the project's default model until the user picks one the project offers
+  useEffect(() => {
+    if (!modelsData || !open) return;
+    const current = form.getValues("model");
+    if (!modelsData.models.some((m) => m.id === current)) {
+      form.setValue("model", modelsData.default);
+    }
+  }, [modelsData, open, form]);
+
   const onSubmit = async (values: FormValues) => {
     if (!projectName) return;
 
@@ -139,7 +144,7 @@ export function CreateSessionDialog({
                 render={({ field }) => (
                   <FormItem className="w-full">
                     <FormLabel>Model</FormLabel>
-                    <Select onValueChange={field.onChange} defaultValue={field.value}>
+                    <Select onValueChange={field.onChange} value={field.value}>
                       <FormControl>
                         <SelectTrigger className="w-full">
                           <SelectValue placeholder="Select a model" />
@@ -147,8 +152,8 @@ export function CreateSessionDialog({
                       </FormControl>
                       <SelectContent>
                         {models.map((m) => (
-                          <SelectItem key={m.value} value={m.value}>
-                            {m.label}
+                          <SelectItem key={m.id} value={m.id}>
+                            {m.name}
                           </SelectItem>
                         ))}
                       </SelectContent>
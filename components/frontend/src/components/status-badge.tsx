@@ -125,6 +125,7 @@ export function StatusBadge({
 export function SessionPhaseBadge({ phase }: { phase: string }) {
   const statusMap: Record<string, StatusVariant> = {
     pending: 'pending',
+    queued: 'pending',
     creating: 'pending',
     running: 'running',
     stopping: 'stopping',
@@ -63,7 +63,7 @@ async function parseResponse<T>(response: Response): Promise<T> {
   if (!response.ok) {
     // Only check isApiError if data is an object (not a string/HTML response)
     if (typeof data === 'object' && data !== null && isApiError(data)) {
-      throw new ApiClientError(data.error, data.code, data.details);
+      throw ApiClientError.fromApiError(data, response.status);
     }
     throw new ApiClientError(
       `HTTP ${response.status}: ${response.statusText}`,
@@ -170,6 +170,16 @@ export const apiClient = {
     return request<T>(path, { ...config, method: 'DELETE' });
   },
 
+  /**
+   * GET request that also returns the response headers (e.g. pagination headers)
+   */
+  getWithHeaders: async <T>(path: string, config?: RequestConfig): Promise<{ data: T; headers: Headers }> => {
+    const { params, ...fetchConfig } = config || {};
+    const response = await fetch(buildUrl(path, params), { ...fetchConfig, method: 'GET' });
+    const data = await parseResponse<T>(response);
+    return { data, headers: response.headers };
+  },
+
   /**
    * GET request that returns raw Response (for blob/text content)
    */
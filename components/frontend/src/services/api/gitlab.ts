@@ -0,0 +1,45 @@
+/**
+ * GitLab API service
+ * Lists projects and branches on the project's configured GitLab instance
+ */
+
+import { apiClient } from './client';
+import type {
+  GitLabProject,
+  ListBranchesResponse,
+  ListGitLabProjectsParams,
+  ListGitLabProjectsResponse,
+} from '@/types/api';
+
+/**
+ * List projects on the configured GitLab instance, one page at a time
+ */
+export async function listGitLabProjects(
+  projectName: string,
+  params: ListGitLabProjectsParams = {}
+): Promise<ListGitLabProjectsResponse> {
+  const query: Record<string, string | number | boolean> = {};
+  if (params.search) query.search = params.search;
+  if (params.membership) query.membership = true;
+  if (params.page) query.page = params.page;
+  if (params.perPage) query.perPage = params.perPage;
+
+  const { data, headers } = await apiClient.getWithHeaders<{ projects: GitLabProject[] }>(
+    `/projects/${encodeURIComponent(projectName)}/gitlab/projects`,
+    { params: query }
+  );
+  const nextPage = Number(headers.get('X-Next-Page'));
+  return { projects: data.projects, nextPage: nextPage > 0 ? nextPage : undefined };
+}
+
+/**
+ * List branches of a project on the configured GitLab instance
+ */
+export async function listGitLabProjectBranches(
+  projectName: string,
+  gitlabProjectId: number
+): Promise<ListBranchesResponse> {
+  return apiClient.get<ListBranchesResponse>(
+    `/projects/${encodeURIComponent(projectName)}/gitlab/projects/${gitlabProjectId}/branches`
+  );
+}
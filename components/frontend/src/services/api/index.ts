@@ -8,6 +8,7 @@ export * as clusterApi from './cluster';
 export * as projectsApi from './projects';
 export * as sessionsApi from './sessions';
 export * as githubApi from './github';
+export * as gitlabApi from './gitlab';
 export * as keysApi from './keys';
 export * as repoApi from './repo';
 export * as workspaceApi from './workspace';
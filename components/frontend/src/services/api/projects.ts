@@ -12,6 +12,7 @@ import type {
   DeleteProjectResponse,
   PermissionAssignment,
   PaginationParams,
+  ListProjectModelsResponse,
 } from '@/types/api';
 
 /**
@@ -24,6 +25,8 @@ export async function listProjectsPaginated(
   if (params.limit) searchParams.set('limit', params.limit.toString());
   if (params.offset) searchParams.set('offset', params.offset.toString());
   if (params.search) searchParams.set('search', params.search);
+  if (params.sortBy) searchParams.set('sortBy', params.sortBy);
+  if (params.order) searchParams.set('order', params.order);
 
   const queryString = searchParams.toString();
   const url = queryString ? `/projects?${queryString}` : '/projects';
@@ -116,3 +119,10 @@ export async function removeProjectPermission(
     `/projects/${projectName}/permissions/${subjectType}/${subjectName}`
   );
 }
+
+/**
+ * Get the models sessions of the project may use
+ */
+export async function getProjectModels(projectName: string): Promise<ListProjectModelsResponse> {
+  return apiClient.get<ListProjectModelsResponse>(`/projects/${projectName}/models`);
+}
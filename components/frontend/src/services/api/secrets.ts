@@ -10,8 +10,15 @@ export type Secret = {
   value: string;
 };
 
+export type SecretReference = {
+  kind: 'ProjectSettings' | 'AgenticSession';
+  name: string;
+  field: string;
+  phase?: string;
+};
+
 export type SecretList = {
-  items: { name: string }[];
+  items: { name: string; referencedBy?: SecretReference[] }[];
 };
 
 export type SecretsConfig = {
@@ -28,6 +28,8 @@ export async function listSessionsPaginated(
   if (params.limit) searchParams.set('limit', params.limit.toString());
   if (params.offset) searchParams.set('offset', params.offset.toString());
   if (params.search) searchParams.set('search', params.search);
+  if (params.sortBy) searchParams.set('sortBy', params.sortBy);
+  if (params.order) searchParams.set('order', params.order);
 
   const queryString = searchParams.toString();
   const url = queryString
@@ -158,6 +160,33 @@ export async function getSessionK8sResources(
   pvcName: string;
   pvcExists: boolean;
   pvcSize?: string;
+  // Set while a resize-workspace request is above the provisioned capacity
+  pvcRequestedSize?: string;
+  pvcResizing?: boolean;
+  pvcConditions?: Array<{
+    type: string;
+    status: string;
+    reason?: string;
+    message?: string;
+    lastTransitionTime?: string;
+  }>;
+  auth?: {
+    serviceAccount: { name: string; exists: boolean; error?: string };
+    role: { name: string; exists: boolean; missingPermissions: string[]; error?: string };
+    roleBinding: { name: string; exists: boolean; roleRef?: string; error?: string };
+    tokenSecret: {
+      name: string;
+      exists: boolean;
+      hasToken?: boolean;
+      issuedAt?: string;
+      expiresAt?: string;
+      ageSeconds?: number;
+      expired?: boolean;
+      tokenError?: string;
+      error?: string;
+    };
+    healthy: boolean;
+  };
 }> {
   return apiClient.get(`/projects/${projectName}/agentic-sessions/${sessionName}/k8s-resources`);
 }
@@ -194,3 +223,58 @@ export async function getSessionExport(
 ): Promise<SessionExportResponse> {
   return apiClient.get(`/projects/${projectName}/agentic-sessions/${sessionName}/export`);
 }
+
+/**
+ * Portable session definition (no workspace content) for moving sessions between projects.
+ * Sensitive environment variable values are exported as "***" and listed in `redacted`.
+ */
+export type SessionBundle = {
+  kind: 'AgenticSessionBundle';
+  version: string;
+  exportedAt?: string;
+  source: { project: string; name: string };
+  spec: Record<string, unknown>;
+  annotations?: Record<string, string>;
+  repoStatuses?: unknown[];
+  result?: {
+    phase?: string;
+    startTime?: string;
+    completionTime?: string;
+    conditions?: unknown[];
+  };
+  redacted?: string[];
+};
+
+export type ImportSessionResponse = {
+  message: string;
+  name: string;
+  uid: string;
+  renamed: boolean;
+  omittedEnvironmentVariables?: string[];
+  omittedFields?: string[];
+};
+
+/**
+ * Export a session as a portable bundle
+ */
+export async function getSessionBundle(
+  projectName: string,
+  sessionName: string
+): Promise<SessionBundle> {
+  return apiClient.get(
+    `/projects/${projectName}/agentic-sessions/${sessionName}/bundle`
+  );
+}
+
+/**
+ * Create a new Pending session from an exported bundle (renamed on conflict)
+ */
+export async function importSessionBundle(
+  projectName: string,
+  bundle: SessionBundle
+): Promise<ImportSessionResponse> {
+  return apiClient.post<ImportSessionResponse, SessionBundle>(
+    `/projects/${projectName}/agentic-sessions/import`,
+    bundle
+  );
+}
@@ -28,6 +28,10 @@ export type WorkflowCommand = {
   description: string;
   slashCommand: string;
   icon?: string;
+  /** "workflow" or "repo:<folder>" */
+  source?: string;
+  /** Set on shadowed entries: the source whose entry won the name */
+  shadowedBy?: string;
 };
 
 export type WorkflowAgent = {
@@ -35,6 +39,10 @@ export type WorkflowAgent = {
   name: string;
   description: string;
   tools?: string[];
+  /** "workflow" or "repo:<folder>" */
+  source?: string;
+  /** Set on shadowed entries: the source whose entry won the name */
+  shadowedBy?: string;
 };
 
 export type WorkflowConfig = {
@@ -48,6 +56,11 @@ export type WorkflowMetadataResponse = {
   commands: WorkflowCommand[];
   agents: WorkflowAgent[];
   config?: WorkflowConfig;
+  /** Repo entries hidden by a workflow or earlier repo entry of the same name */
+  shadowed?: {
+    commands: WorkflowCommand[];
+    agents: WorkflowAgent[];
+  };
 };
 
 export async function getWorkflowMetadata(
@@ -182,6 +182,37 @@ export async function gitPush(
   );
 }
 
+export type PromoteWorkspaceRequest = {
+  path: string;
+  provider: 'github' | 'gitlab';
+  owner: string;
+  name: string;
+  visibility?: 'private' | 'internal' | 'public';
+  description?: string;
+};
+
+export type PromoteWorkspaceResponse = {
+  url: string;
+  provider: 'github' | 'gitlab';
+  path: string;
+  branch: string;
+  podSpawned?: boolean;
+};
+
+/**
+ * Create a repository from a workspace directory and push the directory to it
+ */
+export async function promoteWorkspaceDirectory(
+  projectName: string,
+  sessionName: string,
+  request: PromoteWorkspaceRequest
+): Promise<PromoteWorkspaceResponse> {
+  return apiClient.post<PromoteWorkspaceResponse, PromoteWorkspaceRequest>(
+    `/projects/${projectName}/agentic-sessions/${sessionName}/promote`,
+    request
+  );
+}
+
 /**
  * Create a new git branch
  */
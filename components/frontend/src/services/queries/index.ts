@@ -7,6 +7,7 @@ export * from './use-cluster';
 export * from './use-projects';
 export * from './use-sessions';
 export * from './use-github';
+export * from './use-gitlab';
 export * from './use-keys';
 export * from './use-secrets';
 export * from './use-repo';
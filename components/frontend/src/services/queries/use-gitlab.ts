@@ -0,0 +1,52 @@
+/**
+ * React Query hooks for the project's configured GitLab instance
+ */
+
+import { keepPreviousData, useQuery } from '@tanstack/react-query';
+import * as gitlabApi from '../api/gitlab';
+import type { ListGitLabProjectsParams } from '@/types/api';
+
+/**
+ * Query keys for GitLab
+ */
+export const gitlabKeys = {
+  all: ['gitlab'] as const,
+  projects: (projectName: string, params: ListGitLabProjectsParams) =>
+    [...gitlabKeys.all, 'projects', projectName, params] as const,
+  branches: (projectName: string, gitlabProjectId: number) =>
+    [...gitlabKeys.all, 'branches', projectName, gitlabProjectId] as const,
+};
+
+/**
+ * Hook to list and search projects on the configured GitLab instance
+ */
+export function useGitLabProjects(
+  projectName: string,
+  params: ListGitLabProjectsParams,
+  options?: { enabled?: boolean }
+) {
+  return useQuery({
+    queryKey: gitlabKeys.projects(projectName, params),
+    queryFn: () => gitlabApi.listGitLabProjects(projectName, params),
+    enabled: (options?.enabled ?? true) && !!projectName,
+    placeholderData: keepPreviousData,
+    retry: false, // 400 when the project has no GitLab instance configured
+    staleTime: 60 * 1000, // 1 minute
+  });
+}
+
+/**
+ * Hook to list branches of a project on the configured GitLab instance
+ */
+export function useGitLabProjectBranches(
+  projectName: string,
+  gitlabProjectId: number | undefined,
+  options?: { enabled?: boolean }
+) {
+  return useQuery({
+    queryKey: gitlabKeys.branches(projectName, gitlabProjectId ?? 0),
+    queryFn: () => gitlabApi.listGitLabProjectBranches(projectName, gitlabProjectId as number),
+    enabled: (options?.enabled ?? true) && !!projectName && !!gitlabProjectId,
+    staleTime: 2 * 60 * 1000, // 2 minutes
+  });
+}
@@ -22,6 +22,7 @@ export const projectKeys = {
   details: () => [...projectKeys.all, 'detail'] as const,
   detail: (name: string) => [...projectKeys.details(), name] as const,
   permissions: (name: string) => [...projectKeys.detail(name), 'permissions'] as const,
+  models: (name: string) => [...projectKeys.detail(name), 'models'] as const,
 };
 
 /**
@@ -123,6 +124,17 @@ export function useProjectPermissions(projectName: string) {
   });
 }
 
+/**
+ * Hook to fetch the models sessions of a project may use
+ */
+export function useProjectModels(projectName: string) {
+  return useQuery({
+    queryKey: projectKeys.models(projectName),
+    queryFn: () => projectsApi.getProjectModels(projectName),
+    enabled: !!projectName,
+  });
+}
+
 /**
  * Hook to add project permission
  */
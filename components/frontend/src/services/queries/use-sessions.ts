@@ -85,8 +85,8 @@ export function useSession(projectName: string, sessionName: string) {
         phase === 'Creating';
       if (isTransitioning) return 1000;
       
-      // Running state - poll normally (every 5 seconds)
-      if (phase === 'Running') return 5000;
+      // Running or queued waiting for capacity - poll normally (every 5 seconds)
+      if (phase === 'Running' || phase === 'Queued') return 5000;
       
       // Terminal states (Stopped, Completed, Failed) - no polling
       return false;
@@ -206,6 +206,29 @@ export function useCloneSession() {
   });
 }
 
+/**
+ * Hook to import a session from an exported bundle
+ */
+export function useImportSessionBundle() {
+  const queryClient = useQueryClient();
+
+  return useMutation({
+    mutationFn: ({
+      projectName,
+      bundle,
+    }: {
+      projectName: string;
+      bundle: sessionsApi.SessionBundle;
+    }) => sessionsApi.importSessionBundle(projectName, bundle),
+    onSuccess: (_response, { projectName }) => {
+      queryClient.invalidateQueries({
+        queryKey: sessionKeys.list(projectName),
+        refetchType: 'all',
+      });
+    },
+  });
+}
+
 /**
  * Hook to delete a session
  */
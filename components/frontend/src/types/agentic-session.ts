@@ -1,4 +1,4 @@
-export type AgenticSessionPhase = "Pending" | "Creating" | "Running" | "Stopping" | "Stopped" | "Completed" | "Failed";
+export type AgenticSessionPhase = "Pending" | "Queued" | "Creating" | "Running" | "Stopping" | "Stopped" | "Completed" | "Failed";
 
 export type LLMSettings = {
 	model: string;
@@ -16,6 +16,10 @@ export type GitRepository = {
 export type SessionRepo = {
     url: string;
     branch?: string;
+    // Commit SHA or tag the repo is pinned to (mutually exclusive with branch)
+    ref?: string;
+    // Workspace directory the repo is cloned into (set by the backend)
+    folder?: string;
 };
 
 export type AgenticSessionSpec = {
@@ -25,6 +29,14 @@ export type AgenticSessionSpec = {
 	displayName?: string;
 	project?: string;
 	interactive?: boolean;
+	// Scheduling priority when the project is at capacity
+	priority?: "low" | "normal" | "high";
+	// Runner image override (empty uses the project or operator default)
+	runnerImage?: string;
+	// Stop the session once its reported cost exceeds this (USD)
+	costLimitUSD?: number;
+	// Workspace PVC size such as "50Gi" (default 5Gi)
+	workspaceSize?: string;
 	// Multi-repo support
 	repos?: SessionRepo[];
 	// Active workflow for dynamic workflow switching
@@ -41,6 +53,14 @@ export type ReconciledRepo = {
 	name?: string;
 	status?: "Cloning" | "Ready" | "Failed";
 	clonedAt?: string;
+	clonedSha?: string;
+	clonedBranch?: string;
+	pushedSha?: string;
+	pushedBranch?: string;
+	gitStatus?: "cloned" | "dirty" | "pushed" | "abandoned" | "error";
+	lastUpdated?: string;
+	forkOf?: string;
+	forkUrl?: string;
 };
 
 export type ReconciledWorkflow = {
@@ -151,6 +171,7 @@ export type MessageObject = Message;
 export type AgenticSessionStatus = {
 	observedGeneration?: number;
 	phase: AgenticSessionPhase;
+	queuedAt?: string;
 	startTime?: string;
 	completionTime?: string;
 	reconciledRepos?: ReconciledRepo[];
@@ -158,6 +179,26 @@ export type AgenticSessionStatus = {
 	sdkSessionId?: string;
 	sdkRestartCount?: number;
 	conditions?: SessionCondition[];
+	runnerAuth?: {
+		secretName: string;
+		tokenExpiresAt?: string;
+	};
+	totalCostUSD?: number;
+	// "costLimit" when the session was stopped for exceeding spec.costLimitUSD
+	stoppedReason?: string;
+	// Running token and cost totals, also pushed as usage_update events
+	usage?: Record<string, number>;
+	usageSeq?: number;
+	// When the latest start reached each startup milestone
+	timeline?: SessionTimeline;
+};
+
+export type SessionTimeline = {
+	createdAt?: string;
+	tokenProvisionedAt?: string;
+	jobCreatedAt?: string;
+	podScheduledAt?: string;
+	runnerStartedAt?: string;
 };
 
 export type AgenticSession = {
@@ -187,6 +228,11 @@ export type CreateAgenticSessionRequest = {
 	autoPushOnComplete?: boolean;
 	labels?: Record<string, string>;
 	annotations?: Record<string, string>;
+	priority?: "low" | "normal" | "high";
+	runnerImage?: string;
+	queue?: boolean;
+	costLimitUSD?: number;
+	workspaceSize?: string;
 };
 
 export type AgentPersona = {
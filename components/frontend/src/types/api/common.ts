@@ -7,8 +7,31 @@ export type ApiResponse<T> = {
   error?: never;
 };
 
+export type ApiErrorKind =
+  | 'Validation'
+  | 'Unauthorized'
+  | 'Forbidden'
+  | 'NotFound'
+  | 'Conflict'
+  | 'UpstreamUnavailable'
+  | 'PreconditionFailed'
+  | 'LimitExceeded'
+  | 'SigningFailed'
+  | 'Internal';
+
+/**
+ * Structured error returned by the backend: {"error": {kind, message, requestId, details?}}
+ */
+export type ApiErrorBody = {
+  kind: ApiErrorKind;
+  message: string;
+  requestId: string;
+  details?: Record<string, unknown>;
+};
+
 export type ApiError = {
-  error: string;
+  // Plain strings are still returned by handlers not yet migrated to the structured format
+  error: string | ApiErrorBody;
   code?: string;
   details?: Record<string, unknown>;
 };
@@ -23,6 +46,8 @@ export type PaginationParams = {
   offset?: number;
   search?: string;
   continue?: string;
+  sortBy?: 'creationTimestamp' | 'completionTime' | 'displayName';
+  order?: 'asc' | 'desc';
 };
 
 /**
@@ -52,13 +77,41 @@ export function isApiSuccess<T>(result: ApiResult<T>): result is ApiResponse<T>
   return 'data' in result && !('error' in result);
 }
 
+/**
+ * Extract a displayable message from either error format
+ */
+export function getApiErrorMessage(error: unknown): string | undefined {
+  if (typeof error === 'string') {
+    return error;
+  }
+  if (typeof error === 'object' && error !== null && typeof (error as ApiErrorBody).message === 'string') {
+    return (error as ApiErrorBody).message;
+  }
+  return undefined;
+}
+
 export class ApiClientError extends Error {
   constructor(
     message: string,
     public code?: string,
-    public details?: Record<string, unknown>
+    public details?: Record<string, unknown>,
+    public kind?: ApiErrorKind,
+    public requestId?: string
   ) {
     super(message);
     this.name = 'ApiClientError';
   }
+
+  static fromApiError(data: ApiError, status: number): ApiClientError {
+    if (typeof data.error === 'string') {
+      return new ApiClientError(data.error, data.code, data.details);
+    }
+    return new ApiClientError(
+      data.error.message,
+      data.code ?? String(status),
+      data.error.details ?? data.details,
+      data.error.kind,
+      data.error.requestId
+    );
+  }
 }
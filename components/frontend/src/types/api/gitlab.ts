@@ -0,0 +1,24 @@
+/**
+ * GitLab API types
+ */
+
+export type GitLabProject = {
+  id: number;
+  path_with_namespace: string;
+  default_branch?: string;
+  http_url_to_repo: string;
+  visibility: 'private' | 'internal' | 'public';
+};
+
+export type ListGitLabProjectsParams = {
+  search?: string;
+  membership?: boolean;
+  page?: number;
+  perPage?: number;
+};
+
+export type ListGitLabProjectsResponse = {
+  projects: GitLabProject[];
+  /** Next page number from GitLab's X-Next-Page header, when there is one */
+  nextPage?: number;
+};
@@ -14,6 +14,9 @@ export type Project = {
   creationTimestamp: string;
   status: ProjectStatus;
   isOpenShift: boolean; // Indicates if cluster is OpenShift (affects available features)
+  sessions?: Record<string, number>; // Session counts by lower-cased phase (?includeStats=true)
+  lastActivity?: string; // Most recent session start time (?includeStats=true)
+  statsError?: string; // Set when the project's sessions could not be listed
   namespace?: string;
   resourceVersion?: string;
   uid?: string;
@@ -143,3 +146,19 @@ export type ProjectCondition = {
   message?: string;
   lastTransitionTime?: string;
 };
+
+export type ModelCostTier = 'economy' | 'standard' | 'premium';
+
+export type ModelInfo = {
+  id: string;
+  name: string;
+  contextWindow?: number;
+  costTier?: ModelCostTier;
+  vertexId?: string;
+};
+
+export type ListProjectModelsResponse = {
+  provider: 'anthropic' | 'vertex';
+  default: string;
+  models: ModelInfo[];
+};
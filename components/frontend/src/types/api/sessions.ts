@@ -22,6 +22,7 @@ export type ResourceOverrides = {
 
 export type AgenticSessionPhase =
   | 'Pending'
+  | 'Queued'
   | 'Creating'
   | 'Running'
   | 'Stopping'
@@ -29,6 +30,8 @@ export type AgenticSessionPhase =
   | 'Completed'
   | 'Failed';
 
+export type SessionPriority = 'low' | 'normal' | 'high';
+
 export type LLMSettings = {
   model: string;
   temperature: number;
@@ -38,6 +41,10 @@ export type LLMSettings = {
 export type SessionRepo = {
   url: string;
   branch?: string;
+  // Commit SHA or tag the repo is pinned to (mutually exclusive with branch)
+  ref?: string;
+  // Workspace directory the repo is cloned into (set by the backend)
+  folder?: string;
 };
 
 export type AgenticSessionSpec = {
@@ -49,6 +56,10 @@ export type AgenticSessionSpec = {
   interactive?: boolean;
   repos?: SessionRepo[];
   mainRepoIndex?: number;
+  priority?: SessionPriority;
+  runnerImage?: string;
+  costLimitUSD?: number;
+  workspaceSize?: string;
   activeWorkflow?: {
     gitUrl: string;
     branch: string;
@@ -62,6 +73,14 @@ export type ReconciledRepo = {
   name?: string;
   status?: 'Cloning' | 'Ready' | 'Failed';
   clonedAt?: string;
+  clonedSha?: string;
+  clonedBranch?: string;
+  pushedSha?: string;
+  pushedBranch?: string;
+  gitStatus?: 'cloned' | 'dirty' | 'pushed' | 'abandoned' | 'error';
+  lastUpdated?: string;
+  forkOf?: string;
+  forkUrl?: string;
 };
 
 export type ReconciledWorkflow = {
@@ -83,6 +102,7 @@ export type SessionCondition = {
 export type AgenticSessionStatus = {
   observedGeneration?: number;
   phase: AgenticSessionPhase;
+  queuedAt?: string;
   startTime?: string;
   completionTime?: string;
   jobName?: string;
@@ -92,6 +112,26 @@ export type AgenticSessionStatus = {
   sdkSessionId?: string;
   sdkRestartCount?: number;
   conditions?: SessionCondition[];
+  runnerAuth?: {
+    secretName: string;
+    tokenExpiresAt?: string;
+  };
+  totalCostUSD?: number;
+  // "costLimit" when the session was stopped for exceeding spec.costLimitUSD
+  stoppedReason?: string;
+  // Running token and cost totals, also pushed as usage_update events
+  usage?: Record<string, number>;
+  usageSeq?: number;
+  // When the latest start reached each startup milestone
+  timeline?: SessionTimeline;
+};
+
+export type SessionTimeline = {
+  createdAt?: string;
+  tokenProvisionedAt?: string;
+  jobCreatedAt?: string;
+  podScheduledAt?: string;
+  runnerStartedAt?: string;
 };
 
 export type AgenticSession = {
@@ -117,16 +157,33 @@ export type CreateAgenticSessionRequest = {
   environmentVariables?: Record<string, string>;
   interactive?: boolean;
   repos?: SessionRepo[];
+  // Add the project's defaultRepos to repos; without it they are used only when repos is empty
+  mergeDefaults?: boolean;
   autoPushOnComplete?: boolean;
   userContext?: UserContext;
   labels?: Record<string, string>;
   annotations?: Record<string, string>;
+  priority?: SessionPriority;
+  // Must match the project's allowedRunnerImages
+  runnerImage?: string;
+  // Queue instead of failing with 429 when the project is at maxConcurrentSessions
+  queue?: boolean;
+  // Defaults to and may not exceed the project's session cost limits
+  costLimitUSD?: number;
+  // Workspace PVC size such as "50Gi"; may not exceed the project's maxWorkspaceSize
+  workspaceSize?: string;
+  // Expand initialPrompt as a Go template against the project's promptVariables
+  templatePrompt?: boolean;
 };
 
 export type CreateAgenticSessionResponse = {
   message: string;
   name: string;
   uid: string;
+  queued?: boolean;
+  // False when the runner token could not be provisioned; see warnings and POST .../provision-retry
+  ready?: boolean;
+  warnings?: string[];
 };
 
 export type GetAgenticSessionResponse = {
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// kubeRootCAConfigMap is published by kube-controller-manager in every namespace
+	kubeRootCAConfigMap = "kube-root-ca.crt"
+	contentKubeCAVolume = "kube-ca"
+	contentKubeCADir    = "/var/run/secrets/ambient/kube-ca"
+)
+
+// contentServiceEnv configures a content service container to serve a single session. The session
+// name and namespace scope its paths and tell it whose tokens to accept; KUBE_CA_FILE lets it verify
+// callers with the API server without mounting a ServiceAccount token. MAX_WORKSPACE_WRITE_BYTES
+// is passed through so the content service accepts the files the backend does, and the OTLP
+// endpoint so it exports spans when the operator does.
+func contentServiceEnv(sessionName, sessionNamespace string) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "CONTENT_SERVICE_MODE", Value: "true"},
+		{Name: "STATE_BASE_DIR", Value: "/workspace"},
+		{Name: "AGENTIC_SESSION_NAME", Value: sessionName},
+		{Name: "AGENTIC_SESSION_NAMESPACE", Value: sessionNamespace},
+		{Name: "KUBE_CA_FILE", Value: contentKubeCADir + "/ca.crt"},
+	}
+	if v := os.Getenv("MAX_WORKSPACE_WRITE_BYTES"); v != "" {
+		env = append(env, corev1.EnvVar{Name: "MAX_WORKSPACE_WRITE_BYTES", Value: v})
+	}
+	return append(env, otlpEnv()...)
+}
+
+// tempContentServiceEnv is contentServiceEnv for a temp content pod; a read-only pod serves
+// reads only (CONTENT_SERVICE_READONLY) and rejects writes, pushes and git changes
+func tempContentServiceEnv(sessionName, sessionNamespace string, readOnly bool) []corev1.EnvVar {
+	env := contentServiceEnv(sessionName, sessionNamespace)
+	if readOnly {
+		env = append(env, corev1.EnvVar{Name: "CONTENT_SERVICE_READONLY", Value: "true"})
+	}
+	return env
+}
+
+// contentKubeCAVolumeSource mounts the cluster CA bundle for content service containers
+func contentKubeCAVolumeSource() corev1.Volume {
+	return corev1.Volume{
+		Name: contentKubeCAVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: kubeRootCAConfigMap},
+			},
+		},
+	}
+}
+
+func contentKubeCAVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{Name: contentKubeCAVolume, MountPath: contentKubeCADir, ReadOnly: true}
+}
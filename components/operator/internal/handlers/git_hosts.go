@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// gitHostAllowed reports whether repoURL is on a host, or under a host/owner prefix, of patterns
+// (ProjectSettings spec.allowedGitHosts). Prefixes match whole path segments; an empty list allows
+// every URL. Keep in sync with gitHostAllowed in the backend (components/backend/handlers/git_hosts.go),
+// which rejects these URLs at the API.
+func gitHostAllowed(repoURL string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	target := strings.TrimPrefix(normalizeRepoURL(repoURL), "https://")
+	if target == "" {
+		return false
+	}
+	for _, p := range patterns {
+		prefix := strings.TrimPrefix(normalizeRepoURL(p), "https://")
+		if prefix != "" && (target == prefix || strings.HasPrefix(target, prefix+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionGitURLs returns the repository URLs a session's runner would use: each repo (input.url or
+// url) and its output.url, and the active workflow's gitUrl
+func sessionGitURLs(session *unstructured.Unstructured) []string {
+	var urls []string
+	repos, _, _ := unstructured.NestedSlice(session.Object, "spec", "repos")
+	for _, r := range repos {
+		rm, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		u, _, _ := unstructured.NestedString(rm, "input", "url")
+		if strings.TrimSpace(u) == "" {
+			u, _ = rm["url"].(string)
+		}
+		out, _, _ := unstructured.NestedString(rm, "output", "url")
+		urls = append(urls, u, out)
+	}
+	wf, _, _ := unstructured.NestedString(session.Object, "spec", "activeWorkflow", "gitUrl")
+	return append(urls, wf)
+}
+
+// disallowedGitURL returns the first repository URL of session outside its project's
+// spec.allowedGitHosts, or "". The backend checks these when they are set; this catches sessions
+// written without it, so the runner is never handed a blocked URL.
+func disallowedGitURL(ctx context.Context, session *unstructured.Unstructured) (string, error) {
+	obj, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(session.GetNamespace()).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	patterns, _, _ := unstructured.NestedStringSlice(obj.Object, "spec", "allowedGitHosts")
+	if len(patterns) == 0 {
+		return "", nil
+	}
+	for _, u := range sessionGitURLs(session) {
+		if strings.TrimSpace(u) != "" && !gitHostAllowed(u, patterns) {
+			return strings.TrimSpace(u), nil
+		}
+	}
+	return "", nil
+}
+
+// failSessionForGitHost moves a session that references a blocked git URL to Error and applies
+// statusPatch
+func failSessionForGitHost(session *unstructured.Unstructured, statusPatch *StatusPatch, blockedURL string) {
+	msg := fmt.Sprintf("Repository %s is not in the project's allowedGitHosts", blockedURL)
+	log.Printf("Session %s/%s: %s", session.GetNamespace(), session.GetName(), msg)
+	recordSessionEvent(session, corev1.EventTypeWarning, "GitHostNotAllowed", msg)
+	statusPatch.SetField("phase", phaseError)
+	statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
+	statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "False", Reason: "GitHostNotAllowed", Message: msg})
+	if err := statusPatch.Apply(); err != nil {
+		log.Printf("Warning: failed to apply status patch: %v", err)
+	}
+}
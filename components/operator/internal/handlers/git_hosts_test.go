@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestGitHostAllowed(t *testing.T) {
+	patterns := []string{"github.com/Acme", "gitlab.example.com"}
+	cases := map[string]bool{
+		"https://github.com/acme/app.git":            true,
+		"git@github.com:acme/app":                    true,
+		"https://github.com/acme-evil/app":           false,
+		"https://github.com/other/app":               false,
+		"https://gitlab.example.com/group/sub/app":   true,
+		"https://gitlab.example.com.evil.io/grp/app": false,
+		"not a url": false,
+	}
+	for repoURL, want := range cases {
+		if got := gitHostAllowed(repoURL, patterns); got != want {
+			t.Errorf("gitHostAllowed(%q) = %v, want %v", repoURL, got, want)
+		}
+	}
+	if !gitHostAllowed("https://anywhere.io/x/y", nil) {
+		t.Errorf("an empty allowlist must allow every URL")
+	}
+}
+
+// TestDisallowedGitURL verifies repos, push targets and the workflow are all checked
+func TestDisallowedGitURL(t *testing.T) {
+	seedRunnerImageSettings(t, map[string]interface{}{
+		"allowedGitHosts": []interface{}{"github.com/acme"},
+	})
+	session := func(namespace string, spec map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "s1", "namespace": namespace},
+			"spec":     spec,
+		}}
+	}
+	repo := func(url, output string) map[string]interface{} {
+		r := map[string]interface{}{"url": url}
+		if output != "" {
+			r["output"] = map[string]interface{}{"url": output}
+		}
+		return r
+	}
+
+	cases := []struct {
+		name      string
+		namespace string
+		spec      map[string]interface{}
+		want      string
+	}{
+		{"allowed repo", "proj", map[string]interface{}{"repos": []interface{}{repo("https://github.com/acme/app", "")}}, ""},
+		{"blocked repo", "proj", map[string]interface{}{"repos": []interface{}{repo("https://gitlab.com/acme/app", "")}}, "https://gitlab.com/acme/app"},
+		{"blocked push target", "proj", map[string]interface{}{"repos": []interface{}{repo("https://github.com/acme/app", "https://github.com/fork/app")}}, "https://github.com/fork/app"},
+		{"blocked workflow", "proj", map[string]interface{}{"activeWorkflow": map[string]interface{}{"gitUrl": "https://github.com/elsewhere/wf"}}, "https://github.com/elsewhere/wf"},
+		{"no ProjectSettings", "other", map[string]interface{}{"repos": []interface{}{repo("https://gitlab.com/acme/app", "")}}, ""},
+	}
+	for _, tc := range cases {
+		got, err := disallowedGitURL(context.Background(), session(tc.namespace, tc.spec))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
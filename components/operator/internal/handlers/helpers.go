@@ -33,10 +33,15 @@ const (
 	runnerTokenRefreshedAtAnnotation   = "ambient-code.io/token-refreshed-at"
 	tempContentRequestedAnnotation     = "ambient-code.io/temp-content-requested"
 	tempContentLastAccessedAnnotation  = "ambient-code.io/temp-content-last-accessed"
+	tempContentReadOnlyAnnotation      = "ambient-code.io/temp-content-readonly"
 	runnerTokenRefreshTTL              = 45 * time.Minute
 	tempContentInactivityTTL           = 10 * time.Minute
 	defaultRunnerTokenSecretPrefix     = "ambient-runner-token-"
 	defaultSessionServiceAccountPrefix = "ambient-session-"
+	runnerTokenSecretKey               = "k8s-token"
+	runnerTokenExpirySecretKey         = "k8s-token-expiry"
+	runnerTokenVolumeName              = "runner-token"
+	runnerTokenMountPath               = "/var/run/secrets/ambient-runner"
 )
 
 type conditionUpdate struct {
@@ -53,6 +58,7 @@ type StatusPatch struct {
 	Fields     map[string]interface{}
 	Conditions []conditionUpdate
 	Deletions  map[string]bool // Fields to delete
+	Milestones map[string]time.Time
 	Namespace  string
 	Name       string
 }
@@ -63,6 +69,7 @@ func NewStatusPatch(namespace, name string) *StatusPatch {
 		Fields:     make(map[string]interface{}),
 		Conditions: make([]conditionUpdate, 0),
 		Deletions:  make(map[string]bool),
+		Milestones: make(map[string]time.Time),
 		Namespace:  namespace,
 		Name:       name,
 	}
@@ -85,9 +92,15 @@ func (sp *StatusPatch) AddCondition(cond conditionUpdate) {
 	sp.Conditions = append(sp.Conditions, cond)
 }
 
+// SetMilestone queues a startup milestone for status.timeline. It is applied after the field
+// updates and only if the timeline does not already have it.
+func (sp *StatusPatch) SetMilestone(name string, at time.Time) {
+	sp.Milestones[name] = at
+}
+
 // HasChanges returns true if there are any pending changes to apply.
 func (sp *StatusPatch) HasChanges() bool {
-	return len(sp.Fields) > 0 || len(sp.Conditions) > 0 || len(sp.Deletions) > 0
+	return len(sp.Fields) > 0 || len(sp.Conditions) > 0 || len(sp.Deletions) > 0 || len(sp.Milestones) > 0
 }
 
 // Apply executes all accumulated changes in a single API call.
@@ -97,7 +110,8 @@ func (sp *StatusPatch) Apply() error {
 		return nil // No changes to apply
 	}
 
-	return mutateAgenticSessionStatus(sp.Namespace, sp.Name, func(status map[string]interface{}) {
+	var completedTimeline map[string]interface{}
+	err := mutateAgenticSessionStatus(sp.Namespace, sp.Name, func(status map[string]interface{}) {
 		// Apply field deletions first
 		for key := range sp.Deletions {
 			delete(status, key)
@@ -112,7 +126,16 @@ func (sp *StatusPatch) Apply() error {
 		for _, cond := range sp.Conditions {
 			setCondition(status, cond)
 		}
+
+		if len(sp.Milestones) > 0 {
+			completedTimeline = setMilestones(status, sp.Milestones)
+		}
 	})
+	// Observe a start's stage latencies once, when the update completing its timeline succeeds
+	if err == nil && completedTimeline != nil {
+		observeStartupTimeline(completedTimeline)
+	}
+	return err
 }
 
 // ApplyAndReset applies all changes and resets the patch for reuse.
@@ -123,6 +146,7 @@ func (sp *StatusPatch) ApplyAndReset() error {
 	sp.Fields = make(map[string]interface{})
 	sp.Conditions = make([]conditionUpdate, 0)
 	sp.Deletions = make(map[string]bool)
+	sp.Milestones = make(map[string]time.Time)
 	return err
 }
 
@@ -149,9 +173,17 @@ func mutateAgenticSessionStatus(sessionNamespace, name string, mutator func(stat
 		obj.Object["status"] = status
 	}
 
+	previousPhase, _ := status["phase"].(string)
 	mutator(status)
 
-	// Phase is set explicitly by callers - no derivation needed
+	// Phase is set explicitly by callers - no derivation needed, but it must follow the state machine
+	if nextPhase, _ := status["phase"].(string); nextPhase != previousPhase {
+		restartRequested := strings.TrimSpace(obj.GetAnnotations()["ambient-code.io/desired-phase"]) == phaseRunning
+		if err := validatePhaseTransition(previousPhase, nextPhase, restartRequested); err != nil {
+			log.Printf("Refusing status update for AgenticSession %s/%s: %v", sessionNamespace, name, err)
+			return err
+		}
+	}
 
 	_, err = config.DynamicClient.Resource(gvr).Namespace(sessionNamespace).UpdateStatus(context.TODO(), obj, v1.UpdateOptions{})
 	if err != nil {
@@ -310,10 +342,7 @@ func ensureFreshRunnerToken(ctx context.Context, session *unstructured.Unstructu
 	}
 
 	annotations := session.GetAnnotations()
-	secretName := strings.TrimSpace(annotations[runnerTokenSecretAnnotation])
-	if secretName == "" {
-		secretName = fmt.Sprintf("%s%s", defaultRunnerTokenSecretPrefix, session.GetName())
-	}
+	secretName := runnerTokenSecretName(session)
 
 	secret, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, secretName, v1.GetOptions{})
 	if err != nil {
@@ -348,11 +377,14 @@ func ensureFreshRunnerToken(ctx context.Context, session *unstructured.Unstructu
 		return fmt.Errorf("received empty token for %s/%s", namespace, saName)
 	}
 
+	expiresAt := tokenExpiryString(tokenResp.Status.ExpirationTimestamp)
+
 	secretCopy := secret.DeepCopy()
 	if secretCopy.Data == nil {
 		secretCopy.Data = map[string][]byte{}
 	}
-	secretCopy.Data["k8s-token"] = []byte(token)
+	secretCopy.Data[runnerTokenSecretKey] = []byte(token)
+	secretCopy.Data[runnerTokenExpirySecretKey] = []byte(expiresAt)
 	if secretCopy.Annotations == nil {
 		secretCopy.Annotations = map[string]string{}
 	}
@@ -362,6 +394,57 @@ func ensureFreshRunnerToken(ctx context.Context, session *unstructured.Unstructu
 		return fmt.Errorf("failed to update runner token secret %s/%s: %w", namespace, secretName, err)
 	}
 
+	if err := setRunnerAuthStatus(namespace, session.GetName(), secretName, expiresAt); err != nil {
+		log.Printf("Warning: failed to record runner token expiry for %s/%s: %v", namespace, session.GetName(), err)
+	}
+
 	log.Printf("Refreshed runner token for session %s/%s", namespace, session.GetName())
 	return nil
 }
+
+// runnerTokenSecretName returns the runner token Secret annotated on the session,
+// falling back to the deterministic per-session name.
+func runnerTokenSecretName(session *unstructured.Unstructured) string {
+	if name := strings.TrimSpace(session.GetAnnotations()[runnerTokenSecretAnnotation]); name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s%s", defaultRunnerTokenSecretPrefix, session.GetName())
+}
+
+// tokenExpiryString formats a TokenRequest expiration as RFC3339, or "" when unset.
+func tokenExpiryString(ts v1.Time) string {
+	if ts.IsZero() {
+		return ""
+	}
+	return ts.UTC().Format(time.RFC3339)
+}
+
+// setRunnerAuthStatus records the runner token Secret and its expiry in status.runnerAuth
+// so clients and the runner can learn the expiry without decoding the JWT.
+func setRunnerAuthStatus(namespace, name, secretName, expiresAt string) error {
+	return mutateAgenticSessionStatus(namespace, name, func(status map[string]interface{}) {
+		runnerAuth := map[string]interface{}{"secretName": secretName}
+		if expiresAt != "" {
+			runnerAuth["tokenExpiresAt"] = expiresAt
+		}
+		status["runnerAuth"] = runnerAuth
+	})
+}
+
+// sessionControllerRef returns a controller owner reference to the session. The apiVersion comes
+// from the object itself so the garbage collector can resolve it; a version the CRD does not serve
+// leaves dependents behind when the session is deleted.
+func sessionControllerRef(session *unstructured.Unstructured) v1.OwnerReference {
+	apiVersion := session.GetAPIVersion()
+	if apiVersion == "" {
+		apiVersion = types.GetAgenticSessionResource().GroupVersion().String()
+	}
+	return v1.OwnerReference{
+		APIVersion: apiVersion,
+		Kind:       "AgenticSession",
+		Name:       session.GetName(),
+		UID:        session.GetUID(),
+		Controller: boolPtr(true),
+		// BlockOwnerDeletion intentionally omitted to avoid permission issues
+	}
+}
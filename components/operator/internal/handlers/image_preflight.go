@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imageReferencePattern is the image reference grammar of github.com/distribution/reference:
+// [domain[:port]/]path[:tag][@digest]
+var imageReferencePattern = regexp.MustCompile(`^` +
+	// optional domain with port
+	`(?:(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])(?:\.(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9]))*(?::[0-9]+)?/)?` +
+	// path components
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	// tag
+	`(?::[\w][\w.-]{0,127})?` +
+	// digest
+	`(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+// maxImageNameLength is the longest repository name (everything before the tag) a registry accepts
+const maxImageNameLength = 255
+
+// imagePullErrorGrace is how long a runner pod may report ErrImagePull before the session fails.
+// The kubelet retries pulls, so a single failure can be a registry hiccup; ImagePullBackOff and
+// InvalidImageName fail the session right away.
+const imagePullErrorGrace = 30 * time.Second
+
+// validateImageReference checks that ref parses as an image reference so a typo fails the session
+// before a Job is created instead of leaving the pod in ImagePullBackOff
+func validateImageReference(ref string) error {
+	if strings.TrimSpace(ref) == "" {
+		return fmt.Errorf("image reference is empty")
+	}
+	if !imageReferencePattern.MatchString(ref) {
+		return fmt.Errorf("not a valid image reference")
+	}
+	name := ref
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	if len(name) > maxImageNameLength {
+		return fmt.Errorf("repository name longer than %d characters", maxImageNameLength)
+	}
+	return nil
+}
+
+// imagePullFailure reports the first container or init container of pod that cannot pull its image,
+// as "<reason>: <kubelet message>"
+func imagePullFailure(pod *corev1.Pod, now time.Time) (string, bool) {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		waiting := cs.State.Waiting
+		if waiting == nil {
+			continue
+		}
+		switch waiting.Reason {
+		case "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+		case "ErrImagePull":
+			if now.Sub(pod.CreationTimestamp.Time) < imagePullErrorGrace {
+				continue
+			}
+		default:
+			continue
+		}
+		if waiting.Message == "" {
+			return fmt.Sprintf("%s (%s)", waiting.Reason, cs.Image), true
+		}
+		return fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message), true
+	}
+	return "", false
+}
+
+// runnerImageDigests maps runner image references to the digest they last started with
+var (
+	runnerImageDigestsMu sync.Mutex
+	runnerImageDigests   = map[string]string{}
+)
+
+// recordRunnerImageDigest remembers the digest a runner container started with and logs it when it
+// changes, so the last known good digest of a tag can be found after the tag is broken
+func recordRunnerImageDigest(image, imageID string) {
+	digest := imageID
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		digest = imageID[i+1:]
+	}
+	if image == "" || digest == "" {
+		return
+	}
+	runnerImageDigestsMu.Lock()
+	previous := runnerImageDigests[image]
+	runnerImageDigests[image] = digest
+	runnerImageDigestsMu.Unlock()
+	if previous != digest {
+		log.Printf("Runner image %s last known good digest: %s", image, digest)
+	}
+}
+
+// lastKnownGoodRunnerDigest returns the digest image last started with, if any
+func lastKnownGoodRunnerDigest(image string) string {
+	runnerImageDigestsMu.Lock()
+	defer runnerImageDigestsMu.Unlock()
+	return runnerImageDigests[image]
+}
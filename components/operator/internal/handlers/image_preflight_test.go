@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateImageReference(t *testing.T) {
+	valid := []string{
+		"runner",
+		"quay.io/ambient_code/vteam_claude_runner:latest",
+		"localhost:5000/team/runner:v1.2.3",
+		"quay.io/team/runner@sha256:" + strings.Repeat("a", 64),
+		"quay.io/team/runner:pr-42@sha256:" + strings.Repeat("0", 64),
+	}
+	for _, ref := range valid {
+		if err := validateImageReference(ref); err != nil {
+			t.Errorf("%q should be valid: %v", ref, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"quay.io/Team/runner:latest",
+		"quay.io/team/runner:",
+		"quay.io/team/runner:latest:extra",
+		"quay.io/team/runner latest",
+		"quay.io/team/runner@sha256:abc",
+		"quay.io/" + strings.Repeat("a", 260) + ":latest",
+	}
+	for _, ref := range invalid {
+		if err := validateImageReference(ref); err == nil {
+			t.Errorf("%q should be rejected", ref)
+		}
+	}
+}
+
+func TestImagePullFailure(t *testing.T) {
+	now := time.Now()
+	pod := func(age time.Duration, reason string, init bool) *corev1.Pod {
+		status := corev1.ContainerStatus{
+			Name:  "ambient-code-runner",
+			Image: "quay.io/team/runner:typo",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "manifest unknown"}},
+		}
+		p := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+		if init {
+			p.Status.InitContainerStatuses = []corev1.ContainerStatus{status}
+		} else {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{status}
+		}
+		return p
+	}
+
+	cases := []struct {
+		name   string
+		pod    *corev1.Pod
+		failed bool
+	}{
+		{"back-off", pod(5*time.Second, "ImagePullBackOff", false), true},
+		{"fresh pull error", pod(5*time.Second, "ErrImagePull", false), false},
+		{"pull error past grace", pod(imagePullErrorGrace+time.Second, "ErrImagePull", false), true},
+		{"init container", pod(time.Second, "InvalidImageName", true), true},
+		{"still creating", pod(time.Minute, "ContainerCreating", false), false},
+	}
+	for _, tc := range cases {
+		reason, failed := imagePullFailure(tc.pod, now)
+		if failed != tc.failed {
+			t.Errorf("%s: expected failed=%v, got %v (%q)", tc.name, tc.failed, failed, reason)
+		}
+		if failed && !strings.HasSuffix(reason, ": manifest unknown") {
+			t.Errorf("%s: expected the kubelet message in %q", tc.name, reason)
+		}
+	}
+}
+
+func TestRecordRunnerImageDigest(t *testing.T) {
+	image := "quay.io/team/runner:digest-test"
+	recordRunnerImageDigest(image, "quay.io/team/runner@sha256:"+strings.Repeat("1", 64))
+	if got := lastKnownGoodRunnerDigest(image); got != "sha256:"+strings.Repeat("1", 64) {
+		t.Errorf("expected the digest to be recorded, got %q", got)
+	}
+	recordRunnerImageDigest(image, "")
+	if got := lastKnownGoodRunnerDigest(image); got != "sha256:"+strings.Repeat("1", 64) {
+		t.Errorf("an empty image ID must not clear the digest, got %q", got)
+	}
+}
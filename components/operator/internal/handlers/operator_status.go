@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// operatorStatusConfigMapName is the ConfigMap in the operator namespace holding its build
+	// metadata, reconcile stats and session startup latency histograms; the backend serves it at
+	// GET /api/admin/operator-status and the histograms on /metrics
+	operatorStatusConfigMapName = "ambient-operator-status"
+	// operatorStatusInterval is how often the status ConfigMap is refreshed
+	operatorStatusInterval = 30 * time.Second
+)
+
+// BuildInfo is the operator's build metadata, set at build time via -ldflags
+type BuildInfo struct {
+	GitVersion string
+	GitCommit  string
+	GitBranch  string
+	BuildDate  string
+}
+
+// reconcileStats counts handled AgenticSession events since the operator started
+type reconcileStats struct {
+	sessionsReconciled int64
+	reconcileErrors    int64
+	lastReconcile      time.Time
+	lastError          string
+	lastErrorTime      time.Time
+}
+
+var (
+	stats   reconcileStats
+	statsMu sync.Mutex
+)
+
+// recordReconcile counts one handled AgenticSession event and its outcome
+func recordReconcile(err error) {
+	now := time.Now()
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	stats.sessionsReconciled++
+	stats.lastReconcile = now
+	if err != nil {
+		stats.reconcileErrors++
+		stats.lastError = err.Error()
+		stats.lastErrorTime = now
+	}
+}
+
+// recordSweep marks a completed periodic session sweep, so an idle but healthy operator keeps a
+// recent last reconcile time
+func recordSweep() {
+	now := time.Now()
+	statsMu.Lock()
+	stats.lastReconcile = now
+	statsMu.Unlock()
+}
+
+// PublishOperatorStatus periodically writes the build metadata and reconcile stats to the
+// operator status ConfigMap in namespace
+func PublishOperatorStatus(namespace string, build BuildInfo) {
+	log.Printf("Publishing operator status to ConfigMap %s/%s", namespace, operatorStatusConfigMapName)
+	startedAt := time.Now()
+	for {
+		if err := publishOperatorStatus(context.TODO(), namespace, build, startedAt, time.Now()); err != nil {
+			log.Printf("Failed to publish operator status: %v", err)
+		}
+		time.Sleep(operatorStatusInterval)
+	}
+}
+
+func publishOperatorStatus(ctx context.Context, namespace string, build BuildInfo, startedAt, now time.Time) error {
+	data := operatorStatusData(build, startedAt, now)
+	cms := config.K8sClient.CoreV1().ConfigMaps(namespace)
+	existing, err := cms.Get(ctx, operatorStatusConfigMapName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		_, err = cms.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      operatorStatusConfigMapName,
+				Namespace: namespace,
+				Labels:    map[string]string{"app": "agentic-operator"},
+			},
+			Data: data,
+		}, v1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	existing.Data = data
+	_, err = cms.Update(ctx, existing, v1.UpdateOptions{})
+	return err
+}
+
+// operatorStatusData renders the ConfigMap data; times are RFC3339 and empty when unset
+func operatorStatusData(build BuildInfo, startedAt, now time.Time) map[string]string {
+	statsMu.Lock()
+	s := stats
+	statsMu.Unlock()
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+	return map[string]string{
+		"gitVersion":         build.GitVersion,
+		"gitCommit":          build.GitCommit,
+		"gitBranch":          build.GitBranch,
+		"buildDate":          build.BuildDate,
+		"startedAt":          formatTime(startedAt),
+		"updatedAt":          formatTime(now),
+		"sessionsReconciled": strconv.FormatInt(s.sessionsReconciled, 10),
+		"reconcileErrors":    strconv.FormatInt(s.reconcileErrors, 10),
+		"lastReconcileTime":  formatTime(s.lastReconcile),
+		"lastError":          s.lastError,
+		"lastErrorTime":      formatTime(s.lastErrorTime),
+		"startupLatency":     startupLatencyData(),
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestPublishOperatorStatus verifies the status ConfigMap is created, then refreshed with the
+// current reconcile stats
+func TestPublishOperatorStatus(t *testing.T) {
+	setupTestClient()
+	statsMu.Lock()
+	stats = reconcileStats{}
+	statsMu.Unlock()
+
+	build := BuildInfo{GitVersion: "v1.2.3", GitCommit: "abc123", GitBranch: "main", BuildDate: "2026-01-01T00:00:00Z"}
+	startedAt := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	if err := publishOperatorStatus(context.Background(), "ambient-code", build, startedAt, startedAt); err != nil {
+		t.Fatalf("first publish: %v", err)
+	}
+
+	recordReconcile(nil)
+	recordReconcile(fmt.Errorf("failed to create job: quota exceeded"))
+	now := startedAt.Add(time.Minute)
+	if err := publishOperatorStatus(context.Background(), "ambient-code", build, startedAt, now); err != nil {
+		t.Fatalf("second publish: %v", err)
+	}
+
+	cm, err := config.K8sClient.CoreV1().ConfigMaps("ambient-code").Get(context.Background(), operatorStatusConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get status ConfigMap: %v", err)
+	}
+	want := map[string]string{
+		"gitVersion":         "v1.2.3",
+		"gitCommit":          "abc123",
+		"startedAt":          "2026-01-01T10:00:00Z",
+		"updatedAt":          "2026-01-01T10:01:00Z",
+		"sessionsReconciled": "2",
+		"reconcileErrors":    "1",
+		"lastError":          "failed to create job: quota exceeded",
+	}
+	for k, v := range want {
+		if cm.Data[k] != v {
+			t.Errorf("data[%s] = %q, want %q", k, cm.Data[k], v)
+		}
+	}
+	if cm.Data["lastReconcileTime"] == "" {
+		t.Error("lastReconcileTime not set")
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// orphanSweepInterval is how often per-session runner RBAC is checked for deleted sessions
+	orphanSweepInterval = 10 * time.Minute
+
+	runnerSessionResourcePrefix = "ambient-session-"
+	runnerJobSuffix             = "-job"
+)
+
+// orphanJobSweepDryRun reports whether orphaned runner Jobs are only logged instead of deleted
+// (ORPHAN_JOB_SWEEP_DRY_RUN=true), for rolling the Job sweep out safely
+func orphanJobSweepDryRun() bool {
+	return strings.EqualFold(strings.TrimSpace(os.Getenv("ORPHAN_JOB_SWEEP_DRY_RUN")), "true")
+}
+
+// CleanupOrphanedSessionResources periodically removes runner Jobs, ServiceAccounts, Roles,
+// RoleBindings and token Secrets whose AgenticSession no longer exists. Owner references normally let the garbage
+// collector do this, but objects left by partially failed provisioning or by sessions deleted while
+// the backend was down can outlive their session.
+func CleanupOrphanedSessionResources() {
+	log.Println("Starting orphaned session resource cleanup goroutine")
+	for {
+		sweepOrphanedSessionResources(context.TODO())
+		time.Sleep(orphanSweepInterval)
+	}
+}
+
+// sweepOrphanedSessionResources performs one pass over all managed namespaces
+func sweepOrphanedSessionResources(ctx context.Context) {
+	namespaces, err := config.K8sClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{
+		LabelSelector: "ambient-code.io/managed=true",
+	})
+	if err != nil {
+		log.Printf("[OrphanCleanup] Failed to list managed namespaces: %v", err)
+		return
+	}
+	for _, ns := range namespaces.Items {
+		sweepNamespaceSessionResources(ctx, ns.Name)
+	}
+}
+
+func sweepNamespaceSessionResources(ctx context.Context, namespace string) {
+	// Cache session lookups; the four object kinds usually share a session
+	exists := map[string]bool{}
+	sessionGone := func(name string) bool {
+		if alive, ok := exists[name]; ok {
+			return !alive
+		}
+		_, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			// Unknown state - treat as alive so nothing is deleted on a transient error
+			log.Printf("[OrphanCleanup] Failed to get session %s/%s: %v", namespace, name, err)
+			return false
+		}
+		exists[name] = err == nil
+		return err != nil
+	}
+	deleteIfOrphaned := func(kind string, meta v1.ObjectMeta, prefix, suffix string, dryRun bool, del func(context.Context, string, v1.DeleteOptions) error) {
+		session, ok := owningSessionName(meta, prefix, suffix)
+		if !ok || !sessionGone(session) {
+			return
+		}
+		if dryRun {
+			log.Printf("[OrphanCleanup] Session %s/%s gone, would delete orphaned %s %s (dry run)", namespace, session, kind, meta.Name)
+			return
+		}
+		log.Printf("[OrphanCleanup] Session %s/%s gone, deleting orphaned %s %s", namespace, session, kind, meta.Name)
+		if err := del(ctx, meta.Name, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("[OrphanCleanup] Failed to delete %s %s/%s: %v", kind, namespace, meta.Name, err)
+		}
+	}
+
+	jobs := config.K8sClient.BatchV1().Jobs(namespace)
+	if list, err := jobs.List(ctx, v1.ListOptions{LabelSelector: "app=ambient-code-runner"}); err != nil {
+		log.Printf("[OrphanCleanup] Failed to list Jobs in %s: %v", namespace, err)
+	} else {
+		dryRun := orphanJobSweepDryRun()
+		for _, job := range list.Items {
+			deleteIfOrphaned("Job", job.ObjectMeta, "", runnerJobSuffix, dryRun, func(ctx context.Context, name string, opts v1.DeleteOptions) error {
+				// Take the runner pod down with the Job
+				propagation := v1.DeletePropagationBackground
+				opts.PropagationPolicy = &propagation
+				return jobs.Delete(ctx, name, opts)
+			})
+		}
+	}
+
+	if sas, err := config.K8sClient.CoreV1().ServiceAccounts(namespace).List(ctx, v1.ListOptions{LabelSelector: "app=ambient-runner"}); err != nil {
+		log.Printf("[OrphanCleanup] Failed to list ServiceAccounts in %s: %v", namespace, err)
+	} else {
+		for _, sa := range sas.Items {
+			deleteIfOrphaned("ServiceAccount", sa.ObjectMeta, runnerSessionResourcePrefix, "", false, config.K8sClient.CoreV1().ServiceAccounts(namespace).Delete)
+		}
+	}
+
+	if roles, err := config.K8sClient.RbacV1().Roles(namespace).List(ctx, v1.ListOptions{}); err != nil {
+		log.Printf("[OrphanCleanup] Failed to list Roles in %s: %v", namespace, err)
+	} else {
+		for _, role := range roles.Items {
+			deleteIfOrphaned("Role", role.ObjectMeta, runnerSessionResourcePrefix, "-role", false, config.K8sClient.RbacV1().Roles(namespace).Delete)
+		}
+	}
+
+	if rbs, err := config.K8sClient.RbacV1().RoleBindings(namespace).List(ctx, v1.ListOptions{}); err != nil {
+		log.Printf("[OrphanCleanup] Failed to list RoleBindings in %s: %v", namespace, err)
+	} else {
+		for _, rb := range rbs.Items {
+			deleteIfOrphaned("RoleBinding", rb.ObjectMeta, runnerSessionResourcePrefix, "-rb", false, config.K8sClient.RbacV1().RoleBindings(namespace).Delete)
+		}
+	}
+
+	if secrets, err := config.K8sClient.CoreV1().Secrets(namespace).List(ctx, v1.ListOptions{LabelSelector: "app=ambient-runner-token"}); err != nil {
+		log.Printf("[OrphanCleanup] Failed to list Secrets in %s: %v", namespace, err)
+	} else {
+		for _, secret := range secrets.Items {
+			deleteIfOrphaned("Secret", secret.ObjectMeta, defaultRunnerTokenSecretPrefix, "", false, config.K8sClient.CoreV1().Secrets(namespace).Delete)
+		}
+	}
+}
+
+// owningSessionName returns the AgenticSession a runner object belongs to, preferring its owner
+// reference and falling back to the name pattern (prefix + session + suffix). Objects matching
+// neither are not runner objects and are left alone.
+func owningSessionName(meta v1.ObjectMeta, prefix, suffix string) (string, bool) {
+	if !strings.HasPrefix(meta.Name, prefix) || !strings.HasSuffix(meta.Name, suffix) {
+		return "", false
+	}
+	for _, ref := range meta.OwnerReferences {
+		if ref.Kind == "AgenticSession" && ref.Name != "" {
+			return ref.Name, true
+		}
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(meta.Name, prefix), suffix)
+	return name, name != ""
+}
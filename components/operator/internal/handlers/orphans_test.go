@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// runnerObjects returns the runner Job the operator creates and the SA, Role, RoleBinding and token
+// Secret the backend provisions for a session
+func runnerObjects(namespace, session string) []runtime.Object {
+	meta := func(name string, labels map[string]string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels}
+	}
+	return []runtime.Object{
+		&batchv1.Job{ObjectMeta: meta(session+"-job", map[string]string{"app": "ambient-code-runner", "agentic-session": session})},
+		&corev1.ServiceAccount{ObjectMeta: meta("ambient-session-"+session, map[string]string{"app": "ambient-runner"})},
+		&rbacv1.Role{ObjectMeta: meta("ambient-session-"+session+"-role", nil)},
+		&rbacv1.RoleBinding{ObjectMeta: meta("ambient-session-"+session+"-rb", nil)},
+		&corev1.Secret{ObjectMeta: meta("ambient-runner-token-"+session, map[string]string{"app": "ambient-runner-token"})},
+	}
+}
+
+// TestSweepOrphanedSessionResources verifies runner RBAC is removed only for sessions that no longer exist
+func TestSweepOrphanedSessionResources(t *testing.T) {
+	ns := "proj"
+	objects := []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns, Labels: map[string]string{"ambient-code.io/managed": "true"}}},
+		// Unrelated objects with similar names must survive
+		&rbacv1.Role{ObjectMeta: metav1.ObjectMeta{Name: "ambient-project-edit", Namespace: ns}},
+	}
+	objects = append(objects, runnerObjects(ns, "alive")...)
+	objects = append(objects, runnerObjects(ns, "gone")...)
+	setupTestClient(objects...)
+
+	session := &unstructured.Unstructured{}
+	session.SetAPIVersion("vteam.ambient-code/v1alpha1")
+	session.SetKind("AgenticSession")
+	session.SetName("alive")
+	session.SetNamespace(ns)
+	gvr := types.GetAgenticSessionResource()
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "AgenticSessionList"}, session)
+
+	sweepOrphanedSessionResources(context.Background())
+
+	ctx := context.Background()
+	core := config.K8sClient.CoreV1()
+	rbac := config.K8sClient.RbacV1()
+	for session, wantExists := range map[string]bool{"alive": true, "gone": false} {
+		checks := map[string]error{}
+		_, checks["Job"] = config.K8sClient.BatchV1().Jobs(ns).Get(ctx, session+"-job", metav1.GetOptions{})
+		_, checks["ServiceAccount"] = core.ServiceAccounts(ns).Get(ctx, "ambient-session-"+session, metav1.GetOptions{})
+		_, checks["Role"] = rbac.Roles(ns).Get(ctx, "ambient-session-"+session+"-role", metav1.GetOptions{})
+		_, checks["RoleBinding"] = rbac.RoleBindings(ns).Get(ctx, "ambient-session-"+session+"-rb", metav1.GetOptions{})
+		_, checks["Secret"] = core.Secrets(ns).Get(ctx, "ambient-runner-token-"+session, metav1.GetOptions{})
+		for kind, err := range checks {
+			if wantExists && err != nil {
+				t.Errorf("expected %s for session %q to be kept, got %v", kind, session, err)
+			}
+			if !wantExists && !errors.IsNotFound(err) {
+				t.Errorf("expected %s for session %q to be deleted, got %v", kind, session, err)
+			}
+		}
+	}
+	if _, err := rbac.Roles(ns).Get(ctx, "ambient-project-edit", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected unrelated Role to be kept, got %v", err)
+	}
+}
+
+// TestSweepOrphanedJobsDryRun verifies the dry-run mode only logs orphaned runner Jobs
+func TestSweepOrphanedJobsDryRun(t *testing.T) {
+	t.Setenv("ORPHAN_JOB_SWEEP_DRY_RUN", "true")
+	ns := "proj"
+	setupTestClient(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns, Labels: map[string]string{"ambient-code.io/managed": "true"}}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "gone-job", Namespace: ns, Labels: map[string]string{"app": "ambient-code-runner"}}},
+	)
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{types.GetAgenticSessionResource(): "AgenticSessionList"})
+
+	sweepOrphanedSessionResources(context.Background())
+
+	if _, err := config.K8sClient.BatchV1().Jobs(ns).Get(context.Background(), "gone-job", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected Job to be kept in dry-run mode, got %v", err)
+	}
+}
+
+// TestSessionControllerRef verifies owner references use the session's served apiVersion
+func TestSessionControllerRef(t *testing.T) {
+	session := &unstructured.Unstructured{}
+	session.SetName("s1")
+	session.SetUID("uid-1")
+	ref := sessionControllerRef(session)
+	if ref.APIVersion != "vteam.ambient-code/v1alpha1" || ref.Kind != "AgenticSession" || ref.Controller == nil || !*ref.Controller {
+		t.Errorf("unexpected owner reference %+v", ref)
+	}
+	session.SetAPIVersion("vteam.ambient-code/v1beta1")
+	if got := sessionControllerRef(session).APIVersion; got != "vteam.ambient-code/v1beta1" {
+		t.Errorf("expected the object's apiVersion, got %q", got)
+	}
+}
+
+// TestOwningSessionName verifies the owner reference wins over the name pattern
+func TestOwningSessionName(t *testing.T) {
+	meta := metav1.ObjectMeta{Name: "ambient-session-a-b-rb"}
+	if got, ok := owningSessionName(meta, "ambient-session-", "-rb"); !ok || got != "a-b" {
+		t.Errorf("expected a-b from name, got %q (%v)", got, ok)
+	}
+	meta.OwnerReferences = []metav1.OwnerReference{{Kind: "AgenticSession", Name: "a-b-c"}}
+	if got, _ := owningSessionName(meta, "ambient-session-", "-rb"); got != "a-b-c" {
+		t.Errorf("expected owner reference name, got %q", got)
+	}
+	if _, ok := owningSessionName(metav1.ObjectMeta{Name: "other-rb"}, "ambient-session-", "-rb"); ok {
+		t.Error("expected non-runner object to be ignored")
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import "fmt"
+
+// Session phases written by the operator (see the status.phase enum in the AgenticSession CRD)
+const (
+	phasePending   = "Pending"
+	phaseCreating  = "Creating"
+	phaseRunning   = "Running"
+	phaseStopping  = "Stopping"
+	phaseStopped   = "Stopped"
+	phaseCompleted = "Completed"
+	phaseFailed    = "Failed"
+	phaseError     = "Error"
+)
+
+// phaseTransitions lists the phase changes the reconciler may make. Terminal phases are left
+// out: they can only move back to Pending when the user asked for a restart (StartSession).
+var phaseTransitions = map[string][]string{
+	phasePending:  {phaseQueued, phaseCreating, phaseFailed, phaseError, phaseStopped},
+	phaseQueued:   {phasePending, phaseStopped},
+	phaseCreating: {phaseRunning, phaseCompleted, phaseFailed, phaseError, phaseStopping, phaseStopped, phasePending, phaseQueued},
+	phaseRunning:  {phaseCompleted, phaseFailed, phaseError, phaseStopping},
+	phaseStopping: {phaseStopped},
+}
+
+func isTerminalPhase(phase string) bool {
+	switch phase {
+	case phaseStopped, phaseCompleted, phaseFailed, phaseError:
+		return true
+	}
+	return false
+}
+
+// validatePhaseTransition rejects unknown phases and moves outside the session state machine.
+// restartRequested reports whether the session carries desired-phase=Running, which is the only
+// way out of a terminal phase.
+func validatePhaseTransition(from, to string, restartRequested bool) error {
+	if _, known := phaseTransitions[to]; !known && !isTerminalPhase(to) {
+		return fmt.Errorf("unknown session phase %q", to)
+	}
+	if from == "" || from == to {
+		return nil
+	}
+	if isTerminalPhase(from) {
+		if to == phasePending && restartRequested {
+			return nil
+		}
+		return fmt.Errorf("session phase %s is terminal; only a restart can move it to Pending (requested %s)", from, to)
+	}
+	for _, allowed := range phaseTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("illegal session phase transition %s -> %s", from, to)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import "testing"
+
+// TestValidatePhaseTransition walks every from/to pair so a new reconciler transition has to be
+// added to phaseTransitions deliberately
+func TestValidatePhaseTransition(t *testing.T) {
+	phases := []string{phasePending, phaseQueued, phaseCreating, phaseRunning, phaseStopping, phaseStopped, phaseCompleted, phaseFailed, phaseError}
+	allowed := map[string]bool{
+		"Pending->Queued":     true,
+		"Pending->Creating":   true,
+		"Pending->Failed":     true,
+		"Pending->Error":      true,
+		"Pending->Stopped":    true,
+		"Queued->Pending":     true,
+		"Queued->Stopped":     true,
+		"Creating->Running":   true,
+		"Creating->Completed": true,
+		"Creating->Failed":    true,
+		"Creating->Error":     true,
+		"Creating->Stopping":  true,
+		"Creating->Stopped":   true,
+		"Creating->Pending":   true,
+		"Creating->Queued":    true,
+		"Running->Completed":  true,
+		"Running->Failed":     true,
+		"Running->Error":      true,
+		"Running->Stopping":   true,
+		"Stopping->Stopped":   true,
+	}
+
+	for _, restart := range []bool{false, true} {
+		for _, from := range phases {
+			for _, to := range phases {
+				want := from == to || allowed[from+"->"+to] || (restart && isTerminalPhase(from) && to == phasePending)
+				err := validatePhaseTransition(from, to, restart)
+				if want && err != nil {
+					t.Errorf("%s -> %s (restart=%v) should be allowed: %v", from, to, restart, err)
+				}
+				if !want && err == nil {
+					t.Errorf("%s -> %s (restart=%v) should be rejected", from, to, restart)
+				}
+			}
+		}
+	}
+}
+
+func TestValidatePhaseTransition_UnknownAndInitial(t *testing.T) {
+	if err := validatePhaseTransition(phaseRunning, "Paused", false); err == nil {
+		t.Error("unknown phase should be rejected")
+	}
+	if err := validatePhaseTransition("", phasePending, false); err != nil {
+		t.Errorf("initializing the phase should be allowed: %v", err)
+	}
+	if err := validatePhaseTransition(phaseStopped, phaseRunning, true); err == nil {
+		t.Error("a restart must go through Pending")
+	}
+}
+
+func TestStopCondition(t *testing.T) {
+	reason, msg := stopCondition(map[string]interface{}{"stoppedReason": "costLimit"}, "Runner stopped by user")
+	if reason != "CostLimitExceeded" || msg != "cost limit exceeded" {
+		t.Errorf("cost limit stop = %q, %q", reason, msg)
+	}
+	reason, msg = stopCondition(map[string]interface{}{}, "Runner stopped by user")
+	if reason != "UserStopped" || msg != "Runner stopped by user" {
+		t.Errorf("user stop = %q, %q", reason, msg)
+	}
+}
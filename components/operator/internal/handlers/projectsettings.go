@@ -141,12 +141,18 @@ func reconcileProjectSettings(obj *unstructured.Unstructured) error {
 		}
 	}
 
+	// Report settings edited outside the backend API that sessions would fail on
+	problems := validateProjectSettings(context.TODO(), namespace, spec)
+	if len(problems) > 0 {
+		log.Printf("ProjectSettings %s/%s is invalid: %s", namespace, name, strings.Join(problems, "; "))
+	}
+
 	// Update status with reconciliation results (only fields defined in CRD)
 	statusUpdate := map[string]interface{}{
 		"groupBindingsCreated": groupBindingsCreated,
 	}
 
-	return updateProjectSettingsStatus(namespace, name, statusUpdate)
+	return updateProjectSettingsStatus(namespace, name, statusUpdate, settingsValidCondition(problems))
 }
 
 func ensureRoleBinding(namespace, groupName, role string) error {
@@ -210,7 +216,7 @@ func mapRoleToKubernetesRole(role string) string {
 	}
 }
 
-func updateProjectSettingsStatus(namespace, name string, statusUpdate map[string]interface{}) error {
+func updateProjectSettingsStatus(namespace, name string, statusUpdate map[string]interface{}, conditions ...conditionUpdate) error {
 	gvr := types.GetProjectSettingsResource()
 
 	// Get current resource
@@ -232,6 +238,9 @@ func updateProjectSettingsStatus(namespace, name string, statusUpdate map[string
 	for key, value := range statusUpdate {
 		status[key] = value
 	}
+	for _, cond := range conditions {
+		setCondition(status, cond)
+	}
 
 	// Update the resource
 	_, err = config.DynamicClient.Resource(gvr).Namespace(namespace).UpdateStatus(context.TODO(), obj, v1.UpdateOptions{})
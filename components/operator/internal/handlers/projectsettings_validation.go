@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"ambient-code-operator/internal/config"
+)
+
+// conditionSettingsValid is the ProjectSettings condition reporting problems found by
+// validateProjectSettings
+const conditionSettingsValid = "SettingsValid"
+
+// outputBranchTemplateFields are the fields the backend renders an outputBranchTemplate with
+type outputBranchTemplateFields struct {
+	SessionName     string
+	User            string
+	Date            string
+	DisplayNameSlug string
+}
+
+// validateProjectSettings returns the problems of a ProjectSettings spec as "field: message". The
+// backend rejects these when settings are saved through its API; this catches settings applied
+// with kubectl before sessions fail on them.
+func validateProjectSettings(ctx context.Context, namespace string, spec map[string]interface{}) []string {
+	problems := []string{}
+	secretHasKey := func(field, name, key string) {
+		sec, err := config.K8sClient.CoreV1().Secrets(namespace).Get(ctx, name, v1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			problems = append(problems, fmt.Sprintf("%s: secret %q not found", field, name))
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: secret %q could not be read: %v", field, name, err))
+		case key != "":
+			if _, ok := sec.Data[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: secret %q has no key %q", field, name, key))
+			}
+		}
+	}
+
+	if name, _, _ := unstructured.NestedString(spec, "runnerSecretsName"); strings.TrimSpace(name) != "" {
+		secretHasKey("spec.runnerSecretsName", strings.TrimSpace(name), "")
+	}
+
+	if name, _, _ := unstructured.NestedString(spec, "gitlab", "caBundleSecretRef", "name"); strings.TrimSpace(name) != "" {
+		key, _, _ := unstructured.NestedString(spec, "gitlab", "caBundleSecretRef", "key")
+		if key = strings.TrimSpace(key); key == "" {
+			key = "ca.crt"
+		}
+		secretHasKey("spec.gitlab.caBundleSecretRef", strings.TrimSpace(name), key)
+	}
+
+	if enabled, _, _ := unstructured.NestedBool(spec, "commitSigning", "enabled"); enabled {
+		sshName, _, _ := unstructured.NestedString(spec, "commitSigning", "sshKeySecretRef", "name")
+		gpgName, _, _ := unstructured.NestedString(spec, "commitSigning", "gpgKeySecretRef", "name")
+		switch {
+		case strings.TrimSpace(sshName) != "" && strings.TrimSpace(gpgName) != "":
+			problems = append(problems, "spec.commitSigning: set only one of sshKeySecretRef and gpgKeySecretRef")
+		case strings.TrimSpace(sshName) != "":
+			key, _, _ := unstructured.NestedString(spec, "commitSigning", "sshKeySecretRef", "key")
+			if key = strings.TrimSpace(key); key == "" {
+				key = "ssh-privatekey"
+			}
+			secretHasKey("spec.commitSigning.sshKeySecretRef", strings.TrimSpace(sshName), key)
+		case strings.TrimSpace(gpgName) != "":
+			key, _, _ := unstructured.NestedString(spec, "commitSigning", "gpgKeySecretRef", "key")
+			if key = strings.TrimSpace(key); key == "" {
+				key = "private.key"
+			}
+			secretHasKey("spec.commitSigning.gpgKeySecretRef", strings.TrimSpace(gpgName), key)
+		default:
+			problems = append(problems, "spec.commitSigning: sshKeySecretRef or gpgKeySecretRef is required when signing is enabled")
+		}
+	}
+
+	if tmpl, _, _ := unstructured.NestedString(spec, "outputBranchTemplate"); strings.TrimSpace(tmpl) != "" {
+		t, err := template.New("outputBranchTemplate").Option("missingkey=error").Parse(tmpl)
+		if err == nil {
+			err = t.Execute(io.Discard, outputBranchTemplateFields{})
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("spec.outputBranchTemplate: %v", err))
+		}
+	}
+
+	if maxAge, _, _ := unstructured.NestedString(spec, "sessionRetention", "maxAge"); maxAge != "" {
+		if d, err := time.ParseDuration(maxAge); err != nil || d <= 0 {
+			problems = append(problems, fmt.Sprintf("spec.sessionRetention.maxAge: %q is not a positive duration", maxAge))
+		}
+	}
+
+	if maxSize, _, _ := unstructured.NestedString(spec, "maxWorkspaceSize"); maxSize != "" {
+		if q, err := resource.ParseQuantity(maxSize); err != nil || q.Sign() <= 0 {
+			problems = append(problems, fmt.Sprintf("spec.maxWorkspaceSize: %q is not a positive storage quantity", maxSize))
+		}
+	}
+
+	if lbls, found, err := unstructured.NestedMap(spec, "chargebackLabels"); err != nil {
+		problems = append(problems, fmt.Sprintf("spec.chargebackLabels: %v", err))
+	} else if found {
+		keys := make([]string, 0, len(lbls))
+		for k := range lbls {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value, _ := lbls[k].(string)
+			errs := append(validation.IsQualifiedName(chargebackLabelPrefix+k), validation.IsValidLabelValue(value)...)
+			if len(errs) > 0 {
+				problems = append(problems, fmt.Sprintf("spec.chargebackLabels.%s: %s", k, strings.Join(errs, "; ")))
+			}
+		}
+	}
+
+	def, _, _ := unstructured.NestedFieldNoCopy(spec, "sessionCostLimitUSD")
+	max, _, _ := unstructured.NestedFieldNoCopy(spec, "maxSessionCostLimitUSD")
+	if d, m := floatValue(def), floatValue(max); d > 0 && m > 0 && d > m {
+		problems = append(problems, fmt.Sprintf("spec.sessionCostLimitUSD: must not exceed maxSessionCostLimitUSD (%g)", m))
+	}
+	return problems
+}
+
+// settingsValidCondition turns validateProjectSettings problems into the SettingsValid condition
+func settingsValidCondition(problems []string) conditionUpdate {
+	if len(problems) == 0 {
+		return conditionUpdate{Type: conditionSettingsValid, Status: "True", Reason: "Valid", Message: "Settings are valid"}
+	}
+	return conditionUpdate{Type: conditionSettingsValid, Status: "False", Reason: "InvalidSettings", Message: strings.Join(problems, "; ")}
+}
+
+// floatValue reads a number from an unstructured object, decoded as an integer or a float; 0 otherwise
+func floatValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateProjectSettings(t *testing.T) {
+	setupTestClient(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "team-runner-secrets", Namespace: "proj"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "corp-ca", Namespace: "proj"}, Data: map[string][]byte{"ca.crt": []byte("pem")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing-key", Namespace: "proj"}, Data: map[string][]byte{"ssh-privatekey": []byte("key")}},
+	)
+	ctx := context.Background()
+
+	valid := map[string]interface{}{
+		"runnerSecretsName":      "team-runner-secrets",
+		"gitlab":                 map[string]interface{}{"baseUrl": "https://gitlab.corp.example", "caBundleSecretRef": map[string]interface{}{"name": "corp-ca"}},
+		"outputBranchTemplate":   "vteam/{{.User}}/{{.DisplayNameSlug}}",
+		"sessionRetention":       map[string]interface{}{"maxAge": "720h"},
+		"sessionCostLimitUSD":    int64(5),
+		"maxSessionCostLimitUSD": 10.5,
+		"commitSigning":          map[string]interface{}{"enabled": true, "sshKeySecretRef": map[string]interface{}{"name": "signing-key"}},
+		"chargebackLabels":       map[string]interface{}{"costCenter": "cc-42", "team": "payments"},
+		"maxWorkspaceSize":       "100Gi",
+	}
+	if problems := validateProjectSettings(ctx, "proj", valid); len(problems) != 0 {
+		t.Fatalf("valid settings reported problems: %v", problems)
+	}
+	if cond := settingsValidCondition(nil); cond.Status != "True" {
+		t.Errorf("condition for valid settings = %+v", cond)
+	}
+
+	invalid := map[string]interface{}{
+		"runnerSecretsName":      "missing-secret",
+		"gitlab":                 map[string]interface{}{"caBundleSecretRef": map[string]interface{}{"name": "corp-ca", "key": "bundle.pem"}},
+		"outputBranchTemplate":   "vteam/{{.Nope}}",
+		"sessionRetention":       map[string]interface{}{"maxAge": "0s"},
+		"sessionCostLimitUSD":    20.0,
+		"maxSessionCostLimitUSD": int64(10),
+		"commitSigning":          map[string]interface{}{"enabled": true, "gpgKeySecretRef": map[string]interface{}{"name": "signing-key"}},
+		"chargebackLabels":       map[string]interface{}{"cost/center": "cc-42"},
+		"maxWorkspaceSize":       "lots",
+	}
+	problems := validateProjectSettings(ctx, "proj", invalid)
+	for _, field := range []string{"spec.runnerSecretsName: secret \"missing-secret\" not found", "spec.gitlab.caBundleSecretRef: secret \"corp-ca\" has no key \"bundle.pem\"", "spec.outputBranchTemplate:", "spec.sessionRetention.maxAge:", "spec.sessionCostLimitUSD:", "spec.commitSigning.gpgKeySecretRef: secret \"signing-key\" has no key \"private.key\"", "spec.chargebackLabels.cost/center:", "spec.maxWorkspaceSize:"} {
+		found := false
+		for _, p := range problems {
+			found = found || strings.HasPrefix(p, field)
+		}
+		if !found {
+			t.Errorf("no problem starting with %q in %v", field, problems)
+		}
+	}
+	cond := settingsValidCondition(problems)
+	if cond.Status != "False" || cond.Reason != "InvalidSettings" || !strings.Contains(cond.Message, "missing-secret") {
+		t.Errorf("condition for invalid settings = %+v", cond)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"path"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	initialPromptVolumeName = "initial-prompt"
+	initialPromptMountPath  = "/var/run/ambient/prompt"
+	initialPromptFileName   = "prompt"
+)
+
+// sessionPromptRef returns spec.promptRef, set by the backend instead of spec.initialPrompt for
+// prompts too large to keep inline in the CR
+func sessionPromptRef(spec map[string]interface{}) (configMap, key string, ok bool) {
+	configMap, _, _ = unstructured.NestedString(spec, "promptRef", "configMapName")
+	key, _, _ = unstructured.NestedString(spec, "promptRef", "key")
+	configMap, key = strings.TrimSpace(configMap), strings.TrimSpace(key)
+	return configMap, key, configMap != "" && key != ""
+}
+
+// mountInitialPrompt mounts the prompt ConfigMap into the runner and points INITIAL_PROMPT_FILE at
+// it. The prompt is not passed in INITIAL_PROMPT because a single environment variable is limited
+// to 128 KiB.
+func mountInitialPrompt(job *batchv1.Job, configMap, key string) {
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: initialPromptVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMap},
+				Items:                []corev1.KeyToPath{{Key: key, Path: initialPromptFileName}},
+			},
+		},
+	})
+	for i := range job.Spec.Template.Spec.Containers {
+		c := &job.Spec.Template.Spec.Containers[i]
+		if c.Name != "ambient-code-runner" {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      initialPromptVolumeName,
+			MountPath: initialPromptMountPath,
+			ReadOnly:  true,
+		})
+		c.Env = append(c.Env, corev1.EnvVar{Name: "INITIAL_PROMPT_FILE", Value: path.Join(initialPromptMountPath, initialPromptFileName)})
+		break
+	}
+}
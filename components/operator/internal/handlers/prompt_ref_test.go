@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestMountInitialPrompt verifies the prompt ConfigMap reaches only the runner container
+func TestMountInitialPrompt(t *testing.T) {
+	spec := map[string]interface{}{"promptRef": map[string]interface{}{"configMapName": "ambient-prompt-s1", "key": "prompt"}}
+	cm, key, ok := sessionPromptRef(spec)
+	if !ok || cm != "ambient-prompt-s1" || key != "prompt" {
+		t.Fatalf("sessionPromptRef = %q, %q, %v", cm, key, ok)
+	}
+	if _, _, ok := sessionPromptRef(map[string]interface{}{"initialPrompt": "hi"}); ok {
+		t.Fatal("inline prompts have no promptRef")
+	}
+
+	job := &batchv1.Job{}
+	job.Spec.Template.Spec.Containers = []corev1.Container{{Name: "ambient-content"}, {Name: "ambient-code-runner"}}
+	mountInitialPrompt(job, cm, key)
+
+	vols := job.Spec.Template.Spec.Volumes
+	if len(vols) != 1 || vols[0].ConfigMap == nil || vols[0].ConfigMap.Name != "ambient-prompt-s1" || vols[0].ConfigMap.Items[0].Key != "prompt" {
+		t.Fatalf("unexpected volumes: %+v", vols)
+	}
+	if len(job.Spec.Template.Spec.Containers[0].VolumeMounts) != 0 {
+		t.Error("content container must not mount the prompt")
+	}
+	runner := job.Spec.Template.Spec.Containers[1]
+	if len(runner.VolumeMounts) != 1 || runner.VolumeMounts[0].MountPath != initialPromptMountPath {
+		t.Errorf("unexpected runner mounts: %+v", runner.VolumeMounts)
+	}
+	if len(runner.Env) != 1 || runner.Env[0].Name != "INITIAL_PROMPT_FILE" || runner.Env[0].Value != "/var/run/ambient/prompt/prompt" {
+		t.Errorf("unexpected runner env: %+v", runner.Env)
+	}
+}
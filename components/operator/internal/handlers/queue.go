@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	phaseQueued = "Queued"
+
+	sessionPriorityLow    = "low"
+	sessionPriorityNormal = "normal"
+	sessionPriorityHigh   = "high"
+
+	// sessionQueueRetryInterval is how often queued sessions are retried
+	sessionQueueRetryInterval = 15 * time.Second
+	// defaultUnschedulableQueueAfter is how long a runner pod may stay unschedulable before the session is queued
+	defaultUnschedulableQueueAfter = 120 * time.Second
+)
+
+// queuedSession is the minimal view of a Queued AgenticSession needed for ordering
+type queuedSession struct {
+	Name     string
+	Priority string
+	QueuedAt time.Time
+}
+
+// sessionPriorityRank maps spec.priority to a sortable rank (higher runs first).
+// Unknown or empty values are treated as normal.
+func sessionPriorityRank(priority string) int {
+	switch strings.ToLower(strings.TrimSpace(priority)) {
+	case sessionPriorityHigh:
+		return 2
+	case sessionPriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// queuedBefore reports whether a should be started before b: priority first, then FIFO
+func queuedBefore(a, b queuedSession) bool {
+	ra, rb := sessionPriorityRank(a.Priority), sessionPriorityRank(b.Priority)
+	if ra != rb {
+		return ra > rb
+	}
+	if !a.QueuedAt.Equal(b.QueuedAt) {
+		return a.QueuedAt.Before(b.QueuedAt)
+	}
+	return a.Name < b.Name
+}
+
+// sortQueuedSessions orders sessions so the next one to start is first
+func sortQueuedSessions(sessions []queuedSession) {
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return queuedBefore(sessions[i], sessions[j])
+	})
+}
+
+// unschedulableQueueAfter returns the configured unschedulable grace period
+// (SESSION_UNSCHEDULABLE_QUEUE_SECONDS), falling back to the default.
+func unschedulableQueueAfter() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_UNSCHEDULABLE_QUEUE_SECONDS")); raw != "" {
+		if secs, err := strconv.Atoi(raw); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		log.Printf("Invalid SESSION_UNSCHEDULABLE_QUEUE_SECONDS %q, using default %v", raw, defaultUnschedulableQueueAfter)
+	}
+	return defaultUnschedulableQueueAfter
+}
+
+// isQuotaExceededError reports whether an API error was caused by an exhausted ResourceQuota
+func isQuotaExceededError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.IsForbidden(err) && strings.Contains(strings.ToLower(err.Error()), "exceeded quota")
+}
+
+// jobHasQuotaFailure checks the Job's events for pod creation failures caused by ResourceQuota.
+// The Job name is reused every time a session is dequeued, so events left over from an earlier
+// Job with the same name are ignored: they must carry this Job's UID and must not predate it.
+func jobHasQuotaFailure(namespace string, job *batchv1.Job) (bool, string) {
+	events, err := config.K8sClient.CoreV1().Events(namespace).List(context.TODO(), v1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=Job,involvedObject.name=%s", job.Name),
+	})
+	if err != nil {
+		log.Printf("Failed to list events for job %s/%s: %v", namespace, job.Name, err)
+		return false, ""
+	}
+	for _, ev := range events.Items {
+		if ev.InvolvedObject.Name != job.Name || ev.Reason != "FailedCreate" {
+			continue
+		}
+		if job.UID != "" && ev.InvolvedObject.UID != "" && ev.InvolvedObject.UID != job.UID {
+			continue
+		}
+		if eventLastSeen(&ev).Before(job.CreationTimestamp.Time) {
+			continue
+		}
+		if strings.Contains(strings.ToLower(ev.Message), "exceeded quota") {
+			return true, ev.Message
+		}
+	}
+	return false, ""
+}
+
+// eventLastSeen returns the most recent time an event was recorded
+func eventLastSeen(ev *corev1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	case !ev.FirstTimestamp.IsZero():
+		return ev.FirstTimestamp.Time
+	default:
+		return ev.CreationTimestamp.Time
+	}
+}
+
+// podUnschedulableFor returns how long the pod has been unschedulable, or 0 if it is schedulable
+func podUnschedulableFor(pod *corev1.Pod, now time.Time) time.Duration {
+	if pod == nil || pod.Status.Phase != corev1.PodPending {
+		return 0
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable {
+			since := cond.LastTransitionTime.Time
+			if since.IsZero() {
+				since = pod.CreationTimestamp.Time
+			}
+			return now.Sub(since)
+		}
+	}
+	return 0
+}
+
+// markSessionQueued queues the status changes that park a session in the Queued phase.
+// An existing queuedAt is preserved so a session keeps its place in line across retries.
+func markSessionQueued(statusPatch *StatusPatch, status map[string]interface{}, message string) {
+	statusPatch.SetField("phase", phaseQueued)
+	if queuedAt, _ := status["queuedAt"].(string); strings.TrimSpace(queuedAt) == "" {
+		statusPatch.SetField("queuedAt", time.Now().UTC().Format(time.RFC3339))
+	}
+	statusPatch.AddCondition(conditionUpdate{
+		Type:    conditionJobCreated,
+		Status:  "False",
+		Reason:  "Queued",
+		Message: message,
+	})
+	statusPatch.AddCondition(conditionUpdate{
+		Type:    conditionReady,
+		Status:  "False",
+		Reason:  "Queued",
+		Message: message,
+	})
+}
+
+// toQueuedSession extracts queue ordering fields from an AgenticSession
+func toQueuedSession(obj *unstructured.Unstructured) queuedSession {
+	priority, _, _ := unstructured.NestedString(obj.Object, "spec", "priority")
+	queuedAtStr, _, _ := unstructured.NestedString(obj.Object, "status", "queuedAt")
+	queuedAt, err := time.Parse(time.RFC3339, queuedAtStr)
+	if err != nil {
+		queuedAt = obj.GetCreationTimestamp().Time
+	}
+	return queuedSession{Name: obj.GetName(), Priority: priority, QueuedAt: queuedAt}
+}
+
+// hasQueuedSessionAhead reports whether another Queued session in the namespace should start
+// before the given one. A session that was never queued goes behind everything of equal or higher priority.
+func hasQueuedSessionAhead(namespace string, session *unstructured.Unstructured) bool {
+	gvr := types.GetAgenticSessionResource()
+	list, err := config.DynamicClient.Resource(gvr).Namespace(namespace).List(context.TODO(), v1.ListOptions{})
+	if err != nil {
+		log.Printf("[Queue] Failed to list sessions in %s: %v", namespace, err)
+		return false
+	}
+
+	self := toQueuedSession(session)
+	if queuedAt, _, _ := unstructured.NestedString(session.Object, "status", "queuedAt"); queuedAt == "" {
+		self.QueuedAt = time.Now().UTC()
+	}
+	for i := range list.Items {
+		item := &list.Items[i]
+		if item.GetName() == session.GetName() {
+			continue
+		}
+		if phase, _, _ := unstructured.NestedString(item.Object, "status", "phase"); phase != phaseQueued {
+			continue
+		}
+		if queuedBefore(toQueuedSession(item), self) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectConcurrencyLimitReached reports whether the namespace already has ProjectSettings
+// spec.maxConcurrentSessions sessions holding a runner Job (Creating, Running or Stopping), not
+// counting the given one. The backend checks the limit when sessions are created or started; this
+// is the safety net for concurrent starts and clients that bypass the backend.
+func projectConcurrencyLimitReached(ctx context.Context, namespace, sessionName string) (bool, string) {
+	settings, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(namespace).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("[Queue] Failed to read ProjectSettings in %s: %v", namespace, err)
+		}
+		return false, ""
+	}
+	limit, found, _ := unstructured.NestedInt64(settings.Object, "spec", "maxConcurrentSessions")
+	if !found || limit <= 0 {
+		return false, ""
+	}
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("[Queue] Failed to list sessions in %s: %v", namespace, err)
+		return false, ""
+	}
+	var active int64
+	for i := range list.Items {
+		if list.Items[i].GetName() == sessionName {
+			continue
+		}
+		switch phase, _, _ := unstructured.NestedString(list.Items[i].Object, "status", "phase"); phase {
+		case "Creating", "Running", "Stopping":
+			active++
+		}
+	}
+	if active < limit {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%d of %d concurrent sessions running", active, limit)
+}
+
+// ProcessSessionQueue periodically moves the highest-priority Queued session in each
+// namespace back to Pending so the operator retries job creation for it.
+func ProcessSessionQueue() {
+	log.Println("Starting session queue processor goroutine")
+	gvr := types.GetAgenticSessionResource()
+	for {
+		time.Sleep(sessionQueueRetryInterval)
+
+		list, err := config.DynamicClient.Resource(gvr).List(context.TODO(), v1.ListOptions{})
+		if err != nil {
+			log.Printf("[Queue] Failed to list AgenticSessions: %v", err)
+			continue
+		}
+
+		queued := make(map[string][]queuedSession)
+		for i := range list.Items {
+			item := &list.Items[i]
+			if phase, _, _ := unstructured.NestedString(item.Object, "status", "phase"); phase != phaseQueued {
+				continue
+			}
+			queued[item.GetNamespace()] = append(queued[item.GetNamespace()], toQueuedSession(item))
+		}
+
+		for namespace, sessions := range queued {
+			sortQueuedSessions(sessions)
+			head := sessions[0]
+			log.Printf("[Queue] Retrying queued session %s/%s (priority=%s, %d queued)", namespace, head.Name, head.Priority, len(sessions))
+			err := mutateAgenticSessionStatus(namespace, head.Name, func(status map[string]interface{}) {
+				if phase, _ := status["phase"].(string); phase != phaseQueued {
+					return
+				}
+				status["phase"] = "Pending"
+				setCondition(status, conditionUpdate{
+					Type:    conditionReady,
+					Status:  "False",
+					Reason:  "Dequeued",
+					Message: "Retrying session start",
+				})
+			})
+			if err != nil {
+				log.Printf("[Queue] Failed to dequeue session %s/%s: %v", namespace, head.Name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// scpLikeRepoURL matches scp-style SSH remotes: [user@]host:path
+var scpLikeRepoURL = regexp.MustCompile(`^(?:[^@/:]+@)?([^@/:]+):(.+)$`)
+
+// normalizeRepoURL returns the canonical form of a repository URL used to compare repos: https
+// scheme, no credentials, lowercased host without its default port, no trailing "/" or ".git", and
+// for GitHub and GitLab hosts a lowercased owner/repo path. Keep in sync with git.NormalizeRepoURL
+// in the backend (components/backend/git/repo_url.go), which matches runner reports to spec repos.
+func normalizeRepoURL(repoURL string) string {
+	s := strings.TrimSpace(repoURL)
+	if s == "" {
+		return ""
+	}
+	if !strings.Contains(s, "://") {
+		if m := scpLikeRepoURL.FindStringSubmatch(s); m != nil {
+			s = "ssh://" + m[1] + "/" + strings.TrimPrefix(m[2], "/")
+		} else {
+			s = "https://" + s
+		}
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Hostname() == "" {
+		s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(repoURL)), "/")
+		return strings.TrimSuffix(s, ".git")
+	}
+
+	hostname := strings.ToLower(u.Hostname())
+	host := hostname
+	port := u.Port()
+	switch strings.ToLower(u.Scheme) {
+	case "https":
+		if port == "443" {
+			port = ""
+		}
+	case "http":
+		if port == "80" {
+			port = ""
+		}
+	default:
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(hostname, port)
+	}
+
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	switch {
+	case hostname == "github.com" || strings.HasSuffix(hostname, ".github.com") || strings.HasPrefix(hostname, "github."):
+		segs := strings.Split(path, "/")
+		if len(segs) > 2 {
+			segs = segs[:2]
+		}
+		segs[len(segs)-1] = strings.TrimSuffix(segs[len(segs)-1], ".git")
+		path = strings.ToLower(strings.Join(segs, "/"))
+	case strings.Contains(hostname, "gitlab"):
+		if i := strings.Index(path, "/-/"); i >= 0 {
+			path = path[:i]
+		}
+		path = strings.ToLower(strings.TrimSuffix(strings.TrimRight(path, "/"), ".git"))
+	}
+	if path == "" {
+		return "https://" + host
+	}
+	return "https://" + host + "/" + path
+}
+
+// sameRepoURL reports whether two repo URLs name the same repository, e.g. the https and ssh
+// remotes of one GitHub repo
+func sameRepoURL(a, b string) bool {
+	na := normalizeRepoURL(a)
+	return na != "" && na == normalizeRepoURL(b)
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// retentionSweepInterval is how often finished sessions are checked against spec.sessionRetention
+	retentionSweepInterval = 10 * time.Minute
+	// retentionBatchSize caps deletions per namespace and sweep so a newly configured cap converges
+	// over a few sweeps instead of issuing thousands of deletes at once
+	retentionBatchSize = 100
+
+	sessionRetainLabel         = "ambient-code.io/retain"
+	sessionRetentionDryRunAnno = "ambient-code.io/session-retention-dry-run"
+)
+
+// sessionRetention is ProjectSettings spec.sessionRetention; zero values mean no limit
+type sessionRetention struct {
+	MaxCompleted int
+	HasMax       bool
+	MaxAge       time.Duration
+}
+
+// parseSessionRetention reads spec.sessionRetention. It reports false when retention is not configured.
+func parseSessionRetention(spec map[string]interface{}) (sessionRetention, bool, error) {
+	raw, found, err := unstructured.NestedMap(spec, "sessionRetention")
+	if err != nil {
+		return sessionRetention{}, false, err
+	}
+	if !found {
+		return sessionRetention{}, false, nil
+	}
+	var r sessionRetention
+	if v, ok := raw["maxCompleted"]; ok {
+		n, ok := v.(int64)
+		if !ok {
+			if f, isFloat := v.(float64); isFloat && f == float64(int64(f)) {
+				n, ok = int64(f), true
+			}
+		}
+		if !ok || n < 0 {
+			return sessionRetention{}, false, fmt.Errorf("maxCompleted must be a non-negative integer, got %v", v)
+		}
+		r.MaxCompleted, r.HasMax = int(n), true
+	}
+	if v, ok := raw["maxAge"]; ok {
+		s, _ := v.(string)
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil || d <= 0 {
+			return sessionRetention{}, false, fmt.Errorf("maxAge must be a positive duration, got %v", v)
+		}
+		r.MaxAge = d
+	}
+	return r, r.HasMax || r.MaxAge > 0, nil
+}
+
+// sessionFinishedAt returns when a finished session ended: status.completionTime, falling back to
+// its creation time for sessions that never recorded one
+func sessionFinishedAt(session *unstructured.Unstructured) time.Time {
+	if raw, _, _ := unstructured.NestedString(session.Object, "status", "completionTime"); raw != "" {
+		if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			return t
+		}
+	}
+	return session.GetCreationTimestamp().Time
+}
+
+// sessionsToPrune returns the finished sessions that exceed the retention limits, oldest first.
+// Sessions labeled ambient-code.io/retain=true are neither pruned nor counted toward MaxCompleted.
+func sessionsToPrune(sessions []unstructured.Unstructured, r sessionRetention, now time.Time) []*unstructured.Unstructured {
+	var finished []*unstructured.Unstructured
+	for i := range sessions {
+		s := &sessions[i]
+		phase, _, _ := unstructured.NestedString(s.Object, "status", "phase")
+		if !isTerminalPhase(phase) || s.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if strings.EqualFold(s.GetLabels()[sessionRetainLabel], "true") {
+			continue
+		}
+		finished = append(finished, s)
+	}
+	// Newest first, so everything from index MaxCompleted on is over the cap
+	sort.SliceStable(finished, func(i, j int) bool {
+		return sessionFinishedAt(finished[i]).After(sessionFinishedAt(finished[j]))
+	})
+
+	var prune []*unstructured.Unstructured
+	for i, s := range finished {
+		overCap := r.HasMax && i >= r.MaxCompleted
+		tooOld := r.MaxAge > 0 && now.Sub(sessionFinishedAt(s)) > r.MaxAge
+		if overCap || tooOld {
+			prune = append(prune, s)
+		}
+	}
+	for i, j := 0, len(prune)-1; i < j; i, j = i+1, j-1 {
+		prune[i], prune[j] = prune[j], prune[i]
+	}
+	return prune
+}
+
+// PruneFinishedSessions periodically deletes finished sessions beyond each project's
+// spec.sessionRetention limits
+func PruneFinishedSessions() {
+	log.Println("Starting session retention pruning goroutine")
+	for {
+		sweepSessionRetention(context.TODO(), time.Now())
+		time.Sleep(retentionSweepInterval)
+	}
+}
+
+// sweepSessionRetention performs one pass over all managed namespaces
+func sweepSessionRetention(ctx context.Context, now time.Time) {
+	namespaces, err := config.K8sClient.CoreV1().Namespaces().List(ctx, v1.ListOptions{
+		LabelSelector: "ambient-code.io/managed=true",
+	})
+	if err != nil {
+		log.Printf("[Retention] Failed to list managed namespaces: %v", err)
+		return
+	}
+	for _, ns := range namespaces.Items {
+		pruneNamespaceSessions(ctx, ns.Name, now)
+	}
+}
+
+func pruneNamespaceSessions(ctx context.Context, namespace string, now time.Time) {
+	settings, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(namespace).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			log.Printf("[Retention] Failed to get ProjectSettings in %s: %v", namespace, err)
+		}
+		return
+	}
+	spec, _, _ := unstructured.NestedMap(settings.Object, "spec")
+	retention, configured, err := parseSessionRetention(spec)
+	if err != nil {
+		log.Printf("[Retention] Ignoring invalid sessionRetention in %s/projectsettings: %v", namespace, err)
+		return
+	}
+	if !configured {
+		return
+	}
+
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("[Retention] Failed to list sessions in %s: %v", namespace, err)
+		return
+	}
+	prune := sessionsToPrune(list.Items, retention, now)
+	if len(prune) == 0 {
+		return
+	}
+	if len(prune) > retentionBatchSize {
+		prune = prune[:retentionBatchSize]
+	}
+
+	names := make([]string, 0, len(prune))
+	if strings.EqualFold(settings.GetAnnotations()[sessionRetentionDryRunAnno], "true") {
+		for _, s := range prune {
+			names = append(names, s.GetName())
+		}
+		log.Printf("[Retention] Dry run in %s: would prune %d session(s): %s", namespace, len(names), strings.Join(names, ", "))
+		recordSessionEvent(settings, corev1.EventTypeNormal, "SessionRetentionDryRun",
+			fmt.Sprintf("Would prune %d finished session(s): %s", len(names), strings.Join(names, ", ")))
+		return
+	}
+
+	// Same delete the backend's DeleteSession issues; runner RBAC, Secrets and PVCs are owned by the
+	// session and removed by the garbage collector
+	for _, s := range prune {
+		err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace(namespace).Delete(ctx, s.GetName(), v1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("[Retention] Failed to delete session %s/%s: %v", namespace, s.GetName(), err)
+			continue
+		}
+		names = append(names, s.GetName())
+	}
+	if len(names) == 0 {
+		return
+	}
+	log.Printf("[Retention] Pruned %d session(s) in %s: %s", len(names), namespace, strings.Join(names, ", "))
+	recordSessionEvent(settings, corev1.EventTypeNormal, "SessionsPruned",
+		fmt.Sprintf("Pruned %d finished session(s) beyond sessionRetention: %s", len(names), strings.Join(names, ", ")))
+}
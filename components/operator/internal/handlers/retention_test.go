@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func retentionSession(name, phase string, finished time.Time, labels map[string]string) *unstructured.Unstructured {
+	s := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "proj"},
+		"status":     map[string]interface{}{"phase": phase, "completionTime": finished.UTC().Format(time.RFC3339)},
+	}}
+	s.SetLabels(labels)
+	return s
+}
+
+// seedRetention installs a managed namespace, ProjectSettings with the given retention and the sessions
+func seedRetention(t *testing.T, retention map[string]interface{}, dryRun bool, sessions ...runtime.Object) {
+	t.Helper()
+	setupTestClient(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "proj", Labels: map[string]string{"ambient-code.io/managed": "true"}}})
+	settings := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "ProjectSettings",
+		"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "proj"},
+		"spec":       map[string]interface{}{"sessionRetention": retention},
+	}}
+	if dryRun {
+		settings.SetAnnotations(map[string]string{sessionRetentionDryRunAnno: "true"})
+	}
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			types.GetAgenticSessionResource():  "AgenticSessionList",
+			types.GetProjectSettingsResource(): "ProjectSettingsList",
+		}, sessions...)
+	// Created through the client: the fake's kind-to-resource guess does not match "projectsettings"
+	if _, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace("proj").Create(context.Background(), settings, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create ProjectSettings: %v", err)
+	}
+}
+
+func remainingSessions(t *testing.T) map[string]bool {
+	t.Helper()
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	names := map[string]bool{}
+	for _, s := range list.Items {
+		names[s.GetName()] = true
+	}
+	return names
+}
+
+func retentionEvents(t *testing.T) []corev1.Event {
+	t.Helper()
+	events, err := config.K8sClient.CoreV1().Events("proj").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	return events.Items
+}
+
+// TestSweepSessionRetentionConvergesToMaxCompleted verifies the oldest finished sessions beyond
+// maxCompleted are deleted while running and pinned sessions are kept
+func TestSweepSessionRetentionConvergesToMaxCompleted(t *testing.T) {
+	now := time.Now()
+	var sessions []runtime.Object
+	for i := 0; i < 60; i++ {
+		sessions = append(sessions, retentionSession(fmt.Sprintf("done-%02d", i), phaseCompleted, now.Add(-time.Duration(i)*time.Hour), nil))
+	}
+	sessions = append(sessions,
+		retentionSession("pinned", phaseFailed, now.Add(-1000*time.Hour), map[string]string{sessionRetainLabel: "true"}),
+		retentionSession("running", phaseRunning, now.Add(-1000*time.Hour), nil),
+	)
+	seedRetention(t, map[string]interface{}{"maxCompleted": int64(50)}, false, sessions...)
+
+	sweepSessionRetention(context.Background(), now)
+
+	left := remainingSessions(t)
+	if len(left) != 52 {
+		t.Fatalf("expected 50 finished plus pinned and running sessions, got %d", len(left))
+	}
+	for i := 0; i < 60; i++ {
+		name := fmt.Sprintf("done-%02d", i)
+		if left[name] != (i < 50) {
+			t.Errorf("session %s kept=%v, want %v", name, left[name], i < 50)
+		}
+	}
+	if !left["pinned"] || !left["running"] {
+		t.Errorf("pinned and running sessions must survive, got %v", left)
+	}
+	events := retentionEvents(t)
+	if len(events) != 1 || events[0].Reason != "SessionsPruned" || events[0].InvolvedObject.Kind != "ProjectSettings" {
+		t.Fatalf("expected one SessionsPruned event on ProjectSettings, got %+v", events)
+	}
+
+	// A second sweep has nothing left to do
+	sweepSessionRetention(context.Background(), now)
+	if got := len(remainingSessions(t)); got != 52 {
+		t.Errorf("second sweep changed session count to %d", got)
+	}
+	if got := len(retentionEvents(t)); got != 1 {
+		t.Errorf("second sweep recorded %d events, want 1", got)
+	}
+}
+
+// TestSweepSessionRetentionMaxAgeAndDryRun verifies maxAge pruning and that dry runs only report
+func TestSweepSessionRetentionMaxAgeAndDryRun(t *testing.T) {
+	now := time.Now()
+	sessions := func() []runtime.Object {
+		return []runtime.Object{
+			retentionSession("fresh", phaseStopped, now.Add(-time.Hour), nil),
+			retentionSession("stale", phaseError, now.Add(-48*time.Hour), nil),
+		}
+	}
+
+	seedRetention(t, map[string]interface{}{"maxAge": "24h"}, true, sessions()...)
+	sweepSessionRetention(context.Background(), now)
+	if left := remainingSessions(t); len(left) != 2 {
+		t.Fatalf("dry run deleted sessions: %v", left)
+	}
+	if events := retentionEvents(t); len(events) != 1 || events[0].Reason != "SessionRetentionDryRun" {
+		t.Fatalf("expected one SessionRetentionDryRun event, got %+v", events)
+	}
+
+	seedRetention(t, map[string]interface{}{"maxAge": "24h"}, false, sessions()...)
+	sweepSessionRetention(context.Background(), now)
+	if left := remainingSessions(t); !left["fresh"] || left["stale"] {
+		t.Fatalf("expected only the stale session to be pruned, got %v", left)
+	}
+}
+
+// TestParseSessionRetention covers the accepted and rejected spec.sessionRetention shapes
+func TestParseSessionRetention(t *testing.T) {
+	cases := []struct {
+		name       string
+		spec       map[string]interface{}
+		want       sessionRetention
+		configured bool
+		wantErr    bool
+	}{
+		{name: "unset", spec: map[string]interface{}{}},
+		{name: "max only", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{"maxCompleted": int64(50)}}, want: sessionRetention{MaxCompleted: 50, HasMax: true}, configured: true},
+		{name: "zero keeps none", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{"maxCompleted": int64(0)}}, want: sessionRetention{HasMax: true}, configured: true},
+		{name: "age only", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{"maxAge": "720h"}}, want: sessionRetention{MaxAge: 720 * time.Hour}, configured: true},
+		{name: "empty object", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{}}},
+		{name: "negative max", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{"maxCompleted": int64(-1)}}, wantErr: true},
+		{name: "bad age", spec: map[string]interface{}{"sessionRetention": map[string]interface{}{"maxAge": "30d"}}, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, configured, err := parseSessionRetention(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want || configured != tc.configured {
+				t.Errorf("got %+v configured=%v, want %+v configured=%v", got, configured, tc.want, tc.configured)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// runnerImageChoice is the runner image picked for a session and where it came from
+type runnerImageChoice struct {
+	Image  string
+	Source string // "session", "project" or "operator"
+	// Rejected is a session override that is not in the project's allowlist and was ignored
+	Rejected string
+}
+
+// runnerImageAllowed reports whether image matches one of the glob patterns (path.Match syntax)
+func runnerImageAllowed(image string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(strings.TrimSpace(p), image); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRunnerImage picks the runner image: spec.runnerImage of the session, then the project's
+// spec.defaultRunnerImage, then the operator default. The session override is checked against
+// spec.allowedRunnerImages again since sessions can be created without going through the backend.
+func resolveRunnerImage(ctx context.Context, session *unstructured.Unstructured, operatorDefault string) (runnerImageChoice, error) {
+	requested, _, _ := unstructured.NestedString(session.Object, "spec", "runnerImage")
+	requested = strings.TrimSpace(requested)
+
+	var allowed []string
+	projectDefault := ""
+	obj, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(session.GetNamespace()).Get(ctx, "projectsettings", v1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return runnerImageChoice{}, err
+	}
+	if err == nil {
+		allowed, _, _ = unstructured.NestedStringSlice(obj.Object, "spec", "allowedRunnerImages")
+		projectDefault, _, _ = unstructured.NestedString(obj.Object, "spec", "defaultRunnerImage")
+		projectDefault = strings.TrimSpace(projectDefault)
+	}
+
+	choice := runnerImageChoice{}
+	if requested != "" {
+		if runnerImageAllowed(requested, allowed) {
+			return runnerImageChoice{Image: requested, Source: "session"}, nil
+		}
+		choice.Rejected = requested
+	}
+	if projectDefault != "" {
+		choice.Image, choice.Source = projectDefault, "project"
+	} else {
+		choice.Image, choice.Source = operatorDefault, "operator"
+	}
+	return choice, nil
+}
+
+// recordSessionEvent creates a core Event on the session (or another custom resource such as
+// ProjectSettings). Failures are logged only.
+func recordSessionEvent(session *unstructured.Unstructured, eventType, reason, message string) {
+	now := v1.Now()
+	ev := &corev1.Event{
+		ObjectMeta: v1.ObjectMeta{
+			// Same naming scheme as the client-go event recorder
+			Name:      fmt.Sprintf("%s.%x", session.GetName(), now.UnixNano()),
+			Namespace: session.GetNamespace(),
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: session.GetAPIVersion(),
+			Kind:       session.GetKind(),
+			Name:       session.GetName(),
+			Namespace:  session.GetNamespace(),
+			UID:        session.GetUID(),
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         corev1.EventSource{Component: "agentic-operator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := config.K8sClient.CoreV1().Events(session.GetNamespace()).Create(context.TODO(), ev, v1.CreateOptions{}); err != nil {
+		log.Printf("Failed to record %s event for session %s/%s: %v", reason, session.GetNamespace(), session.GetName(), err)
+	}
+}
+
+// recordRunnerImageEvents emits the events describing a runner image choice
+func recordRunnerImageEvents(session *unstructured.Unstructured, choice runnerImageChoice) {
+	if choice.Rejected != "" {
+		recordSessionEvent(session, corev1.EventTypeWarning, "RunnerImageRejected",
+			fmt.Sprintf("Runner image %s is not in the project's allowedRunnerImages; using the %s default", choice.Rejected, choice.Source))
+	}
+	recordSessionEvent(session, corev1.EventTypeNormal, "RunnerImageSelected",
+		fmt.Sprintf("Using runner image %s (%s)", choice.Image, choice.Source))
+}
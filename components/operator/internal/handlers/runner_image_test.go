@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// seedRunnerImageSettings installs a fake dynamic client holding a ProjectSettings in namespace proj
+func seedRunnerImageSettings(t *testing.T, spec map[string]interface{}) {
+	t.Helper()
+	gvr := types.GetProjectSettingsResource()
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "ProjectSettingsList"})
+	settings := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "ProjectSettings",
+		"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "proj"},
+		"spec":       spec,
+	}}
+	if _, err := config.DynamicClient.Resource(gvr).Namespace("proj").Create(context.Background(), settings, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to seed ProjectSettings: %v", err)
+	}
+}
+
+func runnerImageSession(namespace, image string) *unstructured.Unstructured {
+	s := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": "s1", "namespace": namespace},
+		"spec":       map[string]interface{}{},
+	}}
+	if image != "" {
+		_ = unstructured.SetNestedField(s.Object, image, "spec", "runnerImage")
+	}
+	return s
+}
+
+// TestResolveRunnerImage verifies the session, project and operator precedence and the allowlist check
+func TestResolveRunnerImage(t *testing.T) {
+	seedRunnerImageSettings(t, map[string]interface{}{
+		"allowedRunnerImages": []interface{}{"quay.io/myteam/runner:*"},
+		"defaultRunnerImage":  "quay.io/myteam/runner:stable",
+	})
+
+	cases := []struct {
+		name      string
+		namespace string
+		image     string
+		want      runnerImageChoice
+	}{
+		{"allowed override", "proj", "quay.io/myteam/runner:pr-42",
+			runnerImageChoice{Image: "quay.io/myteam/runner:pr-42", Source: "session"}},
+		{"other repository", "proj", "quay.io/myteam/runner-evil:latest",
+			runnerImageChoice{Image: "quay.io/myteam/runner:stable", Source: "project", Rejected: "quay.io/myteam/runner-evil:latest"}},
+		{"no override", "proj", "",
+			runnerImageChoice{Image: "quay.io/myteam/runner:stable", Source: "project"}},
+		{"no ProjectSettings", "other", "quay.io/myteam/runner:pr-42",
+			runnerImageChoice{Image: "quay.io/ambient/runner:latest", Source: "operator", Rejected: "quay.io/myteam/runner:pr-42"}},
+	}
+	for _, tc := range cases {
+		got, err := resolveRunnerImage(context.Background(), runnerImageSession(tc.namespace, tc.image), "quay.io/ambient/runner:latest")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, tc.want, got)
+		}
+	}
+}
+
+// TestRecordRunnerImageEvents verifies the chosen image is reported on the session
+func TestRecordRunnerImageEvents(t *testing.T) {
+	setupTestClient()
+	session := runnerImageSession("proj", "")
+
+	recordRunnerImageEvents(session, runnerImageChoice{Image: "quay.io/myteam/runner:stable", Source: "project", Rejected: "docker.io/evil:1"})
+
+	events, err := config.K8sClient.CoreV1().Events("proj").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list events: %v", err)
+	}
+	reasons := map[string]corev1.Event{}
+	for _, ev := range events.Items {
+		reasons[ev.Reason] = ev
+	}
+	selected, ok := reasons["RunnerImageSelected"]
+	if !ok || selected.Type != corev1.EventTypeNormal || selected.InvolvedObject.Name != "s1" ||
+		selected.Message != "Using runner image quay.io/myteam/runner:stable (project)" {
+		t.Errorf("expected a RunnerImageSelected event naming the image, got %+v", events.Items)
+	}
+	if rejected, ok := reasons["RunnerImageRejected"]; !ok || rejected.Type != corev1.EventTypeWarning {
+		t.Errorf("expected a RunnerImageRejected warning, got %+v", events.Items)
+	}
+}
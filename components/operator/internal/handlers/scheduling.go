@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// reservedRunnerLabels are pod labels owned by the operator or the Job controller; session labels
+// with these keys are not copied so selectors (Services, Job pod tracking) keep matching
+var reservedRunnerLabels = map[string]bool{
+	"app":             true,
+	"agentic-session": true,
+	"job-name":        true,
+	"controller-uid":  true,
+}
+
+// chargebackLabelPrefix prefixes the labels the backend copies onto sessions from ProjectSettings
+// spec.chargebackLabels
+const chargebackLabelPrefix = "chargeback.ambient-code.io/"
+
+// runnerJobLabels returns the runner Job labels: the operator's own labels plus the session's
+// chargeback labels, so cost tooling can attribute Jobs as well as their pods
+func runnerJobLabels(session *unstructured.Unstructured) map[string]string {
+	labels := map[string]string{
+		"agentic-session": session.GetName(),
+		"app":             "ambient-code-runner",
+	}
+	for k, v := range session.GetLabels() {
+		if strings.HasPrefix(k, chargebackLabelPrefix) {
+			labels[k] = v
+		}
+	}
+	return labels
+}
+
+// runnerPodLabels returns the runner pod labels: the operator's own labels plus the session's
+// metadata.labels, skipping reserved keys and kubernetes.io / k8s.io prefixed keys
+func runnerPodLabels(session *unstructured.Unstructured) map[string]string {
+	labels := map[string]string{
+		"agentic-session": session.GetName(),
+		"app":             "ambient-code-runner",
+	}
+	for k, v := range session.GetLabels() {
+		if reservedRunnerLabels[k] {
+			continue
+		}
+		if prefix, _, found := strings.Cut(k, "/"); found &&
+			(prefix == "kubernetes.io" || strings.HasSuffix(prefix, ".kubernetes.io") ||
+				prefix == "k8s.io" || strings.HasSuffix(prefix, ".k8s.io")) {
+			continue
+		}
+		labels[k] = v
+	}
+	return labels
+}
+
+// runnerScheduling holds the ProjectSettings scheduling constraints applied to runner pods
+type runnerScheduling struct {
+	NodeSelector map[string]string
+	Tolerations  []corev1.Toleration
+	Affinity     *corev1.Affinity
+}
+
+// parseRunnerScheduling decodes spec.runnerNodeSelector, spec.runnerTolerations and spec.runnerAffinity
+func parseRunnerScheduling(spec map[string]interface{}) (runnerScheduling, error) {
+	var s runnerScheduling
+	if raw, found, err := unstructured.NestedStringMap(spec, "runnerNodeSelector"); err != nil {
+		return s, fmt.Errorf("spec.runnerNodeSelector: %v", err)
+	} else if found {
+		s.NodeSelector = raw
+	}
+	if raw, found := spec["runnerTolerations"]; found && raw != nil {
+		list, ok := raw.([]interface{})
+		if !ok {
+			return s, fmt.Errorf("spec.runnerTolerations: expected a list")
+		}
+		for i, item := range list {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return s, fmt.Errorf("spec.runnerTolerations[%d]: expected an object", i)
+			}
+			var t corev1.Toleration
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(m, &t, true); err != nil {
+				return s, fmt.Errorf("spec.runnerTolerations[%d]: %v", i, err)
+			}
+			s.Tolerations = append(s.Tolerations, t)
+		}
+	}
+	if raw, found := spec["runnerAffinity"]; found && raw != nil {
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return s, fmt.Errorf("spec.runnerAffinity: expected an object")
+		}
+		s.Affinity = &corev1.Affinity{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(m, s.Affinity, true); err != nil {
+			return s, fmt.Errorf("spec.runnerAffinity: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// loadRunnerScheduling reads the runner scheduling constraints from the namespace's ProjectSettings.
+// A missing ProjectSettings means no constraints; malformed constraints are logged and skipped so a
+// bad settings edit does not block every session. Only API errors are returned.
+func loadRunnerScheduling(ctx context.Context, namespace string) (runnerScheduling, error) {
+	obj, err := config.DynamicClient.Resource(types.GetProjectSettingsResource()).Namespace(namespace).Get(ctx, "projectsettings", v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return runnerScheduling{}, nil
+	}
+	if err != nil {
+		return runnerScheduling{}, err
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+	scheduling, err := parseRunnerScheduling(spec)
+	if err != nil {
+		log.Printf("Warning: ignoring invalid runner scheduling constraints in %s/projectsettings: %v", namespace, err)
+		return runnerScheduling{}, nil
+	}
+	return scheduling, nil
+}
+
+// applyRunnerScheduling merges the constraints into the pod spec; ProjectSettings values win over
+// node selector keys already set on the pod
+func applyRunnerScheduling(podSpec *corev1.PodSpec, s runnerScheduling) {
+	if len(s.NodeSelector) > 0 {
+		if podSpec.NodeSelector == nil {
+			podSpec.NodeSelector = map[string]string{}
+		}
+		for k, v := range s.NodeSelector {
+			podSpec.NodeSelector[k] = v
+		}
+	}
+	podSpec.Tolerations = append(podSpec.Tolerations, s.Tolerations...)
+	if s.Affinity != nil {
+		podSpec.Affinity = s.Affinity
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestRunnerPodLabels verifies session labels are copied without overriding operator or Job labels
+func TestRunnerPodLabels(t *testing.T) {
+	session := &unstructured.Unstructured{}
+	session.SetName("s1")
+	session.SetLabels(map[string]string{
+		"team":                         "payments",
+		"rfe-workflow":                 "rfe-1",
+		"app":                          "spoofed",
+		"job-name":                     "other",
+		"batch.kubernetes.io/job-name": "other",
+		"ambient-code.io/project":      "proj",
+	})
+
+	labels := runnerPodLabels(session)
+
+	want := map[string]string{
+		"agentic-session":         "s1",
+		"app":                     "ambient-code-runner",
+		"team":                    "payments",
+		"rfe-workflow":            "rfe-1",
+		"ambient-code.io/project": "proj",
+	}
+	if len(labels) != len(want) {
+		t.Fatalf("expected labels %v, got %v", want, labels)
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s: expected %q, got %q", k, v, labels[k])
+		}
+	}
+}
+
+// TestRunnerJobLabels verifies only chargeback labels are copied from the session onto the Job
+func TestRunnerJobLabels(t *testing.T) {
+	session := &unstructured.Unstructured{}
+	session.SetName("s1")
+	session.SetLabels(map[string]string{
+		"team":                                  "payments",
+		"chargeback.ambient-code.io/costCenter": "cc-42",
+		"app":                                   "spoofed",
+	})
+
+	labels := runnerJobLabels(session)
+
+	want := map[string]string{
+		"agentic-session":                       "s1",
+		"app":                                   "ambient-code-runner",
+		"chargeback.ambient-code.io/costCenter": "cc-42",
+	}
+	if len(labels) != len(want) {
+		t.Fatalf("expected labels %v, got %v", want, labels)
+	}
+	for k, v := range want {
+		if labels[k] != v {
+			t.Errorf("label %s: expected %q, got %q", k, v, labels[k])
+		}
+	}
+	if pod := runnerPodLabels(session); pod["chargeback.ambient-code.io/costCenter"] != "cc-42" {
+		t.Errorf("pod labels %v lack the chargeback label", pod)
+	}
+}
+
+// TestLoadRunnerScheduling verifies ProjectSettings constraints are merged into the pod spec
+func TestLoadRunnerScheduling(t *testing.T) {
+	settings := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "ProjectSettings",
+		"metadata":   map[string]interface{}{"name": "projectsettings", "namespace": "proj"},
+		"spec": map[string]interface{}{
+			"runnerNodeSelector": map[string]interface{}{"pool": "runners"},
+			"runnerTolerations": []interface{}{
+				map[string]interface{}{"key": "dedicated", "operator": "Equal", "value": "runners", "effect": "NoSchedule"},
+			},
+			"runnerAffinity": map[string]interface{}{
+				"nodeAffinity": map[string]interface{}{
+					"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{map[string]interface{}{
+						"weight": int64(10),
+						"preference": map[string]interface{}{
+							"matchExpressions": []interface{}{map[string]interface{}{
+								"key": "zone", "operator": "In", "values": []interface{}{"a"},
+							}},
+						},
+					}},
+				},
+			},
+		},
+	}}
+	gvr := types.GetProjectSettingsResource()
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "ProjectSettingsList"})
+	// Seed through the GVR: the fake client would otherwise guess the plural "projectsettingses"
+	if _, err := config.DynamicClient.Resource(gvr).Namespace("proj").Create(context.Background(), settings, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to seed ProjectSettings: %v", err)
+	}
+
+	scheduling, err := loadRunnerScheduling(context.Background(), "proj")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	podSpec := corev1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/os": "linux"}}
+	applyRunnerScheduling(&podSpec, scheduling)
+
+	if podSpec.NodeSelector["pool"] != "runners" || podSpec.NodeSelector["kubernetes.io/os"] != "linux" {
+		t.Errorf("expected merged node selector, got %v", podSpec.NodeSelector)
+	}
+	if len(podSpec.Tolerations) != 1 || podSpec.Tolerations[0].Effect != corev1.TaintEffectNoSchedule {
+		t.Errorf("expected one NoSchedule toleration, got %v", podSpec.Tolerations)
+	}
+	if podSpec.Affinity == nil || podSpec.Affinity.NodeAffinity == nil ||
+		podSpec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].Weight != 10 {
+		t.Errorf("expected node affinity to be set, got %+v", podSpec.Affinity)
+	}
+
+	// Missing ProjectSettings means no constraints
+	scheduling, err = loadRunnerScheduling(context.Background(), "other")
+	if err != nil || scheduling.NodeSelector != nil || scheduling.Tolerations != nil || scheduling.Affinity != nil {
+		t.Errorf("expected empty constraints for a namespace without ProjectSettings, got %+v (%v)", scheduling, err)
+	}
+}
+
+// TestParseRunnerSchedulingRejectsMalformed verifies unknown or mistyped fields are reported
+func TestParseRunnerSchedulingRejectsMalformed(t *testing.T) {
+	cases := map[string]map[string]interface{}{
+		"selector value":   {"runnerNodeSelector": map[string]interface{}{"pool": int64(1)}},
+		"tolerations type": {"runnerTolerations": map[string]interface{}{}},
+		"toleration field": {"runnerTolerations": []interface{}{map[string]interface{}{"keey": "a"}}},
+		"affinity field":   {"runnerAffinity": map[string]interface{}{"nodeAfinity": map[string]interface{}{}}},
+	}
+	for name, spec := range cases {
+		if _, err := parseRunnerScheduling(spec); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
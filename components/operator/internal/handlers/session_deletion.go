@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// sessionDrainFinalizer keeps a deleted AgenticSession around until its runner has shut down
+	sessionDrainFinalizer = "ambient-code.io/drain-runner"
+	// defaultSessionDrainTimeout bounds how long a deleted session waits for its runner pods
+	defaultSessionDrainTimeout = 60 * time.Second
+)
+
+// sessionDrainPollInterval is the delay between checks of whether a deleted session's runner is gone
+var sessionDrainPollInterval = 2 * time.Second
+
+// Track which deleted sessions are being drained to prevent duplicate goroutines
+var (
+	drainingSessions   = make(map[string]bool)
+	drainingSessionsMu sync.Mutex
+)
+
+// sessionDrainTimeout returns the configured drain timeout (SESSION_DELETE_DRAIN_SECONDS), falling
+// back to the default
+func sessionDrainTimeout() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_DELETE_DRAIN_SECONDS")); raw != "" {
+		if secs, err := strconv.Atoi(raw); err == nil && secs > 0 {
+			return time.Duration(secs) * time.Second
+		}
+		log.Printf("Invalid SESSION_DELETE_DRAIN_SECONDS %q, using default %v", raw, defaultSessionDrainTimeout)
+	}
+	return defaultSessionDrainTimeout
+}
+
+func hasSessionDrainFinalizer(session *unstructured.Unstructured) bool {
+	for _, f := range session.GetFinalizers() {
+		if f == sessionDrainFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureSessionDrainFinalizer adds the drain finalizer to a live session that does not have it yet
+func ensureSessionDrainFinalizer(session *unstructured.Unstructured) error {
+	if hasSessionDrainFinalizer(session) {
+		return nil
+	}
+	gvr := types.GetAgenticSessionResource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := config.DynamicClient.Resource(gvr).Namespace(session.GetNamespace()).Get(context.TODO(), session.GetName(), v1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if obj.GetDeletionTimestamp() != nil || hasSessionDrainFinalizer(obj) {
+			return nil
+		}
+		obj.SetFinalizers(append(obj.GetFinalizers(), sessionDrainFinalizer))
+		_, err = config.DynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Update(context.TODO(), obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// removeSessionDrainFinalizer drops the drain finalizer so the API server can finish the deletion
+func removeSessionDrainFinalizer(namespace, name string) error {
+	gvr := types.GetAgenticSessionResource()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := config.DynamicClient.Resource(gvr).Namespace(namespace).Get(context.TODO(), name, v1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var kept []string
+		for _, f := range obj.GetFinalizers() {
+			if f != sessionDrainFinalizer {
+				kept = append(kept, f)
+			}
+		}
+		if len(kept) == len(obj.GetFinalizers()) {
+			return nil
+		}
+		obj.SetFinalizers(kept)
+		_, err = config.DynamicClient.Resource(gvr).Namespace(namespace).Update(context.TODO(), obj, v1.UpdateOptions{})
+		return err
+	})
+}
+
+// handleSessionDeletion drains the runner of a session that is being deleted, in the background so
+// the watch loop is not held up. Events for a session that is already draining are ignored.
+func handleSessionDeletion(session *unstructured.Unstructured) error {
+	if !hasSessionDrainFinalizer(session) {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%s", session.GetNamespace(), session.GetName())
+	drainingSessionsMu.Lock()
+	if drainingSessions[key] {
+		drainingSessionsMu.Unlock()
+		return nil
+	}
+	drainingSessions[key] = true
+	drainingSessionsMu.Unlock()
+
+	go func() {
+		defer func() {
+			drainingSessionsMu.Lock()
+			delete(drainingSessions, key)
+			drainingSessionsMu.Unlock()
+		}()
+		if err := drainDeletedSession(session, sessionDrainTimeout()); err != nil {
+			log.Printf("[Deletion] Failed to finalize session %s: %v", key, err)
+		}
+	}()
+	return nil
+}
+
+// drainDeletedSession stops the runner of a deleted session the way a user stop does, waits up to
+// timeout for its Job and pods to go away (force-deleting pods that outlive it), records a final
+// status snapshot and removes the drain finalizer
+func drainDeletedSession(session *unstructured.Unstructured, timeout time.Duration) error {
+	namespace, name := session.GetNamespace(), session.GetName()
+	jobName := fmt.Sprintf("%s-job", name)
+	phase, _, _ := unstructured.NestedString(session.Object, "status", "phase")
+	log.Printf("[Deletion] Session %s/%s deleted in phase %s, draining runner", namespace, name, phase)
+
+	if phase == phaseRunning || phase == phaseCreating {
+		statusPatch := NewStatusPatch(namespace, name)
+		statusPatch.SetField("phase", phaseStopping)
+		statusPatch.AddCondition(conditionUpdate{
+			Type:    conditionReady,
+			Status:  "False",
+			Reason:  "Deleting",
+			Message: "Session is being deleted; waiting for the runner to shut down",
+		})
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("[Deletion] Warning: failed to update status of %s/%s: %v", namespace, name, err)
+		}
+	}
+
+	svcName := fmt.Sprintf("ambient-content-%s", name)
+	if err := config.K8sClient.CoreV1().Services(namespace).Delete(context.TODO(), svcName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("[Deletion] Failed to delete per-job service %s/%s: %v", namespace, svcName, err)
+	}
+	// Foreground propagation keeps the Job until its pods are gone, so its absence means the runner exited
+	policy := v1.DeletePropagationForeground
+	if err := config.K8sClient.BatchV1().Jobs(namespace).Delete(context.TODO(), jobName, v1.DeleteOptions{PropagationPolicy: &policy}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("[Deletion] Failed to delete job %s/%s: %v", namespace, jobName, err)
+	}
+
+	started := time.Now()
+	drained := waitForRunnerShutdown(namespace, jobName, timeout)
+	if !drained {
+		log.Printf("[Deletion] Runner of %s/%s did not shut down within %v, force-deleting its pods", namespace, name, timeout)
+		forceDeleteJobPods(namespace, jobName)
+	}
+	// Same cleanup as a stop: leftover pods and the secrets copied for the runner
+	if err := deleteJobAndPerJobService(namespace, jobName, name); err != nil {
+		log.Printf("[Deletion] Warning: cleanup of %s/%s failed: %v", namespace, name, err)
+	}
+
+	snapshot := sessionFinalSnapshot(session, drained, time.Since(started))
+	log.Printf("[Deletion] Session %s/%s: %s", namespace, name, snapshot)
+	eventType := corev1.EventTypeNormal
+	if !drained {
+		eventType = corev1.EventTypeWarning
+	}
+	recordSessionEvent(session, eventType, "SessionDeleted", snapshot)
+
+	return removeSessionDrainFinalizer(namespace, name)
+}
+
+// waitForRunnerShutdown reports whether the Job and its pods were gone before timeout
+func waitForRunnerShutdown(namespace, jobName string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := config.K8sClient.BatchV1().Jobs(namespace).Get(context.TODO(), jobName, v1.GetOptions{})
+		jobGone := errors.IsNotFound(err)
+		pods, err := config.K8sClient.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", jobName)})
+		if jobGone && err == nil && len(pods.Items) == 0 {
+			return true
+		}
+		if !time.Now().Before(deadline) {
+			return false
+		}
+		time.Sleep(sessionDrainPollInterval)
+	}
+}
+
+// forceDeleteJobPods deletes the pods of a Job without a grace period
+func forceDeleteJobPods(namespace, jobName string) {
+	pods, err := config.K8sClient.CoreV1().Pods(namespace).List(context.TODO(), v1.ListOptions{LabelSelector: fmt.Sprintf("job-name=%s", jobName)})
+	if err != nil {
+		log.Printf("[Deletion] Failed to list pods for job %s/%s: %v", namespace, jobName, err)
+		return
+	}
+	var grace int64
+	for i := range pods.Items {
+		p := pods.Items[i]
+		if err := config.K8sClient.CoreV1().Pods(namespace).Delete(context.TODO(), p.Name, v1.DeleteOptions{GracePeriodSeconds: &grace}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("[Deletion] Failed to force-delete pod %s/%s: %v", namespace, p.Name, err)
+		}
+	}
+}
+
+// sessionFinalSnapshot summarizes the last known status of a deleted session for its final event
+func sessionFinalSnapshot(session *unstructured.Unstructured, drained bool, took time.Duration) string {
+	status, _, _ := unstructured.NestedMap(session.Object, "status")
+	phase, _ := status["phase"].(string)
+	if phase == "" {
+		phase = phasePending
+	}
+	parts := []string{fmt.Sprintf("deleted in phase %s", phase)}
+	if start, _ := status["startTime"].(string); start != "" {
+		parts = append(parts, "started "+start)
+	}
+	if done, _ := status["completionTime"].(string); done != "" {
+		parts = append(parts, "completed "+done)
+	}
+	repos, _ := status["reconciledRepos"].([]interface{})
+	for _, r := range repos {
+		rm, _ := r.(map[string]interface{})
+		if sha, _ := rm["pushedSha"].(string); sha != "" {
+			url, _ := rm["url"].(string)
+			parts = append(parts, fmt.Sprintf("pushed %s@%s", url, sha))
+		}
+	}
+	if drained {
+		parts = append(parts, fmt.Sprintf("runner shut down in %s", took.Round(time.Second)))
+	} else {
+		parts = append(parts, fmt.Sprintf("runner force-deleted after %s", took.Round(time.Second)))
+	}
+	return strings.Join(parts, "; ")
+}
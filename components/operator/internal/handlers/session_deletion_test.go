@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func setupDeletedSession(t *testing.T, session *unstructured.Unstructured, objects ...runtime.Object) {
+	t.Helper()
+	setupTestClient(objects...)
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{types.GetAgenticSessionResource(): "AgenticSessionList"}, session)
+	interval := sessionDrainPollInterval
+	sessionDrainPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { sessionDrainPollInterval = interval })
+}
+
+func sessionFinalizers(t *testing.T, name string) []string {
+	t.Helper()
+	obj, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get session %s: %v", name, err)
+	}
+	return obj.GetFinalizers()
+}
+
+func deletionEvents(t *testing.T) []corev1.Event {
+	t.Helper()
+	events, err := config.K8sClient.CoreV1().Events("proj").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	var out []corev1.Event
+	for _, e := range events.Items {
+		if e.Reason == "SessionDeleted" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// TestEnsureSessionDrainFinalizer verifies the finalizer is added once and kept alongside others
+func TestEnsureSessionDrainFinalizer(t *testing.T) {
+	session := waitingSession("s1", phasePending, nil, time.Now())
+	session.SetFinalizers([]string{"example.com/other"})
+	setupDeletedSession(t, session)
+
+	for i := 0; i < 2; i++ {
+		current, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), "s1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ensureSessionDrainFinalizer(current); err != nil {
+			t.Fatalf("ensureSessionDrainFinalizer: %v", err)
+		}
+	}
+	got := sessionFinalizers(t, "s1")
+	if len(got) != 2 || got[0] != "example.com/other" || got[1] != sessionDrainFinalizer {
+		t.Errorf("finalizers = %v", got)
+	}
+}
+
+// TestDrainDeletedSessionForceDeletesStuckRunner verifies a runner that outlives the drain timeout
+// is force-deleted, and the session still gets its final event and loses the finalizer
+func TestDrainDeletedSessionForceDeletesStuckRunner(t *testing.T) {
+	session := waitingSession("s1", phaseRunning, nil, time.Now())
+	session.SetFinalizers([]string{sessionDrainFinalizer})
+	now := metav1.Now()
+	session.SetDeletionTimestamp(&now)
+	_ = unstructured.SetNestedField(session.Object, "2026-01-01T10:00:00Z", "status", "startTime")
+	_ = unstructured.SetNestedSlice(session.Object, []interface{}{
+		map[string]interface{}{"url": "https://github.com/org/repo", "pushedSha": "ccccccc"},
+	}, "status", "reconciledRepos")
+	setupDeletedSession(t, session,
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "s1-job", Namespace: "proj"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "s1-job-abc", Namespace: "proj", Labels: map[string]string{"job-name": "s1-job"}}},
+	)
+
+	if err := drainDeletedSession(session, 50*time.Millisecond); err != nil {
+		t.Fatalf("drainDeletedSession: %v", err)
+	}
+
+	if _, err := config.K8sClient.BatchV1().Jobs("proj").Get(context.Background(), "s1-job", metav1.GetOptions{}); err == nil {
+		t.Error("expected the runner job to be deleted")
+	}
+	if pods, _ := config.K8sClient.CoreV1().Pods("proj").List(context.Background(), metav1.ListOptions{}); len(pods.Items) != 0 {
+		t.Errorf("expected the runner pod to be deleted, found %d", len(pods.Items))
+	}
+	if got := sessionPhase(t, "s1"); got != phaseStopping {
+		t.Errorf("phase = %q, want %q", got, phaseStopping)
+	}
+	if got := sessionFinalizers(t, "s1"); len(got) != 0 {
+		t.Errorf("finalizers = %v, want none", got)
+	}
+	events := deletionEvents(t)
+	if len(events) != 1 {
+		t.Fatalf("expected one SessionDeleted event, got %d", len(events))
+	}
+	msg := events[0].Message
+	if events[0].Type != corev1.EventTypeWarning || !strings.Contains(msg, "deleted in phase Running") ||
+		!strings.Contains(msg, "pushed https://github.com/org/repo@ccccccc") || !strings.Contains(msg, "force-deleted") {
+		t.Errorf("unexpected event %s: %s", events[0].Type, msg)
+	}
+}
+
+// TestDrainDeletedSessionWithoutRunner verifies a session with no runner is released right away
+func TestDrainDeletedSessionWithoutRunner(t *testing.T) {
+	session := waitingSession("s1", phaseCompleted, nil, time.Now())
+	session.SetFinalizers([]string{sessionDrainFinalizer})
+	now := metav1.Now()
+	session.SetDeletionTimestamp(&now)
+	setupDeletedSession(t, session)
+
+	if err := drainDeletedSession(session, time.Minute); err != nil {
+		t.Fatalf("drainDeletedSession: %v", err)
+	}
+
+	if got := sessionPhase(t, "s1"); got != phaseCompleted {
+		t.Errorf("phase = %q, want %q", got, phaseCompleted)
+	}
+	if got := sessionFinalizers(t, "s1"); len(got) != 0 {
+		t.Errorf("finalizers = %v, want none", got)
+	}
+	events := deletionEvents(t)
+	if len(events) != 1 || events[0].Type != corev1.EventTypeNormal || !strings.Contains(events[0].Message, "runner shut down") {
+		t.Errorf("unexpected events %+v", events)
+	}
+}
@@ -10,12 +10,14 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"ambient-code-operator/internal/config"
 	"ambient-code-operator/internal/services"
+	"ambient-code-operator/internal/tracing"
 	"ambient-code-operator/internal/types"
 
 	authnv1 "k8s.io/api/authentication/v1"
@@ -74,7 +76,9 @@ func WatchAgenticSessions() {
 				// Add small delay to avoid race conditions with rapid create/delete cycles
 				time.Sleep(100 * time.Millisecond)
 
-				if err := handleAgenticSessionEvent(obj); err != nil {
+				err = handleAgenticSessionEvent(obj)
+				recordReconcile(err)
+				if err != nil {
 					log.Printf("Error handling AgenticSession event: %v", err)
 				}
 			case watch.Deleted:
@@ -98,6 +102,16 @@ func WatchAgenticSessions() {
 	}
 }
 
+// stopCondition returns the condition reason and message for a stopped session: CostLimitExceeded
+// when the backend stopped it for exceeding spec.costLimitUSD (status.stoppedReason "costLimit"),
+// otherwise UserStopped with userMessage
+func stopCondition(status map[string]interface{}, userMessage string) (string, string) {
+	if reason, _ := status["stoppedReason"].(string); reason == "costLimit" {
+		return "CostLimitExceeded", "cost limit exceeded"
+	}
+	return "UserStopped", userMessage
+}
+
 func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	name := obj.GetName()
 	sessionNamespace := obj.GetNamespace()
@@ -113,6 +127,14 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return fmt.Errorf("failed to verify AgenticSession %s exists: %v", name, err)
 	}
 
+	// A deleted session only needs its runner drained before the finalizer lets it go
+	if currentObj.GetDeletionTimestamp() != nil {
+		return handleSessionDeletion(currentObj)
+	}
+	if err := ensureSessionDrainFinalizer(currentObj); err != nil {
+		log.Printf("Warning: failed to add finalizer to AgenticSession %s/%s: %v", sessionNamespace, name, err)
+	}
+
 	// Create status accumulator - all status changes will be batched into a single API call
 	statusPatch := NewStatusPatch(sessionNamespace, name)
 
@@ -128,6 +150,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	// If status.phase is missing, treat as Pending and initialize it
 	if phase == "" {
 		statusPatch.SetField("phase", "Pending")
+		statusPatch.SetMilestone(timelineCreatedAt, currentObj.GetCreationTimestamp().Time)
 		if err := statusPatch.ApplyAndReset(); err != nil {
 			log.Printf("Warning: failed to initialize phase: %v", err)
 		}
@@ -147,7 +170,8 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	// Handle user-requested state transitions via annotations
 
 	// Handle desired-phase=Running (user wants to start/restart)
-	if desiredPhase == "Running" && phase != "Running" && phase != "Creating" && phase != "Pending" {
+	// Queued sessions are started by ProcessSessionQueue in priority order
+	if desiredPhase == "Running" && phase != "Running" && phase != "Creating" && phase != "Pending" && phase != phaseQueued {
 		log.Printf("[DesiredPhase] Session %s/%s: user requested start/restart (current=%s → desired=Running)", sessionNamespace, name, phase)
 
 		// Delete temp pod if it exists (to free PVC for job)
@@ -174,6 +198,10 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			log.Printf("[DesiredPhase] Error checking for old job: %v", err)
 		}
 
+		// The restart starts a new timeline
+		restartRequestedAt := time.Now()
+		statusPatch.SetField("timeline", map[string]interface{}{timelineCreatedAt: formatMilestone(restartRequestedAt)})
+
 		// Regenerate runner token if this is a continuation
 		// Check if parent-session-id annotation is set
 		if parentSessionID := strings.TrimSpace(annotations["vteam.ambient-code/parent-session-id"]); parentSessionID != "" {
@@ -181,14 +209,17 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			if err := regenerateRunnerToken(sessionNamespace, name, currentObj); err != nil {
 				log.Printf("[DesiredPhase] Warning: failed to regenerate token: %v", err)
 				// Non-fatal - backend may have already done it
+			} else {
+				statusPatch.SetMilestone(timelineTokenProvisionedAt, time.Now())
 			}
 		}
 
 		// Set phase=Pending to trigger job creation (using StatusPatch)
 		// Set phase explicitly and clear completion time for restart
 		statusPatch.SetField("phase", "Pending")
-		statusPatch.SetField("startTime", time.Now().UTC().Format(time.RFC3339))
+		statusPatch.SetField("startTime", restartRequestedAt.UTC().Format(time.RFC3339))
 		statusPatch.DeleteField("completionTime")
+		statusPatch.DeleteField("stoppedReason")
 		statusPatch.AddCondition(conditionUpdate{
 			Type:    conditionReady,
 			Status:  "False",
@@ -226,11 +257,15 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		// Set phase=Stopping explicitly (transitional state)
 		// The Stopping phase handler will verify cleanup and transition to Stopped
 		statusPatch.SetField("phase", "Stopping")
+		stoppingMessage := "Session is stopping"
+		if _, msg := stopCondition(stMap, ""); msg != "" {
+			stoppingMessage += ": " + msg
+		}
 		statusPatch.AddCondition(conditionUpdate{
 			Type:    conditionReady,
 			Status:  "False",
 			Reason:  "Stopping",
-			Message: "Session is stopping",
+			Message: stoppingMessage,
 		})
 		if err := statusPatch.Apply(); err != nil {
 			log.Printf("[DesiredPhase] Warning: failed to update status: %v", err)
@@ -241,6 +276,26 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return nil
 	}
 
+	// Handle desired-phase=Stopped for Queued sessions - no job exists, stop immediately
+	if desiredPhase == "Stopped" && phase == phaseQueued {
+		log.Printf("[DesiredPhase] Session %s/%s: user requested stop while queued, transitioning to Stopped", sessionNamespace, name)
+		statusPatch.SetField("phase", "Stopped")
+		statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
+		statusPatch.DeleteField("queuedAt")
+		statusPatch.AddCondition(conditionUpdate{
+			Type:    conditionReady,
+			Status:  "False",
+			Reason:  "UserStopped",
+			Message: "User requested stop while queued",
+		})
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("[DesiredPhase] Warning: failed to update status: %v", err)
+		}
+		_ = clearAnnotation(sessionNamespace, name, "ambient-code.io/desired-phase")
+		_ = clearAnnotation(sessionNamespace, name, "ambient-code.io/stop-requested-at")
+		return nil
+	}
+
 	// === STOPPING PHASE HANDLER ===
 	// Complete the stop transition: verify cleanup and transition to Stopped
 	if phase == "Stopping" {
@@ -255,17 +310,19 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			statusPatch.SetField("phase", "Stopped")
 			statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
 			// Update progress-tracking conditions to reflect stopped state
+			jobReason, jobMessage := stopCondition(stMap, "Job deleted by user stop request")
+			runnerReason, runnerMessage := stopCondition(stMap, "Runner stopped by user")
 			statusPatch.AddCondition(conditionUpdate{
 				Type:    conditionJobCreated,
 				Status:  "False",
-				Reason:  "UserStopped",
-				Message: "Job deleted by user stop request",
+				Reason:  jobReason,
+				Message: jobMessage,
 			})
 			statusPatch.AddCondition(conditionUpdate{
 				Type:    conditionRunnerStarted,
 				Status:  "False",
-				Reason:  "UserStopped",
-				Message: "Runner stopped by user",
+				Reason:  runnerReason,
+				Message: runnerMessage,
 			})
 
 			if err := statusPatch.Apply(); err != nil {
@@ -456,6 +513,20 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 
 		if currentGeneration > observedGeneration {
+			// A repo or workflow added outside the project's git hosts ends the session instead of
+			// reaching the runner
+			blocked, err := disallowedGitURL(context.TODO(), currentObj)
+			if err != nil {
+				return fmt.Errorf("failed to check allowed git hosts: %v", err)
+			}
+			if blocked != "" {
+				failSessionForGitHost(currentObj, statusPatch, blocked)
+				if err := deleteJobAndPerJobService(sessionNamespace, fmt.Sprintf("%s-job", name), name); err != nil {
+					log.Printf("Warning: failed to delete job of session %s/%s: %v", sessionNamespace, name, err)
+				}
+				return nil
+			}
+
 			spec, _, _ := unstructured.NestedMap(currentObj.Object, "spec")
 			reposErr := reconcileSpecReposWithPatch(sessionNamespace, name, spec, currentObj, statusPatch)
 			if reposErr != nil {
@@ -506,6 +577,11 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return nil
 	}
 
+	// Startup reconciles continue the trace of the request that created the session
+	traceCtx, reconcileSpan := startSessionSpan(currentObj, "reconcile AgenticSession")
+	reconcileSpan.SetAttribute("ambient.phase", phase)
+	defer reconcileSpan.End()
+
 	// If in Creating phase, check if job exists
 	if phase == "Creating" {
 		jobName := fmt.Sprintf("%s-job", name)
@@ -604,6 +680,17 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 	}
 
+	// Respect the queue: if higher-priority (or earlier) sessions are waiting for capacity,
+	// park this one behind them instead of competing for the freed quota
+	if phase == "Pending" && hasQueuedSessionAhead(sessionNamespace, currentObj) {
+		log.Printf("[Queue] Session %s/%s has queued sessions ahead of it, queuing", sessionNamespace, name)
+		markSessionQueued(statusPatch, stMap, "Waiting for project capacity behind other queued sessions")
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("[Queue] Warning: failed to queue session: %v", err)
+		}
+		return nil
+	}
+
 	// Check for session continuation (parent session ID)
 	parentSessionID := ""
 	// Annotations already loaded above, reuse
@@ -634,21 +721,14 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	} else {
 		// New session: create fresh PVC with owner refs
 		pvcName = fmt.Sprintf("ambient-workspace-%s", name)
-		ownerRefs = []v1.OwnerReference{
-			{
-				APIVersion: "vteam.ambient-code/v1",
-				Kind:       "AgenticSession",
-				Name:       currentObj.GetName(),
-				UID:        currentObj.GetUID(),
-				Controller: boolPtr(true),
-				// BlockOwnerDeletion intentionally omitted to avoid permission issues
-			},
-		}
+		ownerRefs = []v1.OwnerReference{sessionControllerRef(currentObj)}
 	}
 
+	workspaceSize, _, _ := unstructured.NestedString(currentObj.Object, "spec", "workspaceSize")
+
 	// Ensure PVC exists (skip for continuation if parent's PVC should exist)
 	if !reusingPVC {
-		if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, ownerRefs); err != nil {
+		if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, workspaceSize, ownerRefs); err != nil {
 			log.Printf("Failed to ensure session PVC %s in %s: %v", pvcName, sessionNamespace, err)
 			statusPatch.AddCondition(conditionUpdate{
 				Type:    conditionPVCReady,
@@ -670,16 +750,8 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			log.Printf("Warning: Parent PVC %s not found for continuation session %s: %v", pvcName, name, err)
 			// Fall back to creating new PVC with current session's owner refs
 			pvcName = fmt.Sprintf("ambient-workspace-%s", name)
-			ownerRefs = []v1.OwnerReference{
-				{
-					APIVersion: "vteam.ambient-code/v1",
-					Kind:       "AgenticSession",
-					Name:       currentObj.GetName(),
-					UID:        currentObj.GetUID(),
-					Controller: boolPtr(true),
-				},
-			}
-			if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, ownerRefs); err != nil {
+			ownerRefs = []v1.OwnerReference{sessionControllerRef(currentObj)}
+			if err := services.EnsureSessionWorkspacePVC(sessionNamespace, pvcName, workspaceSize, ownerRefs); err != nil {
 				log.Printf("Failed to create fallback PVC %s: %v", pvcName, err)
 				statusPatch.AddCondition(conditionUpdate{
 					Type:    conditionPVCReady,
@@ -921,8 +993,9 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 
 	// Extract repos configuration (simplified format: url and branch)
 	type RepoConfig struct {
-		URL    string
-		Branch string
+		URL          string
+		Branch       string
+		OutputBranch string
 	}
 
 	var repos []RepoConfig
@@ -941,6 +1014,12 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 				} else {
 					repo.Branch = "main"
 				}
+				// Set by the backend from the project's outputBranchTemplate (or by the user)
+				if output, ok := repoMap["output"].(map[string]interface{}); ok {
+					if branch, ok := output["branch"].(string); ok {
+						repo.OutputBranch = strings.TrimSpace(branch)
+					}
+				}
 				if repo.URL != "" {
 					repos = append(repos, repo)
 				}
@@ -974,6 +1053,9 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		inputBranch = repos[0].Branch
 		outputRepo = repos[0].URL // Output same as input in simplified format
 		outputBranch = repos[0].Branch
+		if repos[0].OutputBranch != "" {
+			outputBranch = repos[0].OutputBranch
+		}
 	}
 
 	// Read autoPushOnComplete flag
@@ -992,26 +1074,43 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 	}
 	log.Printf("Session %s initiated by user: %s (userId: %s)", name, userName, userID)
 
+	// Refuse repos and workflows outside the project's allowedGitHosts before the runner sees them
+	blockedURL, err := disallowedGitURL(context.TODO(), currentObj)
+	if err != nil {
+		return fmt.Errorf("failed to check allowed git hosts: %v", err)
+	}
+	if blockedURL != "" {
+		failSessionForGitHost(currentObj, statusPatch, blockedURL)
+		_ = clearAnnotation(sessionNamespace, name, "ambient-code.io/desired-phase")
+		return nil
+	}
+
+	// Pick the runner image: session override, then project default, then operator default
+	runnerImage, err := resolveRunnerImage(context.TODO(), currentObj, appConfig.AmbientCodeRunnerImage)
+	if err != nil {
+		return fmt.Errorf("failed to resolve runner image: %v", err)
+	}
+	if err := validateImageReference(runnerImage.Image); err != nil {
+		msg := fmt.Sprintf("invalid runner image %q (%s): %v", runnerImage.Image, runnerImage.Source, err)
+		log.Printf("Session %s/%s: %s", sessionNamespace, name, msg)
+		recordSessionEvent(currentObj, corev1.EventTypeWarning, "RunnerImageInvalid", msg)
+		statusPatch.SetField("phase", phaseError)
+		statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
+		statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "False", Reason: "InvalidImageName", Message: msg})
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("Warning: failed to apply status patch: %v", err)
+		}
+		_ = clearAnnotation(sessionNamespace, name, "ambient-code.io/desired-phase")
+		return nil
+	}
+
 	// Create the Job
 	job := &batchv1.Job{
 		ObjectMeta: v1.ObjectMeta{
-			Name:      jobName,
-			Namespace: sessionNamespace,
-			Labels: map[string]string{
-				"agentic-session": name,
-				"app":             "ambient-code-runner",
-			},
-			OwnerReferences: []v1.OwnerReference{
-				{
-					APIVersion: "vteam.ambient-code/v1",
-					Kind:       "AgenticSession",
-					Name:       currentObj.GetName(),
-					UID:        currentObj.GetUID(),
-					Controller: boolPtr(true),
-					// Remove BlockOwnerDeletion to avoid permission issues
-					// BlockOwnerDeletion: boolPtr(true),
-				},
-			},
+			Name:            jobName,
+			Namespace:       sessionNamespace,
+			Labels:          runnerJobLabels(currentObj),
+			OwnerReferences: []v1.OwnerReference{sessionControllerRef(currentObj)},
 		},
 		Spec: batchv1.JobSpec{
 			BackoffLimit:          int32Ptr(3),
@@ -1020,10 +1119,9 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			TTLSecondsAfterFinished: int32Ptr(600),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: v1.ObjectMeta{
-					Labels: map[string]string{
-						"agentic-session": name,
-						"app":             "ambient-code-runner",
-					},
+					// Session labels (team, rfe-workflow, ...) make runner pods targetable by
+					// NetworkPolicies and cost attribution
+					Labels: runnerPodLabels(currentObj),
 					// If you run a service mesh that injects sidecars and causes egress issues for Jobs:
 					// Annotations: map[string]string{"sidecar.istio.io/inject": "false"},
 				},
@@ -1040,6 +1138,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 								},
 							},
 						},
+						contentKubeCAVolumeSource(),
 					},
 
 					// InitContainer to ensure workspace directory structure exists
@@ -1063,11 +1162,8 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 							Name:            "ambient-content",
 							Image:           appConfig.ContentServiceImage,
 							ImagePullPolicy: appConfig.ImagePullPolicy,
-							Env: []corev1.EnvVar{
-								{Name: "CONTENT_SERVICE_MODE", Value: "true"},
-								{Name: "STATE_BASE_DIR", Value: "/workspace"},
-							},
-							Ports: []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
+							Env:             contentServiceEnv(name, sessionNamespace),
+							Ports:           []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
@@ -1078,11 +1174,14 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 								InitialDelaySeconds: 5,
 								PeriodSeconds:       5,
 							},
-							VolumeMounts: []corev1.VolumeMount{{Name: "workspace", MountPath: "/workspace"}},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "workspace", MountPath: "/workspace"},
+								contentKubeCAVolumeMount(),
+							},
 						},
 						{
 							Name:            "ambient-code-runner",
-							Image:           appConfig.AmbientCodeRunnerImage,
+							Image:           runnerImage.Image,
 							ImagePullPolicy: appConfig.ImagePullPolicy,
 							// 🔒 Container-level security (SCC-compatible, no privileged capabilities)
 							SecurityContext: &corev1.SecurityContext{
@@ -1226,27 +1325,20 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 									base = append(base, corev1.EnvVar{Name: "PARENT_SESSION_ID", Value: parentSessionID})
 									log.Printf("Session %s: passing PARENT_SESSION_ID=%s to runner", name, parentSessionID)
 								}
-								// If backend annotated the session with a runner token secret, inject only BOT_TOKEN
-								// Secret contains: 'k8s-token' (for CR updates)
-								// Prefer annotated secret name; fallback to deterministic name
-								secretName := ""
-								if meta, ok := currentObj.Object["metadata"].(map[string]interface{}); ok {
-									if anns, ok := meta["annotations"].(map[string]interface{}); ok {
-										if v, ok := anns["ambient-code.io/runner-token-secret"].(string); ok && strings.TrimSpace(v) != "" {
-											secretName = strings.TrimSpace(v)
-										}
-									}
-								}
-								if secretName == "" {
-									secretName = fmt.Sprintf("ambient-runner-token-%s", name)
-								}
-								base = append(base, corev1.EnvVar{
-									Name: "BOT_TOKEN",
-									ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
-										LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
-										Key:                  "k8s-token",
-									}},
-								})
+								// Runner token: BOT_TOKEN_FILE points at the projected Secret volume, which the
+								// kubelet swaps atomically on refresh. BOT_TOKEN is kept for older runner images
+								// but is fixed at pod start and never sees refreshed tokens.
+								base = append(base,
+									corev1.EnvVar{Name: "BOT_TOKEN_FILE", Value: fmt.Sprintf("%s/%s", runnerTokenMountPath, runnerTokenSecretKey)},
+									corev1.EnvVar{Name: "BOT_TOKEN_EXPIRY_FILE", Value: fmt.Sprintf("%s/%s", runnerTokenMountPath, runnerTokenExpirySecretKey)},
+									corev1.EnvVar{
+										Name: "BOT_TOKEN",
+										ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+											LocalObjectReference: corev1.LocalObjectReference{Name: runnerTokenSecretName(currentObj)},
+											Key:                  runnerTokenSecretKey,
+										}},
+									},
+								)
 								// Add CR-provided envs last (override base when same key)
 								if spec, ok := currentObj.Object["spec"].(map[string]interface{}); ok {
 									// Inject REPOS_JSON and MAIN_REPO_NAME from spec.repos and spec.mainRepoName if present
@@ -1376,6 +1468,12 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 	}
 
+	// Prompts too large for the CR are stored in a ConfigMap by the backend
+	if promptConfigMap, promptKey, ok := sessionPromptRef(spec); ok {
+		mountInitialPrompt(job, promptConfigMap, promptKey)
+		log.Printf("Mounted initial prompt from ConfigMap %s for session %s", promptConfigMap, name)
+	}
+
 	// Create placeholder Google OAuth secret if it doesn't exist (for MCP Google Workspace integration)
 	// This ensures the volume mount is always present so K8s can sync credentials after OAuth completion
 	googleOAuthSecretName := fmt.Sprintf("%s-google-oauth", name)
@@ -1391,15 +1489,7 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 					"ambient-code.io/provider": "google",
 					"ambient-code.io/oauth":    "placeholder",
 				},
-				OwnerReferences: []v1.OwnerReference{
-					{
-						APIVersion: "vteam.ambient-code/v1",
-						Kind:       "AgenticSession",
-						Name:       currentObj.GetName(),
-						UID:        currentObj.GetUID(),
-						Controller: boolPtr(true),
-					},
-				},
+				OwnerReferences: []v1.OwnerReference{sessionControllerRef(currentObj)},
 			},
 			Type: corev1.SecretTypeOpaque,
 			Data: map[string][]byte{
@@ -1441,10 +1531,60 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		}
 	}
 
-	// Do not mount runner Secret volume; runner fetches tokens on demand
+	// Project the runner token Secret at a stable path so refreshes reach the running pod
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: runnerTokenVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{{
+					Secret: &corev1.SecretProjection{
+						LocalObjectReference: corev1.LocalObjectReference{Name: runnerTokenSecretName(currentObj)},
+						Items: []corev1.KeyToPath{
+							{Key: runnerTokenSecretKey, Path: runnerTokenSecretKey},
+							{Key: runnerTokenExpirySecretKey, Path: runnerTokenExpirySecretKey},
+						},
+						Optional: boolPtr(true),
+					},
+				}},
+			},
+		},
+	})
+	for i := range job.Spec.Template.Spec.Containers {
+		if job.Spec.Template.Spec.Containers[i].Name == "ambient-code-runner" {
+			job.Spec.Template.Spec.Containers[i].VolumeMounts = append(job.Spec.Template.Spec.Containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      runnerTokenVolumeName,
+				MountPath: runnerTokenMountPath,
+				ReadOnly:  true,
+			})
+			break
+		}
+	}
+
+	// Enforce ProjectSettings maxConcurrentSessions; ProcessSessionQueue retries once a slot frees
+	if reached, msg := projectConcurrencyLimitReached(context.TODO(), sessionNamespace, name); reached {
+		log.Printf("[Queue] Session %s/%s is over the project's concurrent session limit (%s), queuing", sessionNamespace, name, msg)
+		markSessionQueued(statusPatch, stMap, fmt.Sprintf("Waiting for project capacity: %s", msg))
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("[Queue] Warning: failed to queue session: %v", err)
+		}
+		return nil
+	}
+
+	// Apply node selector, tolerations and affinity from ProjectSettings
+	scheduling, err := loadRunnerScheduling(context.TODO(), sessionNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to read runner scheduling constraints: %v", err)
+	}
+	applyRunnerScheduling(&job.Spec.Template.Spec, scheduling)
 
 	// Create the job
+	jobSpan := startChildSpan(traceCtx, "create runner Job", tracing.KindClient)
+	jobSpan.SetAttribute("k8s.job.name", jobName)
 	createdJob, err := config.K8sClient.BatchV1().Jobs(sessionNamespace).Create(context.TODO(), job, v1.CreateOptions{})
+	if err != nil {
+		jobSpan.SetError(err.Error())
+	}
+	jobSpan.End()
 	if err != nil {
 		// If job already exists, this is likely a race condition from duplicate watch events - not an error
 		if errors.IsAlreadyExists(err) {
@@ -1453,6 +1593,14 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 			_ = clearAnnotation(sessionNamespace, name, "ambient-code.io/desired-phase")
 			return nil
 		}
+		if isQuotaExceededError(err) {
+			log.Printf("[Queue] Job %s for session %s/%s exceeds project quota, queuing", jobName, sessionNamespace, name)
+			markSessionQueued(statusPatch, stMap, fmt.Sprintf("Waiting for project capacity: %v", err))
+			if err := statusPatch.Apply(); err != nil {
+				log.Printf("[Queue] Warning: failed to queue session: %v", err)
+			}
+			return nil
+		}
 		log.Printf("Failed to create job %s: %v", jobName, err)
 		statusPatch.AddCondition(conditionUpdate{
 			Type:    conditionJobCreated,
@@ -1470,9 +1618,12 @@ func handleAgenticSessionEvent(obj *unstructured.Unstructured) error {
 		return fmt.Errorf("failed to create job: %v", err)
 	}
 
-	log.Printf("Created job %s for AgenticSession %s", jobName, name)
+	log.Printf("Created job %s for AgenticSession %s with runner image %s (%s)", jobName, name, runnerImage.Image, runnerImage.Source)
+	recordRunnerImageEvents(currentObj, runnerImage)
 	statusPatch.SetField("phase", "Creating")
 	statusPatch.SetField("observedGeneration", currentObj.GetGeneration())
+	statusPatch.DeleteField("queuedAt")
+	statusPatch.SetMilestone(timelineJobCreatedAt, time.Now())
 	statusPatch.AddCondition(conditionUpdate{
 		Type:    conditionJobCreated,
 		Status:  "True",
@@ -1591,9 +1742,15 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 			if b, ok := repoMap["branch"].(string); ok && strings.TrimSpace(b) != "" {
 				branch = b
 			}
+			// The backend stores the folder so same-named repos from different owners do not collide
+			name, _ := repoMap["folder"].(string)
+			if strings.TrimSpace(name) == "" {
+				name = deriveRepoNameFromURL(url)
+			}
 			specRepos = append(specRepos, map[string]string{
 				"url":    url,
 				"branch": branch,
+				"name":   name,
 			})
 		}
 	}
@@ -1606,10 +1763,15 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 		if repoMap, ok := entry.(map[string]interface{}); ok {
 			url, _ := repoMap["url"].(string)
 			branch, _ := repoMap["branch"].(string)
+			name, _ := repoMap["name"].(string)
+			if name == "" {
+				name = deriveRepoNameFromURL(url)
+			}
 			if url != "" {
 				reconciledRepos = append(reconciledRepos, map[string]string{
 					"url":    url,
 					"branch": branch,
+					"name":   name,
 				})
 			}
 		}
@@ -1623,7 +1785,7 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 	for _, specRepo := range specRepos {
 		found := false
 		for _, reconciledRepo := range reconciledRepos {
-			if specRepo["url"] == reconciledRepo["url"] {
+			if sameRepoURL(specRepo["url"], reconciledRepo["url"]) {
 				found = true
 				break
 			}
@@ -1637,7 +1799,7 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 	for _, reconciledRepo := range reconciledRepos {
 		found := false
 		for _, specRepo := range specRepos {
-			if reconciledRepo["url"] == specRepo["url"] {
+			if sameRepoURL(reconciledRepo["url"], specRepo["url"]) {
 				found = true
 				break
 			}
@@ -1657,12 +1819,10 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 
 	// Add repos
 	for _, repo := range toAdd {
-		repoName := deriveRepoNameFromURL(repo["url"])
-
 		payload := map[string]interface{}{
 			"url":    repo["url"],
 			"branch": repo["branch"],
-			"name":   repoName,
+			"name":   repo["name"],
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -1688,10 +1848,8 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 
 	// Remove repos
 	for _, repo := range toRemove {
-		repoName := deriveRepoNameFromURL(repo["url"])
-
 		payload := map[string]interface{}{
-			"name": repoName,
+			"name": repo["name"],
 		}
 		payloadBytes, _ := json.Marshal(payload)
 
@@ -1718,12 +1876,15 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 	// Update status to reflect the reconciled state (via statusPatch)
 	reconciled := make([]interface{}, 0, len(specRepos))
 	for _, repo := range specRepos {
-		reconciled = append(reconciled, map[string]interface{}{
+		entry := map[string]interface{}{
 			"url":      repo["url"],
 			"branch":   repo["branch"],
+			"name":     repo["name"],
 			"status":   "Ready",
 			"clonedAt": time.Now().UTC().Format(time.RFC3339),
-		})
+		}
+		carryOverRepoCommitFields(entry, reconciledReposRaw)
+		reconciled = append(reconciled, entry)
 	}
 	statusPatch.SetField("reconciledRepos", reconciled)
 	statusPatch.AddCondition(conditionUpdate{
@@ -1736,6 +1897,30 @@ func reconcileSpecReposWithPatch(sessionNamespace, sessionName string, spec map[
 	return nil
 }
 
+// repoCommitFields are per-repo status fields written by the runner and backend (not the operator)
+var repoCommitFields = []string{"clonedSha", "clonedBranch", "pushedSha", "pushedBranch", "gitStatus", "lastUpdated"}
+
+// carryOverRepoCommitFields copies commit SHA/branch fields from the previous status entry
+// for the same repo URL so operator reconciliation does not erase them.
+func carryOverRepoCommitFields(entry map[string]interface{}, previous []interface{}) {
+	url, _ := entry["url"].(string)
+	for _, p := range previous {
+		prev, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if prevURL, _ := prev["url"].(string); !sameRepoURL(prevURL, url) {
+			continue
+		}
+		for _, field := range repoCommitFields {
+			if v, ok := prev[field]; ok {
+				entry[field] = v
+			}
+		}
+		return
+	}
+}
+
 // reconcileActiveWorkflowWithPatch is a version of reconcileActiveWorkflow that uses StatusPatch for batched updates.
 func reconcileActiveWorkflowWithPatch(sessionNamespace, sessionName string, spec map[string]interface{}, session *unstructured.Unstructured, statusPatch *StatusPatch) error {
 	workflow, found, _ := unstructured.NestedMap(spec, "activeWorkflow")
@@ -1849,6 +2034,10 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
+	// The runner's lifetime, from Job creation until monitoring stops, is one span of the session's trace
+	var runSpan *tracing.Span
+	defer func() { runSpan.End() }()
+
 	for range ticker.C {
 		// Create status accumulator for this tick - all updates batched into single API call
 		statusPatch := NewStatusPatch(sessionNamespace, sessionName)
@@ -1864,6 +2053,11 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 			continue
 		}
 
+		if runSpan == nil && tracing.Enabled() {
+			_, runSpan = startSessionSpan(sessionObj, "runner Job")
+			runSpan.SetAttribute("k8s.job.name", jobName)
+		}
+
 		// Check if session was stopped - exit monitor loop immediately
 		sessionStatus, _, _ := unstructured.NestedMap(sessionObj.Object, "status")
 		if sessionStatus != nil {
@@ -1914,6 +2108,14 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 		}
 
 		if len(pods.Items) == 0 {
+			// Pod creation rejected by ResourceQuota - park the session until capacity frees up
+			if quotaHit, msg := jobHasQuotaFailure(sessionNamespace, job); quotaHit {
+				log.Printf("[Queue] Runner pod for %s/%s blocked by quota, queuing session", sessionNamespace, sessionName)
+				_ = deleteJobAndPerJobService(sessionNamespace, jobName, sessionName)
+				markSessionQueued(statusPatch, sessionStatus, fmt.Sprintf("Waiting for project capacity: %s", msg))
+				_ = statusPatch.Apply()
+				return
+			}
 			if job.Status.Active == 0 && job.Status.Succeeded == 0 && job.Status.Failed == 0 {
 				statusPatch.SetField("phase", "Failed")
 				statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
@@ -1935,8 +2137,17 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 		// Note: We don't store pod name in status (pods are ephemeral, can be recreated)
 		// Use k8s-resources endpoint or kubectl for live pod info
 
+		if pending := podUnschedulableFor(&pod, time.Now()); pending > unschedulableQueueAfter() {
+			log.Printf("[Queue] Runner pod %s unschedulable for %v, queuing session %s/%s", pod.Name, pending.Round(time.Second), sessionNamespace, sessionName)
+			_ = deleteJobAndPerJobService(sessionNamespace, jobName, sessionName)
+			markSessionQueued(statusPatch, sessionStatus, fmt.Sprintf("Waiting for cluster capacity: runner pod unschedulable for %v", pending.Round(time.Second)))
+			_ = statusPatch.Apply()
+			return
+		}
+
 		if pod.Spec.NodeName != "" {
 			statusPatch.AddCondition(conditionUpdate{Type: conditionPodScheduled, Status: "True", Reason: "Scheduled", Message: fmt.Sprintf("Scheduled on %s", pod.Spec.NodeName)})
+			statusPatch.SetMilestone(timelinePodScheduledAt, podScheduledAt(&pod))
 		}
 
 		if pod.Status.Phase == corev1.PodFailed {
@@ -1949,6 +2160,27 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 			return
 		}
 
+		if reason, failed := imagePullFailure(&pod, time.Now()); failed {
+			msg := fmt.Sprintf("image pull failed: %s", reason)
+			if runner := getContainerStatusByName(&pod, "ambient-code-runner"); runner != nil {
+				if digest := lastKnownGoodRunnerDigest(runner.Image); digest != "" {
+					log.Printf("Runner image %s failed to pull for %s/%s; last known good digest: %s", runner.Image, sessionNamespace, sessionName, digest)
+				}
+			}
+			recordSessionEvent(sessionObj, corev1.EventTypeWarning, "ImagePullFailed", msg)
+			statusPatch.SetField("phase", phaseError)
+			statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
+			statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "False", Reason: "ImagePullFailed", Message: msg})
+			_ = statusPatch.Apply()
+			_ = ensureSessionIsInteractive(sessionNamespace, sessionName)
+			_ = deleteJobAndPerJobService(sessionNamespace, jobName, sessionName)
+			return
+		}
+
+		if msg, conflict := runnerVolumeConflict(&pod); conflict {
+			statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "False", Reason: "VolumeMultiAttach", Message: msg})
+		}
+
 		runner := getContainerStatusByName(&pod, "ambient-code-runner")
 		if runner == nil {
 			// Apply any accumulated changes (e.g., PodScheduled) before continuing
@@ -1957,6 +2189,8 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 		}
 
 		if runner.State.Running != nil {
+			recordRunnerImageDigest(runner.Image, runner.ImageID)
+			statusPatch.SetMilestone(timelineRunnerStartedAt, runnerStartedAt(runner.State.Running))
 			statusPatch.SetField("phase", "Running")
 			statusPatch.AddCondition(conditionUpdate{Type: conditionRunnerStarted, Status: "True", Reason: "ContainerRunning", Message: "Runner container is executing"})
 			statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "True", Reason: "Running", Message: "Session is running"})
@@ -1966,7 +2200,8 @@ func monitorJob(jobName, sessionName, sessionNamespace string) {
 
 		if runner.State.Waiting != nil {
 			waiting := runner.State.Waiting
-			errorStates := map[string]bool{"ImagePullBackOff": true, "ErrImagePull": true, "CrashLoopBackOff": true, "CreateContainerConfigError": true, "InvalidImageName": true}
+			// Image pull failures are handled above by imagePullFailure
+			errorStates := map[string]bool{"CrashLoopBackOff": true, "CreateContainerConfigError": true}
 			if errorStates[waiting.Reason] {
 				msg := fmt.Sprintf("Runner waiting: %s - %s", waiting.Reason, waiting.Message)
 				statusPatch.SetField("phase", "Failed")
@@ -2328,9 +2563,27 @@ func deleteAmbientLangfuseSecret(ctx context.Context, namespace string) error {
 
 // reconcileTempContentPodWithPatch is a version of reconcileTempContentPod that uses StatusPatch for batched updates.
 func reconcileTempContentPodWithPatch(sessionNamespace, sessionName, tempPodName string, session *unstructured.Unstructured, statusPatch *StatusPatch) error {
+	// The backend requests a read-only pod for callers who may only view the session
+	readOnly := session.GetAnnotations()[tempContentReadOnlyAnnotation] == "true"
+
 	// Check if pod already exists
 	tempPod, err := config.K8sClient.CoreV1().Pods(sessionNamespace).Get(context.TODO(), tempPodName, v1.GetOptions{})
 
+	// A pod in the other mode is replaced; the next reconcile creates the requested one
+	if err == nil && tempPod.DeletionTimestamp == nil && (tempPod.Annotations[tempContentReadOnlyAnnotation] == "true") != readOnly {
+		log.Printf("[TempPod] Replacing temp pod %s/%s (readOnly=%v requested)", sessionNamespace, tempPodName, readOnly)
+		if err := config.K8sClient.CoreV1().Pods(sessionNamespace).Delete(context.TODO(), tempPodName, v1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to replace temp pod: %w", err)
+		}
+		statusPatch.AddCondition(conditionUpdate{
+			Type:    conditionTempContentPodReady,
+			Status:  "Unknown",
+			Reason:  "ModeChanged",
+			Message: "Temp content pod restarting with the requested access mode",
+		})
+		return nil
+	}
+
 	if errors.IsNotFound(err) {
 		// Create temp pod
 		log.Printf("[TempPod] Creating temp content pod for workspace access: %s/%s", sessionNamespace, tempPodName)
@@ -2347,7 +2600,8 @@ func reconcileTempContentPodWithPatch(sessionNamespace, sessionName, tempPodName
 					"agentic-session": sessionName,
 				},
 				Annotations: map[string]string{
-					"ambient-code.io/created-at": time.Now().UTC().Format(time.RFC3339),
+					"ambient-code.io/created-at":  time.Now().UTC().Format(time.RFC3339),
+					tempContentReadOnlyAnnotation: strconv.FormatBool(readOnly),
 				},
 				OwnerReferences: []v1.OwnerReference{{
 					APIVersion: session.GetAPIVersion(),
@@ -2364,15 +2618,12 @@ func reconcileTempContentPodWithPatch(sessionNamespace, sessionName, tempPodName
 					Name:            "content",
 					Image:           appConfig.ContentServiceImage,
 					ImagePullPolicy: appConfig.ImagePullPolicy,
-					Env: []corev1.EnvVar{
-						{Name: "CONTENT_SERVICE_MODE", Value: "true"},
-						{Name: "STATE_BASE_DIR", Value: "/workspace"},
+					Env:             tempContentServiceEnv(sessionName, sessionNamespace, readOnly),
+					Ports:           []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
+					VolumeMounts: []corev1.VolumeMount{
+						{Name: "workspace", MountPath: "/workspace"},
+						contentKubeCAVolumeMount(),
 					},
-					Ports: []corev1.ContainerPort{{ContainerPort: 8080, Name: "http"}},
-					VolumeMounts: []corev1.VolumeMount{{
-						Name:      "workspace",
-						MountPath: "/workspace",
-					}},
 					ReadinessProbe: &corev1.Probe{
 						ProbeHandler: corev1.ProbeHandler{
 							HTTPGet: &corev1.HTTPGetAction{
@@ -2384,14 +2635,17 @@ func reconcileTempContentPodWithPatch(sessionNamespace, sessionName, tempPodName
 						PeriodSeconds:       3,
 					},
 				}},
-				Volumes: []corev1.Volume{{
-					Name: "workspace",
-					VolumeSource: corev1.VolumeSource{
-						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-							ClaimName: pvcName,
+				Volumes: []corev1.Volume{
+					{
+						Name: "workspace",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+								ClaimName: pvcName,
+							},
 						},
 					},
-				}},
+					contentKubeCAVolumeSource(),
+				},
 			},
 		}
 
@@ -2505,7 +2759,9 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 		log.Printf("[TokenProvision] ServiceAccount %s already exists", saName)
 	}
 
-	// Create Role with least-privilege permissions
+	// Create Role with least-privilege permissions: the runner's own session only, and no status
+	// rights; status goes through the backend's runner endpoints. Same rules as runnerRoleRules in
+	// the backend (components/backend/handlers/session_runner_auth.go).
 	roleName := fmt.Sprintf("ambient-session-%s-role", sessionName)
 	role := &rbacv1.Role{
 		ObjectMeta: v1.ObjectMeta{
@@ -2515,9 +2771,10 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 		},
 		Rules: []rbacv1.PolicyRule{
 			{
-				APIGroups: []string{"vteam.ambient-code"},
-				Resources: []string{"agenticsessions"},
-				Verbs:     []string{"get", "list", "watch", "update", "patch"},
+				APIGroups:     []string{"vteam.ambient-code"},
+				Resources:     []string{"agenticsessions"},
+				ResourceNames: []string{sessionName},
+				Verbs:         []string{"get", "update", "patch"},
 			},
 			{
 				APIGroups: []string{"authorization.k8s.io"},
@@ -2566,6 +2823,7 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 	if k8sToken == "" {
 		return fmt.Errorf("received empty token for SA %s", saName)
 	}
+	expiresAt := tokenExpiryString(tok.Status.ExpirationTimestamp)
 
 	// Store token in Secret
 	secretName := fmt.Sprintf("ambient-runner-token-%s", sessionName)
@@ -2582,7 +2840,8 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 		},
 		Type: corev1.SecretTypeOpaque,
 		Data: map[string][]byte{
-			"k8s-token": []byte(k8sToken),
+			runnerTokenSecretKey:       []byte(k8sToken),
+			runnerTokenExpirySecretKey: []byte(expiresAt),
 		},
 	}
 
@@ -2597,7 +2856,8 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 			if secretCopy.Data == nil {
 				secretCopy.Data = map[string][]byte{}
 			}
-			secretCopy.Data["k8s-token"] = []byte(k8sToken)
+			secretCopy.Data[runnerTokenSecretKey] = []byte(k8sToken)
+			secretCopy.Data[runnerTokenExpirySecretKey] = []byte(expiresAt)
 			if secretCopy.Annotations == nil {
 				secretCopy.Annotations = map[string]string{}
 			}
@@ -2624,6 +2884,9 @@ func regenerateRunnerToken(sessionNamespace, sessionName string, session *unstru
 		log.Printf("[TokenProvision] Warning: failed to annotate session: %v", err)
 		// Non-fatal - job will use default names
 	}
+	if err := setRunnerAuthStatus(sessionNamespace, sessionName, secretName, expiresAt); err != nil {
+		log.Printf("[TokenProvision] Warning: failed to record runner token expiry: %v", err)
+	}
 
 	log.Printf("[TokenProvision] Successfully regenerated token for session %s/%s", sessionNamespace, sessionName)
 	return nil
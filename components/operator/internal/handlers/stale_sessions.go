@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// staleSessionSweepInterval is how often sessions waiting for their runner Job are checked
+	staleSessionSweepInterval = time.Minute
+	// defaultStaleCreatingAfter is how long a session may wait for its Job before it is recovered
+	defaultStaleCreatingAfter = 5 * time.Minute
+	// maxJobRecoveryAttempts is how many times Job creation is retried before the session fails
+	maxJobRecoveryAttempts = 3
+)
+
+// jobRecoveryAttempts counts Job creation retries per session UID. It lives in memory only, so a
+// restarted operator gives every stuck session a fresh set of attempts.
+var (
+	jobRecoveryAttempts   = map[string]int{}
+	jobRecoveryAttemptsMu sync.Mutex
+)
+
+// staleCreatingAfter returns the configured threshold (SESSION_CREATING_STALE_MINUTES), falling back
+// to the default
+func staleCreatingAfter() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("SESSION_CREATING_STALE_MINUTES")); raw != "" {
+		if mins, err := strconv.Atoi(raw); err == nil && mins > 0 {
+			return time.Duration(mins) * time.Minute
+		}
+		log.Printf("Invalid SESSION_CREATING_STALE_MINUTES %q, using default %v", raw, defaultStaleCreatingAfter)
+	}
+	return defaultStaleCreatingAfter
+}
+
+// sessionCondition returns the named status condition, or nil
+func sessionCondition(session *unstructured.Unstructured, condType string) map[string]interface{} {
+	conditions, _, _ := unstructured.NestedSlice(session.Object, "status", "conditions")
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && strings.EqualFold(fmt.Sprint(m["type"]), condType) {
+			return m
+		}
+	}
+	return nil
+}
+
+// awaitingJobSince reports whether the session is waiting for its runner Job to be created and
+// since when: Creating sessions, and Pending sessions whose Job went missing or failed to create. The time is
+// the JobCreated condition's last transition, or the session's creation for older sessions.
+func awaitingJobSince(session *unstructured.Unstructured) (time.Time, bool) {
+	phase, _, _ := unstructured.NestedString(session.Object, "status", "phase")
+	cond := sessionCondition(session, conditionJobCreated)
+	switch phase {
+	case phaseCreating:
+	case phasePending:
+		if cond == nil || fmt.Sprint(cond["status"]) != "False" || (fmt.Sprint(cond["reason"]) != "CreateFailed" && fmt.Sprint(cond["reason"]) != "JobMissing") {
+			return time.Time{}, false
+		}
+	default:
+		return time.Time{}, false
+	}
+	if cond != nil {
+		if raw, _ := cond["lastTransitionTime"].(string); raw != "" {
+			if t, err := time.Parse(time.RFC3339, raw); err == nil {
+				return t, true
+			}
+		}
+	}
+	return session.GetCreationTimestamp().Time, true
+}
+
+// RecoverStaleSessions periodically re-runs Job creation for sessions stuck waiting for their runner
+// Job, e.g. because the operator stopped between the status update and Job creation or the create
+// call failed and no further event arrived
+func RecoverStaleSessions() {
+	log.Println("Starting stale session recovery goroutine")
+	for {
+		time.Sleep(staleSessionSweepInterval)
+		recoverStaleSessions(context.TODO(), time.Now())
+	}
+}
+
+func recoverStaleSessions(ctx context.Context, now time.Time) {
+	list, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).List(ctx, v1.ListOptions{})
+	if err != nil {
+		log.Printf("[StaleRecovery] Failed to list AgenticSessions: %v", err)
+		return
+	}
+	threshold := staleCreatingAfter()
+	waitingUIDs := map[string]bool{}
+	for i := range list.Items {
+		session := &list.Items[i]
+		since, waiting := awaitingJobSince(session)
+		if !waiting {
+			continue
+		}
+		waitingUIDs[string(session.GetUID())] = true
+		if now.Sub(since) < threshold || session.GetDeletionTimestamp() != nil {
+			continue
+		}
+		recoverStaleSession(ctx, session)
+	}
+
+	// Forget sessions that got their Job or went away
+	jobRecoveryAttemptsMu.Lock()
+	for uid := range jobRecoveryAttempts {
+		if !waitingUIDs[uid] {
+			delete(jobRecoveryAttempts, uid)
+		}
+	}
+	jobRecoveryAttemptsMu.Unlock()
+	recordSweep()
+}
+
+// recoverStaleSession retries Job creation for one stale session, failing it with the last creation
+// error once maxJobRecoveryAttempts retries have not produced a Job
+func recoverStaleSession(ctx context.Context, session *unstructured.Unstructured) {
+	namespace, name := session.GetNamespace(), session.GetName()
+	jobName := fmt.Sprintf("%s-job", name)
+	if _, err := config.K8sClient.BatchV1().Jobs(namespace).Get(ctx, jobName, v1.GetOptions{}); err == nil {
+		return
+	} else if !errors.IsNotFound(err) {
+		log.Printf("[StaleRecovery] Failed to get job %s/%s: %v", namespace, jobName, err)
+		return
+	}
+
+	key := string(session.GetUID())
+	jobRecoveryAttemptsMu.Lock()
+	attempts := jobRecoveryAttempts[key]
+	if attempts < maxJobRecoveryAttempts {
+		jobRecoveryAttempts[key] = attempts + 1
+	} else {
+		delete(jobRecoveryAttempts, key)
+	}
+	jobRecoveryAttemptsMu.Unlock()
+
+	if attempts >= maxJobRecoveryAttempts {
+		cause := "runner job was never created"
+		if cond := sessionCondition(session, conditionJobCreated); cond != nil {
+			if msg, _ := cond["message"].(string); msg != "" && fmt.Sprint(cond["status"]) == "False" {
+				cause = msg
+			}
+		}
+		msg := fmt.Sprintf("runner job could not be created after %d attempts: %s", maxJobRecoveryAttempts, cause)
+		log.Printf("[StaleRecovery] Session %s/%s: %s", namespace, name, msg)
+		recordSessionEvent(session, corev1.EventTypeWarning, "JobCreationFailed", msg)
+		statusPatch := NewStatusPatch(namespace, name)
+		statusPatch.SetField("phase", phaseError)
+		statusPatch.SetField("completionTime", time.Now().UTC().Format(time.RFC3339))
+		statusPatch.AddCondition(conditionUpdate{Type: conditionReady, Status: "False", Reason: "JobCreationFailed", Message: msg})
+		if err := statusPatch.Apply(); err != nil {
+			log.Printf("[StaleRecovery] Failed to fail session %s/%s: %v", namespace, name, err)
+		}
+		return
+	}
+
+	log.Printf("[StaleRecovery] Session %s/%s has no runner job, retrying creation (attempt %d/%d)", namespace, name, attempts+1, maxJobRecoveryAttempts)
+	recordSessionEvent(session, corev1.EventTypeWarning, "JobRecovery",
+		fmt.Sprintf("Runner job missing, retrying creation (attempt %d/%d)", attempts+1, maxJobRecoveryAttempts))
+	if err := handleAgenticSessionEvent(session); err != nil {
+		log.Printf("[StaleRecovery] Job creation for %s/%s failed: %v", namespace, name, err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func waitingSession(name, phase string, jobCreated map[string]interface{}, created time.Time) *unstructured.Unstructured {
+	status := map[string]interface{}{"phase": phase}
+	if jobCreated != nil {
+		status["conditions"] = []interface{}{jobCreated}
+	}
+	s := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "vteam.ambient-code/v1alpha1",
+		"kind":       "AgenticSession",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "proj"},
+		"status":     status,
+	}}
+	s.SetUID(k8stypes.UID(name + "-uid"))
+	s.SetCreationTimestamp(metav1.NewTime(created))
+	return s
+}
+
+func sessionPhase(t *testing.T, name string) string {
+	t.Helper()
+	obj, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get session %s: %v", name, err)
+	}
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return phase
+}
+
+// TestAwaitingJobSince covers which phases count as waiting for a Job and where the clock starts
+func TestAwaitingJobSince(t *testing.T) {
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	transition := created.Add(time.Hour)
+	cond := func(status, reason string) map[string]interface{} {
+		return map[string]interface{}{"type": conditionJobCreated, "status": status, "reason": reason, "message": "quota exceeded", "lastTransitionTime": transition.Format(time.RFC3339)}
+	}
+	cases := []struct {
+		name    string
+		session *unstructured.Unstructured
+		waiting bool
+		since   time.Time
+	}{
+		{name: "creating without condition", session: waitingSession("a", phaseCreating, nil, created), waiting: true, since: created},
+		{name: "creating with condition", session: waitingSession("b", phaseCreating, cond("False", "Creating"), created), waiting: true, since: transition},
+		{name: "pending after failed create", session: waitingSession("c", phasePending, cond("False", "CreateFailed"), created), waiting: true, since: transition},
+		{name: "pending after missing job", session: waitingSession("d", phasePending, cond("False", "JobMissing"), created), waiting: true, since: transition},
+		{name: "fresh pending", session: waitingSession("e", phasePending, nil, created)},
+		{name: "running", session: waitingSession("f", phaseRunning, cond("True", "Created"), created)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			since, waiting := awaitingJobSince(tc.session)
+			if waiting != tc.waiting || (waiting && !since.Equal(tc.since)) {
+				t.Errorf("got waiting=%v since=%v, want waiting=%v since=%v", waiting, since, tc.waiting, tc.since)
+			}
+		})
+	}
+}
+
+// TestRecoverStaleSessionsFailsAfterMaxAttempts verifies a stale session that exhausted its retries
+// moves to Error with the underlying cause, while recent and Job-backed sessions are left alone
+func TestRecoverStaleSessionsFailsAfterMaxAttempts(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	failed := map[string]interface{}{"type": conditionJobCreated, "status": "False", "reason": "CreateFailed",
+		"message": "exceeded quota: pods", "lastTransitionTime": old.UTC().Format(time.RFC3339)}
+
+	setupTestClient(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "has-job-job", Namespace: "proj"}})
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{types.GetAgenticSessionResource(): "AgenticSessionList"},
+		waitingSession("exhausted", phasePending, failed, old),
+		waitingSession("has-job", phaseCreating, nil, old),
+		waitingSession("recent", phaseCreating, nil, now),
+	)
+	jobRecoveryAttemptsMu.Lock()
+	jobRecoveryAttempts = map[string]int{"exhausted-uid": maxJobRecoveryAttempts, "gone-uid": 1}
+	jobRecoveryAttemptsMu.Unlock()
+
+	recoverStaleSessions(context.Background(), now)
+
+	if got := sessionPhase(t, "exhausted"); got != phaseError {
+		t.Fatalf("exhausted session phase = %q, want %q", got, phaseError)
+	}
+	obj, _ := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), "exhausted", metav1.GetOptions{})
+	ready := sessionCondition(obj, conditionReady)
+	if ready == nil || ready["reason"] != "JobCreationFailed" || ready["message"] != "runner job could not be created after 3 attempts: exceeded quota: pods" {
+		t.Errorf("unexpected Ready condition: %v", ready)
+	}
+	if got := sessionPhase(t, "has-job"); got != phaseCreating {
+		t.Errorf("session with a job changed phase to %q", got)
+	}
+	if got := sessionPhase(t, "recent"); got != phaseCreating {
+		t.Errorf("recent session changed phase to %q", got)
+	}
+
+	jobRecoveryAttemptsMu.Lock()
+	defer jobRecoveryAttemptsMu.Unlock()
+	if _, ok := jobRecoveryAttempts["gone-uid"]; ok {
+		t.Error("attempts of sessions no longer waiting must be forgotten")
+	}
+	if _, ok := jobRecoveryAttempts["exhausted-uid"]; ok {
+		t.Error("attempts of failed sessions must be forgotten")
+	}
+}
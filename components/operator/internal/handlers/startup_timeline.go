@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Startup milestones recorded in status.timeline, in the order a start reaches them. createdAt is
+// when the session was created or its restart was requested; a restart replaces the timeline.
+const (
+	timelineCreatedAt          = "createdAt"
+	timelineTokenProvisionedAt = "tokenProvisionedAt"
+	timelineJobCreatedAt       = "jobCreatedAt"
+	timelinePodScheduledAt     = "podScheduledAt"
+	timelineRunnerStartedAt    = "runnerStartedAt"
+)
+
+var timelineMilestones = []string{
+	timelineCreatedAt,
+	timelineTokenProvisionedAt,
+	timelineJobCreatedAt,
+	timelinePodScheduledAt,
+	timelineRunnerStartedAt,
+}
+
+// startupStages names the stage that ends at each milestone after createdAt
+var startupStages = map[string]string{
+	timelineTokenProvisionedAt: "token_provisioning",
+	timelineJobCreatedAt:       "operator_pickup",
+	timelinePodScheduledAt:     "pod_scheduling",
+	timelineRunnerStartedAt:    "runner_startup",
+}
+
+// startupStageTotal is the stage spanning createdAt to runnerStartedAt
+const startupStageTotal = "total"
+
+// startupLatencyBuckets are the histogram upper bounds in seconds
+var startupLatencyBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// latencyHistogram is one stage's histogram; Counts are cumulative per bucket, Count includes +Inf
+type latencyHistogram struct {
+	Counts []int64 `json:"counts"`
+	Sum    float64 `json:"sum"`
+	Count  int64   `json:"count"`
+}
+
+var (
+	startupLatency   = map[string]*latencyHistogram{}
+	startupLatencyMu sync.Mutex
+)
+
+// formatMilestone renders a milestone time the way status timestamps are stored
+func formatMilestone(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// podScheduledAt is when the pod's PodScheduled condition turned true, or now when it is not reported
+func podScheduledAt(pod *corev1.Pod) time.Time {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionTrue && !cond.LastTransitionTime.IsZero() {
+			return cond.LastTransitionTime.Time
+		}
+	}
+	return time.Now()
+}
+
+// runnerStartedAt is when the runner container started running, or now when it is not reported
+func runnerStartedAt(running *corev1.ContainerStateRunning) time.Time {
+	if running.StartedAt.IsZero() {
+		return time.Now()
+	}
+	return running.StartedAt.Time
+}
+
+// setMilestones records milestones in status.timeline, keeping any already set for this start. It
+// returns the timeline when this call completed it (set runnerStartedAt), nil otherwise.
+func setMilestones(status map[string]interface{}, milestones map[string]time.Time) map[string]interface{} {
+	timeline, _ := status["timeline"].(map[string]interface{})
+	if timeline == nil {
+		timeline = map[string]interface{}{}
+	}
+	completed := false
+	for name, at := range milestones {
+		if existing, _ := timeline[name].(string); existing != "" {
+			continue
+		}
+		timeline[name] = formatMilestone(at)
+		completed = completed || name == timelineRunnerStartedAt
+	}
+	status["timeline"] = timeline
+	if !completed {
+		return nil
+	}
+	done := make(map[string]interface{}, len(timeline))
+	for k, v := range timeline {
+		done[k] = v
+	}
+	return done
+}
+
+// startupStageDurations returns the seconds spent in each stage of a timeline. A stage is measured
+// from the latest earlier milestone that was recorded, so a skipped milestone (no token provisioning
+// on a plain restart) folds into the next stage.
+func startupStageDurations(timeline map[string]interface{}) map[string]float64 {
+	parse := func(name string) (time.Time, bool) {
+		raw, _ := timeline[name].(string)
+		t, err := time.Parse(time.RFC3339, raw)
+		return t, err == nil
+	}
+	durations := map[string]float64{}
+	var prev time.Time
+	for _, name := range timelineMilestones {
+		at, ok := parse(name)
+		if !ok {
+			continue
+		}
+		if !prev.IsZero() && !at.Before(prev) {
+			durations[startupStages[name]] = at.Sub(prev).Seconds()
+		}
+		prev = at
+	}
+	created, okCreated := parse(timelineCreatedAt)
+	started, okStarted := parse(timelineRunnerStartedAt)
+	if okCreated && okStarted && !started.Before(created) {
+		durations[startupStageTotal] = started.Sub(created).Seconds()
+	}
+	return durations
+}
+
+// observeStartupTimeline adds a completed timeline's stage durations to the startup histograms
+func observeStartupTimeline(timeline map[string]interface{}) {
+	startupLatencyMu.Lock()
+	defer startupLatencyMu.Unlock()
+	for stage, seconds := range startupStageDurations(timeline) {
+		h := startupLatency[stage]
+		if h == nil {
+			h = &latencyHistogram{Counts: make([]int64, len(startupLatencyBuckets))}
+			startupLatency[stage] = h
+		}
+		for i, le := range startupLatencyBuckets {
+			if seconds <= le {
+				h.Counts[i]++
+			}
+		}
+		h.Sum += seconds
+		h.Count++
+	}
+}
+
+// startupLatencyData renders the histograms for the operator status ConfigMap, which the backend
+// exposes on /metrics
+func startupLatencyData() string {
+	startupLatencyMu.Lock()
+	defer startupLatencyMu.Unlock()
+	b, err := json.Marshal(map[string]interface{}{
+		"buckets": startupLatencyBuckets,
+		"stages":  startupLatency,
+	})
+	if err != nil {
+		log.Printf("Failed to render startup latency histograms: %v", err)
+		return ""
+	}
+	return string(b)
+}
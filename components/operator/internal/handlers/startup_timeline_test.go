@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+	"ambient-code-operator/internal/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// TestStartupStageDurations verifies each stage is measured from the latest earlier milestone
+func TestStartupStageDurations(t *testing.T) {
+	got := startupStageDurations(map[string]interface{}{
+		timelineCreatedAt:       "2026-01-01T10:00:00Z",
+		timelineJobCreatedAt:    "2026-01-01T10:00:04Z",
+		timelinePodScheduledAt:  "2026-01-01T10:01:04Z",
+		timelineRunnerStartedAt: "2026-01-01T10:03:04Z",
+	})
+	want := map[string]float64{
+		"operator_pickup": 4,
+		"pod_scheduling":  60,
+		"runner_startup":  120,
+		"total":           184,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("durations = %v, want %v", got, want)
+	}
+	for stage, seconds := range want {
+		if got[stage] != seconds {
+			t.Errorf("%s = %v, want %v", stage, got[stage], seconds)
+		}
+	}
+}
+
+// TestStatusPatchMilestones verifies milestones are recorded once per start and a completed
+// timeline is observed once in the startup histograms
+func TestStatusPatchMilestones(t *testing.T) {
+	config.DynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{types.GetAgenticSessionResource(): "AgenticSessionList"},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "vteam.ambient-code/v1alpha1",
+			"kind":       "AgenticSession",
+			"metadata":   map[string]interface{}{"name": "s", "namespace": "proj"},
+			"status": map[string]interface{}{
+				"phase":    "Creating",
+				"timeline": map[string]interface{}{timelineCreatedAt: "2026-01-01T10:00:00Z", timelineJobCreatedAt: "2026-01-01T10:00:02Z"},
+			},
+		}})
+	startupLatencyMu.Lock()
+	startupLatency = map[string]*latencyHistogram{}
+	startupLatencyMu.Unlock()
+
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	sp := NewStatusPatch("proj", "s")
+	sp.SetMilestone(timelineJobCreatedAt, start.Add(time.Hour))
+	sp.SetMilestone(timelinePodScheduledAt, start.Add(30*time.Second))
+	sp.SetMilestone(timelineRunnerStartedAt, start.Add(90*time.Second))
+	if err := sp.ApplyAndReset(); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	sp.SetMilestone(timelineRunnerStartedAt, start.Add(time.Hour))
+	if err := sp.Apply(); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+
+	obj, err := config.DynamicClient.Resource(types.GetAgenticSessionResource()).Namespace("proj").Get(context.Background(), "s", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	timeline, _, _ := unstructured.NestedStringMap(obj.Object, "status", "timeline")
+	want := map[string]string{
+		timelineCreatedAt:       "2026-01-01T10:00:00Z",
+		timelineJobCreatedAt:    "2026-01-01T10:00:02Z",
+		timelinePodScheduledAt:  "2026-01-01T10:00:30Z",
+		timelineRunnerStartedAt: "2026-01-01T10:01:30Z",
+	}
+	for k, v := range want {
+		if timeline[k] != v {
+			t.Errorf("timeline[%s] = %q, want %q", k, timeline[k], v)
+		}
+	}
+
+	var published struct {
+		Buckets []float64                    `json:"buckets"`
+		Stages  map[string]*latencyHistogram `json:"stages"`
+	}
+	if err := json.Unmarshal([]byte(startupLatencyData()), &published); err != nil {
+		t.Fatalf("decode histograms: %v", err)
+	}
+	scheduling := published.Stages["pod_scheduling"]
+	if scheduling == nil || scheduling.Count != 1 || scheduling.Sum != 28 {
+		t.Fatalf("pod_scheduling histogram = %+v, want one observation of 28s", scheduling)
+	}
+	// 28s falls in the 30s bucket and above
+	if scheduling.Counts[2] != 0 || scheduling.Counts[3] != 1 {
+		t.Errorf("pod_scheduling counts = %v", scheduling.Counts)
+	}
+	if total := published.Stages["total"]; total == nil || total.Count != 1 || total.Sum != 90 {
+		t.Errorf("total histogram = %+v, want one observation of 90s", total)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"os"
+
+	"ambient-code-operator/internal/tracing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// otlpEnvVars are passed through to content service containers so their spans reach the same
+// collector. OTEL_EXPORTER_OTLP_HEADERS is not: it may hold collector credentials.
+var otlpEnvVars = []string{"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"}
+
+// startSessionSpan starts a span named name in the trace the backend recorded on session at create
+// (the ambient-code.io/traceparent annotation). With tracing off, or for a session created without
+// a trace, it returns a nil span and a context without one, so child spans are skipped as well.
+func startSessionSpan(session *unstructured.Unstructured, name string) (context.Context, *tracing.Span) {
+	ctx := context.Background()
+	if !tracing.Enabled() {
+		return ctx, nil
+	}
+	ctx = tracing.WithRemoteParent(ctx, session.GetAnnotations()[tracing.TraceparentAnnotation])
+	if tracing.Traceparent(ctx) == "" {
+		return ctx, nil
+	}
+	ctx, span := tracing.Start(ctx, name, tracing.KindInternal)
+	span.SetAttribute("ambient.project", session.GetNamespace())
+	span.SetAttribute("ambient.session", session.GetName())
+	return ctx, span
+}
+
+// startChildSpan starts a span under the one in ctx, or returns nil when ctx carries none
+func startChildSpan(ctx context.Context, name string, kind tracing.SpanKind) *tracing.Span {
+	if tracing.Traceparent(ctx) == "" {
+		return nil
+	}
+	_, span := tracing.Start(ctx, name, kind)
+	return span
+}
+
+// otlpEnv returns the OTLP endpoint settings of the operator for a content service container
+func otlpEnv() []corev1.EnvVar {
+	var env []corev1.EnvVar
+	for _, name := range otlpEnvVars {
+		if v := os.Getenv(name); v != "" {
+			env = append(env, corev1.EnvVar{Name: name, Value: v})
+		}
+	}
+	return env
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"testing"
+
+	"ambient-code-operator/internal/tracing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestContentServiceEnvPassesOTLPEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://otel-collector.observability:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer secret")
+
+	got := map[string]string{}
+	for _, e := range contentServiceEnv("s1", "proj") {
+		got[e.Name] = e.Value
+	}
+	if got["OTEL_EXPORTER_OTLP_ENDPOINT"] != "http://otel-collector.observability:4318" {
+		t.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT = %q", got["OTEL_EXPORTER_OTLP_ENDPOINT"])
+	}
+	if _, ok := got["OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"]; ok {
+		t.Errorf("unset OTEL_EXPORTER_OTLP_TRACES_ENDPOINT was passed through")
+	}
+	if _, ok := got["OTEL_EXPORTER_OTLP_HEADERS"]; ok {
+		t.Errorf("collector headers must not be copied into pod specs")
+	}
+}
+
+func TestSessionSpansNeedTracing(t *testing.T) {
+	session := &unstructured.Unstructured{}
+	session.SetName("s1")
+	session.SetNamespace("proj")
+	session.SetAnnotations(map[string]string{tracing.TraceparentAnnotation: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"})
+
+	ctx, span := startSessionSpan(session, "reconcile AgenticSession")
+	if span != nil {
+		t.Fatalf("startSessionSpan returned a span with tracing off")
+	}
+	if child := startChildSpan(ctx, "create runner Job", tracing.KindClient); child != nil {
+		t.Errorf("startChildSpan returned a span without a parent")
+	}
+}
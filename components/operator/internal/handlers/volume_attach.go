@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// workspacePVCPrefix names the workspace PVC of a session: ambient-workspace-<session>
+const workspacePVCPrefix = "ambient-workspace-"
+
+// podWorkspaceSession returns the session whose workspace PVC the pod mounts. Continuations mount
+// their parent's workspace, so this is not always the pod's own session.
+func podWorkspaceSession(pod *corev1.Pod) string {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.PersistentVolumeClaim != nil && strings.HasPrefix(vol.PersistentVolumeClaim.ClaimName, workspacePVCPrefix) {
+			return strings.TrimPrefix(vol.PersistentVolumeClaim.ClaimName, workspacePVCPrefix)
+		}
+	}
+	return ""
+}
+
+// volumeMultiAttachEvent returns the message of the newest Multi-Attach FailedAttachVolume event of a
+// pod stuck waiting for its volumes
+func volumeMultiAttachEvent(pod *corev1.Pod) (string, bool) {
+	if pod.Status.Phase != corev1.PodPending {
+		return "", false
+	}
+	events, err := config.K8sClient.CoreV1().Events(pod.Namespace).List(context.TODO(), v1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.name=%s,reason=FailedAttachVolume", pod.Name),
+	})
+	if err != nil {
+		log.Printf("Failed to list events of pod %s/%s: %v", pod.Namespace, pod.Name, err)
+		return "", false
+	}
+	var latest *corev1.Event
+	for i := range events.Items {
+		ev := &events.Items[i]
+		if ev.InvolvedObject.Name != pod.Name || ev.Reason != "FailedAttachVolume" || !strings.Contains(ev.Message, "Multi-Attach") {
+			continue
+		}
+		if latest == nil || ev.LastTimestamp.After(latest.LastTimestamp.Time) {
+			latest = ev
+		}
+	}
+	if latest == nil {
+		return "", false
+	}
+	return latest.Message, true
+}
+
+// runnerVolumeConflict reports a runner pod that cannot start because its workspace volume is still
+// attached elsewhere, usually to the temp content pod of a workspace that was just browsed. That temp
+// pod is deleted so the volume can move to the runner; the returned message explains the wait.
+func runnerVolumeConflict(pod *corev1.Pod) (string, bool) {
+	event, ok := volumeMultiAttachEvent(pod)
+	if !ok {
+		return "", false
+	}
+	msg := fmt.Sprintf("Waiting for the workspace volume to be released by another pod: %s", event)
+	if workspace := podWorkspaceSession(pod); workspace != "" {
+		tempPodName := fmt.Sprintf("temp-content-%s", workspace)
+		err := config.K8sClient.CoreV1().Pods(pod.Namespace).Delete(context.TODO(), tempPodName, v1.DeleteOptions{})
+		switch {
+		case err == nil:
+			log.Printf("Deleted temp content pod %s/%s holding the workspace of runner pod %s", pod.Namespace, tempPodName, pod.Name)
+			msg = fmt.Sprintf("Waiting for the workspace volume to be released by temp content pod %s: %s", tempPodName, event)
+		case !errors.IsNotFound(err):
+			log.Printf("Failed to delete temp content pod %s/%s: %v", pod.Namespace, tempPodName, err)
+		}
+	}
+	return msg, true
+}
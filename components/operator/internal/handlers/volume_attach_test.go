@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func runnerPodWithWorkspace(phase corev1.PodPhase, workspace string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "s2-job-abc", Namespace: "proj"},
+		Spec: corev1.PodSpec{Volumes: []corev1.Volume{{
+			Name:         "workspace",
+			VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: workspacePVCPrefix + workspace}},
+		}}},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func attachEvent(name, pod, message string, at time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "proj"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "proj"},
+		Reason:         "FailedAttachVolume",
+		Message:        message,
+		LastTimestamp:  metav1.NewTime(at),
+	}
+}
+
+// TestRunnerVolumeConflictDeletesTempPod verifies a Multi-Attach event on a pending runner pod is
+// reported and the temp content pod of the workspace it mounts is deleted
+func TestRunnerVolumeConflictDeletesTempPod(t *testing.T) {
+	now := time.Now()
+	// A continuation of s1 mounts s1's workspace
+	pod := runnerPodWithWorkspace(corev1.PodPending, "s1")
+	setupTestClient(
+		pod,
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "temp-content-s1", Namespace: "proj"}},
+		attachEvent("e1", pod.Name, "Multi-Attach error for volume \"pvc-1\" Volume is already used by pod(s) temp-content-s1", now),
+		attachEvent("e2", pod.Name, "AttachVolume.Attach failed for volume \"pvc-1\": timed out", now.Add(time.Minute)),
+		attachEvent("e3", "other-pod", "Multi-Attach error for volume \"pvc-2\"", now),
+	)
+
+	msg, conflict := runnerVolumeConflict(pod)
+	if !conflict {
+		t.Fatal("expected a volume conflict")
+	}
+	if !strings.Contains(msg, "temp content pod temp-content-s1") || !strings.Contains(msg, "already used by pod(s) temp-content-s1") {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if _, err := config.K8sClient.CoreV1().Pods("proj").Get(context.Background(), "temp-content-s1", metav1.GetOptions{}); err == nil {
+		t.Error("expected the temp content pod to be deleted")
+	}
+}
+
+// TestRunnerVolumeConflictIgnoresOtherPods verifies running pods and pods without a Multi-Attach
+// event are not reported
+func TestRunnerVolumeConflictIgnoresOtherPods(t *testing.T) {
+	running := runnerPodWithWorkspace(corev1.PodRunning, "s2")
+	pending := runnerPodWithWorkspace(corev1.PodPending, "s2")
+	setupTestClient(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "temp-content-s2", Namespace: "proj"}},
+		attachEvent("e1", running.Name, "AttachVolume.Attach failed for volume \"pvc-1\": timed out", time.Now()),
+	)
+
+	if _, conflict := runnerVolumeConflict(running); conflict {
+		t.Error("running pod reported as conflicting")
+	}
+	if _, conflict := runnerVolumeConflict(pending); conflict {
+		t.Error("pod without a Multi-Attach event reported as conflicting")
+	}
+	if _, err := config.K8sClient.CoreV1().Pods("proj").Get(context.Background(), "temp-content-s2", metav1.GetOptions{}); err != nil {
+		t.Errorf("temp content pod was deleted: %v", err)
+	}
+}
+
+// TestReconcileTempContentPodReadOnly verifies the read-only flag reaches the temp pod and that a
+// pod in the other mode is replaced
+func TestReconcileTempContentPodReadOnly(t *testing.T) {
+	setupTestClient()
+	session := &unstructured.Unstructured{}
+	session.SetAPIVersion("vteam.ambient-code/v1alpha1")
+	session.SetKind("AgenticSession")
+	session.SetName("s1")
+	session.SetNamespace("proj")
+	session.SetAnnotations(map[string]string{tempContentReadOnlyAnnotation: "true"})
+
+	if err := reconcileTempContentPodWithPatch("proj", "s1", "temp-content-s1", session, NewStatusPatch("proj", "s1")); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	pod, err := config.K8sClient.CoreV1().Pods("proj").Get(context.Background(), "temp-content-s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected temp pod, got %v", err)
+	}
+	if pod.Annotations[tempContentReadOnlyAnnotation] != "true" {
+		t.Errorf("expected read-only annotation on pod, got %v", pod.Annotations)
+	}
+	readOnlyEnv := false
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == "CONTENT_SERVICE_READONLY" && env.Value == "true" {
+			readOnlyEnv = true
+		}
+	}
+	if !readOnlyEnv {
+		t.Error("expected CONTENT_SERVICE_READONLY=true on the content container")
+	}
+
+	// An editor asks for a writable pod: the read-only one is deleted so it can be recreated
+	session.SetAnnotations(map[string]string{tempContentReadOnlyAnnotation: "false"})
+	if err := reconcileTempContentPodWithPatch("proj", "s1", "temp-content-s1", session, NewStatusPatch("proj", "s1")); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := config.K8sClient.CoreV1().Pods("proj").Get(context.Background(), "temp-content-s1", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Fatalf("expected read-only pod to be replaced, got %v", err)
+	}
+	if err := reconcileTempContentPodWithPatch("proj", "s1", "temp-content-s1", session, NewStatusPatch("proj", "s1")); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	pod, err = config.K8sClient.CoreV1().Pods("proj").Get(context.Background(), "temp-content-s1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected writable temp pod, got %v", err)
+	}
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == "CONTENT_SERVICE_READONLY" {
+			t.Errorf("expected no CONTENT_SERVICE_READONLY on a writable pod, got %q", env.Value)
+		}
+	}
+}
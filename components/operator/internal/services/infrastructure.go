@@ -3,6 +3,7 @@ package services
 
 import (
 	"context"
+	"log"
 
 	"ambient-code-operator/internal/config"
 
@@ -51,8 +52,27 @@ func EnsureContentService(namespace string) error {
 	return nil
 }
 
-// EnsureSessionWorkspacePVC creates a per-session PVC owned by the AgenticSession to avoid multi-attach conflicts
-func EnsureSessionWorkspacePVC(namespace, pvcName string, ownerRefs []v1.OwnerReference) error {
+// DefaultWorkspaceSize is the size of a session workspace PVC when spec.workspaceSize is unset
+const DefaultWorkspaceSize = "5Gi"
+
+// workspacePVCSize parses spec.workspaceSize, falling back to DefaultWorkspaceSize when it is
+// empty or not a positive quantity
+func workspacePVCSize(size string) resource.Quantity {
+	if size == "" {
+		return resource.MustParse(DefaultWorkspaceSize)
+	}
+	q, err := resource.ParseQuantity(size)
+	if err != nil || q.Sign() <= 0 {
+		log.Printf("Invalid workspaceSize %q, using %s", size, DefaultWorkspaceSize)
+		return resource.MustParse(DefaultWorkspaceSize)
+	}
+	return q
+}
+
+// EnsureSessionWorkspacePVC creates a per-session PVC owned by the AgenticSession to avoid multi-attach conflicts.
+// size is the session's spec.workspaceSize; an existing PVC is left alone, since growing it is the
+// backend's resize-workspace endpoint's job.
+func EnsureSessionWorkspacePVC(namespace, pvcName, size string, ownerRefs []v1.OwnerReference) error {
 	// Check if PVC exists
 	if _, err := config.K8sClient.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), pvcName, v1.GetOptions{}); err == nil {
 		return nil
@@ -71,7 +91,7 @@ func EnsureSessionWorkspacePVC(namespace, pvcName string, ownerRefs []v1.OwnerRe
 			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
 			Resources: corev1.VolumeResourceRequirements{
 				Requests: corev1.ResourceList{
-					corev1.ResourceStorage: resource.MustParse("5Gi"),
+					corev1.ResourceStorage: workspacePVCSize(size),
 				},
 			},
 		},
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"ambient-code-operator/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEnsureSessionWorkspacePVCSize(t *testing.T) {
+	existing := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ambient-workspace-old", Namespace: "proj"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("20Gi")},
+			},
+		},
+	}
+	config.K8sClient = fake.NewSimpleClientset(existing)
+	ctx := context.Background()
+
+	cases := []struct {
+		pvc, size, want string
+	}{
+		{"ambient-workspace-default", "", DefaultWorkspaceSize},
+		{"ambient-workspace-big", "50Gi", "50Gi"},
+		{"ambient-workspace-bad", "lots", DefaultWorkspaceSize},
+		{"ambient-workspace-zero", "0", DefaultWorkspaceSize},
+		// An existing PVC keeps its size; growing it goes through the backend
+		{"ambient-workspace-old", "50Gi", "20Gi"},
+	}
+	for _, tc := range cases {
+		if err := EnsureSessionWorkspacePVC("proj", tc.pvc, tc.size, nil); err != nil {
+			t.Fatalf("EnsureSessionWorkspacePVC(%s, %q): %v", tc.pvc, tc.size, err)
+		}
+		pvc, err := config.K8sClient.CoreV1().PersistentVolumeClaims("proj").Get(ctx, tc.pvc, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("get %s: %v", tc.pvc, err)
+		}
+		got := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if want := resource.MustParse(tc.want); got.Cmp(want) != 0 {
+			t.Errorf("%s with size %q requests %s, want %s", tc.pvc, tc.size, got.String(), tc.want)
+		}
+	}
+}
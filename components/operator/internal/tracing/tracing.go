@@ -0,0 +1,182 @@
+// Package tracing records OpenTelemetry spans for the operator and exports them to an OTLP/HTTP
+// collector with the OpenTelemetry SDK. The operator continues the trace of the request that
+// created a session from the session's ambient-code.io/traceparent annotation.
+//
+// Tracing is off unless OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set;
+// the exporter also reads the other OTEL_EXPORTER_OTLP_* settings, such as headers and timeout.
+// While it is off Start returns a nil *Span, every Span method is a no-op on nil, and nothing is
+// added to contexts or headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// TraceparentHeader carries the W3C trace context between services
+	TraceparentHeader = "traceparent"
+	// TraceparentAnnotation is the traceparent of the request that created an AgenticSession,
+	// which the operator continues when it reconciles the session
+	TraceparentAnnotation = "ambient-code.io/traceparent"
+	// TraceIDAnnotation is the trace ID of TraceparentAnnotation, for finding the trace in a UI
+	TraceIDAnnotation = "ambient-code.io/trace-id"
+	// scopeName is the instrumentation scope reported for every span
+	scopeName = "ambient-code"
+)
+
+// SpanKind is the OTLP span kind
+type SpanKind int
+
+const (
+	KindInternal SpanKind = SpanKind(trace.SpanKindInternal)
+	KindServer   SpanKind = SpanKind(trace.SpanKindServer)
+	KindClient   SpanKind = SpanKind(trace.SpanKindClient)
+)
+
+var (
+	// provider exports ended spans in batches, nil while tracing is off
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	// propagator reads and writes the traceparent header
+	propagator = propagation.TraceContext{}
+)
+
+// Init turns tracing on when an OTLP endpoint is configured. serviceName is reported as the
+// service.name resource attribute unless OTEL_SERVICE_NAME overrides it.
+func Init(serviceName string) {
+	endpoint := strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"))
+	if endpoint == "" {
+		endpoint = strings.TrimSpace(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	}
+	if endpoint == "" {
+		return
+	}
+	if name := strings.TrimSpace(os.Getenv("OTEL_SERVICE_NAME")); name != "" {
+		serviceName = name
+	}
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		log.Printf("Tracing disabled: failed to create the OTLP exporter: %v", err)
+		return
+	}
+	enable(exporter, serviceName)
+	log.Printf("Tracing enabled: exporting %s spans to %s", serviceName, endpoint)
+}
+
+// enable starts recording spans of serviceName and exporting them with exporter
+func enable(exporter sdktrace.SpanExporter, serviceName string) {
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		// Record every span, including those continuing an unsampled remote parent
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	tracer = provider.Tracer(scopeName)
+}
+
+// Enabled reports whether spans are recorded
+func Enabled() bool {
+	return provider != nil
+}
+
+// Span is one timed operation of a trace. A nil *Span is valid and records nothing.
+type Span struct {
+	span trace.Span
+}
+
+// Start begins a span named name, a child of the span in ctx or the root of a new trace, and returns
+// a context carrying it. With tracing off it returns ctx and a nil span.
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if provider == nil {
+		return ctx, nil
+	}
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKind(kind)))
+	return ctx, &Span{span: span}
+}
+
+// WithRemoteParent returns ctx carrying the span context of a traceparent value received from
+// another service, so the next Start continues that trace. It returns ctx unchanged with tracing
+// off or when traceparent is empty or malformed.
+func WithRemoteParent(ctx context.Context, traceparent string) context.Context {
+	if provider == nil || traceparent == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{TraceparentHeader: strings.TrimSpace(traceparent)})
+}
+
+// Traceparent returns the W3C traceparent of the span in ctx, or ""
+func Traceparent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+	return carrier[TraceparentHeader]
+}
+
+// TraceID returns the trace ID of the span in ctx as 32 hex digits, or ""
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Inject sets the traceparent header of an outgoing request from the span in ctx
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// TraceID returns the span's trace ID as 32 hex digits, or "" for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.span.SpanContext().TraceID().String()
+}
+
+// SetAttribute records a string, int, int64 or bool attribute on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		s.span.SetAttributes(attribute.String(key, v))
+	case int:
+		s.span.SetAttributes(attribute.Int(key, v))
+	case int64:
+		s.span.SetAttributes(attribute.Int64(key, v))
+	case bool:
+		s.span.SetAttributes(attribute.Bool(key, v))
+	default:
+		s.span.SetAttributes(attribute.String(key, fmt.Sprint(v)))
+	}
+}
+
+// SetError marks the span failed with message
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.span.SetStatus(codes.Error, message)
+}
+
+// End finishes the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
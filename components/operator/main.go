@@ -7,6 +7,7 @@ import (
 	"ambient-code-operator/internal/config"
 	"ambient-code-operator/internal/handlers"
 	"ambient-code-operator/internal/preflight"
+	"ambient-code-operator/internal/tracing"
 )
 
 // Build-time metadata (set via -ldflags -X during build)
@@ -53,6 +54,9 @@ func main() {
 	log.Printf("Agentic Session Operator starting in namespace: %s", appConfig.Namespace)
 	log.Printf("Using ambient-code runner image: %s", appConfig.AmbientCodeRunnerImage)
 
+	// Export reconcile spans when an OTLP endpoint is configured
+	tracing.Init("ambient-code-operator")
+
 	// Validate Vertex AI configuration at startup if enabled
 	if os.Getenv("CLAUDE_CODE_USE_VERTEX") == "1" {
 		if err := preflight.ValidateVertexConfig(appConfig.Namespace); err != nil {
@@ -72,6 +76,26 @@ func main() {
 	// Start cleanup of expired temporary content pods
 	go handlers.CleanupExpiredTempContentPods()
 
+	// Start cleanup of runner RBAC left behind by deleted sessions
+	go handlers.CleanupOrphanedSessionResources()
+
+	// Start retrying Job creation for sessions stuck waiting for their runner Job
+	go handlers.RecoverStaleSessions()
+
+	// Start pruning finished sessions beyond ProjectSettings sessionRetention
+	go handlers.PruneFinishedSessions()
+
+	// Start retrying sessions queued for project capacity
+	go handlers.ProcessSessionQueue()
+
+	// Start publishing build metadata and reconcile stats for the backend's admin endpoint
+	go handlers.PublishOperatorStatus(appConfig.Namespace, handlers.BuildInfo{
+		GitVersion: GitVersion,
+		GitCommit:  GitCommit,
+		GitBranch:  GitBranch,
+		BuildDate:  BuildDate,
+	})
+
 	// Keep the operator running
 	select {}
 }
@@ -14,6 +14,7 @@ import logging
 import json as _json
 import re
 import shutil
+import time
 import uuid
 from pathlib import Path
 from typing import AsyncIterator, Optional, Any
@@ -46,6 +47,7 @@ from ag_ui.core import (
 )
 
 from context import RunnerContext
+from security_utils import get_bot_token
 
 logger = logging.getLogger(__name__)
 
@@ -70,6 +72,10 @@ class ClaudeCodeAdapter:
         self._skip_resume_on_restart = False
         self._turn_count = 0
 
+        # Incremental usage reporting (see _report_usage_delta)
+        self._usage_seq = 0
+        self._last_total_cost: Optional[float] = None
+
         # AG-UI streaming state
         self._current_message_id: Optional[str] = None
         self._current_tool_id: Optional[str] = None
@@ -706,6 +712,11 @@ class ClaudeCodeAdapter:
                             "result": getattr(message, 'result', None),
                         }
 
+                        await self._report_usage_delta(
+                            usage_raw if isinstance(usage_raw, dict) else None,
+                            getattr(message, 'total_cost_usd', None),
+                        )
+
                         # Emit state delta with result
                         yield StateDeltaEvent(
                             type=EventType.STATE_DELTA,
@@ -962,6 +973,8 @@ class ClaudeCodeAdapter:
             await self._run_cmd(["git", "config", "user.name", user_name], cwd=str(workspace))
             await self._run_cmd(["git", "config", "user.email", user_email], cwd=str(workspace))
 
+            await self._report_cloned_commit(workspace, self._parse_owner_repo(input_repo)[1])
+
             if output_repo:
                 out_url = self._url_with_token(output_repo, token) if token else output_repo
                 await self._run_cmd(["git", "remote", "remove", "output"], cwd=str(workspace), ignore_errors=True)
@@ -993,6 +1006,8 @@ class ClaudeCodeAdapter:
                 inp = r.get('input') or {}
                 url = (inp.get('url') or '').strip()
                 branch = (inp.get('branch') or '').strip() or 'main'
+                # A pinned commit SHA or tag is checked out detached instead of the branch
+                ref = (inp.get('ref') or '').strip()
                 if not name or not url:
                     continue
 
@@ -1008,7 +1023,11 @@ class ClaudeCodeAdapter:
                         event={"type": "system_log", "message": f"📥 Cloning {name}..."}
                     )
                     clone_url = self._url_with_token(url, token) if token else url
-                    await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", clone_url, str(repo_dir)], cwd=str(workspace))
+                    if ref:
+                        await self._run_cmd(["git", "clone", clone_url, str(repo_dir)], cwd=str(workspace))
+                        await self._checkout_pinned_ref(repo_dir, ref)
+                    else:
+                        await self._run_cmd(["git", "clone", "--branch", branch, "--single-branch", clone_url, str(repo_dir)], cwd=str(workspace))
                     await self._run_cmd(["git", "remote", "set-url", "origin", clone_url], cwd=str(repo_dir), ignore_errors=True)
                 elif reusing_workspace:
                     yield RawEvent(
@@ -1026,9 +1045,12 @@ class ClaudeCodeAdapter:
                         event={"type": "system_log", "message": f"🔄 Resetting {name} to clean state"}
                     )
                     await self._run_cmd(["git", "remote", "set-url", "origin", self._url_with_token(url, token) if token else url], cwd=str(repo_dir), ignore_errors=True)
-                    await self._run_cmd(["git", "fetch", "origin", branch], cwd=str(repo_dir))
-                    await self._run_cmd(["git", "checkout", branch], cwd=str(repo_dir))
-                    await self._run_cmd(["git", "reset", "--hard", f"origin/{branch}"], cwd=str(repo_dir))
+                    if ref:
+                        await self._checkout_pinned_ref(repo_dir, ref)
+                    else:
+                        await self._run_cmd(["git", "fetch", "origin", branch], cwd=str(repo_dir))
+                        await self._run_cmd(["git", "checkout", branch], cwd=str(repo_dir))
+                        await self._run_cmd(["git", "reset", "--hard", f"origin/{branch}"], cwd=str(repo_dir))
 
                 # Git identity
                 user_name = os.getenv("GIT_USER_NAME", "").strip() or "Ambient Code Bot"
@@ -1036,6 +1058,8 @@ class ClaudeCodeAdapter:
                 await self._run_cmd(["git", "config", "user.name", user_name], cwd=str(repo_dir))
                 await self._run_cmd(["git", "config", "user.email", user_email], cwd=str(repo_dir))
 
+                await self._report_cloned_commit(repo_dir, name)
+
                 # Configure output remote
                 out = r.get('output') or {}
                 out_url_raw = (out.get('url') or '').strip()
@@ -1053,9 +1077,114 @@ class ClaudeCodeAdapter:
                 event={"type": "system_log", "message": f"Workspace preparation failed: {e}"}
             )
 
+    async def _checkout_pinned_ref(self, repo_dir: Path, ref: str) -> None:
+        """Check out a pinned commit SHA or tag detached, discarding local changes."""
+        # A SHA that no branch or tag reaches is not in the clone; fetch it (and tags) explicitly
+        await self._run_cmd(["git", "fetch", "--tags", "origin"], cwd=str(repo_dir), ignore_errors=True)
+        await self._run_cmd(["git", "fetch", "origin", ref], cwd=str(repo_dir), ignore_errors=True)
+        await self._run_cmd(["git", "-c", "advice.detachedHead=false", "checkout", "--detach", ref], cwd=str(repo_dir))
+        await self._run_cmd(["git", "reset", "--hard", ref], cwd=str(repo_dir))
+
+    async def _report_cloned_commit(self, repo_dir: Path, name: str) -> None:
+        """Record the checked-out commit of an input repo in session status (best-effort).
+
+        Sent as a repos entry on PUT .../status, which the backend merges by name into
+        status.reconciledRepos.
+        """
+        base = os.getenv('BACKEND_API_URL', '').rstrip('/')
+        project = os.getenv('PROJECT_NAME', '').strip()
+        session_id = self.context.session_id
+        if not base or not project or not session_id or not name:
+            return
+
+        try:
+            sha = (await self._run_cmd(["git", "rev-parse", "HEAD"], cwd=str(repo_dir), capture_stdout=True)).strip()
+            branch = (await self._run_cmd(["git", "rev-parse", "--abbrev-ref", "HEAD"], cwd=str(repo_dir), capture_stdout=True)).strip()
+        except Exception as e:
+            logger.warning(f"Could not resolve cloned commit for {name}: {e}")
+            return
+        if not sha:
+            return
+
+        entry = {"name": name, "status": "cloned", "clonedSha": sha}
+        if branch and branch != "HEAD":
+            entry["clonedBranch"] = branch
+        payload = {"repos": [entry]}
+
+        status_url = f"{base}/projects/{project}/agentic-sessions/{session_id}/status"
+        req = _urllib_request.Request(status_url, data=_json.dumps(payload).encode('utf-8'), headers={'Content-Type': 'application/json'}, method='PUT')
+        bot = get_bot_token()
+        if bot:
+            req.add_header('Authorization', f'Bearer {bot}')
+
+        loop = asyncio.get_event_loop()
+
+        def _do_req():
+            try:
+                with _urllib_request.urlopen(req, timeout=10) as resp:
+                    resp.read()
+                logger.info(f"Recorded cloned commit {sha[:12]} for {name}")
+            except Exception as e:
+                logger.warning(f"Failed to record cloned commit for {name}: {e}")
+
+        await loop.run_in_executor(None, _do_req)
+
+    async def _report_usage_delta(self, usage: Optional[dict], total_cost_usd: Optional[float]) -> None:
+        """Add one turn's token usage and cost to the session's status.usage (best-effort).
+
+        The SDK's usage is per turn while total_cost_usd is cumulative for this process, so the
+        cost delta is the increase since the last report. usage_seq is based on the clock so it
+        keeps increasing across runner restarts; the backend ignores sequences it already counted.
+        """
+        base = os.getenv('BACKEND_API_URL', '').rstrip('/')
+        project = os.getenv('PROJECT_NAME', '').strip()
+        session_id = self.context.session_id if self.context else ''
+        if not base or not project or not session_id:
+            return
+
+        delta = {}
+        for field in ("input_tokens", "output_tokens", "cache_creation_input_tokens", "cache_read_input_tokens"):
+            value = (usage or {}).get(field)
+            if isinstance(value, int) and value > 0:
+                delta[field] = value
+        if isinstance(total_cost_usd, (int, float)):
+            cost = float(total_cost_usd)
+            last = self._last_total_cost
+            cost_delta = cost - last if last is not None and cost >= last else cost
+            self._last_total_cost = cost
+            if cost_delta > 0:
+                delta["total_cost_usd"] = cost_delta
+        if not delta:
+            return
+
+        self._usage_seq = max(self._usage_seq + 1, int(time.time() * 1000))
+        payload = {"usage_delta": delta, "usage_seq": self._usage_seq}
+        status_url = f"{base}/projects/{project}/agentic-sessions/{session_id}/status"
+        req = _urllib_request.Request(status_url, data=_json.dumps(payload).encode('utf-8'), headers={'Content-Type': 'application/json'}, method='PUT')
+        bot = get_bot_token()
+        if bot:
+            req.add_header('Authorization', f'Bearer {bot}')
+
+        loop = asyncio.get_event_loop()
+
+        def _do_req():
+            try:
+                with _urllib_request.urlopen(req, timeout=10) as resp:
+                    resp.read()
+            except Exception as e:
+                logger.warning(f"Failed to report usage for session {session_id}: {e}")
+
+        await loop.run_in_executor(None, _do_req)
+
     async def _validate_prerequisites(self):
         """Validate prerequisite files exist for phase-based slash commands."""
         prompt = self.context.get_env("INITIAL_PROMPT", "")
+        prompt_file = self.context.get_env("INITIAL_PROMPT_FILE", "")
+        if not prompt and prompt_file:
+            try:
+                prompt = Path(prompt_file).read_text(encoding="utf-8")
+            except OSError as e:
+                logger.warning(f"Failed to read INITIAL_PROMPT_FILE {prompt_file}: {e}")
         if not prompt:
             return
 
@@ -1291,7 +1420,7 @@ class ClaudeCodeAdapter:
         logger.info(f"Fetching GitHub token from: {url}")
 
         req = _urllib_request.Request(url, data=b"{}", headers={'Content-Type': 'application/json'}, method='POST')
-        bot = (os.getenv('BOT_TOKEN') or '').strip()
+        bot = get_bot_token()
         if bot:
             req.add_header('Authorization', f'Bearer {bot}')
 
@@ -1360,8 +1489,15 @@ class ClaudeCodeAdapter:
                 for it in data:
                     if not isinstance(it, dict):
                         continue
-                    name = str(it.get('name') or '').strip()
+                    # spec.repos[].folder is the directory the backend picked for this repo
+                    # (distinct for same-named repos from different owners); prefer it
+                    name = str(it.get('folder') or it.get('name') or '').strip()
                     input_obj = it.get('input') or {}
+                    if not input_obj and it.get('url'):
+                        # spec.repos entries are flat: {url, branch, ref, folder, output}
+                        input_obj = {'url': it.get('url'), 'branch': it.get('branch') or 'main'}
+                        if it.get('ref'):
+                            input_obj['ref'] = it.get('ref')
                     output_obj = it.get('output') or None
                     url = str((input_obj or {}).get('url') or '').strip()
                     if not name and url:
@@ -18,6 +18,7 @@ from ag_ui.core import RunAgentInput
 from ag_ui.encoder import EventEncoder
 
 from context import RunnerContext
+from security_utils import get_bot_token
 
 logging.basicConfig(level=logging.INFO)
 logger = logging.getLogger(__name__)
@@ -102,7 +103,7 @@ async def lifespan(app: FastAPI):
     parent_session_id = os.getenv("PARENT_SESSION_ID", "").strip()
     
     # Check for INITIAL_PROMPT and auto-execute (only if no parent session)
-    initial_prompt = os.getenv("INITIAL_PROMPT", "").strip()
+    initial_prompt = load_initial_prompt()
     if initial_prompt and not parent_session_id:
         logger.info(f"INITIAL_PROMPT detected ({len(initial_prompt)} chars), will auto-execute after 3s delay")
         asyncio.create_task(auto_execute_initial_prompt(initial_prompt, session_id))
@@ -117,6 +118,19 @@ async def lifespan(app: FastAPI):
     logger.info("Shutting down AG-UI server...")
 
 
+def load_initial_prompt() -> str:
+    """Return the initial prompt from INITIAL_PROMPT, or from INITIAL_PROMPT_FILE for prompts too
+    large to keep inline (the operator mounts them from a ConfigMap)."""
+    prompt_file = os.getenv("INITIAL_PROMPT_FILE", "").strip()
+    if prompt_file:
+        try:
+            with open(prompt_file, encoding="utf-8") as f:
+                return f.read().strip()
+        except OSError as e:
+            logger.error(f"Failed to read INITIAL_PROMPT_FILE {prompt_file}: {e}")
+    return os.getenv("INITIAL_PROMPT", "").strip()
+
+
 async def auto_execute_initial_prompt(prompt: str, session_id: str):
     """Auto-execute INITIAL_PROMPT by POSTing to backend after short delay.
     
@@ -162,7 +176,7 @@ async def auto_execute_initial_prompt(prompt: str, session_id: str):
     }
     
     # Get BOT_TOKEN for auth
-    bot_token = os.getenv("BOT_TOKEN", "").strip()
+    bot_token = get_bot_token()
     headers = {"Content-Type": "application/json"}
     if bot_token:
         headers["Authorization"] = f"Bearer {bot_token}"
@@ -364,7 +378,7 @@ async def trigger_workflow_greeting(git_url: str, branch: str, path: str):
             }]
         }
         
-        bot_token = os.getenv("BOT_TOKEN", "").strip()
+        bot_token = get_bot_token()
         headers = {"Content-Type": "application/json"}
         if bot_token:
             headers["Authorization"] = f"Bearer {bot_token}"
@@ -447,8 +461,8 @@ async def remove_repo(request: Request):
     except:
         repos = []
     
-    # Remove repo by name
-    repos = [r for r in repos if r.get("name") != repo_name]
+    # Remove repo by name (entries from spec.repos carry it as "folder")
+    repos = [r for r in repos if (r.get("folder") or r.get("name")) != repo_name]
     
     os.environ["REPOS_JSON"] = json.dumps(repos)
     
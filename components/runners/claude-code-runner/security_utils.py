@@ -5,6 +5,7 @@ Provides exception sanitization and timeout wrappers
 to prevent API key leaks and hanging operations.
 """
 
+import os
 import re
 import asyncio
 import logging
@@ -203,3 +204,25 @@ def sanitize_model_name(model: str, max_length: int = 100) -> str | None:
 
     # Return None if empty after sanitization
     return sanitized if sanitized else None
+
+
+def get_bot_token() -> str:
+    """Return the runner's backend token.
+
+    Prefers the file named by BOT_TOKEN_FILE, which the operator projects from
+    the runner token Secret and the kubelet refreshes atomically. Falls back to
+    the BOT_TOKEN env var (fixed at pod start) for older operator versions.
+
+    Returns:
+        The token, or an empty string if none is configured
+    """
+    token_file = os.getenv("BOT_TOKEN_FILE", "").strip()
+    if token_file:
+        try:
+            with open(token_file, "r") as f:
+                token = f.read().strip()
+            if token:
+                return token
+        except OSError as e:
+            logging.debug(f"Could not read BOT_TOKEN_FILE {token_file}: {e}")
+    return os.getenv("BOT_TOKEN", "").strip()
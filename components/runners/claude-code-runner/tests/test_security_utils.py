@@ -4,6 +4,7 @@ import pytest
 import asyncio
 import logging
 from security_utils import (
+    get_bot_token,
     sanitize_exception_message,
     sanitize_model_name,
     with_timeout,
@@ -394,3 +395,30 @@ class TestSanitizeModelName:
         assert sanitize_model_name("../../etc/passwd") == "../../etc/passwd"
         # JavaScript injection
         assert sanitize_model_name("<script>alert('xss')</script>") == "scriptalertxss/script"
+
+
+class TestGetBotToken:
+    """Tests for get_bot_token function."""
+
+    def test_prefers_token_file(self, tmp_path, monkeypatch):
+        """Test that BOT_TOKEN_FILE takes precedence over BOT_TOKEN."""
+        token_file = tmp_path / "k8s-token"
+        token_file.write_text("file-token\n")
+        monkeypatch.setenv("BOT_TOKEN_FILE", str(token_file))
+        monkeypatch.setenv("BOT_TOKEN", "env-token")
+
+        assert get_bot_token() == "file-token"
+
+    def test_falls_back_to_env_when_file_missing(self, tmp_path, monkeypatch):
+        """Test fallback to BOT_TOKEN when the projected file is absent."""
+        monkeypatch.setenv("BOT_TOKEN_FILE", str(tmp_path / "missing"))
+        monkeypatch.setenv("BOT_TOKEN", "env-token")
+
+        assert get_bot_token() == "env-token"
+
+    def test_empty_when_unset(self, monkeypatch):
+        """Test that an empty string is returned when nothing is configured."""
+        monkeypatch.delenv("BOT_TOKEN_FILE", raising=False)
+        monkeypatch.delenv("BOT_TOKEN", raising=False)
+
+        assert get_bot_token() == ""